@@ -76,7 +76,14 @@ type SimulatedBackend struct {
 // and uses a simulated blockchain for testing purposes.
 // A simulated backend always uses chainID 1337.
 func NewSimulatedBackendWithDatabase(database ethdb.Database, alloc core.GenesisAlloc, gasLimit uint64) *SimulatedBackend {
-	genesis := core.Genesis{Config: params.AllEthashProtocolChanges, GasLimit: gasLimit, Alloc: alloc}
+	return NewSimulatedBackendWithConfig(database, params.AllEthashProtocolChanges, alloc, gasLimit)
+}
+
+// NewSimulatedBackendWithConfig creates a new binding backend running the given
+// chain configuration, e.g. one with Zktrie set to simulate the L2 state. The
+// chain must use ethash as its consensus engine is faked.
+func NewSimulatedBackendWithConfig(database ethdb.Database, config *params.ChainConfig, alloc core.GenesisAlloc, gasLimit uint64) *SimulatedBackend {
+	genesis := core.Genesis{Config: config, GasLimit: gasLimit, Alloc: alloc}
 	genesis.MustCommit(database)
 	blockchain, _ := core.NewBlockChain(database, nil, genesis.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
 
@@ -802,6 +809,8 @@ type callMsg struct {
 func (m callMsg) From() common.Address         { return m.CallMsg.From }
 func (m callMsg) Nonce() uint64                { return 0 }
 func (m callMsg) IsFake() bool                 { return true }
+func (m callMsg) IsSystem() bool               { return false }
+func (m callMsg) Payer() common.Address        { return m.CallMsg.From }
 func (m callMsg) To() *common.Address          { return m.CallMsg.To }
 func (m callMsg) GasPrice() *big.Int           { return m.CallMsg.GasPrice }
 func (m callMsg) GasFeeCap() *big.Int          { return m.CallMsg.GasFeeCap }
@@ -868,6 +877,10 @@ func (fb *filterBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEve
 	return fb.bc.SubscribeRemovedLogsEvent(ch)
 }
 
+func (fb *filterBackend) SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription {
+	return fb.bc.SubscribeReorgEvent(ch)
+}
+
 func (fb *filterBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
 	return fb.bc.SubscribeLogsEvent(ch)
 }
@@ -32,6 +32,7 @@ import (
 	"github.com/scroll-tech/go-ethereum/accounts/abi/bind"
 	"github.com/scroll-tech/go-ethereum/common"
 	"github.com/scroll-tech/go-ethereum/core"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
 	"github.com/scroll-tech/go-ethereum/core/types"
 	"github.com/scroll-tech/go-ethereum/crypto"
 	"github.com/scroll-tech/go-ethereum/params"
@@ -140,6 +141,46 @@ func TestNewSimulatedBackend(t *testing.T) {
 	}
 }
 
+func TestSimulatedBackendZktrie(t *testing.T) {
+	testAddr := crypto.PubkeyToAddress(testKey.PublicKey)
+	config := *params.AllEthashProtocolChanges
+	config.Zktrie = true
+	sim := NewSimulatedBackendWithConfig(rawdb.NewMemoryDatabase(), &config, core.GenesisAlloc{
+		testAddr: {Balance: big.NewInt(10000000000000000)},
+	}, 10000000)
+	defer sim.Close()
+	bgCtx := context.Background()
+
+	if !sim.blockchain.StateCache().TrieDB().Zktrie {
+		t.Fatal("expected the state to be stored in the zk trie")
+	}
+	parsed, _ := abi.JSON(strings.NewReader(abiJSON))
+	contractAuth, _ := bind.NewKeyedTransactorWithChainID(testKey, big.NewInt(1337))
+	addr, _, _, err := bind.DeployContract(contractAuth, parsed, common.FromHex(abiBin), sim)
+	if err != nil {
+		t.Fatalf("could not deploy contract: %v", err)
+	}
+	sim.Commit()
+
+	input, _ := parsed.Pack("receive", []byte("X"))
+	res, err := sim.CallContract(bgCtx, ethereum.CallMsg{From: testAddr, To: &addr, Data: input}, nil)
+	if err != nil {
+		t.Fatalf("could not call receive method on contract: %v", err)
+	}
+	if !bytes.Equal(res, expectedReturn) {
+		t.Errorf("response from calling contract was expected to be 'hello world' instead received %v", string(res))
+	}
+	// The head state must be reachable through its zk trie root
+	head := sim.blockchain.CurrentBlock()
+	statedb, err := sim.blockchain.StateAt(head.Root())
+	if err != nil {
+		t.Fatalf("could not open the zk trie state: %v", err)
+	}
+	if code := statedb.GetCode(addr); len(code) == 0 {
+		t.Error("contract code missing from the zk trie state")
+	}
+}
+
 func TestAdjustTime(t *testing.T) {
 	sim := NewSimulatedBackend(
 		core.GenesisAlloc{}, 10000000,
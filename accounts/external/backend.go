@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"math/big"
 	"sync"
+	"time"
 
 	"github.com/scroll-tech/go-ethereum"
 	"github.com/scroll-tech/go-ethereum/accounts"
@@ -57,15 +58,33 @@ func (eb *ExternalBackend) Subscribe(sink chan<- accounts.WalletEvent) event.Sub
 	})
 }
 
+const (
+	// signRetries is the number of times a signing request is retried if the
+	// external signer can't be reached.
+	signRetries = 3
+
+	// signRetryDelay is the delay before the first retry of a signing request,
+	// doubled on every further retry.
+	signRetryDelay = 250 * time.Millisecond
+
+	// statusCacheTime is how long Status reuses the result of a ping, so that
+	// frequent status polls don't hit the external signer.
+	statusCacheTime = 5 * time.Second
+)
+
 // ExternalSigner provides an API to interact with an external signer (clef)
 // It proxies request to the external signer while forwarding relevant
 // request headers
 type ExternalSigner struct {
 	client   *rpc.Client
 	endpoint string
-	status   string
 	cacheMu  sync.RWMutex
 	cache    []accounts.Account
+
+	statusMu      sync.Mutex
+	statusVersion string    // Version reported by the last ping
+	statusErr     error     // Error of the last ping
+	statusTime    time.Time // Time of the last ping
 }
 
 func NewExternalSigner(endpoint string) (*ExternalSigner, error) {
@@ -82,7 +101,7 @@ func NewExternalSigner(endpoint string) (*ExternalSigner, error) {
 	if err != nil {
 		return nil, err
 	}
-	extsigner.status = fmt.Sprintf("ok [version=%v]", version)
+	extsigner.statusVersion, extsigner.statusTime = version, time.Now()
 	return extsigner, nil
 }
 
@@ -93,8 +112,21 @@ func (api *ExternalSigner) URL() accounts.URL {
 	}
 }
 
+// Status pings the external signer, returning an error if it can't be reached.
+// The result of a ping is reused for statusCacheTime.
 func (api *ExternalSigner) Status() (string, error) {
-	return api.status, nil
+	api.statusMu.Lock()
+	if time.Since(api.statusTime) >= statusCacheTime {
+		api.statusVersion, api.statusErr = api.pingVersion()
+		api.statusTime = time.Now()
+	}
+	version, err := api.statusVersion, api.statusErr
+	api.statusMu.Unlock()
+
+	if err != nil {
+		return "unreachable", err
+	}
+	return fmt.Sprintf("ok [version=%v]", version), nil
 }
 
 func (api *ExternalSigner) Open(passphrase string) error {
@@ -160,12 +192,15 @@ func (api *ExternalSigner) signHash(account accounts.Account, hash []byte) ([]by
 func (api *ExternalSigner) SignData(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
 	var res hexutil.Bytes
 	var signAddress = common.NewMixedcaseAddress(account.Address)
-	if err := api.client.Call(&res, "account_signData",
+	if err := api.callSign(&res, "account_signData",
 		mimeType,
 		&signAddress, // Need to use the pointer here, because of how MarshalJSON is defined
 		hexutil.Encode(data)); err != nil {
 		return nil, err
 	}
+	if len(res) != 65 {
+		return nil, fmt.Errorf("invalid signature length %d from external signer", len(res))
+	}
 	// If V is on 27/28-form, convert to 0/1 for Clique
 	if mimeType == accounts.MimetypeClique && (res[64] == 27 || res[64] == 28) {
 		res[64] -= 27 // Transform V from 27/28 to 0/1 for Clique use
@@ -176,12 +211,15 @@ func (api *ExternalSigner) SignData(account accounts.Account, mimeType string, d
 func (api *ExternalSigner) SignText(account accounts.Account, text []byte) ([]byte, error) {
 	var signature hexutil.Bytes
 	var signAddress = common.NewMixedcaseAddress(account.Address)
-	if err := api.client.Call(&signature, "account_signData",
+	if err := api.callSign(&signature, "account_signData",
 		accounts.MimetypeTextPlain,
 		&signAddress, // Need to use the pointer here, because of how MarshalJSON is defined
 		hexutil.Encode(text)); err != nil {
 		return nil, err
 	}
+	if len(signature) != 65 {
+		return nil, fmt.Errorf("invalid signature length %d from external signer", len(signature))
+	}
 	if signature[64] == 27 || signature[64] == 28 {
 		// If clef is used as a backend, it may already have transformed
 		// the signature to ethereum-type signature.
@@ -239,7 +277,7 @@ func (api *ExternalSigner) SignTx(account accounts.Account, tx *types.Transactio
 		args.AccessList = &accessList
 	}
 	var res signTransactionResult
-	if err := api.client.Call(&res, "account_signTransaction", args); err != nil {
+	if err := api.callSign(&res, "account_signTransaction", args); err != nil {
 		return nil, err
 	}
 	return res.Tx, nil
@@ -256,6 +294,25 @@ func (api *ExternalSigner) SignDataWithPassphrase(account accounts.Account, pass
 	return nil, fmt.Errorf("password-operations not supported on external signers")
 }
 
+// callSign issues a signing request to the external signer, retrying it with
+// backoff if the signer can't be reached. Requests the signer answered with an
+// error, e.g. because its rules rejected them, are not retried.
+func (api *ExternalSigner) callSign(result interface{}, method string, args ...interface{}) error {
+	delay := signRetryDelay
+	for attempt := 0; ; attempt++ {
+		err := api.client.Call(result, method, args...)
+		if err == nil {
+			return nil
+		}
+		if _, answered := err.(rpc.Error); answered || attempt == signRetries {
+			return err
+		}
+		log.Warn("External signer unreachable, retrying", "method", method, "attempt", attempt+1, "err", err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
 func (api *ExternalSigner) listAccounts() ([]common.Address, error) {
 	var res []common.Address
 	if err := api.client.Call(&res, "account_list"); err != nil {
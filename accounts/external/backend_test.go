@@ -0,0 +1,162 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package external
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/accounts"
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/go-ethereum/rpc"
+)
+
+// fakeSignerAPI is the account API of a fake external signer.
+type fakeSignerAPI struct {
+	pings int32 // Number of version requests answered
+	signs int32 // Number of signing requests answered
+}
+
+func (api *fakeSignerAPI) Version() string {
+	atomic.AddInt32(&api.pings, 1)
+	return "6.1.0"
+}
+
+func (api *fakeSignerAPI) SignData(mimeType string, addr common.MixedcaseAddress, data hexutil.Bytes) (hexutil.Bytes, error) {
+	atomic.AddInt32(&api.signs, 1)
+	if len(data) == 0 {
+		return nil, errors.New("request denied")
+	}
+	return make(hexutil.Bytes, 65), nil
+}
+
+// fakeSigner is an external signer reachable over HTTP, failing the given
+// number of requests before serving them again.
+type fakeSigner struct {
+	api      *fakeSignerAPI
+	server   *rpc.Server
+	failures int32 // Number of requests left to fail
+	requests int32 // Number of requests received
+}
+
+func (s *fakeSigner) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&s.requests, 1)
+	if atomic.AddInt32(&s.failures, -1) >= 0 {
+		http.Error(w, "signer unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	s.server.ServeHTTP(w, r)
+}
+
+// fail makes the signer fail the given number of requests, resetting the count
+// of requests received.
+func (s *fakeSigner) fail(n int32) {
+	atomic.StoreInt32(&s.failures, n)
+	atomic.StoreInt32(&s.requests, 0)
+}
+
+func newFakeSigner(t *testing.T) (*fakeSigner, *ExternalSigner) {
+	signer := &fakeSigner{api: new(fakeSignerAPI), server: rpc.NewServer()}
+	if err := signer.server.RegisterName("account", signer.api); err != nil {
+		t.Fatalf("failed to register signer API: %v", err)
+	}
+	httpsrv := httptest.NewServer(signer)
+	t.Cleanup(httpsrv.Close)
+
+	extsigner, err := NewExternalSigner(httpsrv.URL)
+	if err != nil {
+		t.Fatalf("failed to connect to signer: %v", err)
+	}
+	return signer, extsigner
+}
+
+// Tests that signing requests are retried while the external signer can't be
+// reached, and go through again once it recovers.
+func TestExternalSignerRetry(t *testing.T) {
+	signer, extsigner := newFakeSigner(t)
+	account := accounts.Account{Address: common.Address{0x01}}
+
+	// A signer recovering within the retries signs the request
+	signer.fail(signRetries)
+	if _, err := extsigner.SignText(account, []byte("hello")); err != nil {
+		t.Fatalf("failed to sign once the signer recovered: %v", err)
+	}
+	if have := atomic.LoadInt32(&signer.requests); have != signRetries+1 {
+		t.Errorf("request count mismatch: have %d, want %d", have, signRetries+1)
+	}
+	// A signer down for longer fails the request, but serves the next one
+	signer.fail(signRetries + 1)
+	if _, err := extsigner.SignText(account, []byte("hello")); err == nil {
+		t.Fatal("signed with an unreachable signer")
+	}
+	if _, err := extsigner.SignText(account, []byte("hello")); err != nil {
+		t.Fatalf("failed to sign after reconnecting: %v", err)
+	}
+	if have := atomic.LoadInt32(&signer.api.signs); have != 2 {
+		t.Errorf("signature count mismatch: have %d, want %d", have, 2)
+	}
+	// Requests answered with an error are not retried
+	signer.fail(0)
+	if _, err := extsigner.SignText(account, nil); err == nil {
+		t.Fatal("denied request succeeded")
+	}
+	if have := atomic.LoadInt32(&signer.requests); have != 1 {
+		t.Errorf("denied request retried: have %d requests, want %d", have, 1)
+	}
+}
+
+// Tests that the status of the external signer is cached instead of pinging
+// it on every call, and follows it going down and recovering.
+func TestExternalSignerStatus(t *testing.T) {
+	signer, extsigner := newFakeSigner(t)
+
+	// The ping done while connecting is reused
+	for i := 0; i < 3; i++ {
+		if _, err := extsigner.Status(); err != nil {
+			t.Fatalf("status %d: signer unreachable: %v", i, err)
+		}
+	}
+	if have := atomic.LoadInt32(&signer.api.pings); have != 1 {
+		t.Errorf("ping count mismatch: have %d, want %d", have, 1)
+	}
+	// A signer going down is only noticed once the cached result expires
+	signer.fail(1 << 30)
+	if _, err := extsigner.Status(); err != nil {
+		t.Errorf("cached status not reused: %v", err)
+	}
+	extsigner.statusTime = time.Now().Add(-statusCacheTime)
+	if status, err := extsigner.Status(); err == nil {
+		t.Errorf("unreachable signer reported as %q", status)
+	}
+	// The failed ping is cached too, until the signer is pinged again
+	signer.fail(0)
+	if _, err := extsigner.Status(); err == nil {
+		t.Error("cached failure not reused")
+	}
+	extsigner.statusTime = time.Now().Add(-statusCacheTime)
+	if _, err := extsigner.Status(); err != nil {
+		t.Errorf("recovered signer unreachable: %v", err)
+	}
+	if have := atomic.LoadInt32(&signer.requests); have != 1 {
+		t.Errorf("request count mismatch: have %d, want %d", have, 1)
+	}
+}
@@ -43,6 +43,15 @@ import (
 )
 
 var (
+	analyticsFormatFlag = cli.StringFlag{
+		Name:  "format",
+		Usage: "Format of the exported tables (csv or parquet)",
+		Value: "csv",
+	}
+	bisectBadFlag = cli.Uint64Flag{
+		Name:  "bad",
+		Usage: "Number of the block suspected to have a diverging state root",
+	}
 	initCommand = cli.Command{
 		Action:    utils.MigrateFlags(initGenesis),
 		Name:      "init",
@@ -103,6 +112,7 @@ The dumpgenesis command dumps the genesis block configuration in JSON format to
 			utils.MetricsInfluxDBBucketFlag,
 			utils.MetricsInfluxDBOrganizationFlag,
 			utils.TxLookupLimitFlag,
+			utils.ImportRootCheckpointFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
@@ -110,7 +120,11 @@ The import command imports blocks from an RLP-encoded form. The form can be one
 with several RLP-encoded blocks, or several files can be used.
 
 If only one file is used, import error will result in failure. If several files are used,
-processing will proceed even if an individual RLP-file import failure occurs.`,
+processing will proceed even if an individual RLP-file import failure occurs.
+
+With --import.rootcheckpoint, the state root is only computed and verified every N
+blocks, keeping the intermediate state in memory. On a mismatch, the error reports
+the range of blocks the faulty one is in.`,
 	}
 	exportCommand = cli.Command{
 		Action:    utils.MigrateFlags(exportChain),
@@ -129,6 +143,49 @@ Optional second and third arguments control the first and
 last block to write. In this mode, the file will be appended
 if already existing. If the file ends with .gz, the output will
 be gzipped.`,
+	}
+	exportAnalyticsCommand = cli.Command{
+		Action:    utils.MigrateFlags(exportAnalytics),
+		Name:      "export-analytics",
+		Usage:     "Export chain data into CSV or Parquet tables for analytics",
+		ArgsUsage: "<directory> <blockNumFirst> <blockNumLast>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.CacheFlag,
+			utils.SyncModeFlag,
+			analyticsFormatFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The export-analytics command exports the canonical blocks of the given range,
+along with their transactions, receipts and logs, into the blocks, transactions,
+receipts and logs tables of the given directory. Existing tables are overwritten.
+
+The tables have a stable schema, new columns are only ever appended. Quantities
+which may exceed 64 bits are exported as decimal strings, hashes, addresses and
+binary data as lower case 0x prefixed hex strings, absent values (e.g. the
+recipient of a contract creation) as empty strings.`,
+	}
+	bisectRootCommand = cli.Command{
+		Action: utils.MigrateFlags(bisectRoot),
+		Name:   "bisect-root",
+		Usage:  "Replay blocks to find the first one diverging from its state root",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.CacheFlag,
+			utils.SyncModeFlag,
+			bisectBadFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The bisect-root command replays the blocks from the nearest one below the --bad
+block with its state available, comparing the state root computed for every
+block with its header. Blocks rejected by the node as bad are replayed if they
+are not canonical. The replay is done in memory, the database is not modified.
+
+At the first mismatch, the replayed state is diffed against the expected one if
+it's available, or against the parent state otherwise. The result is written to
+stdout as JSON, listing the differing accounts and storage slots.`,
 	}
 	importPreimagesCommand = cli.Command{
 		Action:    utils.MigrateFlags(importPreimages),
@@ -350,6 +407,62 @@ func exportChain(ctx *cli.Context) error {
 	return nil
 }
 
+// exportAnalytics exports a range of blocks into analytics tables.
+func exportAnalytics(ctx *cli.Context) error {
+	if len(ctx.Args()) < 3 {
+		utils.Fatalf("This command requires three arguments.")
+	}
+	format := ctx.String(analyticsFormatFlag.Name)
+	if format != "csv" && format != "parquet" {
+		utils.Fatalf("Invalid --%s %q, must be csv or parquet", analyticsFormatFlag.Name, format)
+	}
+	first, ferr := strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+	last, lerr := strconv.ParseUint(ctx.Args().Get(2), 10, 64)
+	if ferr != nil || lerr != nil {
+		utils.Fatalf("Export error in parsing parameters: block number not an integer\n")
+	}
+	if first > last {
+		utils.Fatalf("Export error: first block %d larger than last block %d\n", first, last)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, _ := utils.MakeChain(ctx, stack)
+	if head := chain.CurrentFastBlock(); last > head.NumberU64() {
+		utils.Fatalf("Export error: block number %d larger than head block %d\n", last, head.NumberU64())
+	}
+	start := time.Now()
+	if err := utils.ExportAnalytics(chain, ctx.Args().First(), format, first, last); err != nil {
+		utils.Fatalf("Export error: %v\n", err)
+	}
+	fmt.Printf("Export done in %v\n", time.Since(start))
+	return nil
+}
+
+// bisectRoot replays blocks up to a suspected bad one and dumps the state diff of
+// the first block diverging from its state root.
+func bisectRoot(ctx *cli.Context) error {
+	if !ctx.IsSet(bisectBadFlag.Name) {
+		utils.Fatalf("The --%s flag is required.", bisectBadFlag.Name)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, _ := utils.MakeChain(ctx, stack)
+	defer chain.Stop()
+
+	result, err := chain.BisectRoot(ctx.Uint64(bisectBadFlag.Name))
+	if err != nil {
+		utils.Fatalf("Bisect error: %v", err)
+	}
+	out, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
 // importPreimages imports preimage data from the specified file.
 func importPreimages(ctx *cli.Context) error {
 	if len(ctx.Args()) < 1 {
@@ -0,0 +1,209 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/scroll-tech/go-ethereum/cmd/utils"
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/params"
+)
+
+var (
+	checkpointCommand = cli.Command{
+		Name:      "checkpoint",
+		Usage:     "Export and import signed header checkpoints",
+		ArgsUsage: "",
+		Category:  "BLOCKCHAIN COMMANDS",
+		Description: `
+Signed header checkpoints pin blocks of the canonical chain. A node enforces the
+imported checkpoints during header verification, replacing the --whitelist flag.`,
+		Subcommands: []cli.Command{
+			checkpointExportCmd,
+			checkpointImportCmd,
+		},
+	}
+	checkpointExportCmd = cli.Command{
+		Action:    utils.MigrateFlags(exportCheckpoints),
+		Name:      "export",
+		Usage:     "Export a signed bundle of header checkpoints",
+		ArgsUsage: "<file> <block numbers (optional)>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.SyncModeFlag,
+			utils.MainnetFlag,
+			utils.RopstenFlag,
+			utils.SepoliaFlag,
+			utils.RinkebyFlag,
+			utils.GoerliFlag,
+			utils.CheckpointKeyFlag,
+		},
+		Description: `
+The export command signs checkpoints of the given canonical blocks (default =
+head block) with the key in --checkpoint.key and writes them to the file.`,
+	}
+	checkpointImportCmd = cli.Command{
+		Action:    utils.MigrateFlags(importCheckpoints),
+		Name:      "import",
+		Usage:     "Import a signed bundle of header checkpoints",
+		ArgsUsage: "<file>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.SyncModeFlag,
+			utils.MainnetFlag,
+			utils.RopstenFlag,
+			utils.SepoliaFlag,
+			utils.RinkebyFlag,
+			utils.GoerliFlag,
+			utils.CheckpointSignerFlag,
+		},
+		Description: `
+The import command verifies the checkpoint bundle against the checkpoint signer
+(--checkpoint.signer, default = network checkpoint signer) and stores it in the
+database, replacing any previously imported bundle.`,
+	}
+)
+
+func exportCheckpoints(ctx *cli.Context) error {
+	if ctx.NArg() < 1 {
+		return fmt.Errorf("missing file argument: %v", ctx.Command.ArgsUsage)
+	}
+	if !ctx.IsSet(utils.CheckpointKeyFlag.Name) {
+		return errors.New("missing checkpoint signing key, use --checkpoint.key")
+	}
+	key, err := crypto.LoadECDSA(ctx.String(utils.CheckpointKeyFlag.Name))
+	if err != nil {
+		return fmt.Errorf("failed to load checkpoint key: %v", err)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, true)
+	defer db.Close()
+
+	genesis := rawdb.ReadCanonicalHash(db, 0)
+	if genesis == (common.Hash{}) {
+		return errors.New("no genesis block")
+	}
+	var numbers []uint64
+	if ctx.NArg() > 1 {
+		for _, arg := range ctx.Args()[1:] {
+			number, err := strconv.ParseUint(arg, 0, 64)
+			if err != nil {
+				return fmt.Errorf("invalid block number %s: %v", arg, err)
+			}
+			numbers = append(numbers, number)
+		}
+	} else {
+		head := rawdb.ReadHeadHeader(db)
+		if head == nil {
+			return errors.New("no head header")
+		}
+		numbers = append(numbers, head.Number.Uint64())
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+
+	bundle := &types.CheckpointBundle{Genesis: genesis}
+	for _, number := range numbers {
+		hash := rawdb.ReadCanonicalHash(db, number)
+		header := rawdb.ReadHeader(db, hash, number)
+		if header == nil {
+			return fmt.Errorf("no canonical header #%d", number)
+		}
+		bundle.Checkpoints = append(bundle.Checkpoints, &types.HeaderCheckpoint{
+			Number: number,
+			Hash:   hash,
+			Root:   header.Root,
+		})
+	}
+	if err := bundle.Sign(key); err != nil {
+		return err
+	}
+	// Reject duplicate block numbers the same way importers would
+	if err := bundle.Verify(genesis, crypto.PubkeyToAddress(key.PublicKey)); err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(ctx.Args().First(), out, 0644); err != nil {
+		return err
+	}
+	log.Info("Exported header checkpoints", "count", len(bundle.Checkpoints), "file", ctx.Args().First())
+	return nil
+}
+
+func importCheckpoints(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("required arguments: %v", ctx.Command.ArgsUsage)
+	}
+	blob, err := ioutil.ReadFile(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	bundle := new(types.CheckpointBundle)
+	if err := json.Unmarshal(blob, bundle); err != nil {
+		return fmt.Errorf("invalid checkpoint bundle: %v", err)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	genesis := rawdb.ReadCanonicalHash(db, 0)
+	if genesis == (common.Hash{}) {
+		return errors.New("no genesis block, run 'geth init' first")
+	}
+	var signer common.Address
+	if ctx.IsSet(utils.CheckpointSignerFlag.Name) {
+		addr := ctx.String(utils.CheckpointSignerFlag.Name)
+		if !common.IsHexAddress(addr) {
+			return fmt.Errorf("invalid checkpoint signer address %s", addr)
+		}
+		signer = common.HexToAddress(addr)
+	} else if known, ok := params.CheckpointSigners[genesis]; ok {
+		signer = known
+	} else {
+		return errors.New("no checkpoint signer for this network, use --checkpoint.signer")
+	}
+	if err := bundle.Verify(genesis, signer); err != nil {
+		return err
+	}
+	// Refuse checkpoints conflicting with the local canonical chain
+	for _, cp := range bundle.Checkpoints {
+		if hash := rawdb.ReadCanonicalHash(db, cp.Number); hash != (common.Hash{}) && hash != cp.Hash {
+			return fmt.Errorf("checkpoint #%d conflicts with local chain: have %x, want %x", cp.Number, hash, cp.Hash)
+		}
+	}
+	rawdb.WriteCheckpointBundle(db, bundle)
+	log.Info("Imported header checkpoints", "count", len(bundle.Checkpoints), "signer", signer)
+	return nil
+}
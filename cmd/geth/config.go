@@ -33,14 +33,17 @@ import (
 	"github.com/scroll-tech/go-ethereum/accounts/scwallet"
 	"github.com/scroll-tech/go-ethereum/accounts/usbwallet"
 	"github.com/scroll-tech/go-ethereum/cmd/utils"
+	"github.com/scroll-tech/go-ethereum/eth"
 	"github.com/scroll-tech/go-ethereum/eth/catalyst"
 	"github.com/scroll-tech/go-ethereum/eth/ethconfig"
+	"github.com/scroll-tech/go-ethereum/firehose"
 	"github.com/scroll-tech/go-ethereum/internal/debug"
 	"github.com/scroll-tech/go-ethereum/internal/ethapi"
 	"github.com/scroll-tech/go-ethereum/log"
 	"github.com/scroll-tech/go-ethereum/metrics"
 	"github.com/scroll-tech/go-ethereum/node"
 	"github.com/scroll-tech/go-ethereum/params"
+	"github.com/scroll-tech/go-ethereum/watchdog"
 )
 
 var (
@@ -86,11 +89,26 @@ type ethstatsConfig struct {
 	URL string `toml:",omitempty"`
 }
 
+type rootcheckConfig struct {
+	URLs []string `toml:",omitempty"`
+}
+
+// logConfig holds the logging settings of the config file, the zero values keep
+// the settings of the command line flags.
+type logConfig struct {
+	Verbosity int    `toml:",omitempty"` // Log verbosity (1=error, 2=warn, 3=info, 4=debug, 5=detail)
+	Vmodule   string `toml:",omitempty"` // Per-module verbosity pattern
+}
+
 type gethConfig struct {
-	Eth      ethconfig.Config
-	Node     node.Config
-	Ethstats ethstatsConfig
-	Metrics  metrics.Config
+	Log       logConfig
+	Eth       ethconfig.Config
+	Node      node.Config
+	Ethstats  ethstatsConfig
+	RootCheck rootcheckConfig
+	Firehose  firehose.Config
+	Watchdog  watchdog.Config
+	Metrics   metrics.Config
 }
 
 func loadConfig(file string, cfg *gethConfig) error {
@@ -122,9 +140,10 @@ func defaultNodeConfig() node.Config {
 func makeConfigNode(ctx *cli.Context) (*node.Node, gethConfig) {
 	// Load defaults.
 	cfg := gethConfig{
-		Eth:     ethconfig.Defaults,
-		Node:    defaultNodeConfig(),
-		Metrics: metrics.DefaultConfig,
+		Eth:      ethconfig.Defaults,
+		Node:     defaultNodeConfig(),
+		Firehose: firehose.DefaultConfig,
+		Metrics:  metrics.DefaultConfig,
 	}
 
 	// Load config file.
@@ -135,6 +154,9 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, gethConfig) {
 	}
 
 	// Apply flags.
+	if err := applyLogConfig(ctx, &cfg.Log); err != nil {
+		utils.Fatalf("Invalid log config: %v", err)
+	}
 	utils.SetNodeConfig(ctx, &cfg.Node)
 	stack, err := node.New(&cfg.Node)
 	if err != nil {
@@ -149,6 +171,11 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, gethConfig) {
 	if ctx.GlobalIsSet(utils.EthStatsURLFlag.Name) {
 		cfg.Ethstats.URL = ctx.GlobalString(utils.EthStatsURLFlag.Name)
 	}
+	if ctx.GlobalIsSet(utils.RootCheckURLsFlag.Name) {
+		cfg.RootCheck.URLs = utils.SplitAndTrim(ctx.GlobalString(utils.RootCheckURLsFlag.Name))
+	}
+	utils.SetFirehoseConfig(ctx, &cfg.Firehose)
+	utils.SetWatchdogConfig(ctx, &cfg.Watchdog)
 	applyTraceConfig(ctx, &cfg.Eth)
 	applyMetricConfig(ctx, &cfg)
 
@@ -161,7 +188,13 @@ func makeFullNode(ctx *cli.Context) (*node.Node, ethapi.Backend) {
 	if ctx.GlobalIsSet(utils.OverrideArrowGlacierFlag.Name) {
 		cfg.Eth.OverrideArrowGlacier = new(big.Int).SetUint64(ctx.GlobalUint64(utils.OverrideArrowGlacierFlag.Name))
 	}
+	if ctx.GlobalIsSet(utils.OverrideStateSchemeFlag.Name) {
+		cfg.Eth.OverrideStateScheme = ctx.GlobalBool(utils.OverrideStateSchemeFlag.Name)
+	}
 	backend, eth := utils.RegisterEthService(stack, &cfg.Eth)
+	if eth != nil {
+		applyTxPoolPolicyFlags(ctx, eth)
+	}
 
 	// Configure catalyst.
 	if ctx.GlobalBool(utils.CatalystFlag.Name) {
@@ -177,13 +210,62 @@ func makeFullNode(ctx *cli.Context) (*node.Node, ethapi.Backend) {
 	if ctx.GlobalIsSet(utils.GraphQLEnabledFlag.Name) {
 		utils.RegisterGraphQLService(stack, backend, cfg.Node)
 	}
+	// Configure the gRPC gateway if requested
+	if ctx.GlobalIsSet(utils.GRPCEnabledFlag.Name) {
+		utils.RegisterGRPCService(stack, backend, utils.GRPCEndpoint(ctx))
+	}
 	// Add the Ethereum Stats daemon if requested.
 	if cfg.Ethstats.URL != "" {
 		utils.RegisterEthStatsService(stack, backend, cfg.Ethstats.URL)
 	}
+	// Add the state root checker if reference nodes were configured.
+	if len(cfg.RootCheck.URLs) > 0 {
+		utils.RegisterRootCheckService(stack, backend, cfg.RootCheck.URLs)
+	}
+	// Add the event firehose if a broker was configured.
+	if cfg.Firehose.URL != "" {
+		utils.RegisterFirehoseService(stack, backend, &cfg.Firehose)
+	}
+	// Allow reloading the runtime adjustable settings of the config file.
+	if file := ctx.GlobalString(configFileFlag.Name); file != "" {
+		registerConfigReloadAPI(stack, backend, eth, file)
+	}
+	// Add the chain head watchdog if a reorg depth was configured.
+	if cfg.Watchdog.Depth > 0 {
+		if eth == nil {
+			utils.Fatalf("The chain head watchdog does not work in light client mode.")
+		}
+		utils.RegisterWatchdogService(stack, eth, &cfg.Watchdog)
+	}
 	return stack, backend
 }
 
+// applyTxPoolPolicyFlags reapplies the pool policy flags given explicitly on the
+// command line, overriding any policy persisted at runtime via txpool_setPolicy.
+func applyTxPoolPolicyFlags(ctx *cli.Context, backend *eth.Ethereum) {
+	var (
+		policy   = backend.TxPool().Policy()
+		override = policy
+	)
+	if ctx.GlobalIsSet(utils.TxPoolPriceLimitFlag.Name) {
+		override.PriceLimit = ctx.GlobalUint64(utils.TxPoolPriceLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(utils.TxPoolPriceBumpFlag.Name) {
+		override.PriceBump = ctx.GlobalUint64(utils.TxPoolPriceBumpFlag.Name)
+	}
+	if ctx.GlobalIsSet(utils.TxPoolReplaceIntervalFlag.Name) {
+		override.ReplaceInterval = ctx.GlobalDuration(utils.TxPoolReplaceIntervalFlag.Name)
+	}
+	if override == policy {
+		return
+	}
+	log.Info("Command line flags override persisted txpool policy",
+		"pricelimit", override.PriceLimit, "pricebump", override.PriceBump, "replaceinterval", override.ReplaceInterval)
+	if err := backend.TxPool().SetPolicy(override); err != nil {
+		utils.Fatalf("Failed to apply txpool policy flags: %v", err)
+	}
+}
+
 // dumpConfig is the dumpconfig command.
 func dumpConfig(ctx *cli.Context) error {
 	_, cfg := makeConfigNode(ctx)
@@ -217,6 +299,9 @@ func applyTraceConfig(ctx *cli.Context, cfg *ethconfig.Config) {
 	subCfg := debug.ConfigTrace(ctx)
 	cfg.TraceCacheLimit = subCfg.TraceCacheLimit
 	cfg.MPTWitness = subCfg.MPTWitness
+	if ctx.GlobalIsSet(debug.CallTraceIndexFlag.Name) {
+		cfg.CallTraceIndex = subCfg.CallTraceIndex
+	}
 }
 
 func applyMetricConfig(ctx *cli.Context, cfg *gethConfig) {
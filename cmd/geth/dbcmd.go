@@ -18,6 +18,7 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -29,19 +30,29 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/olekukonko/tablewriter"
 	"gopkg.in/urfave/cli.v1"
 
 	"github.com/scroll-tech/go-ethereum/cmd/utils"
 	"github.com/scroll-tech/go-ethereum/common"
 	"github.com/scroll-tech/go-ethereum/common/hexutil"
 	"github.com/scroll-tech/go-ethereum/console/prompt"
+	"github.com/scroll-tech/go-ethereum/core"
 	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/core/state"
+	"github.com/scroll-tech/go-ethereum/core/state/pruner"
 	"github.com/scroll-tech/go-ethereum/ethdb"
 	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/params"
 	"github.com/scroll-tech/go-ethereum/trie"
 )
 
 var (
+	verifyStateIntervalFlag = cli.Uint64Flag{
+		Name:  "state.interval",
+		Usage: "Number of blocks between state spot checks (0 = disabled)",
+		Value: 1000,
+	}
 	removedbCommand = cli.Command{
 		Action:    utils.MigrateFlags(removeDB),
 		Name:      "removedb",
@@ -70,6 +81,12 @@ Remove blockchain and state databases`,
 			dbDumpFreezerIndex,
 			dbImportCmd,
 			dbExportCmd,
+			dbImportAncientCmd,
+			dbExportAncientCmd,
+			dbAccountStatsCmd,
+			dbExpiryStatsCmd,
+			dbPruneZktrieCmd,
+			dbVerifyChainCmd,
 		},
 	}
 	dbInspectCmd = cli.Command{
@@ -188,6 +205,99 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 		},
 		Description: "This command looks up the specified database key from the database.",
 	}
+	dbAccountStatsCmd = cli.Command{
+		Action:    utils.MigrateFlags(dbAccountStats),
+		Name:      "stats-accounts",
+		Usage:     "Show the storage usage of the accounts in a state",
+		ArgsUsage: "<hex-encoded state root (optional)> <int top contracts (optional)>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.SyncModeFlag,
+			utils.MainnetFlag,
+			utils.RopstenFlag,
+			utils.SepoliaFlag,
+			utils.RinkebyFlag,
+			utils.GoerliFlag,
+		},
+		Description: `This command walks the state trie with the given root (default = head
+block state) and all its storage tries, reporting the total number of accounts,
+storage slots and contract code sizes, the leaf depth distribution of the tries,
+and the contracts with the most storage slots and the deepest storage tries.`,
+	}
+	dbExpiryStatsCmd = cli.Command{
+		Action:    utils.MigrateFlags(dbExpiryStats),
+		Name:      "stats-expiry",
+		Usage:     "Show the active and resurrectable size of a zktrie state",
+		ArgsUsage: "<hex-encoded state root (optional)> <int inactive epochs (optional)>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.SyncModeFlag,
+			utils.MainnetFlag,
+			utils.RopstenFlag,
+			utils.SepoliaFlag,
+			utils.RinkebyFlag,
+			utils.GoerliFlag,
+		},
+		Description: `This command walks the zktrie state with the given root (default = head
+block state) and all its storage tries, splitting the leaves by the state epoch
+of their last access by a canonical block. Leaves accessed within the given number of epochs (default
+= 2) before the epoch of the head block are active, older ones would have to be
+resurrected with a witness under state expiry. Leaves untouched since epoch
+tagging was introduced are reported as untagged.`,
+	}
+	dbPruneZktrieCmd = cli.Command{
+		Action:    utils.MigrateFlags(dbPruneZktrie),
+		Name:      "prune-zktrie",
+		Usage:     "Prune the zktrie nodes not belonging to the recent states",
+		ArgsUsage: "<int blocks (optional)>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.SyncModeFlag,
+			utils.MainnetFlag,
+			utils.RopstenFlag,
+			utils.SepoliaFlag,
+			utils.RinkebyFlag,
+			utils.GoerliFlag,
+			utils.CacheTrieJournalFlag,
+			utils.BloomFilterSizeFlag,
+		},
+		Description: `This command walks the zktrie states of the given number of most recent
+blocks (default = 128) and the genesis, marking all their account and storage
+trie nodes as live, then deletes every other trie node from the database. The
+states of blocks which were never persisted are skipped.
+
+The live nodes are kept in a bloom filter written to the data directory before
+anything is deleted, and the sweep progress is recorded in the database. If the
+pruning is interrupted, running the command again resumes it.
+
+WARNING: The trie clean cache is deleted after the marking. If you specify
+another directory for it via "--cache.trie.journal" during the use of Geth,
+please also specify it here.`,
+	}
+	dbVerifyChainCmd = cli.Command{
+		Action:    utils.MigrateFlags(dbVerifyChain),
+		Name:      "verify-chain",
+		Usage:     "Verify the integrity of the chain data of a range of blocks",
+		ArgsUsage: "<int first block (optional)> <int last block (optional)>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.SyncModeFlag,
+			utils.MainnetFlag,
+			utils.RopstenFlag,
+			utils.SepoliaFlag,
+			utils.RinkebyFlag,
+			utils.GoerliFlag,
+			verifyStateIntervalFlag,
+		},
+		Description: `This command verifies the canonical blocks of the given range (default =
+genesis to head block): the links of the headers, the transaction and uncle
+roots of the bodies, the receipt roots and blooms, and the transaction lookup
+entries of the indexed blocks. The state of every --state.interval-th block is
+spot checked, if it's persisted.
+
+The report is written to stdout as JSON, listing all issues found. The command
+fails if there are any, so it can be used to validate backups.`,
+	}
 	dbDumpFreezerIndex = cli.Command{
 		Action:    utils.MigrateFlags(freezerInspect),
 		Name:      "freezer-index",
@@ -234,6 +344,37 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 		},
 		Description: "Exports the specified chain data to an RLP encoded stream, optionally gzip-compressed.",
 	}
+	dbImportAncientCmd = cli.Command{
+		Action:    utils.MigrateFlags(importAncientData),
+		Name:      "import-ancient",
+		Usage:     "Appends an exported range of ancient chain data to the freezer.",
+		ArgsUsage: "<dumpfile>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.SyncModeFlag,
+			utils.MainnetFlag,
+			utils.RopstenFlag,
+			utils.RinkebyFlag,
+			utils.GoerliFlag,
+		},
+		Description: "The import-ancient command appends the blocks of a dump created by export-ancient to the freezer. The dump must start right after the last block already frozen.",
+	}
+	dbExportAncientCmd = cli.Command{
+		Action:    utils.MigrateFlags(exportAncientData),
+		Name:      "export-ancient",
+		Usage:     "Exports a range of ancient chain data into an RLP dump. If the <dumpfile> has .gz suffix, gzip compression will be used.",
+		ArgsUsage: "<start (int)> <end (int)> <dumpfile>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.SyncModeFlag,
+			utils.MainnetFlag,
+			utils.RopstenFlag,
+			utils.RinkebyFlag,
+			utils.GoerliFlag,
+		},
+		Description: `Exports the items of all freezer tables for the blocks in [start, end) to an
+RLP encoded stream, allowing incremental backups of the ancient store.`,
+	}
 )
 
 func removeDB(ctx *cli.Context) error {
@@ -508,6 +649,149 @@ func dbDumpTrie(ctx *cli.Context) error {
 	return it.Err
 }
 
+func dbAccountStats(ctx *cli.Context) error {
+	if ctx.NArg() > 2 {
+		return fmt.Errorf("max 2 arguments: %v", ctx.Command.ArgsUsage)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, true)
+	defer db.Close()
+
+	var (
+		root common.Hash
+		top  = 20
+		err  error
+	)
+	if ctx.NArg() >= 1 {
+		if root, err = parseRoot(ctx.Args().Get(0)); err != nil {
+			return fmt.Errorf("failed to resolve state root: %v", err)
+		}
+	} else {
+		head := rawdb.ReadHeadBlock(db)
+		if head == nil {
+			return errors.New("no head block")
+		}
+		root = head.Root()
+	}
+	if ctx.NArg() >= 2 {
+		if top, err = strconv.Atoi(ctx.Args().Get(1)); err != nil {
+			return fmt.Errorf("failed to parse top contract count: %v", err)
+		}
+	}
+	scheme := rawdb.ReadStateScheme(db)
+	if scheme == "" {
+		scheme = rawdb.DetectStateScheme(db, root)
+	}
+	log.Info("Collecting state statistics", "root", root, "scheme", scheme)
+
+	start := time.Now()
+	stats, err := state.CollectStateStats(state.NewDatabaseWithConfig(db, &trie.Config{Zktrie: scheme == rawdb.StateSchemeZktrie}), root, top)
+	if err != nil {
+		return err
+	}
+	log.Info("Collected state statistics", "elapsed", common.PrettyDuration(time.Since(start)))
+
+	fmt.Printf("Accounts:   %d\n", stats.Accounts)
+	fmt.Printf("Contracts:  %d\n", stats.Contracts)
+	fmt.Printf("Slots:      %d\n", stats.Slots)
+	fmt.Printf("Codes:      %d (%v)\n", stats.Codes, common.StorageSize(stats.CodeSize))
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Depth", "Account leaves", "Storage leaves"})
+	for depth := 0; depth < len(stats.AccountDepths) || depth < len(stats.StorageDepths); depth++ {
+		var accounts, slots uint64
+		if depth < len(stats.AccountDepths) {
+			accounts = stats.AccountDepths[depth]
+		}
+		if depth < len(stats.StorageDepths) {
+			slots = stats.StorageDepths[depth]
+		}
+		table.Append([]string{strconv.Itoa(depth), strconv.FormatUint(accounts, 10), strconv.FormatUint(slots, 10)})
+	}
+	table.Render()
+
+	fmt.Println("Contracts with the most storage slots:")
+	renderContractStats(stats.TopSlots)
+	fmt.Println("Contracts with the deepest storage tries:")
+	renderContractStats(stats.TopDepth)
+	return nil
+}
+
+func dbExpiryStats(ctx *cli.Context) error {
+	if ctx.NArg() > 2 {
+		return fmt.Errorf("max 2 arguments: %v", ctx.Command.ArgsUsage)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, true)
+	defer db.Close()
+
+	head := rawdb.ReadHeadBlock(db)
+	if head == nil {
+		return errors.New("no head block")
+	}
+	var (
+		root     = head.Root()
+		inactive = uint64(2)
+		err      error
+	)
+	if ctx.NArg() >= 1 {
+		if root, err = parseRoot(ctx.Args().Get(0)); err != nil {
+			return fmt.Errorf("failed to resolve state root: %v", err)
+		}
+	}
+	if ctx.NArg() >= 2 {
+		if inactive, err = strconv.ParseUint(ctx.Args().Get(1), 10, 64); err != nil {
+			return fmt.Errorf("failed to parse inactive epochs: %v", err)
+		}
+	}
+	epoch := head.NumberU64() / params.StateEpochLength
+	log.Info("Collecting state expiry statistics", "root", root, "epoch", epoch, "inactive", inactive)
+
+	start := time.Now()
+	stats, err := state.CollectExpiryStats(state.NewDatabaseWithConfig(db, &trie.Config{Zktrie: true}), root, epoch, inactive)
+	if err != nil {
+		return err
+	}
+	log.Info("Collected state expiry statistics", "elapsed", common.PrettyDuration(time.Since(start)))
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Leaves", "Active", "Resurrectable", "Untagged"})
+	for _, row := range []struct {
+		name                            string
+		active, resurrectable, untagged state.LeafStats
+	}{
+		{"Accounts", stats.ActiveAccounts, stats.ResurrectableAccounts, stats.UntaggedAccounts},
+		{"Storage slots", stats.ActiveSlots, stats.ResurrectableSlots, stats.UntaggedSlots},
+	} {
+		table.Append([]string{row.name, renderLeafStats(row.active), renderLeafStats(row.resurrectable), renderLeafStats(row.untagged)})
+	}
+	table.Render()
+	return nil
+}
+
+// renderLeafStats formats the number and size of a class of trie leaves.
+func renderLeafStats(s state.LeafStats) string {
+	return fmt.Sprintf("%d (%v)", s.Leaves, s.Size)
+}
+
+// renderContractStats prints the storage usage of the given contracts as a table.
+func renderContractStats(contracts []*state.ContractStats) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Contract", "Slots", "Max depth", "Code size"})
+	for _, c := range contracts {
+		id := c.Hash.Hex()
+		if c.Address != nil {
+			id = c.Address.Hex()
+		}
+		table.Append([]string{id, strconv.FormatUint(c.Slots, 10), strconv.Itoa(c.MaxDepth), common.StorageSize(c.CodeSize).String()})
+	}
+	table.Render()
+}
+
 func freezerInspect(ctx *cli.Context) error {
 	var (
 		start, end    int64
@@ -653,6 +937,74 @@ var chainExporters = map[string]func(db ethdb.Database) utils.ChainDataIterator{
 	},
 }
 
+func exportAncientData(ctx *cli.Context) error {
+	if ctx.NArg() < 3 {
+		return fmt.Errorf("required arguments: %v", ctx.Command.ArgsUsage)
+	}
+	start, err := strconv.ParseUint(ctx.Args().Get(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid start block: %v", err)
+	}
+	end, err := strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid end block: %v", err)
+	}
+	var (
+		stack, _  = makeConfigNode(ctx)
+		interrupt = make(chan os.Signal, 1)
+		stop      = make(chan struct{})
+	)
+	defer stack.Close()
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	defer close(interrupt)
+	go func() {
+		if _, ok := <-interrupt; ok {
+			log.Info("Interrupted during ancient export, stopping at next batch")
+		}
+		close(stop)
+	}()
+	db := utils.MakeChainDatabase(ctx, stack, true)
+	frozen, err := db.Ancients()
+	if err != nil {
+		return err
+	}
+	if end > frozen {
+		return fmt.Errorf("end block %d beyond the ancient store (%d blocks frozen)", end, frozen)
+	}
+	if start >= end {
+		return fmt.Errorf("empty range [%d, %d)", start, end)
+	}
+	iter := utils.NewAncientIterator(db, start, end)
+	if err := utils.ExportChaindata(ctx.Args().Get(2), utils.AncientExportKind, iter, stop); err != nil {
+		return err
+	}
+	return iter.Err()
+}
+
+func importAncientData(ctx *cli.Context) error {
+	if ctx.NArg() < 1 {
+		return fmt.Errorf("required arguments: %v", ctx.Command.ArgsUsage)
+	}
+	var (
+		stack, _  = makeConfigNode(ctx)
+		interrupt = make(chan os.Signal, 1)
+		stop      = make(chan struct{})
+	)
+	defer stack.Close()
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	defer close(interrupt)
+	go func() {
+		if _, ok := <-interrupt; ok {
+			log.Info("Interrupted during ancient import, stopping at next batch")
+		}
+		close(stop)
+	}()
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	return utils.ImportAncientData(db, ctx.Args().Get(0), stop)
+}
+
 func exportChaindata(ctx *cli.Context) error {
 	if ctx.NArg() < 2 {
 		return fmt.Errorf("required arguments: %v", ctx.Command.ArgsUsage)
@@ -686,3 +1038,79 @@ func exportChaindata(ctx *cli.Context) error {
 	db := utils.MakeChainDatabase(ctx, stack, true)
 	return utils.ExportChaindata(ctx.Args().Get(1), kind, exporter(db), stop)
 }
+
+func dbPruneZktrie(ctx *cli.Context) error {
+	if ctx.NArg() > 1 {
+		return fmt.Errorf("max 1 argument: %v", ctx.Command.ArgsUsage)
+	}
+	stack, config := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	blocks := uint64(core.TriesInMemory)
+	if ctx.NArg() == 1 {
+		var err error
+		if blocks, err = strconv.ParseUint(ctx.Args().Get(0), 10, 64); err != nil {
+			return fmt.Errorf("failed to parse block count: %v", err)
+		}
+	}
+	pruner, err := pruner.NewZktriePruner(db, stack.ResolvePath(""), stack.ResolvePath(config.Eth.TrieCleanCacheJournal), ctx.GlobalUint64(utils.BloomFilterSizeFlag.Name))
+	if err != nil {
+		return err
+	}
+	return pruner.Prune(blocks)
+}
+
+func dbVerifyChain(ctx *cli.Context) error {
+	if ctx.NArg() > 2 {
+		return fmt.Errorf("max 2 arguments: %v", ctx.Command.ArgsUsage)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, true)
+	defer db.Close()
+
+	head := rawdb.ReadHeadBlock(db)
+	if head == nil {
+		return errors.New("no head block")
+	}
+	config := rawdb.ReadChainConfig(db, rawdb.ReadCanonicalHash(db, 0))
+	if config == nil {
+		return errors.New("no chain config")
+	}
+	var (
+		first = uint64(0)
+		last  = head.NumberU64()
+		err   error
+	)
+	if ctx.NArg() >= 1 {
+		if first, err = strconv.ParseUint(ctx.Args().Get(0), 10, 64); err != nil {
+			return fmt.Errorf("failed to parse first block: %v", err)
+		}
+	}
+	if ctx.NArg() >= 2 {
+		if last, err = strconv.ParseUint(ctx.Args().Get(1), 10, 64); err != nil {
+			return fmt.Errorf("failed to parse last block: %v", err)
+		}
+	}
+	log.Info("Verifying chain", "first", first, "last", last)
+	start := time.Now()
+	report, err := core.VerifyChain(db, config, first, last, ctx.Uint64(verifyStateIntervalFlag.Name))
+	if err != nil {
+		return err
+	}
+	log.Info("Verified chain", "blocks", report.Blocks, "issues", len(report.Issues), "elapsed", common.PrettyDuration(time.Since(start)))
+
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	if len(report.Issues) > 0 {
+		return fmt.Errorf("%d integrity issues found", len(report.Issues))
+	}
+	return nil
+}
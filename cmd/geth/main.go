@@ -65,13 +65,20 @@ var (
 		utils.BootnodesFlag,
 		utils.DataDirFlag,
 		utils.AncientFlag,
+		utils.AncientRemoteFlag,
+		utils.ZktrieDBEngineFlag,
+		utils.ZktrieDBCacheFlag,
+		utils.DBCompactionIdleFlag,
+		utils.DBCompactionIntervalFlag,
 		utils.MinFreeDiskSpaceFlag,
+		utils.DataDirReadOnlyFlag,
 		utils.KeyStoreDirFlag,
 		utils.ExternalSignerFlag,
 		utils.NoUSBFlag,
 		utils.USBFlag,
 		utils.SmartCardDaemonPathFlag,
 		utils.OverrideArrowGlacierFlag,
+		utils.OverrideStateSchemeFlag,
 		utils.EthashCacheDirFlag,
 		utils.EthashCachesInMemoryFlag,
 		utils.EthashCachesOnDiskFlag,
@@ -86,16 +93,30 @@ var (
 		utils.TxPoolRejournalFlag,
 		utils.TxPoolPriceLimitFlag,
 		utils.TxPoolPriceBumpFlag,
+		utils.TxPoolReplaceIntervalFlag,
 		utils.TxPoolAccountSlotsFlag,
 		utils.TxPoolGlobalSlotsFlag,
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
 		utils.TxPoolLifetimeFlag,
+		utils.TxPoolMaxLifetimeFlag,
+		utils.TxPoolGapTolerantFlag,
+		utils.TxPoolGapToleranceFlag,
+		utils.TxForwardFlag,
+		utils.TxForwardRateFlag,
 		utils.SyncModeFlag,
+		utils.RoleFlag,
 		utils.ExitWhenSyncedFlag,
+		utils.GracefulShutdownFlag,
+		utils.RecoveryParallelFlag,
 		utils.GCModeFlag,
 		utils.SnapshotFlag,
 		utils.TxLookupLimitFlag,
+		utils.SenderNonceIndexFlag,
+		utils.AddressActivityFlag,
+		utils.TokenTransfersFlag,
+		utils.EventLogIndexFlag,
+		utils.BloomSectionSizeFlag,
 		utils.LightServeFlag,
 		utils.LightIngressFlag,
 		utils.LightEgressFlag,
@@ -107,6 +128,7 @@ var (
 		utils.UltraLightOnlyAnnounceFlag,
 		utils.LightNoSyncServeFlag,
 		utils.WhitelistFlag,
+		utils.CheckpointSignerFlag,
 		utils.BloomFilterSizeFlag,
 		utils.CacheFlag,
 		utils.CacheDatabaseFlag,
@@ -115,6 +137,8 @@ var (
 		utils.CacheTrieRejournalFlag,
 		utils.CacheGCFlag,
 		utils.CacheSnapshotFlag,
+		utils.CacheCodeFlag,
+		utils.CachePrefetchWorkersFlag,
 		utils.CacheNoPrefetchFlag,
 		utils.CachePreimagesFlag,
 		utils.ListenPortFlag,
@@ -130,6 +154,9 @@ var (
 		utils.MinerExtraDataFlag,
 		utils.MinerRecommitIntervalFlag,
 		utils.MinerNoVerifyFlag,
+		utils.MinerMaxWitnessNodesFlag,
+		utils.MinerOrderingAuditFlag,
+		utils.MinerPreconfirmKeyFlag,
 		utils.NATFlag,
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
@@ -141,13 +168,22 @@ var (
 		utils.DeveloperFlag,
 		utils.DeveloperPeriodFlag,
 		utils.DeveloperGasLimitFlag,
+		utils.DeveloperZktrieFlag,
+		utils.DeveloperMinBaseFeeFlag,
 		utils.RopstenFlag,
 		utils.SepoliaFlag,
 		utils.RinkebyFlag,
 		utils.GoerliFlag,
 		utils.VMEnableDebugFlag,
+		utils.VMFuseInstructionsFlag,
 		utils.NetworkIdFlag,
 		utils.EthStatsURLFlag,
+		utils.RootCheckURLsFlag,
+		utils.FirehoseURLFlag,
+		utils.FirehoseTopicFlag,
+		utils.FirehoseFormatFlag,
+		utils.WatchdogDepthFlag,
+		utils.WatchdogHaltFlag,
 		utils.FakePoWFlag,
 		utils.NoCompactionFlag,
 		utils.GpoBlocksFlag,
@@ -168,6 +204,9 @@ var (
 		utils.GraphQLEnabledFlag,
 		utils.GraphQLCORSDomainFlag,
 		utils.GraphQLVirtualHostsFlag,
+		utils.GRPCEnabledFlag,
+		utils.GRPCListenAddrFlag,
+		utils.GRPCPortFlag,
 		utils.HTTPApiFlag,
 		utils.HTTPPathPrefixFlag,
 		utils.WSEnabledFlag,
@@ -176,11 +215,22 @@ var (
 		utils.WSApiFlag,
 		utils.WSAllowedOriginsFlag,
 		utils.WSPathPrefixFlag,
+		utils.WSMaxSubscriptionsFlag,
+		utils.WSNotificationBufferFlag,
+		utils.WSOverflowPolicyFlag,
 		utils.IPCDisabledFlag,
 		utils.IPCPathFlag,
 		utils.InsecureUnlockAllowedFlag,
 		utils.RPCGlobalGasCapFlag,
 		utils.RPCGlobalEVMTimeoutFlag,
+		utils.RPCCallCacheFlag,
+		utils.RPCCallCacheTTLFlag,
+		utils.RPCMaxReexecFlag,
+		utils.RPCLogQueryMaxBlocksFlag,
+		utils.RPCLogQueryMaxResultsFlag,
+		utils.RPCBatchItemLimitFlag,
+		utils.RPCBatchCostLimitFlag,
+		utils.RPCAPIKeysFlag,
 		utils.RPCGlobalTxFeeCapFlag,
 		utils.AllowUnprotectedTxs,
 	}
@@ -213,6 +263,8 @@ func init() {
 		initCommand,
 		importCommand,
 		exportCommand,
+		exportAnalyticsCommand,
+		bisectRootCommand,
 		importPreimagesCommand,
 		exportPreimagesCommand,
 		removedbCommand,
@@ -235,6 +287,10 @@ func init() {
 		dumpConfigCommand,
 		// see dbcmd.go
 		dbCommand,
+		// See checkpointcmd.go
+		checkpointCommand,
+		// See rollupcmd.go
+		rollupCommand,
 		// See cmd/utils/flags_legacy.go
 		utils.ShowDeprecated,
 		// See snapshot.go
@@ -285,6 +341,9 @@ func prepare(ctx *cli.Context) {
 	case !ctx.GlobalIsSet(utils.NetworkIdFlag.Name):
 		log.Info("Starting Geth on Ethereum mainnet...")
 	}
+	// Fill in the defaults of the requested node role and reject conflicting flags
+	utils.ApplyRole(ctx, ctx.GlobalString(configFileFlag.Name))
+
 	// If we're a full node on mainnet without --cache specified, bump default cache allowance
 	if ctx.GlobalString(utils.SyncModeFlag.Name) != "light" && !ctx.GlobalIsSet(utils.CacheFlag.Name) && !ctx.GlobalIsSet(utils.NetworkIdFlag.Name) {
 		// Make sure we're not on any supported preconfigured testnet either
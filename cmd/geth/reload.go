@@ -0,0 +1,255 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/naoina/toml"
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/scroll-tech/go-ethereum/core"
+	"github.com/scroll-tech/go-ethereum/eth"
+	"github.com/scroll-tech/go-ethereum/eth/ethconfig"
+	"github.com/scroll-tech/go-ethereum/eth/gasprice"
+	"github.com/scroll-tech/go-ethereum/firehose"
+	"github.com/scroll-tech/go-ethereum/internal/debug"
+	"github.com/scroll-tech/go-ethereum/internal/ethapi"
+	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/metrics"
+	"github.com/scroll-tech/go-ethereum/node"
+	"github.com/scroll-tech/go-ethereum/rpc"
+)
+
+// applyLogConfig applies the logging settings of the config file, unless they
+// were overridden on the command line.
+func applyLogConfig(ctx *cli.Context, cfg *logConfig) error {
+	if cfg.Verbosity != 0 && !ctx.GlobalIsSet("verbosity") {
+		debug.Handler.Verbosity(cfg.Verbosity)
+	}
+	if cfg.Vmodule != "" && !ctx.GlobalIsSet("vmodule") {
+		return debug.Handler.Vmodule(cfg.Vmodule)
+	}
+	return nil
+}
+
+// oracleBackend is implemented by the API backends running a gas price oracle.
+type oracleBackend interface {
+	GasPriceOracle() *gasprice.Oracle
+}
+
+// configReloadAPI applies the runtime adjustable settings of the config file
+// without restarting the node.
+type configReloadAPI struct {
+	file    string
+	stack   *node.Node
+	backend ethapi.Backend
+	eth     *eth.Ethereum // Nil in light client mode
+
+	lock sync.Mutex // Serializes reloads
+}
+
+// registerConfigReloadAPI exposes admin_reloadConfig for the given config file.
+func registerConfigReloadAPI(stack *node.Node, backend ethapi.Backend, eth *eth.Ethereum, file string) {
+	stack.RegisterAPIs([]rpc.API{{
+		Namespace: "admin",
+		Version:   "1.0",
+		Service:   &configReloadAPI{file: file, stack: stack, backend: backend, eth: eth},
+		Public:    false,
+	}})
+}
+
+// reloadableConfig holds the settings of the config file ReloadConfig applies.
+// The settings missing from the file stay nil and keep their running values,
+// which may have been given by command line flags.
+type reloadableConfig struct {
+	Log struct {
+		Verbosity *int
+		Vmodule   *string
+	}
+	Eth struct {
+		GPO struct {
+			Blocks           *int
+			Percentile       *int
+			MaxHeaderHistory *int
+			MaxBlockHistory  *int
+			MaxPrice         *big.Int
+			IgnorePrice      *big.Int
+		}
+		TxPool struct {
+			PriceLimit      *uint64
+			PriceBump       *uint64
+			ReplaceInterval *time.Duration
+		}
+	}
+	Node struct {
+		RPCBatchItemLimit    *int
+		RPCBatchCostLimit    *uint64
+		RPCMethodWeights     map[string]uint64
+		WSMaxSubscriptions   *int
+		WSNotificationBuffer *int
+		WSDisconnectSlow     *bool
+	}
+}
+
+// reloadTomlSettings decodes the reloadable settings of a config file, skipping
+// all the others.
+var reloadTomlSettings = toml.Config{
+	NormFieldName: tomlSettings.NormFieldName,
+	FieldToKey:    tomlSettings.FieldToKey,
+	MissingField: func(rt reflect.Type, field string) error {
+		return nil
+	},
+}
+
+// loadReloadableConfig checks the entire config file like at startup, and
+// returns the reloadable settings in it.
+func loadReloadableConfig(file string) (*reloadableConfig, error) {
+	check := gethConfig{
+		Eth:      ethconfig.Defaults,
+		Node:     defaultNodeConfig(),
+		Firehose: firehose.DefaultConfig,
+		Metrics:  metrics.DefaultConfig,
+	}
+	if err := loadConfig(file, &check); err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(reloadableConfig)
+	if err := reloadTomlSettings.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	pool := cfg.Eth.TxPool
+	if (pool.PriceLimit != nil && *pool.PriceLimit < 1) || (pool.PriceBump != nil && *pool.PriceBump < 1) || (pool.ReplaceInterval != nil && *pool.ReplaceInterval < 0) {
+		return nil, errors.New("invalid transaction pool policy")
+	}
+	return cfg, nil
+}
+
+// ReloadConfig re-reads the config file the node was started with and applies
+// the logging, RPC limit, gas price oracle and transaction pool policy settings
+// in it. The settings in the file replace the running ones, including the ones
+// given by command line flags, while the settings missing from it are kept.
+// Other settings, including the static and trusted peer lists, which have no
+// effect as the p2p server is not started, only apply after a restart.
+//
+// The names of the applied config sections are returned.
+func (api *configReloadAPI) ReloadConfig() ([]string, error) {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	cfg, err := loadReloadableConfig(api.file)
+	if err != nil {
+		return nil, err
+	}
+	var applied []string
+
+	if cfg.Log.Verbosity != nil {
+		debug.Handler.Verbosity(*cfg.Log.Verbosity)
+	}
+	if cfg.Log.Vmodule != nil {
+		if err := debug.Handler.Vmodule(*cfg.Log.Vmodule); err != nil {
+			return applied, err
+		}
+	}
+	applied = append(applied, "Log")
+
+	current := api.stack.Config()
+	limits := node.Config{
+		RPCBatchItemLimit:    current.RPCBatchItemLimit,
+		RPCBatchCostLimit:    current.RPCBatchCostLimit,
+		RPCMethodWeights:     current.RPCMethodWeights,
+		WSMaxSubscriptions:   current.WSMaxSubscriptions,
+		WSNotificationBuffer: current.WSNotificationBuffer,
+		WSDisconnectSlow:     current.WSDisconnectSlow,
+	}
+	if v := cfg.Node.RPCBatchItemLimit; v != nil {
+		limits.RPCBatchItemLimit = *v
+	}
+	if v := cfg.Node.RPCBatchCostLimit; v != nil {
+		limits.RPCBatchCostLimit = *v
+	}
+	if v := cfg.Node.RPCMethodWeights; v != nil {
+		limits.RPCMethodWeights = v
+	}
+	if v := cfg.Node.WSMaxSubscriptions; v != nil {
+		limits.WSMaxSubscriptions = *v
+	}
+	if v := cfg.Node.WSNotificationBuffer; v != nil {
+		limits.WSNotificationBuffer = *v
+	}
+	if v := cfg.Node.WSDisconnectSlow; v != nil {
+		limits.WSDisconnectSlow = *v
+	}
+	api.stack.SetRPCLimits(&limits)
+	applied = append(applied, "Node")
+
+	if backend, ok := api.backend.(oracleBackend); ok {
+		var (
+			oracle = backend.GasPriceOracle()
+			gpo    = oracle.Config()
+		)
+		if v := cfg.Eth.GPO.Blocks; v != nil {
+			gpo.Blocks = *v
+		}
+		if v := cfg.Eth.GPO.Percentile; v != nil {
+			gpo.Percentile = *v
+		}
+		if v := cfg.Eth.GPO.MaxHeaderHistory; v != nil {
+			gpo.MaxHeaderHistory = *v
+		}
+		if v := cfg.Eth.GPO.MaxBlockHistory; v != nil {
+			gpo.MaxBlockHistory = *v
+		}
+		if v := cfg.Eth.GPO.MaxPrice; v != nil {
+			gpo.MaxPrice = v
+		}
+		if v := cfg.Eth.GPO.IgnorePrice; v != nil {
+			gpo.IgnorePrice = v
+		}
+		oracle.SetConfig(gpo)
+		applied = append(applied, "Eth.GPO")
+	}
+	if api.eth != nil {
+		_, err := api.eth.TxPool().UpdatePolicy(func(policy *core.TxPoolPolicy) error {
+			if v := cfg.Eth.TxPool.PriceLimit; v != nil {
+				policy.PriceLimit = *v
+			}
+			if v := cfg.Eth.TxPool.PriceBump; v != nil {
+				policy.PriceBump = *v
+			}
+			if v := cfg.Eth.TxPool.ReplaceInterval; v != nil {
+				policy.ReplaceInterval = *v
+			}
+			return nil
+		})
+		if err != nil {
+			return applied, err
+		}
+		applied = append(applied, "Eth.TxPool")
+	}
+	log.Info("Reloaded node configuration", "file", api.file, "sections", applied)
+	return applied, nil
+}
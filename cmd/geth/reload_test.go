@@ -0,0 +1,103 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/core"
+	"github.com/scroll-tech/go-ethereum/eth/gasprice"
+	"github.com/scroll-tech/go-ethereum/event"
+	"github.com/scroll-tech/go-ethereum/internal/ethapi"
+	"github.com/scroll-tech/go-ethereum/node"
+)
+
+// reloadOracleBackend is a gas price oracle backend only serving chain head
+// subscriptions, enough to create an oracle.
+type reloadOracleBackend struct {
+	gasprice.OracleBackend
+	feed event.Feed
+}
+
+func (b *reloadOracleBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return b.feed.Subscribe(ch)
+}
+
+// reloadBackend is an API backend only running a gas price oracle.
+type reloadBackend struct {
+	ethapi.Backend
+	oracle *gasprice.Oracle
+}
+
+func (b *reloadBackend) GasPriceOracle() *gasprice.Oracle {
+	return b.oracle
+}
+
+// Tests that reloading the config file only replaces the settings in it, and
+// keeps the ones given on the command line otherwise.
+func TestReloadConfigKeepsFlags(t *testing.T) {
+	dir, err := ioutil.TempDir("", "geth-reload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The node was started with flags setting these limits and oracle parameters
+	stack, err := node.New(&node.Config{RPCBatchItemLimit: 10, WSMaxSubscriptions: 7})
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	defer stack.Close()
+
+	oracle := gasprice.NewOracle(new(reloadOracleBackend), gasprice.Config{Blocks: 3, Percentile: 40, MaxHeaderHistory: 1, MaxBlockHistory: 1})
+	file := filepath.Join(dir, "config.toml")
+	api := &configReloadAPI{file: file, stack: stack, backend: &reloadBackend{oracle: oracle}}
+
+	config := "[Eth.GPO]\nBlocks = 7\n\n[Node]\nRPCBatchItemLimit = 20\n"
+	if err := ioutil.WriteFile(file, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.ReloadConfig(); err != nil {
+		t.Fatalf("failed to reload config: %v", err)
+	}
+	if have := stack.Config().RPCBatchItemLimit; have != 20 {
+		t.Errorf("batch item limit mismatch: have %d, want %d", have, 20)
+	}
+	if have := stack.Config().WSMaxSubscriptions; have != 7 {
+		t.Errorf("flag set subscription limit not kept: have %d, want %d", have, 7)
+	}
+	if have := oracle.Config().Blocks; have != 7 {
+		t.Errorf("oracle blocks mismatch: have %d, want %d", have, 7)
+	}
+	if have := oracle.Config().Percentile; have != 40 {
+		t.Errorf("flag set oracle percentile not kept: have %d, want %d", have, 40)
+	}
+	// An invalid pool policy is rejected before anything is applied
+	config = "[Eth.TxPool]\nPriceBump = 0\n\n[Node]\nRPCBatchItemLimit = 30\n"
+	if err := ioutil.WriteFile(file, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.ReloadConfig(); err == nil {
+		t.Fatal("invalid pool policy accepted")
+	}
+	if have := stack.Config().RPCBatchItemLimit; have != 20 {
+		t.Errorf("batch item limit changed by failed reload: have %d, want %d", have, 20)
+	}
+}
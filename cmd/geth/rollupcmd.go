@@ -0,0 +1,256 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	fieldutils "github.com/iden3/go-iden3-crypto/utils"
+	"github.com/naoina/toml"
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/scroll-tech/go-ethereum/cmd/utils"
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/go-ethereum/common/math"
+	"github.com/scroll-tech/go-ethereum/core"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/params"
+)
+
+var (
+	rollupCommand = cli.Command{
+		Name:      "rollup",
+		Usage:     "Rollup deployment helpers",
+		ArgsUsage: "",
+		Category:  "BLOCKCHAIN COMMANDS",
+		Description: `
+The rollup commands prepare the artifacts shared between the L2 nodes and the
+L1 deployment scripts.`,
+		Subcommands: []cli.Command{
+			rollupInitGenesisCmd,
+		},
+	}
+	rollupInitGenesisCmd = cli.Command{
+		Action:    utils.MigrateFlags(rollupInitGenesis),
+		Name:      "init-genesis",
+		Usage:     "Generate a genesis file from a rollup spec",
+		ArgsUsage: "<spec file> <genesis file>",
+		Description: `
+The init-genesis command reads a TOML or JSON (by file extension) rollup spec
+listing the sequencer, fee parameters, system contracts and prefunded accounts,
+writes the resulting genesis.json and prints the genesis state root and block
+hash. The output only depends on the spec, so every party generating the
+genesis from the same spec obtains the same root.`,
+	}
+)
+
+// rollupSpec is the high level description of a rollup genesis.
+type rollupSpec struct {
+	ChainID   uint64         `json:"chainId"`
+	Sequencer common.Address `json:"sequencer"` // Clique signer sealing the blocks
+	Period    uint64         `json:"period"`    // Block time in seconds
+	Timestamp uint64         `json:"timestamp"`
+	GasLimit  uint64         `json:"gasLimit"`
+	Zktrie    bool           `json:"zktrie"`
+
+	BaseFee                  *math.HexOrDecimal256 `json:"baseFee"` // Genesis base fee, default = params.InitialBaseFee
+	MinBaseFee               *math.HexOrDecimal256 `json:"minBaseFee"`
+	ElasticityMultiplier     uint64                `json:"elasticityMultiplier"`
+	BaseFeeChangeDenominator uint64                `json:"baseFeeChangeDenominator"`
+
+	SystemContracts []rollupContract `json:"systemContracts"`
+	Accounts        []rollupAccount  `json:"accounts"`
+}
+
+// rollupContract is a system contract predeployed in the genesis state.
+type rollupContract struct {
+	Name    string                      `json:"name"`
+	Address common.Address              `json:"address"`
+	Code    hexutil.Bytes               `json:"code"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+	Balance *math.HexOrDecimal256       `json:"balance"`
+}
+
+// rollupAccount is an account prefunded in the genesis state.
+type rollupAccount struct {
+	Address common.Address        `json:"address"`
+	Balance *math.HexOrDecimal256 `json:"balance"`
+}
+
+// loadRollupSpec reads the spec file, decoding it as JSON or TOML depending on
+// the file extension.
+func loadRollupSpec(file string) (*rollupSpec, error) {
+	spec := new(rollupSpec)
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+		blob, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(blob, spec); err != nil {
+			return nil, fmt.Errorf("invalid rollup spec %s: %v", file, err)
+		}
+	case ".toml":
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		err = tomlSettings.NewDecoder(bufio.NewReader(f)).Decode(spec)
+		if _, ok := err.(*toml.LineError); ok {
+			err = errors.New(file + ", " + err.Error())
+		}
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown rollup spec format %q, expected .toml or .json", filepath.Ext(file))
+	}
+	return spec, nil
+}
+
+// genesis assembles the genesis block described by the spec.
+func (s *rollupSpec) genesis() (*core.Genesis, error) {
+	if s.ChainID == 0 {
+		return nil, errors.New("missing chain id")
+	}
+	if s.Sequencer == (common.Address{}) {
+		return nil, errors.New("missing sequencer address")
+	}
+	if s.GasLimit == 0 {
+		return nil, errors.New("missing gas limit")
+	}
+	config := *params.AllCliqueProtocolChanges
+	config.ChainID = new(big.Int).SetUint64(s.ChainID)
+	config.Clique = &params.CliqueConfig{
+		Period: s.Period,
+		Epoch:  config.Clique.Epoch,
+	}
+	config.Zktrie = s.Zktrie
+	if s.MinBaseFee != nil || s.ElasticityMultiplier != 0 || s.BaseFeeChangeDenominator != 0 {
+		fees := &params.EIP1559Config{
+			Block:                    big.NewInt(0),
+			ElasticityMultiplier:     s.ElasticityMultiplier,
+			BaseFeeChangeDenominator: s.BaseFeeChangeDenominator,
+		}
+		if s.MinBaseFee != nil {
+			fees.MinBaseFee = (*big.Int)(s.MinBaseFee)
+		}
+		config.EIP1559 = []*params.EIP1559Config{fees}
+	}
+	if err := config.CheckConfigForkOrder(); err != nil {
+		return nil, err
+	}
+	baseFee := big.NewInt(params.InitialBaseFee)
+	if s.BaseFee != nil {
+		baseFee = (*big.Int)(s.BaseFee)
+	}
+	alloc := make(core.GenesisAlloc)
+	add := func(addr common.Address, account core.GenesisAccount) error {
+		if _, ok := alloc[addr]; ok {
+			return fmt.Errorf("duplicate genesis account %x", addr)
+		}
+		if account.Balance == nil {
+			account.Balance = new(big.Int)
+		}
+		// Balances are stored as field elements in the zk trie
+		if s.Zktrie && !fieldutils.CheckBigIntInField(account.Balance) {
+			return fmt.Errorf("balance of %x out of the zk field", addr)
+		}
+		alloc[addr] = account
+		return nil
+	}
+	for _, contract := range s.SystemContracts {
+		if len(contract.Code) == 0 {
+			return nil, fmt.Errorf("system contract %s (%x) has no code", contract.Name, contract.Address)
+		}
+		if err := add(contract.Address, core.GenesisAccount{
+			Code:    contract.Code,
+			Storage: contract.Storage,
+			Balance: (*big.Int)(contract.Balance),
+		}); err != nil {
+			return nil, err
+		}
+	}
+	for _, account := range s.Accounts {
+		if err := add(account.Address, core.GenesisAccount{Balance: (*big.Int)(account.Balance)}); err != nil {
+			return nil, err
+		}
+	}
+	return &core.Genesis{
+		Config:     &config,
+		Timestamp:  s.Timestamp,
+		ExtraData:  append(append(make([]byte, 32), s.Sequencer[:]...), make([]byte, crypto.SignatureLength)...),
+		GasLimit:   s.GasLimit,
+		BaseFee:    baseFee,
+		Difficulty: big.NewInt(1),
+		Alloc:      alloc,
+	}, nil
+}
+
+// rollupGenesisInfo is printed for the L1 deployment scripts.
+type rollupGenesisInfo struct {
+	ChainID   uint64      `json:"chainId"`
+	StateRoot common.Hash `json:"stateRoot"`
+	Hash      common.Hash `json:"hash"`
+}
+
+func rollupInitGenesis(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return fmt.Errorf("required arguments: %v", ctx.Command.ArgsUsage)
+	}
+	spec, err := loadRollupSpec(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	genesis, err := spec.genesis()
+	if err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(genesis, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(ctx.Args().Get(1), out, 0644); err != nil {
+		return err
+	}
+	// The state root is the Poseidon root for zk trie specs
+	block := genesis.ToBlock(nil)
+	log.Info("Wrote rollup genesis", "file", ctx.Args().Get(1), "zktrie", spec.Zktrie)
+
+	info, err := json.MarshalIndent(&rollupGenesisInfo{
+		ChainID:   spec.ChainID,
+		StateRoot: block.Root(),
+		Hash:      block.Hash(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(info))
+	return nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+)
+
+const rollupSpecTOML = `
+ChainID = 534353
+Sequencer = "0x00000000000000000000000000000000000000aa"
+Period = 3
+Timestamp = 1650000000
+GasLimit = 10000000
+Zktrie = true
+BaseFee = "0x3b9aca00"
+MinBaseFee = "1000000"
+ElasticityMultiplier = 2
+BaseFeeChangeDenominator = 8
+
+[[SystemContracts]]
+Name = "L2MessageQueue"
+Address = "0x5300000000000000000000000000000000000000"
+Code = "0x6001600055"
+[SystemContracts.Storage]
+"0x0000000000000000000000000000000000000000000000000000000000000000" = "0x00000000000000000000000000000000000000000000000000000000000000aa"
+
+[[Accounts]]
+Address = "0x00000000000000000000000000000000000000bb"
+Balance = "1000000000000000000"
+`
+
+const rollupSpecJSON = `{
+	"chainId": 534353,
+	"sequencer": "0x00000000000000000000000000000000000000aa",
+	"period": 3,
+	"timestamp": 1650000000,
+	"gasLimit": 10000000,
+	"zktrie": true,
+	"baseFee": "0x3b9aca00",
+	"minBaseFee": "1000000",
+	"elasticityMultiplier": 2,
+	"baseFeeChangeDenominator": 8,
+	"systemContracts": [{
+		"name": "L2MessageQueue",
+		"address": "0x5300000000000000000000000000000000000000",
+		"code": "0x6001600055",
+		"storage": {
+			"0x0000000000000000000000000000000000000000000000000000000000000000": "0x00000000000000000000000000000000000000000000000000000000000000aa"
+		}
+	}],
+	"accounts": [{
+		"address": "0x00000000000000000000000000000000000000bb",
+		"balance": "1000000000000000000"
+	}]
+}`
+
+// Tests that the TOML and JSON encodings of a rollup spec produce the same
+// genesis block, and that the zk trie flag selects the Poseidon state root.
+func TestRollupGenesisSpec(t *testing.T) {
+	dir := tmpdir(t)
+	defer os.RemoveAll(dir)
+
+	var roots []common.Hash
+	for _, spec := range []struct{ name, data string }{
+		{"spec.toml", rollupSpecTOML},
+		{"spec.json", rollupSpecJSON},
+	} {
+		file := filepath.Join(dir, spec.name)
+		if err := ioutil.WriteFile(file, []byte(spec.data), 0600); err != nil {
+			t.Fatal(err)
+		}
+		s, err := loadRollupSpec(file)
+		if err != nil {
+			t.Fatalf("%s: failed to load spec: %v", spec.name, err)
+		}
+		genesis, err := s.genesis()
+		if err != nil {
+			t.Fatalf("%s: failed to assemble genesis: %v", spec.name, err)
+		}
+		if !genesis.Config.Zktrie {
+			t.Fatalf("%s: zk trie not enabled", spec.name)
+		}
+		if n := len(genesis.Config.EIP1559); n != 1 || genesis.Config.EIP1559[0].MinBaseFee.Uint64() != 1000000 {
+			t.Fatalf("%s: fee parameters mismatch: %v", spec.name, genesis.Config.EIP1559)
+		}
+		if n := len(genesis.Alloc); n != 2 {
+			t.Fatalf("%s: genesis account count mismatch: have %d, want 2", spec.name, n)
+		}
+		roots = append(roots, genesis.ToBlock(nil).Root())
+
+		// The root must differ from the one of the keccak MPT
+		s.Zktrie = false
+		genesis, _ = s.genesis()
+		if root := genesis.ToBlock(nil).Root(); root == roots[len(roots)-1] {
+			t.Fatalf("%s: zk trie flag ignored", spec.name)
+		}
+	}
+	if roots[0] != roots[1] {
+		t.Fatalf("genesis root mismatch: toml %x, json %x", roots[0], roots[1])
+	}
+}
+
+func TestRollupGenesisSpecInvalid(t *testing.T) {
+	spec := &rollupSpec{
+		ChainID:   1,
+		Sequencer: common.HexToAddress("0xaa"),
+		GasLimit:  10000000,
+		SystemContracts: []rollupContract{
+			{Name: "A", Address: common.HexToAddress("0x01"), Code: []byte{0x00}},
+		},
+		Accounts: []rollupAccount{{Address: common.HexToAddress("0x01")}},
+	}
+	if _, err := spec.genesis(); err == nil {
+		t.Fatal("duplicate account accepted")
+	}
+	spec.Accounts = nil
+	spec.SystemContracts[0].Code = nil
+	if _, err := spec.genesis(); err == nil {
+		t.Fatal("system contract without code accepted")
+	}
+}
@@ -37,7 +37,13 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			configFileFlag,
 			utils.DataDirFlag,
 			utils.AncientFlag,
+			utils.AncientRemoteFlag,
+			utils.ZktrieDBEngineFlag,
+			utils.ZktrieDBCacheFlag,
+			utils.DBCompactionIdleFlag,
+			utils.DBCompactionIntervalFlag,
 			utils.MinFreeDiskSpaceFlag,
+			utils.DataDirReadOnlyFlag,
 			utils.KeyStoreDirFlag,
 			utils.USBFlag,
 			utils.SmartCardDaemonPathFlag,
@@ -48,13 +54,28 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.RopstenFlag,
 			utils.SepoliaFlag,
 			utils.SyncModeFlag,
+			utils.RoleFlag,
 			utils.ExitWhenSyncedFlag,
+			utils.GracefulShutdownFlag,
+			utils.RecoveryParallelFlag,
 			utils.GCModeFlag,
 			utils.TxLookupLimitFlag,
+			utils.SenderNonceIndexFlag,
+			utils.AddressActivityFlag,
+			utils.TokenTransfersFlag,
+			utils.EventLogIndexFlag,
+			utils.BloomSectionSizeFlag,
 			utils.EthStatsURLFlag,
+			utils.RootCheckURLsFlag,
+			utils.FirehoseURLFlag,
+			utils.FirehoseTopicFlag,
+			utils.FirehoseFormatFlag,
+			utils.WatchdogDepthFlag,
+			utils.WatchdogHaltFlag,
 			utils.IdentityFlag,
 			utils.LightKDFFlag,
 			utils.WhitelistFlag,
+			utils.CheckpointSignerFlag,
 		},
 	},
 	{
@@ -77,6 +98,8 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.DeveloperFlag,
 			utils.DeveloperPeriodFlag,
 			utils.DeveloperGasLimitFlag,
+			utils.DeveloperZktrieFlag,
+			utils.DeveloperMinBaseFeeFlag,
 		},
 	},
 	{
@@ -101,11 +124,17 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.TxPoolRejournalFlag,
 			utils.TxPoolPriceLimitFlag,
 			utils.TxPoolPriceBumpFlag,
+			utils.TxPoolReplaceIntervalFlag,
 			utils.TxPoolAccountSlotsFlag,
 			utils.TxPoolGlobalSlotsFlag,
 			utils.TxPoolAccountQueueFlag,
 			utils.TxPoolGlobalQueueFlag,
 			utils.TxPoolLifetimeFlag,
+			utils.TxPoolMaxLifetimeFlag,
+			utils.TxPoolGapTolerantFlag,
+			utils.TxPoolGapToleranceFlag,
+			utils.TxForwardFlag,
+			utils.TxForwardRateFlag,
 		},
 	},
 	{
@@ -118,6 +147,8 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.CacheTrieRejournalFlag,
 			utils.CacheGCFlag,
 			utils.CacheSnapshotFlag,
+			utils.CacheCodeFlag,
+			utils.CachePrefetchWorkersFlag,
 			utils.CacheNoPrefetchFlag,
 			utils.CachePreimagesFlag,
 		},
@@ -148,12 +179,26 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.WSPortFlag,
 			utils.WSApiFlag,
 			utils.WSPathPrefixFlag,
+			utils.WSMaxSubscriptionsFlag,
+			utils.WSNotificationBufferFlag,
+			utils.WSOverflowPolicyFlag,
 			utils.WSAllowedOriginsFlag,
 			utils.GraphQLEnabledFlag,
 			utils.GraphQLCORSDomainFlag,
 			utils.GraphQLVirtualHostsFlag,
+			utils.GRPCEnabledFlag,
+			utils.GRPCListenAddrFlag,
+			utils.GRPCPortFlag,
 			utils.RPCGlobalGasCapFlag,
 			utils.RPCGlobalEVMTimeoutFlag,
+			utils.RPCCallCacheFlag,
+			utils.RPCCallCacheTTLFlag,
+			utils.RPCMaxReexecFlag,
+			utils.RPCLogQueryMaxBlocksFlag,
+			utils.RPCLogQueryMaxResultsFlag,
+			utils.RPCBatchItemLimitFlag,
+			utils.RPCBatchCostLimitFlag,
+			utils.RPCAPIKeysFlag,
 			utils.RPCGlobalTxFeeCapFlag,
 			utils.AllowUnprotectedTxs,
 			utils.JSpathFlag,
@@ -190,6 +235,9 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.MinerExtraDataFlag,
 			utils.MinerRecommitIntervalFlag,
 			utils.MinerNoVerifyFlag,
+			utils.MinerMaxWitnessNodesFlag,
+			utils.MinerOrderingAuditFlag,
+			utils.MinerPreconfirmKeyFlag,
 		},
 	},
 	{
@@ -205,6 +253,7 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 		Name: "VIRTUAL MACHINE",
 		Flags: []cli.Flag{
 			utils.VMEnableDebugFlag,
+			utils.VMFuseInstructionsFlag,
 		},
 	},
 	{
@@ -0,0 +1,252 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/go-ethereum/core"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/internal/tabular"
+	"github.com/scroll-tech/go-ethereum/log"
+)
+
+// The schemas of the analytics tables. Columns may only be appended to keep the
+// existing warehouse loaders working. Quantities which may exceed 64 bits are
+// exported as decimal strings, hashes, addresses and binary data as lower case
+// 0x prefixed hex strings, absent values as empty strings.
+var (
+	analyticsBlockSchema = tabular.Schema{
+		{Name: "number", Kind: tabular.Int64},
+		{Name: "hash", Kind: tabular.String},
+		{Name: "parent_hash", Kind: tabular.String},
+		{Name: "timestamp", Kind: tabular.Int64},
+		{Name: "miner", Kind: tabular.String},
+		{Name: "difficulty", Kind: tabular.String},
+		{Name: "gas_limit", Kind: tabular.Int64},
+		{Name: "gas_used", Kind: tabular.Int64},
+		{Name: "base_fee", Kind: tabular.String},
+		{Name: "state_root", Kind: tabular.String},
+		{Name: "size", Kind: tabular.Int64},
+		{Name: "tx_count", Kind: tabular.Int64},
+	}
+	analyticsTxSchema = tabular.Schema{
+		{Name: "block_number", Kind: tabular.Int64},
+		{Name: "block_hash", Kind: tabular.String},
+		{Name: "tx_index", Kind: tabular.Int64},
+		{Name: "hash", Kind: tabular.String},
+		{Name: "type", Kind: tabular.Int64},
+		{Name: "from", Kind: tabular.String},
+		{Name: "to", Kind: tabular.String},
+		{Name: "nonce", Kind: tabular.Int64},
+		{Name: "value", Kind: tabular.String},
+		{Name: "gas", Kind: tabular.Int64},
+		{Name: "gas_price", Kind: tabular.String},
+		{Name: "max_priority_fee", Kind: tabular.String},
+		{Name: "max_fee", Kind: tabular.String},
+		{Name: "input", Kind: tabular.String},
+	}
+	analyticsReceiptSchema = tabular.Schema{
+		{Name: "block_number", Kind: tabular.Int64},
+		{Name: "tx_hash", Kind: tabular.String},
+		{Name: "tx_index", Kind: tabular.Int64},
+		{Name: "status", Kind: tabular.Int64},
+		{Name: "cumulative_gas_used", Kind: tabular.Int64},
+		{Name: "gas_used", Kind: tabular.Int64},
+		{Name: "contract_address", Kind: tabular.String},
+		{Name: "log_count", Kind: tabular.Int64},
+	}
+	analyticsLogSchema = tabular.Schema{
+		{Name: "block_number", Kind: tabular.Int64},
+		{Name: "tx_hash", Kind: tabular.String},
+		{Name: "tx_index", Kind: tabular.Int64},
+		{Name: "log_index", Kind: tabular.Int64},
+		{Name: "address", Kind: tabular.String},
+		{Name: "topic0", Kind: tabular.String},
+		{Name: "topic1", Kind: tabular.String},
+		{Name: "topic2", Kind: tabular.String},
+		{Name: "topic3", Kind: tabular.String},
+		{Name: "data", Kind: tabular.String},
+	}
+)
+
+// analyticsTable is an output file of the analytics export.
+type analyticsTable struct {
+	file   *os.File
+	buffer *bufio.Writer
+	tabular.Writer
+}
+
+// createAnalyticsTable creates the file of a table in the given format.
+func createAnalyticsTable(dir, name, format string, schema tabular.Schema) (*analyticsTable, error) {
+	file, err := os.Create(filepath.Join(dir, name+"."+format))
+	if err != nil {
+		return nil, err
+	}
+	buffer := bufio.NewWriter(file)
+	writer, err := tabular.NewWriter(format, buffer, schema)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &analyticsTable{file: file, buffer: buffer, Writer: writer}, nil
+}
+
+// Close finalizes the table and closes its file.
+func (t *analyticsTable) Close() error {
+	defer t.file.Close()
+	if err := t.Writer.Close(); err != nil {
+		return err
+	}
+	if err := t.buffer.Flush(); err != nil {
+		return err
+	}
+	return t.file.Close()
+}
+
+// ExportAnalytics exports the blocks, transactions, receipts and logs of a range
+// of canonical blocks into one table file each, in the given format ("csv" or
+// "parquet"), in the specified directory.
+func ExportAnalytics(blockchain *core.BlockChain, dir string, format string, first uint64, last uint64) error {
+	log.Info("Exporting analytics tables", "dir", dir, "format", format, "first", first, "last", last)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	var (
+		schemas = []tabular.Schema{analyticsBlockSchema, analyticsTxSchema, analyticsReceiptSchema, analyticsLogSchema}
+		names   = []string{"blocks", "transactions", "receipts", "logs"}
+		tables  = make([]*analyticsTable, len(names))
+	)
+	for i, name := range names {
+		table, err := createAnalyticsTable(dir, name, format, schemas[i])
+		if err != nil {
+			for _, table := range tables[:i] {
+				table.Close()
+			}
+			return err
+		}
+		tables[i] = table
+	}
+	var (
+		start  = time.Now()
+		logged = time.Now()
+		err    error
+	)
+	for number := first; number <= last && err == nil; number++ {
+		block := blockchain.GetBlockByNumber(number)
+		if block == nil {
+			err = fmt.Errorf("export failed on #%d: not found", number)
+			break
+		}
+		err = exportAnalyticsBlock(blockchain, block, tables[0], tables[1], tables[2], tables[3])
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Exporting analytics tables", "exported", number-first, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	for _, table := range tables {
+		if cerr := table.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	log.Info("Exported analytics tables", "dir", dir, "blocks", last-first+1, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// exportAnalyticsBlock writes the rows of a block into the analytics tables.
+func exportAnalyticsBlock(blockchain *core.BlockChain, block *types.Block, blocks, txs, receipts, logs tabular.Writer) error {
+	var (
+		number = int64(block.NumberU64())
+		hash   = block.Hash().Hex()
+		signer = types.MakeSigner(blockchain.Config(), block.Number())
+	)
+	if err := blocks.Write(
+		number, hash, block.ParentHash().Hex(), int64(block.Time()), hexutil.Encode(block.Coinbase().Bytes()),
+		bigString(block.Difficulty()), int64(block.GasLimit()), int64(block.GasUsed()), bigString(block.BaseFee()),
+		block.Root().Hex(), int64(block.Size()), int64(len(block.Transactions())),
+	); err != nil {
+		return err
+	}
+	blockReceipts := blockchain.GetReceiptsByHash(block.Hash())
+	if len(blockReceipts) != len(block.Transactions()) {
+		return fmt.Errorf("export failed on #%d: have %d receipts for %d transactions", number, len(blockReceipts), len(block.Transactions()))
+	}
+	for i, tx := range block.Transactions() {
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return fmt.Errorf("export failed on #%d: invalid transaction %d: %v", number, i, err)
+		}
+		var to string
+		if tx.To() != nil {
+			to = hexutil.Encode(tx.To().Bytes())
+		}
+		var tip, cap string
+		if tx.Type() == types.DynamicFeeTxType {
+			tip, cap = bigString(tx.GasTipCap()), bigString(tx.GasFeeCap())
+		}
+		if err := txs.Write(
+			number, hash, int64(i), tx.Hash().Hex(), int64(tx.Type()), hexutil.Encode(from.Bytes()), to,
+			int64(tx.Nonce()), bigString(tx.Value()), int64(tx.Gas()), bigString(tx.GasPrice()),
+			tip, cap, hexutil.Encode(tx.Data()),
+		); err != nil {
+			return err
+		}
+		receipt := blockReceipts[i]
+		var contract string
+		if tx.To() == nil {
+			contract = hexutil.Encode(receipt.ContractAddress.Bytes())
+		}
+		if err := receipts.Write(
+			number, tx.Hash().Hex(), int64(i), int64(receipt.Status), int64(receipt.CumulativeGasUsed),
+			int64(receipt.GasUsed), contract, int64(len(receipt.Logs)),
+		); err != nil {
+			return err
+		}
+		for _, entry := range receipt.Logs {
+			var topics [4]string
+			for j := 0; j < len(entry.Topics) && j < len(topics); j++ {
+				topics[j] = entry.Topics[j].Hex()
+			}
+			if err := logs.Write(
+				number, tx.Hash().Hex(), int64(i), int64(entry.Index), hexutil.Encode(entry.Address.Bytes()),
+				topics[0], topics[1], topics[2], topics[3], hexutil.Encode(entry.Data),
+			); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// bigString formats an optional big integer as a decimal string.
+func bigString(v *big.Int) string {
+	if v == nil {
+		return ""
+	}
+	return v.String()
+}
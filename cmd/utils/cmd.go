@@ -19,13 +19,16 @@ package utils
 
 import (
 	"bufio"
+	"bytes"
 	"compress/gzip"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/signal"
 	"runtime"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
@@ -368,6 +371,32 @@ const (
 	OpBatchDel = 1
 )
 
+// AncientExportKind is the export kind of ancient store ranges. The entries of
+// such an export are keyed by AncientExportKey and can only be imported with
+// ImportAncientData, never into the key-value store.
+const AncientExportKind = "ancient"
+
+// AncientExportKey returns the export key of an item of a freezer table, being
+// the table name followed by the big endian item number.
+func AncientExportKey(kind string, number uint64) []byte {
+	key := make([]byte, len(kind)+8)
+	copy(key, kind)
+	binary.BigEndian.PutUint64(key[len(kind):], number)
+	return key
+}
+
+// parseAncientExportKey splits an export key into its table name and number.
+func parseAncientExportKey(key []byte) (string, uint64, error) {
+	if len(key) <= 8 {
+		return "", 0, fmt.Errorf("invalid ancient export key %x", key)
+	}
+	kind := string(key[:len(key)-8])
+	if _, ok := rawdb.FreezerNoSnappy[kind]; !ok {
+		return "", 0, fmt.Errorf("unknown freezer table %q", kind)
+	}
+	return kind, binary.BigEndian.Uint64(key[len(key)-8:]), nil
+}
+
 // ImportLDBData imports a batch of snapshot data into the database
 func ImportLDBData(db ethdb.Database, f string, startIndex int64, interrupt chan struct{}) error {
 	log.Info("Importing leveldb data", "file", f)
@@ -398,6 +427,9 @@ func ImportLDBData(db ethdb.Database, f string, startIndex int64, interrupt chan
 	if header.Version != 0 {
 		return fmt.Errorf("incompatible version %d, (support only 0)", header.Version)
 	}
+	if header.Kind == AncientExportKind {
+		return errors.New("ancient store exports can't be imported into the key-value store")
+	}
 	log.Info("Importing data", "file", f, "type", header.Kind, "data age",
 		common.PrettyDuration(time.Since(time.Unix(int64(header.UnixTime), 0))))
 
@@ -473,6 +505,215 @@ func ImportLDBData(db ethdb.Database, f string, startIndex int64, interrupt chan
 	return nil
 }
 
+// ImportAncientData appends an exported range of the ancient store to the local
+// one. The range must start exactly at the current end of the ancient store and
+// contain the items of every freezer table for each block. Along with the items,
+// the hash to number mappings and transaction lookups of the blocks are written
+// to the key-value store, and the header and fast block heads are moved onto
+// the last block if they are behind, the same as for fast synced blocks.
+//
+// As the imported blocks come without state, the head block must be either the
+// genesis or beyond the imported range, otherwise the ancient store would be
+// truncated back to it on the next startup. Every block must link up to the
+// last ancient block or the previously imported one, blocks of another chain
+// are rejected before anything is appended.
+func ImportAncientData(db ethdb.Database, f string, interrupt chan struct{}) error {
+	log.Info("Importing ancient data", "file", f)
+
+	// Open the file handle and potentially unwrap the gzip stream
+	fh, err := os.Open(f)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	var reader io.Reader = bufio.NewReader(fh)
+	if strings.HasSuffix(f, ".gz") {
+		if reader, err = gzip.NewReader(reader); err != nil {
+			return err
+		}
+	}
+	stream := rlp.NewStream(reader, 0)
+
+	// Read the header
+	var header exportHeader
+	if err := stream.Decode(&header); err != nil {
+		return fmt.Errorf("could not decode header: %v", err)
+	}
+	if header.Magic != exportMagic {
+		return errors.New("incompatible data, wrong magic")
+	}
+	if header.Version != 0 {
+		return fmt.Errorf("incompatible version %d, (support only 0)", header.Version)
+	}
+	if header.Kind != AncientExportKind {
+		return fmt.Errorf("incompatible data kind %q, want %q", header.Kind, AncientExportKind)
+	}
+	next, err := db.Ancients()
+	if err != nil {
+		return err
+	}
+	var limit uint64 // Last block that may be imported, zero if unlimited
+	if number := rawdb.ReadHeaderNumber(db, rawdb.ReadHeadBlockHash(db)); number != nil {
+		limit = *number
+	}
+	var parent common.Hash // Hash of the block preceding the next imported one
+	if next > 0 {
+		if parent = rawdb.ReadCanonicalHash(db, next-1); parent == (common.Hash{}) {
+			return fmt.Errorf("ancient block %d missing", next-1)
+		}
+	}
+	// Gather the items block by block, appending them to the freezer in batches
+	var (
+		start  = time.Now()
+		logged = time.Now()
+		first  = next
+
+		pending []map[string][]byte
+		current map[string][]byte
+		number  uint64
+	)
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		_, err := db.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+			for i, items := range pending {
+				for kind, item := range items {
+					if err := op.AppendRaw(kind, next+uint64(i), item); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		// Index the blocks appended, reading them back from the freezer
+		batch := db.NewBatch()
+		for n := next; n < next+uint64(len(pending)); n++ {
+			hash := rawdb.ReadCanonicalHash(db, n)
+			block := rawdb.ReadBlock(db, hash, n)
+			if block == nil {
+				return fmt.Errorf("imported ancient block %d unreadable", n)
+			}
+			rawdb.WriteHeaderNumber(batch, hash, n)
+			rawdb.WriteTxLookupEntriesByBlock(batch, block)
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		next += uint64(len(pending))
+		pending = pending[:0]
+		return writeAncientHeads(db, next-1)
+	}
+	finish := func() error {
+		if current == nil {
+			return nil
+		}
+		if len(current) != len(rawdb.FreezerNoSnappy) {
+			return fmt.Errorf("incomplete ancient data for block %d: %d tables out of %d", number, len(current), len(rawdb.FreezerNoSnappy))
+		}
+		header := new(types.Header)
+		if err := rlp.DecodeBytes(current[rawdb.FreezerHeaderTable], header); err != nil {
+			return fmt.Errorf("invalid header of ancient block %d: %v", number, err)
+		}
+		hash := header.Hash()
+		if header.Number.Uint64() != number || !bytes.Equal(current[rawdb.FreezerHashTable], hash.Bytes()) {
+			return fmt.Errorf("inconsistent ancient data for block %d", number)
+		}
+		if number > 0 && header.ParentHash != parent {
+			return fmt.Errorf("ancient block %d not on the local chain: parent %x, want %x", number, header.ParentHash, parent)
+		}
+		parent = hash
+		pending = append(pending, current)
+		current = nil
+		return nil
+	}
+	for {
+		// Read the next entry
+		var (
+			op       byte
+			key, val []byte
+		)
+		if err := stream.Decode(&op); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		if err := stream.Decode(&key); err != nil {
+			return err
+		}
+		if err := stream.Decode(&val); err != nil {
+			return err
+		}
+		if op != OpBatchAdd {
+			return fmt.Errorf("unexpected op %d in ancient data", op)
+		}
+		kind, n, err := parseAncientExportKey(key)
+		if err != nil {
+			return err
+		}
+		if current == nil || n != number {
+			if err := finish(); err != nil {
+				return err
+			}
+			if want := next + uint64(len(pending)); n != want {
+				return fmt.Errorf("non contiguous ancient data: have block %d, want %d", n, want)
+			}
+			if limit > 0 && n > limit {
+				return fmt.Errorf("ancient data beyond the head block %d", limit)
+			}
+			current, number = make(map[string][]byte), n
+		}
+		current[kind] = val
+
+		if len(pending) >= 1000 {
+			if err := flush(); err != nil {
+				return err
+			}
+			// Check interruption emitted by ctrl+c
+			select {
+			case <-interrupt:
+				log.Info("Ancient data import interrupted", "file", f, "blocks", next-first, "elapsed", common.PrettyDuration(time.Since(start)))
+				return nil
+			default:
+			}
+			if time.Since(logged) > 8*time.Second {
+				log.Info("Importing ancient data", "file", f, "blocks", next-first, "elapsed", common.PrettyDuration(time.Since(start)))
+				logged = time.Now()
+			}
+		}
+	}
+	if err := finish(); err != nil {
+		return err
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	log.Info("Imported ancient data", "file", f, "from", first, "blocks", next-first,
+		"elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// writeAncientHeads moves the head header and the head fast block onto the last
+// imported ancient block, if they are behind it.
+func writeAncientHeads(db ethdb.Database, number uint64) error {
+	hash := rawdb.ReadCanonicalHash(db, number)
+	if hash == (common.Hash{}) {
+		return fmt.Errorf("imported ancient block %d missing", number)
+	}
+	if head := rawdb.ReadHeaderNumber(db, rawdb.ReadHeadHeaderHash(db)); head == nil || *head < number {
+		rawdb.WriteHeadHeaderHash(db, hash)
+	}
+	if head := rawdb.ReadHeaderNumber(db, rawdb.ReadHeadFastBlockHash(db)); head == nil || *head < number {
+		rawdb.WriteHeadFastBlockHash(db, hash)
+	}
+	return nil
+}
+
 // ChainDataIterator is an interface wraps all necessary functions to iterate
 // the exporting chain data.
 type ChainDataIterator interface {
@@ -485,6 +726,55 @@ type ChainDataIterator interface {
 	Release()
 }
 
+// AncientIterator walks a range of the ancient store, emitting the items of all
+// the freezer tables of a block before moving on to the next one.
+type AncientIterator struct {
+	db    ethdb.AncientReader
+	kinds []string // Freezer tables to export, in a fixed order
+	next  uint64   // Next block to export
+	end   uint64   // Block to stop at (exclusive)
+	index int      // Next table to export of the current block
+	err   error    // Error which interrupted the iteration
+}
+
+// NewAncientIterator creates an iterator over the blocks [start, end) of the
+// ancient store.
+func NewAncientIterator(db ethdb.AncientReader, start, end uint64) *AncientIterator {
+	var kinds []string
+	for kind := range rawdb.FreezerNoSnappy {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return &AncientIterator{db: db, kinds: kinds, next: start, end: end}
+}
+
+// Next implements ChainDataIterator, returning the next freezer item keyed by
+// AncientExportKey.
+func (iter *AncientIterator) Next() (byte, []byte, []byte, bool) {
+	if iter.err != nil || iter.next >= iter.end {
+		return 0, nil, nil, false
+	}
+	kind := iter.kinds[iter.index]
+	blob, err := iter.db.Ancient(kind, iter.next)
+	if err != nil {
+		iter.err = fmt.Errorf("failed to read %s of block %d: %v", kind, iter.next, err)
+		return 0, nil, nil, false
+	}
+	key := AncientExportKey(kind, iter.next)
+	if iter.index++; iter.index == len(iter.kinds) {
+		iter.index, iter.next = 0, iter.next+1
+	}
+	return OpBatchAdd, key, blob, true
+}
+
+// Release implements ChainDataIterator.
+func (iter *AncientIterator) Release() {}
+
+// Err returns the error which stopped the iteration early, if any.
+func (iter *AncientIterator) Err() error {
+	return iter.err
+}
+
 // ExportChaindata exports the given data type (truncating any data already present)
 // in the file. If the suffix is 'gz', gzip compression is used.
 func ExportChaindata(fn string, kind string, iter ChainDataIterator, interrupt chan struct{}) error {
@@ -17,13 +17,23 @@
 package utils
 
 import (
+	"bytes"
 	"fmt"
+	"math/big"
 	"os"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/consensus/ethash"
+	"github.com/scroll-tech/go-ethereum/core"
 	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/core/vm"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/scroll-tech/go-ethereum/ethdb"
+	"github.com/scroll-tech/go-ethereum/params"
 	"github.com/scroll-tech/go-ethereum/rlp"
 )
 
@@ -196,3 +206,176 @@ func TestImportFutureFormat(t *testing.T) {
 		t.Fatalf("wrong error: %v", err)
 	}
 }
+
+// TestAncientExport tests that a range of the ancient store can be exported and
+// appended to another one.
+func TestAncientExport(t *testing.T) {
+	f := fmt.Sprintf("%v/tempdump-ancient.gz", os.TempDir())
+	defer func() {
+		os.Remove(f)
+	}()
+	newFreezerDB := func() ethdb.Database {
+		db, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+		if err != nil {
+			t.Fatalf("failed to create database with ancient backend: %v", err)
+		}
+		return db
+	}
+	// makeChain creates a chain of empty blocks, linked by their parent hashes
+	makeChain := func(extra string) ([]*types.Block, []types.Receipts) {
+		var (
+			blocks   []*types.Block
+			receipts []types.Receipts
+			parent   common.Hash
+		)
+		for i := 0; i < 10; i++ {
+			block := types.NewBlockWithHeader(&types.Header{
+				ParentHash: parent,
+				Number:     big.NewInt(int64(i)),
+				Difficulty: big.NewInt(1),
+				Extra:      []byte(extra),
+			})
+			blocks, receipts, parent = append(blocks, block), append(receipts, nil), block.Hash()
+		}
+		return blocks, receipts
+	}
+	blocks, receipts := makeChain("test block")
+
+	src := newFreezerDB()
+	defer src.Close()
+	if _, err := rawdb.WriteAncientBlocks(src, blocks, receipts, big.NewInt(1)); err != nil {
+		t.Fatalf("failed to write ancient blocks: %v", err)
+	}
+	dst := newFreezerDB()
+	defer dst.Close()
+	if _, err := rawdb.WriteAncientBlocks(dst, blocks[:4], receipts[:4], big.NewInt(1)); err != nil {
+		t.Fatalf("failed to write ancient blocks: %v", err)
+	}
+	// Ranges not continuing the destination store should be rejected
+	if err := ExportChaindata(f, AncientExportKind, NewAncientIterator(src, 5, 10), make(chan struct{})); err != nil {
+		t.Fatal(err)
+	}
+	if err := ImportAncientData(dst, f, make(chan struct{})); err == nil {
+		t.Fatal("Expected error importing non contiguous range, got none")
+	}
+	if err := ImportLDBData(dst, f, 0, make(chan struct{})); err == nil {
+		t.Fatal("Expected error importing ancient data into key-value store, got none")
+	}
+	// Ranges of another chain should be rejected without appending anything
+	foreign := newFreezerDB()
+	defer foreign.Close()
+	fblocks, freceipts := makeChain("foreign block")
+	if _, err := rawdb.WriteAncientBlocks(foreign, fblocks, freceipts, big.NewInt(1)); err != nil {
+		t.Fatalf("failed to write ancient blocks: %v", err)
+	}
+	if err := ExportChaindata(f, AncientExportKind, NewAncientIterator(foreign, 4, 10), make(chan struct{})); err != nil {
+		t.Fatal(err)
+	}
+	if err := ImportAncientData(dst, f, make(chan struct{})); err == nil || !strings.Contains(err.Error(), "not on the local chain") {
+		t.Fatalf("Expected error importing foreign range, got %v", err)
+	}
+	if frozen, _ := dst.Ancients(); frozen != 4 {
+		t.Fatalf("ancient count mismatch after rejected import: have %d, want %d", frozen, 4)
+	}
+	// Append the missing range and check everything was copied over
+	if err := ExportChaindata(f, AncientExportKind, NewAncientIterator(src, 4, 10), make(chan struct{})); err != nil {
+		t.Fatal(err)
+	}
+	if err := ImportAncientData(dst, f, make(chan struct{})); err != nil {
+		t.Fatal(err)
+	}
+	if frozen, _ := dst.Ancients(); frozen != 10 {
+		t.Fatalf("ancient count mismatch: have %d, want %d", frozen, 10)
+	}
+	for kind := range rawdb.FreezerNoSnappy {
+		for i := uint64(0); i < 10; i++ {
+			want, _ := src.Ancient(kind, i)
+			if have, err := dst.Ancient(kind, i); err != nil || !bytes.Equal(have, want) {
+				t.Fatalf("%s #%d mismatch: have %x (%v), want %x", kind, i, have, err, want)
+			}
+		}
+	}
+}
+
+// Tests that imported ancient blocks are reachable after reopening the database,
+// without the ancient store being truncated back to the head block.
+func TestAncientImportReopen(t *testing.T) {
+	f := fmt.Sprintf("%v/tempdump-ancient-reopen.gz", os.TempDir())
+	defer os.Remove(f)
+
+	var (
+		key, _  = crypto.GenerateKey()
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &core.Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   core.GenesisAlloc{address: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	src, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database with ancient backend: %v", err)
+	}
+	defer src.Close()
+
+	genesis := gspec.MustCommit(src)
+	blocks, receipts := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), src, 10, func(i int, block *core.BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x01}, big.NewInt(1), params.TxGas, block.BaseFee(), nil), signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block.AddTx(tx)
+	})
+	if _, err := rawdb.WriteAncientBlocks(src, append([]*types.Block{genesis}, blocks...), append([]types.Receipts{nil}, receipts...), genesis.Difficulty()); err != nil {
+		t.Fatalf("failed to write ancient blocks: %v", err)
+	}
+	if err := ExportChaindata(f, AncientExportKind, NewAncientIterator(src, 0, 11), make(chan struct{})); err != nil {
+		t.Fatal(err)
+	}
+	// Import into a fresh node and reopen it
+	var (
+		datadir = t.TempDir()
+		ancient = t.TempDir()
+	)
+	dst, err := rawdb.NewLevelDBDatabaseWithFreezer(datadir, 16, 16, ancient, "", false)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	gspec.MustCommit(dst)
+	if err := ImportAncientData(dst, f, make(chan struct{})); err != nil {
+		t.Fatal(err)
+	}
+	dst.Close()
+
+	dst, err = rawdb.NewLevelDBDatabaseWithFreezer(datadir, 16, 16, ancient, "", false)
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	defer dst.Close()
+
+	chain, err := core.NewBlockChain(dst, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if frozen, _ := dst.Ancients(); frozen != 11 {
+		t.Fatalf("ancient count mismatch: have %d, want %d", frozen, 11)
+	}
+	if head := chain.CurrentFastBlock(); head.Hash() != blocks[9].Hash() {
+		t.Fatalf("fast block head mismatch: have %d, want %d", head.NumberU64(), 10)
+	}
+	for _, block := range blocks {
+		if have := chain.GetBlockByHash(block.Hash()); have == nil {
+			t.Fatalf("block %d unreachable by hash", block.NumberU64())
+		}
+		if have := chain.GetBlockByNumber(block.NumberU64()); have == nil || have.Hash() != block.Hash() {
+			t.Fatalf("block %d unreachable by number", block.NumberU64())
+		}
+		tx := block.Transactions()[0]
+		if lookup := chain.GetTransactionLookup(tx.Hash()); lookup == nil || lookup.BlockIndex != block.NumberU64() {
+			t.Fatalf("block %d: transaction lookup missing", block.NumberU64())
+		}
+	}
+}
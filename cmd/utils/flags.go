@@ -24,6 +24,7 @@ import (
 	"io/ioutil"
 	"math"
 	"math/big"
+	"net"
 	"os"
 	"path/filepath"
 	godebug "runtime/debug"
@@ -55,7 +56,9 @@ import (
 	"github.com/scroll-tech/go-ethereum/eth/tracers"
 	"github.com/scroll-tech/go-ethereum/ethdb"
 	"github.com/scroll-tech/go-ethereum/ethstats"
+	"github.com/scroll-tech/go-ethereum/firehose"
 	"github.com/scroll-tech/go-ethereum/graphql"
+	"github.com/scroll-tech/go-ethereum/grpcapi"
 	"github.com/scroll-tech/go-ethereum/internal/ethapi"
 	"github.com/scroll-tech/go-ethereum/internal/flags"
 	"github.com/scroll-tech/go-ethereum/les"
@@ -70,6 +73,8 @@ import (
 	"github.com/scroll-tech/go-ethereum/p2p/nat"
 	"github.com/scroll-tech/go-ethereum/p2p/netutil"
 	"github.com/scroll-tech/go-ethereum/params"
+	"github.com/scroll-tech/go-ethereum/rootcheck"
+	"github.com/scroll-tech/go-ethereum/watchdog"
 )
 
 func init() {
@@ -118,10 +123,18 @@ var (
 		Name:  "datadir.ancient",
 		Usage: "Data directory for ancient chain segments (default = inside chaindata)",
 	}
+	AncientRemoteFlag = cli.StringFlag{
+		Name:  "datadir.ancient.remote",
+		Usage: "Comma separated table=URL pairs of object storages (s3://bucket/prefix, gs://bucket/prefix) to offload ancient data files to",
+	}
 	MinFreeDiskSpaceFlag = DirectoryFlag{
 		Name:  "datadir.minfreedisk",
 		Usage: "Minimum free disk space in MB, once reached triggers auto shut down (default = --cache.gc converted to MB, 0 = disabled)",
 	}
+	DataDirReadOnlyFlag = cli.BoolFlag{
+		Name:  "datadir.readonly",
+		Usage: "Open the data directory read-only, serving RPC requests without syncing, mining or accepting transactions",
+	}
 	KeyStoreDirFlag = DirectoryFlag{
 		Name:  "keystore",
 		Usage: "Directory for the keystore (default = inside the datadir)",
@@ -173,6 +186,15 @@ var (
 		Usage: "Initial block gas limit",
 		Value: 11500000,
 	}
+	DeveloperZktrieFlag = cli.BoolFlag{
+		Name:  "dev.zktrie",
+		Usage: "Store the developer chain state in the zk trie",
+	}
+	DeveloperMinBaseFeeFlag = BigFlag{
+		Name:  "dev.minbasefee",
+		Usage: "Minimum base fee of the developer chain, enabling the rollup fee model",
+		Value: new(big.Int),
+	}
 	IdentityFlag = cli.StringFlag{
 		Name:  "identity",
 		Usage: "Custom node name",
@@ -186,6 +208,14 @@ var (
 		Name:  "exitwhensynced",
 		Usage: "Exits after block synchronisation completes",
 	}
+	RecoveryParallelFlag = cli.BoolFlag{
+		Name:  "recovery.parallel",
+		Usage: "Regenerate the state lost in an unclean shutdown using the parallel import pipeline",
+	}
+	GracefulShutdownFlag = cli.DurationFlag{
+		Name:  "shutdown.graceful",
+		Usage: "Shuts down gracefully, waiting up to the given time for the block being sealed to be finished (0 = disabled)",
+	}
 	IterativeOutputFlag = cli.BoolTFlag{
 		Name:  "iterative",
 		Usage: "Print streaming JSON iteratively, delimited by newlines",
@@ -218,6 +248,10 @@ var (
 		Usage: `Blockchain sync mode ("fast", "full", "snap" or "light")`,
 		Value: &defaultSyncMode,
 	}
+	RoleFlag = cli.StringFlag{
+		Name:  "role",
+		Usage: `Node role profile applying coherent defaults for flags and config settings left unset ("sequencer", "follower", "archive" or "rpc")`,
+	}
 	GCModeFlag = cli.StringFlag{
 		Name:  "gcmode",
 		Usage: `Blockchain garbage collection mode ("full", "archive")`,
@@ -232,13 +266,42 @@ var (
 		Usage: "Number of recent blocks to maintain transactions index for (default = about one year, 0 = entire chain)",
 		Value: ethconfig.Defaults.TxLookupLimit,
 	}
+	SenderNonceIndexFlag = cli.BoolFlag{
+		Name:  "txlookup.sendernonce",
+		Usage: "Index canonical transactions by sender and nonce (served by eth_getTransactionBySenderAndNonce)",
+	}
+	AddressActivityFlag = cli.BoolFlag{
+		Name:  "txlookup.activity",
+		Usage: "Index transactions by their sender, recipient and internal value transfer parties (served by eth_getTransactionsByAddress)",
+	}
+	TokenTransfersFlag = cli.BoolFlag{
+		Name:  "txlookup.tokens",
+		Usage: "Index ERC-20 and ERC-721 transfer logs by token and holder (served by scroll_getTokenTransfers)",
+	}
+	EventLogIndexFlag = cli.BoolFlag{
+		Name:  "txlookup.logs",
+		Usage: "Index logs by emitting contract and event signature (served by scroll_getLogsByEvent)",
+	}
+	BloomSectionSizeFlag = cli.Uint64Flag{
+		Name:  "bloom.sectionsize",
+		Usage: "Number of blocks per bloom bits section of the log index (4096 = default, 512 = fine-grained for short block times)",
+		Value: ethconfig.Defaults.BloomSectionSize,
+	}
 	LightKDFFlag = cli.BoolFlag{
 		Name:  "lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
 	}
 	WhitelistFlag = cli.StringFlag{
 		Name:  "whitelist",
-		Usage: "Comma separated block number-to-hash mappings to enforce (<number>=<hash>)",
+		Usage: "Comma separated block number-to-hash mappings to enforce (<number>=<hash>) (deprecated, use 'geth checkpoint import')",
+	}
+	CheckpointSignerFlag = cli.StringFlag{
+		Name:  "checkpoint.signer",
+		Usage: "Address of the signer of imported header checkpoints (default = network checkpoint signer)",
+	}
+	CheckpointKeyFlag = cli.StringFlag{
+		Name:  "checkpoint.key",
+		Usage: "Private key file to sign exported header checkpoints with",
 	}
 	BloomFilterSizeFlag = cli.Uint64Flag{
 		Name:  "bloomfilter.size",
@@ -249,6 +312,10 @@ var (
 		Name:  "override.arrowglacier",
 		Usage: "Manually specify Arrow Glacier fork-block, overriding the bundled setting",
 	}
+	OverrideStateSchemeFlag = cli.BoolFlag{
+		Name:  "override.statescheme",
+		Usage: "Start even if the state trie scheme of the database mismatches the chain config (applies to the current run only)",
+	}
 	// Light server and client settings
 	LightServeFlag = cli.IntFlag{
 		Name:  "light.serve",
@@ -359,6 +426,11 @@ var (
 		Usage: "Price bump percentage to replace an already existing transaction",
 		Value: ethconfig.Defaults.TxPool.PriceBump,
 	}
+	TxPoolReplaceIntervalFlag = cli.DurationFlag{
+		Name:  "txpool.replaceinterval",
+		Usage: "Minimum time between two transaction replacements of the same remote account (0 = unlimited)",
+		Value: ethconfig.Defaults.TxPool.ReplaceInterval,
+	}
 	TxPoolAccountSlotsFlag = cli.Uint64Flag{
 		Name:  "txpool.accountslots",
 		Usage: "Minimum number of executable transaction slots guaranteed per account",
@@ -384,6 +456,29 @@ var (
 		Usage: "Maximum amount of time non-executable transaction are queued",
 		Value: ethconfig.Defaults.TxPool.Lifetime,
 	}
+	TxPoolMaxLifetimeFlag = cli.DurationFlag{
+		Name:  "txpool.maxlifetime",
+		Usage: "Maximum amount of time non-local transactions stay in the pool (0 = unlimited)",
+		Value: ethconfig.Defaults.TxPool.MaxLifetime,
+	}
+	TxPoolGapTolerantFlag = cli.StringFlag{
+		Name:  "txpool.gaptolerant",
+		Usage: "Comma separated contract wallet accounts whose out-of-order transactions are held until nonce gaps fill",
+	}
+	TxPoolGapToleranceFlag = cli.Uint64Flag{
+		Name:  "txpool.gaptolerance",
+		Usage: "Maximum nonce gap held for gap tolerant accounts",
+		Value: ethconfig.Defaults.TxPool.GapTolerance,
+	}
+	TxForwardFlag = cli.StringFlag{
+		Name:  "txforward.endpoints",
+		Usage: "Comma separated RPC endpoints of the sequencers to relay transactions submitted over RPC to",
+	}
+	TxForwardRateFlag = cli.Float64Flag{
+		Name:  "txforward.rate",
+		Usage: "Maximum number of transactions relayed per second to each sequencer (0 = unlimited)",
+		Value: ethconfig.Defaults.TxForwardRate,
+	}
 	// Performance tuning settings
 	CacheFlag = cli.IntFlag{
 		Name:  "cache",
@@ -395,6 +490,25 @@ var (
 		Usage: "Percentage of cache memory allowance to use for database io",
 		Value: 50,
 	}
+	ZktrieDBEngineFlag = cli.StringFlag{
+		Name:  "db.zktrie.engine",
+		Usage: "Engine of a dedicated zk trie node database (\"leveldb\"), trie nodes are kept in the chain database if unset",
+	}
+	ZktrieDBCacheFlag = cli.IntFlag{
+		Name:  "db.zktrie.cache",
+		Usage: "Megabytes of memory allocated to the dedicated zk trie node database",
+		Value: ethconfig.Defaults.ZktrieDatabaseCache,
+	}
+	DBCompactionIdleFlag = cli.DurationFlag{
+		Name:  "db.compaction.idle",
+		Usage: "Time without block sealing or witness generation required before running scheduled manual database compactions",
+		Value: ethconfig.Defaults.DatabaseCompactionIdle,
+	}
+	DBCompactionIntervalFlag = cli.DurationFlag{
+		Name:  "db.compaction.interval",
+		Usage: "Time between proactive manual database compaction sweeps run in idle windows (0 = disabled)",
+		Value: ethconfig.Defaults.DatabaseCompactionInterval,
+	}
 	CacheTrieFlag = cli.IntFlag{
 		Name:  "cache.trie",
 		Usage: "Percentage of cache memory allowance to use for trie caching (default = 15% full mode, 30% archive mode)",
@@ -420,10 +534,24 @@ var (
 		Usage: "Percentage of cache memory allowance to use for snapshot caching (default = 10% full mode, 20% archive mode)",
 		Value: 10,
 	}
+	CacheCodeFlag = cli.IntFlag{
+		Name:  "cache.code",
+		Usage: "Megabytes of memory allocated to the contract code cache shared by block import, RPC and tracing",
+		Value: ethconfig.Defaults.CodeCache,
+	}
+	CachePrefetchWorkersFlag = cli.IntFlag{
+		Name:  "cache.prefetch.workers",
+		Usage: "Number of goroutines speculatively executing transactions in parallel to warm zk trie caches (0 = disabled)",
+		Value: ethconfig.Defaults.PrefetchWorkers,
+	}
 	CacheNoPrefetchFlag = cli.BoolFlag{
 		Name:  "cache.noprefetch",
 		Usage: "Disable heuristic state prefetch during block import (less CPU and disk IO, more time waiting for data)",
 	}
+	ImportRootCheckpointFlag = cli.Uint64Flag{
+		Name:  "import.rootcheckpoint",
+		Usage: "Number of blocks between state root verifications during chain import, the last block of every batch is always verified (0 = every block)",
+	}
 	CachePreimagesFlag = cli.BoolFlag{
 		Name:  "cache.preimages",
 		Usage: "Enable recording the SHA3/keccak preimages of trie keys",
@@ -474,6 +602,18 @@ var (
 		Name:  "miner.noverify",
 		Usage: "Disable remote sealing verification",
 	}
+	MinerMaxWitnessNodesFlag = cli.IntFlag{
+		Name:  "miner.maxwitnessnodes",
+		Usage: "Maximum estimated witness trie nodes of mined blocks (0 = unlimited)",
+	}
+	MinerOrderingAuditFlag = cli.BoolFlag{
+		Name:  "miner.orderingaudit",
+		Usage: "Persist the candidate transactions and ordering decisions of the sealed blocks (debug_getOrderingAudit)",
+	}
+	MinerPreconfirmKeyFlag = cli.StringFlag{
+		Name:  "miner.preconfirmkey",
+		Usage: "Private key file signing preconfirmations of the pending transactions, enables scroll_getPreconfirmation",
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -494,6 +634,10 @@ var (
 		Name:  "vmdebug",
 		Usage: "Record information useful for VM and contract debugging",
 	}
+	VMFuseInstructionsFlag = cli.BoolFlag{
+		Name:  "vm.fuse",
+		Usage: "Execute common opcode sequences as single instructions (ignored while tracing)",
+	}
 	InsecureUnlockAllowedFlag = cli.BoolFlag{
 		Name:  "allow-insecure-unlock",
 		Usage: "Allow insecure account unlocking when account-related RPCs are exposed by http",
@@ -508,6 +652,45 @@ var (
 		Usage: "Sets a timeout used for eth_call (0=infinite)",
 		Value: ethconfig.Defaults.RPCEVMTimeout,
 	}
+	RPCCallCacheFlag = cli.IntFlag{
+		Name:  "rpc.callcache",
+		Usage: "Number of eth_call results memoized by block and call parameters (0=disabled)",
+		Value: ethconfig.Defaults.RPCCallCacheSize,
+	}
+	RPCCallCacheTTLFlag = cli.DurationFlag{
+		Name:  "rpc.callcache.ttl",
+		Usage: "Time an eth_call result stays memoized",
+		Value: ethconfig.Defaults.RPCCallCacheTTL,
+	}
+	RPCMaxReexecFlag = cli.Uint64Flag{
+		Name:  "rpc.max-reexec",
+		Usage: "Maximum number of blocks re-executed to regenerate historical state for tracing",
+		Value: ethconfig.Defaults.RPCMaxReexec,
+	}
+	RPCLogQueryMaxBlocksFlag = cli.Uint64Flag{
+		Name:  "rpc.logs.maxblocks",
+		Usage: "Sets a cap on the number of blocks a single eth_getLogs query may span (0=infinite)",
+		Value: ethconfig.Defaults.RPCLogQueryMaxBlocks,
+	}
+	RPCLogQueryMaxResultsFlag = cli.IntFlag{
+		Name:  "rpc.logs.maxresults",
+		Usage: "Sets a cap on the number of logs a single eth_getLogs query may return (0=infinite)",
+		Value: ethconfig.Defaults.RPCLogQueryMaxResults,
+	}
+	RPCBatchItemLimitFlag = cli.IntFlag{
+		Name:  "rpc.batch.items",
+		Usage: "Maximum number of calls in a JSON-RPC batch served over HTTP or WS (0=infinite)",
+		Value: node.DefaultConfig.RPCBatchItemLimit,
+	}
+	RPCBatchCostLimitFlag = cli.Uint64Flag{
+		Name:  "rpc.batch.cost",
+		Usage: "Maximum sum of the method weights of a JSON-RPC batch served over HTTP or WS (0=infinite)",
+		Value: node.DefaultConfig.RPCBatchCostLimit,
+	}
+	RPCAPIKeysFlag = cli.StringFlag{
+		Name:  "rpc.apikeys",
+		Usage: "JSON file of the API keys and quota tiers required to call the HTTP and WS RPC endpoints",
+	}
 	RPCGlobalTxFeeCapFlag = cli.Float64Flag{
 		Name:  "rpc.txfeecap",
 		Usage: "Sets a cap on transaction fee (in ether) that can be sent via the RPC APIs (0 = no cap)",
@@ -518,6 +701,32 @@ var (
 		Name:  "ethstats",
 		Usage: "Reporting URL of a ethstats service (nodename:secret@host:port)",
 	}
+	RootCheckURLsFlag = cli.StringFlag{
+		Name:  "rootcheck",
+		Usage: "Comma separated list of reference RPC endpoints to cross-check the state roots of imported blocks against",
+	}
+	FirehoseURLFlag = cli.StringFlag{
+		Name:  "firehose.url",
+		Usage: "Message broker to publish new heads, receipts and reorgs to (nats://host:port or kafka+http://restproxy:port)",
+	}
+	FirehoseTopicFlag = cli.StringFlag{
+		Name:  "firehose.topic",
+		Usage: "Prefix of the firehose topics, suffixed with .heads, .receipts and .reorgs",
+		Value: firehose.DefaultConfig.Topic,
+	}
+	FirehoseFormatFlag = cli.StringFlag{
+		Name:  "firehose.format",
+		Usage: "Encoding of the firehose messages (json or protobuf)",
+		Value: firehose.DefaultConfig.Format,
+	}
+	WatchdogDepthFlag = cli.Uint64Flag{
+		Name:  "watchdog.depth",
+		Usage: "Number of dropped blocks from which a reorg raises an alert and re-queues its transactions (0 = disabled)",
+	}
+	WatchdogHaltFlag = cli.BoolFlag{
+		Name:  "watchdog.halt",
+		Usage: "Halt sequencing after a deep reorg until resumed with watchdog_resume",
+	}
 	FakePoWFlag = cli.BoolFlag{
 		Name:  "fakepow",
 		Usage: "Disables proof-of-work verification",
@@ -583,6 +792,20 @@ var (
 		Usage: "Comma separated list of virtual hostnames from which to accept requests (server enforced). Accepts '*' wildcard.",
 		Value: strings.Join(node.DefaultConfig.GraphQLVirtualHosts, ","),
 	}
+	GRPCEnabledFlag = cli.BoolFlag{
+		Name:  "grpc",
+		Usage: "Enable the gRPC gateway to the chain and state read APIs",
+	}
+	GRPCListenAddrFlag = cli.StringFlag{
+		Name:  "grpc.addr",
+		Usage: "gRPC gateway listening interface",
+		Value: "localhost",
+	}
+	GRPCPortFlag = cli.IntFlag{
+		Name:  "grpc.port",
+		Usage: "gRPC gateway listening port",
+		Value: 8549,
+	}
 	WSEnabledFlag = cli.BoolFlag{
 		Name:  "ws",
 		Usage: "Enable the WS-RPC server",
@@ -612,6 +835,19 @@ var (
 		Usage: "HTTP path prefix on which JSON-RPC is served. Use '/' to serve on all paths.",
 		Value: "",
 	}
+	WSMaxSubscriptionsFlag = cli.IntFlag{
+		Name:  "ws.subscriptions",
+		Usage: "Maximum number of active subscriptions per WS-RPC connection (0 = unlimited)",
+	}
+	WSNotificationBufferFlag = cli.IntFlag{
+		Name:  "ws.notifybuffer",
+		Usage: "Maximum number of queued notifications per WS-RPC subscription (0 = unbuffered, slow consumers block notifying)",
+	}
+	WSOverflowPolicyFlag = cli.StringFlag{
+		Name:  "ws.overflow",
+		Usage: `Handling of WS-RPC consumers overflowing their notification buffer ("drop" oldest notifications or "disconnect")`,
+		Value: "drop",
+	}
 	ExecFlag = cli.StringFlag{
 		Name:  "exec",
 		Usage: "Execute JavaScript statement",
@@ -1006,6 +1242,23 @@ func setWS(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(WSPathPrefixFlag.Name) {
 		cfg.WSPathPrefix = ctx.GlobalString(WSPathPrefixFlag.Name)
 	}
+
+	if ctx.GlobalIsSet(WSMaxSubscriptionsFlag.Name) {
+		cfg.WSMaxSubscriptions = ctx.GlobalInt(WSMaxSubscriptionsFlag.Name)
+	}
+	if ctx.GlobalIsSet(WSNotificationBufferFlag.Name) {
+		cfg.WSNotificationBuffer = ctx.GlobalInt(WSNotificationBufferFlag.Name)
+	}
+	if ctx.GlobalIsSet(WSOverflowPolicyFlag.Name) {
+		switch policy := ctx.GlobalString(WSOverflowPolicyFlag.Name); policy {
+		case "drop":
+			cfg.WSDisconnectSlow = false
+		case "disconnect":
+			cfg.WSDisconnectSlow = true
+		default:
+			Fatalf("Invalid --%s policy %q, must be drop or disconnect", WSOverflowPolicyFlag.Name, policy)
+		}
+	}
 }
 
 // setIPC creates an IPC path configuration from the set command line flags,
@@ -1218,6 +1471,37 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	setDataDir(ctx, cfg)
 	setSmartCard(ctx, cfg)
 
+	if ctx.GlobalIsSet(RPCBatchItemLimitFlag.Name) {
+		cfg.RPCBatchItemLimit = ctx.GlobalInt(RPCBatchItemLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCBatchCostLimitFlag.Name) {
+		cfg.RPCBatchCostLimit = ctx.GlobalUint64(RPCBatchCostLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCAPIKeysFlag.Name) {
+		cfg.RPCAPIKeys = ctx.GlobalString(RPCAPIKeysFlag.Name)
+	}
+	if ctx.GlobalIsSet(DataDirReadOnlyFlag.Name) {
+		cfg.ReadOnly = ctx.GlobalBool(DataDirReadOnlyFlag.Name)
+	}
+	if ctx.GlobalIsSet(AncientRemoteFlag.Name) {
+		cfg.AncientRemote = make(map[string]string)
+		for _, entry := range strings.Split(ctx.GlobalString(AncientRemoteFlag.Name), ",") {
+			parts := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+			if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				Fatalf("Invalid ancient remote store %q, expected table=URL", entry)
+			}
+			cfg.AncientRemote[parts[0]] = parts[1]
+		}
+	}
+	if cfg.ReadOnly {
+		// A read-only node can't follow the chain, don't join the network
+		cfg.P2P.MaxPeers = 0
+		cfg.P2P.ListenAddr = ""
+		cfg.P2P.NoDial = true
+		cfg.P2P.NoDiscovery = true
+		cfg.P2P.DiscoveryV5 = false
+	}
+
 	if ctx.GlobalIsSet(ExternalSignerFlag.Name) {
 		cfg.ExternalSigner = ctx.GlobalString(ExternalSignerFlag.Name)
 	}
@@ -1335,6 +1619,9 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolPriceBumpFlag.Name) {
 		cfg.PriceBump = ctx.GlobalUint64(TxPoolPriceBumpFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolReplaceIntervalFlag.Name) {
+		cfg.ReplaceInterval = ctx.GlobalDuration(TxPoolReplaceIntervalFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolAccountSlotsFlag.Name) {
 		cfg.AccountSlots = ctx.GlobalUint64(TxPoolAccountSlotsFlag.Name)
 	}
@@ -1350,6 +1637,22 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolMaxLifetimeFlag.Name) {
+		cfg.MaxLifetime = ctx.GlobalDuration(TxPoolMaxLifetimeFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolGapTolerantFlag.Name) {
+		accounts := strings.Split(ctx.GlobalString(TxPoolGapTolerantFlag.Name), ",")
+		for _, account := range accounts {
+			if trimmed := strings.TrimSpace(account); !common.IsHexAddress(trimmed) {
+				Fatalf("Invalid account in --txpool.gaptolerant: %s", trimmed)
+			} else {
+				cfg.GapTolerant = append(cfg.GapTolerant, common.HexToAddress(account))
+			}
+		}
+	}
+	if ctx.GlobalIsSet(TxPoolGapToleranceFlag.Name) {
+		cfg.GapTolerance = ctx.GlobalUint64(TxPoolGapToleranceFlag.Name)
+	}
 }
 
 func setEthash(ctx *cli.Context, cfg *ethconfig.Config) {
@@ -1399,6 +1702,12 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 	if ctx.GlobalIsSet(MinerNoVerifyFlag.Name) {
 		cfg.Noverify = ctx.GlobalBool(MinerNoVerifyFlag.Name)
 	}
+	if ctx.GlobalIsSet(MinerMaxWitnessNodesFlag.Name) {
+		cfg.MaxWitnessNodes = ctx.GlobalInt(MinerMaxWitnessNodesFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerOrderingAuditFlag.Name) {
+		cfg.OrderingAudit = ctx.GlobalBool(MinerOrderingAuditFlag.Name)
+	}
 	if ctx.GlobalIsSet(LegacyMinerGasTargetFlag.Name) {
 		log.Warn("The generic --miner.gastarget flag is deprecated and will be removed in the future!")
 	}
@@ -1409,6 +1718,7 @@ func setWhitelist(ctx *cli.Context, cfg *ethconfig.Config) {
 	if whitelist == "" {
 		return
 	}
+	log.Warn("The --whitelist flag is deprecated, use signed checkpoints imported with 'geth checkpoint import'")
 	cfg.Whitelist = make(map[uint64]common.Hash)
 	for _, entry := range strings.Split(whitelist, ",") {
 		parts := strings.Split(entry, "=")
@@ -1427,6 +1737,18 @@ func setWhitelist(ctx *cli.Context, cfg *ethconfig.Config) {
 	}
 }
 
+// setCheckpointSigner configures the signer of imported header checkpoints.
+func setCheckpointSigner(ctx *cli.Context, cfg *ethconfig.Config) {
+	if ctx.GlobalIsSet(CheckpointSignerFlag.Name) {
+		signer := ctx.GlobalString(CheckpointSignerFlag.Name)
+		if !common.IsHexAddress(signer) {
+			Fatalf("Invalid checkpoint signer address %s", signer)
+		}
+		addr := common.HexToAddress(signer)
+		cfg.CheckpointSigner = &addr
+	}
+}
+
 // CheckExclusive verifies that only a single instance of the provided flags was
 // set by the user. Each flag might optionally be followed by a string type to
 // specialize it further.
@@ -1490,7 +1812,15 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	setTxPool(ctx, &cfg.TxPool)
 	setEthash(ctx, cfg)
 	setMiner(ctx, &cfg.Miner)
+	if file := ctx.GlobalString(MinerPreconfirmKeyFlag.Name); file != "" {
+		key, err := crypto.LoadECDSA(file)
+		if err != nil {
+			Fatalf("Option %q: %v", MinerPreconfirmKeyFlag.Name, err)
+		}
+		cfg.PreconfirmationKey = key
+	}
 	setWhitelist(ctx, cfg)
+	setCheckpointSigner(ctx, cfg)
 	setLes(ctx, cfg)
 
 	// Cap the cache allowance and tune the garbage collector
@@ -1526,6 +1856,18 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.GlobalIsSet(AncientFlag.Name) {
 		cfg.DatabaseFreezer = ctx.GlobalString(AncientFlag.Name)
 	}
+	if ctx.GlobalIsSet(ZktrieDBEngineFlag.Name) {
+		cfg.ZktrieDatabaseEngine = ctx.GlobalString(ZktrieDBEngineFlag.Name)
+	}
+	if ctx.GlobalIsSet(ZktrieDBCacheFlag.Name) {
+		cfg.ZktrieDatabaseCache = ctx.GlobalInt(ZktrieDBCacheFlag.Name)
+	}
+	if ctx.GlobalIsSet(DBCompactionIdleFlag.Name) {
+		cfg.DatabaseCompactionIdle = ctx.GlobalDuration(DBCompactionIdleFlag.Name)
+	}
+	if ctx.GlobalIsSet(DBCompactionIntervalFlag.Name) {
+		cfg.DatabaseCompactionInterval = ctx.GlobalDuration(DBCompactionIntervalFlag.Name)
+	}
 
 	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
 		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
@@ -1542,9 +1884,36 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 		cfg.Preimages = true
 		log.Info("Enabling recording of key preimages since archive mode is used")
 	}
+	if ctx.GlobalIsSet(RecoveryParallelFlag.Name) {
+		cfg.RecoveryParallel = ctx.GlobalBool(RecoveryParallelFlag.Name)
+	}
+	if ctx.GlobalIsSet(GracefulShutdownFlag.Name) {
+		cfg.GracefulShutdown = ctx.GlobalDuration(GracefulShutdownFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxLookupLimitFlag.Name) {
 		cfg.TxLookupLimit = ctx.GlobalUint64(TxLookupLimitFlag.Name)
 	}
+	if ctx.GlobalIsSet(SenderNonceIndexFlag.Name) {
+		cfg.SenderNonceIndex = ctx.GlobalBool(SenderNonceIndexFlag.Name)
+	}
+	if ctx.GlobalIsSet(AddressActivityFlag.Name) {
+		cfg.AddressActivity = ctx.GlobalBool(AddressActivityFlag.Name)
+	}
+	if ctx.GlobalIsSet(TokenTransfersFlag.Name) {
+		cfg.TokenTransfers = ctx.GlobalBool(TokenTransfersFlag.Name)
+	}
+	if ctx.GlobalIsSet(EventLogIndexFlag.Name) {
+		cfg.EventLogIndex = ctx.GlobalBool(EventLogIndexFlag.Name)
+	}
+	if ctx.GlobalIsSet(BloomSectionSizeFlag.Name) {
+		cfg.BloomSectionSize = ctx.GlobalUint64(BloomSectionSizeFlag.Name)
+	}
+	if cfg.BloomSectionSize != params.BloomBitsBlocks && cfg.BloomSectionSize != params.BloomBitsBlocksFine {
+		Fatalf("Invalid bloom section size %d, must be %d or %d", cfg.BloomSectionSize, params.BloomBitsBlocks, params.BloomBitsBlocksFine)
+	}
+	if cfg.LightServ > 0 && cfg.BloomSectionSize != params.BloomBitsBlocks {
+		Fatalf("Light server requires the default bloom section size %d", params.BloomBitsBlocks)
+	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheTrieFlag.Name) {
 		cfg.TrieCleanCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheTrieFlag.Name) / 100
 	}
@@ -1560,6 +1929,12 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheSnapshotFlag.Name) {
 		cfg.SnapshotCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheSnapshotFlag.Name) / 100
 	}
+	if ctx.GlobalIsSet(CacheCodeFlag.Name) {
+		cfg.CodeCache = ctx.GlobalInt(CacheCodeFlag.Name)
+	}
+	if ctx.GlobalIsSet(CachePrefetchWorkersFlag.Name) {
+		cfg.PrefetchWorkers = ctx.GlobalInt(CachePrefetchWorkersFlag.Name)
+	}
 	if !ctx.GlobalBool(SnapshotFlag.Name) {
 		// If snap-sync is requested, this flag is also required
 		if cfg.SyncMode == downloader.SnapSync {
@@ -1576,6 +1951,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 		// TODO(fjl): force-enable this in --dev mode
 		cfg.EnablePreimageRecording = ctx.GlobalBool(VMEnableDebugFlag.Name)
 	}
+	if ctx.GlobalIsSet(VMFuseInstructionsFlag.Name) {
+		cfg.EnableInstructionFusion = ctx.GlobalBool(VMFuseInstructionsFlag.Name)
+	}
 
 	if ctx.GlobalIsSet(RPCGlobalGasCapFlag.Name) {
 		cfg.RPCGasCap = ctx.GlobalUint64(RPCGlobalGasCapFlag.Name)
@@ -1588,9 +1966,35 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.GlobalIsSet(RPCGlobalEVMTimeoutFlag.Name) {
 		cfg.RPCEVMTimeout = ctx.GlobalDuration(RPCGlobalEVMTimeoutFlag.Name)
 	}
+	if ctx.GlobalIsSet(RPCCallCacheFlag.Name) {
+		cfg.RPCCallCacheSize = ctx.GlobalInt(RPCCallCacheFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCCallCacheTTLFlag.Name) {
+		cfg.RPCCallCacheTTL = ctx.GlobalDuration(RPCCallCacheTTLFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCMaxReexecFlag.Name) {
+		cfg.RPCMaxReexec = ctx.GlobalUint64(RPCMaxReexecFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCLogQueryMaxBlocksFlag.Name) {
+		cfg.RPCLogQueryMaxBlocks = ctx.GlobalUint64(RPCLogQueryMaxBlocksFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCLogQueryMaxResultsFlag.Name) {
+		cfg.RPCLogQueryMaxResults = ctx.GlobalInt(RPCLogQueryMaxResultsFlag.Name)
+	}
 	if ctx.GlobalIsSet(RPCGlobalTxFeeCapFlag.Name) {
 		cfg.RPCTxFeeCap = ctx.GlobalFloat64(RPCGlobalTxFeeCapFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxForwardFlag.Name) {
+		cfg.TxForward = nil
+		for _, endpoint := range strings.Split(ctx.GlobalString(TxForwardFlag.Name), ",") {
+			if trimmed := strings.TrimSpace(endpoint); trimmed != "" {
+				cfg.TxForward = append(cfg.TxForward, trimmed)
+			}
+		}
+	}
+	if ctx.GlobalIsSet(TxForwardRateFlag.Name) {
+		cfg.TxForwardRate = ctx.GlobalFloat64(TxForwardRateFlag.Name)
+	}
 	if ctx.GlobalIsSet(NoDiscoverFlag.Name) {
 		cfg.EthDiscoveryURLs, cfg.SnapDiscoveryURLs = []string{}, []string{}
 	} else if ctx.GlobalIsSet(DNSDiscoveryFlag.Name) {
@@ -1668,6 +2072,17 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 
 		// Create a new developer genesis block or reuse existing one
 		cfg.Genesis = core.DeveloperGenesisBlock(uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name)), ctx.GlobalUint64(DeveloperGasLimitFlag.Name), developer.Address)
+		cfg.Genesis.Config.Zktrie = ctx.GlobalBool(DeveloperZktrieFlag.Name)
+		if ctx.GlobalIsSet(DeveloperMinBaseFeeFlag.Name) {
+			minBaseFee := GlobalBig(ctx, DeveloperMinBaseFeeFlag.Name)
+			cfg.Genesis.Config.EIP1559 = []*params.EIP1559Config{{
+				Block:      big.NewInt(0),
+				MinBaseFee: minBaseFee,
+			}}
+			if cfg.Genesis.BaseFee.Cmp(minBaseFee) < 0 {
+				cfg.Genesis.BaseFee = new(big.Int).Set(minBaseFee)
+			}
+		}
 		if ctx.GlobalIsSet(DataDirFlag.Name) {
 			// Check if we have an already initialized chain and fall back to
 			// that if so. Otherwise we need to generate a new genesis spec.
@@ -1737,6 +2152,66 @@ func RegisterEthStatsService(stack *node.Node, backend ethapi.Backend, url strin
 	}
 }
 
+// RegisterRootCheckService configures the state root checker and adds it to the
+// given node.
+func RegisterRootCheckService(stack *node.Node, backend ethapi.Backend, urls []string) {
+	if err := rootcheck.New(stack, backend, urls); err != nil {
+		Fatalf("Failed to register the state root checker: %v", err)
+	}
+}
+
+// SetFirehoseConfig applies firehose related command line flags to the config.
+func SetFirehoseConfig(ctx *cli.Context, cfg *firehose.Config) {
+	if ctx.GlobalIsSet(FirehoseURLFlag.Name) {
+		cfg.URL = ctx.GlobalString(FirehoseURLFlag.Name)
+	}
+	if ctx.GlobalIsSet(FirehoseTopicFlag.Name) {
+		cfg.Topic = ctx.GlobalString(FirehoseTopicFlag.Name)
+	}
+	if ctx.GlobalIsSet(FirehoseFormatFlag.Name) {
+		cfg.Format = ctx.GlobalString(FirehoseFormatFlag.Name)
+	}
+}
+
+// RegisterFirehoseService configures the event firehose and adds it to the
+// given node.
+func RegisterFirehoseService(stack *node.Node, backend ethapi.Backend, cfg *firehose.Config) {
+	if err := firehose.New(stack, backend, cfg); err != nil {
+		Fatalf("Failed to register the firehose: %v", err)
+	}
+}
+
+// SetWatchdogConfig applies chain head watchdog related command line flags to
+// the config.
+func SetWatchdogConfig(ctx *cli.Context, cfg *watchdog.Config) {
+	if ctx.GlobalIsSet(WatchdogDepthFlag.Name) {
+		cfg.Depth = ctx.GlobalUint64(WatchdogDepthFlag.Name)
+	}
+	if ctx.GlobalIsSet(WatchdogHaltFlag.Name) {
+		cfg.Halt = ctx.GlobalBool(WatchdogHaltFlag.Name)
+	}
+}
+
+// RegisterWatchdogService configures the chain head watchdog and adds it to the
+// given node.
+func RegisterWatchdogService(stack *node.Node, backend *eth.Ethereum, cfg *watchdog.Config) {
+	if err := watchdog.New(stack, backend.BlockChain(), backend.TxPool(), backend, cfg); err != nil {
+		Fatalf("Failed to register the chain head watchdog: %v", err)
+	}
+}
+
+// GRPCEndpoint returns the listening endpoint of the gRPC gateway.
+func GRPCEndpoint(ctx *cli.Context) string {
+	return net.JoinHostPort(ctx.GlobalString(GRPCListenAddrFlag.Name), strconv.Itoa(ctx.GlobalInt(GRPCPortFlag.Name)))
+}
+
+// RegisterGRPCService configures the gRPC gateway and adds it to the given node.
+func RegisterGRPCService(stack *node.Node, backend ethapi.Backend, endpoint string) {
+	if err := grpcapi.New(stack, backend, endpoint); err != nil {
+		Fatalf("Failed to register the gRPC gateway: %v", err)
+	}
+}
+
 // RegisterGraphQLService is a utility function to construct a new service and register it against a node.
 func RegisterGraphQLService(stack *node.Node, backend ethapi.Backend, cfg node.Config) {
 	if err := graphql.New(stack, backend, cfg.GraphQLCors, cfg.GraphQLVirtualHosts); err != nil {
@@ -1834,7 +2309,14 @@ func MakeChainDatabase(ctx *cli.Context, stack *node.Node, readonly bool) ethdb.
 		chainDb, err = stack.OpenDatabase(name, cache, handles, "", readonly)
 	} else {
 		name := "chaindata"
+		engine := ctx.GlobalString(ZktrieDBEngineFlag.Name)
+		if engine != "" {
+			handles /= 2 // Leave half of the file handles to the trie node store
+		}
 		chainDb, err = stack.OpenDatabaseWithFreezer(name, cache, handles, ctx.GlobalString(AncientFlag.Name), "", readonly)
+		if err == nil {
+			chainDb, err = ethconfig.OpenZktrieDatabase(stack, chainDb, engine, ctx.GlobalInt(ZktrieDBCacheFlag.Name), handles, readonly)
+		}
 	}
 	if err != nil {
 		Fatalf("Could not open database: %v", err)
@@ -1897,7 +2379,10 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 		TrieDirtyDisabled:   ctx.GlobalString(GCModeFlag.Name) == "archive",
 		TrieTimeLimit:       ethconfig.Defaults.TrieTimeout,
 		SnapshotLimit:       ethconfig.Defaults.SnapshotCache,
+		CodeCacheLimit:      ctx.GlobalInt(CacheCodeFlag.Name),
+		PrefetchWorkers:     ctx.GlobalInt(CachePrefetchWorkersFlag.Name),
 		Preimages:           ctx.GlobalBool(CachePreimagesFlag.Name),
+		RootCheckpoint:      ctx.GlobalUint64(ImportRootCheckpointFlag.Name),
 	}
 	if cache.TrieDirtyDisabled && !cache.Preimages {
 		cache.Preimages = true
@@ -1912,7 +2397,10 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cache.TrieDirtyLimit = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
 	}
-	vmcfg := vm.Config{EnablePreimageRecording: ctx.GlobalBool(VMEnableDebugFlag.Name)}
+	vmcfg := vm.Config{
+		EnablePreimageRecording: ctx.GlobalBool(VMEnableDebugFlag.Name),
+		EnableInstructionFusion: ctx.GlobalBool(VMFuseInstructionsFlag.Name),
+	}
 
 	// TODO(rjl493456442) disable snapshot generation/wiping if the chain is read only.
 	// Disable transaction indexing/unindexing by default.
@@ -18,8 +18,20 @@
 package utils
 
 import (
+	"flag"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
+
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/scroll-tech/go-ethereum/accounts/keystore"
+	"github.com/scroll-tech/go-ethereum/eth/ethconfig"
+	"github.com/scroll-tech/go-ethereum/internal/debug"
+	"github.com/scroll-tech/go-ethereum/node"
 )
 
 func Test_SplitTagsFlag(t *testing.T) {
@@ -62,3 +74,146 @@ func Test_SplitTagsFlag(t *testing.T) {
 		})
 	}
 }
+
+func newRoleContext(t *testing.T, args ...string) *cli.Context {
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	for _, f := range []cli.Flag{RoleFlag, MiningEnabledFlag, GCModeFlag, TxLookupLimitFlag, SenderNonceIndexFlag, AddressActivityFlag, EventLogIndexFlag, debug.CallTraceIndexFlag, TxPoolGlobalSlotsFlag, TxPoolGlobalQueueFlag, CacheDatabaseFlag, CacheTrieFlag, CacheGCFlag, CacheSnapshotFlag} {
+		f.Apply(set)
+	}
+	set.String(SyncModeFlag.Name, "full", "")
+	if err := set.Parse(args); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+	return cli.NewContext(cli.NewApp(), set, nil)
+}
+
+func TestNodeRoleDefaults(t *testing.T) {
+	ctx := newRoleContext(t, "--role", "archive", "--cache.trie", "20")
+	role := nodeRoles["archive"]
+	if err := checkRole(ctx, "archive", role); err != nil {
+		t.Fatalf("unexpected role conflict: %v", err)
+	}
+	ApplyRole(ctx, "")
+
+	if mode := ctx.GlobalString(GCModeFlag.Name); mode != "archive" {
+		t.Errorf("gcmode mismatch: have %s, want archive", mode)
+	}
+	if limit := ctx.GlobalUint64(TxLookupLimitFlag.Name); limit != 0 {
+		t.Errorf("txlookuplimit mismatch: have %d, want 0", limit)
+	}
+	if trie := ctx.GlobalInt(CacheTrieFlag.Name); trie != 20 {
+		t.Errorf("explicit cache.trie overridden: have %d, want 20", trie)
+	}
+	for _, flag := range []string{SenderNonceIndexFlag.Name, AddressActivityFlag.Name, EventLogIndexFlag.Name, debug.CallTraceIndexFlag.Name} {
+		if !ctx.GlobalBool(flag) {
+			t.Errorf("index --%s not enabled", flag)
+		}
+	}
+}
+
+func TestNodeRoleConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "geth-role")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "config.toml")
+	config := "[Eth]\nTxLookupLimit = 100\nSenderNonceIndex = false\n\n[Eth.TxPool]\nGlobalSlots = 1024\n"
+	if err := ioutil.WriteFile(file, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	ctx := newRoleContext(t, "--role", "rpc")
+	ApplyRole(ctx, file)
+
+	// The settings of the config file are left to it
+	for _, flag := range []string{TxLookupLimitFlag.Name, SenderNonceIndexFlag.Name, TxPoolGlobalSlotsFlag.Name} {
+		if ctx.GlobalIsSet(flag) {
+			t.Errorf("role overrides config file setting of --%s", flag)
+		}
+	}
+	// The others are filled in by the role
+	for _, flag := range []string{AddressActivityFlag.Name, TxPoolGlobalQueueFlag.Name, CacheTrieFlag.Name} {
+		if !ctx.GlobalIsSet(flag) {
+			t.Errorf("role default of --%s not applied", flag)
+		}
+	}
+}
+
+func TestNodeRoleConflicts(t *testing.T) {
+	tests := []struct {
+		role string
+		args []string
+		fail bool
+	}{
+		{"sequencer", nil, false},
+		{"sequencer", []string{"--mine"}, false},
+		{"sequencer", []string{"--gcmode", "archive"}, true},
+		{"sequencer", []string{"--syncmode", "light"}, true},
+		{"follower", []string{"--mine"}, true},
+		{"rpc", []string{"--txlookuplimit", "100"}, false},
+		{"archive", []string{"--gcmode", "full"}, true},
+		{"archive", []string{"--txlookuplimit", "100"}, true},
+		{"archive", []string{"--syncmode", "light"}, true},
+	}
+	for i, tt := range tests {
+		ctx := newRoleContext(t, append([]string{"--role", tt.role}, tt.args...)...)
+		err := checkRole(ctx, tt.role, nodeRoles[tt.role])
+		if (err != nil) != tt.fail {
+			t.Errorf("test %d (%s %v): error mismatch: have %v, want failure %v", i, tt.role, tt.args, err, tt.fail)
+		}
+	}
+}
+
+// Tests that the developer chain gets the zk trie and minimum base fee given by
+// the dev flags.
+func TestDeveloperGenesis(t *testing.T) {
+	dir, err := ioutil.TempDir("", "geth-dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		args       []string
+		zktrie     bool
+		minBaseFee *big.Int
+		baseFee    *big.Int
+	}{
+		{nil, false, nil, big.NewInt(1000000000)},
+		{[]string{"--dev.zktrie"}, true, nil, big.NewInt(1000000000)},
+		{[]string{"--dev.minbasefee", "100"}, false, big.NewInt(100), big.NewInt(1000000000)},
+		{[]string{"--dev.zktrie", "--dev.minbasefee", "5000000000"}, true, big.NewInt(5000000000), big.NewInt(5000000000)},
+	}
+	for i, tt := range tests {
+		set := flag.NewFlagSet("test", flag.ContinueOnError)
+		for _, f := range []cli.Flag{DeveloperFlag, DeveloperPeriodFlag, DeveloperGasLimitFlag, DeveloperZktrieFlag, DeveloperMinBaseFeeFlag, GCModeFlag, SyncModeFlag} {
+			f.Apply(set)
+		}
+		if err := set.Parse(append([]string{"--dev"}, tt.args...)); err != nil {
+			t.Fatalf("test %d: failed to parse flags: %v", i, err)
+		}
+		ctx := cli.NewContext(cli.NewApp(), set, nil)
+
+		stack, err := node.New(&node.Config{})
+		if err != nil {
+			t.Fatalf("test %d: failed to create node: %v", i, err)
+		}
+		stack.AccountManager().AddBackend(keystore.NewKeyStore(filepath.Join(dir, "keystore"), keystore.LightScryptN, keystore.LightScryptP))
+
+		cfg := ethconfig.Defaults
+		SetEthConfig(ctx, stack, &cfg)
+		stack.Close()
+
+		genesis := cfg.Genesis
+		if genesis.Config.Zktrie != tt.zktrie {
+			t.Errorf("test %d: zktrie mismatch: have %v, want %v", i, genesis.Config.Zktrie, tt.zktrie)
+		}
+		if have := genesis.Config.MinBaseFee(big.NewInt(0)); (have == nil) != (tt.minBaseFee == nil) || (have != nil && have.Cmp(tt.minBaseFee) != 0) {
+			t.Errorf("test %d: minimum base fee mismatch: have %v, want %v", i, have, tt.minBaseFee)
+		}
+		if genesis.BaseFee.Cmp(tt.baseFee) != 0 {
+			t.Errorf("test %d: genesis base fee mismatch: have %v, want %v", i, genesis.BaseFee, tt.baseFee)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/naoina/toml"
+	"github.com/naoina/toml/ast"
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/scroll-tech/go-ethereum/internal/debug"
+	"github.com/scroll-tech/go-ethereum/log"
+)
+
+// nodeRole is a named bundle of flag defaults describing how a node in an
+// operator fleet is expected to run. Role defaults only fill in flags that were
+// neither given explicitly nor set in the config file, explicit flags that
+// contradict the role are rejected.
+type nodeRole struct {
+	mining   bool              // Whether the role seals blocks
+	archive  bool              // Whether the role keeps all historical state
+	defaults map[string]string // Flag values applied when not set explicitly
+}
+
+// nodeRoles contains the supported --role profiles.
+var nodeRoles = map[string]nodeRole{
+	"sequencer": {
+		mining: true,
+		defaults: map[string]string{
+			MiningEnabledFlag.Name:     "true",
+			GCModeFlag.Name:            "full",
+			TxPoolGlobalSlotsFlag.Name: "16384",
+			TxPoolGlobalQueueFlag.Name: "4096",
+			CacheDatabaseFlag.Name:     "40",
+			CacheTrieFlag.Name:         "15",
+			CacheGCFlag.Name:           "35",
+			CacheSnapshotFlag.Name:     "10",
+		},
+	},
+	"follower": {
+		defaults: map[string]string{
+			GCModeFlag.Name: "full",
+		},
+	},
+	"archive": {
+		archive: true,
+		defaults: map[string]string{
+			GCModeFlag.Name:               "archive",
+			TxLookupLimitFlag.Name:        "0",
+			SenderNonceIndexFlag.Name:     "true",
+			AddressActivityFlag.Name:      "true",
+			EventLogIndexFlag.Name:        "true",
+			debug.CallTraceIndexFlag.Name: "true",
+			CacheDatabaseFlag.Name:        "60",
+			CacheTrieFlag.Name:            "30",
+			CacheGCFlag.Name:              "0",
+			CacheSnapshotFlag.Name:        "10",
+		},
+	},
+	"rpc": {
+		defaults: map[string]string{
+			GCModeFlag.Name:               "full",
+			TxLookupLimitFlag.Name:        "0",
+			SenderNonceIndexFlag.Name:     "true",
+			AddressActivityFlag.Name:      "true",
+			EventLogIndexFlag.Name:        "true",
+			debug.CallTraceIndexFlag.Name: "true",
+			TxPoolGlobalSlotsFlag.Name:    "8192",
+			TxPoolGlobalQueueFlag.Name:    "2048",
+			CacheDatabaseFlag.Name:        "40",
+			CacheTrieFlag.Name:            "25",
+			CacheGCFlag.Name:              "15",
+			CacheSnapshotFlag.Name:        "20",
+		},
+	},
+}
+
+// roleConfigKeys maps the flags with role defaults to the config file settings
+// they override. Flags missing here, like --mine, have no config file setting.
+var roleConfigKeys = map[string]string{
+	GCModeFlag.Name:               "Eth.NoPruning",
+	TxLookupLimitFlag.Name:        "Eth.TxLookupLimit",
+	SenderNonceIndexFlag.Name:     "Eth.SenderNonceIndex",
+	AddressActivityFlag.Name:      "Eth.AddressActivity",
+	EventLogIndexFlag.Name:        "Eth.EventLogIndex",
+	debug.CallTraceIndexFlag.Name: "Eth.CallTraceIndex",
+	TxPoolGlobalSlotsFlag.Name:    "Eth.TxPool.GlobalSlots",
+	TxPoolGlobalQueueFlag.Name:    "Eth.TxPool.GlobalQueue",
+	CacheDatabaseFlag.Name:        "Eth.DatabaseCache",
+	CacheTrieFlag.Name:            "Eth.TrieCleanCache",
+	CacheGCFlag.Name:              "Eth.TrieDirtyCache",
+	CacheSnapshotFlag.Name:        "Eth.SnapshotCache",
+}
+
+// roleNames returns the sorted list of supported role names.
+func roleNames() []string {
+	names := make([]string, 0, len(nodeRoles))
+	for name := range nodeRoles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyRole validates the flags against the profile selected with --role and
+// fills in the profile defaults for every flag neither set on the command line
+// nor in the given config file, if any. It is a no-op if no role was requested.
+func ApplyRole(ctx *cli.Context, file string) {
+	if !ctx.GlobalIsSet(RoleFlag.Name) {
+		return
+	}
+	name := ctx.GlobalString(RoleFlag.Name)
+	role, ok := nodeRoles[name]
+	if !ok {
+		Fatalf("Unknown node role %q, must be one of %s", name, strings.Join(roleNames(), ", "))
+	}
+	if err := checkRole(ctx, name, role); err != nil {
+		Fatalf("Invalid flags for node role %q: %v", name, err)
+	}
+	var config *ast.Table
+	if file != "" {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			Fatalf("Failed to read config file: %v", err)
+		}
+		if config, err = toml.Parse(data); err != nil {
+			Fatalf("Failed to parse config file: %v", err)
+		}
+	}
+	for flag, value := range role.defaults {
+		if ctx.GlobalIsSet(flag) || configHas(config, roleConfigKeys[flag]) {
+			continue
+		}
+		if err := ctx.GlobalSet(flag, value); err != nil {
+			Fatalf("Failed to apply node role %q default for --%s: %v", name, flag, err)
+		}
+	}
+	log.Info("Applied node role profile", "role", name)
+}
+
+// configHas reports whether the parsed config file sets the setting with the
+// given dotted path.
+func configHas(config *ast.Table, path string) bool {
+	if config == nil || path == "" {
+		return false
+	}
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		table, ok := config.Fields[key].(*ast.Table)
+		if !ok {
+			return false
+		}
+		config = table
+	}
+	_, ok := config.Fields[keys[len(keys)-1]]
+	return ok
+}
+
+// checkRole returns an error if any explicitly set flag conflicts with the role.
+func checkRole(ctx *cli.Context, name string, role nodeRole) error {
+	if ctx.GlobalString(SyncModeFlag.Name) == "light" && (role.mining || role.archive) {
+		return fmt.Errorf("--%s light cannot serve as %s", SyncModeFlag.Name, name)
+	}
+	if !role.mining && ctx.GlobalBool(MiningEnabledFlag.Name) {
+		return fmt.Errorf("--%s is only allowed for the sequencer role", MiningEnabledFlag.Name)
+	}
+	if ctx.GlobalIsSet(GCModeFlag.Name) {
+		if archive := ctx.GlobalString(GCModeFlag.Name) == "archive"; archive != role.archive {
+			return fmt.Errorf("--%s %s conflicts with the role's pruning mode", GCModeFlag.Name, ctx.GlobalString(GCModeFlag.Name))
+		}
+	}
+	if role.archive && ctx.GlobalIsSet(TxLookupLimitFlag.Name) && ctx.GlobalUint64(TxLookupLimitFlag.Name) != 0 {
+		return fmt.Errorf("--%s must be 0 to index the entire chain", TxLookupLimitFlag.Name)
+	}
+	return nil
+}
@@ -143,8 +143,24 @@ var (
 // SignerFn hashes and signs the data to be signed by a backing account.
 type SignerFn func(signer accounts.Account, mimeType string, message []byte) ([]byte, error)
 
+var (
+	// typedSealDomain is the EIP-712 domain separator of typed seals.
+	typedSealDomain = crypto.Keccak256(
+		crypto.Keccak256([]byte("EIP712Domain(string name,string version)")),
+		crypto.Keccak256([]byte("Clique")),
+		crypto.Keccak256([]byte("1")),
+	)
+	// typedSealType is the EIP-712 type hash of typed seals.
+	typedSealType = crypto.Keccak256([]byte("Seal(bytes32 sealHash)"))
+
+	// hardwareSchemes are the URL schemes of wallets only able to sign typed
+	// data, not arbitrary hashes (see accounts/usbwallet). Trezor devices can't
+	// sign typed data either, so they can't seal at all.
+	hardwareSchemes = map[string]bool{"ledger": true}
+)
+
 // ecrecover extracts the Ethereum account address from a signed header.
-func ecrecover(header *types.Header, sigcache *lru.ARCCache) (common.Address, error) {
+func ecrecover(config *params.CliqueConfig, header *types.Header, sigcache *lru.ARCCache) (common.Address, error) {
 	// If the signature's already cached, return that
 	hash := header.Hash()
 	if address, known := sigcache.Get(hash); known {
@@ -157,7 +173,7 @@ func ecrecover(header *types.Header, sigcache *lru.ARCCache) (common.Address, er
 	signature := header.Extra[len(header.Extra)-extraSeal:]
 
 	// Recover the public key and the Ethereum address
-	pubkey, err := crypto.Ecrecover(SealHash(header).Bytes(), signature)
+	pubkey, err := crypto.Ecrecover(sealDigest(config, header), signature)
 	if err != nil {
 		return common.Address{}, err
 	}
@@ -211,7 +227,7 @@ func New(config *params.CliqueConfig, db ethdb.Database) *Clique {
 // Author implements consensus.Engine, returning the Ethereum address recovered
 // from the signature in the header's extra-data section.
 func (c *Clique) Author(header *types.Header) (common.Address, error) {
-	return ecrecover(header, c.signatures)
+	return ecrecover(c.config, header, c.signatures)
 }
 
 // VerifyHeader checks whether a header conforms to the consensus rules.
@@ -468,7 +484,7 @@ func (c *Clique) verifySeal(snap *Snapshot, header *types.Header, parents []*typ
 		return errUnknownBlock
 	}
 	// Resolve the authorization key and check against signers
-	signer, err := ecrecover(header, c.signatures)
+	signer, err := ecrecover(c.config, header, c.signatures)
 	if err != nil {
 		return err
 	}
@@ -589,6 +605,29 @@ func (c *Clique) Authorize(signer common.Address, signFn SignerFn) {
 	c.signFn = signFn
 }
 
+// AuthorizeWallet injects the wallet holding the signer account into the
+// consensus engine to mint new blocks with, opening it if needed. Hardware
+// wallets only sign typed data, so they can seal only on networks using typed
+// seals. Trezor devices sign neither.
+func (c *Clique) AuthorizeWallet(wallet accounts.Wallet, signer common.Address) error {
+	if wallet.URL().Scheme == "trezor" {
+		return fmt.Errorf("trezor wallet %s can't sign clique seals", wallet.URL())
+	}
+	if hardwareSchemes[wallet.URL().Scheme] {
+		if !c.config.TypedSeal {
+			return fmt.Errorf("hardware wallet %s can't sign untyped seals, network needs clique.typedSeal", wallet.URL())
+		}
+		if err := wallet.Open(""); err != nil && err != accounts.ErrWalletAlreadyOpen {
+			return err
+		}
+	}
+	if !wallet.Contains(accounts.Account{Address: signer}) {
+		return accounts.ErrUnknownAccount
+	}
+	c.Authorize(signer, wallet.SignData)
+	return nil
+}
+
 // Seal implements consensus.Engine, attempting to create a sealed block using
 // the local signing credentials.
 func (c *Clique) Seal(chain consensus.ChainHeaderReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) error {
@@ -635,10 +674,20 @@ func (c *Clique) Seal(chain consensus.ChainHeaderReader, block *types.Block, res
 		log.Trace("Out-of-turn signing requested", "wiggle", common.PrettyDuration(wiggle))
 	}
 	// Sign all the things!
-	sighash, err := signFn(accounts.Account{Address: signer}, accounts.MimetypeClique, CliqueRLP(header))
+	mimeType, message := accounts.MimetypeClique, CliqueRLP(header)
+	if c.config.TypedSeal {
+		mimeType, message = accounts.MimetypeTypedData, TypedSealData(header)
+	}
+	sighash, err := signFn(accounts.Account{Address: signer}, mimeType, message)
 	if err != nil {
 		return err
 	}
+	if len(sighash) != extraSeal {
+		return fmt.Errorf("invalid seal signature length %d", len(sighash))
+	}
+	if sighash[64] == 27 || sighash[64] == 28 {
+		sighash[64] -= 27 // Hardware wallets return V in the 27/28 form
+	}
 	copy(header.Extra[len(header.Extra)-extraSeal:], sighash)
 	// Wait until sealing is terminated or delay timeout.
 	log.Trace("Waiting for slot to sign and propagate", "delay", common.PrettyDuration(delay))
@@ -720,6 +769,25 @@ func CliqueRLP(header *types.Header) []byte {
 	return b.Bytes()
 }
 
+// TypedSealData returns the EIP-712 encoding of the seal hash of a header,
+// 0x19 0x01 || domainSeparator || hashStruct(Seal), signed on networks using
+// typed seals.
+func TypedSealData(header *types.Header) []byte {
+	sealHash := SealHash(header)
+	data := make([]byte, 0, 66)
+	data = append(data, 0x19, 0x01)
+	data = append(data, typedSealDomain...)
+	return append(data, crypto.Keccak256(typedSealType, sealHash[:])...)
+}
+
+// sealDigest returns the hash signed by the seal of a header.
+func sealDigest(config *params.CliqueConfig, header *types.Header) []byte {
+	if config.TypedSeal {
+		return crypto.Keccak256(TypedSealData(header))
+	}
+	return SealHash(header).Bytes()
+}
+
 func encodeSigHeader(w io.Writer, header *types.Header) {
 	enc := []interface{}{
 		header.ParentHash,
@@ -20,6 +20,7 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/scroll-tech/go-ethereum/accounts"
 	"github.com/scroll-tech/go-ethereum/common"
 	"github.com/scroll-tech/go-ethereum/core"
 	"github.com/scroll-tech/go-ethereum/core/rawdb"
@@ -125,3 +126,99 @@ func TestSealHash(t *testing.T) {
 		t.Errorf("have %x, want %x", have, want)
 	}
 }
+
+// Tests that networks using typed seals can be sealed by wallets only able to
+// sign EIP-712 typed data, such as hardware wallets.
+func TestTypedSeal(t *testing.T) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		config = *params.AllCliqueProtocolChanges
+		clique = &params.CliqueConfig{Period: 1, Epoch: 30000, TypedSeal: true}
+	)
+	config.Clique = clique
+
+	newChain := func(clique *params.CliqueConfig) (*core.BlockChain, *Clique, *types.Block) {
+		db := rawdb.NewMemoryDatabase()
+		genspec := &core.Genesis{
+			Config:    &config,
+			ExtraData: make([]byte, extraVanity+common.AddressLength+extraSeal),
+			BaseFee:   big.NewInt(params.InitialBaseFee),
+		}
+		copy(genspec.ExtraData[extraVanity:], addr[:])
+		genesis := genspec.MustCommit(db)
+
+		engine := New(clique, db)
+		chain, _ := core.NewBlockChain(db, nil, &config, engine, vm.Config{}, nil, nil)
+		return chain, engine, genesis
+	}
+	chain, engine, genesis := newChain(clique)
+	defer chain.Stop()
+
+	// Sign like a hardware wallet: only typed data, V in the 27/28 form
+	engine.Authorize(addr, func(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
+		if mimeType != accounts.MimetypeTypedData || len(data) != 66 || data[0] != 0x19 || data[1] != 0x01 {
+			return nil, accounts.ErrNotSupported
+		}
+		sig, err := crypto.Sign(crypto.Keccak256(data), key)
+		if err != nil {
+			return nil, err
+		}
+		sig[64] += 27
+		return sig, nil
+	})
+	blocks, _ := core.GenerateChain(&config, genesis, engine, rawdb.NewMemoryDatabase(), 1, func(i int, block *core.BlockGen) {
+		block.SetDifficulty(diffInTurn)
+	})
+	header := blocks[0].Header()
+	header.Extra = make([]byte, extraVanity+extraSeal)
+	header.Difficulty = diffInTurn
+
+	results := make(chan *types.Block, 1)
+	if err := engine.Seal(chain, blocks[0].WithSeal(header), results, nil); err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	sealed := <-results
+	if signer, err := engine.Author(sealed.Header()); err != nil || signer != addr {
+		t.Fatalf("seal signer mismatch: have %x (%v), want %x", signer, err, addr)
+	}
+	if _, err := chain.InsertChain(types.Blocks{sealed}); err != nil {
+		t.Fatalf("failed to insert typed seal block: %v", err)
+	}
+	// Networks using untyped seals must reject the block
+	legacy, _, _ := newChain(&params.CliqueConfig{Period: 1, Epoch: 30000})
+	defer legacy.Stop()
+
+	if _, err := legacy.InsertChain(types.Blocks{sealed}); err == nil {
+		t.Fatal("untyped seal network accepted typed seal")
+	}
+}
+
+// urlWallet is an accounts.Wallet stub only reporting its URL.
+type urlWallet struct {
+	accounts.Wallet
+	url accounts.URL
+}
+
+func (w *urlWallet) URL() accounts.URL { return w.url }
+
+// Tests that hardware wallets are only authorized to seal if they can sign the
+// seals of the network.
+func TestAuthorizeHardwareWallet(t *testing.T) {
+	var (
+		db     = rawdb.NewMemoryDatabase()
+		typed  = New(&params.CliqueConfig{Period: 1, Epoch: 30000, TypedSeal: true}, db)
+		legacy = New(&params.CliqueConfig{Period: 1, Epoch: 30000}, db)
+		trezor = &urlWallet{url: accounts.URL{Scheme: "trezor", Path: "0001"}}
+		ledger = &urlWallet{url: accounts.URL{Scheme: "ledger", Path: "0001"}}
+	)
+	if err := typed.AuthorizeWallet(trezor, common.Address{}); err == nil {
+		t.Error("trezor wallet authorized for typed seals")
+	}
+	if err := legacy.AuthorizeWallet(trezor, common.Address{}); err == nil {
+		t.Error("trezor wallet authorized for untyped seals")
+	}
+	if err := legacy.AuthorizeWallet(ledger, common.Address{}); err == nil {
+		t.Error("ledger wallet authorized for untyped seals")
+	}
+}
@@ -33,7 +33,7 @@ func VerifyEip1559Header(config *params.ChainConfig, parent, header *types.Heade
 	// Verify that the gas limit remains within allowed bounds
 	parentGasLimit := parent.GasLimit
 	if !config.IsLondon(parent.Number) {
-		parentGasLimit = parent.GasLimit * params.ElasticityMultiplier
+		parentGasLimit = parent.GasLimit * config.ElasticityMultiplier(header.Number)
 	}
 	if err := VerifyGaslimit(parentGasLimit, header.GasLimit); err != nil {
 		return err
@@ -42,6 +42,13 @@ func VerifyEip1559Header(config *params.ChainConfig, parent, header *types.Heade
 	if header.BaseFee == nil {
 		return fmt.Errorf("header is missing baseFee")
 	}
+	// An oracle base fee depends on the parent state, it is verified on processing
+	if config.BaseFeeOracle(header.Number) != nil {
+		if min := config.MinBaseFee(header.Number); min != nil && header.BaseFee.Cmp(min) < 0 {
+			return fmt.Errorf("invalid baseFee: have %s, minimum %s", header.BaseFee, min)
+		}
+		return nil
+	}
 	// Verify the baseFee is correct based on the parent header.
 	expectedBaseFee := CalcBaseFee(config, parent)
 	if header.BaseFee.Cmp(expectedBaseFee) != 0 {
@@ -51,17 +58,85 @@ func VerifyEip1559Header(config *params.ChainConfig, parent, header *types.Heade
 	return nil
 }
 
-// CalcBaseFee calculates the basefee of the header.
+// StateReader provides access to the base fee oracle in the parent state.
+type StateReader interface {
+	GetState(addr common.Address, key common.Hash) common.Hash
+}
+
+// VerifyOracleBaseFee verifies the base fee of a header against the value of the
+// base fee oracle in the parent state, if the oracle is in force.
+func VerifyOracleBaseFee(config *params.ChainConfig, parent, header *types.Header, state StateReader) error {
+	if config.BaseFeeOracle(header.Number) == nil {
+		return nil
+	}
+	if expected := CalcBaseFeeFromState(config, parent, state); header.BaseFee == nil || header.BaseFee.Cmp(expected) != 0 {
+		return fmt.Errorf("invalid oracle baseFee: have %s, want %s, parentBaseFee %s", header.BaseFee, expected, parent.BaseFee)
+	}
+	return nil
+}
+
+// CalcBaseFee calculates the basefee of the header, following the EIP-1559
+// parameters configured for it. Without access to the state, the target of a
+// base fee oracle is approximated by the base fee of the parent.
 func CalcBaseFee(config *params.ChainConfig, parent *types.Header) *big.Int {
+	return CalcBaseFeeFromState(config, parent, nil)
+}
+
+// CalcBaseFeeFromState calculates the basefee of the header, reading the target
+// of the base fee oracle, if in force, from the given parent state.
+func CalcBaseFeeFromState(config *params.ChainConfig, parent *types.Header, state StateReader) *big.Int {
+	number := new(big.Int).Add(parent.Number, common.Big1)
+
+	var baseFee *big.Int
+	if oracle := config.BaseFeeOracle(number); oracle != nil {
+		baseFee = calcOracleBaseFee(config, oracle, parent, state)
+	}
+	if baseFee == nil {
+		baseFee = calcBaseFee(config, parent, number)
+	}
+	if min := config.MinBaseFee(number); min != nil && baseFee.Cmp(min) < 0 {
+		return new(big.Int).Set(min)
+	}
+	return baseFee
+}
+
+// calcOracleBaseFee calculates the basefee of the header from the oracle target,
+// returning nil if the oracle is not set.
+func calcOracleBaseFee(config *params.ChainConfig, oracle *params.BaseFeeOracleConfig, parent *types.Header, state StateReader) *big.Int {
+	londonParent := config.IsLondon(parent.Number) && parent.BaseFee != nil
+	if state == nil {
+		if londonParent {
+			return new(big.Int).Set(parent.BaseFee)
+		}
+		return nil
+	}
+	target := state.GetState(oracle.Address, oracle.Slot).Big()
+	if target.Sign() == 0 {
+		return nil
+	}
+	if oracle.Smoothing <= 1 || !londonParent {
+		return target
+	}
+	// Move towards the target by a fraction of the distance, at least by one
+	delta := new(big.Int).Sub(target, parent.BaseFee)
+	step := new(big.Int).Quo(delta, new(big.Int).SetUint64(oracle.Smoothing))
+	if step.Sign() == 0 {
+		step.SetInt64(int64(delta.Sign()))
+	}
+	return step.Add(step, parent.BaseFee)
+}
+
+// calcBaseFee calculates the basefee of the header before applying the lower bound.
+func calcBaseFee(config *params.ChainConfig, parent *types.Header, number *big.Int) *big.Int {
 	// If the current block is the first EIP-1559 block, return the InitialBaseFee.
 	if !config.IsLondon(parent.Number) {
 		return new(big.Int).SetUint64(params.InitialBaseFee)
 	}
 
 	var (
-		parentGasTarget          = parent.GasLimit / params.ElasticityMultiplier
+		parentGasTarget          = parent.GasLimit / config.ElasticityMultiplier(number)
 		parentGasTargetBig       = new(big.Int).SetUint64(parentGasTarget)
-		baseFeeChangeDenominator = new(big.Int).SetUint64(params.BaseFeeChangeDenominator)
+		baseFeeChangeDenominator = new(big.Int).SetUint64(config.BaseFeeChangeDenominator(number))
 	)
 	// If the parent gasUsed is the same as the target, the baseFee remains unchanged.
 	if parent.GasUsed == parentGasTarget {
@@ -47,6 +47,7 @@ func copyConfig(original *params.ChainConfig) *params.ChainConfig {
 		TerminalTotalDifficulty: original.TerminalTotalDifficulty,
 		Ethash:                  original.Ethash,
 		Clique:                  original.Clique,
+		EIP1559:                 original.EIP1559,
 	}
 }
 
@@ -130,3 +131,99 @@ func TestCalcBaseFee(t *testing.T) {
 		}
 	}
 }
+
+// TestCalcBaseFeeOverrides tests the base fee calculation with the EIP-1559
+// parameters overridden from block 10 on.
+func TestCalcBaseFeeOverrides(t *testing.T) {
+	config := config()
+	config.EIP1559 = []*params.EIP1559Config{{
+		Block:                    big.NewInt(10),
+		ElasticityMultiplier:     4,
+		BaseFeeChangeDenominator: 16,
+		MinBaseFee:               big.NewInt(990000000),
+	}}
+	tests := []struct {
+		parentNumber    int64
+		parentGasUsed   uint64
+		expectedBaseFee int64
+	}{
+		{8, 10000000, 937500000},              // default parameters, usage below target
+		{9, 10000000, params.InitialBaseFee},  // usage == target
+		{9, 9000000, 993750000},               // usage below target
+		{9, 11000000, 1006250000},             // usage above target
+		{9, 0, 990000000},                     // clamped to the minimum
+		{20, 10000000, params.InitialBaseFee}, // override stays in force
+	}
+	for i, test := range tests {
+		parent := &types.Header{
+			Number:   big.NewInt(test.parentNumber),
+			GasLimit: 40000000,
+			GasUsed:  test.parentGasUsed,
+			BaseFee:  big.NewInt(params.InitialBaseFee),
+		}
+		if have, want := CalcBaseFee(config, parent), big.NewInt(test.expectedBaseFee); have.Cmp(want) != 0 {
+			t.Errorf("test %d: have %d  want %d, ", i, have, want)
+		}
+	}
+	// The gas limit may jump by the configured elasticity at the London fork
+	config.EIP1559[0].Block = big.NewInt(5)
+	parent := &types.Header{GasLimit: 10000000, Number: big.NewInt(4)}
+	header := &types.Header{GasLimit: 40000000, Number: big.NewInt(5), BaseFee: new(big.Int).SetUint64(params.InitialBaseFee)}
+	if err := VerifyEip1559Header(config, parent, header); err != nil {
+		t.Errorf("expected valid header: %v", err)
+	}
+}
+
+// oracleState is a state with a single storage slot set.
+type oracleState common.Hash
+
+func (s oracleState) GetState(addr common.Address, key common.Hash) common.Hash {
+	return common.Hash(s)
+}
+
+// TestCalcOracleBaseFee tests the base fee pinned to or smoothed towards the
+// value of a base fee oracle.
+func TestCalcOracleBaseFee(t *testing.T) {
+	oracle := &params.BaseFeeOracleConfig{Address: common.Address{0x01}}
+	config := config()
+	config.EIP1559 = []*params.EIP1559Config{{Block: big.NewInt(10), MinBaseFee: big.NewInt(100), Oracle: oracle}}
+
+	tests := []struct {
+		smoothing       uint64
+		parentNumber    int64
+		oracle          int64
+		expectedBaseFee int64
+	}{
+		{0, 8, 500, 875000000},         // oracle not in force yet
+		{0, 9, 0, 875000000},           // oracle unset, EIP-1559 dynamics
+		{0, 9, 500, 500},               // pinned
+		{0, 9, 50, 100},                // clamped to the minimum
+		{4, 9, 500, 750000125},         // smoothed down
+		{4, 9, 2000000000, 1250000000}, // smoothed up
+		{4, 9, params.InitialBaseFee + 3, params.InitialBaseFee + 1}, // moves at least by one
+	}
+	for i, test := range tests {
+		oracle.Smoothing = test.smoothing
+		parent := &types.Header{
+			Number:   big.NewInt(test.parentNumber),
+			GasLimit: 20000000,
+			BaseFee:  big.NewInt(params.InitialBaseFee),
+		}
+		state := oracleState(common.BigToHash(big.NewInt(test.oracle)))
+		if have, want := CalcBaseFeeFromState(config, parent, state), big.NewInt(test.expectedBaseFee); have.Cmp(want) != 0 {
+			t.Errorf("test %d: have %d  want %d, ", i, have, want)
+		}
+	}
+	// Header checks defer the oracle base fee to block processing
+	parent := &types.Header{Number: big.NewInt(9), GasLimit: 20000000, BaseFee: big.NewInt(params.InitialBaseFee)}
+	header := &types.Header{Number: big.NewInt(10), GasLimit: 20000000, BaseFee: big.NewInt(500)}
+	if err := VerifyEip1559Header(config, parent, header); err != nil {
+		t.Errorf("oracle base fee rejected by header checks: %v", err)
+	}
+	if err := VerifyOracleBaseFee(config, parent, header, oracleState(common.BigToHash(big.NewInt(600)))); err == nil {
+		t.Errorf("mismatching oracle base fee accepted")
+	}
+	if header.BaseFee = big.NewInt(99); VerifyEip1559Header(config, parent, header) == nil {
+		t.Errorf("base fee below minimum accepted")
+	}
+}
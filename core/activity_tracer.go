@@ -0,0 +1,152 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/vm"
+)
+
+// AddressActivityCollector is a lightweight vm.EVMLogger which records the
+// parties of the internal value transfers of all the transactions executed in a
+// block. Transfers of reverted call frames are discarded. It's used to populate
+// the address activity index during block import and local sealing.
+type AddressActivityCollector struct {
+	internal [][]common.Address // Internal transfer parties, one list per transaction
+	marks    []int              // Length of the current list when each open frame was entered
+}
+
+// NewAddressActivityCollector creates an address activity collector for a
+// single block.
+func NewAddressActivityCollector() *AddressActivityCollector {
+	return &AddressActivityCollector{}
+}
+
+// Transfers returns the parties of the internal value transfers collected so
+// far, one list per executed transaction.
+func (c *AddressActivityCollector) Transfers() [][]common.Address {
+	return c.internal
+}
+
+// CaptureStart implements vm.EVMLogger, starting the list of a new transaction.
+func (c *AddressActivityCollector) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	c.internal = append(c.internal, nil)
+	c.marks = c.marks[:0]
+}
+
+// CaptureEnd implements vm.EVMLogger, dropping all internal transfers if the
+// transaction failed.
+func (c *AddressActivityCollector) CaptureEnd(output []byte, gasUsed uint64, _ time.Duration, err error) {
+	if len(c.internal) == 0 {
+		return
+	}
+	if err != nil {
+		c.internal[len(c.internal)-1] = nil
+	}
+	c.marks = c.marks[:0]
+}
+
+// CaptureEnter implements vm.EVMLogger, recording the parties of a nested call
+// frame transferring value.
+func (c *AddressActivityCollector) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if len(c.internal) == 0 {
+		return
+	}
+	current := len(c.internal) - 1
+	c.marks = append(c.marks, len(c.internal[current]))
+
+	// CALLCODE transfers the value to the caller itself, nothing moves
+	if typ != vm.CALLCODE && value != nil && value.Sign() > 0 {
+		c.internal[current] = append(c.internal[current], from, to)
+	}
+}
+
+// CaptureExit implements vm.EVMLogger, discarding the transfers of the closed
+// call frame if it failed.
+func (c *AddressActivityCollector) CaptureExit(output []byte, gasUsed uint64, err error) {
+	if len(c.internal) == 0 || len(c.marks) == 0 {
+		return
+	}
+	mark := c.marks[len(c.marks)-1]
+	c.marks = c.marks[:len(c.marks)-1]
+
+	if err != nil {
+		current := len(c.internal) - 1
+		c.internal[current] = c.internal[current][:mark]
+	}
+}
+
+// CaptureState implements vm.EVMLogger, it's a noop.
+func (c *AddressActivityCollector) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+// CaptureStateAfter implements vm.EVMLogger, it's a noop.
+func (c *AddressActivityCollector) CaptureStateAfter(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+// CaptureFault implements vm.EVMLogger, it's a noop.
+func (c *AddressActivityCollector) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+// TracerMux is a vm.EVMLogger forwarding all events to multiple loggers, used
+// when indexes are populated next to the configured tracer.
+type TracerMux []vm.EVMLogger
+
+func (t TracerMux) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	for _, tracer := range t {
+		tracer.CaptureStart(env, from, to, create, input, gas, value)
+	}
+}
+
+func (t TracerMux) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	for _, tracer := range t {
+		tracer.CaptureState(pc, op, gas, cost, scope, rData, depth, err)
+	}
+}
+
+func (t TracerMux) CaptureStateAfter(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	for _, tracer := range t {
+		tracer.CaptureStateAfter(pc, op, gas, cost, scope, rData, depth, err)
+	}
+}
+
+func (t TracerMux) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	for _, tracer := range t {
+		tracer.CaptureEnter(typ, from, to, input, gas, value)
+	}
+}
+
+func (t TracerMux) CaptureExit(output []byte, gasUsed uint64, err error) {
+	for _, tracer := range t {
+		tracer.CaptureExit(output, gasUsed, err)
+	}
+}
+
+func (t TracerMux) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+	for _, tracer := range t {
+		tracer.CaptureFault(pc, op, gas, cost, scope, depth, err)
+	}
+}
+
+func (t TracerMux) CaptureEnd(output []byte, gasUsed uint64, elapsed time.Duration, err error) {
+	for _, tracer := range t {
+		tracer.CaptureEnd(output, gasUsed, elapsed, err)
+	}
+}
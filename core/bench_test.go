@@ -70,6 +70,12 @@ func BenchmarkInsertChain_ring1000_memdb(b *testing.B) {
 func BenchmarkInsertChain_ring1000_diskdb(b *testing.B) {
 	benchInsertChain(b, true, genTxRing(1000))
 }
+func BenchmarkInsertChain_smallBlocks_mpt(b *testing.B) {
+	benchInsertSmallBlocks(b, false)
+}
+func BenchmarkInsertChain_smallBlocks_zktrie(b *testing.B) {
+	benchInsertSmallBlocks(b, true)
+}
 
 var (
 	// This is the content of the genesis block used by the benchmarks.
@@ -172,6 +178,45 @@ func genUncles(i int, gen *BlockGen) {
 	}
 }
 
+// benchInsertSmallBlocks measures the import of a long history of small blocks
+// into a fresh chain, the shape of a full sync of an L2 chain. Unlike the other
+// insertion benchmarks the chain length is fixed, b.N is the number of imports.
+func benchInsertSmallBlocks(b *testing.B, zktrie bool) {
+	const (
+		blocks      = 2048
+		txsPerBlock = 4
+	)
+	config := *params.TestChainConfig
+	config.Zktrie = zktrie
+	gspec := Genesis{
+		Config: &config,
+		Alloc:  GenesisAlloc{benchRootAddr: {Balance: benchRootFunds}},
+	}
+	gendb := rawdb.NewMemoryDatabase()
+	genesis := gspec.MustCommit(gendb)
+	chain, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), gendb, blocks, func(i int, gen *BlockGen) {
+		for j := 0; j < txsPerBlock; j++ {
+			genValueTx(0)(i, gen)
+		}
+	})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		db := rawdb.NewMemoryDatabase()
+		gspec.MustCommit(db)
+		chainman, _ := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+		b.StartTimer()
+
+		if n, err := chainman.InsertChain(chain); err != nil {
+			b.Fatalf("insert error (block %d): %v\n", n, err)
+		}
+		b.StopTimer()
+		chainman.Stop()
+		b.StartTimer()
+	}
+}
+
 func benchInsertChain(b *testing.B, disk bool, gen func(int, *BlockGen)) {
 	// Create the database in memory or in a temporary directory.
 	var db ethdb.Database
@@ -18,6 +18,7 @@ package core
 
 import (
 	"fmt"
+	"math/big"
 
 	"github.com/scroll-tech/go-ethereum/consensus"
 	"github.com/scroll-tech/go-ethereum/core/state"
@@ -50,20 +51,29 @@ func NewBlockValidator(config *params.ChainConfig, blockchain *BlockChain, engin
 // header's transaction and uncle roots. The headers are assumed to be already
 // validated at this point.
 func (v *BlockValidator) ValidateBody(block *types.Block) error {
+	return v.validateBody(block, nil)
+}
+
+// validateBody validates the given block's uncles and verifies the block header's
+// transaction and uncle roots. If roots is non-nil, it delivers the result of the
+// context free checks of ValidateBodyRoots, which were run ahead of time.
+func (v *BlockValidator) validateBody(block *types.Block, roots <-chan error) error {
 	// Check whether the block's known, and if not, that it's linkable
 	if v.bc.HasBlockAndState(block.Hash(), block.NumberU64()) {
 		return ErrKnownBlock
 	}
 	// Header validity is known at this point, check the uncles and transactions
-	header := block.Header()
 	if err := v.engine.VerifyUncles(v.bc, block); err != nil {
 		return err
 	}
-	if hash := types.CalcUncleHash(block.Uncles()); hash != header.UncleHash {
-		return fmt.Errorf("uncle root hash mismatch: have %x, want %x", hash, header.UncleHash)
+	var err error
+	if roots != nil {
+		err = <-roots
+	} else {
+		err = v.ValidateBodyRoots(block)
 	}
-	if hash := types.DeriveSha(block.Transactions(), trie.NewStackTrie(nil)); hash != header.TxHash {
-		return fmt.Errorf("transaction root hash mismatch: have %x, want %x", hash, header.TxHash)
+	if err != nil {
+		return err
 	}
 	if !v.bc.HasBlockAndState(block.ParentHash(), block.NumberU64()-1) {
 		if !v.bc.HasBlock(block.ParentHash(), block.NumberU64()-1) {
@@ -74,15 +84,59 @@ func (v *BlockValidator) ValidateBody(block *types.Block) error {
 	return nil
 }
 
+// ValidateBodyRoots runs the checks of ValidateBody which don't depend on the
+// chain: it verifies the uncle and transaction roots of the header and rejects
+// transaction types not activated yet. It is safe for concurrent use.
+func (v *BlockValidator) ValidateBodyRoots(block *types.Block) error {
+	header := block.Header()
+	if hash := types.CalcUncleHash(block.Uncles()); hash != header.UncleHash {
+		return fmt.Errorf("uncle root hash mismatch: have %x, want %x", hash, header.UncleHash)
+	}
+	if hash := types.DeriveSha(block.Transactions(), trie.NewStackTrie(nil)); hash != header.TxHash {
+		return fmt.Errorf("transaction root hash mismatch: have %x, want %x", hash, header.TxHash)
+	}
+	// Reject transaction types that aren't activated yet before executing anything
+	for i, tx := range block.Transactions() {
+		if err := ValidateTxType(v.config, tx, header.Number); err != nil {
+			return fmt.Errorf("invalid transaction %d [%v]: %w", i, tx.Hash().Hex(), err)
+		}
+	}
+	return nil
+}
+
 // ValidateState validates the various changes that happen after a state
 // transition, such as amount of used gas, the receipt roots and the state root
 // itself. ValidateState returns a database batch if the validation was a success
-// otherwise nil and an error is returned.
+// otherwise nil and an error is returned. The state root is not checked if the
+// statedb is nil, which allows deferring its verification to a later block.
 func (v *BlockValidator) ValidateState(block *types.Block, statedb *state.StateDB, receipts types.Receipts, usedGas uint64) error {
 	header := block.Header()
 	if block.GasUsed() != usedGas {
 		return fmt.Errorf("invalid gas used (remote: %d local: %d)", block.GasUsed(), usedGas)
 	}
+	// Verify the receipts in the background, hashing the state dominates anyway
+	receiptErr := make(chan error, 1)
+	go func() {
+		receiptErr <- validateReceipts(header, receipts)
+	}()
+	// Validate the state root against the received state root and throw
+	// an error if they don't match. Receipt errors take precedence.
+	if statedb == nil {
+		return <-receiptErr
+	}
+	root := statedb.IntermediateRoot(v.config.IsEIP158(header.Number))
+	if err := <-receiptErr; err != nil {
+		return err
+	}
+	if header.Root != root {
+		return fmt.Errorf("invalid merkle root (remote: %x local: %x)", header.Root, root)
+	}
+	return nil
+}
+
+// validateReceipts checks the bloom and the receipt root of the header against
+// the ones derived from the given receipts.
+func validateReceipts(header *types.Header, receipts types.Receipts) error {
 	// Validate the received block's bloom with the one derived from the generated receipts.
 	// For valid blocks this should always validate to true.
 	rbloom := types.CreateBloom(receipts)
@@ -94,14 +148,45 @@ func (v *BlockValidator) ValidateState(block *types.Block, statedb *state.StateD
 	if receiptSha != header.ReceiptHash {
 		return fmt.Errorf("invalid receipt root hash (remote: %x local: %x)", header.ReceiptHash, receiptSha)
 	}
-	// Validate the state root against the received state root and throw
-	// an error if they don't match.
-	if root := statedb.IntermediateRoot(v.config.IsEIP158(header.Number)); header.Root != root {
-		return fmt.Errorf("invalid merkle root (remote: %x local: %x)", header.Root, root)
-	}
 	return nil
 }
 
+// ValidateTxType checks whether the type of the given transaction is activated
+// by the chain config at the given block number.
+func ValidateTxType(config *params.ChainConfig, tx *types.Transaction, number *big.Int) error {
+	// System transactions are checked against the required ones during processing
+	if tx.Type() == types.SystemTxType {
+		return nil
+	}
+	return checkTxType(tx.Type(), config.IsBerlin(number), config.IsLondon(number), config.IsFeePayer(number))
+}
+
+// checkTxType checks whether the given transaction type is allowed with the
+// given forks activated.
+func checkTxType(txType uint8, eip2718, eip1559, feePayer bool) error {
+	switch txType {
+	case types.LegacyTxType:
+		return nil
+	case types.AccessListTxType:
+		if !eip2718 {
+			return ErrAccessListTxNotActive
+		}
+		return nil
+	case types.DynamicFeeTxType:
+		if !eip1559 {
+			return ErrDynamicFeeTxNotActive
+		}
+		return nil
+	case types.FeePayerTxType:
+		if !feePayer {
+			return ErrFeePayerTxNotActive
+		}
+		return nil
+	default:
+		return ErrTxTypeNotSupported
+	}
+}
+
 // CalcGasLimit computes the gas limit of the next block after parent. It aims
 // to keep the baseline gas close to the provided target, and increase it towards
 // the target if the baseline gas is lower.
@@ -81,6 +81,7 @@ var (
 
 	blockPrefetchExecuteTimer   = metrics.NewRegisteredTimer("chain/prefetch/executes", nil)
 	blockPrefetchInterruptMeter = metrics.NewRegisteredMeter("chain/prefetch/interrupts", nil)
+	blockPrefetchParallelTimer  = metrics.NewRegisteredTimer("chain/prefetch/parallel", nil)
 
 	errInsertionInterrupted = errors.New("insertion is interrupted")
 	errChainStopped         = errors.New("blockchain is stopped")
@@ -95,6 +96,15 @@ const (
 	maxTimeFutureBlocks   = 30
 	TriesInMemory         = 128
 	blockResultCacheLimit = 128
+	sideAccessCacheLimit  = 128
+
+	// zkTrieRewindCheckDepth is the number of zk trie levels below the state root
+	// verified to be present before rewinding the chain onto a state.
+	zkTrieRewindCheckDepth = 8
+
+	// badBlockWitnessLogLimit is the maximum number of struct logs kept for each
+	// transaction in the execution witness of a bad block.
+	badBlockWitnessLogLimit = 4096
 
 	// BlockChainVersion ensures that an incompatible database forces a resync from scratch.
 	//
@@ -133,9 +143,19 @@ type CacheConfig struct {
 	TrieDirtyDisabled   bool          // Whether to disable trie write caching and GC altogether (archive node)
 	TrieTimeLimit       time.Duration // Time limit after which to flush the current in-memory trie to disk
 	SnapshotLimit       int           // Memory allowance (MB) to use for caching snapshot entries in memory
+	CodeCacheLimit      int           // Memory allowance (MB) to use for caching contract codes shared across state databases
+	PrefetchWorkers     int           // Number of goroutines speculatively executing transactions to warm zk trie caches (0 = disabled)
 	Preimages           bool          // Whether to store preimage of trie key to the disk
 	TraceCacheLimit     int
-	MPTWitness          int // How to generate witness data for mpt circuit, 0: nothing, 1: natural
+	MPTWitness          int           // How to generate witness data for mpt circuit, 0: nothing, 1: natural
+	CallTraceIndex      bool          // Whether to index the call traces of the imported transactions
+	SenderNonceIndex    bool          // Whether to index the canonical transactions by sender and nonce
+	AddressActivity     bool          // Whether to index the transactions by the addresses taking part in them
+	TokenTransfers      bool          // Whether to index the ERC-20 and ERC-721 transfer logs by token and holder
+	EventLogIndex       bool          // Whether to index the logs by emitting contract and event signature
+	RootCheckpoint      uint64        // Number of blocks between state root verifications during imports (0 = every block)
+	CompactionIdle      time.Duration // Idle time required before running scheduled database compactions
+	CompactionInterval  time.Duration // Time between proactive database compaction sweeps (0 = disabled)
 
 	SnapshotWait bool // Wait for snapshot construction on startup. TODO(karalabe): This is a dirty hack for testing, nuke it
 }
@@ -189,6 +209,7 @@ type BlockChain struct {
 	chainHeadFeed event.Feed
 	logsFeed      event.Feed
 	blockProcFeed event.Feed
+	reorgFeed     event.Feed
 	scope         event.SubscriptionScope
 	genesisBlock  *types.Block
 
@@ -199,19 +220,22 @@ type BlockChain struct {
 	currentBlock     atomic.Value // Current head of the block chain
 	currentFastBlock atomic.Value // Current head of the fast-sync chain (may be above the block chain!)
 
-	stateCache       state.Database // State database to reuse between imports (contains state cache)
-	bodyCache        *lru.Cache     // Cache for the most recent block bodies
-	bodyRLPCache     *lru.Cache     // Cache for the most recent block bodies in RLP encoded format
-	receiptsCache    *lru.Cache     // Cache for the most recent receipts per block
-	blockCache       *lru.Cache     // Cache for the most recent entire blocks
-	txLookupCache    *lru.Cache     // Cache for the most recent transaction lookup data.
-	futureBlocks     *lru.Cache     // future blocks are blocks added for later processing
-	blockResultCache *lru.Cache     // Cache for the most recent block results.
+	stateCache       state.Database   // State database to reuse between imports (contains state cache)
+	codeCache        *state.CodeCache // Contract code cache shared by all state databases of the node
+	bodyCache        *lru.Cache       // Cache for the most recent block bodies
+	bodyRLPCache     *lru.Cache       // Cache for the most recent block bodies in RLP encoded format
+	receiptsCache    *lru.Cache       // Cache for the most recent receipts per block
+	blockCache       *lru.Cache       // Cache for the most recent entire blocks
+	txLookupCache    *lru.Cache       // Cache for the most recent transaction lookup data.
+	futureBlocks     *lru.Cache       // future blocks are blocks added for later processing
+	blockResultCache *lru.Cache       // Cache for the most recent block results.
+	sideAccessCache  *lru.Cache       // Cache for the zk trie leaves accessed by the most recent side blocks
 
 	wg            sync.WaitGroup //
 	quit          chan struct{}  // shutdown signal, closed in Stop.
 	running       int32          // 0 if chain is running, 1 when stopped
 	procInterrupt int32          // interrupt signaler for block processing
+	badWitness    int32          // 1 while the witness of a bad block is being captured
 
 	engine     consensus.Engine
 	validator  Validator // Block and state validator interface
@@ -219,6 +243,11 @@ type BlockChain struct {
 	processor  Processor // Block transaction processor interface
 	vmConfig   vm.Config
 
+	compactor *rawdb.CompactionScheduler // Scheduler running database compactions while idle, nil if disabled
+
+	recovery     *RecoveryProgress // Progress of the state regeneration, nil if not recovering
+	recoveryLock sync.RWMutex      // Protects the recovery progress
+
 	shouldPreserve func(*types.Block) bool // Function used to determine whether should preserve the given block.
 }
 
@@ -236,6 +265,7 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 	txLookupCache, _ := lru.New(txLookupCacheLimit)
 	futureBlocks, _ := lru.New(maxFutureBlocks)
 	blockResultCache, _ := lru.New(blockResultCacheLimit)
+	sideAccessCache, _ := lru.New(sideAccessCacheLimit)
 	if cacheConfig.TraceCacheLimit != 0 {
 		blockResultCache, _ = lru.New(cacheConfig.TraceCacheLimit)
 	}
@@ -245,17 +275,20 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 		cacheConfig.SnapshotLimit = 0
 	}
 
+	codeCache := state.NewCodeCache(cacheConfig.CodeCacheLimit * 1024 * 1024)
+
 	bc := &BlockChain{
 		chainConfig: chainConfig,
 		cacheConfig: cacheConfig,
 		db:          db,
 		triegc:      prque.New(nil),
-		stateCache: state.NewDatabaseWithConfig(db, &trie.Config{
+		stateCache: state.NewDatabaseWithCodeCache(db, &trie.Config{
 			Cache:     cacheConfig.TrieCleanLimit,
 			Journal:   cacheConfig.TrieCleanJournal,
 			Preimages: cacheConfig.Preimages,
 			Zktrie:    chainConfig.Zktrie,
-		}),
+		}, codeCache),
+		codeCache:        codeCache,
 		quit:             make(chan struct{}),
 		chainmu:          syncx.NewClosableMutex(),
 		shouldPreserve:   shouldPreserve,
@@ -266,6 +299,7 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 		txLookupCache:    txLookupCache,
 		futureBlocks:     futureBlocks,
 		blockResultCache: blockResultCache,
+		sideAccessCache:  sideAccessCache,
 		engine:           engine,
 		vmConfig:         vmConfig,
 	}
@@ -305,7 +339,7 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 
 	// Make sure the state associated with the block is available
 	head := bc.CurrentBlock()
-	if _, err := state.New(head.Root(), bc.stateCache, bc.snaps); err != nil {
+	if err := bc.checkRewindableState(head.Root()); err != nil {
 		// Head state is missing, before the state recovery, find out the
 		// disk layer point of snapshot(if it's enabled). Make sure the
 		// rewound point is lower than disk layer.
@@ -412,6 +446,10 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 		go bc.maintainTxIndex(txIndexBlock)
 	}
 
+	// Start the idle time compaction scheduler if proactive sweeps are requested.
+	if bc.cacheConfig.CompactionInterval > 0 {
+		bc.compactor = rawdb.NewCompactionScheduler(bc.db, bc.cacheConfig.CompactionIdle, bc.cacheConfig.CompactionInterval)
+	}
 	// If periodic cache journal is required, spin it up.
 	if bc.cacheConfig.TrieCleanRejournal > 0 {
 		if bc.cacheConfig.TrieCleanRejournal < time.Minute {
@@ -506,6 +544,22 @@ func (bc *BlockChain) SetHead(head uint64) error {
 	return err
 }
 
+// checkRewindableState checks whether the state of the given root is available
+// to set the chain head onto. For zk trie backed state only the root node is
+// resolved when opening the state, so the upper levels of the trie are also
+// verified to avoid rewinding onto a partially persisted state.
+func (bc *BlockChain) checkRewindableState(root common.Hash) error {
+	if !bc.chainConfig.Zktrie {
+		_, err := state.New(root, bc.stateCache, bc.snaps)
+		return err
+	}
+	tr, err := trie.NewZkTrie(root, trie.NewZktrieDatabaseFromTriedb(bc.stateCache.TrieDB()))
+	if err != nil {
+		return err
+	}
+	return tr.CheckNodes(zkTrieRewindCheckDepth)
+}
+
 // setHeadBeyondRoot rewinds the local chain to a new head with the extra condition
 // that the rewind must pass the specified state root. This method is meant to be
 // used when rewinding with snapshots enabled to ensure that we go back further than
@@ -548,8 +602,8 @@ func (bc *BlockChain) setHeadBeyondRoot(head uint64, root common.Hash, repair bo
 					if root != (common.Hash{}) && !beyondRoot && newHeadBlock.Root() == root {
 						beyondRoot, rootNumber = true, newHeadBlock.NumberU64()
 					}
-					if _, err := state.New(newHeadBlock.Root(), bc.stateCache, bc.snaps); err != nil {
-						log.Trace("Block state missing, rewinding further", "number", newHeadBlock.NumberU64(), "hash", newHeadBlock.Hash())
+					if err := bc.checkRewindableState(newHeadBlock.Root()); err != nil {
+						log.Trace("Block state missing, rewinding further", "number", newHeadBlock.NumberU64(), "hash", newHeadBlock.Hash(), "err", err)
 						if pivot == nil || newHeadBlock.NumberU64() > *pivot {
 							parent := bc.GetBlock(newHeadBlock.ParentHash(), newHeadBlock.NumberU64()-1)
 							if parent != nil {
@@ -609,6 +663,12 @@ func (bc *BlockChain) setHeadBeyondRoot(head uint64, root common.Hash, repair bo
 	}
 	// Rewind the header chain, deleting all block bodies until then
 	delFn := func(db ethdb.KeyValueWriter, hash common.Hash, num uint64) {
+		// Drop the call traces of the rewound block while its body is around
+		if bc.cacheConfig.CallTraceIndex {
+			if body := rawdb.ReadBody(bc.db, hash, num); body != nil {
+				deleteCallTraces(db, hash, body.Transactions)
+			}
+		}
 		// Ignore the error here since light client won't hit this path
 		frozen, _ := bc.db.Ancients()
 		if num+1 <= frozen {
@@ -765,6 +825,9 @@ func (bc *BlockChain) writeHeadBlock(block *types.Block) {
 	batch := bc.db.NewBatch()
 	rawdb.WriteCanonicalHash(batch, block.Hash(), block.NumberU64())
 	rawdb.WriteTxLookupEntriesByBlock(batch, block)
+	if bc.cacheConfig.SenderNonceIndex {
+		bc.writeSenderNonceLookups(batch, block)
+	}
 	rawdb.WriteHeadBlockHash(batch, block.Hash())
 
 	// If the block is better than our head or is on a different chain, force update heads
@@ -808,6 +871,7 @@ func (bc *BlockChain) Stop() {
 	// returned.
 	bc.chainmu.Close()
 	bc.wg.Wait()
+	bc.compactor.Stop()
 
 	// Ensure that the entirety of the state snapshot is journalled to disk.
 	var snapBase common.Hash
@@ -1201,18 +1265,46 @@ func (bc *BlockChain) writeKnownBlock(block *types.Block) error {
 	return nil
 }
 
+// WriteOrderingAudit stores the ordering decisions the local miner took for a
+// block it sealed.
+func (bc *BlockChain) WriteOrderingAudit(hash common.Hash, audit *types.OrderingAudit) {
+	rawdb.WriteOrderingAudit(bc.db, hash, audit)
+}
+
 // WriteBlockWithState writes the block and all associated state to the database.
-func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.Receipt, logs []*types.Log, evmTraces []*types.ExecutionResult, storageTrace *types.StorageTrace, state *state.StateDB, emitHeadEvent bool) (status WriteStatus, err error) {
+// The internal value transfers collected while executing the block, if any, are
+// used to populate the address activity index.
+func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.Receipt, logs []*types.Log, evmTraces []*types.ExecutionResult, storageTrace *types.StorageTrace, traces []*types.CallTrace, internal [][]common.Address, state *state.StateDB, emitHeadEvent bool) (status WriteStatus, err error) {
 	if !bc.chainmu.TryLock() {
 		return NonStatTy, errInsertionInterrupted
 	}
 	defer bc.chainmu.Unlock()
-	return bc.writeBlockWithState(block, receipts, logs, evmTraces, storageTrace, state, emitHeadEvent)
+
+	status, err = bc.writeBlockWithState(block, receipts, logs, evmTraces, storageTrace, state, nil, emitHeadEvent)
+	if err == nil && bc.cacheConfig.CallTraceIndex {
+		bc.writeCallTraces(block, traces)
+	}
+	if err == nil && bc.cacheConfig.AddressActivity {
+		bc.writeAddressActivity(block, receipts, internal)
+	}
+	return status, err
+}
+
+// deferredBlock is an imported block whose state root verification is deferred
+// to a later checkpoint. It only becomes canonical once the root is verified.
+type deferredBlock struct {
+	block    *types.Block
+	receipts types.Receipts
+	logs     []*types.Log
+	traces   []*types.CallTrace // Call traces to index, if enabled
+	internal [][]common.Address // Internal transfer parties to index, if enabled
 }
 
 // writeBlockWithState writes the block and all associated state to the database,
-// but is expects the chain mutex to be held.
-func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.Receipt, logs []*types.Log, evmTraces []*types.ExecutionResult, storageTrace *types.StorageTrace, state *state.StateDB, emitHeadEvent bool) (status WriteStatus, err error) {
+// but is expects the chain mutex to be held. Any deferred blocks the block is on
+// top of are made canonical along with it, as verifying the state of the block
+// also verified theirs.
+func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.Receipt, logs []*types.Log, evmTraces []*types.ExecutionResult, storageTrace *types.StorageTrace, state *state.StateDB, deferred []*deferredBlock, emitHeadEvent bool) (status WriteStatus, err error) {
 	if bc.insertStopped() {
 		return NonStatTy, errInsertionInterrupted
 	}
@@ -1228,28 +1320,191 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	externTd := new(big.Int).Add(block.Difficulty(), ptd)
 
 	// Irrelevant of the canonical status, write the block itself to the database.
-	//
+	bc.writeBlockData(block, receipts, state, externTd)
+
+	// Commit all cached state changes into underlying memory database.
+	if err := bc.commitState(block, state); err != nil {
+		return NonStatTy, err
+	}
+	// If the total difficulty is higher than our known, add it to the canonical chain
+	// Second clause in the if statement reduces the vulnerability to selfish mining.
+	// Please refer to http://www.cs.cornell.edu/~ie53/publications/btcProcFC.pdf
+	reorg := externTd.Cmp(localTd) > 0
+	currentBlock = bc.CurrentBlock()
+	if !reorg && externTd.Cmp(localTd) == 0 {
+		// Split same-difficulty blocks by number, then preferentially select
+		// the block generated by the local miner as the canonical block.
+		if block.NumberU64() < currentBlock.NumberU64() {
+			reorg = true
+		} else if block.NumberU64() == currentBlock.NumberU64() {
+			var currentPreserve, blockPreserve bool
+			if bc.shouldPreserve != nil {
+				currentPreserve, blockPreserve = bc.shouldPreserve(currentBlock), bc.shouldPreserve(block)
+			}
+			reorg = !currentPreserve && (blockPreserve || mrand.Float64() < 0.5)
+		}
+	}
+	if reorg {
+		// Deferred blocks extend the head block, make them canonical first.
+		// Otherwise reorganise the chain if the parent is not the head block.
+		if len(deferred) > 0 {
+			bc.writeDeferredHeads(deferred)
+		} else if block.ParentHash() != currentBlock.Hash() {
+			if err := bc.reorg(currentBlock, block); err != nil {
+				return NonStatTy, err
+			}
+		}
+		status = CanonStatTy
+	} else {
+		status = SideStatTy
+	}
+	// Set new head.
+	if status == CanonStatTy {
+		bc.writeHeadBlock(block)
+		if bc.chainConfig.Zktrie {
+			bc.writeLeafEpochs(block, state.LeafAccesses())
+		}
+	} else if bc.chainConfig.Zktrie {
+		// Keep the accesses in case a reorg makes the block canonical later
+		bc.sideAccessCache.Add(block.Hash(), state.LeafAccesses())
+	}
+	bc.futureBlocks.Remove(block.Hash())
+
+	// Fill blockResult content
+	var blockResult *types.BlockResult
+	if evmTraces != nil {
+		blockResult = bc.writeBlockResult(state, block, evmTraces, storageTrace)
+		bc.blockResultCache.Add(block.Hash(), blockResult)
+	}
+
+	if status == CanonStatTy {
+		bc.chainFeed.Send(ChainEvent{Block: block, Hash: block.Hash(), Logs: logs, BlockResult: blockResult})
+		if len(logs) > 0 {
+			bc.logsFeed.Send(logs)
+		}
+		// In theory we should fire a ChainHeadEvent when we inject
+		// a canonical block, but sometimes we can insert a batch of
+		// canonicial blocks. Avoid firing too much ChainHeadEvents,
+		// we will fire an accumulated ChainHeadEvent and disable fire
+		// event here.
+		if emitHeadEvent {
+			bc.chainHeadFeed.Send(ChainHeadEvent{Block: block})
+		}
+	} else {
+		bc.chainSideFeed.Send(ChainSideEvent{Block: block})
+	}
+	return status, nil
+}
+
+// writeLeafEpochs records the state epoch of a canonical block as the epoch of
+// the last access to the zk trie leaves read or written by its state. The state
+// of the deferred blocks below it is committed along with it, so their accesses
+// take its epoch.
+func (bc *BlockChain) writeLeafEpochs(block *types.Block, accesses state.LeafAccesses) {
+	batch := bc.db.NewBatch()
+	if err := accesses.WriteEpochs(batch, block.NumberU64()/params.StateEpochLength); err != nil {
+		log.Error("Failed to hash zk trie leaf keys", "number", block.Number(), "hash", block.Hash(), "err", err)
+		return
+	}
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to write zk trie leaf epochs", "err", err)
+	}
+}
+
+// writeBlockData writes a block along with its receipts and indexes to the
+// database, irrelevant of its canonical status. The state is only used for the
+// preimages and may be nil.
+func (bc *BlockChain) writeBlockData(block *types.Block, receipts []*types.Receipt, state *state.StateDB, td *big.Int) {
 	// Note all the components of block(td, hash->number map, header, body, receipts)
 	// should be written atomically. BlockBatch is used for containing all components.
 	blockBatch := bc.db.NewBatch()
-	rawdb.WriteTd(blockBatch, block.Hash(), block.NumberU64(), externTd)
+	rawdb.WriteTd(blockBatch, block.Hash(), block.NumberU64(), td)
 	rawdb.WriteBlock(blockBatch, block)
 	rawdb.WriteReceipts(blockBatch, block.Hash(), block.NumberU64(), receipts)
-	rawdb.WritePreimages(blockBatch, state.Preimages())
+	if state != nil {
+		rawdb.WritePreimages(blockBatch, state.Preimages())
+	}
+	if bc.cacheConfig.TokenTransfers {
+		writeTokenTransfers(blockBatch, block, receipts)
+	}
+	if bc.cacheConfig.EventLogIndex {
+		writeEventLogs(blockBatch, block, receipts)
+	}
+	if bc.chainConfig.Zktrie {
+		rawdb.WriteStateRootBlockNumber(blockBatch, block.Root(), block.NumberU64())
+	}
 	if err := blockBatch.Write(); err != nil {
 		log.Crit("Failed to write block into disk", "err", err)
 	}
-	// Commit all cached state changes into underlying memory database.
+}
+
+// writeDeferredBlock writes a block whose state root verification is deferred
+// to the database, without touching the head block or the canonical chain.
+func (bc *BlockChain) writeDeferredBlock(deferred *deferredBlock) error {
+	if bc.insertStopped() {
+		return errInsertionInterrupted
+	}
+	block := deferred.block
+	ptd := bc.GetTd(block.ParentHash(), block.NumberU64()-1)
+	if ptd == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	bc.writeBlockData(block, deferred.receipts, nil, new(big.Int).Add(block.Difficulty(), ptd))
+	return nil
+}
+
+// writeDeferredHeads makes a run of deferred blocks extending the head block
+// canonical, once the state root of a later block has been verified and its
+// state committed.
+func (bc *BlockChain) writeDeferredHeads(deferred []*deferredBlock) {
+	for _, d := range deferred {
+		bc.writeHeadBlock(d.block)
+		bc.futureBlocks.Remove(d.block.Hash())
+
+		if bc.cacheConfig.CallTraceIndex {
+			bc.writeCallTraces(d.block, d.traces)
+		}
+		if bc.cacheConfig.AddressActivity {
+			bc.writeAddressActivity(d.block, d.receipts, d.internal)
+		}
+		bc.chainFeed.Send(ChainEvent{Block: d.block, Hash: d.block.Hash(), Logs: d.logs})
+		if len(d.logs) > 0 {
+			bc.logsFeed.Send(d.logs)
+		}
+	}
+}
+
+// deferStateRoot reports whether the state root verification of a block can be
+// deferred to a later checkpoint during chain insertion.
+func (bc *BlockChain) deferStateRoot(block *types.Block, it *insertIterator, deferred []*deferredBlock) bool {
+	interval := bc.rootCheckpoint()
+	if interval <= 1 || block.NumberU64()%interval == 0 {
+		return false
+	}
+	// The state is carried over in memory, so there must be a followup block
+	// to continue on top of it, and the block must extend the head block
+	if followup, err := it.peek(); followup == nil || err != nil {
+		return false
+	}
+	if n := len(deferred); n > 0 {
+		return block.ParentHash() == deferred[n-1].block.Hash()
+	}
+	return block.ParentHash() == bc.CurrentBlock().Hash()
+}
+
+// commitState commits the state changes of a block into the trie database,
+// flushing and garbage collecting the tries in memory as needed.
+func (bc *BlockChain) commitState(block *types.Block, state *state.StateDB) error {
 	root, err := state.Commit(bc.chainConfig.IsEIP158(block.Number()))
 	if err != nil {
-		return NonStatTy, err
+		return err
 	}
 	triedb := bc.stateCache.TrieDB()
 
 	// If we're running an archive node, always flush
 	if bc.cacheConfig.TrieDirtyDisabled {
 		if err := triedb.Commit(root, false, nil); err != nil {
-			return NonStatTy, err
+			return err
 		}
 	} else {
 		// Full but not archive node, do proper garbage collection
@@ -1298,69 +1553,145 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 			}
 		}
 	}
-	// If the total difficulty is higher than our known, add it to the canonical chain
-	// Second clause in the if statement reduces the vulnerability to selfish mining.
-	// Please refer to http://www.cs.cornell.edu/~ie53/publications/btcProcFC.pdf
-	reorg := externTd.Cmp(localTd) > 0
-	currentBlock = bc.CurrentBlock()
-	if !reorg && externTd.Cmp(localTd) == 0 {
-		// Split same-difficulty blocks by number, then preferentially select
-		// the block generated by the local miner as the canonical block.
-		if block.NumberU64() < currentBlock.NumberU64() {
-			reorg = true
-		} else if block.NumberU64() == currentBlock.NumberU64() {
-			var currentPreserve, blockPreserve bool
-			if bc.shouldPreserve != nil {
-				currentPreserve, blockPreserve = bc.shouldPreserve(currentBlock), bc.shouldPreserve(block)
-			}
-			reorg = !currentPreserve && (blockPreserve || mrand.Float64() < 0.5)
+	return nil
+}
+
+// writeSenderNonceLookups indexes the transactions of a canonical block by their
+// sender and nonce, replacing the entries of transactions reorged out.
+func (bc *BlockChain) writeSenderNonceLookups(db ethdb.KeyValueWriter, block *types.Block) {
+	signer := types.MakeSigner(bc.chainConfig, block.Number())
+	for _, tx := range block.Transactions() {
+		sender, err := types.Sender(signer, tx)
+		if err != nil {
+			continue // Block was validated, only reachable for the genesis
 		}
+		rawdb.WriteSenderNonceLookup(db, sender, tx.Nonce(), tx.Hash())
 	}
-	if reorg {
-		// Reorganise the chain if the parent is not the head block
-		if block.ParentHash() != currentBlock.Hash() {
-			if err := bc.reorg(currentBlock, block); err != nil {
-				return NonStatTy, err
+}
+
+// deleteSenderNonceLookup removes the index entry of a transaction dropped from
+// the canonical chain, unless a replacement was indexed in the meantime.
+func (bc *BlockChain) deleteSenderNonceLookup(db ethdb.KeyValueWriter, tx *types.Transaction) {
+	sender, err := types.Sender(types.LatestSigner(bc.chainConfig), tx)
+	if err != nil {
+		return
+	}
+	if hash := rawdb.ReadSenderNonceLookup(bc.db, sender, tx.Nonce()); hash != nil && *hash == tx.Hash() {
+		rawdb.DeleteSenderNonceLookup(db, sender, tx.Nonce())
+	}
+}
+
+// writeCallTraces stores the call traces collected while processing a block
+// into the call trace index. Side chain blocks are indexed too, their traces are
+// filtered out on retrieval as long as they are not canonical.
+func (bc *BlockChain) writeCallTraces(block *types.Block, traces []*types.CallTrace) {
+	txs := block.Transactions()
+	if len(traces) != len(txs) {
+		log.Warn("Mismatching call trace count, skipping indexing", "number", block.Number(), "hash", block.Hash(), "txs", len(txs), "traces", len(traces))
+		return
+	}
+	batch := bc.db.NewBatch()
+	for i, tx := range txs {
+		rawdb.WriteCallTrace(batch, tx.Hash(), block.Hash(), traces[i])
+	}
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to write call traces", "err", err)
+	}
+}
+
+// deleteCallTraces removes the call traces of the transactions of a block deleted
+// from the database from the call trace index.
+func deleteCallTraces(db ethdb.KeyValueWriter, hash common.Hash, txs types.Transactions) {
+	for _, tx := range txs {
+		rawdb.DeleteCallTrace(db, tx.Hash(), hash)
+	}
+}
+
+// writeAddressActivity indexes the transactions of a block by their sender, their
+// recipient or created contract and the parties of their internal value transfers,
+// if those were collected. Side chain blocks are indexed too, their entries are
+// filtered out on retrieval as long as they are not canonical.
+func (bc *BlockChain) writeAddressActivity(block *types.Block, receipts types.Receipts, internal [][]common.Address) {
+	txs := block.Transactions()
+	if internal != nil && len(internal) != len(txs) {
+		log.Warn("Mismatching address activity count, skipping internal transfers", "number", block.Number(), "hash", block.Hash(), "txs", len(txs), "activity", len(internal))
+		internal = nil
+	}
+	var (
+		signer = types.MakeSigner(bc.chainConfig, block.Number())
+		batch  = bc.db.NewBatch()
+	)
+	for i, tx := range txs {
+		parties := make(map[common.Address]byte)
+		if sender, err := types.Sender(signer, tx); err == nil {
+			parties[sender] |= rawdb.ActivitySent
+		}
+		if to := tx.To(); to != nil {
+			parties[*to] |= rawdb.ActivityReceived
+		} else if i < len(receipts) {
+			parties[receipts[i].ContractAddress] |= rawdb.ActivityReceived
+		}
+		if internal != nil {
+			for _, addr := range internal[i] {
+				parties[addr] |= rawdb.ActivityInternal
 			}
 		}
-		status = CanonStatTy
-	} else {
-		status = SideStatTy
+		for addr, flags := range parties {
+			rawdb.WriteAddressActivity(batch, addr, rawdb.AddressActivity{
+				BlockNumber: block.NumberU64(),
+				BlockHash:   block.Hash(),
+				TxIndex:     uint32(i),
+				TxHash:      tx.Hash(),
+				Flags:       flags,
+			})
+		}
 	}
-	// Set new head.
-	if status == CanonStatTy {
-		bc.writeHeadBlock(block)
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to write address activity", "err", err)
 	}
-	bc.futureBlocks.Remove(block.Hash())
+}
 
-	// Fill blockResult content
-	var blockResult *types.BlockResult
-	if evmTraces != nil {
-		blockResult = bc.writeBlockResult(state, block, evmTraces, storageTrace)
-		bc.blockResultCache.Add(block.Hash(), blockResult)
+// writeTokenTransfers indexes the ERC-20 and ERC-721 transfer logs of a block.
+func writeTokenTransfers(db ethdb.KeyValueWriter, block *types.Block, receipts types.Receipts) {
+	var logIndex uint
+	for i, receipt := range receipts {
+		for _, l := range receipt.Logs {
+			// Logs of locally sealed blocks might lack their final position, derive
+			// it from the block instead
+			if transfer := types.NewTokenTransfer(l); transfer != nil {
+				transfer.BlockNumber, transfer.BlockHash = block.NumberU64(), block.Hash()
+				transfer.TxHash, transfer.TxIndex, transfer.LogIndex = receipt.TxHash, uint(i), logIndex
+				rawdb.WriteTokenTransfer(db, transfer)
+			}
+			logIndex++
+		}
 	}
+}
 
-	if status == CanonStatTy {
-		bc.chainFeed.Send(ChainEvent{Block: block, Hash: block.Hash(), Logs: logs, BlockResult: blockResult})
-		if len(logs) > 0 {
-			bc.logsFeed.Send(logs)
-		}
-		// In theory we should fire a ChainHeadEvent when we inject
-		// a canonical block, but sometimes we can insert a batch of
-		// canonicial blocks. Avoid firing too much ChainHeadEvents,
-		// we will fire an accumulated ChainHeadEvent and disable fire
-		// event here.
-		if emitHeadEvent {
-			bc.chainHeadFeed.Send(ChainHeadEvent{Block: block})
+// writeEventLogs indexes the logs of a block by emitting contract and event
+// signature. Anonymous logs without topics are not indexed.
+func writeEventLogs(db ethdb.KeyValueWriter, block *types.Block, receipts types.Receipts) {
+	var logIndex uint32
+	for i, receipt := range receipts {
+		for _, l := range receipt.Logs {
+			if len(l.Topics) > 0 {
+				rawdb.WriteEventLog(db, l.Address, l.Topics[0], rawdb.EventLog{
+					BlockNumber: block.NumberU64(),
+					BlockHash:   block.Hash(),
+					LogIndex:    logIndex,
+					TxIndex:     uint32(i),
+				})
+			}
+			logIndex++
 		}
-	} else {
-		bc.chainSideFeed.Send(ChainSideEvent{Block: block})
 	}
-	return status, nil
 }
 
 // Fill blockResult content
 func (bc *BlockChain) writeBlockResult(state *state.StateDB, block *types.Block, evmTraces []*types.ExecutionResult, storageTrace *types.StorageTrace) *types.BlockResult {
+	// Witness generation is latency critical, hold off database compactions.
+	defer bc.compactor.Busy()()
+
 	blockResult := &types.BlockResult{
 		ExecutionResults: evmTraces,
 		StorageTrace:     storageTrace,
@@ -1571,7 +1902,15 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 		return it.index, err
 	}
 	// No validation errors for the first block (or chain prefix skipped)
-	var activeState *state.StateDB
+	var (
+		activeState *state.StateDB
+
+		// State carried over from blocks whose root verification is deferred
+		// until the next checkpoint, along with the blocks themselves. These are
+		// stored, but only become canonical once the root is verified.
+		carriedState *state.StateDB
+		deferred     []*deferredBlock
+	)
 	defer func() {
 		// The chain importer is starting and stopping trie prefetchers. If a bad
 		// block or other error is hit however, an early return may not properly
@@ -1582,7 +1921,16 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 		}
 	}()
 
-	for ; block != nil && err == nil || errors.Is(err, ErrKnownBlock); block, err = it.next() {
+	// Blocks on top of a carried state are reported as having a pruned ancestor,
+	// as the parent state is not committed yet. The body is otherwise valid.
+	next := func() (*types.Block, error) {
+		block, err := it.next()
+		if carriedState != nil && errors.Is(err, consensus.ErrPrunedAncestor) && block.ParentHash() == deferred[len(deferred)-1].block.Hash() {
+			err = nil
+		}
+		return block, err
+	}
+	for ; block != nil && err == nil || errors.Is(err, ErrKnownBlock); block, err = next() {
 		// If the chain is terminating, stop processing blocks
 		if bc.insertStopped() {
 			log.Debug("Abort during block processing")
@@ -1639,19 +1987,28 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 		if parent == nil {
 			parent = bc.GetHeader(block.ParentHash(), block.NumberU64()-1)
 		}
-		statedb, err := state.New(parent.Root, bc.stateCache, bc.snaps)
-		if err != nil {
-			return it.index, err
-		}
-
-		// Enable prefetching to pull in trie node paths while processing transactions
-		statedb.StartPrefetcher("chain")
-		activeState = statedb
+		var statedb *state.StateDB
+		if carriedState != nil && block.ParentHash() == deferred[len(deferred)-1].block.Hash() {
+			// The parent state was never committed, continue on top of it
+			statedb, carriedState = carriedState, nil
+			statedb.ResetLogs()
+		} else {
+			carriedState, deferred = nil, nil
 
+			statedb, err = state.New(parent.Root, bc.stateCache, bc.snaps)
+			if err != nil {
+				return it.index, err
+			}
+			// Enable prefetching to pull in trie node paths while processing transactions
+			statedb.StartPrefetcher("chain")
+			activeState = statedb
+		}
 		// If we have a followup block, run that against the current state to pre-cache
 		// transactions and probabilistically some of the account/storage trie nodes.
+		// Prefetching is skipped if the state is carried over to the followup, as the
+		// parent state of the followup is not available on disk to run it against.
 		var followupInterrupt uint32
-		if !bc.cacheConfig.TrieCleanNoPrefetch {
+		if !bc.cacheConfig.TrieCleanNoPrefetch && bc.rootCheckpoint() <= 1 {
 			if followup, err := it.peek(); followup != nil && err == nil {
 				throwaway, _ := state.New(parent.Root, bc.stateCache, bc.snaps)
 
@@ -1666,9 +2023,36 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 			}
 		}
 
-		// Process block using the parent state as reference point
+		// Speculatively run the transactions of the block in parallel to pull the
+		// zk trie nodes they touch into memory ahead of the serial execution.
+		var parallelInterrupt uint32
+		bc.PrefetchTransactions(block.Header(), block.Transactions(), statedb, &parallelInterrupt)
+
+		// Process block using the parent state as reference point. If indexes are
+		// populated, chain their collectors after the configured tracer.
+		var (
+			vmConfig  = bc.vmConfig
+			collector *CallTraceCollector
+			activity  *AddressActivityCollector
+			tracers   TracerMux
+		)
+		if bc.cacheConfig.CallTraceIndex {
+			collector = NewCallTraceCollector()
+			tracers = append(tracers, collector)
+		}
+		if bc.cacheConfig.AddressActivity {
+			activity = NewAddressActivityCollector()
+			tracers = append(tracers, activity)
+		}
+		if len(tracers) > 0 {
+			if vmConfig.Debug && vmConfig.Tracer != nil {
+				tracers = append(TracerMux{vmConfig.Tracer}, tracers...)
+			}
+			vmConfig.Debug, vmConfig.Tracer = true, tracers
+		}
 		substart := time.Now()
-		receipts, logs, usedGas, err := bc.processor.Process(block, statedb, bc.vmConfig)
+		receipts, logs, usedGas, err := bc.processor.Process(block, statedb, vmConfig)
+		atomic.StoreUint32(&parallelInterrupt, 1)
 		if err != nil {
 			bc.reportBlock(block, receipts, err)
 			atomic.StoreUint32(&followupInterrupt, 1)
@@ -1688,13 +2072,28 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 
 		blockExecutionTimer.Update(time.Since(substart) - trieproc - triehash)
 
-		// Validate the state using the default validator
+		// Validate the state using the default validator. If root checkpoints are
+		// enabled, the state root is only verified at checkpoint blocks, the last
+		// block of the batch and blocks not extending the current head, saving
+		// the costly trie hashing for all the blocks in between.
 		substart = time.Now()
-		if err := bc.validator.ValidateState(block, statedb, receipts, usedGas); err != nil {
+		deferRoot := bc.deferStateRoot(block, it, deferred)
+
+		validated := statedb
+		if deferRoot {
+			validated = nil
+		}
+		if err := bc.validator.ValidateState(block, validated, receipts, usedGas); err != nil {
+			if len(deferred) > 0 {
+				err = fmt.Errorf("%w (root verification deferred since block %d)", err, deferred[0].block.NumberU64())
+			}
 			bc.reportBlock(block, receipts, err)
 			atomic.StoreUint32(&followupInterrupt, 1)
 			return it.index, err
 		}
+		if deferRoot {
+			statedb.Finalise(bc.chainConfig.IsEIP158(block.Number()))
+		}
 		proctime := time.Since(start)
 
 		// Update the metrics touched during block validation
@@ -1703,14 +2102,49 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 
 		blockValidationTimer.Update(time.Since(substart) - (statedb.AccountHashes + statedb.StorageHashes - triehash))
 
-		// Write the block to the chain and get the status.
+		// Write the block to the chain and get the status. Blocks whose root
+		// verification is deferred are stored, but the head block is only moved
+		// onto them once the state root of a later block is verified.
 		substart = time.Now()
+		if deferRoot {
+			d := &deferredBlock{block: block, receipts: receipts, logs: logs}
+			if collector != nil {
+				d.traces = collector.traces
+			}
+			if activity != nil {
+				d.internal = activity.internal
+			}
+			err = bc.writeDeferredBlock(d)
+			atomic.StoreUint32(&followupInterrupt, 1)
+			if err != nil {
+				return it.index, err
+			}
+			carriedState, deferred = statedb, append(deferred, d)
+
+			log.Debug("Inserted block with deferred root", "number", block.Number(), "hash", block.Hash(),
+				"txs", len(block.Transactions()), "gas", block.GasUsed(), "elapsed", common.PrettyDuration(time.Since(start)))
+
+			stats.processed++
+			stats.usedGas += usedGas
+
+			dirty, _ := bc.stateCache.TrieDB().Size()
+			stats.report(chain, it.index, dirty)
+			continue
+		}
 		// EvmTraces & StorageTrace being nil is safe because l2geth's p2p server is stoped and the code will not execute there.
-		status, err := bc.writeBlockWithState(block, receipts, logs, nil, nil, statedb, false)
+		status, err := bc.writeBlockWithState(block, receipts, logs, nil, nil, validated, deferred, false)
 		atomic.StoreUint32(&followupInterrupt, 1)
 		if err != nil {
 			return it.index, err
 		}
+		deferred = nil
+
+		if collector != nil {
+			bc.writeCallTraces(block, collector.traces)
+		}
+		if activity != nil {
+			bc.writeAddressActivity(block, receipts, activity.internal)
+		}
 		// Update the metrics touched during block commit
 		accountCommitTimer.Update(statedb.AccountCommits)   // Account commits are complete, we can mark them
 		storageCommitTimer.Update(statedb.StorageCommits)   // Storage commits are complete, we can mark them
@@ -1751,6 +2185,23 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 		dirty, _ := bc.stateCache.TrieDB().Size()
 		stats.report(chain, it.index, dirty)
 	}
+	// If the import was interrupted while verification was deferred, verify and
+	// commit the state of the last imported block before making the deferred
+	// blocks canonical. On failure, the head stays at the last verified block.
+	if carriedState != nil {
+		last := deferred[len(deferred)-1].block
+		if root := carriedState.IntermediateRoot(bc.chainConfig.IsEIP158(last.Number())); root != last.Root() {
+			err := fmt.Errorf("invalid merkle root (remote: %x local: %x) (root verification deferred since block %d)", last.Root(), root, deferred[0].block.NumberU64())
+			bc.reportBlock(last, nil, err)
+			return it.index, err
+		}
+		rawdb.WritePreimages(bc.db, carriedState.Preimages())
+		if err := bc.commitState(last, carriedState); err != nil {
+			return it.index, err
+		}
+		bc.writeDeferredHeads(deferred)
+		lastCanon = last
+	}
 
 	// Any blocks remaining here? The only ones we care about are the future ones
 	if block != nil && errors.Is(err, consensus.ErrFutureBlock) {
@@ -2013,6 +2464,17 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 	} else {
 		log.Error("Impossible reorg, please file an issue", "oldnum", oldBlock.Number(), "oldhash", oldBlock.Hash(), "newnum", newBlock.Number(), "newhash", newBlock.Hash())
 	}
+	// Record the leaf epochs of the new chain, oldest first for the later
+	// accesses to win. Side blocks imported without state, or whose accesses
+	// were evicted from the cache already, are not recorded.
+	if bc.chainConfig.Zktrie {
+		for i := len(newChain) - 1; i >= 0; i-- {
+			if accesses, ok := bc.sideAccessCache.Get(newChain[i].Hash()); ok {
+				bc.writeLeafEpochs(newChain[i], accesses.(state.LeafAccesses))
+				bc.sideAccessCache.Remove(newChain[i].Hash())
+			}
+		}
+	}
 	// Insert the new chain(except the head block(reverse order)),
 	// taking care of the proper incremental order.
 	for i := len(newChain) - 1; i >= 1; i-- {
@@ -2030,6 +2492,9 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 	indexesBatch := bc.db.NewBatch()
 	for _, tx := range types.TxDifference(deletedTxs, addedTxs) {
 		rawdb.DeleteTxLookupEntry(indexesBatch, tx.Hash())
+		if bc.cacheConfig.SenderNonceIndex {
+			bc.deleteSenderNonceLookup(indexesBatch, tx)
+		}
 	}
 	// Delete any canonical number assignments above the new head
 	number := bc.CurrentBlock().NumberU64()
@@ -2057,10 +2522,39 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		for i := len(oldChain) - 1; i >= 0; i-- {
 			bc.chainSideFeed.Send(ChainSideEvent{Block: oldChain[i]})
 		}
+		bc.reorgFeed.Send(newReorgEvent(commonBlock, oldChain, newChain, deletedTxs))
 	}
 	return nil
 }
 
+// newReorgEvent assembles the notification of a reorg, splitting the transactions
+// of the old chain segment into dropped and re-included ones.
+func newReorgEvent(commonBlock *types.Block, oldChain, newChain types.Blocks, deletedTxs types.Transactions) ReorgEvent {
+	ev := ReorgEvent{
+		CommonAncestor: commonBlock.Header(),
+		OldChain:       make([]*types.Header, len(oldChain)),
+		NewChain:       make([]*types.Header, len(newChain)),
+	}
+	included := make(map[common.Hash]struct{})
+	for i, block := range newChain {
+		ev.NewChain[i] = block.Header()
+		for _, tx := range block.Transactions() {
+			included[tx.Hash()] = struct{}{}
+		}
+	}
+	for i, block := range oldChain {
+		ev.OldChain[i] = block.Header()
+	}
+	for _, tx := range deletedTxs {
+		if _, ok := included[tx.Hash()]; ok {
+			ev.Reincluded = append(ev.Reincluded, tx.Hash())
+		} else {
+			ev.Dropped = append(ev.Dropped, tx.Hash())
+		}
+	}
+	return ev
+}
+
 // futureBlocksLoop processes the 'future block' queue.
 func (bc *BlockChain) futureBlocksLoop() {
 	defer bc.wg.Done()
@@ -2210,6 +2704,23 @@ func (bc *BlockChain) maintainTxIndex(ancients uint64) {
 func (bc *BlockChain) reportBlock(block *types.Block, receipts types.Receipts, err error) {
 	rawdb.WriteBadBlock(bc.db, block)
 
+	// Capturing the witness replays the block, don't hold up the chain for it
+	if atomic.CompareAndSwapInt32(&bc.badWitness, 0, 1) {
+		bc.wg.Add(1)
+		go func() {
+			defer bc.wg.Done()
+			defer atomic.StoreInt32(&bc.badWitness, 0)
+
+			if witness, werr := bc.captureBadBlockWitness(block); werr != nil {
+				log.Warn("Failed to capture bad block witness", "number", block.Number(), "hash", block.Hash(), "err", werr)
+			} else {
+				rawdb.WriteBadBlockWitness(bc.db, block.Hash(), witness)
+			}
+		}()
+	} else {
+		log.Warn("Skipping bad block witness, capture in progress", "number", block.Number(), "hash", block.Hash())
+	}
+
 	var receiptString string
 	for i, receipt := range receipts {
 		receiptString += fmt.Sprintf("\t %d: cumulative: %v gas: %v contract: %v status: %v tx: %v logs: %v bloom: %x state: %x\n",
@@ -2229,6 +2740,126 @@ Error: %v
 `, bc.chainConfig, block.Number(), block.Hash(), receiptString, err))
 }
 
+// captureBadBlockWitness replays a block which failed import on top of its
+// parent state, collecting the execution trace of every transaction and the zk
+// trie proofs of all the state touched, up until the first failing transaction.
+// The result allows diagnosing state mismatches between sequencer and prover
+// without access to the node which rejected the block.
+func (bc *BlockChain) captureBadBlockWitness(block *types.Block) (*types.BlockResult, error) {
+	parent := bc.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	statedb, err := state.New(parent.Root, bc.stateCache, nil)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		header  = block.Header()
+		signer  = types.MakeSigner(bc.chainConfig, header.Number)
+		gp      = new(GasPool).AddGas(header.GasLimit)
+		usedGas = new(uint64)
+		tracer  = vm.NewStructLogger(&vm.LogConfig{EnableReturnData: true, Limit: badBlockWitnessLogLimit})
+		cfg     = bc.vmConfig
+
+		results []*types.ExecutionResult
+		storage = &types.StorageTrace{
+			RootBefore:    parent.Root,
+			RootAfter:     header.Root,
+			Proofs:        make(map[string][]hexutil.Bytes),
+			StorageProofs: make(map[string]map[string][]hexutil.Bytes),
+		}
+	)
+	cfg.Debug, cfg.Tracer = true, tracer
+
+	wrapAccount := func(addr common.Address) *types.AccountWrapper {
+		return &types.AccountWrapper{
+			Address:  addr,
+			Nonce:    statedb.GetNonce(addr),
+			Balance:  (*hexutil.Big)(statedb.GetBalance(addr)),
+			CodeHash: statedb.GetCodeHash(addr),
+		}
+	}
+	wrapProof := func(proof [][]byte) []hexutil.Bytes {
+		wrapped := make([]hexutil.Bytes, len(proof))
+		for i, bt := range proof {
+			wrapped[i] = bt
+		}
+		return wrapped
+	}
+	for i, tx := range block.Transactions() {
+		if bc.insertStopped() {
+			return nil, errInsertionInterrupted
+		}
+		tracer.Reset()
+		statedb.Prepare(tx.Hash(), i)
+
+		from, _ := types.Sender(signer, tx)
+		result := &types.ExecutionResult{From: wrapAccount(from)}
+		if to := tx.To(); to != nil {
+			result.To = wrapAccount(*to)
+		}
+		receipt, err := ApplyTransaction(bc.chainConfig, bc, nil, gp, statedb, header, tx, usedGas, cfg)
+
+		// Collect the proofs of everything touched so far, even if the transaction
+		// failed half way, these are the trie nodes the prover would need.
+		for addr := range tracer.UpdatedAccounts() {
+			if _, ok := storage.Proofs[addr.String()]; ok {
+				continue
+			}
+			proof, perr := statedb.GetProof(addr)
+			if perr != nil {
+				log.Debug("Bad block account proof not available", "address", addr, "err", perr)
+			}
+			storage.Proofs[addr.String()] = wrapProof(proof)
+		}
+		for addr, keys := range tracer.UpdatedStorages() {
+			m, ok := storage.StorageProofs[addr.String()]
+			if !ok {
+				m = make(map[string][]hexutil.Bytes)
+				storage.StorageProofs[addr.String()] = m
+			}
+			for key := range keys {
+				if _, ok := m[key.String()]; ok {
+					continue
+				}
+				proof, perr := statedb.GetStorageTrieProof(addr, key)
+				if perr != nil {
+					log.Debug("Bad block storage proof not available", "address", addr, "key", key, "err", perr)
+				}
+				m[key.String()] = wrapProof(proof)
+			}
+		}
+		result.StructLogs = vm.FormatLogs(tracer.StructLogs())
+		if err != nil {
+			result.Failed = true
+			results = append(results, result)
+			break
+		}
+		result.Gas = receipt.GasUsed
+		result.Failed = receipt.Status != types.ReceiptStatusSuccessful
+		result.ReturnValue = fmt.Sprintf("%x", receipt.ReturnValue)
+		result.AccountCreated = tracer.CreatedAccount()
+		to := receipt.ContractAddress
+		if tx.To() != nil {
+			to = *tx.To()
+		}
+		seen := make(map[common.Address]bool)
+		for _, addr := range []common.Address{from, to, header.Coinbase} {
+			if !seen[addr] {
+				seen[addr] = true
+				result.AccountsAfter = append(result.AccountsAfter, wrapAccount(addr))
+			}
+		}
+		results = append(results, result)
+	}
+	return &types.BlockResult{
+		BlockTrace:       types.NewTraceBlock(bc.chainConfig, block, wrapAccount(header.Coinbase)),
+		StorageTrace:     storage,
+		ExecutionResults: results,
+	}, nil
+}
+
 // InsertHeaderChain attempts to insert the given header chain in to the local
 // chain, possibly creating a reorg. If an error is returned, it will return the
 // index number of the failing header as well an error describing what went wrong.
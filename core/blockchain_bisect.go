@@ -0,0 +1,139 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/core/state"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/trie"
+)
+
+// RootBisection is the result of replaying the blocks up to a suspected bad one,
+// locating the first block whose state root diverges from its header.
+type RootBisection struct {
+	Checkpoint uint64 `json:"checkpoint"` // Block whose state the replay started from
+	Target     uint64 `json:"target"`     // Suspected bad block the replay ran up to
+
+	// Fields of the first diverging block, all empty if the replay matched
+	Block    *uint64             `json:"block,omitempty"`
+	Hash     common.Hash         `json:"hash,omitempty"`
+	Expected common.Hash         `json:"expected,omitempty"` // State root of the header
+	Computed common.Hash         `json:"computed,omitempty"` // State root of the replay
+	Error    string              `json:"error,omitempty"`    // Processing error, if the block couldn't be replayed
+	DiffBase string              `json:"diffBase,omitempty"` // State the replayed one is diffed against: "header" or "parent"
+	Diff     []state.AccountDiff `json:"diff,omitempty"`
+}
+
+// BisectRoot replays the blocks from the nearest one below the given suspected
+// bad block with its state available, comparing the replayed state root of each
+// block with its header. The replay stops at the first mismatch, diffing the
+// replayed state against the expected one if it's available, or against the
+// parent state otherwise, telling what the block changed when replayed locally.
+// Bad blocks rejected by the node are replayed if they are not canonical.
+//
+// Only states persisted to disk are replayed from. The replay is done in memory,
+// neither the chain nor the state on disk are modified.
+func (bc *BlockChain) BisectRoot(bad uint64) (*RootBisection, error) {
+	if bad == 0 {
+		return nil, fmt.Errorf("genesis block can't be replayed")
+	}
+	// Replay on a separate database, so that only persisted states are used and
+	// the replayed ones don't end up in the state cache of the chain
+	database := state.NewDatabaseWithConfig(bc.db, &trie.Config{Cache: 16, Preimages: true, Zktrie: bc.chainConfig.Zktrie})
+	hasState := func(root common.Hash) bool {
+		_, err := database.OpenTrie(root)
+		return err == nil
+	}
+	var checkpoint *types.Header
+	for number := bad - 1; checkpoint == nil; number-- {
+		if header := bc.GetHeaderByNumber(number); header != nil && hasState(header.Root) {
+			checkpoint = header
+		} else if number == 0 {
+			return nil, fmt.Errorf("no state available below block %d", bad)
+		}
+	}
+	var (
+		result = &RootBisection{Checkpoint: checkpoint.Number.Uint64(), Target: bad}
+		parent = checkpoint
+		start  = time.Now()
+		logged = time.Now()
+	)
+	log.Info("Replaying blocks to bisect state root", "checkpoint", result.Checkpoint, "target", bad)
+	for number := result.Checkpoint + 1; number <= bad; number++ {
+		block := bc.GetBlockByNumber(number)
+		if block == nil || block.ParentHash() != parent.Hash() {
+			block = bc.badBlock(number, parent.Hash())
+		}
+		if block == nil {
+			return nil, fmt.Errorf("block %d missing", number)
+		}
+		statedb, err := state.New(parent.Root, database, nil)
+		if err != nil {
+			return nil, err
+		}
+		var root common.Hash
+		if _, _, _, err = bc.processor.Process(block, statedb, bc.vmConfig); err == nil {
+			root, err = statedb.Commit(bc.chainConfig.IsEIP158(block.Number()))
+		}
+		if err != nil || root != block.Root() {
+			n := number
+			result.Block, result.Hash = &n, block.Hash()
+			result.Expected, result.Computed = block.Root(), root
+			if err != nil {
+				result.Error = err.Error()
+				return result, nil
+			}
+			base := parent.Root
+			result.DiffBase = "parent"
+			if hasState(block.Root()) {
+				base, result.DiffBase = block.Root(), "header"
+			}
+			if bc.chainConfig.Zktrie {
+				if result.Diff, err = state.DiffStates(database, base, root); err != nil {
+					return nil, err
+				}
+			}
+			log.Warn("Found diverging state root", "number", number, "hash", block.Hash(), "expected", block.Root(), "computed", root)
+			return result, nil
+		}
+		parent = block.Header()
+
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Replaying blocks to bisect state root", "number", number, "target", bad, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	log.Info("Replayed blocks without state root mismatch", "checkpoint", result.Checkpoint, "target", bad, "elapsed", common.PrettyDuration(time.Since(start)))
+	return result, nil
+}
+
+// badBlock returns the bad block with the given number and parent the node has
+// rejected, if any.
+func (bc *BlockChain) badBlock(number uint64, parent common.Hash) *types.Block {
+	for _, block := range rawdb.ReadAllBadBlocks(bc.db) {
+		if block.NumberU64() == number && block.ParentHash() == parent {
+			return block
+		}
+	}
+	return nil
+}
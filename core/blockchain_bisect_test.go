@@ -0,0 +1,114 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/consensus/ethash"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/core/vm"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/scroll-tech/go-ethereum/params"
+)
+
+// Tests that the replay locates a bad block with a diverging state root and
+// reports what the block changed.
+func TestBisectRoot(t *testing.T) {
+	config := *params.TestChainConfig
+	config.Zktrie = true
+
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address  = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xc0de")
+		db       = rawdb.NewMemoryDatabase()
+		gspec    = &Genesis{
+			Config: &config,
+			Alloc: GenesisAlloc{
+				address:  {Balance: big.NewInt(1000000000000000000)},
+				contract: {Code: common.FromHex("0x43600055"), Balance: common.Big0}, // sstore(0, number)
+			},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.LatestSigner(gspec.Config)
+		engine  = ethash.NewFaker()
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, engine, db, 5, func(i int, gen *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(address), contract, big.NewInt(1), 50000, gen.header.BaseFee, nil), signer, key)
+		gen.AddTx(tx)
+	})
+	cacheConfig := *defaultCacheConfig
+	cacheConfig.TrieDirtyDisabled = true
+
+	chain, err := NewBlockChain(db, &cacheConfig, gspec.Config, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(blocks[:4]); err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	// The canonical chain replays without mismatch
+	result, err := chain.BisectRoot(4)
+	if err != nil {
+		t.Fatalf("failed to bisect chain: %v", err)
+	}
+	if result.Checkpoint != 3 || result.Block != nil {
+		t.Fatalf("unexpected result for canonical chain: checkpoint %d, block %v", result.Checkpoint, result.Block)
+	}
+	// Reject a block with a corrupted state root
+	header := blocks[4].Header()
+	header.Root = common.Hash{0x01}
+	bad := types.NewBlockWithHeader(header).WithBody(blocks[4].Transactions(), nil)
+	rawdb.WriteBadBlock(db, bad)
+
+	result, err = chain.BisectRoot(5)
+	if err != nil {
+		t.Fatalf("failed to bisect chain: %v", err)
+	}
+	if result.Block == nil || *result.Block != 5 || result.Hash != bad.Hash() {
+		t.Fatalf("diverging block mismatch: have %v %x, want 5 %x", result.Block, result.Hash, bad.Hash())
+	}
+	if result.Expected != header.Root || result.Computed != blocks[4].Root() {
+		t.Errorf("root mismatch: expected %x computed %x, want %x %x", result.Expected, result.Computed, header.Root, blocks[4].Root())
+	}
+	if result.DiffBase != "parent" {
+		t.Errorf("diff base mismatch: have %q, want %q", result.DiffBase, "parent")
+	}
+	// The diff has the nonce of the sender and the storage of the contract bumped
+	diffs := make(map[common.Address]int)
+	for i, diff := range result.Diff {
+		if diff.Address != nil {
+			diffs[*diff.Address] = i
+		}
+	}
+	if i, ok := diffs[address]; !ok {
+		t.Errorf("sender missing from diff")
+	} else if diff := result.Diff[i]; diff.Before.Nonce != 4 || diff.After.Nonce != 5 {
+		t.Errorf("sender nonce mismatch: have %d -> %d, want 4 -> 5", diff.Before.Nonce, diff.After.Nonce)
+	}
+	if i, ok := diffs[contract]; !ok {
+		t.Errorf("contract missing from diff")
+	} else if storage := result.Diff[i].Storage; len(storage) != 1 || storage[0].Before != common.BigToHash(big.NewInt(4)) || storage[0].After != common.BigToHash(big.NewInt(5)) {
+		t.Errorf("contract storage mismatch: %+v", storage)
+	}
+}
@@ -78,6 +78,23 @@ func (st *insertStats) report(chain []*types.Block, index int, dirty common.Stor
 	}
 }
 
+// bodyValidationAhead is the number of blocks ahead of the one being imported
+// whose context free body checks are run concurrently.
+const bodyValidationAhead = 32
+
+// bodyPrevalidator is implemented by validators able to run the context free
+// parts of the body validation ahead of time, concurrently with the import.
+// State execution itself is not pipelined: a block runs against the state its
+// parent is still hashing and committing, which is not safe for concurrent use.
+type bodyPrevalidator interface {
+	// ValidateBodyRoots runs the body checks not depending on the chain.
+	ValidateBodyRoots(block *types.Block) error
+
+	// validateBody runs the full body validation, taking the result of the
+	// context free checks from the given channel.
+	validateBody(block *types.Block, roots <-chan error) error
+}
+
 // insertIterator is a helper to assist during chain import.
 type insertIterator struct {
 	chain types.Blocks // Chain of blocks being iterated over
@@ -87,18 +104,43 @@ type insertIterator struct {
 
 	index     int       // Current offset of the iterator
 	validator Validator // Validator to run if verification succeeds
+
+	prevalidator bodyPrevalidator // Validator running body checks ahead, nil if unsupported
+	roots        []chan error     // Result sinks of the body checks run ahead of time
 }
 
 // newInsertIterator creates a new iterator based on the given blocks, which are
 // assumed to be a contiguous chain.
 func newInsertIterator(chain types.Blocks, results <-chan error, validator Validator) *insertIterator {
-	return &insertIterator{
+	it := &insertIterator{
 		chain:     chain,
 		results:   results,
 		errors:    make([]error, 0, len(chain)),
 		index:     -1,
 		validator: validator,
 	}
+	if prevalidator, ok := validator.(bodyPrevalidator); ok {
+		it.prevalidator = prevalidator
+		it.roots = make([]chan error, 0, len(chain))
+		it.prevalidate(bodyValidationAhead)
+	}
+	return it
+}
+
+// prevalidate starts the context free body checks of the blocks up to, but not
+// including the given index, if not yet started.
+func (it *insertIterator) prevalidate(limit int) {
+	if limit > len(it.chain) {
+		limit = len(it.chain)
+	}
+	for i := len(it.roots); i < limit; i++ {
+		res := make(chan error, 1)
+		it.roots = append(it.roots, res)
+
+		go func(block *types.Block) {
+			res <- it.prevalidator.ValidateBodyRoots(block)
+		}(it.chain[i])
+	}
 }
 
 // next returns the next block in the iterator, along with any potential validation
@@ -118,7 +160,11 @@ func (it *insertIterator) next() (*types.Block, error) {
 		return it.chain[it.index], it.errors[it.index]
 	}
 	// Block header valid, run body validation and return
-	return it.chain[it.index], it.validator.ValidateBody(it.chain[it.index])
+	if it.prevalidator == nil {
+		return it.chain[it.index], it.validator.ValidateBody(it.chain[it.index])
+	}
+	it.prevalidate(it.index + 1 + bodyValidationAhead)
+	return it.chain[it.index], it.prevalidator.validateBody(it.chain[it.index], it.roots[it.index])
 }
 
 // peek returns the next block in the iterator, along with any potential validation
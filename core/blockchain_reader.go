@@ -27,6 +27,7 @@ import (
 	"github.com/scroll-tech/go-ethereum/core/types"
 	"github.com/scroll-tech/go-ethereum/core/vm"
 	"github.com/scroll-tech/go-ethereum/event"
+	"github.com/scroll-tech/go-ethereum/log"
 	"github.com/scroll-tech/go-ethereum/params"
 	"github.com/scroll-tech/go-ethereum/rlp"
 )
@@ -165,6 +166,147 @@ func (bc *BlockChain) GetBlockResultByHash(blockHash common.Hash) *types.BlockRe
 	return nil
 }
 
+// GetCallTrace retrieves the indexed call trace of a canonical transaction, or nil
+// if the transaction wasn't indexed. Traces recorded while the transaction was
+// part of a side chain block are not returned.
+func (bc *BlockChain) GetCallTrace(txHash common.Hash) *types.CallTrace {
+	number := rawdb.ReadTxLookupEntry(bc.db, txHash)
+	if number == nil {
+		return nil
+	}
+	hash := rawdb.ReadCanonicalHash(bc.db, *number)
+	if hash == (common.Hash{}) {
+		return nil
+	}
+	return rawdb.ReadCallTrace(bc.db, txHash, hash)
+}
+
+// GetAddressActivity retrieves up to limit canonical transactions the given address
+// took part in, starting at the given block number. A block is never split across
+// pages, so the limit might be exceeded by the entries of the last block. The
+// returned next block number is where the following page starts, or 0 if there
+// are no more entries.
+func (bc *BlockChain) GetAddressActivity(address common.Address, from uint64, limit int) ([]rawdb.AddressActivity, uint64) {
+	var (
+		entries   []rawdb.AddressActivity
+		next      uint64
+		number    uint64
+		canonical common.Hash
+	)
+	rawdb.IterateAddressActivity(bc.db, address, from, func(entry rawdb.AddressActivity) bool {
+		if entry.BlockNumber != number || canonical == (common.Hash{}) {
+			if len(entries) >= limit {
+				next = entry.BlockNumber
+				return false
+			}
+			number, canonical = entry.BlockNumber, bc.GetCanonicalHash(entry.BlockNumber)
+		}
+		if entry.BlockHash == canonical {
+			entries = append(entries, entry)
+		}
+		return true
+	})
+	return entries, next
+}
+
+// GetTokenTransfers retrieves up to limit canonical token transfers of the given
+// token, or sent and received by the given holder if no token is specified, in
+// log order starting at the given block number and log index, and not beyond the
+// given end block. If both are specified, the transfers of the holder are filtered
+// by token. The first transfer which didn't fit into the limit is returned as the
+// start of the following page, or nil if there are no more transfers.
+func (bc *BlockChain) GetTokenTransfers(token, holder *common.Address, number uint64, index uint32, end uint64, limit int) ([]*types.TokenTransfer, *types.TokenTransfer) {
+	var (
+		transfers []*types.TokenTransfer
+		next      *types.TokenTransfer
+		cached    uint64
+		canonical common.Hash
+	)
+	collect := func(transfer *types.TokenTransfer) bool {
+		if transfer.BlockNumber > end {
+			return false
+		}
+		if transfer.BlockNumber != cached || canonical == (common.Hash{}) {
+			cached, canonical = transfer.BlockNumber, bc.GetCanonicalHash(transfer.BlockNumber)
+		}
+		if transfer.BlockHash != canonical || (token != nil && transfer.Token != *token) {
+			return true
+		}
+		if len(transfers) >= limit {
+			next = transfer
+			return false
+		}
+		transfers = append(transfers, transfer)
+		return true
+	}
+	switch {
+	case holder != nil:
+		rawdb.IterateHolderTransfers(bc.db, *holder, number, index, collect)
+	case token != nil:
+		rawdb.IterateTokenTransfers(bc.db, *token, number, index, collect)
+	}
+	return transfers, next
+}
+
+// GetEventLogs retrieves up to limit canonical logs emitted by the given contract
+// with the given event signature as first topic, in log order starting at the
+// given block number and log index, and not beyond the given end block. The
+// position of the first log which didn't fit into the limit is returned as the
+// start of the following page, or nil if there are no more logs.
+func (bc *BlockChain) GetEventLogs(address common.Address, topic common.Hash, number uint64, index uint32, end uint64, limit int) ([]*types.Log, *rawdb.EventLog) {
+	var (
+		logs      []*types.Log
+		next      *rawdb.EventLog
+		cached    uint64
+		canonical common.Hash
+		receipts  types.Receipts
+	)
+	rawdb.IterateEventLogs(bc.db, address, topic, number, index, func(entry rawdb.EventLog) bool {
+		if entry.BlockNumber > end {
+			return false
+		}
+		if entry.BlockNumber != cached || canonical == (common.Hash{}) {
+			cached, canonical = entry.BlockNumber, bc.GetCanonicalHash(entry.BlockNumber)
+			receipts = nil
+		}
+		if entry.BlockHash != canonical {
+			return true
+		}
+		if len(logs) >= limit {
+			next = &entry
+			return false
+		}
+		if receipts == nil {
+			receipts = bc.GetReceiptsByHash(entry.BlockHash)
+		}
+		if int(entry.TxIndex) >= len(receipts) {
+			log.Error("Event log index refers to missing receipt", "number", entry.BlockNumber, "hash", entry.BlockHash, "tx", entry.TxIndex)
+			return true
+		}
+		for _, l := range receipts[entry.TxIndex].Logs {
+			if l.Index == uint(entry.LogIndex) {
+				logs = append(logs, l)
+				break
+			}
+		}
+		return true
+	})
+	return logs, next
+}
+
+// GetStateRootBlockNumbers retrieves the numbers of the canonical blocks whose
+// zk trie state root is the given one. Side chain blocks and blocks which were
+// reorged out since their root was indexed are skipped.
+func (bc *BlockChain) GetStateRootBlockNumbers(root common.Hash) []uint64 {
+	var numbers []uint64
+	for _, number := range rawdb.ReadStateRootBlockNumbers(bc.db, root) {
+		if header := bc.GetHeaderByNumber(number); header != nil && header.Root == root {
+			numbers = append(numbers, number)
+		}
+	}
+	return numbers
+}
+
 // GetBlockByNumber retrieves a block from the database by number, caching it
 // (associated with its hash) if found.
 func (bc *BlockChain) GetBlockByNumber(number uint64) *types.Block {
@@ -335,6 +477,31 @@ func (bc *BlockChain) StateCache() state.Database {
 	return bc.stateCache
 }
 
+// Compactor returns the scheduler running database compactions in idle windows,
+// or nil if scheduled compactions are disabled.
+func (bc *BlockChain) Compactor() *rawdb.CompactionScheduler {
+	return bc.compactor
+}
+
+// CodeCache returns the contract code cache shared by the state databases of
+// the node. Ephemeral state databases, e.g. for tracing, should reuse it.
+func (bc *BlockChain) CodeCache() *state.CodeCache {
+	return bc.codeCache
+}
+
+// PrefetchTransactions speculatively executes the given transactions in parallel
+// on copies of the statedb to warm the zk trie node and code caches ahead of
+// their serial execution. It returns once the state is copied, the background
+// work stops when the interrupt is set. Nothing is done for non-zk chains or
+// if parallel prefetching is disabled.
+func (bc *BlockChain) PrefetchTransactions(header *types.Header, txs types.Transactions, statedb *state.StateDB, interrupt *uint32) {
+	workers := bc.prefetchWorkers()
+	if !bc.chainConfig.Zktrie || workers <= 0 || bc.cacheConfig.TrieCleanNoPrefetch {
+		return
+	}
+	bc.prefetcher.PrefetchParallel(header, txs, statedb, bc.vmConfig, workers, interrupt)
+}
+
 // GasLimit returns the gas limit of the current HEAD block.
 func (bc *BlockChain) GasLimit() uint64 {
 	return bc.CurrentBlock().GasLimit()
@@ -345,6 +512,18 @@ func (bc *BlockChain) Genesis() *types.Block {
 	return bc.genesisBlock
 }
 
+// CallTraceIndexed reports whether the call traces of the transactions are
+// indexed.
+func (bc *BlockChain) CallTraceIndexed() bool {
+	return bc.cacheConfig.CallTraceIndex
+}
+
+// AddressActivityIndexed reports whether the transactions are indexed by the
+// addresses taking part in them.
+func (bc *BlockChain) AddressActivityIndexed() bool {
+	return bc.cacheConfig.AddressActivity
+}
+
 // GetVMConfig returns the block chain VM config.
 func (bc *BlockChain) GetVMConfig() *vm.Config {
 	return &bc.vmConfig
@@ -387,6 +566,11 @@ func (bc *BlockChain) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscript
 	return bc.scope.Track(bc.logsFeed.Subscribe(ch))
 }
 
+// SubscribeReorgEvent registers a subscription of ReorgEvent.
+func (bc *BlockChain) SubscribeReorgEvent(ch chan<- ReorgEvent) event.Subscription {
+	return bc.scope.Track(bc.reorgFeed.Subscribe(ch))
+}
+
 // SubscribeBlockProcessingEvent registers a subscription of bool where true means
 // block processing has started while false means it has stopped.
 func (bc *BlockChain) SubscribeBlockProcessingEvent(ch chan<- bool) event.Subscription {
@@ -0,0 +1,186 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"runtime"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/log"
+)
+
+const (
+	// recoveryBatchSize is the number of blocks replayed at once while regenerating
+	// the state lost in an unclean shutdown.
+	recoveryBatchSize = 128
+
+	// recoveryRootCheckpoint is the number of blocks between state root
+	// verifications when regenerating state through the parallel import pipeline.
+	recoveryRootCheckpoint = 32
+
+	// recoveryReportInterval is the time between two state regeneration progress logs.
+	recoveryReportInterval = 8 * time.Second
+)
+
+// errRecoveryGap is returned if a canonical block to replay is missing from the
+// database, so the state can only be regenerated beyond it after syncing.
+var errRecoveryGap = errors.New("canonical block missing")
+
+// RecoveryProgress is the progress of regenerating the state lost in an unclean
+// shutdown by replaying the blocks above the last head with state available.
+type RecoveryProgress struct {
+	StartingBlock uint64    // Head block the chain was rewound to
+	CurrentBlock  uint64    // Last block whose state was regenerated
+	TargetBlock   uint64    // Head block before the state was lost
+	Parallel      bool      // Whether the parallel import pipeline is used
+	Started       time.Time // Time the regeneration started at
+}
+
+// ETA estimates the time remaining until the target block is reached, based on
+// the replay rate so far. Zero is returned if no estimate is available yet.
+func (p *RecoveryProgress) ETA() time.Duration {
+	done := p.CurrentBlock - p.StartingBlock
+	if done == 0 || p.CurrentBlock >= p.TargetBlock {
+		return 0
+	}
+	perBlock := float64(time.Since(p.Started)) / float64(done)
+	return time.Duration(perBlock * float64(p.TargetBlock-p.CurrentBlock))
+}
+
+// RecoveryProgress returns the progress of the running state regeneration, or
+// nil if no state is being regenerated.
+func (bc *BlockChain) RecoveryProgress() *RecoveryProgress {
+	bc.recoveryLock.RLock()
+	defer bc.recoveryLock.RUnlock()
+
+	if bc.recovery == nil {
+		return nil
+	}
+	progress := *bc.recovery
+	return &progress
+}
+
+// NeedsRecovery reports whether the head block was rewound after its state was
+// lost, leaving canonical blocks above it to replay.
+func (bc *BlockChain) NeedsRecovery() bool {
+	head := bc.CurrentBlock().NumberU64()
+	if bc.CurrentHeader().Number.Uint64() <= head {
+		return false
+	}
+	return bc.GetBlockByNumber(head+1) != nil
+}
+
+// RecoverState regenerates the state lost in an unclean shutdown by replaying the
+// canonical blocks retained above the head block, which was rewound to the last
+// block with its state available. If parallel is set, the blocks are replayed
+// through the parallel import pipeline, speculatively executing transactions on
+// all cores and verifying the state root of every few blocks only.
+func (bc *BlockChain) RecoverState(parallel bool) error {
+	head, target := bc.CurrentBlock().NumberU64(), bc.CurrentHeader().Number.Uint64()
+	if target <= head {
+		return nil
+	}
+	progress := &RecoveryProgress{
+		StartingBlock: head,
+		CurrentBlock:  head,
+		TargetBlock:   target,
+		Parallel:      parallel,
+		Started:       time.Now(),
+	}
+	bc.recoveryLock.Lock()
+	bc.recovery = progress
+	bc.recoveryLock.Unlock()
+
+	defer func() {
+		bc.recoveryLock.Lock()
+		bc.recovery = nil
+		bc.recoveryLock.Unlock()
+	}()
+	log.Info("Regenerating state lost in unclean shutdown", "head", head, "target", target, "parallel", parallel)
+
+	var (
+		blocks = make([]*types.Block, 0, recoveryBatchSize)
+		report = time.Now()
+	)
+	for number := head + 1; number <= target; {
+		var gap bool
+
+		blocks = blocks[:0]
+		for ; number <= target && len(blocks) < recoveryBatchSize; number++ {
+			block := bc.GetBlockByNumber(number)
+			if block == nil {
+				gap = true
+				break
+			}
+			blocks = append(blocks, block)
+		}
+		if len(blocks) > 0 {
+			if _, err := bc.InsertChain(blocks); err != nil {
+				return err
+			}
+			if bc.insertStopped() {
+				return errInsertionInterrupted
+			}
+		}
+		current := bc.CurrentBlock().NumberU64()
+
+		bc.recoveryLock.Lock()
+		progress.CurrentBlock = current
+		bc.recoveryLock.Unlock()
+
+		if gap {
+			log.Warn("Stopped state regeneration at chain gap", "number", current+1, "target", target)
+			return errRecoveryGap
+		}
+		if time.Since(report) > recoveryReportInterval {
+			log.Info("Regenerating state", "number", current, "target", target, "eta", common.PrettyDuration(progress.ETA()))
+			report = time.Now()
+		}
+	}
+	log.Info("Regenerated state lost in unclean shutdown", "head", bc.CurrentBlock().NumberU64(), "elapsed", common.PrettyDuration(time.Since(progress.Started)))
+	return nil
+}
+
+// recoveringParallel reports whether state is being regenerated through the
+// parallel import pipeline.
+func (bc *BlockChain) recoveringParallel() bool {
+	bc.recoveryLock.RLock()
+	defer bc.recoveryLock.RUnlock()
+
+	return bc.recovery != nil && bc.recovery.Parallel
+}
+
+// prefetchWorkers returns the number of goroutines to speculatively execute the
+// transactions of an imported block with.
+func (bc *BlockChain) prefetchWorkers() int {
+	if bc.cacheConfig.PrefetchWorkers <= 0 && bc.recoveringParallel() {
+		return runtime.NumCPU()
+	}
+	return bc.cacheConfig.PrefetchWorkers
+}
+
+// rootCheckpoint returns the number of blocks between state root verifications
+// during chain insertion.
+func (bc *BlockChain) rootCheckpoint() uint64 {
+	if bc.cacheConfig.RootCheckpoint <= 1 && bc.recoveringParallel() {
+		return recoveryRootCheckpoint
+	}
+	return bc.cacheConfig.RootCheckpoint
+}
@@ -0,0 +1,178 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/consensus/ethash"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/core/vm"
+	"github.com/scroll-tech/go-ethereum/params"
+)
+
+// Tests that the blocks left above the repaired head after a crash are replayed
+// to regenerate their state, both serially and through the parallel pipeline.
+func TestRecoverState(t *testing.T)         { testRecoverState(t, false) }
+func TestRecoverStateParallel(t *testing.T) { testRecoverState(t, true) }
+
+func testRecoverState(t *testing.T, parallel bool) {
+	datadir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("Failed to create temporary datadir: %v", err)
+	}
+	defer os.RemoveAll(datadir)
+
+	db, err := rawdb.NewLevelDBDatabaseWithFreezer(datadir, 0, 0, datadir, "", false)
+	if err != nil {
+		t.Fatalf("Failed to create persistent database: %v", err)
+	}
+	defer db.Close() // Might double close, should be fine
+
+	var (
+		genesis = (&Genesis{BaseFee: big.NewInt(params.InitialBaseFee)}).MustCommit(db)
+		engine  = ethash.NewFullFaker()
+		config  = &CacheConfig{
+			TrieCleanLimit: 256,
+			TrieDirtyLimit: 256,
+			TrieTimeLimit:  5 * time.Minute,
+		}
+	)
+	chain, err := NewBlockChain(db, config, params.AllEthashProtocolChanges, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create chain: %v", err)
+	}
+	blocks, _ := GenerateChain(params.TestChainConfig, genesis, engine, rawdb.NewMemoryDatabase(), 2*recoveryBatchSize+10, nil)
+	if _, err := chain.InsertChain(blocks[:4]); err != nil {
+		t.Fatalf("Failed to import chain start: %v", err)
+	}
+	chain.stateCache.TrieDB().Commit(blocks[3].Root(), true, nil)
+	if _, err := chain.InsertChain(blocks[4:]); err != nil {
+		t.Fatalf("Failed to import chain tail: %v", err)
+	}
+	// Pull the plug on the database, simulating a hard crash
+	db.Close()
+
+	db, err = rawdb.NewLevelDBDatabaseWithFreezer(datadir, 0, 0, datadir, "", false)
+	if err != nil {
+		t.Fatalf("Failed to reopen persistent database: %v", err)
+	}
+	defer db.Close()
+
+	chain, err = NewBlockChain(db, config, params.AllEthashProtocolChanges, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to recreate chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if head := chain.CurrentBlock().NumberU64(); head != 4 {
+		t.Fatalf("Repaired head block mismatch: have %d, want %d", head, 4)
+	}
+	if !chain.NeedsRecovery() {
+		t.Fatalf("Recovery not needed after repair")
+	}
+	if err := chain.RecoverState(parallel); err != nil {
+		t.Fatalf("Failed to recover state: %v", err)
+	}
+	if head := chain.CurrentBlock(); head.Hash() != blocks[len(blocks)-1].Hash() {
+		t.Fatalf("Recovered head block mismatch: have %d, want %d", head.NumberU64(), len(blocks))
+	}
+	if !chain.HasState(chain.CurrentBlock().Root()) {
+		t.Fatalf("Recovered head state missing")
+	}
+	if chain.NeedsRecovery() || chain.RecoveryProgress() != nil {
+		t.Fatalf("Recovery still pending after completion")
+	}
+}
+
+// Tests that if the state root of a checkpoint block doesn't match while the
+// state is regenerated through the parallel pipeline, the head block is left at
+// the last verified checkpoint with its state available.
+func TestRecoverStateParallelBadRoot(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		genesis = (&Genesis{BaseFee: big.NewInt(params.InitialBaseFee)}).MustCommit(db)
+		engine  = ethash.NewFullFaker()
+	)
+	chain, err := NewBlockChain(db, nil, params.AllEthashProtocolChanges, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create chain: %v", err)
+	}
+	blocks, _ := GenerateChain(params.TestChainConfig, genesis, engine, rawdb.NewMemoryDatabase(), 100, nil)
+	if _, err := chain.InsertChain(blocks[:4]); err != nil {
+		t.Fatalf("Failed to import chain start: %v", err)
+	}
+	chain.Stop()
+
+	// Retain the rest of the chain above the head without its state, with the
+	// root of a checkpoint block corrupted and the blocks on top relinked
+	var (
+		td     = chain.GetTd(blocks[3].Hash(), blocks[3].NumberU64())
+		parent = blocks[3]
+	)
+	for _, block := range blocks[4:] {
+		header := block.Header()
+		header.ParentHash = parent.Hash()
+		if header.Number.Uint64() == 2*recoveryRootCheckpoint {
+			header.Root = common.Hash{0x01}
+		}
+		block = types.NewBlockWithHeader(header).WithBody(block.Transactions(), block.Uncles())
+		td = new(big.Int).Add(td, block.Difficulty())
+
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteTd(db, block.Hash(), block.NumberU64(), td)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadHeaderHash(db, block.Hash())
+		parent = block
+	}
+	chain, err = NewBlockChain(db, nil, params.AllEthashProtocolChanges, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to recreate chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if !chain.NeedsRecovery() {
+		t.Fatalf("Recovery not needed with blocks above the head")
+	}
+	if err := chain.RecoverState(true); err == nil {
+		t.Fatalf("Recovery succeeded past a bad state root")
+	}
+	head := chain.CurrentBlock()
+	if head.NumberU64() != recoveryRootCheckpoint {
+		t.Fatalf("Head block mismatch after failed recovery: have %d, want %d", head.NumberU64(), recoveryRootCheckpoint)
+	}
+	if !chain.HasState(head.Root()) {
+		t.Fatalf("Head state missing after failed recovery")
+	}
+}
+
+func TestRecoveryProgressETA(t *testing.T) {
+	progress := &RecoveryProgress{StartingBlock: 100, CurrentBlock: 100, TargetBlock: 300, Started: time.Now().Add(-10 * time.Second)}
+	if eta := progress.ETA(); eta != 0 {
+		t.Fatalf("ETA without replayed blocks: have %v, want 0", eta)
+	}
+	progress.CurrentBlock = 200
+	if eta := progress.ETA(); eta < 9*time.Second || eta > 11*time.Second {
+		t.Fatalf("ETA mismatch: have %v, want about 10s", eta)
+	}
+}
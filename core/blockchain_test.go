@@ -20,10 +20,14 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"math/big"
 	"math/rand"
 	"os"
+	"reflect"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -1242,6 +1246,85 @@ done:
 
 }
 
+// Tests that a reorg event reports the replaced chain segments and splits the
+// transactions of the old segment into dropped and re-included ones.
+func TestReorgEvent(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		gspec   = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  GenesisAlloc{addr1: {Balance: big.NewInt(10000000000000000)}},
+		}
+		genesis  = gspec.MustCommit(db)
+		signer   = types.LatestSigner(gspec.Config)
+		gasPrice = big.NewInt(2 * params.InitialBaseFee)
+	)
+	blockchain, _ := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	defer blockchain.Stop()
+
+	// Both chains include the first transaction, only the old one the second
+	tx0, _ := types.SignTx(types.NewTransaction(0, common.Address{0x01}, big.NewInt(1), params.TxGas, gasPrice, nil), signer, key1)
+	tx1, _ := types.SignTx(types.NewTransaction(1, common.Address{0x01}, big.NewInt(1), params.TxGas, gasPrice, nil), signer, key1)
+
+	chain, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 3, func(i int, gen *BlockGen) {
+		switch i {
+		case 0:
+			gen.AddTx(tx0)
+		case 1:
+			gen.AddTx(tx1)
+		}
+	})
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	replacementBlocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 5, func(i int, gen *BlockGen) {
+		gen.SetCoinbase(common.Address{0x02})
+		if i == 1 {
+			gen.AddTx(tx0)
+		}
+	})
+	reorgCh := make(chan ReorgEvent, 8)
+	sub := blockchain.SubscribeReorgEvent(reorgCh)
+	defer sub.Unsubscribe()
+	if _, err := blockchain.InsertChain(replacementBlocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+
+	var ev ReorgEvent
+	select {
+	case ev = <-reorgCh:
+	case <-time.After(time.Second):
+		t.Fatal("no reorg event fired")
+	}
+	if ev.CommonAncestor.Hash() != genesis.Hash() {
+		t.Errorf("common ancestor mismatch: have %x, want %x", ev.CommonAncestor.Hash(), genesis.Hash())
+	}
+	if len(ev.OldChain) != len(chain) {
+		t.Fatalf("old chain length mismatch: have %d, want %d", len(ev.OldChain), len(chain))
+	}
+	for i, header := range ev.OldChain {
+		if want := chain[len(chain)-1-i].Hash(); header.Hash() != want {
+			t.Errorf("old chain block %d mismatch: have %x, want %x", i, header.Hash(), want)
+		}
+	}
+	if len(ev.NewChain) == 0 || ev.NewChain[len(ev.NewChain)-1].Hash() != replacementBlocks[0].Hash() {
+		t.Errorf("new chain does not start at the fork point")
+	}
+	if len(ev.Reincluded) != 1 || ev.Reincluded[0] != tx0.Hash() {
+		t.Errorf("re-included transactions mismatch: have %x, want [%x]", ev.Reincluded, tx0.Hash())
+	}
+	if len(ev.Dropped) != 1 || ev.Dropped[0] != tx1.Hash() {
+		t.Errorf("dropped transactions mismatch: have %x, want [%x]", ev.Dropped, tx1.Hash())
+	}
+	select {
+	case ev := <-reorgCh:
+		t.Errorf("unexpected reorg event: %v", ev)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
 // Tests if the canonical block can be fetched from the database during chain insertion.
 func TestCanonicalBlockRetrieval(t *testing.T) {
 	_, blockchain, err := newCanonical(ethash.NewFaker(), 0, true)
@@ -3336,3 +3419,730 @@ func TestEIP1559Transition(t *testing.T) {
 		t.Fatalf("sender balance incorrect: expected %d, got %d", expected, actual)
 	}
 }
+
+// Tests that importing a chain with root checkpoints only commits the state of
+// the checkpoint blocks and the last block, while still rejecting invalid roots.
+func TestInsertChainRootCheckpoint(t *testing.T) {
+	var (
+		gendb   = rawdb.NewMemoryDatabase()
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		funds   = big.NewInt(100000000000000000)
+		gspec   = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   GenesisAlloc{address: {Balance: funds}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		genesis = gspec.MustCommit(gendb)
+		signer  = types.LatestSigner(gspec.Config)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), gendb, 10, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x10, byte(i)}, big.NewInt(1000), params.TxGas, block.header.BaseFee, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+	newChain := func() *BlockChain {
+		db := rawdb.NewMemoryDatabase()
+		gspec.MustCommit(db)
+
+		cacheConfig := *defaultCacheConfig
+		cacheConfig.RootCheckpoint = 4
+		chain, err := NewBlockChain(db, &cacheConfig, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+		if err != nil {
+			t.Fatalf("failed to create tester chain: %v", err)
+		}
+		return chain
+	}
+	// Import the valid chain and ensure only the checkpoints have their state
+	chain := newChain()
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	if head := chain.CurrentBlock(); head.Hash() != blocks[len(blocks)-1].Hash() {
+		t.Fatalf("head mismatch: have %d, want %d", head.NumberU64(), blocks[len(blocks)-1].NumberU64())
+	}
+	for _, block := range blocks {
+		want := block.NumberU64()%4 == 0 || block.NumberU64() == 10
+		if have := chain.HasState(block.Root()); have != want {
+			t.Errorf("block %d: state availability mismatch: have %v, want %v", block.NumberU64(), have, want)
+		}
+	}
+	state, err := chain.State()
+	if err != nil {
+		t.Fatalf("failed to retrieve head state: %v", err)
+	}
+	for i := range blocks {
+		if balance := state.GetBalance(common.Address{0x10, byte(i)}); balance.Cmp(big.NewInt(1000)) != 0 {
+			t.Errorf("recipient %d: balance mismatch: have %v, want %v", i, balance, 1000)
+		}
+	}
+	// Corrupt the root of a checkpoint and ensure the import is rejected
+	header := blocks[7].Header()
+	header.Root = common.Hash{0x01}
+	bad := types.NewBlockWithHeader(header).WithBody(blocks[7].Transactions(), blocks[7].Uncles())
+
+	chain2 := newChain()
+	defer chain2.Stop()
+
+	invalid := append(types.Blocks{}, blocks[:7]...)
+	if n, err := chain2.InsertChain(append(invalid, bad)); err == nil {
+		t.Fatalf("invalid checkpoint root accepted")
+	} else if n != 7 {
+		t.Fatalf("failed block index mismatch: have %d, want %d", n, 7)
+	} else if !strings.Contains(err.Error(), "deferred since block 5") {
+		t.Fatalf("error does not report deferred range: %v", err)
+	}
+	// Ensure the head stayed at the last verified checkpoint with its state
+	// available, and the blocks with deferred roots didn't become canonical
+	if head := chain2.CurrentBlock(); head.Hash() != blocks[3].Hash() {
+		t.Fatalf("head mismatch after rejected checkpoint: have %d, want %d", head.NumberU64(), blocks[3].NumberU64())
+	}
+	if _, err := chain2.State(); err != nil {
+		t.Fatalf("head state unavailable after rejected checkpoint: %v", err)
+	}
+	for _, block := range blocks[4:7] {
+		if hash := rawdb.ReadCanonicalHash(chain2.db, block.NumberU64()); hash != (common.Hash{}) {
+			t.Errorf("block %d: deferred block canonical after rejected checkpoint", block.NumberU64())
+		}
+	}
+	// Ensure the valid chain can still be imported on top
+	if n, err := chain2.InsertChain(blocks[4:]); err != nil {
+		t.Fatalf("block %d: failed to insert valid chain after rejected checkpoint: %v", n, err)
+	}
+	if head := chain2.CurrentBlock(); head.Hash() != blocks[len(blocks)-1].Hash() {
+		t.Fatalf("head mismatch: have %d, want %d", head.NumberU64(), blocks[len(blocks)-1].NumberU64())
+	}
+}
+
+// Tests that the witness of a bad block is captured in the background, and that
+// bad blocks arriving while a capture is running are not replayed.
+func TestBadBlockWitnessCapture(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   GenesisAlloc{address: {Balance: big.NewInt(100000000000000000)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		gendb   = rawdb.NewMemoryDatabase()
+		genesis = gspec.MustCommit(gendb)
+		signer  = types.LatestSigner(gspec.Config)
+	)
+	gspec.MustCommit(db)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), gendb, 2, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x10}, big.NewInt(1000), params.TxGas, block.header.BaseFee, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+	chain, err := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	corrupt := func(block *types.Block, root common.Hash) *types.Block {
+		header := block.Header()
+		header.Root = root
+		return types.NewBlockWithHeader(header).WithBody(block.Transactions(), block.Uncles())
+	}
+	bad := corrupt(blocks[0], common.Hash{0x01})
+	if _, err := chain.InsertChain(types.Blocks{bad}); err == nil {
+		t.Fatalf("bad block accepted")
+	}
+	for start := time.Now(); rawdb.ReadBadBlockWitness(db, bad.Hash()) == nil; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("bad block witness not captured")
+		}
+	}
+	if witness := rawdb.ReadBadBlockWitness(db, bad.Hash()); len(witness.ExecutionResults) != 1 {
+		t.Errorf("witness execution results mismatch: have %d, want %d", len(witness.ExecutionResults), 1)
+	}
+	// Pretend a capture is running and ensure the next bad block is skipped
+	atomic.StoreInt32(&chain.badWitness, 1)
+	skipped := corrupt(blocks[0], common.Hash{0x02})
+	if _, err := chain.InsertChain(types.Blocks{skipped}); err == nil {
+		t.Fatalf("bad block accepted")
+	}
+	chain.Stop()
+	if rawdb.ReadBadBlock(db, skipped.Hash()) == nil {
+		t.Errorf("skipped bad block not stored")
+	}
+	if rawdb.ReadBadBlockWitness(db, skipped.Hash()) != nil {
+		t.Errorf("witness captured while another capture was running")
+	}
+}
+
+// Tests that the state root index only reports the canonical blocks a state root
+// was committed for.
+func TestStateRootBlockIndex(t *testing.T) {
+	_, chain, err := newCanonical(ethash.NewFaker(), 4, true)
+	if err != nil {
+		t.Fatalf("failed to create pristine chain: %v", err)
+	}
+	defer chain.Stop()
+
+	var roots []common.Hash
+	for i := uint64(1); i <= 4; i++ {
+		root := chain.GetHeaderByNumber(i).Root
+		rawdb.WriteStateRootBlockNumber(chain.db, root, i)
+		roots = append(roots, root)
+	}
+	// Add a stale entry, as left behind by a reorged or side chain block, and
+	// a duplicate
+	rawdb.WriteStateRootBlockNumber(chain.db, roots[1], 3)
+	rawdb.WriteStateRootBlockNumber(chain.db, roots[1], 2)
+
+	if have := rawdb.ReadStateRootBlockNumbers(chain.db, roots[1]); !reflect.DeepEqual(have, []uint64{2, 3}) {
+		t.Fatalf("indexed block numbers mismatch: have %v, want %v", have, []uint64{2, 3})
+	}
+	for i, root := range roots {
+		if have, want := chain.GetStateRootBlockNumbers(root), []uint64{uint64(i + 1)}; !reflect.DeepEqual(have, want) {
+			t.Errorf("root %d: block numbers mismatch: have %v, want %v", i, have, want)
+		}
+	}
+}
+
+// Tests that body roots verified ahead of the import are reported against the
+// right block, leaving the chain at the last valid block.
+func TestInsertChainPrevalidatedBodies(t *testing.T) {
+	var (
+		gendb   = rawdb.NewMemoryDatabase()
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   GenesisAlloc{address: {Balance: big.NewInt(100000000000000000)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		genesis = gspec.MustCommit(gendb)
+		signer  = types.LatestSigner(gspec.Config)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), gendb, 2*bodyValidationAhead, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x10, byte(i)}, big.NewInt(1000), params.TxGas, block.header.BaseFee, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+	// Swap the transactions of a block beyond the first validation window
+	bad := bodyValidationAhead + 3
+	blocks[bad] = blocks[bad].WithBody(blocks[bad-1].Transactions(), nil)
+
+	db := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(db)
+	chain, err := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	n, err := chain.InsertChain(blocks)
+	if err == nil || !strings.Contains(err.Error(), "transaction root hash mismatch") {
+		t.Fatalf("bad body not detected: %v", err)
+	}
+	if n != bad {
+		t.Errorf("failure index mismatch: have %d, want %d", n, bad)
+	}
+	if head := chain.CurrentBlock().NumberU64(); head != blocks[bad-1].NumberU64() {
+		t.Errorf("head mismatch: have %d, want %d", head, blocks[bad-1].NumberU64())
+	}
+}
+
+// Tests that the sender and nonce transaction index follows the canonical chain,
+// including replacing and dropping the entries of reorged out transactions.
+func TestSenderNonceIndex(t *testing.T) {
+	var (
+		gendb   = rawdb.NewMemoryDatabase()
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   GenesisAlloc{address: {Balance: big.NewInt(100000000000000000)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		genesis = gspec.MustCommit(gendb)
+		signer  = types.LatestSigner(gspec.Config)
+	)
+	// Create a chain with a transfer in every block and a longer fork replacing
+	// the first two transfers and omitting the third one
+	transfer := func(to byte, txs int) func(int, *BlockGen) {
+		return func(i int, block *BlockGen) {
+			if i >= txs {
+				return
+			}
+			tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{to, byte(i)}, big.NewInt(1000), params.TxGas, block.header.BaseFee, nil), signer, key)
+			if err != nil {
+				panic(err)
+			}
+			block.AddTx(tx)
+		}
+	}
+	original, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), gendb, 3, transfer(0x10, 3))
+	fork, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), gendb, 4, transfer(0x20, 2))
+
+	db := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(db)
+
+	config := *defaultCacheConfig
+	config.SenderNonceIndex = true
+	chain, err := NewBlockChain(db, &config, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	check := func(blocks []*types.Block, count int) {
+		t.Helper()
+		for nonce := uint64(0); nonce < 3; nonce++ {
+			hash := rawdb.ReadSenderNonceLookup(db, address, nonce)
+			if int(nonce) >= count {
+				if hash != nil {
+					t.Errorf("nonce %d: stale lookup %x", nonce, *hash)
+				}
+				continue
+			}
+			if want := blocks[nonce].Transactions()[0].Hash(); hash == nil || *hash != want {
+				t.Errorf("nonce %d: lookup mismatch: have %v, want %x", nonce, hash, want)
+			}
+		}
+	}
+	if _, err := chain.InsertChain(original); err != nil {
+		t.Fatalf("failed to insert original chain: %v", err)
+	}
+	check(original, 3)
+
+	if _, err := chain.InsertChain(fork); err != nil {
+		t.Fatalf("failed to insert forked chain: %v", err)
+	}
+	check(fork, 2)
+}
+
+// Tests that the address activity index records the direct and the internal
+// parties of transactions, skips reverted transfers, pages by whole blocks and
+// only serves the canonical chain.
+func TestAddressActivityIndex(t *testing.T) {
+	var (
+		key, _      = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender      = crypto.PubkeyToAddress(key.PublicKey)
+		forwarder   = common.HexToAddress("0xf0")
+		reverter    = common.HexToAddress("0xf1")
+		beneficiary = common.HexToAddress("0xbb")
+		reverted    = common.HexToAddress("0xcc")
+	)
+	// forward calls the target with the received value, optionally reverting
+	forward := func(target common.Address, revert bool) []byte {
+		code := []byte{0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x34, 0x73}
+		code = append(code, target.Bytes()...)
+		code = append(code, 0x5a, 0xf1)
+		if revert {
+			return append(code, 0x60, 0x00, 0x60, 0x00, 0xfd)
+		}
+		return append(code, 0x00)
+	}
+	var (
+		gendb = rawdb.NewMemoryDatabase()
+		gspec = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc: GenesisAlloc{
+				sender:    {Balance: big.NewInt(100000000000000000)},
+				forwarder: {Balance: common.Big0, Code: forward(beneficiary, false)},
+				reverter:  {Balance: common.Big0, Code: forward(reverted, true)},
+			},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		genesis = gspec.MustCommit(gendb)
+		signer  = types.LatestSigner(gspec.Config)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), gendb, 3, func(i int, block *BlockGen) {
+		for _, to := range []common.Address{forwarder, reverter} {
+			tx, err := types.SignTx(types.NewTransaction(block.TxNonce(sender), to, big.NewInt(1000), 100000, block.header.BaseFee, nil), signer, key)
+			if err != nil {
+				panic(err)
+			}
+			block.AddTx(tx)
+		}
+	})
+	fork, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), gendb, 4, func(i int, block *BlockGen) {})
+
+	db := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(db)
+
+	config := *defaultCacheConfig
+	config.AddressActivity, config.CallTraceIndex = true, true
+	logger := vm.NewStructLogger(nil)
+	chain, err := NewBlockChain(db, &config, gspec.Config, ethash.NewFaker(), vm.Config{Debug: true, Tracer: logger}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	// The configured tracer keeps running next to the index collectors
+	if len(logger.StructLogs()) == 0 {
+		t.Errorf("configured tracer not invoked")
+	}
+	// Both indexes are populated when their tracers run side by side
+	if trace := chain.GetCallTrace(blocks[0].Transactions()[0].Hash()); trace == nil || len(trace.Calls) != 1 {
+		t.Fatalf("call trace mismatch: %+v", trace)
+	}
+	// The forwarded transfers are indexed as internal activity, reverted ones not
+	entries, next := chain.GetAddressActivity(beneficiary, 0, 100)
+	if len(entries) != 3 || next != 0 {
+		t.Fatalf("beneficiary activity mismatch: have %d entries, next %d, want 3, 0", len(entries), next)
+	}
+	for i, entry := range entries {
+		if entry.BlockHash != blocks[i].Hash() || entry.TxHash != blocks[i].Transactions()[0].Hash() || entry.Flags != rawdb.ActivityInternal {
+			t.Errorf("beneficiary entry %d mismatch: %+v", i, entry)
+		}
+	}
+	if entries, _ := chain.GetAddressActivity(reverted, 0, 100); len(entries) != 0 {
+		t.Errorf("reverted transfer indexed: %+v", entries)
+	}
+	if entries, _ := chain.GetAddressActivity(forwarder, 0, 100); len(entries) != 3 || entries[0].Flags != rawdb.ActivityReceived|rawdb.ActivityInternal {
+		t.Errorf("forwarder activity mismatch: %+v", entries)
+	}
+	if entries, _ := chain.GetAddressActivity(reverter, 0, 100); len(entries) != 3 || entries[0].Flags != rawdb.ActivityReceived {
+		t.Errorf("reverter activity mismatch: %+v", entries)
+	}
+	// Pages never split blocks
+	entries, next = chain.GetAddressActivity(sender, 0, 3)
+	if len(entries) != 4 || next != 3 {
+		t.Fatalf("first page mismatch: have %d entries, next %d, want 4, 3", len(entries), next)
+	}
+	for _, entry := range entries {
+		if entry.Flags != rawdb.ActivitySent {
+			t.Errorf("sender flags mismatch: have %x, want %x", entry.Flags, rawdb.ActivitySent)
+		}
+	}
+	if entries, next = chain.GetAddressActivity(sender, next, 3); len(entries) != 2 || next != 0 {
+		t.Fatalf("second page mismatch: have %d entries, next %d, want 2, 0", len(entries), next)
+	}
+	// Reorg to a chain without the transactions, the entries must not be served
+	if _, err := chain.InsertChain(fork); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	if entries, _ := chain.GetAddressActivity(sender, 0, 100); len(entries) != 0 {
+		t.Errorf("reorged out activity served: %+v", entries)
+	}
+}
+
+// Tests that the call trace index only serves the traces of canonical transactions
+// across rewinds and reorgs, including blocks which become canonical through a
+// reorg after having been processed as side chain blocks.
+func TestCallTraceIndexRewind(t *testing.T) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender = crypto.PubkeyToAddress(key.PublicKey)
+		gendb  = rawdb.NewMemoryDatabase()
+		gspec  = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   GenesisAlloc{sender: {Balance: big.NewInt(100000000000000000)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		genesis = gspec.MustCommit(gendb)
+		signer  = types.LatestSigner(gspec.Config)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), gendb, 5, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(sender), common.Address{0xbb}, big.NewInt(1000), params.TxGas, block.header.BaseFee, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+	fork, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), gendb, 4, func(i int, block *BlockGen) {})
+
+	db := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(db)
+
+	config := *defaultCacheConfig
+	config.TrieDirtyDisabled, config.CallTraceIndex = true, true
+	chain, err := NewBlockChain(db, &config, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks[:3]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	check := func(indexed ...bool) {
+		t.Helper()
+		for i, want := range indexed {
+			if have := chain.GetCallTrace(blocks[i].Transactions()[0].Hash()) != nil; have != want {
+				t.Errorf("block %d: call trace presence mismatch: have %v, want %v", i+1, have, want)
+			}
+		}
+	}
+	check(true, true, true)
+
+	// Rewinding drops the traces of the blocks above the new head
+	if err := chain.SetHead(2); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	check(true, true, false)
+
+	// Reorging to a chain without the transactions hides the remaining ones
+	if _, err := chain.InsertChain(fork); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	check(false, false, false)
+
+	// Reorging back serves the traces of the blocks processed as side blocks
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	check(true, true, true, true, true)
+}
+
+// Tests that the token transfer index serves the canonical transfers by token
+// and by holder, paged by log position.
+func TestTokenTransferIndex(t *testing.T) {
+	var (
+		key, _    = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender    = crypto.PubkeyToAddress(key.PublicKey)
+		token     = common.HexToAddress("0x7070")
+		recipient = common.HexToAddress("0xbb")
+	)
+	// The token emits Transfer(caller, recipient, callvalue) on every call
+	code := []byte{0x34, 0x60, 0x00, 0x52, 0x73}
+	code = append(code, recipient.Bytes()...)
+	code = append(code, 0x33, 0x7f)
+	code = append(code, types.TransferEventTopic.Bytes()...)
+	code = append(code, 0x60, 0x20, 0x60, 0x00, 0xa3, 0x00)
+
+	var (
+		gendb = rawdb.NewMemoryDatabase()
+		gspec = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc: GenesisAlloc{
+				sender: {Balance: big.NewInt(100000000000000000)},
+				token:  {Balance: common.Big0, Code: code},
+			},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		genesis = gspec.MustCommit(gendb)
+		signer  = types.LatestSigner(gspec.Config)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), gendb, 2, func(i int, block *BlockGen) {
+		for j := 0; j < 2; j++ {
+			tx, err := types.SignTx(types.NewTransaction(block.TxNonce(sender), token, big.NewInt(int64(2*i+j+1)), 100000, block.header.BaseFee, nil), signer, key)
+			if err != nil {
+				panic(err)
+			}
+			block.AddTx(tx)
+		}
+	})
+	fork, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), gendb, 3, func(i int, block *BlockGen) {})
+
+	db := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(db)
+
+	config := *defaultCacheConfig
+	config.TokenTransfers = true
+	chain, err := NewBlockChain(db, &config, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	transfers, next := chain.GetTokenTransfers(&token, nil, 0, 0, math.MaxUint64, 3)
+	if len(transfers) != 3 || next == nil {
+		t.Fatalf("first page mismatch: have %d transfers, next %v", len(transfers), next)
+	}
+	for i, transfer := range transfers {
+		if transfer.From != sender || transfer.To != recipient || transfer.Value.Int64() != int64(i+1) {
+			t.Errorf("transfer %d mismatch: %+v", i, transfer)
+		}
+	}
+	if next.BlockNumber != 2 || next.LogIndex != 1 || next.TxHash != blocks[1].Transactions()[1].Hash() {
+		t.Errorf("next transfer mismatch: %+v", next)
+	}
+	if transfers, next = chain.GetTokenTransfers(&token, nil, next.BlockNumber, uint32(next.LogIndex), math.MaxUint64, 3); len(transfers) != 1 || next != nil {
+		t.Fatalf("second page mismatch: have %d transfers, next %v", len(transfers), next)
+	}
+	if transfers, _ := chain.GetTokenTransfers(nil, &recipient, 0, 0, 1, 10); len(transfers) != 2 {
+		t.Errorf("holder transfers up to block 1 mismatch: have %d, want 2", len(transfers))
+	}
+	if transfers, _ := chain.GetTokenTransfers(&recipient, &sender, 0, 0, math.MaxUint64, 10); len(transfers) != 0 {
+		t.Errorf("holder transfers of other token returned: %d", len(transfers))
+	}
+	// Reorg to a chain without the transfers, they must not be served
+	if _, err := chain.InsertChain(fork); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	if transfers, _ := chain.GetTokenTransfers(nil, &sender, 0, 0, math.MaxUint64, 10); len(transfers) != 0 {
+		t.Errorf("reorged out transfers served: %d", len(transfers))
+	}
+}
+
+// Tests that the event log index serves the canonical logs of a contract and
+// event signature, paged by log position.
+func TestEventLogIndex(t *testing.T) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0x7070")
+		topic    = crypto.Keccak256Hash([]byte("Ping()"))
+	)
+	// The contract emits an anonymous log and a Ping() log on every call
+	code := []byte{0x60, 0x00, 0x60, 0x00, 0xa0, 0x7f}
+	code = append(code, topic.Bytes()...)
+	code = append(code, 0x60, 0x00, 0x60, 0x00, 0xa1, 0x00)
+
+	var (
+		gendb = rawdb.NewMemoryDatabase()
+		gspec = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc: GenesisAlloc{
+				sender:   {Balance: big.NewInt(100000000000000000)},
+				contract: {Balance: common.Big0, Code: code},
+			},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		genesis = gspec.MustCommit(gendb)
+		signer  = types.LatestSigner(gspec.Config)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), gendb, 2, func(i int, block *BlockGen) {
+		for j := 0; j < 2; j++ {
+			tx, err := types.SignTx(types.NewTransaction(block.TxNonce(sender), contract, common.Big0, 100000, block.header.BaseFee, nil), signer, key)
+			if err != nil {
+				panic(err)
+			}
+			block.AddTx(tx)
+		}
+	})
+	fork, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), gendb, 3, func(i int, block *BlockGen) {})
+
+	db := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(db)
+
+	config := *defaultCacheConfig
+	config.EventLogIndex = true
+	chain, err := NewBlockChain(db, &config, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	logs, next := chain.GetEventLogs(contract, topic, 0, 0, math.MaxUint64, 3)
+	if len(logs) != 3 || next == nil {
+		t.Fatalf("first page mismatch: have %d logs, next %v", len(logs), next)
+	}
+	for i, l := range logs {
+		block, tx := blocks[i/2], blocks[i/2].Transactions()[i%2]
+		if l.Address != contract || len(l.Topics) != 1 || l.Topics[0] != topic {
+			t.Errorf("log %d mismatch: %+v", i, l)
+		}
+		if l.BlockHash != block.Hash() || l.TxHash != tx.Hash() || l.Index != uint(2*(i%2)+1) {
+			t.Errorf("log %d position mismatch: %+v", i, l)
+		}
+	}
+	if next.BlockNumber != 2 || next.LogIndex != 3 || next.TxIndex != 1 {
+		t.Errorf("next log mismatch: %+v", next)
+	}
+	if logs, next = chain.GetEventLogs(contract, topic, next.BlockNumber, next.LogIndex, math.MaxUint64, 3); len(logs) != 1 || next != nil {
+		t.Fatalf("second page mismatch: have %d logs, next %v", len(logs), next)
+	}
+	if logs, _ := chain.GetEventLogs(contract, topic, 0, 0, 1, 10); len(logs) != 2 {
+		t.Errorf("logs up to block 1 mismatch: have %d, want 2", len(logs))
+	}
+	if logs, _ := chain.GetEventLogs(contract, common.Hash{}, 0, 0, math.MaxUint64, 10); len(logs) != 0 {
+		t.Errorf("anonymous logs served: %d", len(logs))
+	}
+	// Reorg to a chain without the logs, they must not be served
+	if _, err := chain.InsertChain(fork); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	if logs, _ := chain.GetEventLogs(contract, topic, 0, 0, math.MaxUint64, 10); len(logs) != 0 {
+		t.Errorf("reorged out logs served: %d", len(logs))
+	}
+}
+
+// Tests that the zk trie leaf epochs are only recorded for the state accessed by
+// canonical blocks, not by side chain blocks until a reorg makes them canonical.
+func TestLeafEpochsCanonicalOnly(t *testing.T) {
+	config := *params.TestChainConfig
+	config.Zktrie = true
+
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		canon   = common.HexToAddress("0xaaaa")
+		side    = common.HexToAddress("0xbbbb")
+		db      = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{
+			Config:  &config,
+			Alloc:   GenesisAlloc{address: {Balance: big.NewInt(1000000000000000000)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.LatestSigner(gspec.Config)
+		engine  = ethash.NewFaker()
+	)
+	transfer := func(to common.Address) func(int, *BlockGen) {
+		return func(i int, gen *BlockGen) {
+			tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(address), to, big.NewInt(1), params.TxGas, gen.header.BaseFee, nil), signer, key)
+			gen.AddTx(tx)
+		}
+	}
+	blocks, _ := GenerateChain(gspec.Config, genesis, engine, db, 2, transfer(canon))
+	forks, _ := GenerateChain(gspec.Config, genesis, engine, db, 3, func(i int, gen *BlockGen) {
+		if i == 0 {
+			transfer(side)(i, gen)
+		}
+	})
+
+	chain, err := NewBlockChain(db, nil, gspec.Config, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	if _, err := chain.InsertChain(forks[:1]); err != nil {
+		t.Fatalf("failed to import side chain: %v", err)
+	}
+	if chain.CurrentBlock().Hash() != blocks[1].Hash() {
+		t.Fatalf("side chain became canonical")
+	}
+	check := func(want map[common.Address]bool) {
+		t.Helper()
+		for addr, want := range want {
+			leaf, err := trie.ZkTrieKey(addr.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if have, _ := db.Has(rawdb.ZkLeafEpochKey(common.Hash{}, leaf)); have != want {
+				t.Errorf("account %x: epoch recorded mismatch: have %v, want %v", addr, have, want)
+			}
+		}
+	}
+	check(map[common.Address]bool{address: true, canon: true, side: false})
+
+	// Extending the side chain reorgs its first block in, which is recorded
+	// even though the block is not the new head
+	if _, err := chain.InsertChain(forks[1:]); err != nil {
+		t.Fatalf("failed to import side chain: %v", err)
+	}
+	if chain.CurrentBlock().Hash() != forks[2].Hash() {
+		t.Fatalf("side chain not canonical")
+	}
+	check(map[common.Address]bool{side: true})
+}
@@ -18,6 +18,7 @@ package core
 
 import (
 	"context"
+	"math"
 	"time"
 
 	"github.com/scroll-tech/go-ethereum/common"
@@ -26,6 +27,8 @@ import (
 	"github.com/scroll-tech/go-ethereum/core/rawdb"
 	"github.com/scroll-tech/go-ethereum/core/types"
 	"github.com/scroll-tech/go-ethereum/ethdb"
+	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/params"
 )
 
 const (
@@ -56,6 +59,43 @@ func NewBloomIndexer(db ethdb.Database, size, confirms uint64) *ChainIndexer {
 	return NewChainIndexer(db, table, backend, size, confirms, bloomThrottling, "bloombits")
 }
 
+// CheckBloomSectionSize ensures that the bloom bits index in the database was
+// built with the given section size. If the section size changed, the entire
+// index is wiped so that the bloom indexer regenerates it from scratch.
+func CheckBloomSectionSize(db ethdb.Database, size uint64) {
+	stored := rawdb.ReadBloomBitsSectionSize(db)
+	if stored != nil && *stored == size {
+		return
+	}
+	// Databases predating the marker were always built with the default size
+	if stored == nil && size == params.BloomBitsBlocks {
+		rawdb.WriteBloomBitsSectionSize(db, size)
+		return
+	}
+	if stored != nil {
+		log.Warn("Bloom section size changed, reindexing", "old", *stored, "new", size)
+	} else {
+		log.Warn("Bloom section size changed, reindexing", "old", params.BloomBitsBlocks, "new", size)
+	}
+	start := time.Now()
+	for i := 0; i < types.BloomBitLength; i++ {
+		rawdb.DeleteBloombits(db, uint(i), 0, math.MaxUint64)
+	}
+	// Drop the progress markers of the chain indexer too
+	table := rawdb.NewTable(db, string(rawdb.BloomBitsIndexPrefix))
+	it := table.NewIterator(nil, nil)
+	batch := table.NewBatch()
+	for it.Next() {
+		batch.Delete(it.Key())
+	}
+	it.Release()
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to delete bloom index progress", "err", err)
+	}
+	rawdb.WriteBloomBitsSectionSize(db, size)
+	log.Info("Wiped bloom bits index", "elapsed", common.PrettyDuration(time.Since(start)))
+}
+
 // Reset implements core.ChainIndexerBackend, starting a new bloombits index
 // section.
 func (b *BloomIndexer) Reset(ctx context.Context, section uint64, lastSectionHead common.Hash) error {
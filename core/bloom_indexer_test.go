@@ -0,0 +1,55 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/params"
+)
+
+// Tests that changing the bloom section size wipes the bloom bits index.
+func TestCheckBloomSectionSize(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	head := common.HexToHash("0xdeadbeef")
+
+	// A legacy database without the marker is assumed to use the default size
+	rawdb.WriteBloomBits(db, 1, 0, head, []byte{0x01})
+	CheckBloomSectionSize(db, params.BloomBitsBlocks)
+	if size := rawdb.ReadBloomBitsSectionSize(db); size == nil || *size != params.BloomBitsBlocks {
+		t.Fatalf("section size mismatch: have %v, want %d", size, params.BloomBitsBlocks)
+	}
+	if bits, _ := rawdb.ReadBloomBits(db, 1, 0, head); len(bits) == 0 {
+		t.Fatalf("bloom bits wiped without section size change")
+	}
+	// Switching to fine-grained sections should drop the index
+	table := rawdb.NewTable(db, string(rawdb.BloomBitsIndexPrefix))
+	table.Put([]byte("count"), []byte{0x01})
+
+	CheckBloomSectionSize(db, params.BloomBitsBlocksFine)
+	if size := rawdb.ReadBloomBitsSectionSize(db); size == nil || *size != params.BloomBitsBlocksFine {
+		t.Fatalf("section size mismatch: have %v, want %d", size, params.BloomBitsBlocksFine)
+	}
+	if bits, _ := rawdb.ReadBloomBits(db, 1, 0, head); len(bits) != 0 {
+		t.Fatalf("bloom bits not wiped on section size change")
+	}
+	if ok, _ := table.Has([]byte("count")); ok {
+		t.Fatalf("bloom indexer progress not wiped on section size change")
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/core/vm"
+)
+
+// CallTraceCollector is a lightweight vm.EVMLogger which records the call frames
+// of all the transactions executed in a block, in execution order. It's used to
+// populate the call trace index during block import and local sealing.
+type CallTraceCollector struct {
+	traces    []*types.CallTrace // Top-level call frames, one per transaction
+	callstack []*types.CallTrace // Call frames currently being executed
+}
+
+// NewCallTraceCollector creates a call trace collector for a single block.
+func NewCallTraceCollector() *CallTraceCollector {
+	return &CallTraceCollector{}
+}
+
+// Traces returns the call traces collected so far, one per executed transaction.
+func (c *CallTraceCollector) Traces() []*types.CallTrace {
+	return c.traces
+}
+
+// CaptureStart implements vm.EVMLogger, opening the top-level call frame of a
+// new transaction.
+func (c *CallTraceCollector) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	typ := vm.CALL
+	if create {
+		typ = vm.CREATE
+	}
+	frame := newCallTrace(typ, from, to, input, gas, value)
+	c.traces = append(c.traces, frame)
+	c.callstack = append(c.callstack[:0], frame)
+}
+
+// CaptureEnd implements vm.EVMLogger, closing the top-level call frame.
+func (c *CallTraceCollector) CaptureEnd(output []byte, gasUsed uint64, _ time.Duration, err error) {
+	if len(c.callstack) == 0 {
+		return
+	}
+	closeCallTrace(c.callstack[0], output, gasUsed, err)
+	c.callstack = c.callstack[:0]
+}
+
+// CaptureEnter implements vm.EVMLogger, opening a nested call frame.
+func (c *CallTraceCollector) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if len(c.callstack) == 0 {
+		return
+	}
+	frame := newCallTrace(typ, from, to, input, gas, value)
+	parent := c.callstack[len(c.callstack)-1]
+	parent.Calls = append(parent.Calls, frame)
+	c.callstack = append(c.callstack, frame)
+}
+
+// CaptureExit implements vm.EVMLogger, closing a nested call frame.
+func (c *CallTraceCollector) CaptureExit(output []byte, gasUsed uint64, err error) {
+	if len(c.callstack) <= 1 {
+		return
+	}
+	frame := c.callstack[len(c.callstack)-1]
+	c.callstack = c.callstack[:len(c.callstack)-1]
+
+	closeCallTrace(frame, output, gasUsed, err)
+	if err != nil && (frame.Type == vm.CREATE.String() || frame.Type == vm.CREATE2.String()) {
+		frame.To = common.Address{}
+	}
+}
+
+// CaptureState implements vm.EVMLogger, it's a noop.
+func (c *CallTraceCollector) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+// CaptureStateAfter implements vm.EVMLogger, it's a noop.
+func (c *CallTraceCollector) CaptureStateAfter(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+// CaptureFault implements vm.EVMLogger, it's a noop.
+func (c *CallTraceCollector) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+// newCallTrace creates a new call frame, copying the mutable inputs.
+func newCallTrace(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) *types.CallTrace {
+	frame := &types.CallTrace{
+		Type:  typ.String(),
+		From:  from,
+		To:    to,
+		Gas:   gas,
+		Input: common.CopyBytes(input),
+	}
+	if value != nil {
+		frame.Value = new(big.Int).Set(value)
+	}
+	return frame
+}
+
+// closeCallTrace fills in the results of a finished call frame.
+func closeCallTrace(frame *types.CallTrace, output []byte, gasUsed uint64, err error) {
+	frame.GasUsed = gasUsed
+	if err != nil {
+		frame.Error = err.Error()
+		if err != vm.ErrExecutionReverted {
+			return
+		}
+	}
+	frame.Output = common.CopyBytes(output)
+}
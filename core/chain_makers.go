@@ -28,6 +28,7 @@ import (
 	"github.com/scroll-tech/go-ethereum/core/vm"
 	"github.com/scroll-tech/go-ethereum/ethdb"
 	"github.com/scroll-tech/go-ethereum/params"
+	"github.com/scroll-tech/go-ethereum/trie"
 )
 
 // BlockGen creates blocks for testing.
@@ -111,6 +112,25 @@ func (b *BlockGen) AddTxWithChain(bc *BlockChain, tx *types.Transaction) {
 	b.receipts = append(b.receipts, receipt)
 }
 
+// addSystemTxs opens the block with the system transactions required by the
+// protocol. They don't take gas from the block, so the coinbase may still be set
+// afterwards.
+func (b *BlockGen) addSystemTxs() {
+	txs, err := SystemTransactions(b.config, b.header, b.statedb)
+	if err != nil {
+		panic(err)
+	}
+	for _, tx := range txs {
+		b.statedb.Prepare(tx.Hash(), len(b.txs))
+		receipt, err := ApplyTransaction(b.config, nil, &b.header.Coinbase, new(GasPool), b.statedb, b.header, tx, &b.header.GasUsed, vm.Config{})
+		if err != nil {
+			panic(err)
+		}
+		b.txs = append(b.txs, tx)
+		b.receipts = append(b.receipts, receipt)
+	}
+}
+
 // GetBalance returns the balance of the given address at the generated block.
 func (b *BlockGen) GetBalance(addr common.Address) *big.Int {
 	return b.statedb.GetBalance(addr)
@@ -217,6 +237,8 @@ func GenerateChain(config *params.ChainConfig, parent *types.Block, engine conse
 		if config.DAOForkSupport && config.DAOForkBlock != nil && config.DAOForkBlock.Cmp(b.header.Number) == 0 {
 			misc.ApplyDAOHardFork(statedb)
 		}
+		b.addSystemTxs()
+
 		// Execute any user modifications to the block
 		if gen != nil {
 			gen(i, b)
@@ -238,7 +260,7 @@ func GenerateChain(config *params.ChainConfig, parent *types.Block, engine conse
 		return nil, nil
 	}
 	for i := 0; i < n; i++ {
-		statedb, err := state.New(parent.Root(), state.NewDatabase(db), nil)
+		statedb, err := state.New(parent.Root(), state.NewDatabaseWithConfig(db, &trie.Config{Zktrie: config.Zktrie}), nil)
 		if err != nil {
 			panic(err)
 		}
@@ -272,9 +294,9 @@ func makeHeader(chain consensus.ChainReader, parent *types.Block, state *state.S
 		Time:     time,
 	}
 	if chain.Config().IsLondon(header.Number) {
-		header.BaseFee = misc.CalcBaseFee(chain.Config(), parent.Header())
+		header.BaseFee = misc.CalcBaseFeeFromState(chain.Config(), parent.Header(), state)
 		if !chain.Config().IsLondon(parent.Number()) {
-			parentGasLimit := parent.GasLimit() * params.ElasticityMultiplier
+			parentGasLimit := parent.GasLimit() * chain.Config().ElasticityMultiplier(header.Number)
 			header.GasLimit = CalcGasLimit(parentGasLimit, parentGasLimit)
 		}
 	}
@@ -0,0 +1,197 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/ethdb"
+	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/params"
+	"github.com/scroll-tech/go-ethereum/trie"
+)
+
+// stateSpotCheckDepth is the number of levels below the state root verified by a
+// spot check of a zktrie state.
+const stateSpotCheckDepth = 4
+
+// Kinds of chain integrity issues found by VerifyChain.
+const (
+	IssueMissingHeader   = "missing-header"
+	IssueHeaderHash      = "header-hash"
+	IssueParentHash      = "parent-hash"
+	IssueMissingBody     = "missing-body"
+	IssueTxRoot          = "tx-root"
+	IssueUncleHash       = "uncle-hash"
+	IssueMissingReceipts = "missing-receipts"
+	IssueReceiptCount    = "receipt-count"
+	IssueReceiptRoot     = "receipt-root"
+	IssueBloom           = "bloom"
+	IssueTxIndex         = "tx-index"
+	IssueState           = "state"
+)
+
+// ChainIssue is an inconsistency of the chain data of a block.
+type ChainIssue struct {
+	Number uint64      `json:"number"`
+	Hash   common.Hash `json:"hash"`
+	Kind   string      `json:"kind"`
+	Detail string      `json:"detail"`
+}
+
+// ChainVerification is the report of verifying the chain data of a range of
+// canonical blocks.
+type ChainVerification struct {
+	First        uint64       `json:"first"`
+	Last         uint64       `json:"last"`
+	Blocks       uint64       `json:"blocks"`
+	Transactions uint64       `json:"transactions"`
+	TxIndexed    uint64       `json:"txIndexed"`    // Transactions whose lookup entry was verified
+	StateChecked uint64       `json:"stateChecked"` // States spot checked
+	StateMissing uint64       `json:"stateMissing"` // States not spot checked since they are not persisted
+	Issues       []ChainIssue `json:"issues"`
+}
+
+// VerifyChain verifies the canonical blocks from first to last: the links of the
+// headers, the transaction and uncle roots of the bodies, the receipt roots and
+// blooms of the receipts and, above the transaction index tail, the lookup
+// entries of the transactions. The state of every stateInterval-th block is
+// spot checked if it's persisted, zero disabling the spot checks.
+//
+// Inconsistencies are collected in the report, an error is only returned if the
+// verification could not be done.
+func VerifyChain(db ethdb.Database, config *params.ChainConfig, first, last, stateInterval uint64) (*ChainVerification, error) {
+	if first > last {
+		return nil, fmt.Errorf("first block %d above last block %d", first, last)
+	}
+	var (
+		report = &ChainVerification{First: first, Last: last, Issues: []ChainIssue{}}
+		tail   uint64 // Transactions below the index tail are not indexed
+		parent common.Hash
+
+		start  = time.Now()
+		logged = time.Now()
+	)
+	if t := rawdb.ReadTxIndexTail(db); t != nil {
+		tail = *t
+	}
+	if first > 0 {
+		parent = rawdb.ReadCanonicalHash(db, first-1)
+	}
+	for number := first; number <= last; number++ {
+		issue := func(hash common.Hash, kind string, format string, args ...interface{}) {
+			report.Issues = append(report.Issues, ChainIssue{Number: number, Hash: hash, Kind: kind, Detail: fmt.Sprintf(format, args...)})
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Verifying chain", "number", number, "last", last, "issues", len(report.Issues), "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+		report.Blocks++
+
+		hash := rawdb.ReadCanonicalHash(db, number)
+		header := rawdb.ReadHeader(db, hash, number)
+		if hash == (common.Hash{}) || header == nil {
+			issue(hash, IssueMissingHeader, "canonical header missing")
+			parent = common.Hash{}
+			continue
+		}
+		if have := header.Hash(); have != hash {
+			issue(hash, IssueHeaderHash, "header hashes to %x", have)
+		}
+		if parent != (common.Hash{}) && header.ParentHash != parent {
+			issue(hash, IssueParentHash, "parent %x, canonical %x", header.ParentHash, parent)
+		}
+		parent = hash
+
+		body := rawdb.ReadBody(db, hash, number)
+		if body == nil {
+			issue(hash, IssueMissingBody, "body missing")
+			continue
+		}
+		txs := types.Transactions(body.Transactions)
+		report.Transactions += uint64(len(txs))
+		if have := types.DeriveSha(txs, trie.NewStackTrie(nil)); have != header.TxHash {
+			issue(hash, IssueTxRoot, "transactions hash to %x, header %x", have, header.TxHash)
+		}
+		if have := types.CalcUncleHash(body.Uncles); have != header.UncleHash {
+			issue(hash, IssueUncleHash, "uncles hash to %x, header %x", have, header.UncleHash)
+		}
+		if number >= tail {
+			for _, tx := range txs {
+				if have := rawdb.ReadTxLookupEntry(db, tx.Hash()); have == nil {
+					issue(hash, IssueTxIndex, "transaction %x not indexed", tx.Hash())
+				} else if *have != number {
+					issue(hash, IssueTxIndex, "transaction %x indexed at block %d", tx.Hash(), *have)
+				}
+				report.TxIndexed++
+			}
+		}
+		receipts := rawdb.ReadRawReceipts(db, hash, number)
+		if receipts == nil {
+			issue(hash, IssueMissingReceipts, "receipts missing")
+		} else if len(receipts) != len(txs) {
+			issue(hash, IssueReceiptCount, "%d receipts for %d transactions", len(receipts), len(txs))
+		} else {
+			if have := types.DeriveSha(receipts, trie.NewStackTrie(nil)); have != header.ReceiptHash {
+				issue(hash, IssueReceiptRoot, "receipts hash to %x, header %x", have, header.ReceiptHash)
+			}
+			if have := types.CreateBloom(receipts); have != header.Bloom {
+				issue(hash, IssueBloom, "receipts bloom mismatch")
+			}
+		}
+		if stateInterval > 0 && number%stateInterval == 0 {
+			switch err := spotCheckState(db, config, header.Root); {
+			case err == errStateNotPersisted:
+				report.StateMissing++
+			case err != nil:
+				issue(hash, IssueState, "state %x: %v", header.Root, err)
+				report.StateChecked++
+			default:
+				report.StateChecked++
+			}
+		}
+	}
+	return report, nil
+}
+
+// errStateNotPersisted is returned by spotCheckState if the root node of the
+// state is not in the database, which is normal for non-archive nodes.
+var errStateNotPersisted = errors.New("state not persisted")
+
+// spotCheckState verifies that the top of the state trie with the given root
+// is present and decodable.
+func spotCheckState(db ethdb.Database, config *params.ChainConfig, root common.Hash) error {
+	if !config.Zktrie {
+		if len(rawdb.ReadTrieNode(db, root)) == 0 {
+			return errStateNotPersisted
+		}
+		_, err := trie.New(root, trie.NewDatabase(db))
+		return err
+	}
+	tr, err := trie.NewZkTrie(root, trie.NewZktrieDatabase(db))
+	if errors.Is(err, trie.ErrKeyNotFound) {
+		return errStateNotPersisted
+	} else if err != nil {
+		return err
+	}
+	return tr.CheckNodes(stateSpotCheckDepth)
+}
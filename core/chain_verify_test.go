@@ -0,0 +1,93 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/consensus/ethash"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/core/vm"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/scroll-tech/go-ethereum/params"
+)
+
+// Tests that a consistent chain verifies without issues and that corruptions
+// of the receipts and the transaction index are reported.
+func TestVerifyChain(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		db      = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   GenesisAlloc{address: {Balance: big.NewInt(1000000000000000000)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.LatestSigner(gspec.Config)
+		engine  = ethash.NewFaker()
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, engine, db, 8, func(i int, gen *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(address), common.Address{0x01}, big.NewInt(1), params.TxGas, gen.header.BaseFee, nil), signer, key)
+		gen.AddTx(tx)
+	})
+	chain, err := NewBlockChain(db, nil, gspec.Config, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	report, err := VerifyChain(db, gspec.Config, 0, 8, 4)
+	if err != nil {
+		t.Fatalf("failed to verify chain: %v", err)
+	}
+	if len(report.Issues) != 0 {
+		t.Fatalf("issues in consistent chain: %v", report.Issues)
+	}
+	if report.Blocks != 9 || report.Transactions != 8 || report.TxIndexed != 8 {
+		t.Errorf("counter mismatch: blocks %d, transactions %d, indexed %d", report.Blocks, report.Transactions, report.TxIndexed)
+	}
+	if report.StateChecked+report.StateMissing != 3 {
+		t.Errorf("spot check count mismatch: have %d, want 3", report.StateChecked+report.StateMissing)
+	}
+	// Corrupt the receipts of block 3 and the transaction index of block 5
+	rawdb.WriteReceipts(db, blocks[2].Hash(), 3, nil)
+	rawdb.WriteTxLookupEntries(db, 4, []common.Hash{blocks[4].Transactions()[0].Hash()})
+
+	report, err = VerifyChain(db, gspec.Config, 1, 8, 0)
+	if err != nil {
+		t.Fatalf("failed to verify chain: %v", err)
+	}
+	want := []ChainIssue{
+		{Number: 3, Hash: blocks[2].Hash(), Kind: IssueReceiptCount},
+		{Number: 5, Hash: blocks[4].Hash(), Kind: IssueTxIndex},
+	}
+	if len(report.Issues) != len(want) {
+		t.Fatalf("issue count mismatch: have %v, want %d", report.Issues, len(want))
+	}
+	for i, issue := range report.Issues {
+		if issue.Number != want[i].Number || issue.Hash != want[i].Hash || issue.Kind != want[i].Kind {
+			t.Errorf("issue %d: have %+v, want %+v", i, issue, want[i])
+		}
+	}
+}
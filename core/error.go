@@ -18,6 +18,7 @@ package core
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/scroll-tech/go-ethereum/core/types"
 )
@@ -32,6 +33,10 @@ var (
 	// ErrNoGenesis is returned when there is no Genesis Block.
 	ErrNoGenesis = errors.New("genesis not found in chain")
 
+	// ErrSystemTxMismatch is returned if the system transactions of a block don't
+	// match the ones required by the protocol.
+	ErrSystemTxMismatch = errors.New("system transactions mismatch")
+
 	errSideChainReceipts = errors.New("side blocks can't be accepted as ancient chain data")
 )
 
@@ -78,6 +83,18 @@ var (
 	// current network configuration.
 	ErrTxTypeNotSupported = types.ErrTxTypeNotSupported
 
+	// ErrAccessListTxNotActive is returned if an access list transaction is
+	// submitted or included before EIP-2718/2930 activates.
+	ErrAccessListTxNotActive = fmt.Errorf("%w: access list transactions not activated before Berlin", ErrTxTypeNotSupported)
+
+	// ErrDynamicFeeTxNotActive is returned if a dynamic fee transaction is
+	// submitted or included before EIP-1559 activates.
+	ErrDynamicFeeTxNotActive = fmt.Errorf("%w: dynamic fee transactions not activated before London", ErrTxTypeNotSupported)
+
+	// ErrFeePayerTxNotActive is returned if a fee payer transaction is
+	// submitted or included before the fee payer fork activates.
+	ErrFeePayerTxNotActive = fmt.Errorf("%w: fee payer transactions not activated", ErrTxTypeNotSupported)
+
 	// ErrTipAboveFeeCap is a sanity error to ensure no one is able to specify a
 	// transaction with a tip higher than the total fee cap.
 	ErrTipAboveFeeCap = errors.New("max priority fee per gas higher than max fee per gas")
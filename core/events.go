@@ -24,6 +24,13 @@ import (
 // NewTxsEvent is posted when a batch of transactions enter the transaction pool.
 type NewTxsEvent struct{ Txs []*types.Transaction }
 
+// DroppedTxsEvent is posted when a batch of transactions is evicted from the
+// transaction pool for the same reason.
+type DroppedTxsEvent struct {
+	Txs    []*types.Transaction
+	Reason TxDropReason
+}
+
 // NewMinedBlockEvent is posted when a block has been imported.
 type NewMinedBlockEvent struct{ Block *types.Block }
 
@@ -42,3 +49,14 @@ type ChainSideEvent struct {
 }
 
 type ChainHeadEvent struct{ Block *types.Block }
+
+// ReorgEvent is posted when the canonical chain is reorganised. The dropped
+// transactions were included in the old chain segment only, the re-included
+// ones were moved from the old segment into the new one.
+type ReorgEvent struct {
+	CommonAncestor *types.Header
+	OldChain       []*types.Header // Blocks removed from the canonical chain, newest first
+	NewChain       []*types.Header // Blocks added to the canonical chain, newest first
+	Dropped        []common.Hash
+	Reincluded     []common.Hash
+}
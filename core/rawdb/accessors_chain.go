@@ -19,6 +19,7 @@ package rawdb
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
@@ -881,6 +882,9 @@ func WriteBadBlock(db ethdb.KeyValueStore, block *types.Block) {
 	})
 	sort.Sort(sort.Reverse(badBlocks))
 	if len(badBlocks) > badBlockToKeep {
+		for _, b := range badBlocks[badBlockToKeep:] {
+			DeleteBadBlockWitness(db, b.Header.Hash())
+		}
 		badBlocks = badBlocks[:badBlockToKeep]
 	}
 	data, err := rlp.EncodeToBytes(badBlocks)
@@ -892,13 +896,56 @@ func WriteBadBlock(db ethdb.KeyValueStore, block *types.Block) {
 	}
 }
 
-// DeleteBadBlocks deletes all the bad blocks from the database
-func DeleteBadBlocks(db ethdb.KeyValueWriter) {
+// DeleteBadBlocks deletes all the bad blocks, along with their witnesses, from
+// the database
+func DeleteBadBlocks(db ethdb.KeyValueStore) {
+	if blob, _ := db.Get(badBlockKey); len(blob) > 0 {
+		var badBlocks badBlockList
+		if err := rlp.DecodeBytes(blob, &badBlocks); err == nil {
+			for _, b := range badBlocks {
+				DeleteBadBlockWitness(db, b.Header.Hash())
+			}
+		}
+	}
 	if err := db.Delete(badBlockKey); err != nil {
 		log.Crit("Failed to delete bad blocks", "err", err)
 	}
 }
 
+// ReadBadBlockWitness retrieves the execution witness captured while importing
+// the bad block with the given hash, if any.
+func ReadBadBlockWitness(db ethdb.KeyValueReader, hash common.Hash) *types.BlockResult {
+	data, _ := db.Get(badBlockWitnessKey(hash))
+	if len(data) == 0 {
+		return nil
+	}
+	witness := new(types.BlockResult)
+	if err := json.Unmarshal(data, witness); err != nil {
+		log.Error("Invalid bad block witness JSON", "hash", hash, "err", err)
+		return nil
+	}
+	return witness
+}
+
+// WriteBadBlockWitness stores the execution witness of a bad block. The witness
+// is only retained for as long as the block itself stays in the bad block list.
+func WriteBadBlockWitness(db ethdb.KeyValueWriter, hash common.Hash, witness *types.BlockResult) {
+	data, err := json.Marshal(witness)
+	if err != nil {
+		log.Crit("Failed to JSON encode bad block witness", "err", err)
+	}
+	if err := db.Put(badBlockWitnessKey(hash), data); err != nil {
+		log.Crit("Failed to store bad block witness", "err", err)
+	}
+}
+
+// DeleteBadBlockWitness removes the execution witness of a bad block.
+func DeleteBadBlockWitness(db ethdb.KeyValueWriter, hash common.Hash) {
+	if err := db.Delete(badBlockWitnessKey(hash)); err != nil {
+		log.Crit("Failed to delete bad block witness", "err", err)
+	}
+}
+
 // FindCommonAncestor returns the last common ancestor of two block headers
 func FindCommonAncestor(db ethdb.Reader, a, b *types.Header) *types.Header {
 	for bn := b.Number.Uint64(); a.Number.Uint64() > bn; {
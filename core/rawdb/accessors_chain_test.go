@@ -30,6 +30,7 @@ import (
 	"golang.org/x/crypto/sha3"
 
 	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
 	"github.com/scroll-tech/go-ethereum/core/types"
 	"github.com/scroll-tech/go-ethereum/crypto"
 	"github.com/scroll-tech/go-ethereum/params"
@@ -260,6 +261,46 @@ func TestBadBlockStorage(t *testing.T) {
 	}
 }
 
+func TestBadBlockWitnessStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	block := types.NewBlockWithHeader(&types.Header{
+		Number:      big.NewInt(1),
+		Extra:       []byte("bad block"),
+		UncleHash:   types.EmptyUncleHash,
+		TxHash:      types.EmptyRootHash,
+		ReceiptHash: types.EmptyRootHash,
+	})
+	if entry := ReadBadBlockWitness(db, block.Hash()); entry != nil {
+		t.Fatalf("Non existent witness returned: %v", entry)
+	}
+	witness := &types.BlockResult{
+		StorageTrace: &types.StorageTrace{
+			RootBefore: common.HexToHash("0x01"),
+			Proofs:     map[string][]hexutil.Bytes{"0x02": {{0x03}}},
+		},
+		ExecutionResults: []*types.ExecutionResult{{Gas: 21000, StructLogs: []*types.StructLogRes{}}},
+	}
+	WriteBadBlock(db, block)
+	WriteBadBlockWitness(db, block.Hash(), witness)
+	if entry := ReadBadBlockWitness(db, block.Hash()); !reflect.DeepEqual(entry, witness) {
+		t.Fatalf("Retrieved witness mismatch: have %v, want %v", entry, witness)
+	}
+	// Push the block out of the retained list, its witness should go along
+	for i := 0; i < badBlockToKeep; i++ {
+		WriteBadBlock(db, types.NewBlockWithHeader(&types.Header{
+			Number:      big.NewInt(int64(i + 2)),
+			Extra:       []byte("bad block"),
+			UncleHash:   types.EmptyUncleHash,
+			TxHash:      types.EmptyRootHash,
+			ReceiptHash: types.EmptyRootHash,
+		}))
+	}
+	if entry := ReadBadBlockWitness(db, block.Hash()); entry != nil {
+		t.Fatalf("Witness of evicted bad block not deleted: %v", entry)
+	}
+}
+
 // Tests block total difficulty storage and retrieval operations.
 func TestTdStorage(t *testing.T) {
 	db := NewMemoryDatabase()
@@ -18,6 +18,7 @@ package rawdb
 
 import (
 	"bytes"
+	"encoding/binary"
 	"math/big"
 
 	"github.com/scroll-tech/go-ethereum/common"
@@ -141,6 +142,180 @@ func ReadReceipt(db ethdb.Reader, hash common.Hash, config *params.ChainConfig)
 	return nil, common.Hash{}, 0, 0
 }
 
+// ReadStateRootBlockNumbers retrieves the numbers of the blocks indexed for the
+// given state root, in ascending order. Blocks sharing the same state, such as
+// empty blocks, all map to the same root. Entries of all indexed blocks are
+// returned, the caller has to filter out the non-canonical ones.
+func ReadStateRootBlockNumbers(db ethdb.Iteratee, root common.Hash) []uint64 {
+	prefix := append(append([]byte{}, stateRootBlockPrefix...), root.Bytes()...)
+	it := db.NewIterator(prefix, nil)
+	defer it.Release()
+
+	var numbers []uint64
+	for it.Next() {
+		if key := it.Key(); len(key) == len(prefix)+8 {
+			numbers = append(numbers, binary.BigEndian.Uint64(key[len(prefix):]))
+		}
+	}
+	return numbers
+}
+
+// WriteStateRootBlockNumber indexes a block under its state root.
+func WriteStateRootBlockNumber(db ethdb.KeyValueWriter, root common.Hash, number uint64) {
+	if err := db.Put(stateRootBlockKey(root, number), nil); err != nil {
+		log.Crit("Failed to store state root block index", "err", err)
+	}
+}
+
+// DeleteStateRootBlockNumber removes the index entry of a block under its state
+// root.
+func DeleteStateRootBlockNumber(db ethdb.KeyValueWriter, root common.Hash, number uint64) {
+	if err := db.Delete(stateRootBlockKey(root, number)); err != nil {
+		log.Crit("Failed to delete state root block index", "err", err)
+	}
+}
+
+// ReadSenderNonceLookup retrieves the hash of the canonical transaction sent by
+// the given account with the given nonce, or nil if it's not indexed.
+func ReadSenderNonceLookup(db ethdb.KeyValueReader, sender common.Address, nonce uint64) *common.Hash {
+	data, _ := db.Get(senderNonceKey(sender, nonce))
+	if len(data) != common.HashLength {
+		return nil
+	}
+	hash := common.BytesToHash(data)
+	return &hash
+}
+
+// WriteSenderNonceLookup stores the hash of the transaction sent by the given
+// account with the given nonce.
+func WriteSenderNonceLookup(db ethdb.KeyValueWriter, sender common.Address, nonce uint64, hash common.Hash) {
+	if err := db.Put(senderNonceKey(sender, nonce), hash.Bytes()); err != nil {
+		log.Crit("Failed to store sender nonce lookup", "err", err)
+	}
+}
+
+// DeleteSenderNonceLookup removes the transaction indexed for the given sender
+// and nonce.
+func DeleteSenderNonceLookup(db ethdb.KeyValueWriter, sender common.Address, nonce uint64) {
+	if err := db.Delete(senderNonceKey(sender, nonce)); err != nil {
+		log.Crit("Failed to delete sender nonce lookup", "err", err)
+	}
+}
+
+// Flags of an address activity entry, describing how the address took part in
+// the transaction.
+const (
+	ActivitySent     byte = 1 << iota // Address is the sender of the transaction
+	ActivityReceived                  // Address is the recipient or the created contract
+	ActivityInternal                  // Address sent or received an internal value transfer
+)
+
+// AddressActivity is an entry of the address activity index, referencing a
+// transaction an address took part in.
+type AddressActivity struct {
+	BlockNumber uint64
+	BlockHash   common.Hash
+	TxIndex     uint32
+	TxHash      common.Hash
+	Flags       byte
+}
+
+// WriteAddressActivity stores an address activity entry. Entries are keyed by
+// block hash too, so the index can be written for side chain blocks and only
+// the entries of canonical blocks are served.
+func WriteAddressActivity(db ethdb.KeyValueWriter, address common.Address, entry AddressActivity) {
+	key := addressActivityKey(address, entry.BlockNumber, entry.TxIndex, entry.BlockHash)
+	if err := db.Put(key, append(entry.TxHash.Bytes(), entry.Flags)); err != nil {
+		log.Crit("Failed to store address activity", "err", err)
+	}
+}
+
+// DeleteAddressActivity removes an address activity entry.
+func DeleteAddressActivity(db ethdb.KeyValueWriter, address common.Address, entry AddressActivity) {
+	if err := db.Delete(addressActivityKey(address, entry.BlockNumber, entry.TxIndex, entry.BlockHash)); err != nil {
+		log.Crit("Failed to delete address activity", "err", err)
+	}
+}
+
+// IterateAddressActivity iterates over the activity entries of an address in
+// ascending block and transaction order, starting at the given block number.
+// Entries of all indexed blocks are returned, the caller has to filter out the
+// non-canonical ones. Iteration stops when the callback returns false.
+func IterateAddressActivity(db ethdb.Iteratee, address common.Address, from uint64, fn func(AddressActivity) bool) {
+	prefix := append(append([]byte{}, addressActivityPrefix...), address.Bytes()...)
+	it := db.NewIterator(prefix, encodeBlockNumber(from))
+	defer it.Release()
+
+	for it.Next() {
+		key, value := it.Key(), it.Value()
+		if len(key) != len(prefix)+8+4+common.HashLength || len(value) != common.HashLength+1 {
+			continue
+		}
+		entry := AddressActivity{
+			BlockNumber: binary.BigEndian.Uint64(key[len(prefix):]),
+			TxIndex:     binary.BigEndian.Uint32(key[len(prefix)+8:]),
+			BlockHash:   common.BytesToHash(key[len(prefix)+12:]),
+			TxHash:      common.BytesToHash(value[:common.HashLength]),
+			Flags:       value[common.HashLength],
+		}
+		if !fn(entry) {
+			return
+		}
+	}
+}
+
+// EventLog is an entry of the event log index, referencing a log by its
+// position.
+type EventLog struct {
+	BlockNumber uint64
+	BlockHash   common.Hash
+	LogIndex    uint32
+	TxIndex     uint32
+}
+
+// WriteEventLog stores an event log entry under the address of the contract
+// emitting the log and its first topic, the event signature. Entries are keyed
+// by block hash too, so the index can be written for side chain blocks and only
+// the entries of canonical blocks are served.
+func WriteEventLog(db ethdb.KeyValueWriter, address common.Address, topic common.Hash, entry EventLog) {
+	var value [4]byte
+	binary.BigEndian.PutUint32(value[:], entry.TxIndex)
+	if err := db.Put(eventLogKey(address, topic, entry.BlockNumber, entry.LogIndex, entry.BlockHash), value[:]); err != nil {
+		log.Crit("Failed to store event log", "err", err)
+	}
+}
+
+// IterateEventLogs iterates over the event log entries of a contract address and
+// event signature in log order, starting at the given block number and log index.
+// Entries of all indexed blocks are returned, the caller has to filter out the
+// non-canonical ones. Iteration stops when the callback returns false.
+func IterateEventLogs(db ethdb.Iteratee, address common.Address, topic common.Hash, number uint64, index uint32, fn func(EventLog) bool) {
+	prefix := append(append(append([]byte{}, eventLogPrefix...), address.Bytes()...), topic.Bytes()...)
+
+	start := make([]byte, 12)
+	binary.BigEndian.PutUint64(start, number)
+	binary.BigEndian.PutUint32(start[8:], index)
+
+	it := db.NewIterator(prefix, start)
+	defer it.Release()
+
+	for it.Next() {
+		key, value := it.Key(), it.Value()
+		if len(key) != len(prefix)+8+4+common.HashLength || len(value) != 4 {
+			continue
+		}
+		entry := EventLog{
+			BlockNumber: binary.BigEndian.Uint64(key[len(prefix):]),
+			LogIndex:    binary.BigEndian.Uint32(key[len(prefix)+8:]),
+			BlockHash:   common.BytesToHash(key[len(prefix)+12:]),
+			TxIndex:     binary.BigEndian.Uint32(value),
+		}
+		if !fn(entry) {
+			return
+		}
+	}
+}
+
 // ReadBloomBits retrieves the compressed bloom bit vector belonging to the given
 // section and bit index from the.
 func ReadBloomBits(db ethdb.KeyValueReader, bit uint, section uint64, head common.Hash) ([]byte, error) {
@@ -155,6 +330,24 @@ func WriteBloomBits(db ethdb.KeyValueWriter, bit uint, section uint64, head comm
 	}
 }
 
+// ReadBloomBitsSectionSize retrieves the section size the bloom bits index was
+// built with, or nil if it was never recorded.
+func ReadBloomBitsSectionSize(db ethdb.KeyValueReader) *uint64 {
+	data, _ := db.Get(bloomBitsSectionSizeKey)
+	if len(data) != 8 {
+		return nil
+	}
+	size := binary.BigEndian.Uint64(data)
+	return &size
+}
+
+// WriteBloomBitsSectionSize stores the section size the bloom bits index is built with.
+func WriteBloomBitsSectionSize(db ethdb.KeyValueWriter, size uint64) {
+	if err := db.Put(bloomBitsSectionSizeKey, encodeBlockNumber(size)); err != nil {
+		log.Crit("Failed to store bloom bits section size", "err", err)
+	}
+}
+
 // DeleteBloombits removes all compressed bloom bits vector belonging to the
 // given section range and bit index.
 func DeleteBloombits(db ethdb.Database, bit uint, from uint64, to uint64) {
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"hash"
 	"math/big"
+	"reflect"
 	"testing"
 
 	"golang.org/x/crypto/sha3"
@@ -178,3 +179,127 @@ func TestDeleteBloomBits(t *testing.T) {
 	check(1, 1, params.MainnetGenesisHash, true)
 	check(1, 1, params.RinkebyGenesisHash, true)
 }
+
+// Tests that the state root to block number index can be stored and retrieved.
+func TestStateRootBlockNumberStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+	root := common.HexToHash("0x1234")
+
+	if numbers := ReadStateRootBlockNumbers(db, root); numbers != nil {
+		t.Fatalf("non existent root returned block numbers: %v", numbers)
+	}
+	for _, number := range []uint64{9, 1, 5, 5} {
+		WriteStateRootBlockNumber(db, root, number)
+	}
+	// Entries of other roots must not leak in
+	WriteStateRootBlockNumber(db, common.HexToHash("0x1235"), 2)
+
+	if numbers := ReadStateRootBlockNumbers(db, root); !reflect.DeepEqual(numbers, []uint64{1, 5, 9}) {
+		t.Fatalf("block numbers mismatch: have %v, want %v", numbers, []uint64{1, 5, 9})
+	}
+	DeleteStateRootBlockNumber(db, root, 5)
+	if numbers := ReadStateRootBlockNumbers(db, root); !reflect.DeepEqual(numbers, []uint64{1, 9}) {
+		t.Fatalf("block numbers mismatch after deletion: have %v, want %v", numbers, []uint64{1, 9})
+	}
+}
+
+// Tests that the sender and nonce transaction index can be stored, retrieved and
+// deleted.
+func TestSenderNonceLookupStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+	sender := common.HexToAddress("0x1234")
+	hash := common.HexToHash("0xabcd")
+
+	if entry := ReadSenderNonceLookup(db, sender, 7); entry != nil {
+		t.Fatalf("non existent lookup returned: %x", *entry)
+	}
+	WriteSenderNonceLookup(db, sender, 7, hash)
+	if entry := ReadSenderNonceLookup(db, sender, 7); entry == nil || *entry != hash {
+		t.Fatalf("lookup mismatch: have %v, want %x", entry, hash)
+	}
+	if entry := ReadSenderNonceLookup(db, sender, 8); entry != nil {
+		t.Fatalf("lookup returned for different nonce: %x", *entry)
+	}
+	DeleteSenderNonceLookup(db, sender, 7)
+	if entry := ReadSenderNonceLookup(db, sender, 7); entry != nil {
+		t.Fatalf("deleted lookup returned: %x", *entry)
+	}
+}
+
+// Tests that address activity entries are iterated in block and transaction
+// order, starting at the requested block.
+func TestAddressActivityStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+	var (
+		address = common.HexToAddress("0x1234")
+		other   = common.HexToAddress("0x5678")
+		entries = []AddressActivity{
+			{BlockNumber: 1, BlockHash: common.Hash{0x01}, TxIndex: 2, TxHash: common.Hash{0x12}, Flags: ActivitySent},
+			{BlockNumber: 1, BlockHash: common.Hash{0x01}, TxIndex: 256, TxHash: common.Hash{0x13}, Flags: ActivityReceived | ActivityInternal},
+			{BlockNumber: 3, BlockHash: common.Hash{0x03}, TxIndex: 0, TxHash: common.Hash{0x30}, Flags: ActivityInternal},
+		}
+	)
+	for i := len(entries) - 1; i >= 0; i-- {
+		WriteAddressActivity(db, address, entries[i])
+	}
+	WriteAddressActivity(db, other, entries[0])
+
+	collect := func(from uint64) []AddressActivity {
+		var have []AddressActivity
+		IterateAddressActivity(db, address, from, func(entry AddressActivity) bool {
+			have = append(have, entry)
+			return true
+		})
+		return have
+	}
+	if have := collect(0); !reflect.DeepEqual(have, entries) {
+		t.Fatalf("entries mismatch: have %v, want %v", have, entries)
+	}
+	if have := collect(2); !reflect.DeepEqual(have, entries[2:]) {
+		t.Fatalf("entries from block 2 mismatch: have %v, want %v", have, entries[2:])
+	}
+	DeleteAddressActivity(db, address, entries[1])
+	if have := collect(0); !reflect.DeepEqual(have, []AddressActivity{entries[0], entries[2]}) {
+		t.Fatalf("entries after deletion mismatch: have %v", have)
+	}
+}
+
+// Tests that event logs are indexed by contract address and event signature and
+// iterated in log order from the requested position.
+func TestEventLogStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	var (
+		address  = common.HexToAddress("0x7070")
+		transfer = common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+		approval = common.HexToHash("0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925")
+		entries  = []EventLog{
+			{BlockNumber: 1, BlockHash: common.Hash{0x11}, LogIndex: 0, TxIndex: 0},
+			{BlockNumber: 1, BlockHash: common.Hash{0x11}, LogIndex: 3, TxIndex: 1},
+			{BlockNumber: 2, BlockHash: common.Hash{0x22}, LogIndex: 1, TxIndex: 0},
+		}
+	)
+	for i := len(entries) - 1; i >= 0; i-- {
+		WriteEventLog(db, address, transfer, entries[i])
+	}
+	WriteEventLog(db, address, approval, entries[0])
+	WriteEventLog(db, common.HexToAddress("0x7071"), transfer, entries[0])
+
+	collect := func(topic common.Hash, number uint64, index uint32) []EventLog {
+		var have []EventLog
+		IterateEventLogs(db, address, topic, number, index, func(entry EventLog) bool {
+			have = append(have, entry)
+			return true
+		})
+		return have
+	}
+	if have := collect(transfer, 0, 0); !reflect.DeepEqual(have, entries) {
+		t.Fatalf("entries mismatch: have %v, want %v", have, entries)
+	}
+	if have := collect(transfer, 1, 1); !reflect.DeepEqual(have, entries[1:]) {
+		t.Fatalf("entries from cursor mismatch: have %v, want %v", have, entries[1:])
+	}
+	if have := collect(approval, 0, 0); !reflect.DeepEqual(have, entries[:1]) {
+		t.Fatalf("other event entries mismatch: have %v, want %v", have, entries[:1])
+	}
+}
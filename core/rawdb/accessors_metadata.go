@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/types"
 	"github.com/scroll-tech/go-ethereum/ethdb"
 	"github.com/scroll-tech/go-ethereum/log"
 	"github.com/scroll-tech/go-ethereum/params"
@@ -53,6 +54,20 @@ func WriteDatabaseVersion(db ethdb.KeyValueWriter, version uint64) {
 	}
 }
 
+// ReadStateScheme retrieves the trie scheme of the state stored in the database,
+// or an empty string if it was not recorded yet.
+func ReadStateScheme(db ethdb.KeyValueReader) string {
+	enc, _ := db.Get(stateSchemeKey)
+	return string(enc)
+}
+
+// WriteStateScheme stores the trie scheme of the state stored in the database.
+func WriteStateScheme(db ethdb.KeyValueWriter, scheme string) {
+	if err := db.Put(stateSchemeKey, []byte(scheme)); err != nil {
+		log.Crit("Failed to store the state scheme", "err", err)
+	}
+}
+
 // ReadChainConfig retrieves the consensus settings based on the given genesis hash.
 func ReadChainConfig(db ethdb.KeyValueReader, hash common.Hash) *params.ChainConfig {
 	data, _ := db.Get(configKey(hash))
@@ -81,6 +96,46 @@ func WriteChainConfig(db ethdb.KeyValueWriter, hash common.Hash, cfg *params.Cha
 	}
 }
 
+// ReadTxPoolPolicy retrieves the serialized transaction pool policy set at
+// runtime, if any.
+func ReadTxPoolPolicy(db ethdb.KeyValueReader) []byte {
+	data, _ := db.Get(txPoolPolicyKey)
+	return data
+}
+
+// WriteTxPoolPolicy stores the serialized transaction pool policy, to be
+// reapplied on the next startup.
+func WriteTxPoolPolicy(db ethdb.KeyValueWriter, policy []byte) {
+	if err := db.Put(txPoolPolicyKey, policy); err != nil {
+		log.Crit("Failed to store transaction pool policy", "err", err)
+	}
+}
+
+// ReadCheckpointBundle retrieves the imported signed header checkpoints, if any.
+func ReadCheckpointBundle(db ethdb.KeyValueReader) *types.CheckpointBundle {
+	data, _ := db.Get(checkpointBundleKey)
+	if len(data) == 0 {
+		return nil
+	}
+	bundle := new(types.CheckpointBundle)
+	if err := rlp.DecodeBytes(data, bundle); err != nil {
+		log.Error("Invalid checkpoint bundle RLP", "err", err)
+		return nil
+	}
+	return bundle
+}
+
+// WriteCheckpointBundle stores the imported signed header checkpoints.
+func WriteCheckpointBundle(db ethdb.KeyValueWriter, bundle *types.CheckpointBundle) {
+	data, err := rlp.EncodeToBytes(bundle)
+	if err != nil {
+		log.Crit("Failed to RLP encode checkpoint bundle", "err", err)
+	}
+	if err := db.Put(checkpointBundleKey, data); err != nil {
+		log.Crit("Failed to store checkpoint bundle", "err", err)
+	}
+}
+
 // crashList is a list of unclean-shutdown-markers, for rlp-encoding to the
 // database
 type crashList struct {
@@ -17,6 +17,8 @@
 package rawdb
 
 import (
+	"encoding/binary"
+
 	"github.com/scroll-tech/go-ethereum/common"
 	"github.com/scroll-tech/go-ethereum/ethdb"
 	"github.com/scroll-tech/go-ethereum/log"
@@ -94,3 +96,58 @@ func DeleteTrieNode(db ethdb.KeyValueWriter, hash common.Hash) {
 		log.Crit("Failed to delete trie node", "err", err)
 	}
 }
+
+// ReadZktriePruningMarker retrieves the last trie node key deleted by an
+// interrupted zktrie pruning.
+func ReadZktriePruningMarker(db ethdb.KeyValueReader) []byte {
+	data, _ := db.Get(zktriePruningMarkerKey)
+	return data
+}
+
+// WriteZktriePruningMarker stores the last trie node key deleted by a running
+// zktrie pruning.
+func WriteZktriePruningMarker(db ethdb.KeyValueWriter, marker []byte) {
+	if err := db.Put(zktriePruningMarkerKey, marker); err != nil {
+		log.Crit("Failed to store zktrie pruning marker", "err", err)
+	}
+}
+
+// DeleteZktriePruningMarker deletes the zktrie pruning marker once the pruning
+// is finished.
+func DeleteZktriePruningMarker(db ethdb.KeyValueWriter) {
+	if err := db.Delete(zktriePruningMarkerKey); err != nil {
+		log.Crit("Failed to remove zktrie pruning marker", "err", err)
+	}
+}
+
+// ReadStateCopyProgress retrieves the serialized progress of an interrupted
+// state copy from a peer node.
+func ReadStateCopyProgress(db ethdb.KeyValueReader) []byte {
+	data, _ := db.Get(stateCopyProgressKey)
+	return data
+}
+
+// WriteStateCopyProgress stores the serialized progress of a running state copy.
+func WriteStateCopyProgress(db ethdb.KeyValueWriter, progress []byte) {
+	if err := db.Put(stateCopyProgressKey, progress); err != nil {
+		log.Crit("Failed to store state copy progress", "err", err)
+	}
+}
+
+// DeleteStateCopyProgress deletes the state copy progress once the copy is
+// finished.
+func DeleteStateCopyProgress(db ethdb.KeyValueWriter) {
+	if err := db.Delete(stateCopyProgressKey); err != nil {
+		log.Crit("Failed to remove state copy progress", "err", err)
+	}
+}
+
+// WriteZkLeafEpoch stores the state epoch of the last canonical access to a leaf
+// of the zk trie owned by the given account leaf, zero for the account trie.
+func WriteZkLeafEpoch(db ethdb.KeyValueWriter, owner, leaf common.Hash, epoch uint64) {
+	var enc [8]byte
+	binary.BigEndian.PutUint64(enc[:], epoch)
+	if err := db.Put(ZkLeafEpochKey(owner, leaf), enc[:]); err != nil {
+		log.Crit("Failed to store zk trie leaf epoch", "err", err)
+	}
+}
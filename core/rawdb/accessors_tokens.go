@@ -0,0 +1,101 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"encoding/binary"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/ethdb"
+	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/rlp"
+)
+
+// WriteTokenTransfer stores a token transfer in the token transfer index, both
+// under the token and under the sending and receiving holders. The zero address
+// is not indexed as a holder, it takes part in every mint and burn. Transfers
+// are keyed by block hash too, so side chain blocks can be indexed and only the
+// transfers of canonical blocks are served.
+func WriteTokenTransfer(db ethdb.KeyValueWriter, transfer *types.TokenTransfer) {
+	data, err := rlp.EncodeToBytes(transfer)
+	if err != nil {
+		log.Crit("Failed to RLP encode token transfer", "err", err)
+	}
+	number, index, hash := transfer.BlockNumber, uint32(transfer.LogIndex), transfer.BlockHash
+	if err := db.Put(tokenTransferKey(transfer.Token, number, index, hash), data); err != nil {
+		log.Crit("Failed to store token transfer", "err", err)
+	}
+	holders := []common.Address{transfer.From}
+	if transfer.To != transfer.From {
+		holders = append(holders, transfer.To)
+	}
+	for _, holder := range holders {
+		if holder == (common.Address{}) {
+			continue
+		}
+		if err := db.Put(holderTransferKey(holder, number, index, hash), data); err != nil {
+			log.Crit("Failed to store token transfer", "err", err)
+		}
+	}
+}
+
+// IterateTokenTransfers iterates over the indexed transfers of a token in log
+// order, starting at the given block number and log index. Transfers of all the
+// indexed blocks are returned, the caller has to filter out the non-canonical
+// ones. Iteration stops when the callback returns false.
+func IterateTokenTransfers(db ethdb.Iteratee, token common.Address, number uint64, index uint32, fn func(*types.TokenTransfer) bool) {
+	iterateTransfers(db, tokenTransferPrefix, token, number, index, fn)
+}
+
+// IterateHolderTransfers iterates over the indexed transfers sent or received by
+// a holder in log order, starting at the given block number and log index. Like
+// IterateTokenTransfers, transfers of non-canonical blocks are returned too.
+func IterateHolderTransfers(db ethdb.Iteratee, holder common.Address, number uint64, index uint32, fn func(*types.TokenTransfer) bool) {
+	iterateTransfers(db, holderTransferPrefix, holder, number, index, fn)
+}
+
+// iterateTransfers iterates over the token transfers indexed under the given
+// prefix and address, filling in their positions from the keys.
+func iterateTransfers(db ethdb.Iteratee, prefix []byte, address common.Address, number uint64, index uint32, fn func(*types.TokenTransfer) bool) {
+	prefix = append(append([]byte{}, prefix...), address.Bytes()...)
+
+	start := make([]byte, 12)
+	binary.BigEndian.PutUint64(start, number)
+	binary.BigEndian.PutUint32(start[8:], index)
+
+	it := db.NewIterator(prefix, start)
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(prefix)+8+4+common.HashLength {
+			continue
+		}
+		transfer := new(types.TokenTransfer)
+		if err := rlp.DecodeBytes(it.Value(), transfer); err != nil {
+			log.Error("Invalid token transfer RLP", "key", key, "err", err)
+			continue
+		}
+		transfer.BlockNumber = binary.BigEndian.Uint64(key[len(prefix):])
+		transfer.LogIndex = uint(binary.BigEndian.Uint32(key[len(prefix)+8:]))
+		transfer.BlockHash = common.BytesToHash(key[len(prefix)+12:])
+		if !fn(transfer) {
+			return
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/ethdb"
+)
+
+// Tests that token transfers are indexed by token and by holder and iterated in
+// log order from the requested position.
+func TestTokenTransferStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	var (
+		token  = common.HexToAddress("0x7070")
+		alice  = common.HexToAddress("0xa11ce")
+		bob    = common.HexToAddress("0xb0b")
+		mint   = &types.TokenTransfer{Token: token, To: alice, Value: big.NewInt(100), TxHash: common.Hash{0x01}, BlockNumber: 1, BlockHash: common.Hash{0x11}, LogIndex: 0}
+		send   = &types.TokenTransfer{Token: token, From: alice, To: bob, Value: big.NewInt(40), TxHash: common.Hash{0x02}, TxIndex: 1, BlockNumber: 1, BlockHash: common.Hash{0x11}, LogIndex: 3}
+		self   = &types.TokenTransfer{Token: token, From: bob, To: bob, Value: big.NewInt(7), NFT: true, TxHash: common.Hash{0x03}, BlockNumber: 2, BlockHash: common.Hash{0x22}, LogIndex: 0}
+		tokens = []*types.TokenTransfer{mint, send, self}
+	)
+	for _, transfer := range tokens {
+		WriteTokenTransfer(db, transfer)
+	}
+	collect := func(iterate func(ethdb.Iteratee, common.Address, uint64, uint32, func(*types.TokenTransfer) bool), address common.Address, number uint64, index uint32) []*types.TokenTransfer {
+		var have []*types.TokenTransfer
+		iterate(db, address, number, index, func(transfer *types.TokenTransfer) bool {
+			have = append(have, transfer)
+			return true
+		})
+		return have
+	}
+	if have := collect(IterateTokenTransfers, token, 0, 0); !reflect.DeepEqual(have, tokens) {
+		t.Fatalf("token transfers mismatch: have %v, want %v", have, tokens)
+	}
+	if have := collect(IterateTokenTransfers, token, 1, 1); !reflect.DeepEqual(have, tokens[1:]) {
+		t.Fatalf("token transfers from cursor mismatch: have %v, want %v", have, tokens[1:])
+	}
+	if have := collect(IterateHolderTransfers, alice, 0, 0); !reflect.DeepEqual(have, tokens[:2]) {
+		t.Fatalf("sender transfers mismatch: have %v, want %v", have, tokens[:2])
+	}
+	if have := collect(IterateHolderTransfers, bob, 0, 0); !reflect.DeepEqual(have, tokens[1:]) {
+		t.Fatalf("recipient transfers mismatch: have %v, want %v", have, tokens[1:])
+	}
+	if have := collect(IterateHolderTransfers, common.Address{}, 0, 0); len(have) != 0 {
+		t.Fatalf("zero address indexed as holder: %v", have)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/ethdb"
+	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/rlp"
+)
+
+// ReadCallTrace retrieves the call trace of a transaction as executed in the
+// given block.
+func ReadCallTrace(db ethdb.KeyValueReader, txHash common.Hash, blockHash common.Hash) *types.CallTrace {
+	data, _ := db.Get(callTraceKey(txHash, blockHash))
+	if len(data) == 0 {
+		return nil
+	}
+	trace := new(types.CallTrace)
+	if err := rlp.DecodeBytes(data, trace); err != nil {
+		log.Error("Invalid call trace RLP", "hash", txHash, "block", blockHash, "err", err)
+		return nil
+	}
+	return trace
+}
+
+// WriteCallTrace stores the call trace of a transaction as executed in the given
+// block. Traces are keyed by block hash too, so side chain blocks can be indexed
+// and only the traces of canonical blocks are served.
+func WriteCallTrace(db ethdb.KeyValueWriter, txHash common.Hash, blockHash common.Hash, trace *types.CallTrace) {
+	data, err := rlp.EncodeToBytes(trace)
+	if err != nil {
+		log.Crit("Failed to RLP encode call trace", "err", err)
+	}
+	if err := db.Put(callTraceKey(txHash, blockHash), data); err != nil {
+		log.Crit("Failed to store call trace", "err", err)
+	}
+}
+
+// DeleteCallTrace removes the call trace of a transaction as executed in the
+// given block from the database.
+func DeleteCallTrace(db ethdb.KeyValueWriter, txHash common.Hash, blockHash common.Hash) {
+	if err := db.Delete(callTraceKey(txHash, blockHash)); err != nil {
+		log.Crit("Failed to delete call trace", "err", err)
+	}
+}
+
+// ReadOrderingAudit retrieves the ordering decisions of a locally mined block.
+func ReadOrderingAudit(db ethdb.KeyValueReader, hash common.Hash) *types.OrderingAudit {
+	data, _ := db.Get(orderingAuditKey(hash))
+	if len(data) == 0 {
+		return nil
+	}
+	audit := new(types.OrderingAudit)
+	if err := rlp.DecodeBytes(data, audit); err != nil {
+		log.Error("Invalid ordering audit RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return audit
+}
+
+// WriteOrderingAudit stores the ordering decisions of a locally mined block.
+func WriteOrderingAudit(db ethdb.KeyValueWriter, hash common.Hash, audit *types.OrderingAudit) {
+	data, err := rlp.EncodeToBytes(audit)
+	if err != nil {
+		log.Crit("Failed to RLP encode ordering audit", "err", err)
+	}
+	if err := db.Put(orderingAuditKey(hash), data); err != nil {
+		log.Crit("Failed to store ordering audit", "err", err)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/types"
+)
+
+// Tests call trace storage and retrieval operations.
+func TestCallTraceStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	hash, block := common.HexToHash("0x01"), common.HexToHash("0x02")
+	trace := &types.CallTrace{
+		Type:    "CALL",
+		From:    common.HexToAddress("0x1111"),
+		To:      common.HexToAddress("0x2222"),
+		Value:   big.NewInt(100),
+		Gas:     50000,
+		GasUsed: 21000,
+		Input:   []byte{0x01, 0x02},
+		Output:  []byte{},
+		Calls: []*types.CallTrace{{
+			Type:    "STATICCALL",
+			From:    common.HexToAddress("0x2222"),
+			To:      common.HexToAddress("0x3333"),
+			Value:   new(big.Int),
+			Gas:     1000,
+			GasUsed: 500,
+			Input:   []byte{},
+			Output:  []byte{0x03},
+			Error:   "execution reverted",
+			Calls:   []*types.CallTrace{},
+		}},
+	}
+	if entry := ReadCallTrace(db, hash, block); entry != nil {
+		t.Fatalf("Non existent call trace returned: %v", entry)
+	}
+	WriteCallTrace(db, hash, block, trace)
+	if entry := ReadCallTrace(db, hash, block); entry == nil {
+		t.Fatalf("Stored call trace not found")
+	} else if !reflect.DeepEqual(entry, trace) {
+		t.Fatalf("Retrieved call trace mismatch: have %+v, want %+v", entry, trace)
+	}
+	// Traces are kept per block, the transaction wasn't executed in any other
+	if entry := ReadCallTrace(db, hash, common.HexToHash("0x03")); entry != nil {
+		t.Fatalf("Call trace returned for wrong block: %v", entry)
+	}
+	DeleteCallTrace(db, hash, block)
+	if entry := ReadCallTrace(db, hash, block); entry != nil {
+		t.Fatalf("Deleted call trace returned: %v", entry)
+	}
+}
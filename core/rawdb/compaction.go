@@ -0,0 +1,232 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/ethdb"
+	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/metrics"
+)
+
+// compactionRecheckInterval is the time between two checks of the scheduler
+// whether the node is idle enough to run the next pending compaction.
+const compactionRecheckInterval = 100 * time.Millisecond
+
+var (
+	compactionRunMeter     = metrics.NewRegisteredMeter("db/compaction/scheduler/run", nil)
+	compactionDeferMeter   = metrics.NewRegisteredMeter("db/compaction/scheduler/deferred", nil)
+	compactionOverlapMeter = metrics.NewRegisteredMeter("db/compaction/scheduler/overlap", nil)
+	compactionFailMeter    = metrics.NewRegisteredMeter("db/compaction/scheduler/fail", nil)
+	compactionTimer        = metrics.NewRegisteredTimer("db/compaction/scheduler/time", nil)
+	compactionPendingGauge = metrics.NewRegisteredGauge("db/compaction/scheduler/pending", nil)
+)
+
+// compactionRange is a key range waiting to be compacted.
+type compactionRange struct {
+	start []byte
+	limit []byte
+}
+
+// CompactionScheduler paces manual range compactions into the idle windows between
+// the latency critical work of the node, such as sealing blocks or generating
+// block witnesses, splitting them up into small chunks.
+//
+// The scheduler only controls the compactions queued through it. It cannot defer
+// the background compactions the database triggers on its own, and it cannot
+// abort a chunk once started: a critical section entered while a chunk runs has
+// to share the disk with it until the chunk finishes (tracked by the overlap
+// meter).
+//
+// A nil scheduler is valid: it never schedules anything and busy sections are
+// no-ops, which lets callers mark their critical sections unconditionally.
+type CompactionScheduler struct {
+	db       ethdb.Compacter
+	idle     time.Duration // Time the node needs to be idle before compacting
+	interval time.Duration // Time between proactive sweeps of the whole key space (0 = disabled)
+
+	busy       int32 // Number of latency critical sections in flight (atomic)
+	lastBusy   int64 // Unix time in nanoseconds the last critical section ended (atomic)
+	compacting int32 // Whether a compaction is currently running (atomic)
+
+	pending []compactionRange // Queue of ranges waiting for an idle window
+	lock    sync.Mutex        // Lock protecting the pending queue
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewCompactionScheduler creates a compaction scheduler for the given database
+// and starts its background loop. Pending compactions only run once no critical
+// section has been active for the given idle duration. If interval is non-zero,
+// a sweep over the whole key space is queued that often.
+func NewCompactionScheduler(db ethdb.Compacter, idle, interval time.Duration) *CompactionScheduler {
+	s := &CompactionScheduler{
+		db:       db,
+		idle:     idle,
+		interval: interval,
+		quit:     make(chan struct{}),
+	}
+	s.wg.Add(1)
+	go s.loop()
+	return s
+}
+
+// Stop terminates the background loop, waiting for a running compaction chunk
+// to finish. Compactions still pending are dropped.
+func (s *CompactionScheduler) Stop() {
+	if s == nil {
+		return
+	}
+	close(s.quit)
+	s.wg.Wait()
+}
+
+// Busy marks the beginning of a latency critical section, deferring all pending
+// compactions until the returned function is called and the idle window passed.
+// A chunk already running is not interrupted.
+func (s *CompactionScheduler) Busy() func() {
+	if s == nil {
+		return func() {}
+	}
+	atomic.AddInt32(&s.busy, 1)
+	if atomic.LoadInt32(&s.compacting) == 1 {
+		compactionOverlapMeter.Mark(1)
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			atomic.StoreInt64(&s.lastBusy, time.Now().UnixNano())
+			atomic.AddInt32(&s.busy, -1)
+		})
+	}
+}
+
+// Schedule queues the given key range for compaction in the next idle windows.
+// The whole key space (nil start and limit) is split up by the first key byte,
+// so a single chunk never blocks the node for too long.
+func (s *CompactionScheduler) Schedule(start, limit []byte) {
+	if s == nil {
+		return
+	}
+	var ranges []compactionRange
+	if start == nil && limit == nil {
+		for b := 0; b < 256; b++ {
+			r := compactionRange{start: []byte{byte(b)}, limit: []byte{byte(b + 1)}}
+			if b == 0 {
+				r.start = nil
+			}
+			if b == 255 {
+				r.limit = nil
+			}
+			ranges = append(ranges, r)
+		}
+	} else {
+		ranges = append(ranges, compactionRange{start: common.CopyBytes(start), limit: common.CopyBytes(limit)})
+	}
+	s.lock.Lock()
+	s.pending = append(s.pending, ranges...)
+	compactionPendingGauge.Update(int64(len(s.pending)))
+	s.lock.Unlock()
+}
+
+// Pending returns the number of key ranges waiting to be compacted.
+func (s *CompactionScheduler) Pending() int {
+	if s == nil {
+		return 0
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return len(s.pending)
+}
+
+// idling reports whether no critical section is in flight and the last one
+// ended at least the idle duration ago.
+func (s *CompactionScheduler) idling() bool {
+	if atomic.LoadInt32(&s.busy) > 0 {
+		return false
+	}
+	last := atomic.LoadInt64(&s.lastBusy)
+	return last == 0 || time.Since(time.Unix(0, last)) >= s.idle
+}
+
+// loop is the background goroutine running the pending compactions whenever
+// the node is idle and queueing the periodic sweeps.
+func (s *CompactionScheduler) loop() {
+	defer s.wg.Done()
+
+	recheck := time.NewTicker(compactionRecheckInterval)
+	defer recheck.Stop()
+
+	var sweep <-chan time.Time
+	if s.interval > 0 {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		sweep = ticker.C
+	}
+	for {
+		select {
+		case <-sweep:
+			// Don't pile up sweeps if the node never got idle enough to finish
+			// the previous one.
+			if s.Pending() == 0 {
+				s.Schedule(nil, nil)
+			}
+		case <-recheck.C:
+			s.step()
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// step compacts the next pending key range if the node is idle.
+func (s *CompactionScheduler) step() {
+	s.lock.Lock()
+	if len(s.pending) == 0 {
+		s.lock.Unlock()
+		return
+	}
+	if !s.idling() {
+		s.lock.Unlock()
+		compactionDeferMeter.Mark(1)
+		return
+	}
+	next := s.pending[0]
+	s.pending = s.pending[1:]
+	compactionPendingGauge.Update(int64(len(s.pending)))
+	s.lock.Unlock()
+
+	atomic.StoreInt32(&s.compacting, 1)
+	start := time.Now()
+	err := s.db.Compact(next.start, next.limit)
+	atomic.StoreInt32(&s.compacting, 0)
+
+	compactionTimer.UpdateSince(start)
+	compactionRunMeter.Mark(1)
+	if err != nil {
+		compactionFailMeter.Mark(1)
+		log.Error("Scheduled database compaction failed", "start", next.start, "limit", next.limit, "err", err)
+		return
+	}
+	log.Debug("Compacted database range", "start", next.start, "limit", next.limit, "elapsed", common.PrettyDuration(time.Since(start)))
+}
@@ -0,0 +1,113 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingCompacter records the number of compactions run against it.
+type countingCompacter struct {
+	lock sync.Mutex
+	runs int
+}
+
+func (c *countingCompacter) Compact(start []byte, limit []byte) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.runs++
+	return nil
+}
+
+func (c *countingCompacter) count() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.runs
+}
+
+// Tests that scheduled compactions are deferred while a critical section is in
+// flight and run once the node has been idle long enough.
+func TestCompactionSchedulerDefersWhileBusy(t *testing.T) {
+	var (
+		db    = new(countingCompacter)
+		idle  = 300 * time.Millisecond
+		sched = NewCompactionScheduler(db, idle, 0)
+	)
+	defer sched.Stop()
+
+	done := sched.Busy()
+	sched.Schedule([]byte{0x01}, []byte{0x02})
+	sched.Schedule([]byte{0x02}, []byte{0x03})
+
+	time.Sleep(3 * compactionRecheckInterval)
+	if runs := db.count(); runs != 0 {
+		t.Fatalf("compacted while busy: have %d runs", runs)
+	}
+	done()
+	done() // releasing twice must not unbalance the counter
+
+	time.Sleep(idle / 2)
+	if runs := db.count(); runs != 0 {
+		t.Fatalf("compacted within idle window: have %d runs", runs)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for sched.Pending() > 0 && time.Now().Before(deadline) {
+		time.Sleep(compactionRecheckInterval)
+	}
+	if runs := db.count(); runs != 2 {
+		t.Fatalf("compaction count mismatch: have %d, want 2", runs)
+	}
+}
+
+// Tests that a full compaction is split into chunks by the first key byte.
+func TestCompactionSchedulerSplitsFullRange(t *testing.T) {
+	sched := NewCompactionScheduler(new(countingCompacter), time.Hour, 0)
+	defer sched.Stop()
+
+	// Keep the scheduler from draining the queue during the check
+	defer sched.Busy()()
+
+	sched.Schedule(nil, nil)
+	if have := sched.Pending(); have != 256 {
+		t.Fatalf("pending chunk count mismatch: have %d, want 256", have)
+	}
+	sched.lock.Lock()
+	defer sched.lock.Unlock()
+
+	if first := sched.pending[0]; first.start != nil || len(first.limit) != 1 || first.limit[0] != 0x01 {
+		t.Errorf("first chunk mismatch: have [%x, %x)", first.start, first.limit)
+	}
+	if last := sched.pending[255]; len(last.start) != 1 || last.start[0] != 0xff || last.limit != nil {
+		t.Errorf("last chunk mismatch: have [%x, %x)", last.start, last.limit)
+	}
+}
+
+// Tests that a nil scheduler can be used unconditionally.
+func TestCompactionSchedulerNil(t *testing.T) {
+	var sched *CompactionScheduler
+
+	sched.Busy()()
+	sched.Schedule(nil, nil)
+	if have := sched.Pending(); have != 0 {
+		t.Fatalf("nil scheduler has pending compactions: %d", have)
+	}
+	sched.Stop()
+}
@@ -146,8 +146,15 @@ func NewDatabase(db ethdb.KeyValueStore) ethdb.Database {
 // value data store with a freezer moving immutable chain segments into cold
 // storage.
 func NewDatabaseWithFreezer(db ethdb.KeyValueStore, freezer string, namespace string, readonly bool) (ethdb.Database, error) {
+	return NewDatabaseWithFreezerStores(db, freezer, namespace, readonly, nil)
+}
+
+// NewDatabaseWithFreezerStores creates a high level database on top of a given
+// key-value data store with a freezer, offloading the immutable data files of
+// the freezer tables to the given remote stores.
+func NewDatabaseWithFreezerStores(db ethdb.KeyValueStore, freezer string, namespace string, readonly bool, stores map[string]FreezerStore) (ethdb.Database, error) {
 	// Create the idle freezer instance
-	frdb, err := newFreezer(freezer, namespace, readonly, freezerTableSize, FreezerNoSnappy)
+	frdb, err := newFreezer(freezer, namespace, readonly, freezerTableSize, FreezerNoSnappy, stores)
 	if err != nil {
 		return nil, err
 	}
@@ -255,11 +262,18 @@ func NewLevelDBDatabase(file string, cache int, handles int, namespace string, r
 // NewLevelDBDatabaseWithFreezer creates a persistent key-value database with a
 // freezer moving immutable chain segments into cold storage.
 func NewLevelDBDatabaseWithFreezer(file string, cache int, handles int, freezer string, namespace string, readonly bool) (ethdb.Database, error) {
+	return NewLevelDBDatabaseWithFreezerStores(file, cache, handles, freezer, namespace, readonly, nil)
+}
+
+// NewLevelDBDatabaseWithFreezerStores creates a persistent key-value database
+// with a freezer, offloading the immutable data files of the freezer tables to
+// the given remote stores.
+func NewLevelDBDatabaseWithFreezerStores(file string, cache int, handles int, freezer string, namespace string, readonly bool, stores map[string]FreezerStore) (ethdb.Database, error) {
 	kvdb, err := leveldb.New(file, cache, handles, namespace, readonly)
 	if err != nil {
 		return nil, err
 	}
-	frdb, err := NewDatabaseWithFreezer(kvdb, freezer, namespace, readonly)
+	frdb, err := NewDatabaseWithFreezerStores(kvdb, freezer, namespace, readonly, stores)
 	if err != nil {
 		kvdb.Close()
 		return nil, err
@@ -318,6 +332,14 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		tries           stat
 		codes           stat
 		txLookups       stat
+		callTraces      stat
+		stateRoots      stat
+		senderNonces    stat
+		addrActivity    stat
+		tokenTransfers  stat
+		eventLogs       stat
+		orderingAudits  stat
+		zkLeafEpochs    stat
 		accountSnaps    stat
 		storageSnaps    stat
 		preimages       stat
@@ -368,6 +390,22 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			codes.Add(size)
 		case bytes.HasPrefix(key, txLookupPrefix) && len(key) == (len(txLookupPrefix)+common.HashLength):
 			txLookups.Add(size)
+		case bytes.HasPrefix(key, callTracePrefix) && len(key) == (len(callTracePrefix)+2*common.HashLength):
+			callTraces.Add(size)
+		case bytes.HasPrefix(key, stateRootBlockPrefix) && len(key) == (len(stateRootBlockPrefix)+common.HashLength+8):
+			stateRoots.Add(size)
+		case bytes.HasPrefix(key, senderNoncePrefix) && len(key) == (len(senderNoncePrefix)+common.AddressLength+8):
+			senderNonces.Add(size)
+		case bytes.HasPrefix(key, addressActivityPrefix) && len(key) == (len(addressActivityPrefix)+common.AddressLength+8+4+common.HashLength):
+			addrActivity.Add(size)
+		case (bytes.HasPrefix(key, tokenTransferPrefix) || bytes.HasPrefix(key, holderTransferPrefix)) && len(key) == (len(tokenTransferPrefix)+common.AddressLength+8+4+common.HashLength):
+			tokenTransfers.Add(size)
+		case bytes.HasPrefix(key, eventLogPrefix) && len(key) == (len(eventLogPrefix)+common.AddressLength+common.HashLength+8+4+common.HashLength):
+			eventLogs.Add(size)
+		case bytes.HasPrefix(key, orderingAuditPrefix) && len(key) == (len(orderingAuditPrefix)+common.HashLength):
+			orderingAudits.Add(size)
+		case bytes.HasPrefix(key, zkLeafEpochPrefix) && len(key) == (len(zkLeafEpochPrefix)+2*common.HashLength):
+			zkLeafEpochs.Add(size)
 		case bytes.HasPrefix(key, SnapshotAccountPrefix) && len(key) == (len(SnapshotAccountPrefix)+common.HashLength):
 			accountSnaps.Add(size)
 		case bytes.HasPrefix(key, SnapshotStoragePrefix) && len(key) == (len(SnapshotStoragePrefix)+2*common.HashLength):
@@ -396,7 +434,8 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 				databaseVersionKey, headHeaderKey, headBlockKey, headFastBlockKey, lastPivotKey,
 				fastTrieProgressKey, snapshotDisabledKey, SnapshotRootKey, snapshotJournalKey,
 				snapshotGeneratorKey, snapshotRecoveryKey, txIndexTailKey, fastTxLookupLimitKey,
-				uncleanShutdownKey, badBlockKey,
+				bloomBitsSectionSizeKey, txPoolPolicyKey, checkpointBundleKey, uncleanShutdownKey, badBlockKey,
+				zktriePruningMarkerKey,
 			} {
 				if bytes.Equal(key, meta) {
 					metadata.Add(size)
@@ -436,6 +475,14 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		{"Key-Value store", "Block number->hash", numHashPairings.Size(), numHashPairings.Count()},
 		{"Key-Value store", "Block hash->number", hashNumPairings.Size(), hashNumPairings.Count()},
 		{"Key-Value store", "Transaction index", txLookups.Size(), txLookups.Count()},
+		{"Key-Value store", "Call trace index", callTraces.Size(), callTraces.Count()},
+		{"Key-Value store", "State root index", stateRoots.Size(), stateRoots.Count()},
+		{"Key-Value store", "Sender nonce index", senderNonces.Size(), senderNonces.Count()},
+		{"Key-Value store", "Address activity index", addrActivity.Size(), addrActivity.Count()},
+		{"Key-Value store", "Token transfer index", tokenTransfers.Size(), tokenTransfers.Count()},
+		{"Key-Value store", "Event log index", eventLogs.Size(), eventLogs.Count()},
+		{"Key-Value store", "Ordering audits", orderingAudits.Size(), orderingAudits.Count()},
+		{"Key-Value store", "Zktrie leaf epochs", zkLeafEpochs.Size(), zkLeafEpochs.Count()},
 		{"Key-Value store", "Bloombit index", bloomBits.Size(), bloomBits.Count()},
 		{"Key-Value store", "Contract codes", codes.Size(), codes.Count()},
 		{"Key-Value store", "Trie nodes", tries.Size(), tries.Count()},
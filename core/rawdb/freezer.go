@@ -90,7 +90,8 @@ type freezer struct {
 	tables       map[string]*freezerTable // Data tables for storing everything
 	instanceLock fileutil.Releaser        // File-system lock to prevent double opens
 
-	trigger chan chan struct{} // Manual blocking freeze trigger, test determinism
+	trigger   chan chan struct{} // Manual blocking freeze trigger, test determinism
+	offloadCh chan struct{}      // Offload trigger, nil if no table has a remote store
 
 	quit      chan struct{}
 	wg        sync.WaitGroup
@@ -98,17 +99,23 @@ type freezer struct {
 }
 
 // newFreezer creates a chain freezer that moves ancient chain data into
-// append-only flat file containers.
+// append-only flat file containers. The data files of the tables with a remote
+// store are offloaded to it once they are complete.
 //
 // The 'tables' argument defines the data tables. If the value of a map
 // entry is true, snappy compression is disabled for the table.
-func newFreezer(datadir string, namespace string, readonly bool, maxTableSize uint32, tables map[string]bool) (*freezer, error) {
+func newFreezer(datadir string, namespace string, readonly bool, maxTableSize uint32, tables map[string]bool, stores map[string]FreezerStore) (*freezer, error) {
 	// Create the initial freezer object
 	var (
 		readMeter  = metrics.NewRegisteredMeter(namespace+"ancient/read", nil)
 		writeMeter = metrics.NewRegisteredMeter(namespace+"ancient/write", nil)
 		sizeGauge  = metrics.NewRegisteredGauge(namespace+"ancient/size", nil)
 	)
+	for name := range stores {
+		if _, ok := tables[name]; !ok {
+			return nil, fmt.Errorf("unknown freezer table %q", name)
+		}
+	}
 	// Ensure the datadir is not a symbolic link if it exists.
 	if info, err := os.Lstat(datadir); !os.IsNotExist(err) {
 		if info.Mode()&os.ModeSymlink != 0 {
@@ -134,7 +141,7 @@ func newFreezer(datadir string, namespace string, readonly bool, maxTableSize ui
 
 	// Create the tables.
 	for name, disableSnappy := range tables {
-		table, err := newTable(datadir, name, readMeter, writeMeter, sizeGauge, maxTableSize, disableSnappy)
+		table, err := newTableWithStore(datadir, name, readMeter, writeMeter, sizeGauge, maxTableSize, disableSnappy, stores[name])
 		if err != nil {
 			for _, table := range freezer.tables {
 				table.Close()
@@ -157,6 +164,16 @@ func newFreezer(datadir string, namespace string, readonly bool, maxTableSize ui
 	// Create the write batch.
 	freezer.writeBatch = newFreezerBatch(freezer)
 
+	// Offload the completed data files in the background, as uploads may take
+	// long. Files left over by a previous run are picked up straight away.
+	if len(stores) > 0 && !readonly {
+		freezer.offloadCh = make(chan struct{}, 1)
+		freezer.offloadCh <- struct{}{}
+
+		freezer.wg.Add(1)
+		go freezer.offloadLoop()
+	}
+
 	log.Info("Opened ancient database", "database", datadir, "readonly", readonly)
 	return freezer, nil
 }
@@ -309,6 +326,38 @@ func (f *freezer) Sync() error {
 	return nil
 }
 
+// scheduleOffload requests the background offloader to move the completed data
+// files to the remote stores, if any are configured.
+func (f *freezer) scheduleOffload() {
+	if f.offloadCh == nil {
+		return
+	}
+	select {
+	case f.offloadCh <- struct{}{}:
+	default:
+	}
+}
+
+// offloadLoop moves the completed data files of the tables with a remote store
+// to it whenever requested, until the freezer is closed. Failures are not fatal,
+// the files stay local and are retried with the next request.
+func (f *freezer) offloadLoop() {
+	defer f.wg.Done()
+
+	for {
+		select {
+		case <-f.offloadCh:
+			for _, table := range f.tables {
+				if err := table.offload(f.quit); err != nil {
+					log.Error("Failed to offload frozen data files", "table", table.name, "err", err)
+				}
+			}
+		case <-f.quit:
+			return
+		}
+	}
+}
+
 // repair truncates all data tables to the same length.
 func (f *freezer) repair() error {
 	min := uint64(math.MaxUint64)
@@ -414,6 +463,8 @@ func (f *freezer) freeze(db ethdb.KeyValueStore) {
 		if err := f.Sync(); err != nil {
 			log.Crit("Failed to flush frozen tables", "err", err)
 		}
+		// Move the completed data files to the remote stores
+		f.scheduleOffload()
 
 		// Wipe out all data from the active database
 		batch := db.NewBatch()
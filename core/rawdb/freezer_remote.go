@@ -0,0 +1,262 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common"
+)
+
+var (
+	// errRemoteNotFound is returned by a FreezerStore if the requested file does
+	// not exist.
+	errRemoteNotFound = errors.New("remote file not found")
+
+	// errOffloadAborted is returned if an upload is aborted by the freezer
+	// shutting down.
+	errOffloadAborted = errors.New("offload aborted")
+)
+
+// FreezerStore is a remote storage for the data files of a freezer table. A data
+// file is only handed to the store once the table advanced past it, so the files
+// are immutable, except for being overwritten if the table gets truncated back
+// into them and advances again.
+type FreezerStore interface {
+	// Put stores the given number of bytes read from r under the given name. The
+	// store has to reject the upload if the data doesn't match the MD5 digest.
+	Put(name string, r io.Reader, size int64, digest []byte) error
+
+	// Size returns the size of the file stored under the given name, or
+	// errRemoteNotFound if there is none.
+	Size(name string) (int64, error)
+
+	// Digest returns the MD5 digest of the file stored under the given name, or
+	// nil if the store can't tell it.
+	Digest(name string) ([]byte, error)
+
+	// ReadAt reads len(p) bytes from the given file starting at offset off.
+	ReadAt(name string, p []byte, off int64) error
+
+	// Delete removes the file stored under the given name, if any.
+	Delete(name string) error
+}
+
+// remoteFile is a data file of a freezer table offloaded to a remote store.
+type remoteFile struct {
+	store FreezerStore
+	name  string
+	size  int64
+}
+
+// ReadAt implements io.ReaderAt, reading the data from the remote store.
+func (f *remoteFile) ReadAt(p []byte, off int64) (int, error) {
+	if off+int64(len(p)) > f.size {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if err := f.store.ReadAt(f.name, p, off); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// readRemote reads from a data file offloaded to the remote store. The read-lock
+// is released during the read, so that a slow store doesn't hold up appends and
+// truncations. It assumes that the read-lock is held by the caller, and checks
+// once it's retaken that the items in the given range weren't truncated away or
+// rewritten meanwhile.
+func (t *freezerTable) readRemote(f *remoteFile, p []byte, off int64, first, last uint64) error {
+	cuts := t.cuts
+	t.lock.RUnlock()
+	_, err := f.ReadAt(p, off)
+	t.lock.RLock()
+
+	if t.index == nil || t.head == nil {
+		return errClosed
+	}
+	if t.cuts != cuts || atomic.LoadUint64(&t.items) < last || uint64(t.itemOffset) > first {
+		return errOutOfBounds
+	}
+	return err
+}
+
+// openRemote registers the data file with the given number as being served by
+// the remote store. It assumes that the write-lock is held by the caller.
+func (t *freezerTable) openRemote(num uint32) error {
+	name := t.fileName(num)
+	size, err := t.store.Size(name)
+	if err != nil {
+		return fmt.Errorf("data file %s: %w", name, err)
+	}
+	t.remote[num] = &remoteFile{store: t.store, name: name, size: size}
+	return nil
+}
+
+// restoreFile downloads the data file with the given number from the remote
+// store if it's missing locally, so that it can be appended to again after a
+// truncation. It assumes that the write-lock is held by the caller.
+func (t *freezerTable) restoreFile(num uint32) error {
+	if t.store == nil {
+		return nil
+	}
+	path := filepath.Join(t.path, t.fileName(num))
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return err
+	}
+	f, ok := t.remote[num]
+	if !ok {
+		if err := t.openRemote(num); err != nil {
+			return err
+		}
+		f = t.remote[num]
+	}
+	t.logger.Info("Restoring offloaded data file", "file", f.name, "size", common.StorageSize(f.size))
+
+	file, err := openFreezerFileTruncated(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, io.NewSectionReader(f, 0, f.size)); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	delete(t.remote, num)
+	return file.Close()
+}
+
+// abortReader is an io.Reader failing once the quit channel is closed, so that
+// uploads don't hold up the shutdown.
+type abortReader struct {
+	r    io.Reader
+	quit <-chan struct{}
+}
+
+// Read implements io.Reader.
+func (r *abortReader) Read(p []byte) (int, error) {
+	select {
+	case <-r.quit:
+		return 0, errOffloadAborted
+	default:
+		return r.r.Read(p)
+	}
+}
+
+// offload moves the data files the table advanced past to the remote store. The
+// files are uploaded without holding the lock and only dropped locally if the
+// stored copies check out and the table wasn't truncated meanwhile.
+func (t *freezerTable) offload(quit <-chan struct{}) error {
+	if t.store == nil {
+		return nil
+	}
+	t.lock.RLock()
+	var nums []uint32
+	for num := range t.files {
+		if num < t.headId {
+			nums = append(nums, num)
+		}
+	}
+	t.lock.RUnlock()
+
+	sort.Slice(nums, func(i, j int) bool { return nums[i] < nums[j] })
+	for _, num := range nums {
+		if err := t.offloadFile(num, quit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// offloadFile uploads the data file with the given number to the remote store,
+// verifies the stored copy and switches the reads over to it.
+func (t *freezerTable) offloadFile(num uint32, quit <-chan struct{}) error {
+	var (
+		start = time.Now()
+		name  = t.fileName(num)
+		path  = filepath.Join(t.path, name)
+	)
+	t.lock.RLock()
+	cuts := t.cuts
+	t.lock.RUnlock()
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Digest the file first, the store verifies the upload against it
+	hasher := md5.New()
+	size, err := io.Copy(hasher, file)
+	if err != nil {
+		return err
+	}
+	digest := hasher.Sum(nil)
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := t.store.Put(name, &abortReader{r: file, quit: quit}, size, digest); err != nil {
+		return fmt.Errorf("failed to offload %s: %v", name, err)
+	}
+	// Check the stored copy before dropping the local one
+	stored, err := t.store.Size(name)
+	if err != nil {
+		return fmt.Errorf("failed to verify %s: %v", name, err)
+	}
+	if stored != size {
+		return fmt.Errorf("offloaded %s size mismatch: have %d, want %d", name, stored, size)
+	}
+	remoteDigest, err := t.store.Digest(name)
+	if err != nil {
+		return fmt.Errorf("failed to verify %s: %v", name, err)
+	}
+	if remoteDigest != nil && !bytes.Equal(remoteDigest, digest) {
+		return fmt.Errorf("offloaded %s digest mismatch: have %x, want %x", name, remoteDigest, digest)
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	// A truncation may have changed the file during the upload, leave it to the
+	// next offload then
+	if t.cuts != cuts || num >= t.headId {
+		return nil
+	}
+	if _, ok := t.files[num]; !ok {
+		return nil
+	}
+	t.releaseFile(num)
+	t.remote[num] = &remoteFile{store: t.store, name: name, size: size}
+
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	t.logger.Info("Offloaded data file", "file", name, "size", common.StorageSize(size), "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
@@ -0,0 +1,394 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/metrics"
+)
+
+// memoryFreezerStore is a FreezerStore keeping the files in memory.
+type memoryFreezerStore struct {
+	files map[string][]byte
+	lock  sync.Mutex
+}
+
+func newMemoryFreezerStore() *memoryFreezerStore {
+	return &memoryFreezerStore{files: make(map[string][]byte)}
+}
+
+func (s *memoryFreezerStore) Put(name string, r io.Reader, size int64, digest []byte) error {
+	data, err := ioutil.ReadAll(io.LimitReader(r, size))
+	if err != nil {
+		return err
+	}
+	if sum := md5.Sum(data); digest != nil && !bytes.Equal(sum[:], digest) {
+		return errors.New("digest mismatch")
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.files[name] = data
+	return nil
+}
+
+func (s *memoryFreezerStore) Size(name string) (int64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	data, ok := s.files[name]
+	if !ok {
+		return 0, errRemoteNotFound
+	}
+	return int64(len(data)), nil
+}
+
+func (s *memoryFreezerStore) Digest(name string) ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	data, ok := s.files[name]
+	if !ok {
+		return nil, errRemoteNotFound
+	}
+	sum := md5.Sum(data)
+	return sum[:], nil
+}
+
+func (s *memoryFreezerStore) ReadAt(name string, p []byte, off int64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	data, ok := s.files[name]
+	if !ok {
+		return errRemoteNotFound
+	}
+	if off+int64(len(p)) > int64(len(data)) {
+		return io.ErrUnexpectedEOF
+	}
+	copy(p, data[off:])
+	return nil
+}
+
+func (s *memoryFreezerStore) Delete(name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.files, name)
+	return nil
+}
+
+// Tests that the completed data files of a table are offloaded to the remote
+// store, served from there after a restart and restored when truncating back
+// into them.
+func TestFreezerOffload(t *testing.T) {
+	var (
+		dir   = t.TempDir()
+		store = newMemoryFreezerStore()
+	)
+	open := func() *freezerTable {
+		f, err := newTableWithStore(dir, "offload", metrics.NewMeter(), metrics.NewMeter(), metrics.NewGauge(), 50, true, store)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+	check := func(f *freezerTable, items int) {
+		t.Helper()
+		for i := 0; i < items; i++ {
+			got, err := f.Retrieve(uint64(i))
+			if err != nil {
+				t.Fatalf("reading item %d: %v", i, err)
+			}
+			if exp := getChunk(15, i); !bytes.Equal(got, exp) {
+				t.Fatalf("item %d: have %x, want %x", i, got, exp)
+			}
+		}
+	}
+	// Write 30 items of 15 bytes, three per file, and offload the full files
+	f := open()
+	writeChunks(t, f, 30, 15)
+	if err := f.offload(nil); err != nil {
+		t.Fatalf("failed to offload: %v", err)
+	}
+	if len(store.files) != 9 {
+		t.Fatalf("offloaded file count mismatch: have %d, want 9", len(store.files))
+	}
+	for i := uint32(0); i < 9; i++ {
+		if _, err := os.Stat(filepath.Join(dir, f.fileName(i))); !os.IsNotExist(err) {
+			t.Errorf("file %d not removed locally: %v", i, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, f.fileName(9))); err != nil {
+		t.Errorf("head file missing: %v", err)
+	}
+	check(f, 30)
+	f.Close()
+
+	// Reopen the table, the offloaded files must be read from the store
+	f = open()
+	check(f, 30)
+
+	// Truncate back into an offloaded file and append again
+	if err := f.truncate(10); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, f.fileName(3))); err != nil {
+		t.Errorf("truncated head not restored: %v", err)
+	}
+	// The restored file is overwritten remotely once it's complete again
+	if len(store.files) != 4 {
+		t.Errorf("truncated files not deleted remotely: have %d, want 4", len(store.files))
+	}
+	batch := f.newBatch()
+	for i := 10; i < 20; i++ {
+		if err := batch.AppendRaw(uint64(i), getChunk(15, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := batch.commit(); err != nil {
+		t.Fatal(err)
+	}
+	check(f, 20)
+	f.Close()
+}
+
+// lossyFreezerStore is a memoryFreezerStore silently dropping the last byte of
+// the uploaded files.
+type lossyFreezerStore struct {
+	*memoryFreezerStore
+}
+
+func (s lossyFreezerStore) Put(name string, r io.Reader, size int64, digest []byte) error {
+	return s.memoryFreezerStore.Put(name, r, size-1, nil)
+}
+
+// Tests that data files are only dropped locally if the stored copies check out.
+func TestFreezerOffloadVerify(t *testing.T) {
+	var (
+		dir   = t.TempDir()
+		store = lossyFreezerStore{newMemoryFreezerStore()}
+	)
+	f, err := newTableWithStore(dir, "verify", metrics.NewMeter(), metrics.NewMeter(), metrics.NewGauge(), 50, true, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	writeChunks(t, f, 6, 15)
+	if err := f.offload(nil); err == nil {
+		t.Fatal("corrupted upload accepted")
+	}
+	if _, err := os.Stat(filepath.Join(dir, f.fileName(0))); err != nil {
+		t.Fatalf("data file removed after a failed offload: %v", err)
+	}
+	if len(f.remote) != 0 {
+		t.Fatalf("reads switched to a corrupted copy")
+	}
+	for i := 0; i < 6; i++ {
+		if got, err := f.Retrieve(uint64(i)); err != nil || !bytes.Equal(got, getChunk(15, i)) {
+			t.Fatalf("item %d mismatch: have %x (err %v)", i, got, err)
+		}
+	}
+	// Closing the quit channel aborts the uploads
+	quit := make(chan struct{})
+	close(quit)
+	f.store = newMemoryFreezerStore()
+	if err := f.offload(quit); err == nil || !strings.Contains(err.Error(), errOffloadAborted.Error()) {
+		t.Fatalf("aborted offload error mismatch: have %v, want %v", err, errOffloadAborted)
+	}
+}
+
+// blockingFreezerStore is a memoryFreezerStore whose reads of a given file block
+// until released.
+type blockingFreezerStore struct {
+	*memoryFreezerStore
+	block   string        // Name of the file whose reads block
+	reading chan struct{} // Signaled when a blocking read starts
+	release chan struct{} // Unblocks a blocking read
+}
+
+func (s *blockingFreezerStore) ReadAt(name string, p []byte, off int64) error {
+	if name == s.block {
+		s.reading <- struct{}{}
+		<-s.release
+	}
+	return s.memoryFreezerStore.ReadAt(name, p, off)
+}
+
+// Tests that slow reads from the remote store don't hold up appends and
+// truncations, and that reads overtaken by a truncation fail.
+func TestFreezerRemoteReadUnlocked(t *testing.T) {
+	store := &blockingFreezerStore{
+		memoryFreezerStore: newMemoryFreezerStore(),
+		reading:            make(chan struct{}),
+		release:            make(chan struct{}),
+	}
+	f, err := newTableWithStore(t.TempDir(), "unlocked", metrics.NewMeter(), metrics.NewMeter(), metrics.NewGauge(), 50, true, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	writeChunks(t, f, 30, 15)
+	if err := f.offload(nil); err != nil {
+		t.Fatalf("failed to offload: %v", err)
+	}
+	store.block = f.fileName(1)
+
+	// done runs fn, failing if it doesn't return while a remote read is pending
+	done := func(name string, fn func() error) {
+		t.Helper()
+		errc := make(chan error, 1)
+		go func() { errc <- fn() }()
+		select {
+		case err := <-errc:
+			if err != nil {
+				t.Fatalf("failed to %s: %v", name, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s held up by a remote read", name)
+		}
+	}
+	type result struct {
+		data []byte
+		err  error
+	}
+	retrieve := func(item uint64) chan result {
+		resc := make(chan result, 1)
+		go func() {
+			data, err := f.Retrieve(item)
+			resc <- result{data, err}
+		}()
+		<-store.reading
+		return resc
+	}
+	// An append during the read leaves the item alone
+	resc := retrieve(4)
+	done("append", func() error {
+		batch := f.newBatch()
+		if err := batch.AppendRaw(30, getChunk(15, 30)); err != nil {
+			return err
+		}
+		return batch.commit()
+	})
+	store.release <- struct{}{}
+	if res := <-resc; res.err != nil || !bytes.Equal(res.data, getChunk(15, 4)) {
+		t.Fatalf("item 4 mismatch: have %x (err %v)", res.data, res.err)
+	}
+	// A truncation during the read removes the item
+	resc = retrieve(4)
+	done("truncate", func() error { return f.truncate(3) })
+	store.release <- struct{}{}
+	if res := <-resc; res.err != errOutOfBounds {
+		t.Fatalf("truncated item read error mismatch: have %v, want %v", res.err, errOutOfBounds)
+	}
+}
+
+// Tests the S3 store against a minimal object storage server.
+func TestS3FreezerStore(t *testing.T) {
+	for key, value := range map[string]string{"AWS_ACCESS_KEY_ID": "key", "AWS_SECRET_ACCESS_KEY": "secret"} {
+		defer os.Setenv(key, os.Getenv(key))
+		os.Setenv(key, value)
+	}
+	objects := newMemoryFreezerStore()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		name := r.URL.Path
+		switch r.Method {
+		case http.MethodPut:
+			digest, _ := base64.StdEncoding.DecodeString(r.Header.Get("Content-Md5"))
+			if err := objects.Put(name, r.Body, r.ContentLength, digest); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		case http.MethodHead:
+			size, err := objects.Size(name)
+			if err != nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			digest, _ := objects.Digest(name)
+			w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+			w.Header().Set("ETag", fmt.Sprintf("%q", hex.EncodeToString(digest)))
+		case http.MethodGet:
+			var start, end int64
+			fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end)
+			data := make([]byte, end-start+1)
+			if err := objects.ReadAt(name, data, start); err != nil {
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+				return
+			}
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(data)
+		case http.MethodDelete:
+			objects.Delete(name)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	store, err := NewFreezerStore("gs://bucket/ancient?endpoint=" + server.URL)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	if _, err := store.Size("bodies.0000.cdat"); err != errRemoteNotFound {
+		t.Fatalf("missing file: have %v, want %v", err, errRemoteNotFound)
+	}
+	data := []byte("ancient chain segment")
+	digest := md5.Sum(data)
+	if err := store.Put("bodies.0000.cdat", bytes.NewReader(data), int64(len(data)), make([]byte, md5.Size)); err == nil {
+		t.Fatalf("upload with mismatching digest accepted")
+	}
+	if err := store.Put("bodies.0000.cdat", bytes.NewReader(data), int64(len(data)), digest[:]); err != nil {
+		t.Fatalf("failed to upload: %v", err)
+	}
+	if have, err := store.Digest("bodies.0000.cdat"); err != nil || !bytes.Equal(have, digest[:]) {
+		t.Fatalf("digest mismatch: have %x (err %v), want %x", have, err, digest)
+	}
+	if _, ok := objects.files["/bucket/ancient/bodies.0000.cdat"]; !ok {
+		t.Fatalf("object not stored under the bucket prefix: %v", objects.files)
+	}
+	if size, err := store.Size("bodies.0000.cdat"); err != nil || size != int64(len(data)) {
+		t.Fatalf("size mismatch: have %d (err %v), want %d", size, err, len(data))
+	}
+	part := make([]byte, 5)
+	if err := store.ReadAt("bodies.0000.cdat", part, 8); err != nil || string(part) != "chain" {
+		t.Fatalf("range mismatch: have %q (err %v), want %q", part, err, "chain")
+	}
+	if err := store.Delete("bodies.0000.cdat"); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if _, err := store.Size("bodies.0000.cdat"); err != errRemoteNotFound {
+		t.Fatalf("deleted file: have %v, want %v", err, errRemoteNotFound)
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+const (
+	// s3RequestTimeout is the timeout of the requests to an object storage,
+	// apart from uploads which are only bounded by the transfer.
+	s3RequestTimeout = 30 * time.Second
+
+	// s3UnsignedPayload is the payload hash used for uploads, which are
+	// streamed from the data files instead of being hashed upfront.
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// s3EmptyPayload is the payload hash of requests without a body.
+var s3EmptyPayload = hex.EncodeToString(sha256.New().Sum(nil))
+
+// s3Store is a FreezerStore keeping the data files in a bucket of an S3
+// compatible object storage, including Google Cloud Storage through its XML API
+// with HMAC keys.
+type s3Store struct {
+	client   *http.Client
+	signer   *v4.Signer
+	creds    aws.CredentialsProvider
+	region   string
+	endpoint string // Base URL of the objects, without a trailing slash
+}
+
+// NewFreezerStore creates a remote store for freezer data files from its URL:
+//
+//   s3://bucket/prefix    an Amazon S3 bucket
+//   gs://bucket/prefix    a Google Cloud Storage bucket, using HMAC keys
+//
+// The credentials are resolved like by the AWS tools, e.g. from the
+// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables. The region
+// and endpoint can be set with the query parameters of the same names, the
+// latter allowing to use any other S3 compatible storage.
+func NewFreezerStore(rawurl string) (FreezerStore, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("missing bucket in %q", rawurl)
+	}
+	var (
+		bucket   = u.Host
+		prefix   = strings.Trim(u.Path, "/")
+		region   = u.Query().Get("region")
+		endpoint = u.Query().Get("endpoint")
+	)
+	cfg, err := config.LoadDefaultConfig(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "s3":
+		if region == "" {
+			region = cfg.Region
+		}
+		if region == "" {
+			region = "us-east-1"
+		}
+		if endpoint == "" {
+			// Virtual hosted-style access, as path-style is being deprecated
+			endpoint, bucket = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, region), ""
+		}
+	case "gs":
+		if region == "" {
+			region = "auto"
+		}
+		if endpoint == "" {
+			endpoint = "https://storage.googleapis.com"
+		}
+	default:
+		return nil, fmt.Errorf("unsupported object storage %q", u.Scheme)
+	}
+	if cfg.Credentials == nil {
+		return nil, fmt.Errorf("no credentials for %q", rawurl)
+	}
+	base := strings.TrimSuffix(endpoint, "/")
+	if p := path.Join(bucket, prefix); p != "" {
+		base += "/" + p
+	}
+	return &s3Store{
+		client: new(http.Client),
+		signer: v4.NewSigner(func(o *v4.SignerOptions) {
+			o.DisableURIPathEscaping = true
+		}),
+		creds:    cfg.Credentials,
+		region:   region,
+		endpoint: base,
+	}, nil
+}
+
+// do signs and sends a request for the given object, returning the response if
+// its status is one of the expected ones.
+func (s *s3Store) do(ctx context.Context, method, name string, body io.Reader, size int64, header http.Header, expect ...int) (*http.Response, error) {
+	req, err := http.NewRequest(method, s.endpoint+"/"+name, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	payload := s3EmptyPayload
+	if body != nil {
+		payload = s3UnsignedPayload
+		req.ContentLength = size
+	}
+	req.Header.Set("X-Amz-Content-Sha256", payload)
+
+	creds, err := s.creds.Retrieve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.signer.SignHTTP(ctx, creds, req, payload, "s3", s.region, time.Now()); err != nil {
+		return nil, err
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range expect {
+		if res.StatusCode == status {
+			return res, nil
+		}
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, errRemoteNotFound
+	}
+	msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 512))
+	return nil, fmt.Errorf("%s %s: %s: %s", method, name, res.Status, strings.TrimSpace(string(msg)))
+}
+
+// Put implements FreezerStore, uploading the file in a single request. The
+// storage checks the upload against the Content-MD5 header.
+func (s *s3Store) Put(name string, r io.Reader, size int64, digest []byte) error {
+	header := http.Header{"Content-Md5": []string{base64.StdEncoding.EncodeToString(digest)}}
+	res, err := s.do(context.Background(), http.MethodPut, name, r, size, header, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+// Size implements FreezerStore.
+func (s *s3Store) Size(name string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s3RequestTimeout)
+	defer cancel()
+
+	res, err := s.do(ctx, http.MethodHead, name, nil, 0, nil, http.StatusOK)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+	return res.ContentLength, nil
+}
+
+// Digest implements FreezerStore. The ETag of objects uploaded in a single
+// request is their MD5 digest, unless they are encrypted with a managed key.
+func (s *s3Store) Digest(name string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s3RequestTimeout)
+	defer cancel()
+
+	res, err := s.do(ctx, http.MethodHead, name, nil, 0, nil, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	res.Body.Close()
+
+	digest, err := hex.DecodeString(strings.Trim(res.Header.Get("ETag"), `"`))
+	if err != nil || len(digest) != md5.Size {
+		return nil, nil
+	}
+	return digest, nil
+}
+
+// ReadAt implements FreezerStore, requesting the given range of the object.
+func (s *s3Store) ReadAt(name string, p []byte, off int64) error {
+	if len(p) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s3RequestTimeout)
+	defer cancel()
+
+	header := http.Header{"Range": []string{fmt.Sprintf("bytes=%d-%d", off, off+int64(len(p))-1)}}
+	res, err := s.do(ctx, http.MethodGet, name, nil, 0, header, http.StatusPartialContent)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	_, err = io.ReadFull(res.Body, p)
+	return err
+}
+
+// Delete implements FreezerStore.
+func (s *s3Store) Delete(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s3RequestTimeout)
+	defer cancel()
+
+	res, err := s.do(ctx, http.MethodDelete, name, nil, 0, nil, http.StatusOK, http.StatusNoContent)
+	if err == errRemoteNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
@@ -100,11 +100,14 @@ type freezerTable struct {
 	name          string
 	path          string
 
-	head   *os.File            // File descriptor for the data head of the table
-	files  map[uint32]*os.File // open files
-	headId uint32              // number of the currently active head file
-	tailId uint32              // number of the earliest file
-	index  *os.File            // File descriptor for the indexEntry file of the table
+	head   *os.File               // File descriptor for the data head of the table
+	files  map[uint32]*os.File    // open files
+	remote map[uint32]*remoteFile // data files offloaded to the remote store
+	store  FreezerStore           // Remote store for the data files, nil if kept locally
+	cuts   uint64                 // number of truncations, to detect files changing during an offload
+	headId uint32                 // number of the currently active head file
+	tailId uint32                 // number of the earliest file
+	index  *os.File               // File descriptor for the indexEntry file of the table
 
 	// In the case that old items are deleted (from the tail), we use itemOffset
 	// to count how many historic items have gone missing.
@@ -166,6 +169,12 @@ func truncateFreezerFile(file *os.File, size int64) error {
 // non existent. Both files are truncated to the shortest common length to ensure
 // they don't go out of sync.
 func newTable(path string, name string, readMeter metrics.Meter, writeMeter metrics.Meter, sizeGauge metrics.Gauge, maxFilesize uint32, noCompression bool) (*freezerTable, error) {
+	return newTableWithStore(path, name, readMeter, writeMeter, sizeGauge, maxFilesize, noCompression, nil)
+}
+
+// newTableWithStore opens a freezer table like newTable, offloading the data
+// files the table advanced past to the given remote store if it's not nil.
+func newTableWithStore(path string, name string, readMeter metrics.Meter, writeMeter metrics.Meter, sizeGauge metrics.Gauge, maxFilesize uint32, noCompression bool, store FreezerStore) (*freezerTable, error) {
 	// Ensure the containing directory exists and open the indexEntry file
 	if err := os.MkdirAll(path, 0755); err != nil {
 		return nil, err
@@ -186,6 +195,8 @@ func newTable(path string, name string, readMeter metrics.Meter, writeMeter metr
 	tab := &freezerTable{
 		index:         offsets,
 		files:         make(map[uint32]*os.File),
+		remote:        make(map[uint32]*remoteFile),
+		store:         store,
 		readMeter:     readMeter,
 		writeMeter:    writeMeter,
 		sizeGauge:     sizeGauge,
@@ -288,6 +299,9 @@ func (t *freezerTable) repair() error {
 			if newLastIndex.filenum != lastIndex.filenum {
 				// Release earlier opened file
 				t.releaseFile(lastIndex.filenum)
+				if err := t.restoreFile(newLastIndex.filenum); err != nil {
+					return err
+				}
 				if t.head, err = t.openFile(newLastIndex.filenum, openFreezerFileForAppend); err != nil {
 					return err
 				}
@@ -329,8 +343,16 @@ func (t *freezerTable) repair() error {
 func (t *freezerTable) preopen() (err error) {
 	// The repair might have already opened (some) files
 	t.releaseFilesAfter(0, false)
-	// Open all except head in RDONLY
+	// Open all except head in RDONLY, the offloaded ones from the remote store
 	for i := t.tailId; i < t.headId; i++ {
+		if t.store != nil {
+			if _, err := os.Stat(filepath.Join(t.path, t.fileName(i))); os.IsNotExist(err) {
+				if err = t.openRemote(i); err != nil {
+					return err
+				}
+				continue
+			}
+		}
 		if _, err = t.openFile(i, openFreezerFileForReadOnly); err != nil {
 			return err
 		}
@@ -361,6 +383,7 @@ func (t *freezerTable) truncate(items uint64) error {
 		log = t.logger.Warn // Only loud warn if we delete multiple items
 	}
 	log("Truncating freezer table", "items", existing, "limit", items)
+	t.cuts++
 	if err := truncateFreezerFile(t.index, int64(items+1)*indexEntrySize); err != nil {
 		return err
 	}
@@ -376,6 +399,9 @@ func (t *freezerTable) truncate(items uint64) error {
 	if expected.filenum != t.headId {
 		// If already open for reading, force-reopen for writing
 		t.releaseFile(expected.filenum)
+		if err := t.restoreFile(expected.filenum); err != nil {
+			return err
+		}
 		newHead, err := t.openFile(expected.filenum, openFreezerFileForAppend)
 		if err != nil {
 			return err
@@ -432,13 +458,7 @@ func (t *freezerTable) Close() error {
 func (t *freezerTable) openFile(num uint32, opener func(string) (*os.File, error)) (f *os.File, err error) {
 	var exist bool
 	if f, exist = t.files[num]; !exist {
-		var name string
-		if t.noCompression {
-			name = fmt.Sprintf("%s.%04d.rdat", t.name, num)
-		} else {
-			name = fmt.Sprintf("%s.%04d.cdat", t.name, num)
-		}
-		f, err = opener(filepath.Join(t.path, name))
+		f, err = opener(filepath.Join(t.path, t.fileName(num)))
 		if err != nil {
 			return nil, err
 		}
@@ -447,6 +467,14 @@ func (t *freezerTable) openFile(num uint32, opener func(string) (*os.File, error
 	return f, err
 }
 
+// fileName returns the name of the data file with the given number.
+func (t *freezerTable) fileName(num uint32) string {
+	if t.noCompression {
+		return fmt.Sprintf("%s.%04d.rdat", t.name, num)
+	}
+	return fmt.Sprintf("%s.%04d.cdat", t.name, num)
+}
+
 // releaseFile closes a file, and removes it from the open file cache.
 // Assumes that the caller holds the write lock
 func (t *freezerTable) releaseFile(num uint32) {
@@ -467,6 +495,16 @@ func (t *freezerTable) releaseFilesAfter(num uint32, remove bool) {
 			}
 		}
 	}
+	for fnum, f := range t.remote {
+		if fnum > num {
+			delete(t.remote, fnum)
+			if remove {
+				if err := t.store.Delete(f.name); err != nil {
+					t.logger.Warn("Failed to delete offloaded data file", "file", f.name, "err", err)
+				}
+			}
+		}
+	}
 }
 
 // getIndices returns the index entries for the given from-item, covering 'count' items.
@@ -576,8 +614,9 @@ func (t *freezerTable) retrieveItems(start, count, maxBytes uint64) ([]byte, []i
 		count = itemCount - start
 	}
 	var (
-		output     = make([]byte, maxBytes) // Buffer to read data into
-		outputSize int                      // Used size of that buffer
+		first, last = start, start + count   // Range of the items being read
+		output      = make([]byte, maxBytes) // Buffer to read data into
+		outputSize  int                      // Used size of that buffer
 	)
 	// readData is a helper method to read a single data item from disk.
 	readData := func(fileId, start uint32, length int) error {
@@ -586,13 +625,17 @@ func (t *freezerTable) retrieveItems(start, count, maxBytes uint64) ([]byte, []i
 		if len(output) < length {
 			output = make([]byte, length)
 		}
-		dataFile, exist := t.files[fileId]
-		if !exist {
+		if f, exist := t.files[fileId]; exist {
+			if _, err := f.ReadAt(output[outputSize:outputSize+length], int64(start)); err != nil {
+				return err
+			}
+		} else if f, exist := t.remote[fileId]; exist {
+			if err := t.readRemote(f, output[outputSize:outputSize+length], int64(start), first, last); err != nil {
+				return err
+			}
+		} else {
 			return fmt.Errorf("missing data file %d", fileId)
 		}
-		if _, err := dataFile.ReadAt(output[outputSize:outputSize+length], int64(start)); err != nil {
-			return err
-		}
 		outputSize += length
 		return nil
 	}
@@ -0,0 +1,216 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/ethdb"
+	"github.com/scroll-tech/go-ethereum/ethdb/memorydb"
+)
+
+// errOverlayNotFound is returned if a key was deleted from the overlay.
+var errOverlayNotFound = errors.New("not found")
+
+// overlaydb is a wrapper around a database opened in read-only mode, which keeps
+// all writes in memory instead of forwarding them to disk. It allows the chain to
+// be loaded from a static copy of a data directory, with the bookkeeping writes
+// done on startup and shutdown being discarded when the node stops.
+type overlaydb struct {
+	ethdb.Database
+
+	mem     *memorydb.Database  // Values written since the database was opened
+	deleted map[string]struct{} // Keys deleted since the database was opened
+	lock    sync.RWMutex        // Mutex protecting the deletion set
+}
+
+// NewReadOnlyDatabase wraps a database opened in read-only mode, absorbing all
+// key-value writes in memory. Ancient writes are still forwarded and need to be
+// rejected by the freezer itself.
+func NewReadOnlyDatabase(db ethdb.Database) ethdb.Database {
+	return &overlaydb{
+		Database: db,
+		mem:      memorydb.New(),
+		deleted:  make(map[string]struct{}),
+	}
+}
+
+// Close closes the underlying database, discarding all in-memory writes.
+func (db *overlaydb) Close() error {
+	db.mem.Close()
+	return db.Database.Close()
+}
+
+// Has retrieves if a key is present in the database.
+func (db *overlaydb) Has(key []byte) (bool, error) {
+	db.lock.RLock()
+	_, deleted := db.deleted[string(key)]
+	db.lock.RUnlock()
+
+	if deleted {
+		return false, nil
+	}
+	if ok, _ := db.mem.Has(key); ok {
+		return true, nil
+	}
+	return db.Database.Has(key)
+}
+
+// Get retrieves the given key if it's present in the database.
+func (db *overlaydb) Get(key []byte) ([]byte, error) {
+	db.lock.RLock()
+	_, deleted := db.deleted[string(key)]
+	db.lock.RUnlock()
+
+	if deleted {
+		return nil, errOverlayNotFound
+	}
+	if blob, err := db.mem.Get(key); err == nil {
+		return blob, nil
+	}
+	return db.Database.Get(key)
+}
+
+// Put inserts the given value into the in-memory overlay.
+func (db *overlaydb) Put(key []byte, value []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	delete(db.deleted, string(key))
+	return db.mem.Put(key, value)
+}
+
+// Delete hides the key from the database without touching the disk.
+func (db *overlaydb) Delete(key []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.deleted[string(key)] = struct{}{}
+	return db.mem.Delete(key)
+}
+
+// NewIterator creates a binary-alphabetical iterator over a subset of database
+// content with a particular key prefix, merging the overlay into the contents
+// on disk.
+func (db *overlaydb) NewIterator(prefix []byte, start []byte) ethdb.Iterator {
+	// Snapshot the deletion set, so the iterator is unaffected by later writes
+	db.lock.RLock()
+	deleted := make(map[string]struct{}, len(db.deleted))
+	for key := range db.deleted {
+		deleted[key] = struct{}{}
+	}
+	db.lock.RUnlock()
+
+	return &overlayIterator{
+		mergedIterator: &mergedIterator{
+			base: db.Database.NewIterator(prefix, start),
+			top:  db.mem.NewIterator(prefix, start),
+		},
+		deleted: deleted,
+	}
+}
+
+// Compact is a noop, the database on disk is never modified.
+func (db *overlaydb) Compact(start []byte, limit []byte) error {
+	return nil
+}
+
+// NewBatch creates a write-only database that buffers changes to the overlay
+// until a final write is called.
+func (db *overlaydb) NewBatch() ethdb.Batch {
+	return &overlayBatch{db: db}
+}
+
+// overlayOp is a single write queued up in an overlay batch.
+type overlayOp struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+// overlayBatch is a batch applying its writes to the in-memory overlay.
+type overlayBatch struct {
+	db     *overlaydb
+	writes []overlayOp
+	size   int
+}
+
+// Put inserts the given value into the batch for later committing.
+func (b *overlayBatch) Put(key, value []byte) error {
+	b.writes = append(b.writes, overlayOp{common.CopyBytes(key), common.CopyBytes(value), false})
+	b.size += len(key) + len(value)
+	return nil
+}
+
+// Delete inserts the a key removal into the batch for later committing.
+func (b *overlayBatch) Delete(key []byte) error {
+	b.writes = append(b.writes, overlayOp{common.CopyBytes(key), nil, true})
+	b.size += len(key)
+	return nil
+}
+
+// ValueSize retrieves the amount of data queued up for writing.
+func (b *overlayBatch) ValueSize() int {
+	return b.size
+}
+
+// Write flushes any accumulated data into the overlay.
+func (b *overlayBatch) Write() error {
+	return b.Replay(b.db)
+}
+
+// Reset resets the batch for reuse.
+func (b *overlayBatch) Reset() {
+	b.writes = b.writes[:0]
+	b.size = 0
+}
+
+// Replay replays the batch contents.
+func (b *overlayBatch) Replay(w ethdb.KeyValueWriter) error {
+	for _, op := range b.writes {
+		if op.delete {
+			if err := w.Delete(op.key); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := w.Put(op.key, op.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// overlayIterator is a merged iterator over the overlay and the disk contents,
+// skipping the keys deleted from the overlay.
+type overlayIterator struct {
+	*mergedIterator
+	deleted map[string]struct{}
+}
+
+// Next moves the iterator to the next key/value pair. It returns whether the
+// iterator is exhausted.
+func (it *overlayIterator) Next() bool {
+	for it.mergedIterator.Next() {
+		if _, ok := it.deleted[string(it.Key())]; !ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/ethdb"
+	"github.com/scroll-tech/go-ethereum/ethdb/dbtest"
+)
+
+func TestReadOnlyDatabaseSuite(t *testing.T) {
+	dbtest.TestDatabaseSuite(t, func() ethdb.KeyValueStore {
+		return NewReadOnlyDatabase(NewMemoryDatabase())
+	})
+}
+
+// Tests that writes to a read-only database are served back without ever
+// reaching the underlying one.
+func TestReadOnlyDatabaseOverlay(t *testing.T) {
+	var (
+		disk = NewMemoryDatabase()
+		db   = NewReadOnlyDatabase(disk)
+	)
+	disk.Put([]byte("a"), []byte("disk-a"))
+	disk.Put([]byte("b"), []byte("disk-b"))
+	disk.Put([]byte("c"), []byte("disk-c"))
+
+	batch := db.NewBatch()
+	batch.Put([]byte("b"), []byte("mem-b"))
+	batch.Put([]byte("d"), []byte("mem-d"))
+	batch.Delete([]byte("c"))
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write batch: %v", err)
+	}
+	if err := db.Delete([]byte("a")); err != nil {
+		t.Fatalf("failed to delete key: %v", err)
+	}
+	// The overlay should be visible through the wrapper
+	for key, want := range map[string]string{"b": "mem-b", "d": "mem-d"} {
+		if have, err := db.Get([]byte(key)); err != nil || string(have) != want {
+			t.Errorf("key %s: value mismatch: have %q (%v), want %q", key, have, err, want)
+		}
+	}
+	for _, key := range []string{"a", "c"} {
+		if ok, _ := db.Has([]byte(key)); ok {
+			t.Errorf("key %s: deleted key still present", key)
+		}
+	}
+	var (
+		keys []string
+		it   = db.NewIterator(nil, nil)
+	)
+	for it.Next() {
+		keys = append(keys, string(it.Key())+"="+string(it.Value()))
+	}
+	it.Release()
+	if len(keys) != 2 || keys[0] != "b=mem-b" || keys[1] != "d=mem-d" {
+		t.Errorf("iterated content mismatch: have %v", keys)
+	}
+	// The underlying database must be untouched
+	for key, want := range map[string]string{"a": "disk-a", "b": "disk-b", "c": "disk-c"} {
+		if have, err := disk.Get([]byte(key)); err != nil || string(have) != want {
+			t.Errorf("key %s: disk value mismatch: have %q (%v), want %q", key, have, err, want)
+		}
+	}
+	if ok, _ := disk.Has([]byte("d")); ok {
+		t.Errorf("overlay write reached the disk")
+	}
+}
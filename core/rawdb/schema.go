@@ -30,6 +30,9 @@ var (
 	// databaseVersionKey tracks the current database version.
 	databaseVersionKey = []byte("DatabaseVersion")
 
+	// stateSchemeKey tracks the trie scheme the state of the database is stored with.
+	stateSchemeKey = []byte("StateScheme")
+
 	// headHeaderKey tracks the latest known header's hash.
 	headHeaderKey = []byte("LastHeader")
 
@@ -69,9 +72,30 @@ var (
 	// fastTxLookupLimitKey tracks the transaction lookup limit during fast sync.
 	fastTxLookupLimitKey = []byte("FastTransactionLookupLimit")
 
+	// bloomBitsSectionSizeKey tracks the section size the bloom bits index was built with.
+	bloomBitsSectionSizeKey = []byte("BloomBitsSectionSize")
+
+	// txPoolPolicyKey tracks the transaction pool policy set at runtime.
+	txPoolPolicyKey = []byte("TxPoolPolicy")
+
+	// checkpointBundleKey tracks the imported signed header checkpoints.
+	checkpointBundleKey = []byte("CheckpointBundle")
+
+	// zktriePruningMarkerKey tracks the sweep progress of an interrupted zktrie pruning.
+	zktriePruningMarkerKey = []byte("ZktriePruningMarker")
+
+	// stateCopyProgressKey tracks the progress of an interrupted state copy from a peer node.
+	stateCopyProgressKey = []byte("StateCopyProgress")
+
 	// badBlockKey tracks the list of bad blocks seen by local
 	badBlockKey = []byte("InvalidBlock")
 
+	// badBlockWitnessPrefix + hash -> execution witness captured for a bad block
+	badBlockWitnessPrefix = []byte("InvalidBlockWitness-")
+
+	// orderingAuditPrefix + hash -> ordering decisions of a locally mined block
+	orderingAuditPrefix = []byte("OrderingAudit-")
+
 	// uncleanShutdownKey tracks the list of local crashes
 	uncleanShutdownKey = []byte("unclean-shutdown") // config prefix for the db
 
@@ -89,6 +113,14 @@ var (
 	SnapshotAccountPrefix = []byte("a") // SnapshotAccountPrefix + account hash -> account trie value
 	SnapshotStoragePrefix = []byte("o") // SnapshotStoragePrefix + account hash + storage hash -> storage trie value
 	CodePrefix            = []byte("c") // CodePrefix + code hash -> account code
+	callTracePrefix       = []byte("T") // callTracePrefix + tx hash + block hash -> call trace
+	stateRootBlockPrefix  = []byte("R") // stateRootBlockPrefix + state root + num (uint64 big endian) -> nil
+	senderNoncePrefix     = []byte("N") // senderNoncePrefix + sender + nonce (uint64 big endian) -> tx hash
+	addressActivityPrefix = []byte("A") // addressActivityPrefix + address + num (uint64 big endian) + tx index (uint32 big endian) + block hash -> tx hash + flags
+	tokenTransferPrefix   = []byte("k") // tokenTransferPrefix + token + num (uint64 big endian) + log index (uint32 big endian) + block hash -> token transfer
+	holderTransferPrefix  = []byte("K") // holderTransferPrefix + holder + num (uint64 big endian) + log index (uint32 big endian) + block hash -> token transfer
+	eventLogPrefix        = []byte("E") // eventLogPrefix + address + topic + num (uint64 big endian) + log index (uint32 big endian) + block hash -> tx index (uint32 big endian)
+	zkLeafEpochPrefix     = []byte("Z") // zkLeafEpochPrefix + trie owner + leaf key -> state epoch (uint64 big endian)
 
 	PreimagePrefix = []byte("secure-key-")      // PreimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-") // config prefix for the db
@@ -115,6 +147,11 @@ const (
 
 	// freezerDifficultyTable indicates the name of the freezer total difficulty table.
 	freezerDifficultyTable = "diffs"
+
+	// FreezerHeaderTable and FreezerHashTable are the names of the header and the
+	// canonical hash tables, for tools handling raw ancient items.
+	FreezerHeaderTable = freezerHeaderTable
+	FreezerHashTable   = freezerHashTable
 )
 
 // FreezerNoSnappy configures whether compression is disabled for the ancient-tables.
@@ -182,6 +219,74 @@ func txLookupKey(hash common.Hash) []byte {
 	return append(txLookupPrefix, hash.Bytes()...)
 }
 
+// callTraceKey = callTracePrefix + tx hash + block hash
+func callTraceKey(txHash common.Hash, blockHash common.Hash) []byte {
+	return append(append(append([]byte{}, callTracePrefix...), txHash.Bytes()...), blockHash.Bytes()...)
+}
+
+// stateRootBlockKey = stateRootBlockPrefix + root + num (uint64 big endian)
+func stateRootBlockKey(root common.Hash, number uint64) []byte {
+	return append(append(append([]byte{}, stateRootBlockPrefix...), root.Bytes()...), encodeBlockNumber(number)...)
+}
+
+// ZkLeafEpochKey = zkLeafEpochPrefix + trie owner + leaf key
+func ZkLeafEpochKey(owner, leaf common.Hash) []byte {
+	return append(append(append([]byte{}, zkLeafEpochPrefix...), owner.Bytes()...), leaf.Bytes()...)
+}
+
+// senderNonceKey = senderNoncePrefix + sender + nonce (uint64 big endian)
+func senderNonceKey(sender common.Address, nonce uint64) []byte {
+	return append(append(senderNoncePrefix, sender.Bytes()...), encodeBlockNumber(nonce)...)
+}
+
+// addressPositionKey = prefix + address + num (uint64 big endian) + index (uint32 big endian) + block hash
+func addressPositionKey(prefix []byte, address common.Address, number uint64, index uint32, hash common.Hash) []byte {
+	key := make([]byte, len(prefix)+common.AddressLength+8+4+common.HashLength)
+	n := copy(key, prefix)
+	n += copy(key[n:], address.Bytes())
+	binary.BigEndian.PutUint64(key[n:], number)
+	binary.BigEndian.PutUint32(key[n+8:], index)
+	copy(key[n+12:], hash.Bytes())
+	return key
+}
+
+// addressActivityKey = addressActivityPrefix + address + num (uint64 big endian) + tx index (uint32 big endian) + block hash
+func addressActivityKey(address common.Address, number uint64, index uint32, hash common.Hash) []byte {
+	return addressPositionKey(addressActivityPrefix, address, number, index, hash)
+}
+
+// tokenTransferKey = tokenTransferPrefix + token + num (uint64 big endian) + log index (uint32 big endian) + block hash
+func tokenTransferKey(token common.Address, number uint64, index uint32, hash common.Hash) []byte {
+	return addressPositionKey(tokenTransferPrefix, token, number, index, hash)
+}
+
+// holderTransferKey = holderTransferPrefix + holder + num (uint64 big endian) + log index (uint32 big endian) + block hash
+func holderTransferKey(holder common.Address, number uint64, index uint32, hash common.Hash) []byte {
+	return addressPositionKey(holderTransferPrefix, holder, number, index, hash)
+}
+
+// eventLogKey = eventLogPrefix + address + topic + num (uint64 big endian) + log index (uint32 big endian) + block hash
+func eventLogKey(address common.Address, topic common.Hash, number uint64, index uint32, hash common.Hash) []byte {
+	key := make([]byte, len(eventLogPrefix)+common.AddressLength+common.HashLength+8+4+common.HashLength)
+	n := copy(key, eventLogPrefix)
+	n += copy(key[n:], address.Bytes())
+	n += copy(key[n:], topic.Bytes())
+	binary.BigEndian.PutUint64(key[n:], number)
+	binary.BigEndian.PutUint32(key[n+8:], index)
+	copy(key[n+12:], hash.Bytes())
+	return key
+}
+
+// badBlockWitnessKey = badBlockWitnessPrefix + hash
+func badBlockWitnessKey(hash common.Hash) []byte {
+	return append(badBlockWitnessPrefix, hash.Bytes()...)
+}
+
+// orderingAuditKey = orderingAuditPrefix + hash
+func orderingAuditKey(hash common.Hash) []byte {
+	return append(orderingAuditPrefix, hash.Bytes()...)
+}
+
 // accountSnapshotKey = SnapshotAccountPrefix + hash
 func accountSnapshotKey(hash common.Hash) []byte {
 	return append(SnapshotAccountPrefix, hash.Bytes()...)
@@ -0,0 +1,63 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/ethdb"
+)
+
+// The trie schemes the state can be stored with.
+const (
+	// StateSchemeMPT is the scheme of merkle patricia tries, whose nodes are RLP
+	// lists stored under their keccak hash.
+	StateSchemeMPT = "mpt"
+
+	// StateSchemeZktrie is the scheme of zk tries, whose nodes start with their
+	// node type and are stored under their little endian poseidon hash.
+	StateSchemeZktrie = "zktrie"
+)
+
+// Leading bytes of the zk trie nodes which can be the root of a state trie.
+const (
+	zktrieNodeTypeMiddle = 0
+	zktrieNodeTypeLeaf   = 1
+)
+
+// DetectStateScheme inspects the root node of the state trie with the given
+// root hash, returning which scheme it was stored with, or an empty string if
+// the node is not present in the database.
+func DetectStateScheme(db ethdb.KeyValueReader, root common.Hash) string {
+	if root == (common.Hash{}) {
+		return ""
+	}
+	if blob, _ := db.Get(root.Bytes()); len(blob) > 0 && blob[0] >= 0xc0 {
+		return StateSchemeMPT
+	}
+	// Zk trie nodes are keyed by the byte reversed hash
+	key := make([]byte, common.HashLength)
+	for i := range key {
+		key[i] = root[common.HashLength-1-i]
+	}
+	if blob, _ := db.Get(key); len(blob) > 0 {
+		switch blob[0] {
+		case zktrieNodeTypeMiddle, zktrieNodeTypeLeaf:
+			return StateSchemeZktrie
+		}
+	}
+	return ""
+}
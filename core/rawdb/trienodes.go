@@ -0,0 +1,259 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/ethdb"
+)
+
+// isTrieNodeKey reports whether a key addresses a trie node. Both zk and merkle
+// patricia trie nodes are stored under their bare 32 byte hash.
+func isTrieNodeKey(key []byte) bool {
+	return len(key) == common.HashLength
+}
+
+// nodedb is a wrapper around a chain database which moves all trie nodes into a
+// separate key-value store, allowing the store to be tuned for the random access
+// pattern of trie nodes independently from the sequential one of chain data.
+type nodedb struct {
+	ethdb.Database
+	nodes ethdb.KeyValueStore
+}
+
+// NewDatabaseWithNodeStore returns a database which keeps trie nodes in the given
+// dedicated store and everything else in the chain database. Nodes missing from
+// the dedicated store are looked up in the chain database, so an existing chain
+// database can be switched over without migrating its nodes.
+func NewDatabaseWithNodeStore(db ethdb.Database, nodes ethdb.KeyValueStore) ethdb.Database {
+	return &nodedb{Database: db, nodes: nodes}
+}
+
+// Close closes both the chain database and the trie node store.
+func (db *nodedb) Close() error {
+	err := db.nodes.Close()
+	if cerr := db.Database.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Has retrieves if a key is present in the database.
+func (db *nodedb) Has(key []byte) (bool, error) {
+	if isTrieNodeKey(key) {
+		if ok, err := db.nodes.Has(key); ok || err != nil {
+			return ok, err
+		}
+	}
+	return db.Database.Has(key)
+}
+
+// Get retrieves the given key if it's present in the database.
+func (db *nodedb) Get(key []byte) ([]byte, error) {
+	if isTrieNodeKey(key) {
+		if blob, err := db.nodes.Get(key); err == nil {
+			return blob, nil
+		}
+	}
+	return db.Database.Get(key)
+}
+
+// Put inserts the given value into the database, trie nodes going into the
+// dedicated store.
+func (db *nodedb) Put(key []byte, value []byte) error {
+	if isTrieNodeKey(key) {
+		return db.nodes.Put(key, value)
+	}
+	return db.Database.Put(key, value)
+}
+
+// Delete removes the key from the database. Trie nodes are removed from both
+// stores, since they might predate the dedicated one.
+func (db *nodedb) Delete(key []byte) error {
+	if isTrieNodeKey(key) {
+		if err := db.nodes.Delete(key); err != nil {
+			return err
+		}
+	}
+	return db.Database.Delete(key)
+}
+
+// NewIterator creates a binary-alphabetical iterator over a subset of database
+// content with a particular key prefix, merging the contents of both stores.
+func (db *nodedb) NewIterator(prefix []byte, start []byte) ethdb.Iterator {
+	if len(prefix) > common.HashLength {
+		return db.Database.NewIterator(prefix, start)
+	}
+	return &mergedIterator{
+		base: db.Database.NewIterator(prefix, start),
+		top:  db.nodes.NewIterator(prefix, start),
+	}
+}
+
+// Stat returns a particular internal stat of the chain database.
+func (db *nodedb) Stat(property string) (string, error) {
+	return db.Database.Stat(property)
+}
+
+// Compact flattens the given key range of both stores.
+func (db *nodedb) Compact(start []byte, limit []byte) error {
+	if err := db.nodes.Compact(start, limit); err != nil {
+		return err
+	}
+	return db.Database.Compact(start, limit)
+}
+
+// NewBatch creates a write-only database that buffers changes to both stores
+// until a final write is called.
+func (db *nodedb) NewBatch() ethdb.Batch {
+	return &nodedbBatch{
+		chain: db.Database.NewBatch(),
+		nodes: db.nodes.NewBatch(),
+	}
+}
+
+// nodedbBatch is a batch spanning both the chain database and the trie node store.
+type nodedbBatch struct {
+	chain ethdb.Batch
+	nodes ethdb.Batch
+}
+
+// Put inserts the given value into the batch for later committing.
+func (b *nodedbBatch) Put(key, value []byte) error {
+	if isTrieNodeKey(key) {
+		return b.nodes.Put(key, value)
+	}
+	return b.chain.Put(key, value)
+}
+
+// Delete inserts the a key removal into the batch for later committing.
+func (b *nodedbBatch) Delete(key []byte) error {
+	if isTrieNodeKey(key) {
+		if err := b.nodes.Delete(key); err != nil {
+			return err
+		}
+	}
+	return b.chain.Delete(key)
+}
+
+// ValueSize retrieves the amount of data queued up for writing.
+func (b *nodedbBatch) ValueSize() int {
+	return b.chain.ValueSize() + b.nodes.ValueSize()
+}
+
+// Write flushes any accumulated data to disk. Trie nodes are written first, so
+// that chain data never references missing nodes if the second write fails.
+func (b *nodedbBatch) Write() error {
+	if err := b.nodes.Write(); err != nil {
+		return err
+	}
+	return b.chain.Write()
+}
+
+// Reset resets the batch for reuse.
+func (b *nodedbBatch) Reset() {
+	b.chain.Reset()
+	b.nodes.Reset()
+}
+
+// Replay replays the batch contents, trie nodes first.
+func (b *nodedbBatch) Replay(w ethdb.KeyValueWriter) error {
+	if err := b.nodes.Replay(w); err != nil {
+		return err
+	}
+	return b.chain.Replay(w)
+}
+
+// mergedIterator merges the iterators of two stores, yielding keys in ascending
+// order. Keys present in both are served from the top store, shadowing the stale
+// copy in the base one.
+type mergedIterator struct {
+	base, top     ethdb.Iterator
+	baseOk, topOk bool
+	init          bool
+	cur           ethdb.Iterator // Iterator positioned at the current entry
+}
+
+// Next moves the iterator to the next key/value pair. It returns whether the
+// iterator is exhausted.
+func (it *mergedIterator) Next() bool {
+	switch {
+	case !it.init:
+		it.init = true
+		it.baseOk, it.topOk = it.base.Next(), it.top.Next()
+	case it.cur == it.base:
+		it.baseOk = it.base.Next()
+	case it.cur == it.top:
+		it.topOk = it.top.Next()
+	}
+	switch {
+	case it.baseOk && it.topOk:
+		switch cmp := bytes.Compare(it.base.Key(), it.top.Key()); {
+		case cmp < 0:
+			it.cur = it.base
+		case cmp > 0:
+			it.cur = it.top
+		default:
+			// Key present in both stores, skip the stale base copy
+			it.baseOk = it.base.Next()
+			it.cur = it.top
+			if it.baseOk && bytes.Compare(it.base.Key(), it.top.Key()) < 0 {
+				it.cur = it.base
+			}
+		}
+	case it.baseOk:
+		it.cur = it.base
+	case it.topOk:
+		it.cur = it.top
+	default:
+		it.cur = nil
+	}
+	return it.cur != nil
+}
+
+// Error returns any accumulated error. Exhausting all the key/value pairs
+// is not considered to be an error.
+func (it *mergedIterator) Error() error {
+	if err := it.base.Error(); err != nil {
+		return err
+	}
+	return it.top.Error()
+}
+
+// Key returns the key of the current key/value pair, or nil if done.
+func (it *mergedIterator) Key() []byte {
+	if it.cur == nil {
+		return nil
+	}
+	return it.cur.Key()
+}
+
+// Value returns the value of the current key/value pair, or nil if done.
+func (it *mergedIterator) Value() []byte {
+	if it.cur == nil {
+		return nil
+	}
+	return it.cur.Value()
+}
+
+// Release releases associated resources.
+func (it *mergedIterator) Release() {
+	it.base.Release()
+	it.top.Release()
+}
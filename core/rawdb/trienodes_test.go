@@ -0,0 +1,99 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/ethdb"
+	"github.com/scroll-tech/go-ethereum/ethdb/dbtest"
+	"github.com/scroll-tech/go-ethereum/ethdb/memorydb"
+)
+
+func TestNodeStoreDatabaseSuite(t *testing.T) {
+	dbtest.TestDatabaseSuite(t, func() ethdb.KeyValueStore {
+		return NewDatabaseWithNodeStore(NewMemoryDatabase(), memorydb.New())
+	})
+}
+
+// Tests that trie nodes are routed into the dedicated store, with nodes of the
+// chain database still being served.
+func TestNodeStoreRouting(t *testing.T) {
+	var (
+		chain = NewMemoryDatabase()
+		nodes = memorydb.New()
+		db    = NewDatabaseWithNodeStore(chain, nodes)
+
+		legacy = common.HexToHash("0x01").Bytes()
+		fresh  = common.HexToHash("0x03").Bytes()
+		stale  = common.HexToHash("0x05").Bytes()
+		other  = []byte("other")
+	)
+	// Nodes written before the dedicated store existed
+	chain.Put(legacy, []byte("legacy"))
+	chain.Put(stale, []byte("stale"))
+
+	batch := db.NewBatch()
+	batch.Put(fresh, []byte("fresh"))
+	batch.Put(stale, []byte("rewritten"))
+	batch.Put(other, []byte("other"))
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write batch: %v", err)
+	}
+	if ok, _ := nodes.Has(fresh); !ok {
+		t.Fatalf("trie node not written into the node store")
+	}
+	if ok, _ := nodes.Has(other); ok {
+		t.Fatalf("chain data written into the node store")
+	}
+	for key, want := range map[string]string{string(legacy): "legacy", string(fresh): "fresh", string(stale): "rewritten", string(other): "other"} {
+		if have, err := db.Get([]byte(key)); err != nil || string(have) != want {
+			t.Errorf("key %x: value mismatch: have %q (%v), want %q", key, have, err, want)
+		}
+	}
+	// Iteration should merge both stores, hiding stale copies
+	var (
+		keys [][]byte
+		it   = db.NewIterator(nil, nil)
+	)
+	for it.Next() {
+		keys = append(keys, common.CopyBytes(it.Key()))
+		if bytes.Equal(it.Key(), stale) && string(it.Value()) != "rewritten" {
+			t.Errorf("stale node value iterated: %q", it.Value())
+		}
+	}
+	it.Release()
+
+	want := [][]byte{legacy, fresh, stale, other}
+	if len(keys) != len(want) {
+		t.Fatalf("iterated key count mismatch: have %d, want %d", len(keys), len(want))
+	}
+	for i := range want {
+		if !bytes.Equal(keys[i], want[i]) {
+			t.Errorf("key %d mismatch: have %x, want %x", i, keys[i], want[i])
+		}
+	}
+	// Deleting a node should drop it from both stores
+	if err := db.Delete(stale); err != nil {
+		t.Fatalf("failed to delete node: %v", err)
+	}
+	if ok, _ := db.Has(stale); ok {
+		t.Fatalf("deleted node still present")
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/ethdb"
+	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/params"
+)
+
+// ErrStateSchemeMismatch is returned if the state of the database is stored with
+// a different trie scheme than the one the chain config requires.
+var ErrStateSchemeMismatch = errors.New("state scheme mismatch")
+
+// configStateScheme returns the trie scheme required by a chain config.
+func configStateScheme(config *params.ChainConfig) string {
+	if config.Zktrie {
+		return rawdb.StateSchemeZktrie
+	}
+	return rawdb.StateSchemeMPT
+}
+
+// detectStateScheme returns the trie scheme the state of the database is stored
+// with. The recorded scheme is preferred, falling back to inspecting the state
+// root of the head block, and then of the genesis block for nodes which didn't
+// finish syncing the head state yet.
+func detectStateScheme(db ethdb.Database) string {
+	if scheme := rawdb.ReadStateScheme(db); scheme != "" {
+		return scheme
+	}
+	if block := rawdb.ReadHeadBlock(db); block != nil {
+		if scheme := rawdb.DetectStateScheme(db, block.Root()); scheme != "" {
+			return scheme
+		}
+	}
+	if hash := rawdb.ReadCanonicalHash(db, 0); hash != (common.Hash{}) {
+		if header := rawdb.ReadHeader(db, hash, 0); header != nil {
+			return rawdb.DetectStateScheme(db, header.Root)
+		}
+	}
+	return ""
+}
+
+// CheckStateScheme verifies that the state of the database is stored with the
+// trie scheme required by the chain config, and records the scheme in the
+// database. A mismatch is only tolerated if explicitly overridden, since the
+// chain would otherwise be processed into garbage state roots. The override
+// only applies to the current run, the recorded scheme is left untouched.
+func CheckStateScheme(db ethdb.Database, config *params.ChainConfig, override bool) error {
+	var (
+		stored = detectStateScheme(db)
+		want   = configStateScheme(config)
+	)
+	if stored != "" && stored != want {
+		if !override {
+			return fmt.Errorf("%w: database uses %s, chain config requires %s", ErrStateSchemeMismatch, stored, want)
+		}
+		log.Warn("Overriding state scheme of the database", "stored", stored, "config", want)
+		return nil
+	}
+	if rawdb.ReadStateScheme(db) != want {
+		rawdb.WriteStateScheme(db, want)
+	}
+	return nil
+}
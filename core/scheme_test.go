@@ -0,0 +1,70 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/params"
+)
+
+func TestStateSchemeDetection(t *testing.T) {
+	mptConfig := *params.TestChainConfig
+	mptConfig.Zktrie = false
+	zkConfig := *params.TestChainConfig
+	zkConfig.Zktrie = true
+
+	alloc := GenesisAlloc{common.HexToAddress("0x01"): {Balance: big.NewInt(1)}}
+	for _, config := range []*params.ChainConfig{&mptConfig, &zkConfig} {
+		var (
+			other = &zkConfig
+			want  = configStateScheme(config)
+		)
+		if config.Zktrie {
+			other = &mptConfig
+		}
+		db := rawdb.NewMemoryDatabase()
+		(&Genesis{Config: config, Alloc: alloc}).MustCommit(db)
+
+		if have := detectStateScheme(db); have != want {
+			t.Fatalf("%s: detected scheme mismatch: have %q, want %q", want, have, want)
+		}
+		if err := CheckStateScheme(db, other, false); !errors.Is(err, ErrStateSchemeMismatch) {
+			t.Fatalf("%s: mismatching config accepted: %v", want, err)
+		}
+		if err := CheckStateScheme(db, config, false); err != nil {
+			t.Fatalf("%s: matching config rejected: %v", want, err)
+		}
+		if have := rawdb.ReadStateScheme(db); have != want {
+			t.Fatalf("%s: recorded scheme mismatch: have %q, want %q", want, have, want)
+		}
+		// An explicit override should only apply to the current run
+		if err := CheckStateScheme(db, other, true); err != nil {
+			t.Fatalf("%s: overridden config rejected: %v", want, err)
+		}
+		if have := rawdb.ReadStateScheme(db); have != want {
+			t.Fatalf("%s: recorded scheme overwritten: have %q", want, have)
+		}
+		if err := CheckStateScheme(db, other, false); !errors.Is(err, ErrStateSchemeMismatch) {
+			t.Fatalf("%s: mismatching config accepted after override: %v", want, err)
+		}
+	}
+}
@@ -107,6 +107,52 @@ type Trie interface {
 	Prove(key []byte, fromLevel uint, proofDb ethdb.KeyValueWriter) error
 }
 
+// CodeCache is a size bounded cache of contract codes and code sizes keyed by
+// code hash. It is safe for concurrent use and can be shared between multiple
+// state databases, so block import, RPC calls and tracing all benefit from the
+// popular contracts loaded by any of them.
+type CodeCache struct {
+	codes *fastcache.Cache // Contract codes by code hash
+	sizes *lru.Cache       // Contract code sizes by code hash
+}
+
+// NewCodeCache creates a contract code cache retaining up to the given number
+// of bytes of code. A non-positive size selects the default allowance.
+func NewCodeCache(size int) *CodeCache {
+	if size <= 0 {
+		size = codeCacheSize
+	}
+	sizes, _ := lru.New(codeSizeCacheSize)
+	return &CodeCache{
+		codes: fastcache.New(size),
+		sizes: sizes,
+	}
+}
+
+// Code returns the cached code with the given hash, or nil if it's not cached.
+func (c *CodeCache) Code(codeHash common.Hash) []byte {
+	if code := c.codes.Get(nil, codeHash.Bytes()); len(code) > 0 {
+		codeCacheHitMeter.Mark(1)
+		return code
+	}
+	codeCacheMissMeter.Mark(1)
+	return nil
+}
+
+// CodeSize returns the size of the code with the given hash, if known.
+func (c *CodeCache) CodeSize(codeHash common.Hash) (int, bool) {
+	if cached, ok := c.sizes.Get(codeHash); ok {
+		return cached.(int), true
+	}
+	return 0, false
+}
+
+// Add inserts a contract code into the cache.
+func (c *CodeCache) Add(codeHash common.Hash, code []byte) {
+	c.codes.Set(codeHash.Bytes(), code)
+	c.sizes.Add(codeHash, len(code))
+}
+
 // NewDatabase creates a backing store for state. The returned database is safe for
 // concurrent use, but does not retain any recent trie nodes in memory. To keep some
 // historical state in memory, use the NewDatabaseWithConfig constructor.
@@ -118,20 +164,24 @@ func NewDatabase(db ethdb.Database) Database {
 // is safe for concurrent use and retains a lot of collapsed RLP trie nodes in a
 // large memory cache.
 func NewDatabaseWithConfig(db ethdb.Database, config *trie.Config) Database {
-	csc, _ := lru.New(codeSizeCacheSize)
+	return NewDatabaseWithCodeCache(db, config, NewCodeCache(codeCacheSize))
+}
+
+// NewDatabaseWithCodeCache creates a backing store for state like the one of
+// NewDatabaseWithConfig, but reading contract codes through the given, possibly
+// shared, code cache.
+func NewDatabaseWithCodeCache(db ethdb.Database, config *trie.Config, codeCache *CodeCache) Database {
 	return &cachingDB{
-		zktrie:        config != nil && config.Zktrie,
-		db:            trie.NewDatabaseWithConfig(db, config),
-		codeSizeCache: csc,
-		codeCache:     fastcache.New(codeCacheSize),
+		zktrie:    config != nil && config.Zktrie,
+		db:        trie.NewDatabaseWithConfig(db, config),
+		codeCache: codeCache,
 	}
 }
 
 type cachingDB struct {
-	db            *trie.Database
-	codeSizeCache *lru.Cache
-	codeCache     *fastcache.Cache
-	zktrie        bool
+	db        *trie.Database
+	codeCache *CodeCache
+	zktrie    bool
 }
 
 // OpenTrie opens the main account trie at a specific root hash.
@@ -180,13 +230,12 @@ func (db *cachingDB) CopyTrie(t Trie) Trie {
 
 // ContractCode retrieves a particular contract's code.
 func (db *cachingDB) ContractCode(addrHash, codeHash common.Hash) ([]byte, error) {
-	if code := db.codeCache.Get(nil, codeHash.Bytes()); len(code) > 0 {
+	if code := db.codeCache.Code(codeHash); len(code) > 0 {
 		return code, nil
 	}
 	code := rawdb.ReadCode(db.db.DiskDB(), codeHash)
 	if len(code) > 0 {
-		db.codeCache.Set(codeHash.Bytes(), code)
-		db.codeSizeCache.Add(codeHash, len(code))
+		db.codeCache.Add(codeHash, code)
 		return code, nil
 	}
 	return nil, errors.New("not found")
@@ -196,13 +245,12 @@ func (db *cachingDB) ContractCode(addrHash, codeHash common.Hash) ([]byte, error
 // code can't be found in the cache, then check the existence with **new**
 // db scheme.
 func (db *cachingDB) ContractCodeWithPrefix(addrHash, codeHash common.Hash) ([]byte, error) {
-	if code := db.codeCache.Get(nil, codeHash.Bytes()); len(code) > 0 {
+	if code := db.codeCache.Code(codeHash); len(code) > 0 {
 		return code, nil
 	}
 	code := rawdb.ReadCodeWithPrefix(db.db.DiskDB(), codeHash)
 	if len(code) > 0 {
-		db.codeCache.Set(codeHash.Bytes(), code)
-		db.codeSizeCache.Add(codeHash, len(code))
+		db.codeCache.Add(codeHash, code)
 		return code, nil
 	}
 	return nil, errors.New("not found")
@@ -210,8 +258,8 @@ func (db *cachingDB) ContractCodeWithPrefix(addrHash, codeHash common.Hash) ([]b
 
 // ContractCodeSize retrieves a particular contracts code's size.
 func (db *cachingDB) ContractCodeSize(addrHash, codeHash common.Hash) (int, error) {
-	if cached, ok := db.codeSizeCache.Get(codeHash); ok {
-		return cached.(int), nil
+	if size, ok := db.codeCache.CodeSize(codeHash); ok {
+		return size, nil
 	}
 	code, err := db.ContractCode(addrHash, codeHash)
 	return len(code), err
@@ -0,0 +1,140 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/trie"
+)
+
+// AccountDiff is an account differing between two states.
+type AccountDiff struct {
+	Key     common.Hash         `json:"key"`               // Hashed address, the key of the account trie
+	Address *common.Address     `json:"address,omitempty"` // Preimage of the key, if known
+	Before  *types.StateAccount `json:"before"`            // Account in the first state, nil if absent
+	After   *types.StateAccount `json:"after"`             // Account in the second state, nil if absent
+	Storage []StorageDiff       `json:"storage,omitempty"`
+}
+
+// StorageDiff is a storage slot differing between two states.
+type StorageDiff struct {
+	Key    common.Hash  `json:"key"`            // Hashed slot, the key of the storage trie
+	Slot   *common.Hash `json:"slot,omitempty"` // Preimage of the key, if known
+	Before common.Hash  `json:"before"`
+	After  common.Hash  `json:"after"`
+}
+
+// DiffStates returns the accounts and storage slots differing between the zktrie
+// states with the given roots, in the order of their hashed keys.
+func DiffStates(db Database, before, after common.Hash) ([]AccountDiff, error) {
+	if !db.TrieDB().Zktrie {
+		return nil, errors.New("state diff requires a zktrie state")
+	}
+	beforeTrie, err := openZkTrie(db, common.Hash{}, before, false)
+	if err != nil {
+		return nil, err
+	}
+	afterTrie, err := openZkTrie(db, common.Hash{}, after, false)
+	if err != nil {
+		return nil, err
+	}
+	var diffs []AccountDiff
+	err = beforeTrie.DiffLeaves(afterTrie, func(key, value, otherValue []byte) error {
+		diff := AccountDiff{Key: common.BytesToHash(key)}
+		if preimage := afterTrie.GetKey(key); len(preimage) > 0 {
+			addr := common.BytesToAddress(preimage)
+			diff.Address = &addr
+		}
+		var beforeRoot, afterRoot common.Hash
+		if value != nil {
+			if diff.Before, err = types.UnmarshalStateAccount(value); err != nil {
+				return fmt.Errorf("invalid account %x: %v", key, err)
+			}
+			beforeRoot = diff.Before.Root
+		}
+		if otherValue != nil {
+			if diff.After, err = types.UnmarshalStateAccount(otherValue); err != nil {
+				return fmt.Errorf("invalid account %x: %v", key, err)
+			}
+			afterRoot = diff.After.Root
+		}
+		if beforeRoot != afterRoot {
+			if diff.Storage, err = diffStorage(db, diff.Key, beforeRoot, afterRoot); err != nil {
+				return err
+			}
+		}
+		diffs = append(diffs, diff)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return diffs, nil
+}
+
+// diffStorage returns the slots differing between the storage tries with the
+// given roots of an account.
+func diffStorage(db Database, addrHash, before, after common.Hash) ([]StorageDiff, error) {
+	beforeTrie, err := openZkTrie(db, addrHash, before, true)
+	if err != nil {
+		return nil, err
+	}
+	afterTrie, err := openZkTrie(db, addrHash, after, true)
+	if err != nil {
+		return nil, err
+	}
+	var diffs []StorageDiff
+	err = beforeTrie.DiffLeaves(afterTrie, func(key, value, otherValue []byte) error {
+		diff := StorageDiff{
+			Key:    common.BytesToHash(key),
+			Before: common.BytesToHash(value),
+			After:  common.BytesToHash(otherValue),
+		}
+		if preimage := afterTrie.GetKey(key); len(preimage) > 0 {
+			slot := common.BytesToHash(preimage)
+			diff.Slot = &slot
+		}
+		diffs = append(diffs, diff)
+		return nil
+	})
+	return diffs, err
+}
+
+// openZkTrie opens the account trie or a storage trie with the given root.
+func openZkTrie(db Database, addrHash, root common.Hash, storage bool) (*trie.ZkTrie, error) {
+	var (
+		tr  Trie
+		err error
+	)
+	if storage {
+		tr, err = db.OpenStorageTrie(addrHash, root)
+	} else {
+		tr, err = db.OpenTrie(root)
+	}
+	if err != nil {
+		return nil, err
+	}
+	zkTrie, ok := tr.(*trie.ZkTrie)
+	if !ok {
+		return nil, fmt.Errorf("unexpected trie type %T", tr)
+	}
+	return zkTrie, nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/ethdb"
+	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/trie"
+)
+
+// LeafStats is the number and size of a class of trie leaves.
+type LeafStats struct {
+	Leaves uint64             // Number of leaves
+	Size   common.StorageSize // Total size of the leaf nodes
+}
+
+// add counts a leaf node of the given size.
+func (s *LeafStats) add(size int) {
+	s.Leaves++
+	s.Size += common.StorageSize(size)
+}
+
+// ExpiryStats splits the size of a zktrie state by the epoch of the last access
+// to its leaves, as groundwork for state expiry. Leaves accessed within the
+// inactivity period are active, older ones would have to be resurrected with a
+// witness were they expired. Leaves untouched since epoch tagging are untagged.
+type ExpiryStats struct {
+	Epoch          uint64 // Epoch the activity of the leaves is measured at
+	InactiveEpochs uint64 // Number of epochs without accesses after which a leaf is inactive
+
+	ActiveAccounts        LeafStats
+	ResurrectableAccounts LeafStats
+	UntaggedAccounts      LeafStats
+
+	ActiveSlots        LeafStats
+	ResurrectableSlots LeafStats
+	UntaggedSlots      LeafStats
+}
+
+// classify returns the stats a leaf last accessed in the given epoch is counted in.
+func (s *ExpiryStats) classify(epoch uint64, active, resurrectable, untagged *LeafStats) *LeafStats {
+	switch {
+	case epoch == 0:
+		return untagged
+	case epoch+s.InactiveEpochs > s.Epoch:
+		return active
+	default:
+		return resurrectable
+	}
+}
+
+// LeafAccesses is a set of zk trie leaves accessed by a state, being accounts
+// along with the storage slots of theirs accessed.
+type LeafAccesses map[common.Address]map[common.Hash]struct{}
+
+// WriteEpochs records the given state epoch as the epoch of the last access to
+// the zk trie leaves in the set.
+func (a LeafAccesses) WriteEpochs(db ethdb.KeyValueWriter, epoch uint64) error {
+	for addr, slots := range a {
+		// Storage leaves are recorded under the key of their account leaf
+		owner, err := trie.ZkTrieKey(addr.Bytes())
+		if err != nil {
+			return err
+		}
+		rawdb.WriteZkLeafEpoch(db, common.Hash{}, owner, epoch)
+
+		for slot := range slots {
+			leaf, err := trie.ZkTrieKey(slot.Bytes())
+			if err != nil {
+				return err
+			}
+			rawdb.WriteZkLeafEpoch(db, owner, leaf, epoch)
+		}
+	}
+	return nil
+}
+
+// copy returns a deep copy of the set.
+func (a LeafAccesses) copy() LeafAccesses {
+	cpy := make(LeafAccesses, len(a))
+	for addr, slots := range a {
+		slotsCpy := make(map[common.Hash]struct{}, len(slots))
+		for slot := range slots {
+			slotsCpy[slot] = struct{}{}
+		}
+		cpy[addr] = slotsCpy
+	}
+	return cpy
+}
+
+// trackLeafAccess records a read or write of the zk trie leaf of an existing
+// account, or of the leaf of one of its storage slots if slot is not nil.
+func (s *StateDB) trackLeafAccess(addr common.Address, slot *common.Hash) {
+	if !s.IsZktrie() {
+		return
+	}
+	if s.leafAccesses == nil {
+		s.leafAccesses = make(LeafAccesses)
+	}
+	slots := s.leafAccesses[addr]
+	if slots == nil {
+		slots = make(map[common.Hash]struct{})
+		s.leafAccesses[addr] = slots
+	}
+	if slot != nil {
+		slots[*slot] = struct{}{}
+	}
+}
+
+// LeafAccesses returns the zk trie leaves read or written by the state so far.
+// The set is not copied, so it must not be modified and only be retained once
+// the state is not used anymore.
+func (s *StateDB) LeafAccesses() LeafAccesses {
+	return s.leafAccesses
+}
+
+// WriteLeafEpochs records the given state epoch as the epoch of the last access
+// to the zk trie leaves the state read or wrote. It is only meant to be called
+// once the block the state belongs to is committed to the canonical chain, as
+// tracing, mining and state regeneration access leaves of throwaway states too.
+func (s *StateDB) WriteLeafEpochs(db ethdb.KeyValueWriter, epoch uint64) error {
+	return s.leafAccesses.WriteEpochs(db, epoch)
+}
+
+// CollectExpiryStats walks the entire zktrie state with the given root and all
+// its storage tries, splitting the leaves by the epoch of their last access.
+func CollectExpiryStats(db Database, root common.Hash, epoch, inactiveEpochs uint64) (*ExpiryStats, error) {
+	if !db.TrieDB().Zktrie {
+		return nil, errors.New("state expiry analysis requires a zktrie state")
+	}
+	tr, err := db.OpenTrie(root)
+	if err != nil {
+		return nil, err
+	}
+	accTrie, ok := tr.(*trie.ZkTrie)
+	if !ok {
+		return nil, fmt.Errorf("unexpected account trie type %T", tr)
+	}
+	var (
+		stats     = &ExpiryStats{Epoch: epoch, InactiveEpochs: inactiveEpochs}
+		emptyRoot = db.TrieDB().EmptyRoot()
+
+		start  = time.Now()
+		logged = time.Now()
+	)
+	err = accTrie.WalkLeafNodes(func(n *trie.Node, depth int) error {
+		epoch, err := accTrie.LeafEpoch(n)
+		if err != nil {
+			return err
+		}
+		stats.classify(epoch, &stats.ActiveAccounts, &stats.ResurrectableAccounts, &stats.UntaggedAccounts).add(len(n.Value()))
+
+		if time.Since(logged) > 8*time.Second {
+			accounts := stats.ActiveAccounts.Leaves + stats.ResurrectableAccounts.Leaves + stats.UntaggedAccounts.Leaves
+			log.Info("Collecting state expiry statistics", "accounts", accounts, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+		acc, err := types.UnmarshalStateAccount(n.Data())
+		if err != nil {
+			return fmt.Errorf("invalid account %x: %v", n.NodeKey.Bytes(), err)
+		}
+		if acc.Root == emptyRoot {
+			return nil
+		}
+		tr, err := db.OpenStorageTrie(common.BytesToHash(n.NodeKey.Bytes()), acc.Root)
+		if err != nil {
+			return err
+		}
+		stTrie, ok := tr.(*trie.ZkTrie)
+		if !ok {
+			return fmt.Errorf("unexpected storage trie type %T", tr)
+		}
+		stTrie.SetOwner(common.BytesToHash(n.NodeKey.Bytes()))
+		return stTrie.WalkLeafNodes(func(n *trie.Node, depth int) error {
+			epoch, err := stTrie.LeafEpoch(n)
+			if err != nil {
+				return err
+			}
+			stats.classify(epoch, &stats.ActiveSlots, &stats.ResurrectableSlots, &stats.UntaggedSlots).add(len(n.Value()))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
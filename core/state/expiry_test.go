@@ -0,0 +1,167 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/trie"
+)
+
+func TestCollectExpiryStats(t *testing.T) {
+	db := NewDatabaseWithConfig(rawdb.NewMemoryDatabase(), &trie.Config{Zktrie: true})
+
+	var (
+		contract = common.HexToAddress("0x0c")
+		other    = common.HexToAddress("0x0d")
+		root     common.Hash
+	)
+	// commit applies the changes to the state written in the given epoch
+	commit := func(epoch uint64, change func(*StateDB)) {
+		state, _ := New(root, db, nil)
+		change(state)
+		var err error
+		if root, err = state.Commit(false); err != nil {
+			t.Fatalf("failed to commit state: %v", err)
+		}
+		if err := state.WriteLeafEpochs(db.TrieDB().DiskDB(), epoch); err != nil {
+			t.Fatalf("failed to write leaf epochs: %v", err)
+		}
+	}
+	commit(0, func(state *StateDB) {
+		state.AddBalance(common.BytesToAddress([]byte{4}), big.NewInt(1))
+	})
+	commit(1, func(state *StateDB) {
+		for i := byte(1); i <= 3; i++ {
+			state.AddBalance(common.BytesToAddress([]byte{i}), big.NewInt(1))
+		}
+		for i := int64(1); i <= 3; i++ {
+			state.SetState(contract, common.BigToHash(big.NewInt(i)), common.HexToHash("0x01"))
+		}
+	})
+	commit(4, func(state *StateDB) {
+		state.AddBalance(common.BytesToAddress([]byte{1}), big.NewInt(1))
+		state.SetState(contract, common.BigToHash(big.NewInt(1)), common.HexToHash("0x02"))
+
+		// The same slot and value shares the leaf node, but not its epoch
+		state.SetState(other, common.BigToHash(big.NewInt(2)), common.HexToHash("0x01"))
+	})
+
+	stats, err := CollectExpiryStats(db, root, 4, 2)
+	if err != nil {
+		t.Fatalf("failed to collect stats: %v", err)
+	}
+	for _, tt := range []struct {
+		name  string
+		stats LeafStats
+		want  uint64
+	}{
+		{"active accounts", stats.ActiveAccounts, 3},
+		{"resurrectable accounts", stats.ResurrectableAccounts, 2},
+		{"untagged accounts", stats.UntaggedAccounts, 1},
+		{"active slots", stats.ActiveSlots, 2},
+		{"resurrectable slots", stats.ResurrectableSlots, 2},
+		{"untagged slots", stats.UntaggedSlots, 0},
+	} {
+		if tt.stats.Leaves != tt.want {
+			t.Errorf("%s mismatch: have %d, want %d", tt.name, tt.stats.Leaves, tt.want)
+		}
+		if (tt.stats.Size == 0) != (tt.want == 0) {
+			t.Errorf("%s size mismatch: have %v", tt.name, tt.stats.Size)
+		}
+	}
+	// The analysis is only meaningful for zktrie states
+	mpt := NewDatabase(rawdb.NewMemoryDatabase())
+	if _, err := CollectExpiryStats(mpt, common.Hash{}, 4, 2); err == nil {
+		t.Fatal("mpt state accepted")
+	}
+}
+
+func TestWriteLeafEpochsCopy(t *testing.T) {
+	db := NewDatabaseWithConfig(rawdb.NewMemoryDatabase(), &trie.Config{Zktrie: true})
+	state, _ := New(common.Hash{}, db, nil)
+
+	addr, slot := common.HexToAddress("0x0c"), common.HexToHash("0x01")
+	state.SetState(addr, slot, common.HexToHash("0x02"))
+
+	// The miner commits a copy of the state it already hashed
+	state.IntermediateRoot(false)
+	cpy := state.Copy()
+	root, err := cpy.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	// Nothing is recorded until the epochs are written
+	diskdb := db.TrieDB().DiskDB()
+	stats, err := CollectExpiryStats(db, root, 3, 1)
+	if err != nil {
+		t.Fatalf("failed to collect stats: %v", err)
+	}
+	if stats.UntaggedAccounts.Leaves != 1 || stats.UntaggedSlots.Leaves != 1 {
+		t.Fatalf("epochs recorded on commit: %+v", stats)
+	}
+	if err := cpy.WriteLeafEpochs(diskdb, 3); err != nil {
+		t.Fatalf("failed to write leaf epochs: %v", err)
+	}
+	if stats, err = CollectExpiryStats(db, root, 3, 1); err != nil {
+		t.Fatalf("failed to collect stats: %v", err)
+	}
+	if stats.ActiveAccounts.Leaves != 1 || stats.ActiveSlots.Leaves != 1 {
+		t.Fatalf("leaf writes of the copy not recorded: %+v", stats)
+	}
+}
+
+func TestWriteLeafEpochsReads(t *testing.T) {
+	db := NewDatabaseWithConfig(rawdb.NewMemoryDatabase(), &trie.Config{Zktrie: true})
+	state, _ := New(common.Hash{}, db, nil)
+
+	addr := common.HexToAddress("0x0c")
+	state.SetState(addr, common.HexToHash("0x01"), common.HexToHash("0x01"))
+	state.SetState(addr, common.HexToHash("0x02"), common.HexToHash("0x01"))
+	root, err := state.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	diskdb := db.TrieDB().DiskDB()
+	if err := state.WriteLeafEpochs(diskdb, 1); err != nil {
+		t.Fatalf("failed to write leaf epochs: %v", err)
+	}
+	// A later state only reading the account and one of its slots accesses
+	// their leaves, but not the ones of missing accounts and slots
+	state, _ = New(root, db, nil)
+	state.GetState(addr, common.HexToHash("0x01"))
+	state.GetState(addr, common.HexToHash("0x03"))
+	state.GetBalance(common.HexToAddress("0x0d"))
+
+	accesses := state.LeafAccesses()
+	if len(accesses) != 1 || len(accesses[addr]) != 1 {
+		t.Fatalf("leaf access mismatch: %v", accesses)
+	}
+	if err := state.WriteLeafEpochs(diskdb, 4); err != nil {
+		t.Fatalf("failed to write leaf epochs: %v", err)
+	}
+	stats, err := CollectExpiryStats(db, root, 4, 2)
+	if err != nil {
+		t.Fatalf("failed to collect stats: %v", err)
+	}
+	if stats.ActiveAccounts.Leaves != 1 || stats.ActiveSlots.Leaves != 1 || stats.ResurrectableSlots.Leaves != 1 {
+		t.Fatalf("leaf reads not recorded: %+v", stats)
+	}
+}
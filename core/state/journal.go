@@ -138,6 +138,12 @@ type (
 		address *common.Address
 		slot    *common.Hash
 	}
+
+	// Changes to the transient storage
+	transientStorageChange struct {
+		account       *common.Address
+		key, prevalue common.Hash
+	}
 )
 
 func (ch createObjectChange) revert(s *StateDB) {
@@ -267,3 +273,13 @@ func (ch accessListAddSlotChange) revert(s *StateDB) {
 func (ch accessListAddSlotChange) dirtied() *common.Address {
 	return nil
 }
+
+func (ch transientStorageChange) revert(s *StateDB) {
+	s.setTransientState(*ch.account, ch.key, ch.prevalue)
+}
+
+// dirtied returns nil, transient storage changes must never mark the account
+// for inclusion in the state trie.
+func (ch transientStorageChange) dirtied() *common.Address {
+	return nil
+}
@@ -25,4 +25,6 @@ var (
 	storageDeletedMeter   = metrics.NewRegisteredMeter("state/delete/storage", nil)
 	accountCommittedMeter = metrics.NewRegisteredMeter("state/commit/account", nil)
 	storageCommittedMeter = metrics.NewRegisteredMeter("state/commit/storage", nil)
+	codeCacheHitMeter     = metrics.NewRegisteredMeter("state/codecache/hit", nil)
+	codeCacheMissMeter    = metrics.NewRegisteredMeter("state/codecache/miss", nil)
 )
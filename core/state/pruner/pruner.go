@@ -207,27 +207,36 @@ func prune(snaptree *snapshot.Tree, root common.Hash, maindb ethdb.Database, sta
 	// Start compactions, will remove the deleted data from the disk immediately.
 	// Note for small pruning, the compaction is skipped.
 	if count >= rangeCompactionThreshold {
-		cstart := time.Now()
-		for b := 0x00; b <= 0xf0; b += 0x10 {
-			var (
-				start = []byte{byte(b)}
-				end   = []byte{byte(b + 0x10)}
-			)
-			if b == 0xf0 {
-				end = nil
-			}
-			log.Info("Compacting database", "range", fmt.Sprintf("%#x-%#x", start, end), "elapsed", common.PrettyDuration(time.Since(cstart)))
-			if err := maindb.Compact(start, end); err != nil {
-				log.Error("Database compaction failed", "error", err)
-				return err
-			}
+		if err := compactDatabase(maindb); err != nil {
+			return err
 		}
-		log.Info("Database compaction finished", "elapsed", common.PrettyDuration(time.Since(cstart)))
 	}
 	log.Info("State pruning successful", "pruned", size, "elapsed", common.PrettyDuration(time.Since(start)))
 	return nil
 }
 
+// compactDatabase compacts the entire key space of the database in sixteen
+// ranges, reporting the progress in between.
+func compactDatabase(db ethdb.Database) error {
+	cstart := time.Now()
+	for b := 0x00; b <= 0xf0; b += 0x10 {
+		var (
+			start = []byte{byte(b)}
+			end   = []byte{byte(b + 0x10)}
+		)
+		if b == 0xf0 {
+			end = nil
+		}
+		log.Info("Compacting database", "range", fmt.Sprintf("%#x-%#x", start, end), "elapsed", common.PrettyDuration(time.Since(cstart)))
+		if err := db.Compact(start, end); err != nil {
+			log.Error("Database compaction failed", "error", err)
+			return err
+		}
+	}
+	log.Info("Database compaction finished", "elapsed", common.PrettyDuration(time.Since(cstart)))
+	return nil
+}
+
 // Prune deletes all historical state nodes except the nodes belong to the
 // specified state version. If user doesn't specify the state version, use
 // the bottom-most snapshot diff layer as the target.
@@ -236,7 +245,7 @@ func (p *Pruner) Prune(root common.Hash) error {
 	// reuse it for pruning instead of generating a new one. It's
 	// mandatory because a part of state may already be deleted,
 	// the recovery procedure is necessary.
-	_, stateBloomRoot, err := findBloomFilter(p.datadir)
+	_, stateBloomRoot, err := findBloomFilter(p.datadir, stateBloomFilePrefix)
 	if err != nil {
 		return err
 	}
@@ -325,7 +334,7 @@ func (p *Pruner) Prune(root common.Hash) error {
 	if err := extractGenesis(p.db, p.stateBloom); err != nil {
 		return err
 	}
-	filterName := bloomFilterName(p.datadir, root)
+	filterName := bloomFilterName(p.datadir, stateBloomFilePrefix, root)
 
 	log.Info("Writing state bloom to disk", "name", filterName)
 	if err := p.stateBloom.Commit(filterName, filterName+stateBloomFileTempSuffix); err != nil {
@@ -343,7 +352,7 @@ func (p *Pruner) Prune(root common.Hash) error {
 // pruning **has to be resumed**. Otherwise a lot of dangling nodes may be left
 // in the disk.
 func RecoverPruning(datadir string, db ethdb.Database, trieCachePath string) error {
-	stateBloomPath, stateBloomRoot, err := findBloomFilter(datadir)
+	stateBloomPath, stateBloomRoot, err := findBloomFilter(datadir, stateBloomFilePrefix)
 	if err != nil {
 		return err
 	}
@@ -453,26 +462,26 @@ func extractGenesis(db ethdb.Database, stateBloom *stateBloom) error {
 	return accIter.Error()
 }
 
-func bloomFilterName(datadir string, hash common.Hash) string {
-	return filepath.Join(datadir, fmt.Sprintf("%s.%s.%s", stateBloomFilePrefix, hash.Hex(), stateBloomFileSuffix))
+func bloomFilterName(datadir string, prefix string, hash common.Hash) string {
+	return filepath.Join(datadir, fmt.Sprintf("%s.%s.%s", prefix, hash.Hex(), stateBloomFileSuffix))
 }
 
-func isBloomFilter(filename string) (bool, common.Hash) {
+func isBloomFilter(filename string, prefix string) (bool, common.Hash) {
 	filename = filepath.Base(filename)
-	if strings.HasPrefix(filename, stateBloomFilePrefix) && strings.HasSuffix(filename, stateBloomFileSuffix) {
-		return true, common.HexToHash(filename[len(stateBloomFilePrefix)+1 : len(filename)-len(stateBloomFileSuffix)-1])
+	if strings.HasPrefix(filename, prefix+".") && strings.HasSuffix(filename, stateBloomFileSuffix) {
+		return true, common.HexToHash(filename[len(prefix)+1 : len(filename)-len(stateBloomFileSuffix)-1])
 	}
 	return false, common.Hash{}
 }
 
-func findBloomFilter(datadir string) (string, common.Hash, error) {
+func findBloomFilter(datadir string, prefix string) (string, common.Hash, error) {
 	var (
 		stateBloomPath string
 		stateBloomRoot common.Hash
 	)
 	if err := filepath.Walk(datadir, func(path string, info os.FileInfo, err error) error {
 		if info != nil && !info.IsDir() {
-			ok, root := isBloomFilter(path)
+			ok, root := isBloomFilter(path, prefix)
 			if ok {
 				stateBloomPath = path
 				stateBloomRoot = root
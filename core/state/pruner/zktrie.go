@@ -0,0 +1,290 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pruner
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/ethdb"
+	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/trie"
+)
+
+// zktrieBloomFilePrefix is the filename prefix of the bloom filter of the live
+// zktrie nodes.
+const zktrieBloomFilePrefix = "zktriebloom"
+
+// ZktriePruner is an offline tool to prune the stale nodes of a zktrie state.
+// Contrary to Pruner it doesn't need a snapshot, the workflow is:
+//
+// - walk the zk tries of the states of the most recent blocks and the genesis,
+//   marking all their nodes as live in a bloom filter
+// - iterate the database, delete all trie nodes which are not marked
+//
+// The bloom filter is persisted before the sweep and the sweep position is
+// recorded in the database, so an interrupted pruning is resumed by running it
+// again.
+type ZktriePruner struct {
+	db            ethdb.Database
+	stateBloom    *stateBloom
+	datadir       string
+	trieCachePath string
+	headBlock     *types.Block
+}
+
+// NewZktriePruner creates the zktrie pruner instance.
+func NewZktriePruner(db ethdb.Database, datadir, trieCachePath string, bloomSize uint64) (*ZktriePruner, error) {
+	headBlock := rawdb.ReadHeadBlock(db)
+	if headBlock == nil {
+		return nil, errors.New("Failed to load head block")
+	}
+	// Sanitize the bloom filter size if it's too small.
+	if bloomSize < 256 {
+		log.Warn("Sanitizing bloomfilter size", "provided(MB)", bloomSize, "updated(MB)", 256)
+		bloomSize = 256
+	}
+	stateBloom, err := newStateBloomWithSize(bloomSize)
+	if err != nil {
+		return nil, err
+	}
+	return &ZktriePruner{
+		db:            db,
+		stateBloom:    stateBloom,
+		datadir:       datadir,
+		trieCachePath: trieCachePath,
+		headBlock:     headBlock,
+	}, nil
+}
+
+// Prune deletes all zktrie nodes except the ones belonging to the states of the
+// given number of most recent canonical blocks and the genesis. The states of
+// blocks which were never persisted are skipped. If a previous pruning was
+// interrupted, it is resumed instead and the number of blocks is ignored.
+func (p *ZktriePruner) Prune(blocks uint64) error {
+	bloomPath, bloomRoot, err := findBloomFilter(p.datadir, zktrieBloomFilePrefix)
+	if err != nil {
+		return err
+	}
+	if bloomPath != "" {
+		stateBloom, err := NewStateBloomFromDisk(bloomPath)
+		if err != nil {
+			return err
+		}
+		log.Info("Resuming zktrie pruning", "root", bloomRoot, "path", bloomPath)
+		deleteCleanTrieCache(p.trieCachePath)
+		return sweepZktrie(p.db, stateBloom, bloomPath, time.Now())
+	}
+	if blocks == 0 {
+		return errors.New("no blocks to retain")
+	}
+	// A marker without a bloom filter is a leftover of a pruning which finished
+	// just before it got deleted, start the sweep afresh.
+	rawdb.DeleteZktriePruningMarker(p.db)
+
+	var (
+		start   = time.Now()
+		zkdb    = trie.NewZktrieDatabase(p.db)
+		marked  = make(map[common.Hash]struct{})
+		storage = make(map[common.Hash]struct{})
+		head    = p.headBlock.NumberU64()
+		number  = head
+		roots   []common.Hash
+	)
+	for ; head-number < blocks; number-- {
+		hash := rawdb.ReadCanonicalHash(p.db, number)
+		header := rawdb.ReadHeader(p.db, hash, number)
+		if header == nil {
+			return fmt.Errorf("missing header #%d", number)
+		}
+		roots = append(roots, header.Root)
+		if number == 0 {
+			break
+		}
+	}
+	if number > 0 {
+		genesis := rawdb.ReadHeader(p.db, rawdb.ReadCanonicalHash(p.db, 0), 0)
+		if genesis == nil {
+			return errors.New("missing genesis block")
+		}
+		roots = append(roots, genesis.Root)
+	}
+	for _, root := range roots {
+		if _, ok := marked[root]; ok {
+			continue
+		}
+		tr, err := trie.NewZkTrie(root, zkdb)
+		if errors.Is(err, trie.ErrKeyNotFound) {
+			log.Debug("Skipping unavailable zktrie state", "root", root)
+			continue
+		} else if err != nil {
+			return err
+		}
+		log.Info("Marking zktrie state", "root", root)
+		if err := markZktrie(zkdb, tr, p.stateBloom, storage); err != nil {
+			return err
+		}
+		marked[root] = struct{}{}
+	}
+	if len(marked) == 0 {
+		return fmt.Errorf("no zktrie state available within the last %d blocks", blocks)
+	}
+	log.Info("Marked live zktrie nodes", "states", len(marked), "elapsed", common.PrettyDuration(time.Since(start)))
+
+	// Persist the marks before deleting anything, the sweep can't be resumed
+	// without them.
+	filterName := bloomFilterName(p.datadir, zktrieBloomFilePrefix, p.headBlock.Root())
+	log.Info("Writing zktrie bloom to disk", "name", filterName)
+	if err := p.stateBloom.Commit(filterName, filterName+stateBloomFileTempSuffix); err != nil {
+		return err
+	}
+	deleteCleanTrieCache(p.trieCachePath)
+	return sweepZktrie(p.db, p.stateBloom, filterName, start)
+}
+
+// markZktrie adds all nodes of the given account trie, of the storage tries it
+// references and the contract codes to the bloom filter. Storage tries already
+// in the given set are not walked again, it's shared between the states.
+func markZktrie(zkdb *trie.ZktrieDatabase, accTrie *trie.ZkTrie, stateBloom *stateBloom, storage map[common.Hash]struct{}) error {
+	var (
+		nodes  int
+		start  = time.Now()
+		logged = time.Now()
+	)
+	mark := func(key []byte, n *trie.Node) error {
+		nodes++
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Marking live zktrie nodes", "nodes", nodes, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+		return stateBloom.Put(key, nil)
+	}
+	return accTrie.WalkNodes(func(key []byte, n *trie.Node) error {
+		if err := mark(key, n); err != nil {
+			return err
+		}
+		if n.Type != trie.NodeTypeLeaf {
+			return nil
+		}
+		acc, err := types.UnmarshalStateAccount(n.Data())
+		if err != nil {
+			return fmt.Errorf("invalid account %x: %v", n.NodeKey.Bytes(), err)
+		}
+		if !bytes.Equal(acc.CodeHash, emptyCode) {
+			stateBloom.Put(acc.CodeHash, nil)
+		}
+		if _, ok := storage[acc.Root]; ok || acc.Root == (common.Hash{}) {
+			return nil
+		}
+		stTrie, err := trie.NewZkTrie(acc.Root, zkdb)
+		if err != nil {
+			return fmt.Errorf("missing storage trie %x: %v", acc.Root, err)
+		}
+		if err := stTrie.WalkNodes(mark); err != nil {
+			return err
+		}
+		storage[acc.Root] = struct{}{}
+		return nil
+	})
+}
+
+// sweepZktrie deletes all trie nodes not contained in the bloom filter, starting
+// from the marker left by an interrupted sweep. The bloom filter is deleted once
+// the sweep is finished.
+func sweepZktrie(db ethdb.Database, stateBloom *stateBloom, bloomPath string, start time.Time) error {
+	var (
+		count  int
+		size   common.StorageSize
+		pstart = time.Now()
+		logged = time.Now()
+		batch  = db.NewBatch()
+		marker = rawdb.ReadZktriePruningMarker(db)
+		iter   = db.NewIterator(nil, marker)
+	)
+	if marker != nil {
+		log.Info("Resuming zktrie sweep", "marker", common.BytesToHash(marker))
+	}
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) != common.HashLength {
+			continue
+		}
+		if ok, err := stateBloom.Contain(key); err != nil {
+			return err
+		} else if ok {
+			continue
+		}
+		count += 1
+		size += common.StorageSize(len(key) + len(iter.Value()))
+		batch.Delete(key)
+
+		var eta time.Duration // Realistically will never remain uninited
+		if done := binary.BigEndian.Uint64(key[:8]); done > 0 {
+			var (
+				left  = math.MaxUint64 - binary.BigEndian.Uint64(key[:8])
+				speed = done/uint64(time.Since(pstart)/time.Millisecond+1) + 1 // +1s to avoid division by zero
+			)
+			eta = time.Duration(left/speed) * time.Millisecond
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Pruning zktrie nodes", "nodes", count, "size", size,
+				"elapsed", common.PrettyDuration(time.Since(pstart)), "eta", common.PrettyDuration(eta))
+			logged = time.Now()
+		}
+		// Record the progress along with every batch, and recreate the iterator
+		// to allow the underlying compactor to delete the entries.
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			rawdb.WriteZktriePruningMarker(batch, key)
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+
+			iter.Release()
+			iter = db.NewIterator(nil, key)
+		}
+	}
+	err := iter.Error()
+	iter.Release()
+	if err != nil {
+		return err
+	}
+	rawdb.DeleteZktriePruningMarker(batch)
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Info("Pruned zktrie nodes", "nodes", count, "size", size, "elapsed", common.PrettyDuration(time.Since(pstart)))
+
+	// Delete the bloom filter, it marks the entire pruning procedure is finished.
+	os.RemoveAll(bloomPath)
+
+	if count >= rangeCompactionThreshold {
+		if err := compactDatabase(db); err != nil {
+			return err
+		}
+	}
+	log.Info("Zktrie pruning successful", "pruned", size, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pruner
+
+import (
+	"bytes"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/core/state"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/trie"
+)
+
+func TestZktriePruning(t *testing.T) {
+	var (
+		db       = rawdb.NewMemoryDatabase()
+		sdb      = state.NewDatabaseWithConfig(db, &trie.Config{Zktrie: true})
+		contract = common.HexToAddress("0x0c")
+		roots    []common.Hash
+		parent   common.Hash
+	)
+	// commit persists the state of the next block, with the given changes
+	// applied to the one of its parent
+	commit := func(change func(*state.StateDB)) {
+		var root common.Hash
+		if len(roots) > 0 {
+			root = roots[len(roots)-1]
+		}
+		statedb, _ := state.New(root, sdb, nil)
+		change(statedb)
+		root, err := statedb.Commit(false)
+		if err != nil {
+			t.Fatalf("failed to commit state: %v", err)
+		}
+		if err := sdb.TrieDB().Commit(root, false, nil); err != nil {
+			t.Fatalf("failed to flush state: %v", err)
+		}
+		block := types.NewBlockWithHeader(&types.Header{
+			ParentHash: parent,
+			Number:     big.NewInt(int64(len(roots))),
+			Root:       root,
+		})
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		roots, parent = append(roots, root), block.Hash()
+	}
+	commit(func(statedb *state.StateDB) {
+		statedb.AddBalance(common.BytesToAddress([]byte{1}), big.NewInt(1))
+	})
+	for i := int64(1); i <= 4; i++ {
+		i := i
+		commit(func(statedb *state.StateDB) {
+			statedb.AddBalance(common.BytesToAddress([]byte{byte(i)}), big.NewInt(i))
+			statedb.SetState(contract, common.BigToHash(big.NewInt(i)), common.BigToHash(big.NewInt(i)))
+			statedb.SetState(contract, common.BigToHash(big.NewInt(0)), common.BigToHash(big.NewInt(i)))
+		})
+	}
+	// Leave a stale marker behind, a fresh pruning must not honour it
+	rawdb.WriteZktriePruningMarker(db, bytes.Repeat([]byte{0xff}, common.HashLength))
+
+	datadir := t.TempDir()
+	p, err := NewZktriePruner(db, datadir, filepath.Join(datadir, "triecache"), 256)
+	if err != nil {
+		t.Fatalf("failed to create pruner: %v", err)
+	}
+	if err := p.Prune(2); err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+	zkdb := trie.NewZktrieDatabase(db)
+	for i, root := range roots {
+		tr, err := trie.NewZkTrie(root, zkdb)
+		switch i {
+		case 0, 3, 4:
+			// The genesis and the retained states must be complete
+			if err != nil {
+				t.Fatalf("state %d: missing root: %v", i, err)
+			}
+			err = tr.WalkLeafNodes(func(n *trie.Node, depth int) error {
+				acc, err := types.UnmarshalStateAccount(n.Data())
+				if err != nil {
+					return err
+				}
+				st, err := trie.NewZkTrie(acc.Root, zkdb)
+				if err != nil {
+					return err
+				}
+				return st.CheckNodes(256)
+			})
+			if err != nil {
+				t.Errorf("state %d: incomplete: %v", i, err)
+			}
+		default:
+			if err == nil {
+				t.Errorf("state %d: root not pruned", i)
+			}
+		}
+	}
+	if marker := rawdb.ReadZktriePruningMarker(db); marker != nil {
+		t.Errorf("pruning marker not deleted: %x", marker)
+	}
+	if path, _, _ := findBloomFilter(datadir, zktrieBloomFilePrefix); path != "" {
+		t.Errorf("bloom filter not deleted: %s", path)
+	}
+}
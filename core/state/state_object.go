@@ -256,6 +256,10 @@ func (s *stateObject) GetCommittedState(db Database, key common.Hash) common.Has
 			value.SetBytes(content)
 		}
 	}
+	// Empty slots have no leaf to record the access of
+	if value != (common.Hash{}) {
+		s.db.trackLeafAccess(s.address, &key)
+	}
 	s.originStorage[key] = value
 	return value
 }
@@ -360,6 +364,7 @@ func (s *stateObject) updateTrie(db Database) Trie {
 			}
 			s.setError(tr.TryUpdate(key[:], v))
 			s.db.StorageUpdated += 1
+			s.db.trackLeafAccess(s.address, &key)
 		}
 		// If state snapshotting is active, cache the data til commit
 		if s.db.snap != nil {
@@ -251,3 +251,34 @@ func compareStateObjects(so0, so1 *stateObject, t *testing.T) {
 		}
 	}
 }
+
+// Tests that contract codes loaded through one state database are served from
+// the shared code cache to every other database using it.
+func TestSharedCodeCache(t *testing.T) {
+	var (
+		diskdb   = rawdb.NewMemoryDatabase()
+		cache    = NewCodeCache(0)
+		first    = NewDatabaseWithCodeCache(diskdb, nil, cache)
+		second   = NewDatabaseWithCodeCache(diskdb, nil, cache)
+		code     = []byte{0x60, 0x00, 0x60, 0x00, 0xf3}
+		codeHash = crypto.Keccak256Hash(code)
+	)
+	rawdb.WriteCode(diskdb, codeHash, code)
+
+	if have, err := first.ContractCode(common.Hash{}, codeHash); err != nil || !bytes.Equal(have, code) {
+		t.Fatalf("code mismatch: have %x, want %x, err %v", have, code, err)
+	}
+	// Drop the code from disk, the second database must still find it
+	rawdb.DeleteCode(diskdb, codeHash)
+
+	if have, err := second.ContractCode(common.Hash{}, codeHash); err != nil || !bytes.Equal(have, code) {
+		t.Fatalf("shared code mismatch: have %x, want %x, err %v", have, code, err)
+	}
+	if size, err := second.ContractCodeSize(common.Hash{}, codeHash); err != nil || size != len(code) {
+		t.Fatalf("shared code size mismatch: have %d, want %d, err %v", size, len(code), err)
+	}
+	// A database with its own cache must not see it
+	if _, err := NewDatabase(diskdb).ContractCode(common.Hash{}, codeHash); err == nil {
+		t.Fatalf("unshared database found deleted code")
+	}
+}
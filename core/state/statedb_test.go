@@ -32,6 +32,7 @@ import (
 	"github.com/scroll-tech/go-ethereum/common"
 	"github.com/scroll-tech/go-ethereum/core/rawdb"
 	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/trie"
 )
 
 // Tests that updating a state trie does not leak any database writes prior to
@@ -915,3 +916,49 @@ func TestStateDBAccessList(t *testing.T) {
 		t.Fatalf("expected empty, got %d", got)
 	}
 }
+
+func TestTransientStorage(t *testing.T) {
+	t.Run("mpt", func(t *testing.T) { testTransientStorage(t, false) })
+	t.Run("zktrie", func(t *testing.T) { testTransientStorage(t, true) })
+}
+
+func testTransientStorage(t *testing.T, zktrie bool) {
+	state, _ := New(common.Hash{}, NewDatabaseWithConfig(rawdb.NewMemoryDatabase(), &trie.Config{Zktrie: zktrie}), nil)
+	emptyRoot := state.IntermediateRoot(false)
+
+	key := common.Hash{0x01}
+	value := common.Hash{0x02}
+	addr := common.Address{}
+
+	state.SetTransientState(addr, key, value)
+	if exp, got := 1, state.journal.length(); exp != got {
+		t.Fatalf("journal length mismatch: have %d, want %d", got, exp)
+	}
+	// The value is readable and survives a state copy
+	if got, exp := state.GetTransientState(addr, key), value; exp != got {
+		t.Fatalf("transient storage mismatch: have %x, want %x", got, exp)
+	}
+	cpy := state.Copy()
+	if got, exp := cpy.GetTransientState(addr, key), value; exp != got {
+		t.Fatalf("copied transient storage mismatch: have %x, want %x", got, exp)
+	}
+	// Reverting restores the previous value
+	id := state.Snapshot()
+	state.SetTransientState(addr, key, common.Hash{0x03})
+	state.RevertToSnapshot(id)
+	if got, exp := state.GetTransientState(addr, key), value; exp != got {
+		t.Fatalf("reverted transient storage mismatch: have %x, want %x", got, exp)
+	}
+	// Transient storage never touches the state trie
+	if state.Exist(addr) {
+		t.Fatalf("transient storage created an account")
+	}
+	if root := state.IntermediateRoot(false); root != emptyRoot {
+		t.Fatalf("transient storage leaked into the state root: have %x, want %x", root, emptyRoot)
+	}
+	// A new transaction starts with empty transient storage
+	state.Prepare(common.Hash{0x04}, 1)
+	if got := state.GetTransientState(addr, key); got != (common.Hash{}) {
+		t.Fatalf("transient storage not reset between transactions: have %x", got)
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/rlp"
+	"github.com/scroll-tech/go-ethereum/trie"
+)
+
+// ContractStats is the storage usage of a single contract.
+type ContractStats struct {
+	Hash     common.Hash     // Hash of the address, the key in the account trie
+	Address  *common.Address // Address of the contract, nil if the preimage is unknown
+	Slots    uint64          // Number of storage slots in use
+	CodeSize int             // Size of the contract code
+	MaxDepth int             // Depth of the deepest storage trie leaf
+}
+
+// StateStats is the storage usage of a whole state, as needed for estimating
+// the witness sizes and prover load it results in.
+type StateStats struct {
+	Accounts  uint64 // Number of accounts, including contracts
+	Contracts uint64 // Number of accounts with code or storage
+	Slots     uint64 // Number of storage slots across all contracts
+	Codes     uint64 // Number of distinct contract codes
+	CodeSize  uint64 // Total size of the distinct contract codes
+
+	AccountDepths []uint64 // Number of account trie leaves per depth
+	StorageDepths []uint64 // Number of storage trie leaves per depth
+
+	TopSlots []*ContractStats // Contracts with the most storage slots, descending
+	TopDepth []*ContractStats // Contracts with the deepest storage tries, descending
+}
+
+// CollectStateStats walks the entire state with the given root, collecting the
+// storage usage statistics of all accounts. The top contracts by slot count and
+// storage trie depth are retained, up to the given number of each.
+func CollectStateStats(db Database, root common.Hash, top int) (*StateStats, error) {
+	accTrie, err := db.OpenTrie(root)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		stats     = new(StateStats)
+		codes     = make(map[common.Hash]struct{})
+		emptyRoot = db.TrieDB().EmptyRoot()
+		zktrie    = db.TrieDB().Zktrie
+
+		start  = time.Now()
+		logged = time.Now()
+	)
+	err = walkTrieLeaves(accTrie, func(key, value []byte, depth int) error {
+		var acc *types.StateAccount
+		if zktrie {
+			if acc, err = types.UnmarshalStateAccount(value); err != nil {
+				return fmt.Errorf("invalid account %x: %v", key, err)
+			}
+		} else {
+			acc = new(types.StateAccount)
+			if err := rlp.DecodeBytes(value, acc); err != nil {
+				return fmt.Errorf("invalid account %x: %v", key, err)
+			}
+		}
+		stats.Accounts++
+		stats.AccountDepths = countDepth(stats.AccountDepths, depth)
+
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Collecting state statistics", "accounts", stats.Accounts, "slots", stats.Slots, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+		hasCode := !bytes.Equal(acc.CodeHash, emptyCodeHash)
+		if !hasCode && acc.Root == emptyRoot {
+			return nil
+		}
+		contract := &ContractStats{Hash: common.BytesToHash(key)}
+		if preimage := accTrie.GetKey(key); len(preimage) == common.AddressLength {
+			addr := common.BytesToAddress(preimage)
+			contract.Address = &addr
+		}
+		if hasCode {
+			codeHash := common.BytesToHash(acc.CodeHash)
+			size, err := db.ContractCodeSize(contract.Hash, codeHash)
+			if err != nil {
+				return fmt.Errorf("missing code %x of account %x", codeHash, key)
+			}
+			contract.CodeSize = size
+			if _, ok := codes[codeHash]; !ok {
+				codes[codeHash] = struct{}{}
+				stats.Codes++
+				stats.CodeSize += uint64(size)
+			}
+		}
+		if acc.Root != emptyRoot {
+			stTrie, err := db.OpenStorageTrie(contract.Hash, acc.Root)
+			if err != nil {
+				return err
+			}
+			err = walkTrieLeaves(stTrie, func(key, value []byte, depth int) error {
+				contract.Slots++
+				if depth > contract.MaxDepth {
+					contract.MaxDepth = depth
+				}
+				stats.StorageDepths = countDepth(stats.StorageDepths, depth)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		stats.Contracts++
+		stats.Slots += contract.Slots
+
+		stats.TopSlots = insertTop(stats.TopSlots, top, contract, func(c *ContractStats) uint64 { return c.Slots })
+		stats.TopDepth = insertTop(stats.TopDepth, top, contract, func(c *ContractStats) uint64 { return uint64(c.MaxDepth) })
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// walkTrieLeaves calls f for every leaf of the trie with its hashed key, its
+// value and its depth, being the number of trie nodes above the leaf node.
+func walkTrieLeaves(tr Trie, f func(key, value []byte, depth int) error) error {
+	if zk, ok := tr.(*trie.ZkTrie); ok {
+		return zk.WalkLeaves(f)
+	}
+	var (
+		it    = tr.NodeIterator(nil)
+		stack [][]byte // Paths of the nodes on the way to the current one
+	)
+	for it.Next(true) {
+		path := it.Path()
+		for len(stack) > 0 && !bytes.HasPrefix(path, stack[len(stack)-1]) {
+			stack = stack[:len(stack)-1]
+		}
+		if it.Leaf() {
+			// The value is embedded into the leaf node on top of the stack
+			if err := f(it.LeafKey(), it.LeafBlob(), len(stack)-1); err != nil {
+				return err
+			}
+			continue
+		}
+		stack = append(stack, common.CopyBytes(path))
+	}
+	return it.Error()
+}
+
+// countDepth increments the histogram bucket of the given depth.
+func countDepth(histogram []uint64, depth int) []uint64 {
+	for len(histogram) <= depth {
+		histogram = append(histogram, 0)
+	}
+	histogram[depth]++
+	return histogram
+}
+
+// insertTop inserts a contract into a list kept sorted in descending order of the
+// given metric, capped at n entries.
+func insertTop(list []*ContractStats, n int, contract *ContractStats, metric func(*ContractStats) uint64) []*ContractStats {
+	value := metric(contract)
+	if value == 0 {
+		return list
+	}
+	i := sort.Search(len(list), func(i int) bool { return metric(list[i]) < value })
+	if i >= n {
+		return list
+	}
+	list = append(list, nil)
+	copy(list[i+1:], list[i:])
+	list[i] = contract
+	if len(list) > n {
+		list = list[:n]
+	}
+	return list
+}
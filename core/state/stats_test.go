@@ -0,0 +1,95 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/trie"
+)
+
+func TestCollectStateStats(t *testing.T) {
+	t.Run("mpt", func(t *testing.T) { testCollectStateStats(t, false) })
+	t.Run("zktrie", func(t *testing.T) { testCollectStateStats(t, true) })
+}
+
+func testCollectStateStats(t *testing.T, zktrie bool) {
+	db := NewDatabaseWithConfig(rawdb.NewMemoryDatabase(), &trie.Config{Zktrie: zktrie, Preimages: true})
+	state, _ := New(common.Hash{}, db, nil)
+
+	// Plain accounts, a contract with code only and two with storage sharing code
+	var (
+		small = common.HexToAddress("0x0a")
+		large = common.HexToAddress("0x0b")
+		code  = common.HexToAddress("0x0c")
+	)
+	for i := byte(1); i <= 5; i++ {
+		state.AddBalance(common.BytesToAddress([]byte{i}), big.NewInt(1))
+	}
+	state.SetCode(code, []byte{0x00, 0x01})
+	state.SetCode(small, []byte{0x01, 0x02, 0x03})
+	state.SetCode(large, []byte{0x01, 0x02, 0x03})
+	state.SetState(small, common.HexToHash("0x01"), common.HexToHash("0x01"))
+	for i := int64(1); i <= 10; i++ {
+		state.SetState(large, common.BigToHash(big.NewInt(i)), common.HexToHash("0x01"))
+	}
+	root, err := state.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := db.TrieDB().Commit(root, false, nil); err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	stats, err := CollectStateStats(db, root, 2)
+	if err != nil {
+		t.Fatalf("failed to collect stats: %v", err)
+	}
+	if stats.Accounts != 8 {
+		t.Errorf("account count mismatch: have %d, want %d", stats.Accounts, 8)
+	}
+	if stats.Contracts != 3 {
+		t.Errorf("contract count mismatch: have %d, want %d", stats.Contracts, 3)
+	}
+	if stats.Slots != 11 {
+		t.Errorf("slot count mismatch: have %d, want %d", stats.Slots, 11)
+	}
+	if stats.Codes != 2 || stats.CodeSize != 5 {
+		t.Errorf("code stats mismatch: have %d/%d, want %d/%d", stats.Codes, stats.CodeSize, 2, 5)
+	}
+	var leaves uint64
+	for _, n := range stats.AccountDepths {
+		leaves += n
+	}
+	if leaves != stats.Accounts {
+		t.Errorf("account depth histogram mismatch: have %d leaves, want %d", leaves, stats.Accounts)
+	}
+	if len(stats.TopSlots) != 2 {
+		t.Fatalf("top contract count mismatch: have %d, want %d", len(stats.TopSlots), 2)
+	}
+	if top := stats.TopSlots[0]; top.Address == nil || *top.Address != large || top.Slots != 10 || top.CodeSize != 3 {
+		t.Errorf("top contract mismatch: have %+v", top)
+	}
+	if next := stats.TopSlots[1]; next.Address == nil || *next.Address != small || next.Slots != 1 {
+		t.Errorf("second contract mismatch: have %+v", next)
+	}
+	if len(stats.TopDepth) == 0 || stats.TopDepth[0].Hash != stats.TopSlots[0].Hash {
+		t.Errorf("deepest storage trie mismatch: have %v", stats.TopDepth)
+	}
+}
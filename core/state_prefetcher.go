@@ -17,8 +17,11 @@
 package core
 
 import (
+	"sync"
 	"sync/atomic"
+	"time"
 
+	"github.com/scroll-tech/go-ethereum/common"
 	"github.com/scroll-tech/go-ethereum/consensus"
 	"github.com/scroll-tech/go-ethereum/core/state"
 	"github.com/scroll-tech/go-ethereum/core/types"
@@ -82,6 +85,87 @@ func (p *statePrefetcher) Prefetch(block *types.Block, statedb *state.StateDB, c
 	}
 }
 
+// PrefetchParallel speculatively executes the given transactions on copies of
+// the given state using multiple goroutines, with the goal of warming the trie
+// node and code caches before the transactions are executed serially on the
+// state itself. All changes are discarded.
+//
+// Transactions are distributed among the workers by sender, keeping the ones
+// of an account in nonce order on the same worker. Transactions failing due to
+// the missing effects of the others are skipped. The state is copied before the
+// method returns, the workers run in the background until they are done or the
+// interrupt is set.
+func (p *statePrefetcher) PrefetchParallel(header *types.Header, txs types.Transactions, statedb *state.StateDB, cfg vm.Config, workers int, interrupt *uint32) {
+	if workers <= 0 || len(txs) == 0 {
+		return
+	}
+	start := time.Now()
+	pend := p.prefetchParallel(header, txs, statedb, cfg, workers, interrupt)
+
+	go func() {
+		pend.Wait()
+
+		blockPrefetchParallelTimer.Update(time.Since(start))
+		if interrupt != nil && atomic.LoadUint32(interrupt) == 1 {
+			blockPrefetchInterruptMeter.Mark(1)
+		}
+	}()
+}
+
+// prefetchParallel distributes the transactions among the workers and starts
+// them on copies of the statedb, returning a wait group tracking the workers.
+func (p *statePrefetcher) prefetchParallel(header *types.Header, txs types.Transactions, statedb *state.StateDB, cfg vm.Config, workers int, interrupt *uint32) *sync.WaitGroup {
+	var (
+		signer  = types.MakeSigner(p.config, header.Number)
+		batches = make([][]types.Message, workers)
+		hashes  = make([][]common.Hash, workers)
+		owners  = make(map[common.Address]int)
+	)
+	for _, tx := range txs {
+		msg, err := tx.AsMessage(signer, header.BaseFee)
+		if err != nil {
+			continue
+		}
+		worker, ok := owners[msg.From()]
+		if !ok {
+			worker = len(owners) % workers
+			owners[msg.From()] = worker
+		}
+		batches[worker] = append(batches[worker], msg)
+		hashes[worker] = append(hashes[worker], tx.Hash())
+	}
+	pend := new(sync.WaitGroup)
+	for i, batch := range batches {
+		if len(batch) == 0 {
+			continue
+		}
+		// Copy the state in the calling goroutine, the original may be
+		// mutated as soon as this method returns
+		throwaway := statedb.Copy()
+
+		pend.Add(1)
+		go func(batch []types.Message, hashes []common.Hash, statedb *state.StateDB) {
+			defer pend.Done()
+
+			var (
+				gaspool      = new(GasPool).AddGas(header.GasLimit)
+				blockContext = NewEVMBlockContext(header, p.bc, nil)
+				evm          = vm.NewEVM(blockContext, vm.TxContext{}, statedb, p.config, cfg)
+			)
+			for j, msg := range batch {
+				if interrupt != nil && atomic.LoadUint32(interrupt) == 1 {
+					return
+				}
+				statedb.Prepare(hashes[j], j)
+				if err := precacheTransaction(msg, p.config, gaspool, statedb, header, evm); err != nil && gaspool.Gas() < params.TxGas {
+					return // Out of gas for the rest of the batch
+				}
+			}
+		}(batch, hashes[i], throwaway)
+	}
+	return pend
+}
+
 // precacheTransaction attempts to apply a transaction to the given state database
 // and uses the input parameters for its environment. The goal is not to execute
 // the transaction successfully, rather to warm up touched data slots.
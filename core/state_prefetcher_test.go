@@ -0,0 +1,114 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/consensus/ethash"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/core/state"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/core/vm"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/scroll-tech/go-ethereum/params"
+	"github.com/scroll-tech/go-ethereum/trie"
+)
+
+// Tests that the parallel prefetcher pulls the zk trie nodes touched by the
+// transactions into the clean cache without modifying the original state.
+func TestPrefetchParallelZktrie(t *testing.T) {
+	_, chain, err := newCanonical(ethash.NewFaker(), 0, true)
+	if err != nil {
+		t.Fatalf("failed to create pristine chain: %v", err)
+	}
+	defer chain.Stop()
+
+	// Create a zk state with a few funded accounts and flush it to disk
+	var (
+		diskdb  = rawdb.NewMemoryDatabase()
+		keys    = make([]*ecdsa.PrivateKey, 4)
+		funds   = big.NewInt(params.Ether)
+		statedb = state.NewDatabaseWithConfig(diskdb, &trie.Config{Zktrie: true})
+	)
+	genesis, _ := state.New(common.Hash{}, statedb, nil)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		genesis.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), funds)
+	}
+	root, err := genesis.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := statedb.TrieDB().Commit(root, false, nil); err != nil {
+		t.Fatalf("failed to flush state: %v", err)
+	}
+	// Run two transfers per account through the prefetcher on a cached database
+	var (
+		cached = state.NewDatabaseWithConfig(diskdb, &trie.Config{Zktrie: true, Cache: 16})
+		signer = types.LatestSigner(params.TestChainConfig)
+		header = &types.Header{
+			Number:     big.NewInt(1),
+			GasLimit:   params.GenesisGasLimit,
+			Difficulty: big.NewInt(1),
+			BaseFee:    big.NewInt(0),
+		}
+		txs types.Transactions
+	)
+	for _, key := range keys {
+		for nonce := uint64(0); nonce < 2; nonce++ {
+			to := common.BigToAddress(big.NewInt(int64(len(txs) + 1)))
+			tx, _ := types.SignTx(types.NewTransaction(nonce, to, big.NewInt(1), params.TxGas, big.NewInt(0), nil), signer, key)
+			txs = append(txs, tx)
+		}
+	}
+	live, err := state.New(root, cached, nil)
+	if err != nil {
+		t.Fatalf("failed to open state: %v", err)
+	}
+	prefetcher := newStatePrefetcher(params.TestChainConfig, chain, chain.engine)
+	prefetcher.prefetchParallel(header, txs, live, vm.Config{NoBaseFee: true}, 2, nil).Wait()
+
+	if nonce := live.GetNonce(crypto.PubkeyToAddress(keys[0].PublicKey)); nonce != 0 {
+		t.Fatalf("original state modified: nonce %d", nonce)
+	}
+	// Drop everything from disk, the touched accounts must be served from memory
+	it := diskdb.NewIterator(nil, nil)
+	for it.Next() {
+		diskdb.Delete(it.Key())
+	}
+	it.Release()
+
+	if _, err := state.New(root, state.NewDatabaseWithConfig(diskdb, &trie.Config{Zktrie: true, Cache: 16}), nil); err == nil {
+		t.Fatalf("cold state opened without disk data")
+	}
+	warm, err := state.New(root, cached, nil)
+	if err != nil {
+		t.Fatalf("failed to open warm state: %v", err)
+	}
+	for i, key := range keys {
+		if balance := warm.GetBalance(crypto.PubkeyToAddress(key.PublicKey)); balance.Cmp(funds) != 0 {
+			t.Errorf("account %d: balance mismatch: have %v, want %v", i, balance, funds)
+		}
+	}
+	if err := warm.Error(); err != nil {
+		t.Fatalf("failed to read warm state: %v", err)
+	}
+}
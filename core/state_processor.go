@@ -66,10 +66,28 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 		allLogs     []*types.Log
 		gp          = new(GasPool).AddGas(block.GasLimit())
 	)
+	// Verify an oracle base fee against the parent state before touching it
+	if p.config.BaseFeeOracle(blockNumber) != nil {
+		parent := p.bc.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+		if parent == nil {
+			return nil, nil, 0, consensus.ErrUnknownAncestor
+		}
+		if err := misc.VerifyOracleBaseFee(p.config, parent, header, statedb); err != nil {
+			return nil, nil, 0, err
+		}
+	}
 	// Mutate the block and state according to any hard-fork specs
 	if p.config.DAOForkSupport && p.config.DAOForkBlock != nil && p.config.DAOForkBlock.Cmp(block.Number()) == 0 {
 		misc.ApplyDAOHardFork(statedb)
 	}
+	// Make sure the block opens with the system transactions required by the protocol
+	systemTxs, err := SystemTransactions(p.config, header, statedb)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if err := verifySystemTransactions(block.Transactions(), systemTxs); err != nil {
+		return nil, nil, 0, err
+	}
 	blockContext := NewEVMBlockContext(header, p.bc, nil)
 	vmenv := vm.NewEVM(blockContext, vm.TxContext{}, statedb, p.config, cfg)
 	// Iterate over and process the individual transactions
@@ -110,7 +128,12 @@ func applyTransaction(msg types.Message, config *params.ChainConfig, bc ChainCon
 	} else {
 		root = statedb.IntermediateRoot(config.IsEIP158(blockNumber)).Bytes()
 	}
-	*usedGas += result.UsedGas
+	// System transactions don't count towards the gas used by the block
+	var gasUsed uint64
+	if !msg.IsSystem() {
+		gasUsed = result.UsedGas
+		*usedGas += gasUsed
+	}
 
 	// If the result contains a revert reason, return it.
 	returnVal := result.Return()
@@ -126,7 +149,7 @@ func applyTransaction(msg types.Message, config *params.ChainConfig, bc ChainCon
 		receipt.Status = types.ReceiptStatusSuccessful
 	}
 	receipt.TxHash = tx.Hash()
-	receipt.GasUsed = result.UsedGas
+	receipt.GasUsed = gasUsed
 
 	// If the transaction created a contract, store the creation address in the receipt.
 	if msg.To() == nil {
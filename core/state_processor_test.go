@@ -29,6 +29,7 @@ import (
 	"github.com/scroll-tech/go-ethereum/consensus/ethash"
 	"github.com/scroll-tech/go-ethereum/consensus/misc"
 	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/core/state"
 	"github.com/scroll-tech/go-ethereum/core/types"
 	"github.com/scroll-tech/go-ethereum/core/vm"
 	"github.com/scroll-tech/go-ethereum/crypto"
@@ -76,6 +77,16 @@ func TestStateProcessorErrors(t *testing.T) {
 		}), signer, key1)
 		return tx
 	}
+	var mkAccessListTx = func(nonce uint64, to common.Address, gasLimit uint64, gasPrice *big.Int) *types.Transaction {
+		tx, _ := types.SignTx(types.NewTx(&types.AccessListTx{
+			Nonce:    nonce,
+			GasPrice: gasPrice,
+			Gas:      gasLimit,
+			To:       &to,
+			Value:    big.NewInt(0),
+		}), signer, key1)
+		return tx
+	}
 	{ // Tests against a 'recent' chain definition
 		var (
 			db    = rawdb.NewMemoryDatabase()
@@ -245,7 +256,13 @@ func TestStateProcessorErrors(t *testing.T) {
 				txs: []*types.Transaction{
 					mkDynamicTx(0, common.Address{}, params.TxGas-1000, big.NewInt(0), big.NewInt(0)),
 				},
-				want: "could not apply tx 0 [0x88626ac0d53cb65308f2416103c62bb1f18b805573d4f96a3640bbbfff13c14f]: transaction type not supported",
+				want: "invalid transaction 0 [0x88626ac0d53cb65308f2416103c62bb1f18b805573d4f96a3640bbbfff13c14f]: transaction type not supported: dynamic fee transactions not activated before London",
+			},
+			{ // ErrAccessListTxNotActive
+				txs: []*types.Transaction{
+					mkAccessListTx(0, common.Address{}, params.TxGas, big.NewInt(0)),
+				},
+				want: "invalid transaction 0 [0x2e70315f445d0b81cf07af29c3b33597dbcb9ab06668a50362811acbbb88f510]: transaction type not supported: access list transactions not activated before Berlin",
 			},
 		} {
 			block := GenerateBadBlock(genesis, ethash.NewFaker(), tt.txs, gspec.Config)
@@ -341,3 +358,149 @@ func GenerateBadBlock(parent *types.Block, engine consensus.Engine, txs types.Tr
 	// Assemble and return the final block for sealing
 	return types.NewBlock(header, txs, nil, receipts, trie.NewStackTrie(nil))
 }
+
+// TestFeePayerTransactions tests that the gas of fee payer transactions is
+// charged to the fee payer while the value is sent by the sender, and that the
+// transactions are rejected before the fork.
+func TestFeePayerTransactions(t *testing.T) {
+	var (
+		senderKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		payerKey, _  = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+		sender       = crypto.PubkeyToAddress(senderKey.PublicKey)
+		payer        = crypto.PubkeyToAddress(payerKey.PublicKey)
+		recipient    = common.HexToAddress("0xdeadbeef")
+		value        = big.NewInt(1000)
+		config       = *params.AllEthashProtocolChanges
+	)
+	config.FeePayerBlock = big.NewInt(0)
+
+	makeTx := func(config *params.ChainConfig) *types.Transaction {
+		signer := types.NewFeePayerSigner(config.ChainID)
+		tx, err := types.SignNewTx(senderKey, signer, &types.FeePayerTx{
+			ChainID:   config.ChainID,
+			GasTipCap: big.NewInt(0),
+			GasFeeCap: big.NewInt(params.InitialBaseFee),
+			Gas:       params.TxGas,
+			To:        &recipient,
+			Value:     value,
+			FeePayer:  payer,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tx, err = types.SignFeePayer(tx, signer, payerKey); err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+	newChain := func(config *params.ChainConfig) (*BlockChain, *types.Block) {
+		db := rawdb.NewMemoryDatabase()
+		gspec := &Genesis{
+			Config: config,
+			Alloc: GenesisAlloc{
+				sender: {Balance: value},
+				payer:  {Balance: big.NewInt(params.Ether)},
+			},
+		}
+		genesis := gspec.MustCommit(db)
+		blockchain, _ := NewBlockChain(db, nil, config, ethash.NewFaker(), vm.Config{}, nil, nil)
+		return blockchain, genesis
+	}
+	// The sender only holds the value, the fee payer covers the gas
+	blockchain, genesis := newChain(&config)
+	defer blockchain.Stop()
+
+	blocks, _ := GenerateChain(&config, genesis, ethash.NewFaker(), blockchain.db, 1, func(i int, b *BlockGen) {
+		b.AddTx(makeTx(&config))
+	})
+	if _, err := blockchain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert fee payer transaction: %v", err)
+	}
+	statedb, _ := blockchain.State()
+	if balance := statedb.GetBalance(sender); balance.Sign() != 0 {
+		t.Errorf("sender balance mismatch: have %v, want 0", balance)
+	}
+	if balance := statedb.GetBalance(recipient); balance.Cmp(value) != 0 {
+		t.Errorf("recipient balance mismatch: have %v, want %v", balance, value)
+	}
+	fee := new(big.Int).Mul(blocks[0].BaseFee(), new(big.Int).SetUint64(params.TxGas))
+	if want, balance := new(big.Int).Sub(big.NewInt(params.Ether), fee), statedb.GetBalance(payer); balance.Cmp(want) != 0 {
+		t.Errorf("fee payer balance mismatch: have %v, want %v", balance, want)
+	}
+	// Blocks including fee payer transactions before the fork are rejected
+	preFork := *params.AllEthashProtocolChanges
+	blockchain, genesis = newChain(&preFork)
+	defer blockchain.Stop()
+
+	block := GenerateBadBlock(genesis, ethash.NewFaker(), types.Transactions{makeTx(&config)}, &preFork)
+	if _, err := blockchain.InsertChain(types.Blocks{block}); err == nil {
+		t.Fatal("fee payer transaction accepted before the fork")
+	}
+}
+
+// TestTransientStorageIsolation tests that transient storage is only available
+// from the Curie fork on, and that it doesn't carry over between transactions.
+func TestTransientStorageIsolation(t *testing.T) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr     = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0x1153")
+		// sstore(0, tload(0)); tstore(0, 1); sstore(1, tload(0))
+		code = []byte{
+			byte(vm.PUSH1), 0, byte(vm.TLOAD), byte(vm.PUSH1), 0, byte(vm.SSTORE),
+			byte(vm.PUSH1), 1, byte(vm.PUSH1), 0, byte(vm.TSTORE),
+			byte(vm.PUSH1), 0, byte(vm.TLOAD), byte(vm.PUSH1), 1, byte(vm.SSTORE),
+		}
+	)
+	run := func(curie bool) (*state.StateDB, types.Receipts) {
+		config := *params.AllEthashProtocolChanges
+		if curie {
+			config.CurieBlock = big.NewInt(0)
+		}
+		var (
+			db    = rawdb.NewMemoryDatabase()
+			gspec = &Genesis{
+				Config: &config,
+				Alloc: GenesisAlloc{
+					addr:     {Balance: big.NewInt(params.Ether)},
+					contract: {Balance: new(big.Int), Code: code},
+				},
+			}
+			genesis       = gspec.MustCommit(db)
+			blockchain, _ = NewBlockChain(db, nil, &config, ethash.NewFaker(), vm.Config{}, nil, nil)
+		)
+		defer blockchain.Stop()
+
+		signer := types.LatestSigner(&config)
+		blocks, receipts := GenerateChain(&config, genesis, ethash.NewFaker(), db, 1, func(i int, b *BlockGen) {
+			for nonce := uint64(0); nonce < 2; nonce++ {
+				tx, _ := types.SignTx(types.NewTransaction(nonce, contract, new(big.Int), 100000, b.header.BaseFee, nil), signer, key)
+				b.AddTx(tx)
+			}
+		})
+		if _, err := blockchain.InsertChain(blocks); err != nil {
+			t.Fatalf("failed to insert chain: %v", err)
+		}
+		statedb, _ := blockchain.State()
+		return statedb, receipts[0]
+	}
+	statedb, receipts := run(true)
+	for i, receipt := range receipts {
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			t.Fatalf("transaction %d failed", i)
+		}
+	}
+	if have := statedb.GetState(contract, common.Hash{}); have != (common.Hash{}) {
+		t.Errorf("transient storage leaked across transactions: have %x", have)
+	}
+	if have, want := statedb.GetState(contract, common.BigToHash(common.Big1)), common.BigToHash(common.Big1); have != want {
+		t.Errorf("transient storage not readable within the transaction: have %x, want %x", have, want)
+	}
+	// Before Curie the opcodes are undefined
+	_, receipts = run(false)
+	for i, receipt := range receipts {
+		if receipt.Status != types.ReceiptStatusFailed {
+			t.Errorf("transaction %d succeeded before curie", i)
+		}
+	}
+}
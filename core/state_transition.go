@@ -75,6 +75,8 @@ type Message interface {
 
 	Nonce() uint64
 	IsFake() bool
+	IsSystem() bool
+	Payer() common.Address
 	Data() []byte
 	AccessList() types.AccessList
 }
@@ -198,8 +200,14 @@ func (st *StateTransition) buyGas() error {
 		balanceCheck = balanceCheck.Mul(balanceCheck, st.gasFeeCap)
 		balanceCheck.Add(balanceCheck, st.value)
 	}
-	if have, want := st.state.GetBalance(st.msg.From()), balanceCheck; have.Cmp(want) < 0 {
-		return fmt.Errorf("%w: address %v have %v want %v", ErrInsufficientFunds, st.msg.From().Hex(), have, want)
+	// Sponsored gas is paid by the fee payer, the value is checked against
+	// the sender before execution
+	payer := st.msg.Payer()
+	if payer != st.msg.From() && st.gasFeeCap != nil {
+		balanceCheck.Sub(balanceCheck, st.value)
+	}
+	if have, want := st.state.GetBalance(payer), balanceCheck; have.Cmp(want) < 0 {
+		return fmt.Errorf("%w: address %v have %v want %v", ErrInsufficientFunds, payer.Hex(), have, want)
 	}
 	if err := st.gp.SubGas(st.msg.Gas()); err != nil {
 		return err
@@ -207,7 +215,7 @@ func (st *StateTransition) buyGas() error {
 	st.gas += st.msg.Gas()
 
 	st.initialGas = st.msg.Gas()
-	st.state.SubBalance(st.msg.From(), mgval)
+	st.state.SubBalance(payer, mgval)
 	return nil
 }
 
@@ -283,6 +291,9 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	// 5. there is no overflow when calculating intrinsic gas
 	// 6. caller has enough balance to cover asset transfer for **topmost** call
 
+	if st.msg.IsSystem() {
+		return st.systemTransition()
+	}
 	// Check clauses 1-3, buy gas if everything is correct
 	if err := st.preCheck(); err != nil {
 		return nil, err
@@ -336,7 +347,35 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	if london {
 		effectiveTip = cmath.BigMin(st.gasTipCap, new(big.Int).Sub(st.gasFeeCap, st.evm.Context.BaseFee))
 	}
-	st.state.AddBalance(st.evm.Context.Coinbase, new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), effectiveTip))
+	if st.evm.Config.NoBaseFee && st.gasFeeCap.Sign() == 0 && st.gasTipCap.Sign() == 0 {
+		// Skip the fee payment of calls simulated without any fees, the tip
+		// would come out negative otherwise.
+	} else {
+		st.state.AddBalance(st.evm.Context.Coinbase, new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), effectiveTip))
+	}
+
+	return &ExecutionResult{
+		UsedGas:    st.gasUsed(),
+		Err:        vmerr,
+		ReturnData: ret,
+	}, nil
+}
+
+// systemTransition applies a system message. System messages are calls made by
+// the protocol: they don't buy gas, don't take gas from the block gas pool and
+// don't touch the nonce or balance of the sender.
+func (st *StateTransition) systemTransition() (*ExecutionResult, error) {
+	msg := st.msg
+	if msg.To() == nil {
+		return nil, fmt.Errorf("%w: system contract creation", ErrTxTypeNotSupported)
+	}
+	st.gas, st.initialGas = msg.Gas(), msg.Gas()
+
+	if rules := st.evm.ChainConfig().Rules(st.evm.Context.BlockNumber); rules.IsBerlin {
+		st.state.PrepareAccessList(msg.From(), msg.To(), vm.ActivePrecompiles(rules), nil)
+	}
+	ret, gas, vmerr := st.evm.Call(vm.AccountRef(msg.From()), *msg.To(), st.data, st.gas, new(big.Int))
+	st.gas = gas
 
 	return &ExecutionResult{
 		UsedGas:    st.gasUsed(),
@@ -355,7 +394,7 @@ func (st *StateTransition) refundGas(refundQuotient uint64) {
 
 	// Return ETH for remaining gas, exchanged at the original rate.
 	remaining := new(big.Int).Mul(new(big.Int).SetUint64(st.gas), st.gasPrice)
-	st.state.AddBalance(st.msg.From(), remaining)
+	st.state.AddBalance(st.msg.Payer(), remaining)
 
 	// Also return remaining gas to the block gas counter so it is
 	// available for the next transaction.
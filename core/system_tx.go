@@ -0,0 +1,102 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/state"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/params"
+)
+
+// SystemCall is a call to a protocol contract, made by a system transaction.
+type SystemCall struct {
+	To   common.Address
+	Gas  uint64
+	Data []byte
+}
+
+// SystemTxSource derives the system calls a block has to open with, from the
+// header of the block and the state it is built on. Sources must be
+// deterministic, as followers re-derive the calls to validate imported blocks,
+// and must not modify the state.
+type SystemTxSource func(config *params.ChainConfig, header *types.Header, statedb *state.StateDB) ([]SystemCall, error)
+
+// systemTxSource is a registered source of system calls.
+type systemTxSource struct {
+	name   string
+	source SystemTxSource
+}
+
+// systemTxSources contains the registered system call sources, in the order
+// their calls are placed in a block.
+var systemTxSources []systemTxSource
+
+// RegisterSystemTxSource adds a source of system transactions, e.g. updates of a
+// fee parameter contract. The calls of all sources are placed at the start of
+// every block in registration order.
+//
+// The registry is not safe for concurrent use, sources must be registered before
+// any block is built or processed, typically from a package init function.
+func RegisterSystemTxSource(name string, source SystemTxSource) {
+	systemTxSources = append(systemTxSources, systemTxSource{name: name, source: source})
+}
+
+// SystemTransactions returns the system transactions the given block has to open
+// with, built on top of the given state.
+func SystemTransactions(config *params.ChainConfig, header *types.Header, statedb *state.StateDB) (types.Transactions, error) {
+	var txs types.Transactions
+	for _, src := range systemTxSources {
+		calls, err := src.source(config, header, statedb)
+		if err != nil {
+			return nil, fmt.Errorf("system transaction source %s: %w", src.name, err)
+		}
+		for _, call := range calls {
+			if call.Gas > params.SystemTxGas {
+				return nil, fmt.Errorf("system transaction source %s: gas %d above limit %d", src.name, call.Gas, params.SystemTxGas)
+			}
+			txs = append(txs, types.NewTx(&types.SystemTx{
+				Number: header.Number.Uint64(),
+				Index:  uint64(len(txs)),
+				To:     call.To,
+				Gas:    call.Gas,
+				Data:   call.Data,
+			}))
+		}
+	}
+	return txs, nil
+}
+
+// verifySystemTransactions checks that the block opens with exactly the expected
+// system transactions, and contains no other ones.
+func verifySystemTransactions(txs types.Transactions, expected types.Transactions) error {
+	if len(txs) < len(expected) {
+		return fmt.Errorf("%w: have %d transactions, want at least %d system ones", ErrSystemTxMismatch, len(txs), len(expected))
+	}
+	for i, tx := range txs {
+		if i < len(expected) {
+			if tx.Hash() != expected[i].Hash() {
+				return fmt.Errorf("%w: transaction %d: have %x, want %x", ErrSystemTxMismatch, i, tx.Hash(), expected[i].Hash())
+			}
+		} else if tx.Type() == types.SystemTxType {
+			return fmt.Errorf("%w: unexpected system transaction %d", ErrSystemTxMismatch, i)
+		}
+	}
+	return nil
+}
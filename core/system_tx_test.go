@@ -0,0 +1,109 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/consensus/ethash"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/core/state"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/core/vm"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/scroll-tech/go-ethereum/params"
+	"github.com/scroll-tech/go-ethereum/trie"
+)
+
+// TestSystemTransactions tests that blocks open with the system transactions of
+// the registered sources, that these are excluded from the gas accounting and
+// that blocks missing them are rejected.
+func TestSystemTransactions(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		updater = common.HexToAddress("0x5300000000000000000000000000000000000002")
+		config  = *params.AllEthashProtocolChanges
+	)
+	config.ChainID = big.NewInt(0x5157)
+
+	// Push the block number into a contract storing it, on the test chain only
+	defer func(sources []systemTxSource) { systemTxSources = sources }(systemTxSources)
+	RegisterSystemTxSource("test", func(cfg *params.ChainConfig, header *types.Header, statedb *state.StateDB) ([]SystemCall, error) {
+		if cfg.ChainID.Cmp(config.ChainID) != 0 {
+			return nil, nil
+		}
+		return []SystemCall{{To: updater, Gas: 50000, Data: common.BigToHash(header.Number).Bytes()}}, nil
+	})
+	gspec := &Genesis{
+		Config: &config,
+		Alloc: GenesisAlloc{
+			addr: {Balance: big.NewInt(params.Ether)},
+			// sstore(0, calldataload(0))
+			updater: {Balance: new(big.Int), Code: []byte{byte(vm.PUSH1), 0, byte(vm.CALLDATALOAD), byte(vm.PUSH1), 0, byte(vm.SSTORE)}},
+		},
+	}
+	db := rawdb.NewMemoryDatabase()
+	genesis := gspec.MustCommit(db)
+	signer := types.LatestSigner(&config)
+
+	blocks, _ := GenerateChain(&config, genesis, ethash.NewFaker(), db, 3, func(i int, b *BlockGen) {
+		if i == 1 {
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{0x01}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+			b.AddTx(tx)
+		}
+	})
+	chain, _ := NewBlockChain(db, nil, &config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert blocks: %v", err)
+	}
+	for i, block := range blocks {
+		if tx := block.Transactions()[0]; tx.Type() != types.SystemTxType || *tx.To() != updater {
+			t.Errorf("block %d: first transaction is not the system one", i)
+		}
+		want := uint64(0)
+		if i == 1 {
+			want = params.TxGas
+		}
+		if block.GasUsed() != want {
+			t.Errorf("block %d: gas used mismatch: have %d, want %d", i, block.GasUsed(), want)
+		}
+	}
+	statedb, _ := chain.State()
+	if have, want := statedb.GetState(updater, common.Hash{}), common.BigToHash(big.NewInt(3)); have != want {
+		t.Errorf("system call not applied: have %x, want %x", have, want)
+	}
+	// Blocks missing the system transactions must be rejected
+	db = rawdb.NewMemoryDatabase()
+	gspec.MustCommit(db)
+	chain, _ = NewBlockChain(db, nil, &config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	defer chain.Stop()
+
+	bad := types.NewBlock(blocks[0].Header(), nil, nil, nil, trie.NewStackTrie(nil))
+	if _, err := chain.InsertChain(types.Blocks{bad}); !errors.Is(err, ErrSystemTxMismatch) {
+		t.Fatalf("block without system transactions: have %v, want %v", err, ErrSystemTxMismatch)
+	}
+	// System transactions can't be submitted by users
+	if err := checkTxType(types.SystemTxType, true, true, true); !errors.Is(err, ErrTxTypeNotSupported) {
+		t.Errorf("user system transaction: have %v, want %v", err, ErrTxTypeNotSupported)
+	}
+}
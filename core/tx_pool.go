@@ -17,7 +17,9 @@
 package core
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"math"
 	"math/big"
 	"sort"
@@ -25,6 +27,8 @@ import (
 	"sync/atomic"
 	"time"
 
+	lru "github.com/hashicorp/golang-lru"
+
 	"github.com/scroll-tech/go-ethereum/common"
 	"github.com/scroll-tech/go-ethereum/common/prque"
 	"github.com/scroll-tech/go-ethereum/consensus/misc"
@@ -61,6 +65,14 @@ var (
 	// ErrInvalidSender is returned if the transaction contains an invalid signature.
 	ErrInvalidSender = errors.New("invalid sender")
 
+	// ErrInvalidFeePayer is returned if the fee payer signature of a transaction
+	// is invalid or doesn't match its fee payer.
+	ErrInvalidFeePayer = errors.New("invalid fee payer")
+
+	// ErrFeePayerOvercommitted is returned if the balance of a fee payer doesn't
+	// cover the fees of all the pooled transactions it sponsors.
+	ErrFeePayerOvercommitted = errors.New("fee payer balance committed to pending transactions")
+
 	// ErrUnderpriced is returned if a transaction's gas price is below the minimum
 	// configured for the transaction pool.
 	ErrUnderpriced = errors.New("transaction underpriced")
@@ -85,6 +97,18 @@ var (
 	// than some meaningful limit a user might use. This is not a consensus error
 	// making the transaction invalid, rather a DOS protection.
 	ErrOversizedData = errors.New("oversized data")
+
+	// ErrNonceGapTooLarge is returned if a transaction of a gap tolerant sender
+	// is further ahead of the pending nonce than the pool is willing to hold.
+	ErrNonceGapTooLarge = errors.New("nonce gap too large")
+
+	// ErrReplaceTooFrequent is returned if a remote sender attempts to replace
+	// a transaction sooner than the replacement interval of the pool allows.
+	ErrReplaceTooFrequent = errors.New("transaction replaced too frequently")
+
+	// ErrTxPoolDraining is returned if a transaction is added to a pool being
+	// drained for shutdown.
+	ErrTxPoolDraining = errors.New("txpool is draining")
 )
 
 var (
@@ -92,6 +116,10 @@ var (
 	statsReportInterval = 8 * time.Second // Time interval to report transaction pool stats
 )
 
+// evictedCacheLimit is the number of evicted transactions whose drop reason is
+// remembered.
+const evictedCacheLimit = 4096
+
 var (
 	// Metrics for the pending pool
 	pendingDiscardMeter   = metrics.NewRegisteredMeter("txpool/pending/discard", nil)
@@ -106,6 +134,11 @@ var (
 	queuedNofundsMeter   = metrics.NewRegisteredMeter("txpool/queued/nofunds", nil)   // Dropped due to out-of-funds
 	queuedEvictionMeter  = metrics.NewRegisteredMeter("txpool/queued/eviction", nil)  // Dropped due to lifetime
 
+	// lifetimeEvictionMeter counts the transactions dropped due to the max lifetime.
+	lifetimeEvictionMeter = metrics.NewRegisteredMeter("txpool/lifetime/eviction", nil)
+	// droppedAgeHistogram tracks how long evicted transactions were in the pool, in milliseconds.
+	droppedAgeHistogram = metrics.NewRegisteredHistogram("txpool/dropped/age", nil, metrics.NewExpDecaySample(1028, 0.015))
+
 	// General tx metrics
 	knownTxMeter       = metrics.NewRegisteredMeter("txpool/known", nil)
 	validTxMeter       = metrics.NewRegisteredMeter("txpool/valid", nil)
@@ -162,6 +195,44 @@ type blockChain interface {
 	SubscribeChainHeadEvent(ch chan<- ChainHeadEvent) event.Subscription
 }
 
+// TxDropReason is the reason code of a transaction evicted from the pool.
+// Transactions leaving the pool by being included in a block are not evicted.
+type TxDropReason uint8
+
+const (
+	TxDropReplaced    TxDropReason = iota + 1 // Superseded by a transaction of the same sender and nonce
+	TxDropUnderpriced                         // Below the pool price threshold or the cheapest of a full pool
+	TxDropUnpayable                           // Sender or fee payer balance, or block gas limit no longer covers the transaction
+	TxDropOverflow                            // Exceeded the account or global slot limits
+	TxDropExpired                             // Non-executable for longer than the queue lifetime
+	TxDropLifetime                            // In the pool for longer than the max lifetime
+)
+
+// String implements fmt.Stringer.
+func (r TxDropReason) String() string {
+	switch r {
+	case TxDropReplaced:
+		return "replaced"
+	case TxDropUnderpriced:
+		return "underpriced"
+	case TxDropUnpayable:
+		return "unpayable"
+	case TxDropOverflow:
+		return "overflow"
+	case TxDropExpired:
+		return "expired"
+	case TxDropLifetime:
+		return "lifetime"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(r))
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (r TxDropReason) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
 // TxPoolConfig are the configuration parameters of the transaction pool.
 type TxPoolConfig struct {
 	Locals    []common.Address // Addresses that should be treated by default as local
@@ -172,12 +243,18 @@ type TxPoolConfig struct {
 	PriceLimit uint64 // Minimum gas price to enforce for acceptance into the pool
 	PriceBump  uint64 // Minimum price bump percentage to replace an already existing transaction (nonce)
 
+	ReplaceInterval time.Duration // Minimum time between two replacements of the same remote sender (0 = unlimited)
+
 	AccountSlots uint64 // Number of executable transaction slots guaranteed per account
 	GlobalSlots  uint64 // Maximum number of executable transaction slots for all accounts
 	AccountQueue uint64 // Maximum number of non-executable transaction slots permitted per account
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
-	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+	Lifetime    time.Duration // Maximum amount of time non-executable transaction are queued
+	MaxLifetime time.Duration // Maximum amount of time non-local transactions stay in the pool (0 = unlimited)
+
+	GapTolerant  []common.Address // Contract wallet senders whose out-of-order transactions are held until gaps fill
+	GapTolerance uint64           // Maximum nonce gap held for gap tolerant senders
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -195,6 +272,8 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	GlobalQueue:  1024,
 
 	Lifetime: 3 * time.Hour,
+
+	GapTolerance: 64,
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -233,9 +312,25 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool lifetime", "provided", conf.Lifetime, "updated", DefaultTxPoolConfig.Lifetime)
 		conf.Lifetime = DefaultTxPoolConfig.Lifetime
 	}
+	if conf.MaxLifetime < 0 {
+		log.Warn("Sanitizing invalid txpool max lifetime", "provided", conf.MaxLifetime, "updated", time.Duration(0))
+		conf.MaxLifetime = 0
+	}
+	if len(conf.GapTolerant) > 0 && conf.GapTolerance < 1 {
+		log.Warn("Sanitizing invalid txpool gap tolerance", "provided", conf.GapTolerance, "updated", DefaultTxPoolConfig.GapTolerance)
+		conf.GapTolerance = DefaultTxPoolConfig.GapTolerance
+	}
 	return conf
 }
 
+// TxPoolPolicy is the set of anti-spam parameters of the transaction pool which
+// can be tuned at runtime.
+type TxPoolPolicy struct {
+	PriceLimit      uint64        `json:"priceLimit"`      // Minimum gas price to enforce for acceptance into the pool
+	PriceBump       uint64        `json:"priceBump"`       // Minimum price bump percentage to replace an already existing transaction
+	ReplaceInterval time.Duration `json:"replaceInterval"` // Minimum time between two replacements of the same remote sender
+}
+
 // TxPool contains all currently known transactions. Transactions
 // enter the pool when they are received from the network or submitted
 // locally. They exit the pool when they are included in the blockchain.
@@ -249,6 +344,7 @@ type TxPool struct {
 	chain       blockChain
 	gasPrice    *big.Int
 	txFeed      event.Feed
+	dropFeed    event.Feed
 	scope       event.SubscriptionScope
 	signer      types.Signer
 	mu          sync.RWMutex
@@ -256,17 +352,20 @@ type TxPool struct {
 	istanbul bool // Fork indicator whether we are in the istanbul stage.
 	eip2718  bool // Fork indicator whether we are using EIP-2718 type transactions.
 	eip1559  bool // Fork indicator whether we are using EIP-1559 type transactions.
+	feePayer bool // Fork indicator whether we are using fee payer type transactions.
 
 	currentState  *state.StateDB // Current state in the blockchain head
 	pendingNonces *txNoncer      // Pending state tracking virtual nonces
 	currentMaxGas uint64         // Current gas limit for transaction caps
 
-	locals  *accountSet // Set of local transaction to exempt from eviction rules
-	journal *txJournal  // Journal of local transaction to back up to disk
+	locals      *accountSet // Set of local transaction to exempt from eviction rules
+	gapTolerant *accountSet // Set of senders whose nonce gapped transactions are held
+	journal     *txJournal  // Journal of local transaction to back up to disk
 
 	pending map[common.Address]*txList   // All currently processable transactions
 	queue   map[common.Address]*txList   // Queued but non-processable transactions
 	beats   map[common.Address]time.Time // Last heartbeat from each known account
+	swaps   map[common.Address]time.Time // Last transaction replacement of each remote account
 	all     *txLookup                    // All transactions to allow lookups
 	priced  *txPricedList                // All transactions sorted by price
 
@@ -281,8 +380,12 @@ type TxPool struct {
 	initDoneCh      chan struct{}  // is closed once the pool is initialized (for tests)
 
 	spammers *prque.Prque
+	drops    []DroppedTxsEvent // Evictions not yet announced to subscribers
+	evicted  *lru.Cache        // Drop reasons of recently evicted transactions
 
 	changesSinceReorg int // A counter for how many drops we've performed in-between reorg.
+
+	draining uint32 // Set when the pool stopped accepting transactions for shutdown (atomic)
 }
 
 type txpoolResetRequest struct {
@@ -296,6 +399,7 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 	config = (&config).sanitize()
 
 	// Create the transaction pool with its initial settings
+	evicted, _ := lru.New(evictedCacheLimit)
 	pool := &TxPool{
 		config:          config,
 		chainconfig:     chainconfig,
@@ -304,6 +408,7 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		pending:         make(map[common.Address]*txList),
 		queue:           make(map[common.Address]*txList),
 		beats:           make(map[common.Address]time.Time),
+		swaps:           make(map[common.Address]time.Time),
 		all:             newTxLookup(),
 		chainHeadCh:     make(chan ChainHeadEvent, chainHeadChanSize),
 		reqResetCh:      make(chan *txpoolResetRequest),
@@ -314,12 +419,18 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		initDoneCh:      make(chan struct{}),
 		gasPrice:        new(big.Int).SetUint64(config.PriceLimit),
 		spammers:        prque.New(nil),
+		evicted:         evicted,
 	}
 	pool.locals = newAccountSet(pool.signer)
 	for _, addr := range config.Locals {
 		log.Info("Setting new local account", "address", addr)
 		pool.locals.add(addr)
 	}
+	pool.gapTolerant = newAccountSet(pool.signer)
+	for _, addr := range config.GapTolerant {
+		log.Info("Setting gap tolerant account", "address", addr, "gap", config.GapTolerance)
+		pool.gapTolerant.add(addr)
+	}
 	pool.priced = newTxPricedList(pool.all)
 	pool.reset(nil, chain.CurrentBlock().Header())
 
@@ -398,8 +509,8 @@ func (pool *TxPool) loop() {
 		case <-evict.C:
 			pool.mu.Lock()
 			for addr := range pool.queue {
-				// Skip local transactions from the eviction mechanism
-				if pool.locals.contains(addr) {
+				// Skip local and gap tolerant transactions from the eviction mechanism
+				if pool.locals.contains(addr) || pool.gapTolerant.contains(addr) {
 					continue
 				}
 				// Any non-locals old enough should be removed
@@ -409,9 +520,32 @@ func (pool *TxPool) loop() {
 						pool.removeTx(tx.Hash(), true)
 					}
 					queuedEvictionMeter.Mark(int64(len(list)))
+					pool.dropped(TxDropExpired, list...)
+				}
+			}
+			// Drop any non-locals older than the max lifetime, executable or not
+			if pool.config.MaxLifetime > 0 {
+				var expired []*types.Transaction
+				pool.all.Range(func(hash common.Hash, tx *types.Transaction, local bool) bool {
+					if time.Since(tx.Time()) > pool.config.MaxLifetime {
+						expired = append(expired, tx)
+					}
+					return true
+				}, false, true)
+				for _, tx := range expired {
+					pool.removeTx(tx.Hash(), true)
+				}
+				lifetimeEvictionMeter.Mark(int64(len(expired)))
+				pool.dropped(TxDropLifetime, expired...)
+			}
+			// Forget replacements that no longer limit their senders
+			for addr, swap := range pool.swaps {
+				if time.Since(swap) > pool.config.ReplaceInterval {
+					delete(pool.swaps, addr)
 				}
 			}
 			pool.mu.Unlock()
+			pool.announceDrops()
 
 		// Handle local transaction journal rotation
 		case <-journal.C:
@@ -436,17 +570,77 @@ func (pool *TxPool) Stop() {
 	pool.wg.Wait()
 
 	if pool.journal != nil {
+		// A drained pool is shut down gracefully, persist its final local content
+		if atomic.LoadUint32(&pool.draining) == 1 {
+			pool.mu.RLock()
+			if err := pool.journal.rotate(pool.local()); err != nil {
+				log.Warn("Failed to journal local transactions", "err", err)
+			}
+			pool.mu.RUnlock()
+		}
 		pool.journal.close()
 	}
 	log.Info("Transaction pool stopped")
 }
 
+// Drain stops the pool from accepting new transactions ahead of a graceful
+// shutdown, the pooled ones stay available to the miner and are journaled when
+// the pool is stopped.
+func (pool *TxPool) Drain() {
+	if atomic.CompareAndSwapUint32(&pool.draining, 0, 1) {
+		log.Info("Transaction pool stopped accepting transactions")
+	}
+}
+
 // SubscribeNewTxsEvent registers a subscription of NewTxsEvent and
 // starts sending event to the given channel.
 func (pool *TxPool) SubscribeNewTxsEvent(ch chan<- NewTxsEvent) event.Subscription {
 	return pool.scope.Track(pool.txFeed.Subscribe(ch))
 }
 
+// SubscribeDroppedTxsEvent registers a subscription of DroppedTxsEvent and
+// starts sending event to the given channel.
+func (pool *TxPool) SubscribeDroppedTxsEvent(ch chan<- DroppedTxsEvent) event.Subscription {
+	return pool.scope.Track(pool.dropFeed.Subscribe(ch))
+}
+
+// dropped records evicted transactions to be announced to subscribers.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) dropped(reason TxDropReason, txs ...*types.Transaction) {
+	if len(txs) == 0 {
+		return
+	}
+	now := time.Now()
+	for _, tx := range txs {
+		droppedAgeHistogram.Update(now.Sub(tx.Time()).Milliseconds())
+		pool.evicted.Add(tx.Hash(), reason)
+	}
+	pool.drops = append(pool.drops, DroppedTxsEvent{Txs: txs, Reason: reason})
+}
+
+// DropReason returns the reason a recently evicted transaction was dropped from
+// the pool for, if it's still remembered.
+func (pool *TxPool) DropReason(hash common.Hash) (TxDropReason, bool) {
+	if reason, ok := pool.evicted.Get(hash); ok {
+		return reason.(TxDropReason), true
+	}
+	return 0, false
+}
+
+// announceDrops sends the recorded evictions to subscribers. The pool lock must
+// not be held, since sending blocks until all subscribers received the events.
+func (pool *TxPool) announceDrops() {
+	pool.mu.Lock()
+	drops := pool.drops
+	pool.drops = nil
+	pool.mu.Unlock()
+
+	for _, ev := range drops {
+		pool.dropFeed.Send(ev)
+	}
+}
+
 // GasPrice returns the current gas price enforced by the transaction pool.
 func (pool *TxPool) GasPrice() *big.Int {
 	pool.mu.RLock()
@@ -458,9 +652,17 @@ func (pool *TxPool) GasPrice() *big.Int {
 // SetGasPrice updates the minimum price required by the transaction pool for a
 // new transaction, and drops all transactions below this threshold.
 func (pool *TxPool) SetGasPrice(price *big.Int) {
+	defer pool.announceDrops()
+
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
 
+	pool.setGasPrice(price)
+}
+
+// setGasPrice is the internal version of SetGasPrice, assuming the pool lock is
+// already held.
+func (pool *TxPool) setGasPrice(price *big.Int) {
 	old := pool.gasPrice
 	pool.gasPrice = price
 	// if the min miner fee increased, remove transactions below the new threshold
@@ -471,11 +673,69 @@ func (pool *TxPool) SetGasPrice(price *big.Int) {
 			pool.removeTx(tx.Hash(), false)
 		}
 		pool.priced.Removed(len(drop))
+		pool.dropped(TxDropUnderpriced, drop...)
 	}
 
 	log.Info("Transaction pool price threshold updated", "price", price)
 }
 
+// Policy returns the currently enforced anti-spam policy of the pool.
+func (pool *TxPool) Policy() TxPoolPolicy {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return TxPoolPolicy{
+		PriceLimit:      pool.gasPrice.Uint64(),
+		PriceBump:       pool.config.PriceBump,
+		ReplaceInterval: pool.config.ReplaceInterval,
+	}
+}
+
+// SetPolicy updates the anti-spam policy of the pool, dropping all transactions
+// below the new price threshold.
+func (pool *TxPool) SetPolicy(policy TxPoolPolicy) error {
+	_, err := pool.UpdatePolicy(func(current *TxPoolPolicy) error {
+		*current = policy
+		return nil
+	})
+	return err
+}
+
+// UpdatePolicy modifies the anti-spam policy of the pool through the given
+// callback, dropping all transactions below the new price threshold. The pool
+// is locked for the whole update, so concurrent partial updates don't overwrite
+// each other. The resulting policy is returned.
+func (pool *TxPool) UpdatePolicy(update func(*TxPoolPolicy) error) (TxPoolPolicy, error) {
+	defer pool.announceDrops()
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	policy := TxPoolPolicy{
+		PriceLimit:      pool.gasPrice.Uint64(),
+		PriceBump:       pool.config.PriceBump,
+		ReplaceInterval: pool.config.ReplaceInterval,
+	}
+	if err := update(&policy); err != nil {
+		return TxPoolPolicy{}, err
+	}
+	if policy.PriceLimit < 1 {
+		return TxPoolPolicy{}, errors.New("price limit must be positive")
+	}
+	if policy.PriceBump < 1 {
+		return TxPoolPolicy{}, errors.New("price bump must be positive")
+	}
+	if policy.ReplaceInterval < 0 {
+		return TxPoolPolicy{}, errors.New("replace interval must not be negative")
+	}
+	pool.setGasPrice(new(big.Int).SetUint64(policy.PriceLimit))
+	pool.config.PriceBump = policy.PriceBump
+	pool.config.ReplaceInterval = policy.ReplaceInterval
+
+	log.Info("Transaction pool policy updated", "pricelimit", policy.PriceLimit, "pricebump", policy.PriceBump, "replaceinterval", policy.ReplaceInterval)
+	return policy, nil
+}
+
 // Nonce returns the next nonce of an account, with all transactions executable
 // by the pool already applied on top.
 func (pool *TxPool) Nonce(addr common.Address) uint64 {
@@ -573,6 +833,22 @@ func (pool *TxPool) Pending(enforceTips bool) map[common.Address]types.Transacti
 	return pending
 }
 
+// PendingLists retrieves all currently processable transactions, grouped by
+// origin account and sorted by nonce, without copying them. Unlike with Pending,
+// the lists are shared with the pool and must not be modified by calling code.
+func (pool *TxPool) PendingLists() map[common.Address]types.Transactions {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pending := make(map[common.Address]types.Transactions, len(pool.pending))
+	for addr, list := range pool.pending {
+		if txs := list.txs.flatten(); len(txs) > 0 {
+			pending[addr] = txs
+		}
+	}
+	return pending
+}
+
 // Locals retrieves the accounts currently considered local by the pool.
 func (pool *TxPool) Locals() []common.Address {
 	pool.mu.Lock()
@@ -581,6 +857,83 @@ func (pool *TxPool) Locals() []common.Address {
 	return pool.locals.flatten()
 }
 
+// TxPoolEntry is a transaction of the pool along with the metadata needed to
+// transfer it into another pool.
+type TxPoolEntry struct {
+	Tx      *types.Transaction
+	From    common.Address
+	Local   bool      // Whether the transaction is exempt from the pricing constraints
+	Pending bool      // Whether the transaction is executable, queued otherwise
+	Time    time.Time // Time the transaction was first seen
+}
+
+// Export retrieves all the transactions of the pool with their metadata, grouped
+// by account and sorted by nonce, so that they can be imported into another pool.
+func (pool *TxPool) Export() []*TxPoolEntry {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	var entries []*TxPoolEntry
+	export := func(lists map[common.Address]*txList, pending bool) {
+		for addr, list := range lists {
+			local := pool.locals.contains(addr)
+			for _, tx := range list.Flatten() {
+				entries = append(entries, &TxPoolEntry{Tx: tx, From: addr, Local: local, Pending: pending, Time: tx.Time()})
+			}
+		}
+	}
+	export(pool.pending, true)
+	export(pool.queue, false)
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if cmp := bytes.Compare(entries[i].From[:], entries[j].From[:]); cmp != 0 {
+			return cmp < 0
+		}
+		return entries[i].Tx.Nonce() < entries[j].Tx.Nonce()
+	})
+	return entries
+}
+
+// Import adds the exported transactions of another pool, keeping their local
+// flags and the times they were first seen. The transactions are revalidated,
+// the returned errors are in the order of the entries. The call returns after
+// the transactions are promoted.
+func (pool *TxPool) Import(entries []*TxPoolEntry) []error {
+	var (
+		errs                = make([]error, len(entries))
+		locals, remotes     []*types.Transaction
+		localIdx, remoteIdx []int
+	)
+	for i, entry := range entries {
+		if entry.Tx == nil {
+			errs[i] = errors.New("missing transaction")
+			continue
+		}
+		if from, err := types.Sender(pool.signer, entry.Tx); err != nil {
+			errs[i] = ErrInvalidSender
+			continue
+		} else if from != entry.From {
+			errs[i] = fmt.Errorf("sender mismatch: have %x, want %x", from, entry.From)
+			continue
+		}
+		if !entry.Time.IsZero() {
+			entry.Tx.SetTime(entry.Time)
+		}
+		if entry.Local {
+			locals, localIdx = append(locals, entry.Tx), append(localIdx, i)
+		} else {
+			remotes, remoteIdx = append(remotes, entry.Tx), append(remoteIdx, i)
+		}
+	}
+	for i, err := range pool.addTxs(locals, !pool.config.NoLocals, true) {
+		errs[localIdx[i]] = err
+	}
+	for i, err := range pool.addTxs(remotes, false, true) {
+		errs[remoteIdx[i]] = err
+	}
+	return errs
+}
+
 // local retrieves all currently known local transactions, grouped by origin
 // account and sorted by nonce. The returned transaction set is a copy and can be
 // freely modified by calling code.
@@ -600,13 +953,9 @@ func (pool *TxPool) local() map[common.Address]types.Transactions {
 // validateTx checks whether a transaction is valid according to the consensus
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
-	// Accept only legacy transactions until EIP-2718/2930 activates.
-	if !pool.eip2718 && tx.Type() != types.LegacyTxType {
-		return ErrTxTypeNotSupported
-	}
-	// Reject dynamic fee transactions until EIP-1559 activates.
-	if !pool.eip1559 && tx.Type() == types.DynamicFeeTxType {
-		return ErrTxTypeNotSupported
+	// Accept only transaction types activated for the pending block.
+	if err := checkTxType(tx.Type(), pool.eip2718, pool.eip1559, pool.feePayer); err != nil {
+		return err
 	}
 	// Reject transactions over defined size to prevent DOS attacks
 	if uint64(tx.Size()) > txMaxSize {
@@ -646,11 +995,38 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if pool.currentState.GetNonce(from) > tx.Nonce() {
 		return ErrNonceTooLow
 	}
+	// Gap tolerant senders may only queue up to the configured distance ahead
+	if pool.gapTolerant.contains(from) && tx.Nonce() > pool.pendingNonces.get(from)+pool.config.GapTolerance {
+		return ErrNonceGapTooLarge
+	}
 	// Transactor should have enough funds to cover the costs
 	// cost == V + GP * GL
 	if pool.currentState.GetBalance(from).Cmp(tx.Cost()) < 0 {
 		return ErrInsufficientFunds
 	}
+	// The gas of fee payer transactions is paid by the fee payer, who must have
+	// signed for it and be able to cover it
+	if tx.Type() == types.FeePayerTxType {
+		payer, err := types.FeePayer(pool.signer, tx)
+		if err != nil {
+			return ErrInvalidFeePayer
+		}
+		balance := pool.currentState.GetBalance(payer)
+		if balance.Cmp(tx.FeeCost()) < 0 {
+			return ErrInsufficientFunds
+		}
+		// The fees of the other pooled transactions the payer sponsors count
+		// too, apart from the one about to be replaced
+		committed := new(big.Int).Add(pool.all.Sponsored(payer), tx.FeeCost())
+		if old := pool.pooledTx(from, tx.Nonce()); old != nil {
+			if oldPayer := old.FeePayer(); oldPayer != nil && *oldPayer == payer {
+				committed.Sub(committed, old.FeeCost())
+			}
+		}
+		if balance.Cmp(committed) < 0 {
+			return ErrFeePayerOvercommitted
+		}
+	}
 	// Ensure the transaction has more gas than the basic tx fee.
 	intrGas, err := IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil, true, pool.istanbul)
 	if err != nil {
@@ -662,6 +1038,20 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	return nil
 }
 
+// pooledTx returns the pending or queued transaction of the given sender with the
+// given nonce, if any.
+func (pool *TxPool) pooledTx(from common.Address, nonce uint64) *types.Transaction {
+	if list := pool.pending[from]; list != nil {
+		if tx := list.txs.Get(nonce); tx != nil {
+			return tx
+		}
+	}
+	if list := pool.queue[from]; list != nil {
+		return list.txs.Get(nonce)
+	}
+	return nil
+}
+
 // add validates a transaction and inserts it into the non-executable queue for later
 // pending promotion and execution. If the transaction is a replacement for an already
 // pending or queued one, it overwrites the previous transaction if its price is higher.
@@ -723,9 +1113,18 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 			underpricedTxMeter.Mark(1)
 			pool.removeTx(tx.Hash(), false)
 		}
+		pool.dropped(TxDropUnderpriced, drop...)
 	}
-	// Try to replace an existing transaction in the pending pool
+	// Rate limit remote senders replacing their transactions
 	from, _ := types.Sender(pool.signer, tx) // already validated
+	if !isLocal && pool.config.ReplaceInterval > 0 && pool.overlaps(from, tx) {
+		if swap, ok := pool.swaps[from]; ok && time.Since(swap) < pool.config.ReplaceInterval {
+			log.Trace("Discarding too frequent replacement", "hash", hash, "from", from)
+			throttleTxMeter.Mark(1)
+			return false, ErrReplaceTooFrequent
+		}
+	}
+	// Try to replace an existing transaction in the pending pool
 	if list := pool.pending[from]; list != nil && list.Overlaps(tx) {
 		// Nonce already pending, check if required price bump is met
 		inserted, old := list.Add(tx, pool.config.PriceBump)
@@ -738,6 +1137,8 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 			pool.all.Remove(old.Hash())
 			pool.priced.Removed(1)
 			pendingReplaceMeter.Mark(1)
+			pool.swaps[from] = time.Now()
+			pool.dropped(TxDropReplaced, old)
 		}
 		pool.all.Add(tx, isLocal)
 		pool.priced.Put(tx, isLocal)
@@ -754,6 +1155,9 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 	if err != nil {
 		return false, err
 	}
+	if replaced {
+		pool.swaps[from] = time.Now()
+	}
 	// Mark local addresses and journal local transactions
 	if local && !pool.locals.contains(from) {
 		log.Info("Setting new local account", "address", from)
@@ -769,6 +1173,20 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 	return replaced, nil
 }
 
+// overlaps checks whether the given transaction would replace a pending or queued
+// transaction of the sender.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) overlaps(from common.Address, tx *types.Transaction) bool {
+	if list := pool.pending[from]; list != nil && list.Overlaps(tx) {
+		return true
+	}
+	if list := pool.queue[from]; list != nil && list.Overlaps(tx) {
+		return true
+	}
+	return false
+}
+
 // enqueueTx inserts a new transaction into the non-executable transaction queue.
 //
 // Note, this method assumes the pool lock is held!
@@ -789,6 +1207,7 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction, local boo
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		queuedReplaceMeter.Mark(1)
+		pool.dropped(TxDropReplaced, old)
 	} else {
 		// Nothing was replaced, bump the queued counter
 		queuedGauge.Inc(1)
@@ -838,6 +1257,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		pool.all.Remove(hash)
 		pool.priced.Removed(1)
 		pendingDiscardMeter.Mark(1)
+		pool.dropped(TxDropReplaced, tx)
 		return false
 	}
 	// Otherwise discard any previous transaction and mark this
@@ -845,6 +1265,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		pendingReplaceMeter.Mark(1)
+		pool.dropped(TxDropReplaced, old)
 	} else {
 		// Nothing was replaced, bump the pending counter
 		pendingGauge.Inc(1)
@@ -909,6 +1330,12 @@ func (pool *TxPool) addTxs(txs []*types.Transaction, local, sync bool) []error {
 		errs = make([]error, len(txs))
 		news = make([]*types.Transaction, 0, len(txs))
 	)
+	if atomic.LoadUint32(&pool.draining) == 1 {
+		for i := range errs {
+			errs[i] = ErrTxPoolDraining
+		}
+		return errs
+	}
 	for i, tx := range txs {
 		// If the transaction is known, pre-set the error slot
 		if pool.all.Get(tx.Hash()) != nil {
@@ -1197,7 +1624,7 @@ func (pool *TxPool) runReorg(done chan struct{}, reset *txpoolResetRequest, dirt
 	if reset != nil {
 		pool.demoteUnexecutables()
 		if reset.newHead != nil && pool.chainconfig.IsLondon(new(big.Int).Add(reset.newHead.Number, big.NewInt(1))) {
-			pendingBaseFee := misc.CalcBaseFee(pool.chainconfig, reset.newHead)
+			pendingBaseFee := misc.CalcBaseFeeFromState(pool.chainconfig, reset.newHead, pool.currentState)
 			pool.priced.SetBaseFee(pendingBaseFee)
 		}
 		// Update all accounts to the latest known pending nonce
@@ -1216,6 +1643,9 @@ func (pool *TxPool) runReorg(done chan struct{}, reset *txpoolResetRequest, dirt
 	pool.changesSinceReorg = 0 // Reset change counter
 	pool.mu.Unlock()
 
+	// Notify subsystems of evicted transactions
+	pool.announceDrops()
+
 	// Notify subsystems for newly added transactions
 	for _, tx := range promoted {
 		addr, _ := types.Sender(pool.signer, tx)
@@ -1322,6 +1752,7 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	pool.istanbul = pool.chainconfig.IsIstanbul(next)
 	pool.eip2718 = pool.chainconfig.IsBerlin(next)
 	pool.eip1559 = pool.chainconfig.IsLondon(next)
+	pool.feePayer = pool.chainconfig.IsFeePayer(next)
 }
 
 // promoteExecutables moves transactions that have become processable from the
@@ -1352,6 +1783,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 		}
 		log.Trace("Removed unpayable queued transactions", "count", len(drops))
 		queuedNofundsMeter.Mark(int64(len(drops)))
+		pool.dropped(TxDropUnpayable, drops...)
 
 		// Gather all executable transactions and promote them
 		readies := list.Ready(pool.pendingNonces.get(addr))
@@ -1367,13 +1799,18 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 		// Drop all transactions over the allowed limit
 		var caps types.Transactions
 		if !pool.locals.contains(addr) {
-			caps = list.Cap(int(pool.config.AccountQueue))
+			limit := pool.config.AccountQueue
+			if pool.gapTolerant.contains(addr) && pool.config.GapTolerance > limit {
+				limit = pool.config.GapTolerance
+			}
+			caps = list.Cap(int(limit))
 			for _, tx := range caps {
 				hash := tx.Hash()
 				pool.all.Remove(hash)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
 			}
 			queuedRateLimitMeter.Mark(int64(len(caps)))
+			pool.dropped(TxDropOverflow, caps...)
 		}
 		// Mark all the items dropped as removed
 		pool.priced.Removed(len(forwards) + len(drops) + len(caps))
@@ -1440,6 +1877,7 @@ func (pool *TxPool) truncatePending() {
 						log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
 					}
 					pool.priced.Removed(len(caps))
+					pool.dropped(TxDropOverflow, caps...)
 					pendingGauge.Dec(int64(len(caps)))
 					if pool.locals.contains(offenders[i]) {
 						localGauge.Dec(int64(len(caps)))
@@ -1467,6 +1905,7 @@ func (pool *TxPool) truncatePending() {
 					log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
 				}
 				pool.priced.Removed(len(caps))
+				pool.dropped(TxDropOverflow, caps...)
 				pendingGauge.Dec(int64(len(caps)))
 				if pool.locals.contains(addr) {
 					localGauge.Dec(int64(len(caps)))
@@ -1492,7 +1931,7 @@ func (pool *TxPool) truncateQueue() {
 	addresses := addrBeatPool.Get().(addressesByHeartbeat)
 	defer addrBeatPool.Put(addresses[:0])
 	for addr := range pool.queue {
-		if !pool.locals.contains(addr) { // don't drop locals
+		if !pool.locals.contains(addr) && !pool.gapTolerant.contains(addr) { // don't drop locals or gap tolerant senders
 			addresses = append(addresses, addressByHeartbeat{addr, pool.beats[addr]})
 		}
 	}
@@ -1507,17 +1946,20 @@ func (pool *TxPool) truncateQueue() {
 
 		// Drop all transactions if they are less than the overflow
 		if size := uint64(list.Len()); size <= drop {
-			for _, tx := range list.Flatten() {
+			txs := list.Flatten()
+			for _, tx := range txs {
 				pool.removeTx(tx.Hash(), true)
 			}
 			drop -= size
 			queuedRateLimitMeter.Mark(int64(size))
+			pool.dropped(TxDropOverflow, txs...)
 			continue
 		}
 		// Otherwise drop only last few transactions
 		txs := list.Flatten()
 		for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
 			pool.removeTx(txs[i].Hash(), true)
+			pool.dropped(TxDropOverflow, txs[i])
 			drop--
 			queuedRateLimitMeter.Mark(1)
 		}
@@ -1551,6 +1993,7 @@ func (pool *TxPool) demoteUnexecutables() {
 			pool.all.Remove(hash)
 		}
 		pendingNofundsMeter.Mark(int64(len(drops)))
+		pool.dropped(TxDropUnpayable, drops...)
 
 		for _, tx := range invalids {
 			hash := tx.Hash()
@@ -1582,6 +2025,52 @@ func (pool *TxPool) demoteUnexecutables() {
 			delete(pool.pending, addr)
 		}
 	}
+	// The sender balance checks above don't cover the fees of sponsored
+	// transactions, check their fee payers separately
+	pool.demoteUnsponsored()
+}
+
+// demoteUnsponsored drops the sponsored transactions whose fee payer can no
+// longer cover the fees of all the transactions it sponsors in the pool. The
+// transactions are kept in nonce order while the payer covers them, once one of
+// a sender is dropped its later ones are dropped too, as they'd be gapped.
+func (pool *TxPool) demoteUnsponsored() {
+	for payer, fees := range pool.all.Sponsors() {
+		balance := pool.currentState.GetBalance(payer)
+		if balance.Cmp(fees) >= 0 {
+			continue
+		}
+		var txs types.Transactions
+		pool.all.Range(func(hash common.Hash, tx *types.Transaction, local bool) bool {
+			if p := tx.FeePayer(); p != nil && *p == payer {
+				txs = append(txs, tx)
+			}
+			return true
+		}, true, true)
+		sort.Sort(types.TxByNonce(txs))
+
+		var (
+			covered = new(big.Int)
+			gapped  = make(map[common.Address]bool)
+			drops   []*types.Transaction
+		)
+		for _, tx := range txs {
+			from, _ := types.Sender(pool.signer, tx) // already validated during insertion
+			if !gapped[from] {
+				if total := new(big.Int).Add(covered, tx.FeeCost()); total.Cmp(balance) <= 0 {
+					covered = total
+					continue
+				}
+			}
+			gapped[from] = true
+			drops = append(drops, tx)
+		}
+		for _, tx := range drops {
+			log.Trace("Removed unsponsored transaction", "hash", tx.Hash(), "payer", payer)
+			pool.removeTx(tx.Hash(), true)
+		}
+		pool.dropped(TxDropUnpayable, drops...)
+	}
 }
 
 // addressByHeartbeat is an account address tagged with its last activity timestamp.
@@ -1689,17 +2178,19 @@ func (as *accountSet) merge(other *accountSet) {
 // This lookup set combines the notion of "local transactions", which is useful
 // to build upper-level structure.
 type txLookup struct {
-	slots   int
-	lock    sync.RWMutex
-	locals  map[common.Hash]*types.Transaction
-	remotes map[common.Hash]*types.Transaction
+	slots     int
+	lock      sync.RWMutex
+	locals    map[common.Hash]*types.Transaction
+	remotes   map[common.Hash]*types.Transaction
+	sponsored map[common.Address]*big.Int // Total fees of the transactions sponsored by each fee payer
 }
 
 // newTxLookup returns a new txLookup structure.
 func newTxLookup() *txLookup {
 	return &txLookup{
-		locals:  make(map[common.Hash]*types.Transaction),
-		remotes: make(map[common.Hash]*types.Transaction),
+		locals:    make(map[common.Hash]*types.Transaction),
+		remotes:   make(map[common.Hash]*types.Transaction),
+		sponsored: make(map[common.Address]*big.Int),
 	}
 }
 
@@ -1798,6 +2289,37 @@ func (t *txLookup) Add(tx *types.Transaction, local bool) {
 	} else {
 		t.remotes[tx.Hash()] = tx
 	}
+	if payer := tx.FeePayer(); payer != nil {
+		if t.sponsored[*payer] == nil {
+			t.sponsored[*payer] = new(big.Int)
+		}
+		t.sponsored[*payer].Add(t.sponsored[*payer], tx.FeeCost())
+	}
+}
+
+// Sponsored returns the total fees of the transactions in the lookup sponsored
+// by the given fee payer.
+func (t *txLookup) Sponsored(payer common.Address) *big.Int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if fees := t.sponsored[payer]; fees != nil {
+		return new(big.Int).Set(fees)
+	}
+	return new(big.Int)
+}
+
+// Sponsors returns the total fees of the transactions in the lookup sponsored by
+// each fee payer.
+func (t *txLookup) Sponsors() map[common.Address]*big.Int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	sponsors := make(map[common.Address]*big.Int, len(t.sponsored))
+	for payer, fees := range t.sponsored {
+		sponsors[payer] = new(big.Int).Set(fees)
+	}
+	return sponsors
 }
 
 // Remove removes a transaction from the lookup.
@@ -1818,6 +2340,14 @@ func (t *txLookup) Remove(hash common.Hash) {
 
 	delete(t.locals, hash)
 	delete(t.remotes, hash)
+
+	if payer := tx.FeePayer(); payer != nil {
+		if fees := t.sponsored[*payer]; fees != nil {
+			if fees.Sub(fees, tx.FeeCost()).Sign() <= 0 {
+				delete(t.sponsored, *payer)
+			}
+		}
+	}
 }
 
 // RemoteToLocals migrates the transactions belongs to the given locals to locals
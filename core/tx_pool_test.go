@@ -24,6 +24,8 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"path/filepath"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -414,6 +416,181 @@ func TestTransactionVeryHighValues(t *testing.T) {
 	}
 }
 
+func TestTransactionFeePayer(t *testing.T) {
+	t.Parallel()
+
+	payerKey, _ := crypto.GenerateKey()
+	payer := crypto.PubkeyToAddress(payerKey.PublicKey)
+
+	feePayerTx := func(nonce uint64, key *ecdsa.PrivateKey, sponsored bool) *types.Transaction {
+		signer := types.NewFeePayerSigner(params.TestChainConfig.ChainID)
+		tx, _ := types.SignNewTx(key, signer, &types.FeePayerTx{
+			ChainID:   params.TestChainConfig.ChainID,
+			Nonce:     nonce,
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(1),
+			Gas:       params.TxGas,
+			To:        &common.Address{},
+			Value:     big.NewInt(100),
+			FeePayer:  payer,
+		})
+		if sponsored {
+			tx, _ = types.SignFeePayer(tx, signer, payerKey)
+		}
+		return tx
+	}
+	// Fee payer transactions are rejected before the fork
+	pool, key := setupTxPoolWithConfig(eip1559Config)
+	defer pool.Stop()
+
+	if err := pool.AddRemote(feePayerTx(0, key, true)); err == nil {
+		t.Error("fee payer transaction accepted before the fork")
+	}
+	config := *eip1559Config
+	config.FeePayerBlock = common.Big0
+	pool, key = setupTxPoolWithConfig(&config)
+	defer pool.Stop()
+
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, from, big.NewInt(100))
+
+	// The fee payer must have signed
+	if err := pool.AddRemote(feePayerTx(0, key, false)); err != ErrInvalidFeePayer {
+		t.Error("expected", ErrInvalidFeePayer, "got", err)
+	}
+	// The fee payer must be able to pay for the gas, the sender doesn't have to
+	if err := pool.AddRemote(feePayerTx(0, key, true)); err != ErrInsufficientFunds {
+		t.Error("expected", ErrInsufficientFunds, "got", err)
+	}
+	testAddBalance(pool, payer, big.NewInt(int64(params.TxGas)))
+	if err := pool.AddRemote(feePayerTx(0, key, true)); err != nil {
+		t.Error("expected nil, got", err)
+	}
+	// The fees of the pooled transactions the payer already sponsors count too
+	other, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(other.PublicKey), big.NewInt(100))
+
+	if err := pool.AddRemote(feePayerTx(0, other, true)); err != ErrFeePayerOvercommitted {
+		t.Error("expected", ErrFeePayerOvercommitted, "got", err)
+	}
+	if fees := pool.all.Sponsored(payer); fees.Uint64() != params.TxGas {
+		t.Errorf("sponsored fees mismatch: have %v, want %v", fees, params.TxGas)
+	}
+	testAddBalance(pool, payer, big.NewInt(int64(params.TxGas)))
+	if err := pool.AddRemote(feePayerTx(0, other, true)); err != nil {
+		t.Error("expected nil, got", err)
+	}
+	// Dropping a sponsored transaction releases its fees
+	pool.removeTx(feePayerTx(0, key, true).Hash(), true)
+	if fees := pool.all.Sponsored(payer); fees.Uint64() != params.TxGas {
+		t.Errorf("sponsored fees mismatch: have %v, want %v", fees, params.TxGas)
+	}
+}
+
+// Tests that sponsored transactions are dropped on reset once their fee payer
+// can no longer cover the fees of all the transactions it sponsors.
+// Tests that the pending lists shared by the pool are left alone by later
+// changes to the pool.
+func TestTransactionPendingLists(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, from, big.NewInt(1000000))
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		if err := pool.addRemoteSync(transaction(nonce, 100000, key)); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", nonce, err)
+		}
+	}
+	lists := pool.PendingLists()
+	if len(lists[from]) != 3 {
+		t.Fatalf("pending list length mismatch: have %d, want %d", len(lists[from]), 3)
+	}
+	want := append(types.Transactions(nil), lists[from]...)
+
+	// Replace the middle transaction and add another one
+	if err := pool.addRemoteSync(pricedTransaction(1, 100000, big.NewInt(2), key)); err != nil {
+		t.Fatalf("failed to replace transaction: %v", err)
+	}
+	if err := pool.addRemoteSync(transaction(3, 100000, key)); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	for i, tx := range lists[from] {
+		if tx != want[i] {
+			t.Errorf("shared transaction %d changed", i)
+		}
+	}
+	if have := pool.PendingLists()[from]; len(have) != 4 || have[1].GasPrice().Cmp(big.NewInt(2)) != 0 {
+		t.Errorf("pending list not updated")
+	}
+}
+
+func TestTransactionFeePayerDemotion(t *testing.T) {
+	t.Parallel()
+
+	payerKey, _ := crypto.GenerateKey()
+	payer := crypto.PubkeyToAddress(payerKey.PublicKey)
+
+	config := *eip1559Config
+	config.FeePayerBlock = common.Big0
+	pool, key := setupTxPoolWithConfig(&config)
+	defer pool.Stop()
+
+	signer := types.NewFeePayerSigner(params.TestChainConfig.ChainID)
+	sponsored := func(nonce uint64) *types.Transaction {
+		tx, _ := types.SignNewTx(key, signer, &types.FeePayerTx{
+			ChainID:   params.TestChainConfig.ChainID,
+			Nonce:     nonce,
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(1),
+			Gas:       params.TxGas,
+			To:        &common.Address{},
+			Value:     big.NewInt(100),
+			FeePayer:  payer,
+		})
+		tx, _ = types.SignFeePayer(tx, signer, payerKey)
+		return tx
+	}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, from, big.NewInt(1000))
+	testAddBalance(pool, payer, big.NewInt(int64(3*params.TxGas)))
+
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		if err := pool.addRemoteSync(sponsored(nonce)); err != nil {
+			t.Fatalf("failed to add sponsored transaction %d: %v", nonce, err)
+		}
+	}
+	if pending, _ := pool.Stats(); pending != 3 {
+		t.Fatalf("pending transactions mismatch: have %d, want %d", pending, 3)
+	}
+	// The payer only covers the first transaction after its balance dropped,
+	// the third one is dropped too as the second one leaves a gap
+	testAddBalance(pool, payer, big.NewInt(-int64(params.TxGas)-1))
+	<-pool.requestReset(nil, nil)
+
+	if pending, queued := pool.Stats(); pending != 1 || queued != 0 {
+		t.Fatalf("pool mismatch: have %d pending %d queued, want 1 pending", pending, queued)
+	}
+	if pool.all.Get(sponsored(0).Hash()) == nil {
+		t.Errorf("covered sponsored transaction dropped")
+	}
+	if fees := pool.all.Sponsored(payer); fees.Uint64() != params.TxGas {
+		t.Errorf("sponsored fees mismatch: have %v, want %v", fees, params.TxGas)
+	}
+	// Once the payer can't cover any fees, all its transactions are dropped
+	testAddBalance(pool, payer, big.NewInt(-int64(params.TxGas)))
+	<-pool.requestReset(nil, nil)
+
+	if count := pool.all.Count(); count != 0 {
+		t.Errorf("sponsored transactions left: %d", count)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 func TestTransactionChainFork(t *testing.T) {
 	t.Parallel()
 
@@ -848,6 +1025,208 @@ func TestTransactionQueueAccountLimiting(t *testing.T) {
 	}
 }
 
+// Tests that remote senders can't replace their transactions more often than
+// the pool policy allows, and that the policy can be updated at runtime.
+func TestTransactionReplaceInterval(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{1000000, statedb, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.ReplaceInterval = time.Hour
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	key, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	// The first replacement is allowed, the second one within the interval isn't
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(100), key)); err != nil {
+		t.Fatalf("failed to add original transaction: %v", err)
+	}
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(200), key)); err != nil {
+		t.Fatalf("failed to replace original transaction: %v", err)
+	}
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(400), key)); err != ErrReplaceTooFrequent {
+		t.Fatalf("frequent replacement error mismatch: have %v, want %v", err, ErrReplaceTooFrequent)
+	}
+	// Lift the rate limit but require a higher price bump
+	if err := pool.SetPolicy(TxPoolPolicy{PriceLimit: 1, PriceBump: 100}); err != nil {
+		t.Fatalf("failed to update pool policy: %v", err)
+	}
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(300), key)); err != ErrReplaceUnderpriced {
+		t.Fatalf("underpriced replacement error mismatch: have %v, want %v", err, ErrReplaceUnderpriced)
+	}
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(400), key)); err != nil {
+		t.Fatalf("failed to replace transaction after policy update: %v", err)
+	}
+	if err := pool.SetPolicy(TxPoolPolicy{PriceLimit: 1}); err == nil {
+		t.Fatalf("invalid policy accepted")
+	}
+}
+
+// Tests that policy updates are applied atomically: concurrent partial updates
+// don't overwrite each other and rejected ones leave the policy untouched.
+func TestTransactionPolicyUpdate(t *testing.T) {
+	t.Parallel()
+
+	pool, _ := setupTxPool()
+	defer pool.Stop()
+
+	initial := pool.Policy()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pool.UpdatePolicy(func(policy *TxPoolPolicy) error {
+				policy.PriceBump++
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	if have, want := pool.Policy().PriceBump, initial.PriceBump+16; have != want {
+		t.Fatalf("price bump mismatch: have %d, want %d", have, want)
+	}
+	if _, err := pool.UpdatePolicy(func(policy *TxPoolPolicy) error {
+		policy.PriceLimit, policy.PriceBump = 2*initial.PriceLimit, 0
+		return nil
+	}); err == nil {
+		t.Fatalf("invalid policy accepted")
+	}
+	if have := pool.Policy(); have.PriceLimit != initial.PriceLimit || have.PriceBump != initial.PriceBump+16 {
+		t.Fatalf("rejected update applied: %+v", have)
+	}
+}
+
+// Tests that the content of a pool can be exported and imported into another
+// one, keeping the local flags and the times the transactions were first seen.
+func TestTransactionPoolExportImport(t *testing.T) {
+	t.Parallel()
+
+	pool, local := setupTxPool()
+	defer pool.Stop()
+
+	remote, _ := crypto.GenerateKey()
+	for _, key := range []*ecdsa.PrivateKey{local, remote} {
+		testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	}
+	for _, err := range pool.AddLocals([]*types.Transaction{transaction(0, 100000, local), transaction(1, 100000, local), transaction(3, 100000, local)}) {
+		if err != nil {
+			t.Fatalf("failed to add local transaction: %v", err)
+		}
+	}
+	if err := pool.addRemoteSync(transaction(0, 100000, remote)); err != nil {
+		t.Fatalf("failed to add remote transaction: %v", err)
+	}
+	entries := pool.Export()
+	if len(entries) != 4 {
+		t.Fatalf("exported transaction count mismatch: have %d, want 4", len(entries))
+	}
+	for i, entry := range entries {
+		local := entry.From == crypto.PubkeyToAddress(local.PublicKey)
+		if entry.Local != local || entry.Pending != (entry.Tx.Nonce() != 3) {
+			t.Errorf("entry %d: flags mismatch: local %v pending %v", i, entry.Local, entry.Pending)
+		}
+		if i > 0 && entries[i-1].From == entry.From && entries[i-1].Tx.Nonce() > entry.Tx.Nonce() {
+			t.Errorf("entry %d: not sorted by nonce", i)
+		}
+	}
+	// Import the content into a fresh pool, as another node would
+	other, _ := setupTxPool()
+	defer other.Stop()
+
+	for _, key := range []*ecdsa.PrivateKey{local, remote} {
+		testAddBalance(other, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	}
+	seen := time.Now().Add(-time.Hour)
+	for _, entry := range entries {
+		entry.Time = seen
+	}
+	for i, err := range other.Import(entries) {
+		if err != nil {
+			t.Fatalf("entry %d: failed to import: %v", i, err)
+		}
+	}
+	if pending, queued := other.Stats(); pending != 3 || queued != 1 {
+		t.Fatalf("imported pool mismatch: pending %d, queued %d, want 3, 1", pending, queued)
+	}
+	if locals := other.Locals(); len(locals) != 1 || locals[0] != crypto.PubkeyToAddress(local.PublicKey) {
+		t.Errorf("imported locals mismatch: %v", locals)
+	}
+	for _, entry := range other.Export() {
+		if !entry.Time.Equal(seen) {
+			t.Errorf("tx %x: first seen time not kept: have %v, want %v", entry.Tx.Hash(), entry.Time, seen)
+		}
+	}
+	if err := validateTxPoolInternals(other); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// Known transactions and forged senders are rejected
+	entries[0].From = common.Address{0x01}
+	errs := other.Import(entries)
+	if errs[0] == nil || errors.Is(errs[0], ErrAlreadyKnown) {
+		t.Errorf("forged sender error mismatch: have %v", errs[0])
+	}
+	for i, err := range errs[1:] {
+		if !errors.Is(err, ErrAlreadyKnown) {
+			t.Errorf("entry %d: reimport error mismatch: have %v, want %v", i+1, err, ErrAlreadyKnown)
+		}
+	}
+}
+
+// Tests that transactions of gap tolerant senders are held beyond the account
+// queue limit, as long as they are within the configured nonce gap, and that
+// they get promoted once the gap is filled.
+func TestTransactionGapTolerance(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{1000000, statedb, new(event.Feed)}
+
+	key, _ := crypto.GenerateKey()
+	account := crypto.PubkeyToAddress(key.PublicKey)
+
+	config := testTxPoolConfig
+	config.AccountQueue = 4
+	config.GapTolerant = []common.Address{account}
+	config.GapTolerance = 8
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	testAddBalance(pool, account, big.NewInt(1000000000))
+
+	// Transactions beyond the allowed gap should be rejected
+	if err := pool.addRemoteSync(transaction(9, 100000, key)); !errors.Is(err, ErrNonceGapTooLarge) {
+		t.Fatalf("far future transaction error mismatch: have %v, want %v", err, ErrNonceGapTooLarge)
+	}
+	// Transactions within the gap should be held beyond the account queue limit
+	for i := uint64(8); i > 0; i-- {
+		if err := pool.addRemoteSync(transaction(i, 100000, key)); err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	if pending, queued := pool.Stats(); pending != 0 || queued != 8 {
+		t.Fatalf("pool size mismatch: have %d/%d, want %d/%d", pending, queued, 0, 8)
+	}
+	// Filling the gap should promote everything
+	if err := pool.addRemoteSync(transaction(0, 100000, key)); err != nil {
+		t.Fatalf("failed to add gap filling transaction: %v", err)
+	}
+	if pending, queued := pool.Stats(); pending != 9 || queued != 0 {
+		t.Fatalf("pool size mismatch: have %d/%d, want %d/%d", pending, queued, 9, 0)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // Tests that if the transaction count belonging to multiple accounts go above
 // some threshold, the higher transactions are dropped to prevent DOS attacks.
 //
@@ -1093,6 +1472,78 @@ func testTransactionQueueTimeLimiting(t *testing.T, nolocals bool) {
 	}
 }
 
+// Tests that evicted transactions are announced to subscribers with the reason
+// of the eviction, and that non-local transactions are dropped after the max
+// lifetime even if executable.
+func TestTransactionDropEvents(t *testing.T) {
+	// Reduce the eviction interval to a testable amount
+	defer func(old time.Duration) { evictionInterval = old }(evictionInterval)
+	evictionInterval = time.Millisecond * 100
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{1000000, statedb, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.MaxLifetime = time.Second
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	drops := make(chan DroppedTxsEvent, 16)
+	sub := pool.SubscribeDroppedTxsEvent(drops)
+	defer sub.Unsubscribe()
+
+	local, _ := crypto.GenerateKey()
+	remote, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000000))
+	testAddBalance(pool, crypto.PubkeyToAddress(remote.PublicKey), big.NewInt(1000000000))
+
+	expect := func(reason TxDropReason, tx *types.Transaction) {
+		t.Helper()
+		select {
+		case ev := <-drops:
+			if ev.Reason != reason {
+				t.Fatalf("drop reason mismatch: have %v, want %v", ev.Reason, reason)
+			}
+			if len(ev.Txs) != 1 || ev.Txs[0].Hash() != tx.Hash() {
+				t.Fatalf("dropped transactions mismatch: have %d, want %x", len(ev.Txs), tx.Hash())
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("no %v drop event", reason)
+		}
+	}
+	// Replace an executable remote transaction, the original is dropped
+	original := pricedTransaction(0, 100000, big.NewInt(1), remote)
+	if err := pool.addRemoteSync(original); err != nil {
+		t.Fatalf("failed to add original transaction: %v", err)
+	}
+	replacement := pricedTransaction(0, 100000, big.NewInt(2), remote)
+	if err := pool.addRemoteSync(replacement); err != nil {
+		t.Fatalf("failed to add replacement transaction: %v", err)
+	}
+	expect(TxDropReplaced, original)
+	if reason, ok := pool.DropReason(original.Hash()); !ok || reason != TxDropReplaced {
+		t.Fatalf("drop reason mismatch: have %v (known %v), want %v", reason, ok, TxDropReplaced)
+	}
+
+	// Exceed the max lifetime, the remote is dropped but not the local
+	if err := pool.AddLocal(pricedTransaction(0, 100000, big.NewInt(1), local)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	expect(TxDropLifetime, replacement)
+
+	pending, queued := pool.Stats()
+	if pending != 1 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 1)
+	}
+	if queued != 0 {
+		t.Fatalf("queued transactions mismatched: have %d, want %d", queued, 0)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // Tests that even if the transaction count belonging to a single account goes
 // above some threshold, as long as the transactions are executable, they are
 // accepted.
@@ -2561,3 +3012,51 @@ func BenchmarkPoolMultiAccountBatchInsert(b *testing.B) {
 		pool.AddRemotesSync([]*types.Transaction{tx})
 	}
 }
+
+// Tests that a drained pool rejects new transactions, but keeps the pooled ones
+// and journals them when stopped.
+func TestTransactionPoolDrain(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{1000000, statedb, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.Journal = filepath.Join(dir, "transactions.rlp")
+	config.Rejournal = time.Hour
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	key, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	if err := pool.AddLocal(pricedTransaction(0, 100000, big.NewInt(1), key)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	pool.Drain()
+
+	if err := pool.AddLocal(pricedTransaction(1, 100000, big.NewInt(1), key)); err != ErrTxPoolDraining {
+		t.Fatalf("local transaction error mismatch: have %v, want %v", err, ErrTxPoolDraining)
+	}
+	if err := pool.addRemoteSync(pricedTransaction(1, 100000, big.NewInt(1), key)); err != ErrTxPoolDraining {
+		t.Fatalf("remote transaction error mismatch: have %v, want %v", err, ErrTxPoolDraining)
+	}
+	if pending, _ := pool.Stats(); pending != 1 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 1)
+	}
+	pool.Stop()
+
+	// Restart the pool and ensure the journaled transaction survived
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	if pending, _ := pool.Stats(); pending != 1 {
+		t.Fatalf("pending transactions mismatched after restart: have %d, want %d", pending, 1)
+	}
+}
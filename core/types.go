@@ -30,7 +30,7 @@ type Validator interface {
 	ValidateBody(block *types.Block) error
 
 	// ValidateState validates the given statedb and optionally the receipts and
-	// gas used.
+	// gas used. A nil statedb skips the state root validation.
 	ValidateState(block *types.Block, state *state.StateDB, receipts types.Receipts, usedGas uint64) error
 }
 
@@ -40,6 +40,11 @@ type Prefetcher interface {
 	// the transaction messages using the statedb, but any changes are discarded. The
 	// only goal is to pre-cache transaction signatures and state trie nodes.
 	Prefetch(block *types.Block, statedb *state.StateDB, cfg vm.Config, interrupt *uint32)
+
+	// PrefetchParallel speculatively runs the given transactions on copies of the
+	// statedb using multiple goroutines, discarding all changes. The goal is to
+	// warm the trie node and code caches ahead of the serial execution.
+	PrefetchParallel(header *types.Header, txs types.Transactions, statedb *state.StateDB, cfg vm.Config, workers int, interrupt *uint32)
 }
 
 // Processor is an interface for processing blocks using a given initial state.
@@ -0,0 +1,93 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+)
+
+// CallTrace is a single call frame of a transaction, along with all the nested
+// call frames it spawned. It is the compact representation of a call trace that
+// is persisted in the trace index.
+type CallTrace struct {
+	Type    string
+	From    common.Address
+	To      common.Address
+	Value   *big.Int
+	Gas     uint64
+	GasUsed uint64
+	Input   []byte
+	Output  []byte
+	Error   string
+	Calls   []*CallTrace
+}
+
+// callTraceJSON is the JSON representation of a call frame, matching the output
+// format of the native callTracer.
+type callTraceJSON struct {
+	Type    string         `json:"type"`
+	From    common.Address `json:"from"`
+	To      common.Address `json:"to,omitempty"`
+	Value   *hexutil.Big   `json:"value,omitempty"`
+	Gas     hexutil.Uint64 `json:"gas"`
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+	Input   hexutil.Bytes  `json:"input"`
+	Output  hexutil.Bytes  `json:"output,omitempty"`
+	Error   string         `json:"error,omitempty"`
+	Calls   []*CallTrace   `json:"calls,omitempty"`
+}
+
+// MarshalJSON marshals as JSON.
+func (c *CallTrace) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&callTraceJSON{
+		Type:    c.Type,
+		From:    c.From,
+		To:      c.To,
+		Value:   (*hexutil.Big)(c.Value),
+		Gas:     hexutil.Uint64(c.Gas),
+		GasUsed: hexutil.Uint64(c.GasUsed),
+		Input:   c.Input,
+		Output:  c.Output,
+		Error:   c.Error,
+		Calls:   c.Calls,
+	})
+}
+
+// UnmarshalJSON unmarshals from JSON.
+func (c *CallTrace) UnmarshalJSON(input []byte) error {
+	var dec callTraceJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	*c = CallTrace{
+		Type:    dec.Type,
+		From:    dec.From,
+		To:      dec.To,
+		Value:   (*big.Int)(dec.Value),
+		Gas:     uint64(dec.Gas),
+		GasUsed: uint64(dec.GasUsed),
+		Input:   dec.Input,
+		Output:  dec.Output,
+		Error:   dec.Error,
+		Calls:   dec.Calls,
+	}
+	return nil
+}
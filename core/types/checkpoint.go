@@ -0,0 +1,100 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/go-ethereum/crypto"
+)
+
+var (
+	// ErrCheckpointGenesis is returned if a checkpoint bundle is for another chain.
+	ErrCheckpointGenesis = errors.New("checkpoint bundle of another chain")
+
+	// ErrCheckpointSigner is returned if a checkpoint bundle isn't signed by the
+	// expected signer.
+	ErrCheckpointSigner = errors.New("checkpoint bundle of unknown signer")
+)
+
+// HeaderCheckpoint pins a block of the canonical chain for header verification.
+type HeaderCheckpoint struct {
+	Number     uint64      `json:"number"`
+	Hash       common.Hash `json:"hash"`
+	Root       common.Hash `json:"root"`       // State root of the block
+	BatchIndex uint64      `json:"batchIndex"` // Index of the batch committing the block, zero if not tracked
+}
+
+// CheckpointBundle is a set of header checkpoints of a chain, signed by the
+// checkpoint signer of the network.
+type CheckpointBundle struct {
+	Genesis     common.Hash         `json:"genesis"`
+	Checkpoints []*HeaderCheckpoint `json:"checkpoints"`
+	Signature   hexutil.Bytes       `json:"signature"`
+}
+
+// SigHash returns the hash the checkpoint signer signs.
+func (b *CheckpointBundle) SigHash() common.Hash {
+	return rlpHash([]interface{}{b.Genesis, b.Checkpoints})
+}
+
+// Sign signs the bundle with the given key.
+func (b *CheckpointBundle) Sign(prv *ecdsa.PrivateKey) error {
+	sig, err := crypto.Sign(b.SigHash().Bytes(), prv)
+	if err != nil {
+		return err
+	}
+	b.Signature = sig
+	return nil
+}
+
+// Signer returns the address of the key that signed the bundle.
+func (b *CheckpointBundle) Signer() (common.Address, error) {
+	if len(b.Signature) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("invalid checkpoint signature length %d", len(b.Signature))
+	}
+	pub, err := crypto.SigToPub(b.SigHash().Bytes(), b.Signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// Verify checks that the bundle is for the chain with the given genesis, is
+// signed by the given signer and lists its checkpoints in ascending order.
+func (b *CheckpointBundle) Verify(genesis common.Hash, signer common.Address) error {
+	if b.Genesis != genesis {
+		return fmt.Errorf("%w: have genesis %x, want %x", ErrCheckpointGenesis, b.Genesis, genesis)
+	}
+	have, err := b.Signer()
+	if err != nil {
+		return err
+	}
+	if have != signer {
+		return fmt.Errorf("%w: have %x, want %x", ErrCheckpointSigner, have, signer)
+	}
+	for i, cp := range b.Checkpoints {
+		if i > 0 && b.Checkpoints[i-1].Number >= cp.Number {
+			return fmt.Errorf("checkpoint %d out of order: number %d after %d", i, cp.Number, b.Checkpoints[i-1].Number)
+		}
+	}
+	return nil
+}
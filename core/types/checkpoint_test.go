@@ -0,0 +1,77 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/crypto"
+)
+
+func TestCheckpointBundle(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+	genesis := common.HexToHash("0x01")
+
+	bundle := &CheckpointBundle{
+		Genesis: genesis,
+		Checkpoints: []*HeaderCheckpoint{
+			{Number: 100, Hash: common.HexToHash("0xa1"), Root: common.HexToHash("0xb1"), BatchIndex: 1},
+			{Number: 200, Hash: common.HexToHash("0xa2"), Root: common.HexToHash("0xb2"), BatchIndex: 2},
+		},
+	}
+	if err := bundle.Sign(key); err != nil {
+		t.Fatalf("failed to sign bundle: %v", err)
+	}
+	if err := bundle.Verify(genesis, signer); err != nil {
+		t.Fatalf("failed to verify bundle: %v", err)
+	}
+	// The bundle must survive a JSON round trip
+	blob, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatalf("failed to encode bundle: %v", err)
+	}
+	decoded := new(CheckpointBundle)
+	if err := json.Unmarshal(blob, decoded); err != nil {
+		t.Fatalf("failed to decode bundle: %v", err)
+	}
+	if err := decoded.Verify(genesis, signer); err != nil {
+		t.Fatalf("failed to verify decoded bundle: %v", err)
+	}
+	if err := bundle.Verify(common.HexToHash("0x02"), signer); !errors.Is(err, ErrCheckpointGenesis) {
+		t.Errorf("genesis mismatch: have %v, want %v", err, ErrCheckpointGenesis)
+	}
+	if err := bundle.Verify(genesis, common.HexToAddress("0x02")); !errors.Is(err, ErrCheckpointSigner) {
+		t.Errorf("signer mismatch: have %v, want %v", err, ErrCheckpointSigner)
+	}
+	// Tampering with a checkpoint invalidates the signature
+	decoded.Checkpoints[1].Hash = common.HexToHash("0xa3")
+	if err := decoded.Verify(genesis, signer); !errors.Is(err, ErrCheckpointSigner) {
+		t.Errorf("tampered bundle: have %v, want %v", err, ErrCheckpointSigner)
+	}
+	// Checkpoints must be in ascending order
+	bundle.Checkpoints[0], bundle.Checkpoints[1] = bundle.Checkpoints[1], bundle.Checkpoints[0]
+	if err := bundle.Sign(key); err != nil {
+		t.Fatalf("failed to sign bundle: %v", err)
+	}
+	if err := bundle.Verify(genesis, signer); err == nil {
+		t.Error("unordered bundle verified")
+	}
+}
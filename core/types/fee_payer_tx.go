@@ -0,0 +1,129 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+
+	"github.com/scroll-tech/go-ethereum/common"
+)
+
+// FeePayerTx is a dynamic fee transaction whose gas is paid by a separate fee
+// payer account. The sender signs the transaction including the address of the
+// fee payer, the fee payer then signs over the sender's signature.
+type FeePayerTx struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	Gas        uint64
+	To         *common.Address `rlp:"nil"` // nil means contract creation
+	Value      *big.Int
+	Data       []byte
+	AccessList AccessList
+	FeePayer   common.Address
+
+	// Signature values of the sender
+	V *big.Int `json:"v" gencodec:"required"`
+	R *big.Int `json:"r" gencodec:"required"`
+	S *big.Int `json:"s" gencodec:"required"`
+
+	// Signature values of the fee payer
+	FeePayerV *big.Int `json:"feePayerV" gencodec:"required"`
+	FeePayerR *big.Int `json:"feePayerR" gencodec:"required"`
+	FeePayerS *big.Int `json:"feePayerS" gencodec:"required"`
+}
+
+// copy creates a deep copy of the transaction data and initializes all fields.
+func (tx *FeePayerTx) copy() TxData {
+	cpy := &FeePayerTx{
+		Nonce:    tx.Nonce,
+		To:       copyAddressPtr(tx.To),
+		Data:     common.CopyBytes(tx.Data),
+		Gas:      tx.Gas,
+		FeePayer: tx.FeePayer,
+		// These are copied below.
+		AccessList: make(AccessList, len(tx.AccessList)),
+		Value:      new(big.Int),
+		ChainID:    new(big.Int),
+		GasTipCap:  new(big.Int),
+		GasFeeCap:  new(big.Int),
+		V:          new(big.Int),
+		R:          new(big.Int),
+		S:          new(big.Int),
+		FeePayerV:  new(big.Int),
+		FeePayerR:  new(big.Int),
+		FeePayerS:  new(big.Int),
+	}
+	copy(cpy.AccessList, tx.AccessList)
+	if tx.Value != nil {
+		cpy.Value.Set(tx.Value)
+	}
+	if tx.ChainID != nil {
+		cpy.ChainID.Set(tx.ChainID)
+	}
+	if tx.GasTipCap != nil {
+		cpy.GasTipCap.Set(tx.GasTipCap)
+	}
+	if tx.GasFeeCap != nil {
+		cpy.GasFeeCap.Set(tx.GasFeeCap)
+	}
+	if tx.V != nil {
+		cpy.V.Set(tx.V)
+	}
+	if tx.R != nil {
+		cpy.R.Set(tx.R)
+	}
+	if tx.S != nil {
+		cpy.S.Set(tx.S)
+	}
+	if tx.FeePayerV != nil {
+		cpy.FeePayerV.Set(tx.FeePayerV)
+	}
+	if tx.FeePayerR != nil {
+		cpy.FeePayerR.Set(tx.FeePayerR)
+	}
+	if tx.FeePayerS != nil {
+		cpy.FeePayerS.Set(tx.FeePayerS)
+	}
+	return cpy
+}
+
+// accessors for innerTx.
+func (tx *FeePayerTx) txType() byte           { return FeePayerTxType }
+func (tx *FeePayerTx) chainID() *big.Int      { return tx.ChainID }
+func (tx *FeePayerTx) accessList() AccessList { return tx.AccessList }
+func (tx *FeePayerTx) data() []byte           { return tx.Data }
+func (tx *FeePayerTx) gas() uint64            { return tx.Gas }
+func (tx *FeePayerTx) gasFeeCap() *big.Int    { return tx.GasFeeCap }
+func (tx *FeePayerTx) gasTipCap() *big.Int    { return tx.GasTipCap }
+func (tx *FeePayerTx) gasPrice() *big.Int     { return tx.GasFeeCap }
+func (tx *FeePayerTx) value() *big.Int        { return tx.Value }
+func (tx *FeePayerTx) nonce() uint64          { return tx.Nonce }
+func (tx *FeePayerTx) to() *common.Address    { return tx.To }
+
+func (tx *FeePayerTx) rawSignatureValues() (v, r, s *big.Int) {
+	return tx.V, tx.R, tx.S
+}
+
+func (tx *FeePayerTx) setSignatureValues(chainID, v, r, s *big.Int) {
+	tx.ChainID, tx.V, tx.R, tx.S = chainID, v, r, s
+}
+
+func (tx *FeePayerTx) setFeePayerSignatureValues(v, r, s *big.Int) {
+	tx.FeePayerV, tx.FeePayerR, tx.FeePayerS = v, r, s
+}
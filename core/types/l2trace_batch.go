@@ -0,0 +1,150 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/go-ethereum/crypto"
+)
+
+// BatchWitness is the witness of a batch of consecutive blocks, with the trie
+// nodes and contract codes shared across the blocks included only once. The
+// witness of a block is the union of its own delta and the deltas of the
+// blocks before it in the batch.
+type BatchWitness struct {
+	Blocks []*BlockWitness `json:"blocks"`
+}
+
+// BlockWitness is the delta of a batch witness contributed by a single block.
+type BlockWitness struct {
+	Hash       common.Hash `json:"hash"`
+	RootBefore common.Hash `json:"rootBefore"`
+	RootAfter  common.Hash `json:"rootAfter"`
+
+	// Accounts and storage slots proven for the block, keyed the same way as in
+	// the storage trace
+	Accounts []string            `json:"accounts"`
+	Storage  map[string][]string `json:"storage,omitempty"`
+
+	Nodes []hexutil.Bytes `json:"nodes"` // Trie nodes not in the witnesses of earlier blocks
+	Codes []hexutil.Bytes `json:"codes"` // Contract codes not in the witnesses of earlier blocks
+}
+
+// BatchWitnessBuilder assembles a batch witness from the block results of
+// consecutive blocks, deduplicating the trie nodes and contract codes.
+type BatchWitnessBuilder struct {
+	nodes   map[string]struct{}
+	codes   map[common.Hash]struct{}
+	witness BatchWitness
+}
+
+// NewBatchWitnessBuilder creates a builder for an empty batch.
+func NewBatchWitnessBuilder() *BatchWitnessBuilder {
+	return &BatchWitnessBuilder{
+		nodes: make(map[string]struct{}),
+		codes: make(map[common.Hash]struct{}),
+	}
+}
+
+// Add appends the witness of the next block in the batch, returning the delta
+// the block contributes. The block must build on the state of the previous one.
+func (b *BatchWitnessBuilder) Add(result *BlockResult) (*BlockWitness, error) {
+	if result.BlockTrace == nil || result.StorageTrace == nil {
+		return nil, fmt.Errorf("block result without block or storage trace")
+	}
+	trace := result.StorageTrace
+	if n := len(b.witness.Blocks); n > 0 && b.witness.Blocks[n-1].RootAfter != trace.RootBefore {
+		return nil, fmt.Errorf("block %x doesn't build on the batch state: have root %x, want %x",
+			result.BlockTrace.Hash, trace.RootBefore, b.witness.Blocks[n-1].RootAfter)
+	}
+	delta := &BlockWitness{
+		Hash:       result.BlockTrace.Hash,
+		RootBefore: trace.RootBefore,
+		RootAfter:  trace.RootAfter,
+		Accounts:   make([]string, 0, len(trace.Proofs)),
+		Nodes:      []hexutil.Bytes{},
+		Codes:      []hexutil.Bytes{},
+	}
+	for addr := range trace.Proofs {
+		delta.Accounts = append(delta.Accounts, addr)
+	}
+	sort.Strings(delta.Accounts)
+	for _, addr := range delta.Accounts {
+		b.addNodes(delta, trace.Proofs[addr])
+	}
+	if len(trace.StorageProofs) > 0 {
+		delta.Storage = make(map[string][]string, len(trace.StorageProofs))
+	}
+	addrs := make([]string, 0, len(trace.StorageProofs))
+	for addr := range trace.StorageProofs {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	for _, addr := range addrs {
+		keys := make([]string, 0, len(trace.StorageProofs[addr]))
+		for key := range trace.StorageProofs[addr] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		delta.Storage[addr] = keys
+		for _, key := range keys {
+			b.addNodes(delta, trace.StorageProofs[addr][key])
+		}
+	}
+	for _, res := range result.ExecutionResults {
+		b.addCode(delta, res.ByteCode)
+		for _, structLog := range res.StructLogs {
+			if structLog.ExtraData != nil {
+				for _, code := range structLog.ExtraData.CodeList {
+					b.addCode(delta, code)
+				}
+			}
+		}
+	}
+	b.witness.Blocks = append(b.witness.Blocks, delta)
+	return delta, nil
+}
+
+// addNodes adds the trie nodes of a proof missing from the batch to the delta.
+func (b *BatchWitnessBuilder) addNodes(delta *BlockWitness, proof []hexutil.Bytes) {
+	for _, node := range proof {
+		if _, ok := b.nodes[string(node)]; ok {
+			continue
+		}
+		b.nodes[string(node)] = struct{}{}
+		delta.Nodes = append(delta.Nodes, node)
+	}
+}
+
+// addCode adds a hex encoded contract code missing from the batch to the delta.
+func (b *BatchWitnessBuilder) addCode(delta *BlockWitness, hex string) {
+	code, err := hexutil.Decode(hex)
+	if err != nil || len(code) == 0 {
+		return
+	}
+	hash := crypto.Keccak256Hash(code)
+	if _, ok := b.codes[hash]; ok {
+		return
+	}
+	b.codes[hash] = struct{}{}
+	delta.Codes = append(delta.Codes, code)
+}
+
+// Witness returns the witness of the batch built so far.
+func (b *BatchWitnessBuilder) Witness() *BatchWitness {
+	return &b.witness
+}
+
+// NewBatchWitness builds the witness of a batch from the block results of its
+// blocks, in order.
+func NewBatchWitness(results []*BlockResult) (*BatchWitness, error) {
+	builder := NewBatchWitnessBuilder()
+	for _, result := range results {
+		if _, err := builder.Add(result); err != nil {
+			return nil, err
+		}
+	}
+	return builder.Witness(), nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+)
+
+func TestBatchWitness(t *testing.T) {
+	var (
+		root    = hexutil.Bytes{0x01}
+		shared  = hexutil.Bytes{0x02}
+		leafA   = hexutil.Bytes{0x03}
+		leafB   = hexutil.Bytes{0x04}
+		slot    = hexutil.Bytes{0x05}
+		newRoot = hexutil.Bytes{0x06}
+	)
+	results := []*BlockResult{
+		{
+			BlockTrace: &BlockTrace{Hash: common.HexToHash("0xa1")},
+			StorageTrace: &StorageTrace{
+				RootBefore: common.HexToHash("0x01"),
+				RootAfter:  common.HexToHash("0x02"),
+				Proofs: map[string][]hexutil.Bytes{
+					"0xA": {root, shared, leafA},
+					"0xB": {root, shared, leafB},
+				},
+				StorageProofs: map[string]map[string][]hexutil.Bytes{
+					"0xA": {"0x01": {slot}},
+				},
+			},
+			ExecutionResults: []*ExecutionResult{{
+				ByteCode: "0x6001",
+				StructLogs: []*StructLogRes{
+					{ExtraData: &ExtraData{CodeList: []string{"0x6001", "0x6002"}}},
+				},
+			}},
+		},
+		{
+			BlockTrace: &BlockTrace{Hash: common.HexToHash("0xa2")},
+			StorageTrace: &StorageTrace{
+				RootBefore: common.HexToHash("0x02"),
+				RootAfter:  common.HexToHash("0x03"),
+				Proofs: map[string][]hexutil.Bytes{
+					"0xA": {newRoot, shared, leafA},
+				},
+			},
+			ExecutionResults: []*ExecutionResult{{ByteCode: "0x6002"}},
+		},
+	}
+	witness, err := NewBatchWitness(results)
+	if err != nil {
+		t.Fatalf("failed to build witness: %v", err)
+	}
+	if len(witness.Blocks) != 2 {
+		t.Fatalf("block count mismatch: have %d, want 2", len(witness.Blocks))
+	}
+	first, second := witness.Blocks[0], witness.Blocks[1]
+	if len(first.Nodes) != 5 || len(first.Codes) != 2 {
+		t.Errorf("first delta mismatch: have %d nodes, %d codes, want 5, 2", len(first.Nodes), len(first.Codes))
+	}
+	if len(first.Accounts) != 2 || len(first.Storage["0xA"]) != 1 {
+		t.Errorf("first delta keys mismatch: have %v, %v", first.Accounts, first.Storage)
+	}
+	if len(second.Nodes) != 1 || second.Nodes[0][0] != newRoot[0] || len(second.Codes) != 0 {
+		t.Errorf("second delta mismatch: have nodes %v, codes %v", second.Nodes, second.Codes)
+	}
+	// Blocks not building on each other can't be batched
+	results[1].StorageTrace.RootBefore = common.HexToHash("0x04")
+	if _, err := NewBatchWitness(results); err == nil {
+		t.Fatal("non-consecutive blocks accepted")
+	}
+}
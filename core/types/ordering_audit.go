@@ -0,0 +1,93 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+)
+
+// OrderingDecision is what the miner decided for a candidate transaction while
+// assembling a block.
+type OrderingDecision struct {
+	Hash     common.Hash
+	Sender   common.Address
+	Nonce    uint64
+	Tip      *big.Int // Effective gas tip at the block base fee, the ordering key
+	Included bool
+	Index    uint64 // Position in the block if included
+	Reason   string // Why the transaction was left out, empty if included
+}
+
+// OrderingAudit lists the candidate transactions the miner saw while assembling
+// a block, in the order it went through them. The candidates it never reached
+// come last.
+type OrderingAudit struct {
+	BaseFee    *big.Int `rlp:"nil"`
+	Candidates []*OrderingDecision
+}
+
+// orderingDecisionJSON is the JSON representation of an ordering decision.
+type orderingDecisionJSON struct {
+	Hash     common.Hash     `json:"hash"`
+	Sender   common.Address  `json:"sender"`
+	Nonce    hexutil.Uint64  `json:"nonce"`
+	Tip      *hexutil.Big    `json:"tip"`
+	Included bool            `json:"included"`
+	Index    *hexutil.Uint64 `json:"index,omitempty"`
+	Reason   string          `json:"reason,omitempty"`
+}
+
+// MarshalJSON marshals as JSON.
+func (d *OrderingDecision) MarshalJSON() ([]byte, error) {
+	enc := &orderingDecisionJSON{
+		Hash:     d.Hash,
+		Sender:   d.Sender,
+		Nonce:    hexutil.Uint64(d.Nonce),
+		Tip:      (*hexutil.Big)(d.Tip),
+		Included: d.Included,
+		Reason:   d.Reason,
+	}
+	if d.Included {
+		index := hexutil.Uint64(d.Index)
+		enc.Index = &index
+	}
+	return json.Marshal(enc)
+}
+
+// UnmarshalJSON unmarshals from JSON.
+func (d *OrderingDecision) UnmarshalJSON(input []byte) error {
+	var dec orderingDecisionJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	*d = OrderingDecision{
+		Hash:     dec.Hash,
+		Sender:   dec.Sender,
+		Nonce:    uint64(dec.Nonce),
+		Tip:      (*big.Int)(dec.Tip),
+		Included: dec.Included,
+		Reason:   dec.Reason,
+	}
+	if dec.Index != nil {
+		d.Index = uint64(*dec.Index)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/scroll-tech/go-ethereum/rlp"
+)
+
+// Preconfirmation is the sequencer's signed promise to include a transaction by
+// the expiry block, behind the transactions ordered before it in the tentative
+// block.
+type Preconfirmation struct {
+	ChainID     uint64        `json:"chainId"`
+	TxHash      common.Hash   `json:"transactionHash"`
+	BlockNumber uint64        `json:"blockNumber"`      // Block the transaction is tentatively ordered in
+	Index       uint64        `json:"transactionIndex"` // Tentative position in the block
+	Expiry      uint64        `json:"expiry"`           // Last block the transaction is promised to be included by
+	Signature   hexutil.Bytes `json:"signature"`
+}
+
+// SigData returns the payload the sequencer signs.
+func (p *Preconfirmation) SigData() []byte {
+	data, _ := rlp.EncodeToBytes([]interface{}{p.ChainID, p.TxHash, p.BlockNumber, p.Index, p.Expiry})
+	return data
+}
+
+// SigHash returns the hash of the payload the sequencer signs.
+func (p *Preconfirmation) SigHash() common.Hash {
+	return crypto.Keccak256Hash(p.SigData())
+}
+
+// Sign signs the preconfirmation with the given key.
+func (p *Preconfirmation) Sign(prv *ecdsa.PrivateKey) error {
+	sig, err := crypto.Sign(p.SigHash().Bytes(), prv)
+	if err != nil {
+		return err
+	}
+	p.Signature = sig
+	return nil
+}
+
+// Signer returns the address of the key that signed the preconfirmation.
+func (p *Preconfirmation) Signer() (common.Address, error) {
+	if len(p.Signature) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("invalid preconfirmation signature length %d", len(p.Signature))
+	}
+	pub, err := crypto.SigToPub(p.SigHash().Bytes(), p.Signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
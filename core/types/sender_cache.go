@@ -0,0 +1,57 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/metrics"
+)
+
+// senderCacheSize is the number of transaction senders retained by the node-wide
+// sender cache.
+const senderCacheSize = 65536
+
+var (
+	senderCacheHitMeter  = metrics.NewRegisteredMeter("types/sender/cache/hit", nil)
+	senderCacheMissMeter = metrics.NewRegisteredMeter("types/sender/cache/miss", nil)
+)
+
+// senderCache maps transaction hashes to their recovered senders, across all
+// the decoded copies of a transaction. The same transaction is usually decoded
+// several times, e.g. when received by the pool, included in a block and served
+// over RPC, each copy otherwise redoing the expensive signature recovery.
+var senderCache, _ = lru.New(senderCacheSize)
+
+// cachedSender retrieves the sender of the transaction with the given hash, if
+// it was recovered before using an equivalent signer.
+func cachedSender(hash common.Hash, signer Signer) (common.Address, bool) {
+	if cached, ok := senderCache.Get(hash); ok {
+		if sc := cached.(sigCache); sc.signer.Equal(signer) {
+			senderCacheHitMeter.Mark(1)
+			return sc.from, true
+		}
+	}
+	senderCacheMissMeter.Mark(1)
+	return common.Address{}, false
+}
+
+// cacheSender stores the sender of the transaction with the given hash.
+func cacheSender(hash common.Hash, signer Signer, from common.Address) {
+	senderCache.Add(hash, sigCache{signer: signer, from: from})
+}
@@ -49,9 +49,7 @@ func (s *StateAccount) Hash() (*big.Int, error) {
 		return nil, err
 	}
 
-	codeHashFirst16 := new(big.Int).SetBytes(s.CodeHash[0:16])
-	codeHashLast16 := new(big.Int).SetBytes(s.CodeHash[16:32])
-	hash2, err := poseidon.Hash([]*big.Int{codeHashFirst16, codeHashLast16})
+	hash2, err := zkt.NewByte32FromBytes(s.CodeHash).Hash()
 	if err != nil {
 		return nil, err
 	}
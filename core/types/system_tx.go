@@ -0,0 +1,72 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+
+	"github.com/scroll-tech/go-ethereum/common"
+)
+
+// SystemAddress is the sender of system transactions.
+var SystemAddress = common.HexToAddress("0xfffffffffffffffffffffffffffffffffffffffe")
+
+// SystemTx is a protocol defined transaction the sequencer opens a block with.
+// System transactions are not signed, are sent from SystemAddress and neither
+// pay for gas nor count towards the gas used by the block.
+type SystemTx struct {
+	Number uint64 // Number of the block including the transaction
+	Index  uint64 // Position of the transaction among the system transactions of the block
+	To     common.Address
+	Gas    uint64
+	Data   []byte
+}
+
+// copy creates a deep copy of the transaction data and initializes all fields.
+func (tx *SystemTx) copy() TxData {
+	return &SystemTx{
+		Number: tx.Number,
+		Index:  tx.Index,
+		To:     tx.To,
+		Gas:    tx.Gas,
+		Data:   common.CopyBytes(tx.Data),
+	}
+}
+
+// accessors for innerTx.
+func (tx *SystemTx) txType() byte           { return SystemTxType }
+func (tx *SystemTx) chainID() *big.Int      { return new(big.Int) }
+func (tx *SystemTx) accessList() AccessList { return nil }
+func (tx *SystemTx) data() []byte           { return tx.Data }
+func (tx *SystemTx) gas() uint64            { return tx.Gas }
+func (tx *SystemTx) gasFeeCap() *big.Int    { return new(big.Int) }
+func (tx *SystemTx) gasTipCap() *big.Int    { return new(big.Int) }
+func (tx *SystemTx) gasPrice() *big.Int     { return new(big.Int) }
+func (tx *SystemTx) value() *big.Int        { return new(big.Int) }
+func (tx *SystemTx) nonce() uint64          { return 0 }
+func (tx *SystemTx) to() *common.Address {
+	to := tx.To
+	return &to
+}
+
+func (tx *SystemTx) rawSignatureValues() (v, r, s *big.Int) {
+	return new(big.Int), new(big.Int), new(big.Int)
+}
+
+func (tx *SystemTx) setSignatureValues(chainID, v, r, s *big.Int) {
+	// System transactions are not signed
+}
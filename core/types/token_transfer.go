@@ -0,0 +1,72 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/crypto"
+)
+
+// TransferEventTopic is the topic of the Transfer event shared by the ERC-20
+// and ERC-721 token standards.
+var TransferEventTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// TokenTransfer is a token transfer decoded from an ERC-20 or ERC-721 Transfer
+// event log. It is the representation persisted in the token transfer index.
+type TokenTransfer struct {
+	Token   common.Address
+	From    common.Address
+	To      common.Address
+	Value   *big.Int // Amount of ERC-20 transfers, token id of ERC-721 ones
+	NFT     bool     // Whether the value is an indexed token id, as mandated by ERC-721
+	TxHash  common.Hash
+	TxIndex uint
+
+	// Position of the log, derived from the index key instead of being stored
+	BlockNumber uint64      `rlp:"-"`
+	BlockHash   common.Hash `rlp:"-"`
+	LogIndex    uint        `rlp:"-"`
+}
+
+// NewTokenTransfer decodes a token transfer from a log, returning nil if the log
+// is not a well formed ERC-20 or ERC-721 Transfer event.
+func NewTokenTransfer(log *Log) *TokenTransfer {
+	if len(log.Topics) < 3 || log.Topics[0] != TransferEventTopic {
+		return nil
+	}
+	transfer := &TokenTransfer{
+		Token:       log.Address,
+		From:        common.BytesToAddress(log.Topics[1].Bytes()),
+		To:          common.BytesToAddress(log.Topics[2].Bytes()),
+		TxHash:      log.TxHash,
+		TxIndex:     log.TxIndex,
+		BlockNumber: log.BlockNumber,
+		BlockHash:   log.BlockHash,
+		LogIndex:    log.Index,
+	}
+	switch {
+	case len(log.Topics) == 3 && len(log.Data) == common.HashLength:
+		transfer.Value = new(big.Int).SetBytes(log.Data)
+	case len(log.Topics) == 4 && len(log.Data) == 0:
+		transfer.Value, transfer.NFT = log.Topics[3].Big(), true
+	default:
+		return nil
+	}
+	return transfer
+}
@@ -0,0 +1,70 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+)
+
+// Tests that ERC-20 and ERC-721 Transfer events are decoded and anything else is
+// rejected.
+func TestNewTokenTransfer(t *testing.T) {
+	var (
+		token = common.HexToAddress("0x7070")
+		from  = common.HexToAddress("0xa11ce")
+		to    = common.HexToAddress("0xb0b")
+		value = common.BigToHash(big.NewInt(1234))
+	)
+	tests := []struct {
+		log   *Log
+		value *big.Int
+		nft   bool
+	}{
+		// ERC-20 transfer with the amount in the data
+		{&Log{Address: token, Topics: []common.Hash{TransferEventTopic, from.Hash(), to.Hash()}, Data: value.Bytes()}, big.NewInt(1234), false},
+		// ERC-721 transfer with an indexed token id
+		{&Log{Address: token, Topics: []common.Hash{TransferEventTopic, from.Hash(), to.Hash(), value}}, big.NewInt(1234), true},
+		// Other event
+		{&Log{Address: token, Topics: []common.Hash{{0x01}, from.Hash(), to.Hash()}, Data: value.Bytes()}, nil, false},
+		// Malformed transfers
+		{&Log{Address: token, Topics: []common.Hash{TransferEventTopic, from.Hash()}, Data: value.Bytes()}, nil, false},
+		{&Log{Address: token, Topics: []common.Hash{TransferEventTopic, from.Hash(), to.Hash()}}, nil, false},
+		{&Log{Address: token, Topics: []common.Hash{TransferEventTopic, from.Hash(), to.Hash(), value}, Data: value.Bytes()}, nil, false},
+	}
+	for i, tt := range tests {
+		transfer := NewTokenTransfer(tt.log)
+		if tt.value == nil {
+			if transfer != nil {
+				t.Errorf("test %d: unexpected transfer: %+v", i, transfer)
+			}
+			continue
+		}
+		if transfer == nil {
+			t.Errorf("test %d: transfer not decoded", i)
+			continue
+		}
+		if transfer.Token != token || transfer.From != from || transfer.To != to {
+			t.Errorf("test %d: parties mismatch: have %x -> %x (%x)", i, transfer.From, transfer.To, transfer.Token)
+		}
+		if transfer.Value.Cmp(tt.value) != 0 || transfer.NFT != tt.nft {
+			t.Errorf("test %d: value mismatch: have %v (nft %v), want %v (nft %v)", i, transfer.Value, transfer.NFT, tt.value, tt.nft)
+		}
+	}
+}
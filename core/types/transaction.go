@@ -45,6 +45,9 @@ const (
 	LegacyTxType = iota
 	AccessListTxType
 	DynamicFeeTxType
+
+	FeePayerTxType = 0x7d
+	SystemTxType   = 0x7f
 )
 
 // Transaction is an Ethereum transaction.
@@ -67,7 +70,7 @@ func NewTx(inner TxData) *Transaction {
 
 // TxData is the underlying data of a transaction.
 //
-// This is implemented by DynamicFeeTx, LegacyTx and AccessListTx.
+// This is implemented by DynamicFeeTx, LegacyTx, AccessListTx, FeePayerTx and SystemTx.
 type TxData interface {
 	txType() byte // returns the type ID
 	copy() TxData // creates a deep copy and initializes all fields
@@ -186,6 +189,14 @@ func (tx *Transaction) decodeTyped(b []byte) (TxData, error) {
 		var inner DynamicFeeTx
 		err := rlp.DecodeBytes(b[1:], &inner)
 		return &inner, err
+	case FeePayerTxType:
+		var inner FeePayerTx
+		err := rlp.DecodeBytes(b[1:], &inner)
+		return &inner, err
+	case SystemTxType:
+		var inner SystemTx
+		err := rlp.DecodeBytes(b[1:], &inner)
+		return &inner, err
 	default:
 		return nil, ErrTxTypeNotSupported
 	}
@@ -260,6 +271,14 @@ func (tx *Transaction) ChainId() *big.Int {
 // Data returns the input data of the transaction.
 func (tx *Transaction) Data() []byte { return tx.inner.data() }
 
+// Time returns the time the transaction was first seen locally.
+func (tx *Transaction) Time() time.Time { return tx.time }
+
+// SetTime overrides the time the transaction was first seen locally, e.g. when
+// it's transferred from another node. It must be set before the transaction is
+// shared.
+func (tx *Transaction) SetTime(t time.Time) { tx.time = t }
+
 // AccessList returns the access list of the transaction.
 func (tx *Transaction) AccessList() AccessList { return tx.inner.accessList() }
 
@@ -287,19 +306,51 @@ func (tx *Transaction) To() *common.Address {
 	return copyAddressPtr(tx.inner.to())
 }
 
-// Cost returns gas * gasPrice + value.
+// Cost returns gas * gasPrice + value, the amount charged to the sender. The
+// gas of fee payer transactions is paid by the fee payer, their cost is the
+// value only.
 func (tx *Transaction) Cost() *big.Int {
+	if tx.Type() == FeePayerTxType {
+		return tx.Value()
+	}
 	total := new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(tx.Gas()))
 	total.Add(total, tx.Value())
 	return total
 }
 
+// FeeCost returns gas * gasPrice, the amount charged to the fee payer of a fee
+// payer transaction.
+func (tx *Transaction) FeeCost() *big.Int {
+	return new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(tx.Gas()))
+}
+
+// FeePayer returns the fee payer address of the transaction, nil if the
+// transaction is not a fee payer transaction. The address is not checked
+// against the fee payer signature, use the package level FeePayer for that.
+func (tx *Transaction) FeePayer() *common.Address {
+	if inner, ok := tx.inner.(*FeePayerTx); ok {
+		payer := inner.FeePayer
+		return &payer
+	}
+	return nil
+}
+
 // RawSignatureValues returns the V, R, S signature values of the transaction.
 // The return values should not be modified by the caller.
 func (tx *Transaction) RawSignatureValues() (v, r, s *big.Int) {
 	return tx.inner.rawSignatureValues()
 }
 
+// RawFeePayerSignatureValues returns the V, R, S signature values of the fee
+// payer, nils if the transaction is not a fee payer transaction. The return
+// values should not be modified by the caller.
+func (tx *Transaction) RawFeePayerSignatureValues() (v, r, s *big.Int) {
+	if inner, ok := tx.inner.(*FeePayerTx); ok {
+		return inner.FeePayerV, inner.FeePayerR, inner.FeePayerS
+	}
+	return nil, nil, nil
+}
+
 // GasFeeCapCmp compares the fee cap of two transactions.
 func (tx *Transaction) GasFeeCapCmp(other *Transaction) int {
 	return tx.inner.gasFeeCap().Cmp(other.inner.gasFeeCap())
@@ -398,6 +449,26 @@ func (tx *Transaction) WithSignature(signer Signer, sig []byte) (*Transaction, e
 	return &Transaction{inner: cpy, time: tx.time}, nil
 }
 
+// WithFeePayerSignature returns a new fee payer transaction with the given fee
+// payer signature. This signature needs to be in the [R || S || V] format where
+// V is 0 or 1.
+func (tx *Transaction) WithFeePayerSignature(signer Signer, sig []byte) (*Transaction, error) {
+	if tx.Type() != FeePayerTxType {
+		return nil, ErrTxTypeNotSupported
+	}
+	fs, ok := signer.(feePayerSigner)
+	if !ok {
+		return nil, ErrTxTypeNotSupported
+	}
+	r, s, v, err := fs.SignatureValues(tx, sig)
+	if err != nil {
+		return nil, err
+	}
+	cpy := tx.inner.copy().(*FeePayerTx)
+	cpy.setFeePayerSignatureValues(v, r, s)
+	return &Transaction{inner: cpy, time: tx.time}, nil
+}
+
 // Transactions implements DerivableList for transactions.
 type Transactions []*Transaction
 
@@ -574,6 +645,8 @@ type Message struct {
 	data       []byte
 	accessList AccessList
 	isFake     bool
+	isSystem   bool
+	payer      common.Address
 }
 
 func NewMessage(from common.Address, to *common.Address, nonce uint64, amount *big.Int, gasLimit uint64, gasPrice, gasFeeCap, gasTipCap *big.Int, data []byte, accessList AccessList, isFake bool) Message {
@@ -589,6 +662,7 @@ func NewMessage(from common.Address, to *common.Address, nonce uint64, amount *b
 		data:       data,
 		accessList: accessList,
 		isFake:     isFake,
+		payer:      from,
 	}
 }
 
@@ -605,6 +679,7 @@ func (tx *Transaction) AsMessage(s Signer, baseFee *big.Int) (Message, error) {
 		data:       tx.Data(),
 		accessList: tx.AccessList(),
 		isFake:     false,
+		isSystem:   tx.Type() == SystemTxType,
 	}
 	// If baseFee provided, set gasPrice to effectiveGasPrice.
 	if baseFee != nil {
@@ -612,6 +687,10 @@ func (tx *Transaction) AsMessage(s Signer, baseFee *big.Int) (Message, error) {
 	}
 	var err error
 	msg.from, err = Sender(s, tx)
+	if err != nil {
+		return msg, err
+	}
+	msg.payer, err = FeePayer(s, tx)
 	return msg, err
 }
 
@@ -626,6 +705,8 @@ func (m Message) Nonce() uint64          { return m.nonce }
 func (m Message) Data() []byte           { return m.data }
 func (m Message) AccessList() AccessList { return m.accessList }
 func (m Message) IsFake() bool           { return m.isFake }
+func (m Message) IsSystem() bool         { return m.isSystem }
+func (m Message) Payer() common.Address  { return m.payer }
 
 // copyAddressPtr copies an address.
 func copyAddressPtr(a *common.Address) *common.Address {
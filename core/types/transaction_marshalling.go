@@ -46,6 +46,16 @@ type txJSON struct {
 	ChainID    *hexutil.Big `json:"chainId,omitempty"`
 	AccessList *AccessList  `json:"accessList,omitempty"`
 
+	// Fee payer transaction fields:
+	FeePayer  *common.Address `json:"feePayer,omitempty"`
+	FeePayerV *hexutil.Big    `json:"feePayerV,omitempty"`
+	FeePayerR *hexutil.Big    `json:"feePayerR,omitempty"`
+	FeePayerS *hexutil.Big    `json:"feePayerS,omitempty"`
+
+	// System transaction fields:
+	Number *hexutil.Uint64 `json:"number,omitempty"`
+	Index  *hexutil.Uint64 `json:"index,omitempty"`
+
 	// Only used for encoding:
 	Hash common.Hash `json:"hash"`
 }
@@ -94,6 +104,29 @@ func (t *Transaction) MarshalJSON() ([]byte, error) {
 		enc.V = (*hexutil.Big)(tx.V)
 		enc.R = (*hexutil.Big)(tx.R)
 		enc.S = (*hexutil.Big)(tx.S)
+	case *FeePayerTx:
+		enc.ChainID = (*hexutil.Big)(tx.ChainID)
+		enc.AccessList = &tx.AccessList
+		enc.Nonce = (*hexutil.Uint64)(&tx.Nonce)
+		enc.Gas = (*hexutil.Uint64)(&tx.Gas)
+		enc.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap)
+		enc.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap)
+		enc.Value = (*hexutil.Big)(tx.Value)
+		enc.Data = (*hexutil.Bytes)(&tx.Data)
+		enc.To = t.To()
+		enc.V = (*hexutil.Big)(tx.V)
+		enc.R = (*hexutil.Big)(tx.R)
+		enc.S = (*hexutil.Big)(tx.S)
+		enc.FeePayer = t.FeePayer()
+		enc.FeePayerV = (*hexutil.Big)(tx.FeePayerV)
+		enc.FeePayerR = (*hexutil.Big)(tx.FeePayerR)
+		enc.FeePayerS = (*hexutil.Big)(tx.FeePayerS)
+	case *SystemTx:
+		enc.Number = (*hexutil.Uint64)(&tx.Number)
+		enc.Index = (*hexutil.Uint64)(&tx.Index)
+		enc.Gas = (*hexutil.Uint64)(&tx.Gas)
+		enc.Data = (*hexutil.Bytes)(&tx.Data)
+		enc.To = t.To()
 	}
 	return json.Marshal(&enc)
 }
@@ -263,6 +296,101 @@ func (t *Transaction) UnmarshalJSON(input []byte) error {
 			}
 		}
 
+	case FeePayerTxType:
+		var itx FeePayerTx
+		inner = &itx
+		// Access list is optional for now.
+		if dec.AccessList != nil {
+			itx.AccessList = *dec.AccessList
+		}
+		if dec.ChainID == nil {
+			return errors.New("missing required field 'chainId' in transaction")
+		}
+		itx.ChainID = (*big.Int)(dec.ChainID)
+		if dec.To != nil {
+			itx.To = dec.To
+		}
+		if dec.Nonce == nil {
+			return errors.New("missing required field 'nonce' in transaction")
+		}
+		itx.Nonce = uint64(*dec.Nonce)
+		if dec.MaxPriorityFeePerGas == nil {
+			return errors.New("missing required field 'maxPriorityFeePerGas' for txdata")
+		}
+		itx.GasTipCap = (*big.Int)(dec.MaxPriorityFeePerGas)
+		if dec.MaxFeePerGas == nil {
+			return errors.New("missing required field 'maxFeePerGas' for txdata")
+		}
+		itx.GasFeeCap = (*big.Int)(dec.MaxFeePerGas)
+		if dec.Gas == nil {
+			return errors.New("missing required field 'gas' for txdata")
+		}
+		itx.Gas = uint64(*dec.Gas)
+		if dec.Value == nil {
+			return errors.New("missing required field 'value' in transaction")
+		}
+		itx.Value = (*big.Int)(dec.Value)
+		if dec.Data == nil {
+			return errors.New("missing required field 'input' in transaction")
+		}
+		itx.Data = *dec.Data
+		if dec.FeePayer == nil {
+			return errors.New("missing required field 'feePayer' in transaction")
+		}
+		itx.FeePayer = *dec.FeePayer
+		if dec.V == nil {
+			return errors.New("missing required field 'v' in transaction")
+		}
+		itx.V = (*big.Int)(dec.V)
+		if dec.R == nil {
+			return errors.New("missing required field 'r' in transaction")
+		}
+		itx.R = (*big.Int)(dec.R)
+		if dec.S == nil {
+			return errors.New("missing required field 's' in transaction")
+		}
+		itx.S = (*big.Int)(dec.S)
+		withSignature := itx.V.Sign() != 0 || itx.R.Sign() != 0 || itx.S.Sign() != 0
+		if withSignature {
+			if err := sanityCheckSignature(itx.V, itx.R, itx.S, false); err != nil {
+				return err
+			}
+		}
+		// The fee payer signature is missing until the fee payer signed.
+		itx.FeePayerV, itx.FeePayerR, itx.FeePayerS = new(big.Int), new(big.Int), new(big.Int)
+		if dec.FeePayerV != nil && dec.FeePayerR != nil && dec.FeePayerS != nil {
+			itx.FeePayerV = (*big.Int)(dec.FeePayerV)
+			itx.FeePayerR = (*big.Int)(dec.FeePayerR)
+			itx.FeePayerS = (*big.Int)(dec.FeePayerS)
+			if err := sanityCheckSignature(itx.FeePayerV, itx.FeePayerR, itx.FeePayerS, false); err != nil {
+				return err
+			}
+		}
+
+	case SystemTxType:
+		var itx SystemTx
+		inner = &itx
+		if dec.Number == nil {
+			return errors.New("missing required field 'number' in transaction")
+		}
+		itx.Number = uint64(*dec.Number)
+		if dec.Index == nil {
+			return errors.New("missing required field 'index' in transaction")
+		}
+		itx.Index = uint64(*dec.Index)
+		if dec.To == nil {
+			return errors.New("missing required field 'to' in transaction")
+		}
+		itx.To = *dec.To
+		if dec.Gas == nil {
+			return errors.New("missing required field 'gas' in transaction")
+		}
+		itx.Gas = uint64(*dec.Gas)
+		if dec.Data == nil {
+			return errors.New("missing required field 'input' in transaction")
+		}
+		itx.Data = *dec.Data
+
 	default:
 		return ErrTxTypeNotSupported
 	}
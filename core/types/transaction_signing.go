@@ -27,7 +27,10 @@ import (
 	"github.com/scroll-tech/go-ethereum/params"
 )
 
-var ErrInvalidChainId = errors.New("invalid chain id for signer")
+var (
+	ErrInvalidChainId  = errors.New("invalid chain id for signer")
+	ErrInvalidFeePayer = errors.New("fee payer signature does not match fee payer")
+)
 
 // sigCache is used to cache the derived sender and contains
 // the signer used to derive it.
@@ -40,6 +43,8 @@ type sigCache struct {
 func MakeSigner(config *params.ChainConfig, blockNumber *big.Int) Signer {
 	var signer Signer
 	switch {
+	case config.IsFeePayer(blockNumber):
+		signer = NewFeePayerSigner(config.ChainID)
 	case config.IsLondon(blockNumber):
 		signer = NewLondonSigner(config.ChainID)
 	case config.IsBerlin(blockNumber):
@@ -63,6 +68,9 @@ func MakeSigner(config *params.ChainConfig, blockNumber *big.Int) Signer {
 // have the current block number available, use MakeSigner instead.
 func LatestSigner(config *params.ChainConfig) Signer {
 	if config.ChainID != nil {
+		if config.FeePayerBlock != nil {
+			return NewFeePayerSigner(config.ChainID)
+		}
 		if config.LondonBlock != nil {
 			return NewLondonSigner(config.ChainID)
 		}
@@ -87,7 +95,7 @@ func LatestSignerForChainID(chainID *big.Int) Signer {
 	if chainID == nil {
 		return HomesteadSigner{}
 	}
-	return NewLondonSigner(chainID)
+	return NewFeePayerSigner(chainID)
 }
 
 // SignTx signs the transaction using the given signer and private key.
@@ -121,14 +129,56 @@ func MustSignNewTx(prv *ecdsa.PrivateKey, s Signer, txdata TxData) *Transaction
 	return tx
 }
 
+// SignFeePayer signs the fee payer part of a fee payer transaction, which must
+// already be signed by its sender, using the given signer and private key.
+func SignFeePayer(tx *Transaction, s Signer, prv *ecdsa.PrivateKey) (*Transaction, error) {
+	fs, ok := s.(feePayerSigner)
+	if !ok || tx.Type() != FeePayerTxType {
+		return nil, ErrTxTypeNotSupported
+	}
+	h := fs.FeePayerHash(tx)
+	sig, err := crypto.Sign(h[:], prv)
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithFeePayerSignature(s, sig)
+}
+
+// FeePayer returns the address paying for the gas of the transaction. For fee
+// payer transactions it is derived from the fee payer signature and must match
+// the fee payer the sender signed for, other transactions are paid by their
+// sender.
+func FeePayer(signer Signer, tx *Transaction) (common.Address, error) {
+	if tx.Type() != FeePayerTxType {
+		return Sender(signer, tx)
+	}
+	fs, ok := signer.(feePayerSigner)
+	if !ok {
+		return common.Address{}, ErrTxTypeNotSupported
+	}
+	addr, err := fs.FeePayer(tx)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if addr != *tx.FeePayer() {
+		return common.Address{}, ErrInvalidFeePayer
+	}
+	return addr, nil
+}
+
 // Sender returns the address derived from the signature (V, R, S) using secp256k1
 // elliptic curve and an error if it failed deriving or upon an incorrect
 // signature.
 //
 // Sender may cache the address, allowing it to be used regardless of
 // signing method. The cache is invalidated if the cached signer does
-// not match the signer used in the current call.
+// not match the signer used in the current call. Recovered addresses
+// are also shared node-wide between all copies of a transaction.
 func Sender(signer Signer, tx *Transaction) (common.Address, error) {
+	// System transactions are not signed, they're always sent by the protocol
+	if tx.Type() == SystemTxType {
+		return SystemAddress, nil
+	}
 	if sc := tx.from.Load(); sc != nil {
 		sigCache := sc.(sigCache)
 		// If the signer used to derive from in a previous
@@ -138,12 +188,17 @@ func Sender(signer Signer, tx *Transaction) (common.Address, error) {
 			return sigCache.from, nil
 		}
 	}
-
+	hash := tx.Hash()
+	if addr, ok := cachedSender(hash, signer); ok {
+		tx.from.Store(sigCache{signer: signer, from: addr})
+		return addr, nil
+	}
 	addr, err := signer.Sender(tx)
 	if err != nil {
 		return common.Address{}, err
 	}
 	tx.from.Store(sigCache{signer: signer, from: addr})
+	cacheSender(hash, signer, addr)
 	return addr, nil
 }
 
@@ -170,6 +225,113 @@ type Signer interface {
 	Equal(Signer) bool
 }
 
+type feePayerSigner struct{ londonSigner }
+
+// NewFeePayerSigner returns a signer that accepts
+// - fee payer transactions,
+// - EIP-1559 dynamic fee transactions,
+// - EIP-2930 access list transactions,
+// - EIP-155 replay protected transactions, and
+// - legacy Homestead transactions.
+func NewFeePayerSigner(chainId *big.Int) Signer {
+	return feePayerSigner{londonSigner{eip2930Signer{NewEIP155Signer(chainId)}}}
+}
+
+func (s feePayerSigner) Sender(tx *Transaction) (common.Address, error) {
+	if tx.Type() != FeePayerTxType {
+		return s.londonSigner.Sender(tx)
+	}
+	V, R, S := tx.RawSignatureValues()
+	// Fee payer txs are defined to use 0 and 1 as their recovery
+	// id, add 27 to become equivalent to unprotected Homestead signatures.
+	V = new(big.Int).Add(V, big.NewInt(27))
+	if tx.ChainId().Cmp(s.chainId) != 0 {
+		return common.Address{}, ErrInvalidChainId
+	}
+	return recoverPlain(s.Hash(tx), R, S, V, true)
+}
+
+// FeePayer returns the address recovered from the fee payer signature of a fee
+// payer transaction.
+func (s feePayerSigner) FeePayer(tx *Transaction) (common.Address, error) {
+	if tx.Type() != FeePayerTxType {
+		return common.Address{}, ErrTxTypeNotSupported
+	}
+	V, R, S := tx.RawFeePayerSignatureValues()
+	if V == nil || R == nil || S == nil {
+		return common.Address{}, ErrInvalidSig
+	}
+	V = new(big.Int).Add(V, big.NewInt(27))
+	if tx.ChainId().Cmp(s.chainId) != 0 {
+		return common.Address{}, ErrInvalidChainId
+	}
+	return recoverPlain(s.FeePayerHash(tx), R, S, V, true)
+}
+
+func (s feePayerSigner) Equal(s2 Signer) bool {
+	x, ok := s2.(feePayerSigner)
+	return ok && x.chainId.Cmp(s.chainId) == 0
+}
+
+func (s feePayerSigner) SignatureValues(tx *Transaction, sig []byte) (R, S, V *big.Int, err error) {
+	txdata, ok := tx.inner.(*FeePayerTx)
+	if !ok {
+		return s.londonSigner.SignatureValues(tx, sig)
+	}
+	// Check that chain ID of tx matches the signer. We also accept ID zero here,
+	// because it indicates that the chain ID was not specified in the tx.
+	if txdata.ChainID.Sign() != 0 && txdata.ChainID.Cmp(s.chainId) != 0 {
+		return nil, nil, nil, ErrInvalidChainId
+	}
+	R, S, _ = decodeSignature(sig)
+	V = big.NewInt(int64(sig[64]))
+	return R, S, V, nil
+}
+
+// Hash returns the hash to be signed by the sender.
+// It does not uniquely identify the transaction.
+func (s feePayerSigner) Hash(tx *Transaction) common.Hash {
+	if tx.Type() != FeePayerTxType {
+		return s.londonSigner.Hash(tx)
+	}
+	return prefixedRlpHash(
+		tx.Type(),
+		[]interface{}{
+			s.chainId,
+			tx.Nonce(),
+			tx.GasTipCap(),
+			tx.GasFeeCap(),
+			tx.Gas(),
+			tx.To(),
+			tx.Value(),
+			tx.Data(),
+			tx.AccessList(),
+			tx.FeePayer(),
+		})
+}
+
+// FeePayerHash returns the hash to be signed by the fee payer. It commits to
+// the signature of the sender, so a fee payer only pays for the exact
+// transaction the sender signed.
+func (s feePayerSigner) FeePayerHash(tx *Transaction) common.Hash {
+	V, R, S := tx.RawSignatureValues()
+	return prefixedRlpHash(
+		tx.Type(),
+		[]interface{}{
+			s.chainId,
+			tx.Nonce(),
+			tx.GasTipCap(),
+			tx.GasFeeCap(),
+			tx.Gas(),
+			tx.To(),
+			tx.Value(),
+			tx.Data(),
+			tx.AccessList(),
+			tx.FeePayer(),
+			V, R, S,
+		})
+}
+
 type londonSigner struct{ eip2930Signer }
 
 // NewLondonSigner returns a signer that accepts
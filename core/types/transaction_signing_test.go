@@ -136,3 +136,38 @@ func TestChainId(t *testing.T) {
 		t.Error("expected no error")
 	}
 }
+
+// Tests that senders recovered for one copy of a transaction are reused for
+// the other copies, but only with an equivalent signer.
+func TestSharedSenderCache(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	signer := NewEIP155Signer(big.NewInt(18))
+	tx, err := SignTx(NewTransaction(0, addr, new(big.Int), 0, new(big.Int), nil), signer, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cachedSender(tx.Hash(), signer); ok {
+		t.Fatalf("sender cached before recovery")
+	}
+	if from, err := Sender(signer, tx); err != nil || from != addr {
+		t.Fatalf("sender mismatch: have %x, want %x, err %v", from, addr, err)
+	}
+	// A freshly decoded copy of the transaction must hit the shared cache
+	blob, _ := rlp.EncodeToBytes(tx)
+	cpy := new(Transaction)
+	if err := rlp.DecodeBytes(blob, cpy); err != nil {
+		t.Fatal(err)
+	}
+	if from, ok := cachedSender(cpy.Hash(), signer); !ok || from != addr {
+		t.Fatalf("shared sender mismatch: have %x, want %x, cached %v", from, addr, ok)
+	}
+	// A signer of a different chain must not use the cached sender
+	if _, ok := cachedSender(cpy.Hash(), NewEIP155Signer(big.NewInt(19))); ok {
+		t.Fatalf("cached sender used with a different signer")
+	}
+	if _, err := Sender(NewEIP155Signer(big.NewInt(19)), cpy); err != ErrInvalidChainId {
+		t.Fatalf("chain id mismatch not detected: %v", err)
+	}
+}
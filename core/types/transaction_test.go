@@ -527,3 +527,104 @@ func assertEqual(orig *Transaction, cpy *Transaction) error {
 	}
 	return nil
 }
+
+// TestSystemTxCoding tests that system transactions survive the RLP and JSON
+// round trips and are attributed to the system address without a signature.
+func TestSystemTxCoding(t *testing.T) {
+	tx := NewTx(&SystemTx{
+		Number: 100,
+		Index:  1,
+		To:     common.HexToAddress("0x5300000000000000000000000000000000000002"),
+		Gas:    50000,
+		Data:   common.FromHex("0xdeadbeef"),
+	})
+	parsedTx, err := encodeDecodeBinary(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := assertEqual(parsedTx, tx); err != nil {
+		t.Fatal(err)
+	}
+	parsedTx, err = encodeDecodeJSON(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := assertEqual(parsedTx, tx); err != nil {
+		t.Fatal(err)
+	}
+	from, err := Sender(LatestSignerForChainID(big.NewInt(1)), tx)
+	if err != nil {
+		t.Fatalf("failed to derive sender: %v", err)
+	}
+	if from != SystemAddress {
+		t.Fatalf("sender mismatch: have %x, want %x", from, SystemAddress)
+	}
+	// Transactions differing only in their position must not collide
+	other := NewTx(&SystemTx{Number: 100, Index: 2, To: *tx.To(), Gas: tx.Gas(), Data: tx.Data()})
+	if other.Hash() == tx.Hash() {
+		t.Fatalf("system transaction hash collision")
+	}
+}
+
+func TestFeePayerTxCoding(t *testing.T) {
+	senderKey, _ := crypto.GenerateKey()
+	payerKey, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(senderKey.PublicKey)
+	payer := crypto.PubkeyToAddress(payerKey.PublicKey)
+
+	signer := NewFeePayerSigner(big.NewInt(1))
+	to := common.HexToAddress("0x5300000000000000000000000000000000000002")
+	tx, err := SignNewTx(senderKey, signer, &FeePayerTx{
+		ChainID:   big.NewInt(1),
+		Nonce:     3,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(10),
+		Gas:       50000,
+		To:        &to,
+		Value:     big.NewInt(7),
+		Data:      common.FromHex("0xdeadbeef"),
+		FeePayer:  payer,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The fee payer must sign before the transaction is valid
+	if _, err := FeePayer(signer, tx); err == nil {
+		t.Fatal("expected error for missing fee payer signature")
+	}
+	tx, err = SignFeePayer(tx, signer, payerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, coding := range []func(*Transaction) (*Transaction, error){encodeDecodeBinary, encodeDecodeJSON} {
+		parsedTx, err := coding(tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := assertEqual(parsedTx, tx); err != nil {
+			t.Fatal(err)
+		}
+		if from, err := Sender(signer, parsedTx); err != nil || from != sender {
+			t.Fatalf("sender mismatch: have %x (%v), want %x", from, err, sender)
+		}
+		if addr, err := FeePayer(signer, parsedTx); err != nil || addr != payer {
+			t.Fatalf("fee payer mismatch: have %x (%v), want %x", addr, err, payer)
+		}
+	}
+	if cost := tx.Cost(); cost.Cmp(big.NewInt(7)) != 0 {
+		t.Fatalf("sender cost mismatch: have %v, want 7", cost)
+	}
+	// A fee payer signing for somebody else is rejected
+	other, _ := crypto.GenerateKey()
+	forged, err := SignFeePayer(tx, signer, other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FeePayer(signer, forged); err != ErrInvalidFeePayer {
+		t.Fatalf("forged fee payer: have %v, want %v", err, ErrInvalidFeePayer)
+	}
+	// Signers predating the fork don't accept the transaction
+	if _, err := Sender(NewLondonSigner(big.NewInt(1)), tx); err != ErrTxTypeNotSupported {
+		t.Fatalf("london signer: have %v, want %v", err, ErrTxTypeNotSupported)
+	}
+}
@@ -9,10 +9,13 @@ import (
 
 type Byte32 [32]byte
 
+// limbBytesLen is the length of the limbs a word is split into, small enough for
+// each limb to be a valid field element.
+const limbBytesLen = 16
+
 func (b *Byte32) Hash() (*big.Int, error) {
-	first16 := new(big.Int).SetBytes(b[0:16])
-	last16 := new(big.Int).SetBytes(b[16:32])
-	hash, err := poseidon.Hash([]*big.Int{first16, last16})
+	hi, lo := b.Limbs()
+	hash, err := poseidon.Hash([]*big.Int{hi, lo})
 	if err != nil {
 		return nil, err
 	}
@@ -21,6 +24,29 @@ func (b *Byte32) Hash() (*big.Int, error) {
 
 func (b *Byte32) Bytes() []byte { return b[:] }
 
+// Limbs splits the big-endian word into its high and low 128 bits. Unlike the
+// word itself, both limbs always fit into the scalar field of the trie hash.
+func (b *Byte32) Limbs() (hi, lo *big.Int) {
+	hi = new(big.Int).SetBytes(b[:limbBytesLen])
+	lo = new(big.Int).SetBytes(b[limbBytesLen:])
+	return hi, lo
+}
+
+// NewByte32FromLimbs joins the high and low 128 bits of a word, as produced by
+// Limbs. It fails if a limb is negative or does not fit into 128 bits.
+func NewByte32FromLimbs(hi, lo *big.Int) (*Byte32, error) {
+	if hi.Sign() < 0 || hi.BitLen() > 8*limbBytesLen {
+		return nil, fmt.Errorf("high limb out of range: %v", hi)
+	}
+	if lo.Sign() < 0 || lo.BitLen() > 8*limbBytesLen {
+		return nil, fmt.Errorf("low limb out of range: %v", lo)
+	}
+	byte32 := new(Byte32)
+	hi.FillBytes(byte32[:limbBytesLen])
+	lo.FillBytes(byte32[limbBytesLen:])
+	return byte32, nil
+}
+
 // same action as common.Hash (truncate bytes longer than 32 bytes FROM beginning,
 // and padding 0 at the beginning for shorter bytes)
 func NewByte32FromBytes(b []byte) *Byte32 {
@@ -0,0 +1,45 @@
+package zktrie
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/utils"
+)
+
+func TestByte32Limbs(t *testing.T) {
+	words := [][]byte{
+		bytes.Repeat([]byte{0x00}, 32),
+		bytes.Repeat([]byte{0xff}, 32),
+		append(bytes.Repeat([]byte{0xff}, 16), bytes.Repeat([]byte{0x00}, 16)...),
+		{0x12, 0x34},
+	}
+	for i, word := range words {
+		b := NewByte32FromBytes(word)
+		hi, lo := b.Limbs()
+		if !utils.CheckBigIntInField(hi) || !utils.CheckBigIntInField(lo) {
+			t.Errorf("word %d: limbs out of field: hi %v lo %v", i, hi, lo)
+		}
+		joined, err := NewByte32FromLimbs(hi, lo)
+		if err != nil {
+			t.Fatalf("word %d: failed to join limbs: %v", i, err)
+		}
+		if *joined != *b {
+			t.Errorf("word %d: round trip mismatch: have %x, want %x", i, joined[:], b[:])
+		}
+	}
+}
+
+func TestByte32LimbsOutOfRange(t *testing.T) {
+	tooLarge := new(big.Int).Lsh(big.NewInt(1), 128)
+	if _, err := NewByte32FromLimbs(tooLarge, new(big.Int)); err == nil {
+		t.Error("oversized high limb accepted")
+	}
+	if _, err := NewByte32FromLimbs(new(big.Int), tooLarge); err == nil {
+		t.Error("oversized low limb accepted")
+	}
+	if _, err := NewByte32FromLimbs(big.NewInt(-1), new(big.Int)); err == nil {
+		t.Error("negative limb accepted")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math/big"
+	"strings"
 
 	"github.com/iden3/go-iden3-crypto/utils"
 
@@ -25,11 +26,39 @@ func (h Hash) MarshalText() ([]byte, error) {
 	return []byte(h.BigInt().String()), nil
 }
 
-// UnmarshalText implements the unmarshaler for the Hash type
+// UnmarshalText implements the unmarshaler for the Hash type. Besides the decimal
+// format produced by MarshalText, 0x-prefixed hex as read by NewHashFromHex is
+// accepted.
 func (h *Hash) UnmarshalText(b []byte) error {
-	ha, err := NewHashFromString(string(b))
+	var (
+		ha  *Hash
+		err error
+	)
+	if s := string(b); strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		ha, err = NewHashFromHex(s)
+	} else {
+		ha, err = NewHashFromString(s)
+	}
+	if err != nil {
+		return err
+	}
 	copy(h[:], ha[:])
-	return err
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, encoding the Hash in its
+// little endian layout, as used for the database keys of the trie nodes.
+func (h Hash) MarshalBinary() ([]byte, error) {
+	return common.CopyBytes(h[:]), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (h *Hash) UnmarshalBinary(b []byte) error {
+	if len(b) != ElemBytesLen {
+		return fmt.Errorf("expected %d bytes, found %d bytes", ElemBytesLen, len(b))
+	}
+	copy(h[:], b)
+	return nil
 }
 
 // String returns decimal representation in string format of the Hash
@@ -101,11 +130,18 @@ func NewHashFromHex(h string) (*Hash, error) {
 	return NewHashFromBytes(ReverseByteOrder(common.FromHex(h)))
 }
 
-// NewHashFromString returns a *Hash representation of the given decimal string
+// NewHashFromString returns a *Hash representation of the given decimal string,
+// rejecting signs, underscores and values that do not fit into 32 bytes.
 func NewHashFromString(s string) (*Hash, error) {
+	if s == "" || strings.ContainsAny(s, "+-_") {
+		return nil, fmt.Errorf("can not parse %q to Hash", s)
+	}
 	bi, ok := new(big.Int).SetString(s, 10)
 	if !ok {
-		return nil, fmt.Errorf("can not parse string to Hash")
+		return nil, fmt.Errorf("can not parse %q to Hash", s)
+	}
+	if bi.BitLen() > 8*ElemBytesLen {
+		return nil, fmt.Errorf("value %q exceeds %d bytes", s, ElemBytesLen)
 	}
 	return NewHashFromBigInt(bi), nil
 }
@@ -0,0 +1,79 @@
+package zktrie
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestHashHexParsing(t *testing.T) {
+	// Hex strings are the little endian layout of all 32 bytes, with or without
+	// the 0x prefix
+	want := NewHashFromBigInt(big.NewInt(0x1234))
+	for _, s := range []string{want.Hex(), "0x" + want.Hex(), "0x3412" + "000000000000000000000000000000000000000000000000000000000000"} {
+		h, err := NewHashFromHex(s)
+		if err != nil {
+			t.Fatalf("%q: failed to parse: %v", s, err)
+		}
+		if *h != *want {
+			t.Errorf("%q: hash mismatch: have %v, want %v", s, h.BigInt(), want.BigInt())
+		}
+	}
+	// Short strings are not numbers
+	for _, s := range []string{"", "0x", "0x1234", "1234", "0xzz"} {
+		if _, err := NewHashFromHex(s); err == nil {
+			t.Errorf("%q: invalid hash accepted", s)
+		}
+	}
+}
+
+func TestHashStringParsing(t *testing.T) {
+	h, err := NewHashFromString("4660")
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if want := NewHashFromBigInt(big.NewInt(0x1234)); *h != *want {
+		t.Errorf("hash mismatch: have %v, want %v", h.BigInt(), want.BigInt())
+	}
+	for _, s := range []string{"", "-1", "+1", "1_000", "12ab", "0x1234"} {
+		if _, err := NewHashFromString(s); err == nil {
+			t.Errorf("%q: invalid hash accepted", s)
+		}
+	}
+	if _, err := NewHashFromString("1" + new(big.Int).Lsh(big.NewInt(1), 256).String()); err == nil {
+		t.Error("oversized hash accepted")
+	}
+}
+
+func TestHashTextRoundTrip(t *testing.T) {
+	for _, bi := range []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(0x1234), new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))} {
+		h := NewHashFromBigInt(bi)
+
+		// Decimal, as produced by MarshalText
+		text, err := h.MarshalText()
+		if err != nil {
+			t.Fatalf("failed to marshal %v: %v", bi, err)
+		}
+		var dec Hash
+		if err := dec.UnmarshalText(text); err != nil {
+			t.Fatalf("failed to unmarshal %s: %v", text, err)
+		}
+		if dec != *h {
+			t.Errorf("decimal round trip mismatch: have %v, want %v", dec.BigInt(), bi)
+		}
+		// Hex needs the 0x prefix, as digit only strings are decimal
+		hex := "0x" + h.Hex()
+		if err := dec.UnmarshalText([]byte(hex)); err != nil {
+			t.Fatalf("failed to unmarshal %s: %v", hex, err)
+		}
+		if dec != *h {
+			t.Errorf("hex round trip mismatch: have %v, want %v", dec.BigInt(), bi)
+		}
+		parsed, err := NewHashFromHex(h.Hex())
+		if err != nil {
+			t.Fatalf("failed to parse hex %s: %v", h.Hex(), err)
+		}
+		if *parsed != *h {
+			t.Errorf("unprefixed hex round trip mismatch: have %v, want %v", parsed.BigInt(), bi)
+		}
+	}
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/scroll-tech/go-ethereum/crypto/blake2b"
 	"github.com/scroll-tech/go-ethereum/crypto/bls12381"
 	"github.com/scroll-tech/go-ethereum/crypto/bn256"
+	"github.com/scroll-tech/go-ethereum/crypto/secp256r1"
 	"github.com/scroll-tech/go-ethereum/params"
 
 	//lint:ignore SA1019 Needed for precompile
@@ -106,11 +107,19 @@ var PrecompiledContractsBLS = map[common.Address]PrecompiledContract{
 	common.BytesToAddress([]byte{18}): &bls12381MapG2{},
 }
 
+// PrecompiledContractsP256Verify contains the secp256r1 signature verification
+// contract specified in RIP-7212.
+var PrecompiledContractsP256Verify = map[common.Address]PrecompiledContract{
+	common.BytesToAddress([]byte{1, 0}): &p256Verify{},
+}
+
 var (
-	PrecompiledAddressesBerlin    []common.Address
-	PrecompiledAddressesIstanbul  []common.Address
-	PrecompiledAddressesByzantium []common.Address
-	PrecompiledAddressesHomestead []common.Address
+	PrecompiledAddressesBerlin     []common.Address
+	PrecompiledAddressesIstanbul   []common.Address
+	PrecompiledAddressesByzantium  []common.Address
+	PrecompiledAddressesHomestead  []common.Address
+	PrecompiledAddressesBLS        []common.Address
+	PrecompiledAddressesP256Verify []common.Address
 )
 
 func init() {
@@ -126,20 +135,54 @@ func init() {
 	for k := range PrecompiledContractsBerlin {
 		PrecompiledAddressesBerlin = append(PrecompiledAddressesBerlin, k)
 	}
+	for k := range PrecompiledContractsBLS {
+		PrecompiledAddressesBLS = append(PrecompiledAddressesBLS, k)
+	}
+	for k := range PrecompiledContractsP256Verify {
+		PrecompiledAddressesP256Verify = append(PrecompiledAddressesP256Verify, k)
+	}
 }
 
-// ActivePrecompiles returns the precompiles enabled with the current configuration.
+// ActivePrecompiles returns the precompiles enabled with the current configuration,
+// including the registered ones.
 func ActivePrecompiles(rules params.Rules) []common.Address {
+	var addrs []common.Address
 	switch {
 	case rules.IsBerlin:
-		return PrecompiledAddressesBerlin
+		addrs = PrecompiledAddressesBerlin
 	case rules.IsIstanbul:
-		return PrecompiledAddressesIstanbul
+		addrs = PrecompiledAddressesIstanbul
 	case rules.IsByzantium:
-		return PrecompiledAddressesByzantium
+		addrs = PrecompiledAddressesByzantium
 	default:
-		return PrecompiledAddressesHomestead
+		addrs = PrecompiledAddressesHomestead
+	}
+	if rules.IsBLS12381 || rules.IsP256Verify {
+		addrs = append([]common.Address{}, addrs...)
+		if rules.IsBLS12381 {
+			addrs = append(addrs, PrecompiledAddressesBLS...)
+		}
+		if rules.IsP256Verify {
+			addrs = append(addrs, PrecompiledAddressesP256Verify...)
+		}
+	}
+	return withRegisteredPrecompiles(rules, addrs)
+}
+
+// optionalPrecompile returns the precompiled contract at the given address out
+// of the ones activated by their own fork, independently of the protocol release.
+func optionalPrecompile(rules params.Rules, addr common.Address) (PrecompiledContract, bool) {
+	if rules.IsBLS12381 {
+		if p, ok := PrecompiledContractsBLS[addr]; ok {
+			return p, true
+		}
+	}
+	if rules.IsP256Verify {
+		if p, ok := PrecompiledContractsP256Verify[addr]; ok {
+			return p, true
+		}
 	}
+	return nil, false
 }
 
 // RunPrecompiledContract runs and evaluates the output of a precompiled contract.
@@ -558,6 +601,33 @@ func (c *bn256PairingByzantium) Run(input []byte) ([]byte, error) {
 	return runBn256Pairing(input)
 }
 
+// p256Verify implements the secp256r1 signature verification precompile
+// specified in RIP-7212.
+type p256Verify struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *p256Verify) RequiredGas(input []byte) uint64 {
+	return params.P256VerifyGas
+}
+
+// Run verifies the signature over the 160 bytes input: the hash, r, s and the
+// public key coordinates x and y. It returns 1 as a 32 byte word for valid
+// signatures and nothing otherwise.
+func (c *p256Verify) Run(input []byte) ([]byte, error) {
+	const p256VerifyInputLength = 160
+	if len(input) != p256VerifyInputLength {
+		return nil, nil
+	}
+	hash := input[:32]
+	r, s := new(big.Int).SetBytes(input[32:64]), new(big.Int).SetBytes(input[64:96])
+	x, y := new(big.Int).SetBytes(input[96:128]), new(big.Int).SetBytes(input[128:160])
+
+	if !secp256r1.Verify(hash, r, s, x, y) {
+		return nil, nil
+	}
+	return common.LeftPadBytes(common.Big1.Bytes(), 32), nil
+}
+
 type blake2F struct{}
 
 func (c *blake2F) RequiredGas(input []byte) uint64 {
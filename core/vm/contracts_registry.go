@@ -0,0 +1,169 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/params"
+)
+
+// Fork identifies the protocol upgrade at which a registered precompiled contract
+// becomes active.
+type Fork int
+
+const (
+	ForkFrontier Fork = iota
+	ForkHomestead
+	ForkEIP150
+	ForkEIP158
+	ForkByzantium
+	ForkConstantinople
+	ForkPetersburg
+	ForkIstanbul
+	ForkBerlin
+	ForkLondon
+	ForkFeePayer
+	ForkP256Verify
+	ForkBLS12381
+	ForkCurie
+
+	lastFork = ForkCurie
+)
+
+// active returns whether the fork is enabled under the given rules.
+func (f Fork) active(rules params.Rules) bool {
+	switch f {
+	case ForkFrontier:
+		return true
+	case ForkHomestead:
+		return rules.IsHomestead
+	case ForkEIP150:
+		return rules.IsEIP150
+	case ForkEIP158:
+		return rules.IsEIP158
+	case ForkByzantium:
+		return rules.IsByzantium
+	case ForkConstantinople:
+		return rules.IsConstantinople
+	case ForkPetersburg:
+		return rules.IsPetersburg
+	case ForkIstanbul:
+		return rules.IsIstanbul
+	case ForkBerlin:
+		return rules.IsBerlin
+	case ForkLondon:
+		return rules.IsLondon
+	case ForkFeePayer:
+		return rules.IsFeePayer
+	case ForkP256Verify:
+		return rules.IsP256Verify
+	case ForkBLS12381:
+		return rules.IsBLS12381
+	case ForkCurie:
+		return rules.IsCurie
+	}
+	return false
+}
+
+// registeredPrecompile is a precompiled contract added through RegisterPrecompile.
+type registeredPrecompile struct {
+	contract PrecompiledContract
+	active   func(params.Rules) bool
+}
+
+var (
+	errNilPrecompile      = errors.New("nil precompiled contract")
+	errUnknownFork        = errors.New("unknown activation fork")
+	errNilActivation      = errors.New("nil activation predicate")
+	errPrecompileConflict = errors.New("address already used by a precompiled contract")
+)
+
+// registeredPrecompiles contains the precompiled contracts added on top of the
+// built-in ones, activated at their respective forks.
+var registeredPrecompiles = make(map[common.Address]registeredPrecompile)
+
+// RegisterPrecompile adds a precompiled contract at the given address, active from
+// the given fork onwards. It allows forks of the protocol to add their own native
+// contracts without modifying the built-in precompile sets.
+//
+// The registry is not safe for concurrent use, contracts must be registered before
+// any EVM is created, typically from a package init function.
+func RegisterPrecompile(addr common.Address, p PrecompiledContract, fork Fork) error {
+	if fork < ForkFrontier || fork > lastFork {
+		return fmt.Errorf("%w: %d", errUnknownFork, fork)
+	}
+	return RegisterPrecompileFunc(addr, p, fork.active)
+}
+
+// RegisterPrecompileFunc adds a precompiled contract at the given address, active
+// whenever the given predicate holds for the rules of the block. It allows gating
+// contracts on upgrades the Fork constants don't cover, e.g. rollup features.
+// Like RegisterPrecompile, it must be called before any EVM is created.
+func RegisterPrecompileFunc(addr common.Address, p PrecompiledContract, active func(params.Rules) bool) error {
+	if p == nil {
+		return errNilPrecompile
+	}
+	if active == nil {
+		return errNilActivation
+	}
+	for _, builtin := range []map[common.Address]PrecompiledContract{
+		PrecompiledContractsHomestead,
+		PrecompiledContractsByzantium,
+		PrecompiledContractsIstanbul,
+		PrecompiledContractsBerlin,
+		PrecompiledContractsBLS,
+		PrecompiledContractsP256Verify,
+	} {
+		if _, ok := builtin[addr]; ok {
+			return fmt.Errorf("%w: %x", errPrecompileConflict, addr)
+		}
+	}
+	if _, ok := registeredPrecompiles[addr]; ok {
+		return fmt.Errorf("%w: %x", errPrecompileConflict, addr)
+	}
+	registeredPrecompiles[addr] = registeredPrecompile{contract: p, active: active}
+	return nil
+}
+
+// registeredPrecompileAt returns the registered precompiled contract at the given
+// address, if it is active under the given rules.
+func registeredPrecompileAt(rules params.Rules, addr common.Address) (PrecompiledContract, bool) {
+	p, ok := registeredPrecompiles[addr]
+	if !ok || !p.active(rules) {
+		return nil, false
+	}
+	return p.contract, true
+}
+
+// withRegisteredPrecompiles returns the given addresses extended with the ones of
+// the registered precompiled contracts active under the given rules.
+func withRegisteredPrecompiles(rules params.Rules, addrs []common.Address) []common.Address {
+	if len(registeredPrecompiles) == 0 {
+		return addrs
+	}
+	active := make([]common.Address, len(addrs), len(addrs)+len(registeredPrecompiles))
+	copy(active, addrs)
+	for addr, p := range registeredPrecompiles {
+		if p.active(rules) {
+			active = append(active, addr)
+		}
+	}
+	return active
+}
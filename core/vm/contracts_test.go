@@ -19,12 +19,14 @@ package vm
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"testing"
 	"time"
 
 	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/params"
 )
 
 // precompiledTest defines the input/output pairs for precompiled contract tests.
@@ -65,6 +67,7 @@ var allPrecompiles = map[common.Address]PrecompiledContract{
 	common.BytesToAddress([]byte{16}):   &bls12381Pairing{},
 	common.BytesToAddress([]byte{17}):   &bls12381MapG1{},
 	common.BytesToAddress([]byte{18}):   &bls12381MapG2{},
+	common.BytesToAddress([]byte{1, 0}): &p256Verify{},
 }
 
 // EIP-152 test vectors
@@ -302,6 +305,9 @@ func benchJson(name, addr string, b *testing.B) {
 	}
 }
 
+func TestPrecompiledP256Verify(t *testing.T)      { testJson("p256Verify", "100", t) }
+func BenchmarkPrecompiledP256Verify(b *testing.B) { benchJson("p256Verify", "100", b) }
+
 func TestPrecompiledBLS12381G1Add(t *testing.T)      { testJson("blsG1Add", "0a", t) }
 func TestPrecompiledBLS12381G1Mul(t *testing.T)      { testJson("blsG1Mul", "0b", t) }
 func TestPrecompiledBLS12381G1MultiExp(t *testing.T) { testJson("blsG1MultiExp", "0c", t) }
@@ -391,3 +397,122 @@ func BenchmarkPrecompiledBLS12381G2MultiExpWorstCase(b *testing.B) {
 	}
 	benchmarkPrecompiled("0f", testcase, b)
 }
+
+// TestRegisterPrecompile tests that registered precompiles are only active from
+// their activation fork onwards and can't shadow existing ones.
+func TestRegisterPrecompile(t *testing.T) {
+	addr := common.HexToAddress("0xff01")
+	defer delete(registeredPrecompiles, addr)
+
+	if err := RegisterPrecompile(addr, &dataCopy{}, ForkLondon); err != nil {
+		t.Fatalf("failed to register precompile: %v", err)
+	}
+	if err := RegisterPrecompile(addr, &dataCopy{}, ForkLondon); !errors.Is(err, errPrecompileConflict) {
+		t.Errorf("duplicate registration: have %v, want %v", err, errPrecompileConflict)
+	}
+	if err := RegisterPrecompile(common.BytesToAddress([]byte{1}), &dataCopy{}, ForkLondon); !errors.Is(err, errPrecompileConflict) {
+		t.Errorf("built-in shadowing: have %v, want %v", err, errPrecompileConflict)
+	}
+	if err := RegisterPrecompile(common.HexToAddress("0xff02"), &dataCopy{}, lastFork+1); !errors.Is(err, errUnknownFork) {
+		t.Errorf("unknown fork: have %v, want %v", err, errUnknownFork)
+	}
+	if err := RegisterPrecompileFunc(common.HexToAddress("0xff02"), &dataCopy{}, nil); !errors.Is(err, errNilActivation) {
+		t.Errorf("nil predicate: have %v, want %v", err, errNilActivation)
+	}
+	contains := func(addrs []common.Address) bool {
+		for _, a := range addrs {
+			if a == addr {
+				return true
+			}
+		}
+		return false
+	}
+	for _, rules := range []params.Rules{{IsBerlin: true}, {IsBerlin: true, IsLondon: true}} {
+		evm := &EVM{chainRules: rules}
+		_, ok := evm.precompile(addr)
+		if ok != rules.IsLondon {
+			t.Errorf("rules %+v: precompile active %v, want %v", rules, ok, rules.IsLondon)
+		}
+		if contains(ActivePrecompiles(rules)) != rules.IsLondon {
+			t.Errorf("rules %+v: active precompiles listing mismatch", rules)
+		}
+		if _, ok := evm.precompile(common.BytesToAddress([]byte{1})); !ok {
+			t.Errorf("rules %+v: built-in precompile missing", rules)
+		}
+	}
+	if len(ActivePrecompiles(params.Rules{IsBerlin: true, IsLondon: true})) != len(PrecompiledAddressesBerlin)+1 {
+		t.Errorf("active precompiles count mismatch")
+	}
+}
+
+// TestRegisterPrecompileRollupForks tests that registered precompiles can be
+// activated at the rollup upgrades, either by fork or by predicate.
+func TestRegisterPrecompileRollupForks(t *testing.T) {
+	var (
+		curie   = common.HexToAddress("0xff03")
+		feature = common.HexToAddress("0xff04")
+	)
+	defer delete(registeredPrecompiles, curie)
+	defer delete(registeredPrecompiles, feature)
+
+	if err := RegisterPrecompile(curie, &dataCopy{}, ForkCurie); err != nil {
+		t.Fatalf("failed to register precompile: %v", err)
+	}
+	active := func(rules params.Rules) bool { return rules.IsCurie && rules.IsBLS12381 }
+	if err := RegisterPrecompileFunc(feature, &dataCopy{}, active); err != nil {
+		t.Fatalf("failed to register precompile: %v", err)
+	}
+	for _, rules := range []params.Rules{
+		{IsLondon: true},
+		{IsLondon: true, IsCurie: true},
+		{IsLondon: true, IsCurie: true, IsBLS12381: true},
+	} {
+		evm := &EVM{chainRules: rules}
+		if _, ok := evm.precompile(curie); ok != rules.IsCurie {
+			t.Errorf("rules %+v: fork gated precompile active %v, want %v", rules, ok, rules.IsCurie)
+		}
+		if _, ok := evm.precompile(feature); ok != active(rules) {
+			t.Errorf("rules %+v: predicate gated precompile active %v, want %v", rules, ok, active(rules))
+		}
+	}
+	for fork := ForkFeePayer; fork <= lastFork; fork++ {
+		if fork.active(params.Rules{IsLondon: true}) {
+			t.Errorf("fork %d active without its rule", fork)
+		}
+	}
+}
+
+func TestOptionalPrecompiles(t *testing.T) {
+	p256 := common.BytesToAddress([]byte{1, 0})
+	bls := common.BytesToAddress([]byte{10})
+
+	contains := func(addrs []common.Address, addr common.Address) bool {
+		for _, a := range addrs {
+			if a == addr {
+				return true
+			}
+		}
+		return false
+	}
+	for _, rules := range []params.Rules{
+		{IsBerlin: true},
+		{IsBerlin: true, IsP256Verify: true},
+		{IsBerlin: true, IsBLS12381: true},
+		{IsBerlin: true, IsP256Verify: true, IsBLS12381: true},
+	} {
+		evm := &EVM{chainRules: rules}
+		for addr, active := range map[common.Address]bool{p256: rules.IsP256Verify, bls: rules.IsBLS12381} {
+			if _, ok := evm.precompile(addr); ok != active {
+				t.Errorf("rules %+v: precompile %x active %v, want %v", rules, addr, ok, active)
+			}
+			if contains(ActivePrecompiles(rules), addr) != active {
+				t.Errorf("rules %+v: active precompiles listing mismatch for %x", rules, addr)
+			}
+		}
+	}
+	// Activating the optional precompiles must not extend the release's set
+	ActivePrecompiles(params.Rules{IsBerlin: true, IsP256Verify: true, IsBLS12381: true})
+	if contains(PrecompiledAddressesBerlin, p256) || contains(PrecompiledAddressesBerlin, bls) {
+		t.Errorf("berlin precompile addresses modified")
+	}
+}
@@ -54,8 +54,13 @@ func (evm *EVM) precompile(addr common.Address) (PrecompiledContract, bool) {
 	default:
 		precompiles = PrecompiledContractsHomestead
 	}
-	p, ok := precompiles[addr]
-	return p, ok
+	if p, ok := precompiles[addr]; ok {
+		return p, true
+	}
+	if p, ok := optionalPrecompile(evm.chainRules, addr); ok {
+		return p, true
+	}
+	return registeredPrecompileAt(evm.chainRules, addr)
 }
 
 // BlockContext provides the EVM with auxiliary information. Once provided
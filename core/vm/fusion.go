@@ -0,0 +1,98 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"github.com/holiman/uint256"
+
+	"github.com/scroll-tech/go-ethereum/params"
+)
+
+// fuse executes the instruction sequence starting at pc in a single interpreter
+// step if it matches one of the fused patterns, returning the program counter
+// of the instruction following the sequence.
+//
+// A sequence is only fused if none of its instructions can fail: there's enough
+// gas for all of them and the stack can't underflow or overflow along the way.
+// Otherwise nothing is modified and false is returned, so the instructions are
+// executed one by one and fail exactly as they would without fusion. Fused
+// instructions are never traced, the caller has to disable fusion if a tracer
+// is set.
+func (in *EVMInterpreter) fuse(pc uint64, contract *Contract, stack *Stack) (uint64, bool) {
+	code := contract.Code
+	if pc+1 >= uint64(len(code)) {
+		return 0, false
+	}
+	var (
+		op   = OpCode(code[pc])
+		next = OpCode(code[pc+1])
+		sLen = stack.len()
+	)
+	switch {
+	case op == PUSH1 && pc+4 < uint64(len(code)) && OpCode(code[pc+2]) == PUSH1 && OpCode(code[pc+4]) == ADD:
+		// PUSH1 a PUSH1 b ADD => PUSH(a + b)
+		if sLen > int(params.StackLimit)-2 || !in.useGas(contract, PUSH1, PUSH1, ADD) {
+			return 0, false
+		}
+		stack.push(new(uint256.Int).SetUint64(uint64(code[pc+1]) + uint64(code[pc+3])))
+		return pc + 5, true
+
+	case op == PUSH1 && pc+2 < uint64(len(code)) && OpCode(code[pc+2]) == ADD:
+		// PUSH1 a ADD => top += a
+		if sLen < 1 || sLen >= int(params.StackLimit) || !in.useGas(contract, PUSH1, ADD) {
+			return 0, false
+		}
+		top := stack.peek()
+		top.Add(top, new(uint256.Int).SetUint64(uint64(code[pc+1])))
+		return pc + 3, true
+
+	case op >= DUP1 && op <= DUP16 && next >= SWAP1 && next <= SWAP16:
+		// DUPn SWAPm
+		n, m := int(op-DUP1)+1, int(next-SWAP1)+1
+		if sLen < n || sLen < m || sLen >= int(params.StackLimit) || !in.useGas(contract, op, next) {
+			return 0, false
+		}
+		stack.dup(n)
+		stack.swap(m + 1)
+		return pc + 2, true
+
+	case op == SWAP1 && next == POP:
+		// SWAP1 POP => drop the second item
+		if sLen < 2 || !in.useGas(contract, SWAP1, POP) {
+			return 0, false
+		}
+		stack.swap(2)
+		stack.pop()
+		return pc + 2, true
+	}
+	return 0, false
+}
+
+// useGas deducts the summed constant gas of the given operations from the
+// contract, or returns false without deducting anything if it has not enough
+// gas left for all of them.
+func (in *EVMInterpreter) useGas(contract *Contract, ops ...OpCode) bool {
+	var gas uint64
+	for _, op := range ops {
+		operation := in.cfg.JumpTable[op]
+		if operation == nil {
+			return false
+		}
+		gas += operation.constantGas
+	}
+	return contract.UseGas(gas)
+}
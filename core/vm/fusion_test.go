@@ -0,0 +1,117 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/params"
+)
+
+// runFusion executes the given code with or without instruction fusion and
+// returns the output, the gas left and the error string.
+func runFusion(code []byte, gas uint64, fusion bool) ([]byte, uint64, string) {
+	evm := NewEVM(BlockContext{}, TxContext{}, nil, params.TestChainConfig, Config{EnableInstructionFusion: fusion})
+	contract := NewContract(AccountRef(common.Address{}), AccountRef(common.Address{0x01}), new(big.Int), gas)
+	contract.Code = code
+
+	ret, err := evm.interpreter.Run(contract, nil, false)
+	if err != nil {
+		return ret, contract.Gas, err.Error()
+	}
+	return ret, contract.Gas, ""
+}
+
+// returnTop appends code returning the top stack item to the given program.
+func returnTop(code []byte) []byte {
+	return append(code, byte(PUSH1), 0, byte(MSTORE), byte(PUSH1), 32, byte(PUSH1), 0, byte(RETURN))
+}
+
+// pushes returns code pushing n ones to the stack.
+func pushes(n int) []byte {
+	return bytes.Repeat([]byte{byte(PUSH1), 1}, n)
+}
+
+func concat(parts ...[]byte) []byte {
+	var code []byte
+	for _, part := range parts {
+		code = append(code, part...)
+	}
+	return code
+}
+
+// checkFusion checks that executing the code with instruction fusion yields the
+// same result as executing it instruction by instruction.
+func checkFusion(t *testing.T, name string, code []byte, gas uint64) {
+	t.Helper()
+
+	wantRet, wantGas, wantErr := runFusion(code, gas, false)
+	haveRet, haveGas, haveErr := runFusion(code, gas, true)
+	if !bytes.Equal(haveRet, wantRet) || haveGas != wantGas || haveErr != wantErr {
+		t.Errorf("%s: result mismatch: have (%x, %d, %q), want (%x, %d, %q)", name, haveRet, haveGas, haveErr, wantRet, wantGas, wantErr)
+	}
+}
+
+func TestFusionConformance(t *testing.T) {
+	tests := []struct {
+		name string
+		code []byte
+		gas  uint64
+	}{
+		{"push-push-add", returnTop([]byte{byte(PUSH1), 0xff, byte(PUSH1), 0xff, byte(ADD)}), 100000},
+		{"push-add", returnTop([]byte{byte(PUSH1), 0xfe, byte(PUSH1), 0xff, byte(ADD)}), 100000},
+		{"push-add-wrap", returnTop(concat([]byte{byte(PUSH32)}, bytes.Repeat([]byte{0xff}, 32), []byte{byte(PUSH1), 2, byte(ADD)})), 100000},
+		{"push-add-underflow", []byte{byte(PUSH1), 1, byte(ADD)}, 100000},
+		{"dup-swap", returnTop(concat(pushes(1), []byte{byte(PUSH1), 2, byte(PUSH1), 3, byte(DUP2), byte(SWAP3), byte(POP), byte(POP)})), 100000},
+		{"dup-swap-underflow", []byte{byte(PUSH1), 1, byte(DUP1), byte(SWAP2)}, 100000},
+		{"dup-overflow", concat(pushes(1024), []byte{byte(DUP1), byte(SWAP1)}), 100000},
+		{"swap-pop", returnTop([]byte{byte(PUSH1), 1, byte(PUSH1), 2, byte(SWAP1), byte(POP)}), 100000},
+		{"swap-pop-underflow", []byte{byte(PUSH1), 1, byte(SWAP1), byte(POP)}, 100000},
+		{"push-push-add-limit", returnTop(concat(pushes(1022), []byte{byte(PUSH1), 1, byte(PUSH1), 2, byte(ADD)})), 100000},
+		{"push-push-add-overflow", concat(pushes(1023), []byte{byte(PUSH1), 1, byte(PUSH1), 2, byte(ADD)}), 100000},
+		{"push-add-overflow", concat(pushes(1024), []byte{byte(PUSH1), 2, byte(ADD)}), 100000},
+		{"push-push-add-oog", []byte{byte(PUSH1), 1, byte(PUSH1), 2, byte(ADD)}, 8},
+		{"truncated", []byte{byte(PUSH1), 1, byte(PUSH1)}, 100000},
+	}
+	for _, tt := range tests {
+		checkFusion(t, tt.name, tt.code, tt.gas)
+	}
+}
+
+// TestFusionRandom checks the conformance of instruction fusion on random
+// programs built from the fused instructions.
+func TestFusionRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	ops := []OpCode{PUSH1, PUSH1, PUSH1, ADD, DUP1, DUP2, DUP3, SWAP1, SWAP2, SWAP3, POP}
+
+	for i := 0; i < 2000; i++ {
+		var code []byte
+		for j := rng.Intn(32); j > 0; j-- {
+			op := ops[rng.Intn(len(ops))]
+			code = append(code, byte(op))
+			if op == PUSH1 {
+				code = append(code, byte(rng.Intn(256)))
+			}
+		}
+		checkFusion(t, fmt.Sprintf("program %d", i), returnTop(code), uint64(rng.Intn(200)))
+	}
+}
@@ -21,11 +21,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/holiman/uint256"
 
 	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/math"
 	"github.com/scroll-tech/go-ethereum/crypto"
 	"github.com/scroll-tech/go-ethereum/params"
 )
@@ -651,3 +654,139 @@ func TestCreate2Addreses(t *testing.T) {
 		}
 	}
 }
+
+func TestOpMCopy(t *testing.T) {
+	// Test cases from https://eips.ethereum.org/EIPS/eip-5656#test-cases
+	for i, tc := range []struct {
+		dst, src, len string
+		pre           string
+		want          string
+		wantGas       uint64
+	}{
+		{ // MCOPY 0 32 32 - copy 32 bytes from offset 32 to offset 0.
+			dst: "0x0", src: "0x20", len: "0x20",
+			pre:     "0000000000000000000000000000000000000000000000000000000000000000 000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+			want:    "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f 000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+			wantGas: 6,
+		},
+		{ // MCOPY 0 0 32 - copy 32 bytes from offset 0 to offset 0.
+			dst: "0x0", src: "0x0", len: "0x20",
+			pre:     "0101010101010101010101010101010101010101010101010101010101010101",
+			want:    "0101010101010101010101010101010101010101010101010101010101010101",
+			wantGas: 6,
+		},
+		{ // MCOPY 0 1 8 - copy 8 bytes from offset 1 to offset 0 (overlapping).
+			dst: "0x0", src: "0x1", len: "0x8",
+			pre:     "000102030405060708 000000000000000000000000000000000000000000000000",
+			want:    "010203040506070808 000000000000000000000000000000000000000000000000",
+			wantGas: 6,
+		},
+		{ // MCOPY 1 0 8 - copy 8 bytes from offset 0 to offset 1 (overlapping).
+			dst: "0x1", src: "0x0", len: "0x8",
+			pre:     "000102030405060708 000000000000000000000000000000000000000000000000",
+			want:    "000001020304050607 000000000000000000000000000000000000000000000000",
+			wantGas: 6,
+		},
+		{ // MCOPY 0x20 0 0x20 - copy into unexpanded memory.
+			dst: "0x20", src: "0x0", len: "0x20",
+			pre:     "0101010101010101010101010101010101010101010101010101010101010101",
+			want:    "0101010101010101010101010101010101010101010101010101010101010101 0101010101010101010101010101010101010101010101010101010101010101",
+			wantGas: 9,
+		},
+		{ // MCOPY 0xffffffffff 0xffffffffff 0 - zero length copies don't expand memory.
+			dst: "0xffffffffff", src: "0xffffffffff", len: "0x0",
+			pre:     "",
+			want:    "",
+			wantGas: 3,
+		},
+	} {
+		var (
+			env            = NewEVM(BlockContext{}, TxContext{}, nil, params.TestChainConfig, Config{})
+			stack          = newstack()
+			pc             = uint64(0)
+			evmInterpreter = env.interpreter
+		)
+		data := common.FromHex(strings.ReplaceAll(tc.pre, " ", ""))
+		// Set pre, accounting for its expansion cost
+		mem := NewMemory()
+		if _, err := memoryGasCost(mem, uint64(len(data))); err != nil {
+			t.Fatal(err)
+		}
+		mem.Resize(uint64(len(data)))
+		mem.Set(0, uint64(len(data)), data)
+		// Push stack args
+		stack.push(new(uint256.Int).SetBytes(common.FromHex(tc.len)))
+		stack.push(new(uint256.Int).SetBytes(common.FromHex(tc.src)))
+		stack.push(new(uint256.Int).SetBytes(common.FromHex(tc.dst)))
+		// Expand memory and charge gas the way the interpreter does
+		memorySize, overflow := memoryMcopy(stack)
+		if overflow {
+			t.Fatalf("test %d: memory size overflow", i)
+		}
+		if memorySize, overflow = math.SafeMul(toWordSize(memorySize), 32); overflow {
+			t.Fatalf("test %d: memory size overflow", i)
+		}
+		dynamicGas, err := gasMcopy(env, nil, stack, mem, memorySize)
+		if err != nil {
+			t.Fatalf("test %d: gas calculation failed: %v", i, err)
+		}
+		if have := GasFastestStep + dynamicGas; have != tc.wantGas {
+			t.Errorf("test %d: gas mismatch: have %d, want %d", i, have, tc.wantGas)
+		}
+		if memorySize > 0 {
+			mem.Resize(memorySize)
+		}
+		opMcopy(&pc, evmInterpreter, &ScopeContext{mem, stack, nil})
+		want := common.FromHex(strings.ReplaceAll(tc.want, " ", ""))
+		if have := mem.store; !bytes.Equal(want, have) {
+			t.Errorf("test %d: memory mismatch: have %x, want %x", i, have, want)
+		}
+	}
+}
+
+func TestFeatureForkInstructions(t *testing.T) {
+	config := *params.TestChainConfig
+	config.CurieBlock = big.NewInt(20)
+	config.FeatureForks = []*params.FeatureFork{
+		{Block: big.NewInt(10), Features: params.NewFeatureSet(params.FeaturePush0, params.FeatureMcopy)},
+	}
+	for _, tt := range []struct {
+		number int64
+		want   []OpCode
+		absent []OpCode
+	}{
+		{9, nil, []OpCode{PUSH0, MCOPY, TLOAD, TSTORE}},
+		{10, []OpCode{PUSH0, MCOPY}, []OpCode{TLOAD, TSTORE}},
+		{20, []OpCode{PUSH0, MCOPY, TLOAD, TSTORE}, nil},
+	} {
+		env := NewEVM(BlockContext{BlockNumber: big.NewInt(tt.number)}, TxContext{}, nil, &config, Config{})
+		for _, op := range tt.want {
+			if env.interpreter.cfg.JumpTable[op] == nil {
+				t.Errorf("block %d: %v missing", tt.number, op)
+			}
+		}
+		for _, op := range tt.absent {
+			if env.interpreter.cfg.JumpTable[op] != nil {
+				t.Errorf("block %d: %v enabled", tt.number, op)
+			}
+		}
+	}
+	// The shared instruction sets must not be modified
+	if londonInstructionSet[PUSH0] != nil || curieInstructionSet[PUSH0] != nil {
+		t.Fatal("feature fork modified the shared instruction sets")
+	}
+}
+
+func TestOpPush0(t *testing.T) {
+	var (
+		env            = NewEVM(BlockContext{}, TxContext{}, nil, params.TestChainConfig, Config{})
+		stack          = newstack()
+		pc             = uint64(0)
+		evmInterpreter = env.interpreter
+	)
+	stack.push(new(uint256.Int).SetUint64(1))
+	opPush0(&pc, evmInterpreter, &ScopeContext{nil, stack, nil})
+	if stack.len() != 2 || !stack.peek().IsZero() {
+		t.Fatalf("unexpected stack after PUSH0: %v", stack.data)
+	}
+}
@@ -18,11 +18,13 @@ package vm
 
 import (
 	"hash"
+	"sync"
 	"sync/atomic"
 
 	"github.com/scroll-tech/go-ethereum/common"
 	"github.com/scroll-tech/go-ethereum/common/math"
 	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/params"
 )
 
 // Config are the configuration options for the Interpreter
@@ -32,6 +34,7 @@ type Config struct {
 	NoRecursion             bool      // Disables call, callcode, delegate call and create
 	NoBaseFee               bool      // Forces the EIP-1559 baseFee to 0 (needed for 0 price calls)
 	EnablePreimageRecording bool      // Enables recording of SHA3/keccak preimages
+	EnableInstructionFusion bool      // Enables fused execution of common opcode sequences, ignored while debugging
 
 	JumpTable [256]*operation // EVM instruction table, automatically populated if unset
 
@@ -46,6 +49,29 @@ type ScopeContext struct {
 	Contract *Contract
 }
 
+var scopePool = sync.Pool{
+	New: func() interface{} {
+		return new(ScopeContext)
+	},
+}
+
+// newScope returns a scope context from the pool holding the given objects.
+func newScope(mem *Memory, stack *Stack, contract *Contract) *ScopeContext {
+	scope := scopePool.Get().(*ScopeContext)
+	scope.Memory, scope.Stack, scope.Contract = mem, stack, contract
+	return scope
+}
+
+// returnScope releases the memory, stack and the scope context itself back to
+// their pools. None of them may be used afterwards.
+func returnScope(scope *ScopeContext) {
+	scope.Memory.Free()
+	returnStack(scope.Stack)
+
+	scope.Memory, scope.Stack, scope.Contract = nil, nil, nil
+	scopePool.Put(scope)
+}
+
 // keccakState wraps sha3.state. In addition to the usual hash methods, it also supports
 // Read to get a variable amount of data from the hash state. Read is faster than Sum
 // because it doesn't copy the internal state, but also modifies the internal state.
@@ -72,8 +98,13 @@ func NewEVMInterpreter(evm *EVM, cfg Config) *EVMInterpreter {
 	// the jump table was initialised. If it was not
 	// we'll set the default jump table.
 	if cfg.JumpTable[STOP] == nil {
-		var jt JumpTable
+		var (
+			jt       JumpTable
+			features params.FeatureSet // Features included in the fork's instruction set
+		)
 		switch {
+		case evm.chainRules.IsCurie:
+			jt, features = curieInstructionSet, params.CurieFeatures
 		case evm.chainRules.IsLondon:
 			jt = londonInstructionSet
 		case evm.chainRules.IsBerlin:
@@ -93,6 +124,12 @@ func NewEVMInterpreter(evm *EVM, cfg Config) *EVMInterpreter {
 		default:
 			jt = frontierInstructionSet
 		}
+		// Add the instructions of the individually adopted features
+		for _, eip := range (evm.chainRules.Features &^ features).EIPs() {
+			if err := EnableEIP(eip, &jt); err != nil {
+				log.Error("Feature activation failed", "eip", eip, "error", err)
+			}
+		}
 		for i, eip := range cfg.ExtraEips {
 			if err := EnableEIP(eip, &jt); err != nil {
 				// Disable it, so caller can check if it's activated or not
@@ -138,14 +175,10 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 	}
 
 	var (
-		op          OpCode        // current opcode
-		mem         = NewMemory() // bound memory
-		stack       = newstack()  // local stack
-		callContext = &ScopeContext{
-			Memory:   mem,
-			Stack:    stack,
-			Contract: contract,
-		}
+		op          OpCode                           // current opcode
+		mem         = NewMemory()                    // bound memory
+		stack       = newstack()                     // local stack
+		callContext = newScope(mem, stack, contract) // pooled scope, released on return
 		// For optimisation reason we're using uint64 as the program counter.
 		// It's theoretically possible to go above 2^64. The YP defines the PC
 		// to be uint256. Practically much less so feasible.
@@ -158,10 +191,10 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		res     []byte // result of the opcode execution function
 	)
 	// Don't move this deferrred function, it's placed before the capturestate-deferred method,
-	// so that it get's executed _after_: the capturestate needs the stacks before
-	// they are returned to the pools
+	// so that it get's executed _after_: the capturestate needs the stack and memory
+	// before they are returned to the pools
 	defer func() {
-		returnStack(stack)
+		returnScope(callContext)
 	}()
 	contract.Input = input
 
@@ -181,6 +214,7 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 	// the execution of one of the operations or until the done flag is set by the
 	// parent context.
 	steps := 0
+	fusion := in.cfg.EnableInstructionFusion && !in.cfg.Debug
 	for {
 		steps++
 		if steps%1000 == 0 && atomic.LoadInt32(&in.evm.abort) != 0 {
@@ -191,6 +225,12 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 			logged, pcCopy, gasCopy = false, pc, contract.Gas
 		}
 
+		if fusion {
+			if next, ok := in.fuse(pc, contract, stack); ok {
+				pc = next
+				continue
+			}
+		}
 		// Get the operation from the jump table and validate the stack to ensure there are
 		// enough stack items available to perform the operation.
 		op = contract.GetOp(pc)
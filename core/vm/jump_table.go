@@ -58,11 +58,21 @@ var (
 	istanbulInstructionSet         = newIstanbulInstructionSet()
 	berlinInstructionSet           = newBerlinInstructionSet()
 	londonInstructionSet           = newLondonInstructionSet()
+	curieInstructionSet            = newCurieInstructionSet()
 )
 
 // JumpTable contains the EVM opcodes supported at a given fork.
 type JumpTable [256]*operation
 
+// newCurieInstructionSet returns the frontier, homestead, byzantium,
+// contantinople, istanbul, petersburg, berlin, london and curie instructions.
+func newCurieInstructionSet() JumpTable {
+	instructionSet := newLondonInstructionSet()
+	enable1153(&instructionSet) // EIP-1153: Transient storage opcodes https://eips.ethereum.org/EIPS/eip-1153
+	enable5656(&instructionSet) // EIP-5656: MCOPY - Memory copying instruction https://eips.ethereum.org/EIPS/eip-5656
+	return instructionSet
+}
+
 // newLondonInstructionSet returns the frontier, homestead, byzantium,
 // contantinople, istanbul, petersburg, berlin and london instructions.
 func newLondonInstructionSet() JumpTable {
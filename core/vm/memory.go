@@ -18,10 +18,21 @@ package vm
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/holiman/uint256"
 )
 
+// maxPooledMemory is the capacity above which memories are not returned to the
+// pool, so a few large executions don't keep a lot of memory alive.
+const maxPooledMemory = 16 * 1024
+
+var memoryPool = sync.Pool{
+	New: func() interface{} {
+		return &Memory{}
+	},
+}
+
 // Memory implements a simple memory model for the ethereum virtual machine.
 type Memory struct {
 	store       []byte
@@ -30,7 +41,18 @@ type Memory struct {
 
 // NewMemory returns a new memory model.
 func NewMemory() *Memory {
-	return &Memory{}
+	return memoryPool.Get().(*Memory)
+}
+
+// Free returns the memory to the pool for reuse by later executions. Neither the
+// memory nor any slice obtained from it may be used afterwards.
+func (m *Memory) Free() {
+	if cap(m.store) > maxPooledMemory {
+		return
+	}
+	m.store = m.store[:0]
+	m.lastGasCost = 0
+	memoryPool.Put(m)
 }
 
 // Set sets offset + size to value
@@ -68,6 +90,17 @@ func (m *Memory) Resize(size uint64) {
 	}
 }
 
+// Copy copies data from the src position slice into the dst position.
+// The source and destination may overlap.
+// OBS: This operation assumes that any necessary memory expansion has already been performed,
+// and this method may panic otherwise.
+func (m *Memory) Copy(dst, src, len uint64) {
+	if len == 0 {
+		return
+	}
+	copy(m.store[dst:], m.store[src:src+len])
+}
+
 // Get returns offset + size as a new slice
 func (m *Memory) GetCopy(offset, size int64) (cpy []byte) {
 	if size == 0 {
@@ -0,0 +1,64 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/params"
+)
+
+// TestMemoryFree tests that memories taken from the pool start out empty.
+func TestMemoryFree(t *testing.T) {
+	for i := 0; i < 16; i++ {
+		mem := NewMemory()
+		if mem.Len() != 0 || mem.lastGasCost != 0 {
+			t.Fatalf("pooled memory not reset: len %d, last gas cost %d", mem.Len(), mem.lastGasCost)
+		}
+		mem.Resize(64)
+		mem.Set(0, 4, []byte{1, 2, 3, 4})
+		mem.lastGasCost = 100
+		mem.Free()
+	}
+}
+
+// TestReturnDataAfterFree tests that the output of a frame stays intact once its
+// memory is reused by later executions.
+func TestReturnDataAfterFree(t *testing.T) {
+	run := func(value byte) []byte {
+		evm := NewEVM(BlockContext{}, TxContext{}, nil, params.TestChainConfig, Config{})
+		contract := NewContract(AccountRef(common.Address{}), AccountRef(common.Address{0x01}), new(big.Int), 100000)
+		// mstore8(0, value) return(0, 1)
+		contract.Code = []byte{byte(PUSH1), value, byte(PUSH1), 0, byte(MSTORE8), byte(PUSH1), 1, byte(PUSH1), 0, byte(RETURN)}
+
+		ret, err := evm.interpreter.Run(contract, nil, false)
+		if err != nil {
+			t.Fatalf("execution failed: %v", err)
+		}
+		return ret
+	}
+	first := run(0xaa)
+	for i := 0; i < 16; i++ {
+		run(0xbb)
+	}
+	if !bytes.Equal(first, []byte{0xaa}) {
+		t.Fatalf("output overwritten: have %x, want aa", first)
+	}
+}
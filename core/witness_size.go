@@ -0,0 +1,185 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/state"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/core/vm"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/scroll-tech/go-ethereum/params"
+	"github.com/scroll-tech/go-ethereum/trie"
+)
+
+// WitnessSize is the estimated size of the execution witness of a transaction.
+type WitnessSize struct {
+	Accounts  int // Number of accounts accessed
+	Slots     int // Number of storage slots accessed
+	TrieNodes int // Number of distinct trie nodes proving the accessed state
+	CodeBytes int // Size of the distinct contract codes executed or accessed
+}
+
+// EstimateWitnessSize predicts the size of the witness needed to prove the
+// execution of a message on top of the given state.
+//
+// Plain value transfers only access the sender, the recipient and the coinbase,
+// so their witness is derived without execution. Any other message is executed
+// on a copy of the state, tracing the accounts and storage slots it accesses.
+// The trie nodes are counted from the proofs of the accessed state, which makes
+// the estimate exact for reads, but may miss the few sibling nodes needed when
+// the message creates new accounts or slots.
+func EstimateWitnessSize(config *params.ChainConfig, blockCtx vm.BlockContext, statedb *state.StateDB, msg Message, vmConfig vm.Config) (*WitnessSize, error) {
+	accessed := types.AccessList{{Address: msg.From()}, {Address: blockCtx.Coinbase}}
+
+	to := msg.To()
+	if to != nil {
+		accessed = append(accessed, types.AccessTuple{Address: *to})
+	}
+	if to == nil || len(msg.Data()) > 0 || statedb.GetCodeSize(*to) > 0 {
+		var (
+			sender      = msg.From()
+			precompiles = vm.ActivePrecompiles(config.Rules(blockCtx.BlockNumber))
+		)
+		if to == nil {
+			to = new(common.Address)
+			*to = crypto.CreateAddress(sender, msg.Nonce())
+		}
+		tracer := vm.NewAccessListTracer(msg.AccessList(), sender, *to, precompiles)
+		vmConfig.Debug, vmConfig.Tracer = true, tracer
+
+		evm := vm.NewEVM(blockCtx, NewEVMTxContext(msg), statedb.Copy(), config, vmConfig)
+		if _, err := ApplyMessage(evm, msg, new(GasPool).AddGas(msg.Gas())); err != nil {
+			return nil, err
+		}
+		accessed = append(accessed, tracer.AccessList()...)
+	}
+	size, err := NewWitnessCollector(statedb).Add(accessed)
+	if err != nil {
+		return nil, err
+	}
+	return &size, nil
+}
+
+// WitnessCollector accumulates the witness needed to prove the execution of a
+// sequence of transactions, such as the ones of a block. Trie nodes and codes
+// shared by several transactions are counted once.
+type WitnessCollector struct {
+	statedb *state.StateDB // State the witness proves, before the first transaction
+	size    WitnessSize
+
+	nodes map[common.Hash]struct{}
+	codes map[common.Hash]struct{}
+	seen  map[common.Address]map[common.Hash]struct{}
+
+	undo []func()    // Removals of the entries recorded by the last Add
+	last WitnessSize // Size before the last Add
+}
+
+// NewWitnessCollector creates an empty witness collector proving against the
+// given state. The state must not be modified while collecting.
+func NewWitnessCollector(statedb *state.StateDB) *WitnessCollector {
+	return &WitnessCollector{
+		statedb: statedb,
+		nodes:   make(map[common.Hash]struct{}),
+		codes:   make(map[common.Hash]struct{}),
+		seen:    make(map[common.Address]map[common.Hash]struct{}),
+	}
+}
+
+// Size returns the size of the witness collected so far.
+func (c *WitnessCollector) Size() WitnessSize {
+	return c.size
+}
+
+// Add collects the proofs and codes of the given accounts and slots, returning
+// the size of the whole witness including them.
+func (c *WitnessCollector) Add(accessed types.AccessList) (WitnessSize, error) {
+	c.undo, c.last = c.undo[:0], c.size
+
+	for _, tuple := range accessed {
+		addr := tuple.Address
+
+		slots, ok := c.seen[addr]
+		if !ok {
+			slots = make(map[common.Hash]struct{})
+			c.seen[addr] = slots
+			c.undo = append(c.undo, func() { delete(c.seen, addr) })
+
+			proof, err := c.statedb.GetProof(addr)
+			if err != nil {
+				c.Revert()
+				return c.size, err
+			}
+			c.addProof(proof)
+			c.size.Accounts++
+
+			if code := c.statedb.GetCode(addr); len(code) > 0 {
+				hash := c.statedb.GetCodeHash(addr)
+				if _, ok := c.codes[hash]; !ok {
+					c.codes[hash] = struct{}{}
+					c.undo = append(c.undo, func() { delete(c.codes, hash) })
+					c.size.CodeBytes += len(code)
+				}
+			}
+		}
+		for _, slot := range tuple.StorageKeys {
+			if _, ok := slots[slot]; ok {
+				continue
+			}
+			slot := slot
+			slots[slot] = struct{}{}
+			c.undo = append(c.undo, func() { delete(slots, slot) })
+			c.size.Slots++
+
+			// Accounts without storage have no storage trie to prove
+			if c.statedb.StorageTrie(addr) == nil {
+				continue
+			}
+			proof, err := c.statedb.GetStorageProof(addr, slot)
+			if err != nil {
+				c.Revert()
+				return c.size, err
+			}
+			c.addProof(proof)
+		}
+	}
+	c.size.TrieNodes = len(c.nodes)
+	return c.size, nil
+}
+
+// Revert drops everything recorded by the last Add.
+func (c *WitnessCollector) Revert() {
+	for i := len(c.undo) - 1; i >= 0; i-- {
+		c.undo[i]()
+	}
+	c.undo, c.size = c.undo[:0], c.last
+}
+
+// addProof records the distinct trie nodes of a proof.
+func (c *WitnessCollector) addProof(proof [][]byte) {
+	for _, blob := range proof {
+		if trie.IsProofMarker(blob) {
+			continue
+		}
+		hash := crypto.Keccak256Hash(blob)
+		if _, ok := c.nodes[hash]; !ok {
+			c.nodes[hash] = struct{}{}
+			c.undo = append(c.undo, func() { delete(c.nodes, hash) })
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/core/state"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/core/vm"
+	"github.com/scroll-tech/go-ethereum/params"
+	"github.com/scroll-tech/go-ethereum/trie"
+)
+
+func TestEstimateWitnessSize(t *testing.T) {
+	t.Run("mpt", func(t *testing.T) { testEstimateWitnessSize(t, false) })
+	t.Run("zktrie", func(t *testing.T) { testEstimateWitnessSize(t, true) })
+}
+
+func testEstimateWitnessSize(t *testing.T, zktrie bool) {
+	var (
+		sender   = common.HexToAddress("0x1001")
+		receiver = common.HexToAddress("0x1002")
+		contract = common.HexToAddress("0x1003")
+		coinbase = common.HexToAddress("0x1004")
+
+		// PUSH1 0 SLOAD PUSH1 1 SLOAD STOP
+		code = []byte{0x60, 0x00, 0x54, 0x60, 0x01, 0x54, 0x00}
+	)
+	db := state.NewDatabaseWithConfig(rawdb.NewMemoryDatabase(), &trie.Config{Zktrie: zktrie})
+	statedb, _ := state.New(common.Hash{}, db, nil)
+	statedb.AddBalance(sender, big.NewInt(params.Ether))
+	statedb.SetCode(contract, code)
+	statedb.SetState(contract, common.Hash{}, common.HexToHash("0x01"))
+	for i := int64(5); i < 20; i++ {
+		statedb.AddBalance(common.BigToAddress(big.NewInt(i)), big.NewInt(1))
+	}
+	root, _ := statedb.Commit(false)
+	statedb, _ = state.New(root, db, nil)
+
+	blockCtx := vm.BlockContext{
+		CanTransfer: CanTransfer,
+		Transfer:    Transfer,
+		Coinbase:    coinbase,
+		BlockNumber: big.NewInt(1),
+		GasLimit:    params.GenesisGasLimit,
+		Difficulty:  big.NewInt(1),
+		BaseFee:     big.NewInt(0),
+	}
+	// A plain transfer should only access the sender, recipient and coinbase
+	transfer := types.NewMessage(sender, &receiver, 0, big.NewInt(1), params.TxGas, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, false)
+	size, err := EstimateWitnessSize(params.TestChainConfig, blockCtx, statedb, transfer, vm.Config{NoBaseFee: true})
+	if err != nil {
+		t.Fatalf("failed to estimate transfer: %v", err)
+	}
+	if size.Accounts != 3 || size.Slots != 0 || size.CodeBytes != 0 || size.TrieNodes == 0 {
+		t.Errorf("transfer estimate mismatch: %+v", size)
+	}
+	// A contract call should account for the code and the loaded slots
+	call := types.NewMessage(sender, &contract, 0, big.NewInt(0), 100000, big.NewInt(0), big.NewInt(0), big.NewInt(0), []byte{0x01}, nil, false)
+	callSize, err := EstimateWitnessSize(params.TestChainConfig, blockCtx, statedb, call, vm.Config{NoBaseFee: true})
+	if err != nil {
+		t.Fatalf("failed to estimate call: %v", err)
+	}
+	if callSize.Accounts != 3 || callSize.Slots != 2 || callSize.CodeBytes != len(code) {
+		t.Errorf("call estimate mismatch: %+v", callSize)
+	}
+	accounts, err := NewWitnessCollector(statedb).Add(types.AccessList{{Address: sender}, {Address: coinbase}, {Address: contract}})
+	if err != nil {
+		t.Fatalf("failed to measure account witness: %v", err)
+	}
+	if callSize.TrieNodes <= accounts.TrieNodes {
+		t.Errorf("storage trie nodes not accounted: have %d, accounts only %d", callSize.TrieNodes, accounts.TrieNodes)
+	}
+	// The estimation must not modify the state
+	if nonce := statedb.GetNonce(sender); nonce != 0 {
+		t.Errorf("state modified by the estimation: nonce %d", nonce)
+	}
+	// The witness of a block counts the nodes shared by its transactions once
+	collector := NewWitnessCollector(statedb)
+	if _, err := collector.Add(types.AccessList{{Address: sender}, {Address: receiver}, {Address: coinbase}}); err != nil {
+		t.Fatalf("failed to collect transfer: %v", err)
+	}
+	callAccess := types.AccessList{{Address: sender}, {Address: coinbase}, {Address: contract, StorageKeys: []common.Hash{{}, common.HexToHash("0x01")}}}
+	block, err := collector.Add(callAccess)
+	if err != nil {
+		t.Fatalf("failed to collect call: %v", err)
+	}
+	if block.Accounts != 4 || block.Slots != 2 || block.CodeBytes != len(code) {
+		t.Errorf("block witness mismatch: %+v", block)
+	}
+	if block.TrieNodes >= size.TrieNodes+callSize.TrieNodes {
+		t.Errorf("shared trie nodes counted twice: have %d, transactions %d and %d", block.TrieNodes, size.TrieNodes, callSize.TrieNodes)
+	}
+	// Reverting drops the last transaction only
+	collector.Revert()
+	if have := collector.Size(); have != *size {
+		t.Errorf("reverted witness mismatch: have %+v, want %+v", have, *size)
+	}
+	if again, _ := collector.Add(callAccess); again != block {
+		t.Errorf("re-collected witness mismatch: have %+v, want %+v", again, block)
+	}
+}
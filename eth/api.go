@@ -19,10 +19,13 @@ package eth
 import (
 	"compress/gzip"
 	"context"
+	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"math/big"
+	"net/url"
 	"os"
 	"runtime"
 	"strings"
@@ -36,6 +39,7 @@ import (
 	"github.com/scroll-tech/go-ethereum/core/types"
 	"github.com/scroll-tech/go-ethereum/internal/ethapi"
 	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/miner"
 	"github.com/scroll-tech/go-ethereum/rlp"
 	"github.com/scroll-tech/go-ethereum/rpc"
 	"github.com/scroll-tech/go-ethereum/trie"
@@ -67,6 +71,70 @@ func (api *PublicEthereumAPI) Hashrate() hexutil.Uint64 {
 	return hexutil.Uint64(api.e.Miner().Hashrate())
 }
 
+const (
+	// defaultAddressActivityPage is the number of transactions returned by
+	// eth_getTransactionsByAddress if no limit is requested.
+	defaultAddressActivityPage = 100
+
+	// maxAddressActivityPage is the maximum number of transactions returned by
+	// a single eth_getTransactionsByAddress call.
+	maxAddressActivityPage = 1000
+)
+
+// AddressTransaction is a transaction an address took part in, along with the
+// roles it had.
+type AddressTransaction struct {
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	BlockHash        common.Hash    `json:"blockHash"`
+	TransactionIndex hexutil.Uint   `json:"transactionIndex"`
+	TransactionHash  common.Hash    `json:"transactionHash"`
+	Sent             bool           `json:"sent"`
+	Received         bool           `json:"received"`
+	Internal         bool           `json:"internal"`
+}
+
+// AddressTransactionsPage is a page of the transactions an address took part in.
+// NextBlock is the block to request the following page from, nil on the last page.
+type AddressTransactionsPage struct {
+	Transactions []AddressTransaction `json:"transactions"`
+	NextBlock    *hexutil.Uint64      `json:"nextBlock"`
+}
+
+// GetTransactionsByAddress returns a page of the canonical transactions the given
+// address sent, received or took part in via an internal value transfer, in
+// ascending order starting at the given block. The index needs to be enabled
+// via --txlookup.activity.
+func (api *PublicEthereumAPI) GetTransactionsByAddress(address common.Address, fromBlock hexutil.Uint64, limit *hexutil.Uint) (*AddressTransactionsPage, error) {
+	if !api.e.config.AddressActivity {
+		return nil, errors.New("address activity index is disabled")
+	}
+	size := defaultAddressActivityPage
+	if limit != nil {
+		size = int(*limit)
+	}
+	if size <= 0 || size > maxAddressActivityPage {
+		return nil, fmt.Errorf("invalid page size %d, must be between 1 and %d", size, maxAddressActivityPage)
+	}
+	entries, next := api.e.blockchain.GetAddressActivity(address, uint64(fromBlock), size)
+
+	page := &AddressTransactionsPage{Transactions: make([]AddressTransaction, 0, len(entries))}
+	for _, entry := range entries {
+		page.Transactions = append(page.Transactions, AddressTransaction{
+			BlockNumber:      hexutil.Uint64(entry.BlockNumber),
+			BlockHash:        entry.BlockHash,
+			TransactionIndex: hexutil.Uint(entry.TxIndex),
+			TransactionHash:  entry.TxHash,
+			Sent:             entry.Flags&rawdb.ActivitySent != 0,
+			Received:         entry.Flags&rawdb.ActivityReceived != 0,
+			Internal:         entry.Flags&rawdb.ActivityInternal != 0,
+		})
+	}
+	if next != 0 {
+		page.NextBlock = (*hexutil.Uint64)(&next)
+	}
+	return page, nil
+}
+
 // PublicMinerAPI provides an API to control the miner.
 // It offers only methods that operate on data that pose no security risk when it is publicly accessible.
 type PublicMinerAPI struct {
@@ -147,6 +215,112 @@ func (api *PrivateMinerAPI) SetRecommitInterval(interval int) {
 	api.e.Miner().SetRecommitInterval(time.Duration(interval) * time.Millisecond)
 }
 
+// PrivateTxPoolAPI is the collection of transaction pool APIs exposed over the
+// private admin endpoint. Over HTTP and WebSocket, it's only served if the admin
+// API is enabled along with the txpool one.
+type PrivateTxPoolAPI struct {
+	eth *Ethereum
+}
+
+// NewPrivateTxPoolAPI creates a new API definition for the private transaction
+// pool methods of the Ethereum service.
+func NewPrivateTxPoolAPI(eth *Ethereum) *PrivateTxPoolAPI {
+	return &PrivateTxPoolAPI{eth: eth}
+}
+
+// TxPoolSnapshot is the content of the transaction pool along with the metadata
+// needed to import it into the pool of another node, e.g. when failing over to
+// a replacement sequencer.
+type TxPoolSnapshot struct {
+	Head         common.Hash         `json:"head"`   // Chain head the pool was exported at
+	Number       hexutil.Uint64      `json:"number"` // Number of the chain head
+	Transactions []*TxPoolSnapshotTx `json:"transactions"`
+}
+
+// TxPoolSnapshotTx is a transaction of a pool snapshot.
+type TxPoolSnapshotTx struct {
+	Hash    common.Hash    `json:"hash"`
+	From    common.Address `json:"from"`
+	Local   bool           `json:"local"`
+	Pending bool           `json:"pending"`
+	Time    hexutil.Uint64 `json:"time"` // Time first seen, in unix milliseconds
+	Raw     hexutil.Bytes  `json:"raw"`  // Binary encoding of the transaction
+}
+
+// TxPoolImportResult is the outcome of importing a pool snapshot.
+type TxPoolImportResult struct {
+	Imported hexutil.Uint           `json:"imported"`
+	Known    hexutil.Uint           `json:"known"`            // Transactions already in the pool
+	Errors   map[common.Hash]string `json:"errors,omitempty"` // Rejected transactions
+}
+
+// Export serializes the entire content of the pool, grouped by account and sorted
+// by nonce, so that it can be imported into another node with Import. Like all
+// the methods of the private pool API, it's only served over HTTP and WebSocket
+// if the admin API is enabled, as it reveals the local transactions.
+func (api *PrivateTxPoolAPI) Export() (*TxPoolSnapshot, error) {
+	head := api.eth.BlockChain().CurrentHeader()
+	snapshot := &TxPoolSnapshot{
+		Head:         head.Hash(),
+		Number:       hexutil.Uint64(head.Number.Uint64()),
+		Transactions: []*TxPoolSnapshotTx{},
+	}
+	for _, entry := range api.eth.TxPool().Export() {
+		raw, err := entry.Tx.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Transactions = append(snapshot.Transactions, &TxPoolSnapshotTx{
+			Hash:    entry.Tx.Hash(),
+			From:    entry.From,
+			Local:   entry.Local,
+			Pending: entry.Pending,
+			Time:    hexutil.Uint64(entry.Time.UnixNano() / int64(time.Millisecond)),
+			Raw:     raw,
+		})
+	}
+	return snapshot, nil
+}
+
+// Import adds the transactions of a snapshot exported by another node to the
+// pool. The transactions are revalidated against the local chain, the rejected
+// ones are reported along with the reason. Transactions flagged local in the
+// snapshot skip the pricing constraints, which is why the method is only served
+// over HTTP and WebSocket if the admin API is enabled.
+func (api *PrivateTxPoolAPI) Import(snapshot TxPoolSnapshot) (*TxPoolImportResult, error) {
+	entries := make([]*core.TxPoolEntry, 0, len(snapshot.Transactions))
+	for i, stx := range snapshot.Transactions {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(stx.Raw); err != nil {
+			return nil, fmt.Errorf("invalid transaction %d: %v", i, err)
+		}
+		if tx.Hash() != stx.Hash {
+			return nil, fmt.Errorf("transaction %d hash mismatch: have %x, want %x", i, tx.Hash(), stx.Hash)
+		}
+		entries = append(entries, &core.TxPoolEntry{
+			Tx:      tx,
+			From:    stx.From,
+			Local:   stx.Local,
+			Pending: stx.Pending,
+			Time:    time.Unix(0, int64(stx.Time)*int64(time.Millisecond)),
+		})
+	}
+	result := &TxPoolImportResult{Errors: make(map[common.Hash]string)}
+	for i, err := range api.eth.TxPool().Import(entries) {
+		switch {
+		case err == nil:
+			result.Imported++
+		case errors.Is(err, core.ErrAlreadyKnown):
+			result.Known++
+		default:
+			result.Errors[entries[i].Tx.Hash()] = err.Error()
+		}
+	}
+	log.Info("Imported transaction pool snapshot", "head", snapshot.Head, "number", uint64(snapshot.Number),
+		"imported", uint(result.Imported), "known", uint(result.Known), "rejected", len(result.Errors))
+	return result, nil
+}
+
 // PrivateAdminAPI is the collection of Ethereum full node-related APIs
 // exposed over the private admin endpoint.
 type PrivateAdminAPI struct {
@@ -257,6 +431,148 @@ func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// TxPoolPolicyArgs is the anti-spam policy of the transaction pool. When used
+// for updates, omitted fields are left unchanged.
+type TxPoolPolicyArgs struct {
+	PriceLimit      *hexutil.Uint64 `json:"priceLimit"`
+	PriceBump       *hexutil.Uint64 `json:"priceBump"`
+	ReplaceInterval *string         `json:"replaceInterval"`
+}
+
+// newTxPoolPolicyArgs converts a pool policy into its RPC representation.
+func newTxPoolPolicyArgs(policy core.TxPoolPolicy) *TxPoolPolicyArgs {
+	var (
+		limit    = hexutil.Uint64(policy.PriceLimit)
+		bump     = hexutil.Uint64(policy.PriceBump)
+		interval = policy.ReplaceInterval.String()
+	)
+	return &TxPoolPolicyArgs{PriceLimit: &limit, PriceBump: &bump, ReplaceInterval: &interval}
+}
+
+// Policy returns the anti-spam policy currently enforced by the pool.
+func (api *PrivateTxPoolAPI) Policy() *TxPoolPolicyArgs {
+	return newTxPoolPolicyArgs(api.eth.TxPool().Policy())
+}
+
+// SetPolicy updates the anti-spam policy of the pool and persists it, so that
+// it survives restarts. Policy flags given explicitly on the command line still
+// take precedence on the next start. The resulting policy is returned.
+func (api *PrivateTxPoolAPI) SetPolicy(args TxPoolPolicyArgs) (*TxPoolPolicyArgs, error) {
+	policy, err := api.eth.TxPool().UpdatePolicy(func(policy *core.TxPoolPolicy) error {
+		if args.PriceLimit != nil {
+			policy.PriceLimit = uint64(*args.PriceLimit)
+		}
+		if args.PriceBump != nil {
+			policy.PriceBump = uint64(*args.PriceBump)
+		}
+		if args.ReplaceInterval != nil {
+			interval, err := time.ParseDuration(*args.ReplaceInterval)
+			if err != nil {
+				return fmt.Errorf("invalid replace interval: %v", err)
+			}
+			policy.ReplaceInterval = interval
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	blob, err := json.Marshal(policy)
+	if err != nil {
+		return nil, err
+	}
+	rawdb.WriteTxPoolPolicy(api.eth.ChainDb(), blob)
+
+	return newTxPoolPolicyArgs(policy), nil
+}
+
+// GasLimitChange is a scheduled change of the gas limit target of mined blocks.
+type GasLimitChange struct {
+	Number   hexutil.Uint64 `json:"number"`
+	GasLimit hexutil.Uint64 `json:"gasLimit"`
+	Ramp     hexutil.Uint64 `json:"ramp"`
+}
+
+// ScheduleGasLimit schedules a change of the gas limit target at a future block.
+// With a ramp, the target moves linearly to the new gas limit over that many
+// blocks. A change already scheduled at the same block is replaced.
+func (api *PrivateAdminAPI) ScheduleGasLimit(number hexutil.Uint64, gasLimit hexutil.Uint64, ramp *hexutil.Uint64) error {
+	change := miner.GasLimitChange{Number: uint64(number), GasLimit: uint64(gasLimit)}
+	if ramp != nil {
+		change.Ramp = uint64(*ramp)
+	}
+	return api.eth.Miner().ScheduleGasLimit(change)
+}
+
+// GasLimitSchedule returns the scheduled changes of the gas limit target.
+func (api *PrivateAdminAPI) GasLimitSchedule() []GasLimitChange {
+	schedule := api.eth.Miner().GasLimitSchedule()
+
+	changes := make([]GasLimitChange, len(schedule))
+	for i, change := range schedule {
+		changes[i] = GasLimitChange{
+			Number:   hexutil.Uint64(change.Number),
+			GasLimit: hexutil.Uint64(change.GasLimit),
+			Ramp:     hexutil.Uint64(change.Ramp),
+		}
+	}
+	return changes
+}
+
+// CancelGasLimitChange removes the change of the gas limit target scheduled at
+// the given block, reporting whether there was one.
+func (api *PrivateAdminAPI) CancelGasLimitChange(number hexutil.Uint64) bool {
+	return api.eth.Miner().CancelGasLimitChange(uint64(number))
+}
+
+// StateLeaves returns up to limit leaves of the zk trie with the given root, in
+// path order, starting after the given hashed key. It serves the nodes copying
+// the state with admin_copyState.
+func (api *PrivateAdminAPI) StateLeaves(root common.Hash, after *hexutil.Bytes, limit int) (*StateLeaves, error) {
+	if !api.eth.blockchain.Config().Zktrie {
+		return nil, errStateCopyUnsupported
+	}
+	server := &stateServer{db: api.eth.chainDb, triedb: api.eth.blockchain.StateCache().TrieDB()}
+	return server.StateLeaves(root, after, limit)
+}
+
+// StateCode returns the contract codes with the given hashes. It serves the
+// nodes copying the state with admin_copyState.
+func (api *PrivateAdminAPI) StateCode(hashes []common.Hash) ([]hexutil.Bytes, error) {
+	server := &stateServer{db: api.eth.chainDb, triedb: api.eth.blockchain.StateCache().TrieDB()}
+	return server.StateCode(hashes)
+}
+
+// CopyState starts copying the zk state with the given root from the node whose
+// admin API is served at the given endpoint, which should be a trusted one. The
+// copy runs in the background, use admin_copyStateStatus to follow it. Calling it
+// again with the same root after an interruption resumes the copy.
+func (api *PrivateAdminAPI) CopyState(endpoint string, root common.Hash) (bool, error) {
+	if !api.eth.blockchain.Config().Zktrie {
+		return false, errStateCopyUnsupported
+	}
+	client, err := rpc.Dial(endpoint)
+	if err != nil {
+		return false, err
+	}
+	// Don't keep the credentials embedded in the endpoint around
+	source := endpoint
+	if u, err := url.Parse(endpoint); err == nil && u.User != nil {
+		u.User = nil
+		source = u.String()
+	}
+	if err := api.eth.stateCopier.start(client, source, root); err != nil {
+		client.Close()
+		return false, err
+	}
+	return true, nil
+}
+
+// CopyStateStatus returns the progress of the last state copy.
+func (api *PrivateAdminAPI) CopyStateStatus() StateCopyStatus {
+	return api.eth.stateCopier.Status()
+}
+
 // PublicDebugAPI is the collection of Ethereum full node APIs exposed
 // over the public debugging endpoint.
 type PublicDebugAPI struct {
@@ -298,6 +614,32 @@ func (api *PublicDebugAPI) DumpBlock(blockNr rpc.BlockNumber) (state.Dump, error
 	return stateDb.RawDump(opts), nil
 }
 
+// GetCallTrace returns the call trace of a transaction from the call trace index,
+// without replaying it. The index needs to be enabled via --trace.callindex.
+func (api *PublicDebugAPI) GetCallTrace(txHash common.Hash) (*types.CallTrace, error) {
+	if !api.eth.config.CallTraceIndex {
+		return nil, errors.New("call trace index is disabled")
+	}
+	if trace := api.eth.blockchain.GetCallTrace(txHash); trace != nil {
+		return trace, nil
+	}
+	return nil, fmt.Errorf("call trace for transaction %#x not found", txHash)
+}
+
+// GetStateRootBlockNumbers returns the numbers of the canonical blocks which
+// produced the given zk trie state root.
+func (api *PublicDebugAPI) GetStateRootBlockNumbers(root common.Hash) ([]hexutil.Uint64, error) {
+	if !api.eth.blockchain.Config().Zktrie {
+		return nil, errors.New("state root index is only maintained for zktrie chains")
+	}
+	numbers := api.eth.blockchain.GetStateRootBlockNumbers(root)
+	result := make([]hexutil.Uint64, len(numbers))
+	for i, number := range numbers {
+		result[i] = hexutil.Uint64(number)
+	}
+	return result, nil
+}
+
 // PrivateDebugAPI is the collection of Ethereum full node APIs exposed over
 // the private debugging endpoint.
 type PrivateDebugAPI struct {
@@ -320,9 +662,10 @@ func (api *PrivateDebugAPI) Preimage(ctx context.Context, hash common.Hash) (hex
 
 // BadBlockArgs represents the entries in the list returned when bad blocks are queried.
 type BadBlockArgs struct {
-	Hash  common.Hash            `json:"hash"`
-	Block map[string]interface{} `json:"block"`
-	RLP   string                 `json:"rlp"`
+	Hash    common.Hash            `json:"hash"`
+	Block   map[string]interface{} `json:"block"`
+	RLP     string                 `json:"rlp"`
+	Witness *types.BlockResult     `json:"witness,omitempty"` // Partial execution trace and zk trie proofs captured on import
 }
 
 // GetBadBlocks returns a list of the last 'bad blocks' that the client has seen on the network
@@ -347,14 +690,48 @@ func (api *PrivateDebugAPI) GetBadBlocks(ctx context.Context) ([]*BadBlockArgs,
 			blockJSON = map[string]interface{}{"error": err.Error()}
 		}
 		results = append(results, &BadBlockArgs{
-			Hash:  block.Hash(),
-			RLP:   blockRlp,
-			Block: blockJSON,
+			Hash:    block.Hash(),
+			RLP:     blockRlp,
+			Block:   blockJSON,
+			Witness: rawdb.ReadBadBlockWitness(api.eth.chainDb, block.Hash()),
 		})
 	}
 	return results, nil
 }
 
+// OrderingAuditResult lists the candidate transactions the local miner saw while
+// assembling a block, and what it decided for each.
+type OrderingAuditResult struct {
+	Number     hexutil.Uint64            `json:"number"`
+	Hash       common.Hash               `json:"hash"`
+	BaseFee    *hexutil.Big              `json:"baseFee,omitempty"`
+	Candidates []*types.OrderingDecision `json:"candidates"`
+}
+
+// GetOrderingAudit returns the ordering decisions the local miner took for a
+// block it sealed, in the order it took them, followed by the candidates it
+// never reached. It returns null for blocks sealed without --miner.orderingaudit
+// and for blocks mined elsewhere.
+func (api *PrivateDebugAPI) GetOrderingAudit(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*OrderingAuditResult, error) {
+	header, err := api.eth.APIBackend.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errors.New("block not found")
+	}
+	audit := rawdb.ReadOrderingAudit(api.eth.chainDb, header.Hash())
+	if audit == nil {
+		return nil, nil
+	}
+	return &OrderingAuditResult{
+		Number:     hexutil.Uint64(header.Number.Uint64()),
+		Hash:       header.Hash(),
+		BaseFee:    (*hexutil.Big)(audit.BaseFee),
+		Candidates: audit.Candidates,
+	}, nil
+}
+
 // AccountRangeMaxResults is the maximum number of results to be returned per call
 const AccountRangeMaxResults = 256
 
@@ -442,6 +819,9 @@ func (api *PrivateDebugAPI) StorageRangeAt(blockHash common.Hash, txIndex int, c
 }
 
 func storageRangeAt(st state.Trie, start []byte, maxResult int) (StorageRangeResult, error) {
+	if zt, ok := st.(*trie.ZkTrie); ok {
+		return zkStorageRangeAt(zt, start, maxResult)
+	}
 	it := trie.NewIterator(st.NodeIterator(start))
 	result := StorageRangeResult{Storage: storageMap{}}
 	for i := 0; i < maxResult && it.Next(); i++ {
@@ -464,6 +844,41 @@ func storageRangeAt(st state.Trie, start []byte, maxResult int) (StorageRangeRes
 	return result, nil
 }
 
+// zkStorageRangeAt is storageRangeAt for zk tries, whose slots are returned in
+// path order. The start key and the next key are hashed slot keys, as they are
+// stored in the trie. Slots with a zero value are left out, as they are those
+// deleted from the trie.
+func zkStorageRangeAt(st *trie.ZkTrie, start []byte, maxResult int) (StorageRangeResult, error) {
+	if len(start) == 0 {
+		start = nil
+	} else if len(start) != common.HashLength {
+		return StorageRangeResult{}, fmt.Errorf("invalid zktrie start key length %d", len(start))
+	}
+	result := StorageRangeResult{Storage: storageMap{}}
+	err := st.WalkLeafNodesFrom(start, func(n *trie.Node) error {
+		value := common.BytesToHash(n.Data())
+		if value == (common.Hash{}) {
+			return nil
+		}
+		key := common.BytesToHash(n.NodeKey.Bytes())
+		if len(result.Storage) >= maxResult {
+			result.NextKey = &key
+			return errStatePageFull
+		}
+		e := storageEntry{Value: value}
+		if preimage := st.GetKey(key[:]); preimage != nil {
+			preimage := common.BytesToHash(preimage)
+			e.Key = &preimage
+		}
+		result.Storage[key] = e
+		return nil
+	})
+	if err != nil && err != errStatePageFull {
+		return StorageRangeResult{}, err
+	}
+	return result, nil
+}
+
 // GetModifiedAccountsByNumber returns all accounts that have changed between the
 // two blocks specified. A change is defined as a difference in nonce, balance,
 // code hash, or storage hash.
@@ -625,3 +1040,261 @@ func (api *PublicTraceAPI) GetBlockResultByHash(blockHash common.Hash) (*types.B
 	}
 	return nil, fmt.Errorf("No block result found")
 }
+
+// maxBatchWitnessBlocks is the maximum number of blocks whose witness can be
+// requested in a single eth_getBatchWitnessByHashes call.
+const maxBatchWitnessBlocks = 128
+
+// GetBatchWitnessByHashes returns the witness of a batch of consecutive blocks
+// given by their hashes in order, with the trie nodes and contract codes shared
+// across the blocks included only once.
+func (api *PublicTraceAPI) GetBatchWitnessByHashes(blockHashes []common.Hash) (*types.BatchWitness, error) {
+	if len(blockHashes) > maxBatchWitnessBlocks {
+		return nil, fmt.Errorf("too many blocks in batch: %d > %d", len(blockHashes), maxBatchWitnessBlocks)
+	}
+	builder := types.NewBatchWitnessBuilder()
+	for _, hash := range blockHashes {
+		blockResult := api.e.blockchain.GetBlockResultByHash(hash)
+		if blockResult == nil {
+			return nil, fmt.Errorf("no block result found for block %x", hash)
+		}
+		if _, err := builder.Add(blockResult); err != nil {
+			return nil, err
+		}
+	}
+	return builder.Witness(), nil
+}
+
+const (
+	// defaultTokenTransferPage is the number of transfers returned by
+	// scroll_getTokenTransfers if no limit is requested.
+	defaultTokenTransferPage = 100
+
+	// maxTokenTransferPage is the maximum number of transfers returned by a
+	// single scroll_getTokenTransfers call.
+	maxTokenTransferPage = 1000
+
+	// tokenTransferCursorLength is the length of a transfer page cursor, the
+	// block number and log index of the first transfer of the page.
+	tokenTransferCursorLength = 8 + 4
+)
+
+// PublicTokenAPI provides an API to query the token transfer index.
+type PublicTokenAPI struct {
+	e *Ethereum
+}
+
+// NewPublicTokenAPI creates a new token transfer API.
+func NewPublicTokenAPI(eth *Ethereum) *PublicTokenAPI {
+	return &PublicTokenAPI{eth}
+}
+
+// TokenTransferQuery selects the token transfers returned by
+// scroll_getTokenTransfers. At least one of token and holder is required.
+type TokenTransferQuery struct {
+	Token     *common.Address `json:"token"`
+	Holder    *common.Address `json:"holder"`
+	FromBlock *hexutil.Uint64 `json:"fromBlock"`
+	ToBlock   *hexutil.Uint64 `json:"toBlock"`
+	Cursor    *hexutil.Bytes  `json:"cursor"` // Cursor returned by the previous page, overrides fromBlock
+	Limit     *hexutil.Uint   `json:"limit"`
+}
+
+// TokenTransfer is an ERC-20 or ERC-721 transfer returned by the token transfer
+// index.
+type TokenTransfer struct {
+	Token            common.Address `json:"token"`
+	Standard         string         `json:"standard"`
+	From             common.Address `json:"from"`
+	To               common.Address `json:"to"`
+	Value            *hexutil.Big   `json:"value"`
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	BlockHash        common.Hash    `json:"blockHash"`
+	TransactionHash  common.Hash    `json:"transactionHash"`
+	TransactionIndex hexutil.Uint   `json:"transactionIndex"`
+	LogIndex         hexutil.Uint   `json:"logIndex"`
+}
+
+// TokenTransfersPage is a page of token transfers. Cursor is the value to pass
+// in the query of the following page, nil on the last page.
+type TokenTransfersPage struct {
+	Transfers []*TokenTransfer `json:"transfers"`
+	Cursor    *hexutil.Bytes   `json:"cursor"`
+}
+
+// GetTokenTransfers returns a page of the canonical ERC-20 and ERC-721 transfers
+// of a token or a holder in log order. The index needs to be enabled via
+// --txlookup.tokens.
+func (api *PublicTokenAPI) GetTokenTransfers(query TokenTransferQuery) (*TokenTransfersPage, error) {
+	if !api.e.config.TokenTransfers {
+		return nil, errors.New("token transfer index is disabled")
+	}
+	if query.Token == nil && query.Holder == nil {
+		return nil, errors.New("token or holder required")
+	}
+	limit := defaultTokenTransferPage
+	if query.Limit != nil {
+		limit = int(*query.Limit)
+	}
+	if limit <= 0 || limit > maxTokenTransferPage {
+		return nil, fmt.Errorf("invalid page size %d, must be between 1 and %d", limit, maxTokenTransferPage)
+	}
+	var (
+		number uint64
+		index  uint32
+		end    = api.e.blockchain.CurrentBlock().NumberU64()
+	)
+	if query.FromBlock != nil {
+		number = uint64(*query.FromBlock)
+	}
+	if query.Cursor != nil {
+		cursor := *query.Cursor
+		if len(cursor) != tokenTransferCursorLength {
+			return nil, fmt.Errorf("invalid cursor length %d, want %d", len(cursor), tokenTransferCursorLength)
+		}
+		number, index = binary.BigEndian.Uint64(cursor), binary.BigEndian.Uint32(cursor[8:])
+	}
+	if query.ToBlock != nil && uint64(*query.ToBlock) < end {
+		end = uint64(*query.ToBlock)
+	}
+	transfers, next := api.e.blockchain.GetTokenTransfers(query.Token, query.Holder, number, index, end, limit)
+
+	page := &TokenTransfersPage{Transfers: make([]*TokenTransfer, 0, len(transfers))}
+	for _, transfer := range transfers {
+		standard := "erc20"
+		if transfer.NFT {
+			standard = "erc721"
+		}
+		page.Transfers = append(page.Transfers, &TokenTransfer{
+			Token:            transfer.Token,
+			Standard:         standard,
+			From:             transfer.From,
+			To:               transfer.To,
+			Value:            (*hexutil.Big)(transfer.Value),
+			BlockNumber:      hexutil.Uint64(transfer.BlockNumber),
+			BlockHash:        transfer.BlockHash,
+			TransactionHash:  transfer.TxHash,
+			TransactionIndex: hexutil.Uint(transfer.TxIndex),
+			LogIndex:         hexutil.Uint(transfer.LogIndex),
+		})
+	}
+	if next != nil {
+		cursor := make(hexutil.Bytes, tokenTransferCursorLength)
+		binary.BigEndian.PutUint64(cursor, next.BlockNumber)
+		binary.BigEndian.PutUint32(cursor[8:], uint32(next.LogIndex))
+		page.Cursor = &cursor
+	}
+	return page, nil
+}
+
+const (
+	// defaultEventLogPage is the number of logs returned by scroll_getLogsByEvent
+	// if no limit is requested.
+	defaultEventLogPage = 100
+
+	// maxEventLogPage is the maximum number of logs returned by a single
+	// scroll_getLogsByEvent call.
+	maxEventLogPage = 1000
+
+	// eventLogCursorLength is the length of a log page cursor, the block number
+	// and log index of the first log of the page.
+	eventLogCursorLength = 8 + 4
+)
+
+// PublicEventLogAPI provides an API to query the event log index.
+type PublicEventLogAPI struct {
+	e *Ethereum
+}
+
+// NewPublicEventLogAPI creates a new event log API.
+func NewPublicEventLogAPI(eth *Ethereum) *PublicEventLogAPI {
+	return &PublicEventLogAPI{eth}
+}
+
+// EventLogQuery selects the logs returned by scroll_getLogsByEvent, the ones
+// emitted by a contract with an event signature as first topic.
+type EventLogQuery struct {
+	Address   common.Address  `json:"address"`
+	Topic     common.Hash     `json:"topic"`
+	FromBlock *hexutil.Uint64 `json:"fromBlock"`
+	ToBlock   *hexutil.Uint64 `json:"toBlock"`
+	Cursor    *hexutil.Bytes  `json:"cursor"` // Cursor returned by the previous page, overrides fromBlock
+	Limit     *hexutil.Uint   `json:"limit"`
+}
+
+// EventLogsPage is a page of logs. Cursor is the value to pass in the query of
+// the following page, nil on the last page.
+type EventLogsPage struct {
+	Logs   []*types.Log   `json:"logs"`
+	Cursor *hexutil.Bytes `json:"cursor"`
+}
+
+// GetLogsByEvent returns a page of the canonical logs emitted by a contract with
+// an event signature, in log order. Unlike eth_getLogs, it doesn't go through the
+// bloom filters, which match most blocks for the popular contracts. The index
+// needs to be enabled via --txlookup.logs.
+func (api *PublicEventLogAPI) GetLogsByEvent(query EventLogQuery) (*EventLogsPage, error) {
+	if !api.e.config.EventLogIndex {
+		return nil, errors.New("event log index is disabled")
+	}
+	limit := defaultEventLogPage
+	if query.Limit != nil {
+		limit = int(*query.Limit)
+	}
+	if limit <= 0 || limit > maxEventLogPage {
+		return nil, fmt.Errorf("invalid page size %d, must be between 1 and %d", limit, maxEventLogPage)
+	}
+	var (
+		number uint64
+		index  uint32
+		end    = api.e.blockchain.CurrentBlock().NumberU64()
+	)
+	if query.FromBlock != nil {
+		number = uint64(*query.FromBlock)
+	}
+	if query.Cursor != nil {
+		cursor := *query.Cursor
+		if len(cursor) != eventLogCursorLength {
+			return nil, fmt.Errorf("invalid cursor length %d, want %d", len(cursor), eventLogCursorLength)
+		}
+		number, index = binary.BigEndian.Uint64(cursor), binary.BigEndian.Uint32(cursor[8:])
+	}
+	if query.ToBlock != nil && uint64(*query.ToBlock) < end {
+		end = uint64(*query.ToBlock)
+	}
+	logs, next := api.e.blockchain.GetEventLogs(query.Address, query.Topic, number, index, end, limit)
+
+	page := &EventLogsPage{Logs: logs}
+	if page.Logs == nil {
+		page.Logs = []*types.Log{}
+	}
+	if next != nil {
+		cursor := make(hexutil.Bytes, eventLogCursorLength)
+		binary.BigEndian.PutUint64(cursor, next.BlockNumber)
+		binary.BigEndian.PutUint32(cursor[8:], next.LogIndex)
+		page.Cursor = &cursor
+	}
+	return page, nil
+}
+
+// PublicPreconfirmationAPI serves the preconfirmations of the sequencer.
+type PublicPreconfirmationAPI struct {
+	e *Ethereum
+}
+
+// NewPublicPreconfirmationAPI creates a new preconfirmation API.
+func NewPublicPreconfirmationAPI(eth *Ethereum) *PublicPreconfirmationAPI {
+	return &PublicPreconfirmationAPI{eth}
+}
+
+// GetPreconfirmation returns the preconfirmation of a transaction ordered in the
+// pending block, signed by the preconfirmation key: a promise to include it by
+// the expiry block, behind the transactions ordered before it. It returns null
+// while the transaction isn't ordered. The feature needs to be enabled via
+// --miner.preconfirmkey.
+func (api *PublicPreconfirmationAPI) GetPreconfirmation(hash common.Hash) (*types.Preconfirmation, error) {
+	if api.e.preconfirmer == nil {
+		return nil, errPreconfirmationsDisabled
+	}
+	return api.e.preconfirmer.preconfirm(hash)
+}
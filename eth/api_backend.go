@@ -35,6 +35,7 @@ import (
 	"github.com/scroll-tech/go-ethereum/eth/gasprice"
 	"github.com/scroll-tech/go-ethereum/ethdb"
 	"github.com/scroll-tech/go-ethereum/event"
+	"github.com/scroll-tech/go-ethereum/internal/ethapi"
 	"github.com/scroll-tech/go-ethereum/miner"
 	"github.com/scroll-tech/go-ethereum/params"
 	"github.com/scroll-tech/go-ethereum/rpc"
@@ -46,6 +47,7 @@ type EthAPIBackend struct {
 	allowUnprotectedTxs bool
 	eth                 *Ethereum
 	gpo                 *gasprice.Oracle
+	callCache           *ethapi.CallCache
 }
 
 // ChainConfig returns the active chain configuration.
@@ -205,6 +207,10 @@ func (b *EthAPIBackend) GetEVM(ctx context.Context, msg core.Message, state *sta
 	vmError := func() error { return nil }
 	if vmConfig == nil {
 		vmConfig = b.eth.blockchain.GetVMConfig()
+	} else if b.eth.config.EnableInstructionFusion && !vmConfig.EnableInstructionFusion {
+		cfg := *vmConfig
+		cfg.EnableInstructionFusion = true
+		vmConfig = &cfg
 	}
 	txContext := core.NewEVMTxContext(msg)
 	context := core.NewEVMBlockContext(header, b.eth.BlockChain(), nil)
@@ -215,6 +221,10 @@ func (b *EthAPIBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEven
 	return b.eth.BlockChain().SubscribeRemovedLogsEvent(ch)
 }
 
+func (b *EthAPIBackend) SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription {
+	return b.eth.BlockChain().SubscribeReorgEvent(ch)
+}
+
 func (b *EthAPIBackend) SubscribePendingLogsEvent(ch chan<- []*types.Log) event.Subscription {
 	return b.eth.miner.SubscribePendingLogs(ch)
 }
@@ -236,7 +246,16 @@ func (b *EthAPIBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscri
 }
 
 func (b *EthAPIBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
-	return b.eth.txPool.AddLocal(signedTx)
+	if b.eth.readonly {
+		return errReadOnly
+	}
+	if err := b.eth.txPool.AddLocal(signedTx); err != nil {
+		return err
+	}
+	if b.eth.txForwarder != nil {
+		b.eth.txForwarder.forward(signedTx)
+	}
+	return nil
 }
 
 func (b *EthAPIBackend) GetPoolTransactions() (types.Transactions, error) {
@@ -257,6 +276,18 @@ func (b *EthAPIBackend) GetTransaction(ctx context.Context, txHash common.Hash)
 	return tx, blockHash, blockNumber, index, nil
 }
 
+func (b *EthAPIBackend) GetTransactionBySenderAndNonce(ctx context.Context, sender common.Address, nonce uint64) (*types.Transaction, common.Hash, uint64, uint64, error) {
+	if !b.eth.config.SenderNonceIndex {
+		return nil, common.Hash{}, 0, 0, errors.New("sender and nonce index disabled")
+	}
+	hash := rawdb.ReadSenderNonceLookup(b.eth.ChainDb(), sender, nonce)
+	if hash == nil {
+		return nil, common.Hash{}, 0, 0, nil
+	}
+	tx, blockHash, blockNumber, index := rawdb.ReadTransaction(b.eth.ChainDb(), *hash)
+	return tx, blockHash, blockNumber, index, nil
+}
+
 func (b *EthAPIBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
 	return b.eth.txPool.Nonce(addr), nil
 }
@@ -273,6 +304,10 @@ func (b *EthAPIBackend) TxPoolContentFrom(addr common.Address) (types.Transactio
 	return b.eth.TxPool().ContentFrom(addr)
 }
 
+func (b *EthAPIBackend) TxPoolPending() map[common.Address]types.Transactions {
+	return b.eth.TxPool().PendingLists()
+}
+
 func (b *EthAPIBackend) TxPool() *core.TxPool {
 	return b.eth.TxPool()
 }
@@ -281,10 +316,39 @@ func (b *EthAPIBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.S
 	return b.eth.TxPool().SubscribeNewTxsEvent(ch)
 }
 
+func (b *EthAPIBackend) TxPoolLocals() []common.Address {
+	return b.eth.TxPool().Locals()
+}
+
+func (b *EthAPIBackend) TxPoolDropReason(hash common.Hash) (core.TxDropReason, bool) {
+	return b.eth.TxPool().DropReason(hash)
+}
+
+func (b *EthAPIBackend) SubscribeDroppedTxsEvent(ch chan<- core.DroppedTxsEvent) event.Subscription {
+	return b.eth.TxPool().SubscribeDroppedTxsEvent(ch)
+}
+
 func (b *EthAPIBackend) SyncProgress() ethereum.SyncProgress {
+	// Report the regeneration of state lost in an unclean shutdown as syncing
+	if progress := b.eth.blockchain.RecoveryProgress(); progress != nil {
+		return ethereum.SyncProgress{
+			StartingBlock: progress.StartingBlock,
+			CurrentBlock:  progress.CurrentBlock,
+			HighestBlock:  progress.TargetBlock,
+		}
+	}
 	return b.eth.Downloader().Progress()
 }
 
+func (b *EthAPIBackend) RecoveryProgress() *core.RecoveryProgress {
+	return b.eth.blockchain.RecoveryProgress()
+}
+
+// GasPriceOracle returns the oracle suggesting transaction fees.
+func (b *EthAPIBackend) GasPriceOracle() *gasprice.Oracle {
+	return b.gpo
+}
+
 func (b *EthAPIBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
 	return b.gpo.SuggestTipCap(ctx)
 }
@@ -321,13 +385,17 @@ func (b *EthAPIBackend) RPCEVMTimeout() time.Duration {
 	return b.eth.config.RPCEVMTimeout
 }
 
+func (b *EthAPIBackend) RPCCallCache() *ethapi.CallCache {
+	return b.callCache
+}
+
 func (b *EthAPIBackend) RPCTxFeeCap() float64 {
 	return b.eth.config.RPCTxFeeCap
 }
 
 func (b *EthAPIBackend) BloomStatus() (uint64, uint64) {
 	sections, _, _ := b.eth.bloomIndexer.Sections()
-	return params.BloomBitsBlocks, sections
+	return b.eth.config.BloomSectionSize, sections
 }
 
 func (b *EthAPIBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {
@@ -353,9 +421,18 @@ func (b *EthAPIBackend) StartMining(threads int) error {
 }
 
 func (b *EthAPIBackend) StateAtBlock(ctx context.Context, block *types.Block, reexec uint64, base *state.StateDB, checkLive, preferDisk bool) (*state.StateDB, error) {
-	return b.eth.stateAtBlock(block, reexec, base, checkLive, preferDisk)
+	return b.eth.stateAtBlock(block, b.maxReexec(reexec), base, checkLive, preferDisk)
 }
 
 func (b *EthAPIBackend) StateAtTransaction(ctx context.Context, block *types.Block, txIndex int, reexec uint64) (core.Message, vm.BlockContext, *state.StateDB, error) {
-	return b.eth.stateAtTransaction(block, txIndex, reexec)
+	return b.eth.stateAtTransaction(block, txIndex, b.maxReexec(reexec))
+}
+
+// maxReexec bounds the re-execution depth requested over RPC by the configured
+// limit, if any.
+func (b *EthAPIBackend) maxReexec(reexec uint64) uint64 {
+	if limit := b.eth.config.RPCMaxReexec; reexec > limit {
+		return limit
+	}
+	return reexec
 }
@@ -29,7 +29,9 @@ import (
 	"github.com/scroll-tech/go-ethereum/common"
 	"github.com/scroll-tech/go-ethereum/core/rawdb"
 	"github.com/scroll-tech/go-ethereum/core/state"
+	zkt "github.com/scroll-tech/go-ethereum/core/types/zktrie"
 	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/scroll-tech/go-ethereum/trie"
 )
 
 var dumper = spew.ConfigState{Indent: "    "}
@@ -219,3 +221,83 @@ func TestStorageRangeAt(t *testing.T) {
 		}
 	}
 }
+
+func TestStorageRangeAtZktrie(t *testing.T) {
+	t.Parallel()
+
+	// Create a state where account 0x010000... has a few storage entries and a
+	// deleted one.
+	var (
+		sdb      = state.NewDatabaseWithConfig(rawdb.NewMemoryDatabase(), &trie.Config{Zktrie: true, Preimages: true})
+		state, _ = state.New(common.Hash{}, sdb, nil)
+		addr     = common.Address{0x01}
+		storage  = make(storageMap)
+	)
+	for i := byte(1); i <= 5; i++ {
+		key, value := common.Hash{i}, common.Hash{0x10 + i}
+		state.SetState(addr, key, value)
+
+		hash, err := zkt.NewByte32FromBytesPaddingZero(key[:]).Hash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		storage[common.BigToHash(hash)] = storageEntry{Key: &common.Hash{i}, Value: value}
+	}
+	state.SetState(addr, common.Hash{0x06}, common.Hash{0x16})
+	state.Commit(false)
+	state.SetState(addr, common.Hash{0x06}, common.Hash{})
+	state.Commit(false)
+
+	result, err := storageRangeAt(state.StorageTrie(addr), nil, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result, StorageRangeResult{storage, nil}) {
+		t.Fatalf("wrong full range:\ngot %s\nwant %s", dumper.Sdump(result), dumper.Sdump(storage))
+	}
+	// Page through the slots following the next keys
+	var (
+		have = make(storageMap)
+		next []byte
+	)
+	for pages := 0; ; pages++ {
+		if pages == len(storage) {
+			t.Fatal("paging doesn't terminate")
+		}
+		result, err := storageRangeAt(state.StorageTrie(addr), next, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Storage) > 2 {
+			t.Fatalf("page too large: %d slots", len(result.Storage))
+		}
+		for key, entry := range result.Storage {
+			if _, ok := have[key]; ok {
+				t.Fatalf("slot %x returned twice", key)
+			}
+			have[key] = entry
+		}
+		if result.NextKey == nil {
+			break
+		}
+		if _, ok := storage[*result.NextKey]; !ok {
+			t.Fatalf("next key %x is not a slot", *result.NextKey)
+		}
+		next = result.NextKey[:]
+	}
+	if !reflect.DeepEqual(have, storage) {
+		t.Fatalf("wrong paged range:\ngot %s\nwant %s", dumper.Sdump(have), dumper.Sdump(storage))
+	}
+	if _, err := storageRangeAt(state.StorageTrie(addr), []byte{0x40}, 2); err == nil {
+		t.Fatal("short start key accepted")
+	}
+}
+
+// Tests that oversized witness batches are rejected before looking up any block.
+func TestBatchWitnessLimit(t *testing.T) {
+	api := NewPublicTraceAPI(nil) // Any block lookup panics
+
+	if _, err := api.GetBatchWitnessByHashes(make([]common.Hash, maxBatchWitnessBlocks+1)); err == nil {
+		t.Fatal("oversized batch accepted")
+	}
+}
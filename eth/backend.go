@@ -18,6 +18,7 @@
 package eth
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
@@ -57,6 +58,10 @@ import (
 	"github.com/scroll-tech/go-ethereum/rpc"
 )
 
+// errReadOnly is returned when attempting to modify the chain of a node running
+// on a read-only data directory.
+var errReadOnly = errors.New("data directory opened read-only")
+
 // Config contains the configuration options of the ETH protocol.
 // Deprecated: use ethconfig.Config instead.
 type Config = ethconfig.Config
@@ -67,6 +72,9 @@ type Ethereum struct {
 
 	// Handlers
 	txPool             *core.TxPool
+	txForwarder        *txForwarder  // Relays RPC submitted transactions to the sequencers, nil if disabled
+	stateCopier        *stateCopier  // Copies the state from another node on admin request
+	preconfirmer       *preconfirmer // Signs preconfirmations of pending transactions, nil if disabled
 	blockchain         *core.BlockChain
 	handler            *handler
 	ethDialCandidates  enode.Iterator
@@ -94,6 +102,10 @@ type Ethereum struct {
 
 	p2pServer *p2p.Server
 
+	readonly bool // Whether the data directory was opened read-only
+
+	recovery sync.WaitGroup // Tracks the regeneration of state lost in an unclean shutdown
+
 	lock sync.RWMutex // Protects the variadic fields (e.g. gas price and etherbase)
 }
 
@@ -127,19 +139,34 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	ethashConfig.NotifyFull = config.Miner.NotifyFull
 
 	// Assemble the Ethereum object
-	chainDb, err := stack.OpenDatabaseWithFreezer("chaindata", config.DatabaseCache, config.DatabaseHandles, config.DatabaseFreezer, "eth/db/chaindata/", false)
+	handles := config.DatabaseHandles
+	if config.ZktrieDatabaseEngine != "" {
+		handles /= 2 // Leave half of the file handles to the trie node store
+	}
+	chainDb, err := stack.OpenDatabaseWithFreezer("chaindata", config.DatabaseCache, handles, config.DatabaseFreezer, "eth/db/chaindata/", false)
 	if err != nil {
 		return nil, err
 	}
+	if chainDb, err = ethconfig.OpenZktrieDatabase(stack, chainDb, config.ZktrieDatabaseEngine, config.ZktrieDatabaseCache, handles, false); err != nil {
+		return nil, err
+	}
 	chainConfig, genesisHash, genesisErr := core.SetupGenesisBlockWithOverride(chainDb, config.Genesis, config.OverrideArrowGlacier)
 	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
 		return nil, genesisErr
 	}
 	log.Info("Initialised chain configuration", "config", chainConfig)
 
+	if err := core.CheckStateScheme(chainDb, chainConfig, config.OverrideStateScheme); err != nil {
+		return nil, err
+	}
+
 	if err := pruner.RecoverPruning(stack.ResolvePath(""), chainDb, stack.ResolvePath(config.TrieCleanCacheJournal)); err != nil {
 		log.Error("Failed to recover state", "error", err)
 	}
+	if config.BloomSectionSize == 0 {
+		config.BloomSectionSize = params.BloomBitsBlocks
+	}
+	core.CheckBloomSectionSize(chainDb, config.BloomSectionSize)
 	eth := &Ethereum{
 		config:            config,
 		chainDb:           chainDb,
@@ -151,8 +178,9 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		gasPrice:          config.Miner.GasPrice,
 		etherbase:         config.Miner.Etherbase,
 		bloomRequests:     make(chan chan *bloombits.Retrieval),
-		bloomIndexer:      core.NewBloomIndexer(chainDb, params.BloomBitsBlocks, params.BloomConfirms),
+		bloomIndexer:      core.NewBloomIndexer(chainDb, config.BloomSectionSize, params.BloomConfirms),
 		p2pServer:         stack.Server(),
+		readonly:          stack.Config().ReadOnly,
 	}
 
 	bcVersion := rawdb.ReadDatabaseVersion(chainDb)
@@ -175,6 +203,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	var (
 		vmConfig = vm.Config{
 			EnablePreimageRecording: config.EnablePreimageRecording,
+			EnableInstructionFusion: config.EnableInstructionFusion,
 			Debug:                   true,
 			Tracer:                  vm.NewStructLogger(&vm.LogConfig{EnableMemory: true}),
 		}
@@ -187,11 +216,25 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			TrieDirtyDisabled:   config.NoPruning,
 			TrieTimeLimit:       config.TrieTimeout,
 			SnapshotLimit:       config.SnapshotCache,
+			CodeCacheLimit:      config.CodeCache,
+			PrefetchWorkers:     config.PrefetchWorkers,
 			Preimages:           config.Preimages,
 			TraceCacheLimit:     config.TraceCacheLimit,
 			MPTWitness:          config.MPTWitness,
+			CallTraceIndex:      config.CallTraceIndex,
+			SenderNonceIndex:    config.SenderNonceIndex,
+			AddressActivity:     config.AddressActivity,
+			TokenTransfers:      config.TokenTransfers,
+			EventLogIndex:       config.EventLogIndex,
+			CompactionIdle:      config.DatabaseCompactionIdle,
+			CompactionInterval:  config.DatabaseCompactionInterval,
 		}
 	)
+	if eth.readonly {
+		// Nothing may be persisted into a read-only data directory
+		cacheConfig.TrieCleanJournal = ""
+		config.TxPool.Journal = ""
+	}
 	eth.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, chainConfig, eth.engine, vmConfig, eth.shouldPreserve, &config.TxLookupLimit)
 	if err != nil {
 		return nil, err
@@ -209,6 +252,23 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	}
 	eth.txPool = core.NewTxPool(config.TxPool, chainConfig, eth.blockchain)
 
+	// Reapply any pool policy set at runtime before the restart
+	if blob := rawdb.ReadTxPoolPolicy(chainDb); len(blob) > 0 {
+		var policy core.TxPoolPolicy
+		if err := json.Unmarshal(blob, &policy); err != nil {
+			log.Error("Invalid persisted txpool policy", "err", err)
+		} else if err := eth.txPool.SetPolicy(policy); err != nil {
+			log.Error("Failed to apply persisted txpool policy", "err", err)
+		}
+	}
+	if len(config.TxForward) > 0 {
+		eth.txForwarder = newTxForwarder(config.TxForward, config.TxForwardRate)
+	}
+	eth.stateCopier = newStateCopier(chainDb)
+	if config.PreconfirmationKey != nil {
+		eth.preconfirmer = newPreconfirmer(eth, config.PreconfirmationKey)
+	}
+
 	// Permit the downloader to use the trie cache allowance during fast sync
 	cacheLimit := cacheConfig.TrieCleanLimit + cacheConfig.TrieDirtyLimit + cacheConfig.SnapshotLimit
 	checkpoint := config.Checkpoint
@@ -224,7 +284,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		BloomCache: uint64(cacheLimit),
 		EventMux:   eth.eventMux,
 		Checkpoint: checkpoint,
-		Whitelist:  config.Whitelist,
+		Whitelist:  checkpointWhitelist(chainDb, genesisHash, config.CheckpointSigner, config.Whitelist),
 	}); err != nil {
 		return nil, err
 	}
@@ -232,7 +292,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	eth.miner = miner.New(eth, &config.Miner, chainConfig, eth.EventMux(), eth.engine, eth.isLocalBlock)
 	eth.miner.SetExtra(makeExtraData(config.Miner.ExtraData))
 
-	eth.APIBackend = &EthAPIBackend{stack.Config().ExtRPCEnabled(), stack.Config().AllowUnprotectedTxs, eth, nil}
+	eth.APIBackend = &EthAPIBackend{stack.Config().ExtRPCEnabled(), stack.Config().AllowUnprotectedTxs, eth, nil, ethapi.NewCallCache(config.RPCCallCacheSize, config.RPCCallCacheTTL)}
 	if eth.APIBackend.allowUnprotectedTxs {
 		log.Info("Unprotected transactions allowed")
 	}
@@ -276,6 +336,40 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	return eth, nil
 }
 
+// checkpointWhitelist merges the imported header checkpoints into the whitelist
+// of required block hashes. Checkpoints not signed by the given signer, or the
+// checkpoint signer of the network if nil, are ignored.
+func checkpointWhitelist(db ethdb.Database, genesis common.Hash, signer *common.Address, whitelist map[uint64]common.Hash) map[uint64]common.Hash {
+	bundle := rawdb.ReadCheckpointBundle(db)
+	if bundle == nil || len(bundle.Checkpoints) == 0 {
+		return whitelist
+	}
+	if signer == nil {
+		if known, ok := params.CheckpointSigners[genesis]; ok {
+			signer = &known
+		}
+	}
+	if signer == nil {
+		log.Warn("Ignoring header checkpoints, no checkpoint signer configured")
+		return whitelist
+	}
+	if err := bundle.Verify(genesis, *signer); err != nil {
+		log.Warn("Ignoring invalid header checkpoints", "err", err)
+		return whitelist
+	}
+	merged := make(map[uint64]common.Hash, len(bundle.Checkpoints)+len(whitelist))
+	for _, cp := range bundle.Checkpoints {
+		merged[cp.Number] = cp.Hash
+	}
+	// Explicitly whitelisted hashes take precedence
+	for number, hash := range whitelist {
+		merged[number] = hash
+	}
+	last := bundle.Checkpoints[len(bundle.Checkpoints)-1]
+	log.Info("Loaded header checkpoints", "count", len(bundle.Checkpoints), "number", last.Number, "hash", last.Hash)
+	return merged
+}
+
 func makeExtraData(extra []byte) []byte {
 	if len(extra) == 0 {
 		// create default extradata
@@ -293,6 +387,14 @@ func makeExtraData(extra []byte) []byte {
 	return extra
 }
 
+// logQueryLimits returns the log query limits configured for the filter API.
+func (s *Ethereum) logQueryLimits() filters.LogQueryLimits {
+	return filters.LogQueryLimits{
+		MaxBlockRange: s.config.RPCLogQueryMaxBlocks,
+		MaxResults:    s.config.RPCLogQueryMaxResults,
+	}
+}
+
 // APIs return the collection of RPC services the ethereum package offers.
 // NOTE, some of these services probably need to be moved to somewhere else.
 func (s *Ethereum) APIs() []rpc.API {
@@ -323,6 +425,21 @@ func (s *Ethereum) APIs() []rpc.API {
 			Version:   "1.0",
 			Service:   NewPublicTraceAPI(s),
 			Public:    true,
+		}, {
+			Namespace: "scroll",
+			Version:   "1.0",
+			Service:   NewPublicTokenAPI(s),
+			Public:    true,
+		}, {
+			Namespace: "scroll",
+			Version:   "1.0",
+			Service:   NewPublicEventLogAPI(s),
+			Public:    true,
+		}, {
+			Namespace: "scroll",
+			Version:   "1.0",
+			Service:   NewPublicPreconfirmationAPI(s),
+			Public:    true,
 		}, {
 			Namespace: "miner",
 			Version:   "1.0",
@@ -331,8 +448,14 @@ func (s *Ethereum) APIs() []rpc.API {
 		}, {
 			Namespace: "eth",
 			Version:   "1.0",
-			Service:   filters.NewPublicFilterAPI(s.APIBackend, false, 5*time.Minute),
+			Service:   filters.NewPublicFilterAPI(s.APIBackend, false, 5*time.Minute, s.logQueryLimits()),
 			Public:    true,
+		}, {
+			Namespace: "txpool",
+			Version:   "1.0",
+			Service:   NewPrivateTxPoolAPI(s),
+			Public:    false,
+			Admin:     true,
 		}, {
 			Namespace: "admin",
 			Version:   "1.0",
@@ -449,6 +572,9 @@ func (s *Ethereum) SetEtherbase(etherbase common.Address) {
 // is already running, this method adjust the number of threads allowed to use
 // and updates the minimum price required by the transaction pool.
 func (s *Ethereum) StartMining(threads int) error {
+	if s.readonly {
+		return errReadOnly
+	}
 	// Update the thread count within the consensus engine
 	type threaded interface {
 		SetThreads(threads int)
@@ -480,7 +606,15 @@ func (s *Ethereum) StartMining(threads int) error {
 				log.Error("Etherbase account unavailable locally", "err", err)
 				return fmt.Errorf("signer missing: %v", err)
 			}
-			clique.Authorize(eb, wallet.SignData)
+			// Refuse to seal with a signer that can't be reached, e.g. a remote one
+			if status, err := wallet.Status(); err != nil {
+				log.Error("Etherbase signer unavailable", "url", wallet.URL(), "status", status, "err", err)
+				return fmt.Errorf("signer unavailable: %v", err)
+			}
+			if err := clique.AuthorizeWallet(wallet, eb); err != nil {
+				log.Error("Etherbase signer unusable for sealing", "url", wallet.URL(), "err", err)
+				return fmt.Errorf("signer unusable: %v", err)
+			}
 		}
 		// If mining is started, we can disable the transaction rejection mechanism
 		// introduced to speed sync times.
@@ -530,13 +664,48 @@ func (s *Ethereum) Protocols() []p2p.Protocol {
 	return protos
 }
 
+// gracefulShutdown stops the transaction pool from accepting transactions and
+// waits up to the given timeout for the block being sealed to be written, so the
+// node restarts from a head whose state is flushed along with the chain.
+func (s *Ethereum) gracefulShutdown(timeout time.Duration) {
+	log.Info("Starting graceful shutdown", "timeout", timeout)
+
+	s.txPool.Drain()
+	if s.miner.Drain(timeout) {
+		log.Info("Finished in-flight block for shutdown", "head", s.blockchain.CurrentBlock().NumberU64())
+	} else {
+		log.Warn("Abandoned in-flight block for shutdown", "head", s.blockchain.CurrentBlock().NumberU64())
+	}
+}
+
+// recoverState replays the blocks above the repaired head block, reporting the
+// outcome to the miner like a finished chain sync.
+func (s *Ethereum) recoverState() {
+	defer s.recovery.Done()
+
+	if err := s.blockchain.RecoverState(s.config.RecoveryParallel); err != nil {
+		log.Error("Failed to regenerate state", "head", s.blockchain.CurrentBlock().NumberU64(), "err", err)
+		s.eventMux.Post(downloader.FailedEvent{Err: err})
+		return
+	}
+	s.eventMux.Post(downloader.DoneEvent{Latest: s.blockchain.CurrentHeader()})
+}
+
 // Start implements node.Lifecycle, starting all internal goroutines needed by the
 // Ethereum protocol implementation.
 func (s *Ethereum) Start() error {
 	//eth.StartENRUpdater(s.blockchain, s.p2pServer.LocalNode())
 
 	// Start the bloom bits servicing goroutines
-	s.startBloomHandlers(params.BloomBitsBlocks)
+	s.startBloomHandlers(s.config.BloomSectionSize)
+
+	// Regenerate the state lost in an unclean shutdown, holding off mining until done
+	if !s.readonly && s.blockchain.NeedsRecovery() {
+		s.eventMux.Post(downloader.StartEvent{})
+
+		s.recovery.Add(1)
+		go s.recoverState()
+	}
 
 	// Figure out a max peers count based on the server limits
 	//maxPeers := s.p2pServer.MaxPeers
@@ -559,12 +728,23 @@ func (s *Ethereum) Stop() error {
 	s.snapDialCandidates.Close()
 	//s.handler.Stop()
 
+	// If shutting down gracefully, stop taking transactions and settle the block
+	// being sealed, the trie nodes, txpool and snapshot are persisted below.
+	if s.config.GracefulShutdown > 0 {
+		s.gracefulShutdown(s.config.GracefulShutdown)
+	}
+
 	// Then stop everything else.
 	s.bloomIndexer.Close()
 	close(s.closeBloomHandler)
+	if s.txForwarder != nil {
+		s.txForwarder.close()
+	}
+	s.stateCopier.close()
 	s.txPool.Stop()
 	s.miner.Close()
 	s.blockchain.Stop()
+	s.recovery.Wait()
 	s.engine.Close()
 	rawdb.PopUncleanShutdownMarker(s.chainDb)
 	s.chainDb.Close()
@@ -18,6 +18,8 @@
 package ethconfig
 
 import (
+	"crypto/ecdsa"
+	"fmt"
 	"math/big"
 	"os"
 	"os/user"
@@ -30,6 +32,7 @@ import (
 	"github.com/scroll-tech/go-ethereum/consensus/clique"
 	"github.com/scroll-tech/go-ethereum/consensus/ethash"
 	"github.com/scroll-tech/go-ethereum/core"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
 	"github.com/scroll-tech/go-ethereum/eth/downloader"
 	"github.com/scroll-tech/go-ethereum/eth/gasprice"
 	"github.com/scroll-tech/go-ethereum/ethdb"
@@ -73,25 +76,32 @@ var Defaults = Config{
 	},
 	NetworkId:               1,
 	TxLookupLimit:           2350000,
+	BloomSectionSize:        params.BloomBitsBlocks,
 	LightPeers:              100,
 	UltraLightFraction:      75,
 	DatabaseCache:           512,
+	ZktrieDatabaseCache:     512,
+	DatabaseCompactionIdle:  2 * time.Second,
 	TrieCleanCache:          154,
 	TrieCleanCacheJournal:   "triecache",
 	TrieCleanCacheRejournal: 60 * time.Minute,
 	TrieDirtyCache:          256,
 	TrieTimeout:             60 * time.Minute,
 	SnapshotCache:           102,
+	CodeCache:               64,
+	PrefetchWorkers:         4,
 	Miner: miner.Config{
 		GasCeil:  8000000,
 		GasPrice: big.NewInt(params.GWei),
 		Recommit: 3 * time.Second,
 	},
-	TxPool:        core.DefaultTxPoolConfig,
-	RPCGasCap:     50000000,
-	RPCEVMTimeout: 5 * time.Second,
-	GPO:           FullNodeGPO,
-	RPCTxFeeCap:   1, // 1 ether
+	TxPool:          core.DefaultTxPoolConfig,
+	RPCGasCap:       50000000,
+	RPCEVMTimeout:   5 * time.Second,
+	RPCCallCacheTTL: time.Minute,
+	RPCMaxReexec:    128,
+	GPO:             FullNodeGPO,
+	RPCTxFeeCap:     1, // 1 ether
 }
 
 func init() {
@@ -137,9 +147,19 @@ type Config struct {
 
 	TxLookupLimit uint64 `toml:",omitempty"` // The maximum number of blocks from head whose tx indices are reserved.
 
+	BloomSectionSize uint64 `toml:",omitempty"` // Number of blocks per bloom bits section (params.BloomBitsBlocks or params.BloomBitsBlocksFine)
+	SenderNonceIndex bool   `toml:",omitempty"` // Whether to index canonical transactions by sender and nonce
+	AddressActivity  bool   `toml:",omitempty"` // Whether to index transactions by the addresses taking part in them
+	TokenTransfers   bool   `toml:",omitempty"` // Whether to index ERC-20 and ERC-721 transfer logs by token and holder
+	EventLogIndex    bool   `toml:",omitempty"` // Whether to index logs by emitting contract and event signature
+
 	// Whitelist of required block number -> hash values to accept
 	Whitelist map[uint64]common.Hash `toml:"-"`
 
+	// Signer of the header checkpoint bundles to accept, nil for the one of
+	// the network
+	CheckpointSigner *common.Address `toml:",omitempty"`
+
 	// Light client options
 	LightServ          int  `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
 	LightIngress       int  `toml:",omitempty"` // Incoming bandwidth limit for light servers
@@ -160,17 +180,37 @@ type Config struct {
 	DatabaseCache      int
 	DatabaseFreezer    string
 
+	ZktrieDatabaseEngine string `toml:",omitempty"` // Engine of a dedicated trie node store, shared with chain data if empty
+	ZktrieDatabaseCache  int    // Megabytes of cache allotted to the dedicated trie node store
+
+	DatabaseCompactionIdle     time.Duration `toml:",omitempty"` // Idle time required before running scheduled compactions
+	DatabaseCompactionInterval time.Duration `toml:",omitempty"` // Time between proactive compaction sweeps (0 = disabled)
+
 	TrieCleanCache          int
 	TrieCleanCacheJournal   string        `toml:",omitempty"` // Disk journal directory for trie cache to survive node restarts
 	TrieCleanCacheRejournal time.Duration `toml:",omitempty"` // Time interval to regenerate the journal for clean cache
 	TrieDirtyCache          int
 	TrieTimeout             time.Duration
 	SnapshotCache           int
+	CodeCache               int `toml:",omitempty"` // Megabytes of contract code cache shared across state databases
+	PrefetchWorkers         int `toml:",omitempty"` // Goroutines speculatively executing transactions to warm zk trie caches
 	Preimages               bool
 
+	// RecoveryParallel regenerates the state lost in an unclean shutdown using the
+	// parallel import pipeline.
+	RecoveryParallel bool `toml:",omitempty"`
+
 	// Mining options
 	Miner miner.Config
 
+	// PreconfirmationKey signs the preconfirmations of the transactions ordered in
+	// the pending block. Preconfirmations are disabled if not set.
+	PreconfirmationKey *ecdsa.PrivateKey `toml:"-"`
+
+	// GracefulShutdown is the time to wait for the block being sealed at shutdown,
+	// enabling the graceful shutdown sequence. Zero disables it.
+	GracefulShutdown time.Duration `toml:",omitempty"`
+
 	// Ethash options
 	Ethash ethash.Config
 
@@ -183,6 +223,9 @@ type Config struct {
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
 
+	// Enables fused execution of common opcode sequences in the VM
+	EnableInstructionFusion bool
+
 	// Miscellaneous options
 	DocRoot string `toml:"-"`
 
@@ -192,10 +235,36 @@ type Config struct {
 	// RPCEVMTimeout is the global timeout for eth-call.
 	RPCEVMTimeout time.Duration
 
+	// RPCCallCacheSize is the number of eth_call results memoized by block hash and
+	// call parameters. Zero disables the cache.
+	RPCCallCacheSize int
+
+	// RPCCallCacheTTL is the time an eth_call result stays memoized.
+	RPCCallCacheTTL time.Duration
+
+	// RPCMaxReexec is the maximum number of blocks re-executed to regenerate the
+	// historical state requested by the tracing RPCs but not retained. Plain
+	// state queries never regenerate state.
+	RPCMaxReexec uint64
+
+	// RPCLogQueryMaxBlocks is the maximum number of blocks a log query may span.
+	RPCLogQueryMaxBlocks uint64
+
+	// RPCLogQueryMaxResults is the maximum number of logs a log query may return.
+	RPCLogQueryMaxResults int
+
 	// RPCTxFeeCap is the global transaction fee(price * gaslimit) cap for
 	// send-transction variants. The unit is ether.
 	RPCTxFeeCap float64
 
+	// TxForward lists the RPC endpoints of the sequencers the transactions
+	// submitted over RPC are relayed to, on top of the p2p gossip.
+	TxForward []string `toml:",omitempty"`
+
+	// TxForwardRate is the maximum number of transactions relayed per second to
+	// each sequencer. Zero disables the limit.
+	TxForwardRate float64 `toml:",omitempty"`
+
 	// Checkpoint is a hardcoded checkpoint which can be nil.
 	Checkpoint *params.TrustedCheckpoint `toml:",omitempty"`
 
@@ -205,9 +274,33 @@ type Config struct {
 	// Arrow Glacier block override (TODO: remove after the fork)
 	OverrideArrowGlacier *big.Int `toml:",omitempty"`
 
+	// OverrideStateScheme allows starting on a database whose state is stored
+	// with a different trie scheme than the chain config requires. The scheme
+	// recorded in the database is not changed.
+	OverrideStateScheme bool `toml:",omitempty"`
+
 	// Trace option
 	TraceCacheLimit int
 	MPTWitness      int
+	CallTraceIndex  bool
+}
+
+// OpenZktrieDatabase opens the dedicated trie node store of the given engine and
+// layers it over the chain database. If no engine is configured, the nodes are
+// kept in the chain database itself which is returned as is.
+func OpenZktrieDatabase(stack *node.Node, chainDb ethdb.Database, engine string, cache, handles int, readonly bool) (ethdb.Database, error) {
+	switch engine {
+	case "":
+		return chainDb, nil
+	case "leveldb":
+		nodes, err := stack.OpenDatabase("zktrie", cache, handles, "eth/db/zktrie/", readonly)
+		if err != nil {
+			return nil, err
+		}
+		return rawdb.NewDatabaseWithNodeStore(chainDb, nodes), nil
+	default:
+		return nil, fmt.Errorf("unsupported zktrie database engine %q", engine)
+	}
 }
 
 // CreateConsensusEngine creates a consensus engine for the given chain configuration.
@@ -3,6 +3,7 @@
 package ethconfig
 
 import (
+	"crypto/ecdsa"
 	"math/big"
 	"time"
 
@@ -18,48 +19,72 @@ import (
 // MarshalTOML marshals as TOML.
 func (c Config) MarshalTOML() (interface{}, error) {
 	type Config struct {
-		Genesis                 *core.Genesis `toml:",omitempty"`
-		NetworkId               uint64
-		SyncMode                downloader.SyncMode
-		EthDiscoveryURLs        []string
-		SnapDiscoveryURLs       []string
-		NoPruning               bool
-		NoPrefetch              bool
-		TxLookupLimit           uint64                 `toml:",omitempty"`
-		Whitelist               map[uint64]common.Hash `toml:"-"`
-		LightServ               int                    `toml:",omitempty"`
-		LightIngress            int                    `toml:",omitempty"`
-		LightEgress             int                    `toml:",omitempty"`
-		LightPeers              int                    `toml:",omitempty"`
-		LightNoPrune            bool                   `toml:",omitempty"`
-		LightNoSyncServe        bool                   `toml:",omitempty"`
-		SyncFromCheckpoint      bool                   `toml:",omitempty"`
-		UltraLightServers       []string               `toml:",omitempty"`
-		UltraLightFraction      int                    `toml:",omitempty"`
-		UltraLightOnlyAnnounce  bool                   `toml:",omitempty"`
-		SkipBcVersionCheck      bool                   `toml:"-"`
-		DatabaseHandles         int                    `toml:"-"`
-		DatabaseCache           int
-		DatabaseFreezer         string
-		TrieCleanCache          int
-		TrieCleanCacheJournal   string        `toml:",omitempty"`
-		TrieCleanCacheRejournal time.Duration `toml:",omitempty"`
-		TrieDirtyCache          int
-		TrieTimeout             time.Duration
-		SnapshotCache           int
-		Preimages               bool
-		Miner                   miner.Config
-		Ethash                  ethash.Config
-		TxPool                  core.TxPoolConfig
-		GPO                     gasprice.Config
-		EnablePreimageRecording bool
-		DocRoot                 string `toml:"-"`
-		RPCGasCap               uint64
-		RPCEVMTimeout           time.Duration
-		RPCTxFeeCap             float64
-		Checkpoint              *params.TrustedCheckpoint      `toml:",omitempty"`
-		CheckpointOracle        *params.CheckpointOracleConfig `toml:",omitempty"`
-		OverrideArrowGlacier    *big.Int                       `toml:",omitempty"`
+		Genesis                    *core.Genesis `toml:",omitempty"`
+		NetworkId                  uint64
+		SyncMode                   downloader.SyncMode
+		EthDiscoveryURLs           []string
+		SnapDiscoveryURLs          []string
+		NoPruning                  bool
+		NoPrefetch                 bool
+		TxLookupLimit              uint64                 `toml:",omitempty"`
+		BloomSectionSize           uint64                 `toml:",omitempty"`
+		SenderNonceIndex           bool                   `toml:",omitempty"`
+		AddressActivity            bool                   `toml:",omitempty"`
+		TokenTransfers             bool                   `toml:",omitempty"`
+		EventLogIndex              bool                   `toml:",omitempty"`
+		Whitelist                  map[uint64]common.Hash `toml:"-"`
+		CheckpointSigner           *common.Address        `toml:",omitempty"`
+		LightServ                  int                    `toml:",omitempty"`
+		LightIngress               int                    `toml:",omitempty"`
+		LightEgress                int                    `toml:",omitempty"`
+		LightPeers                 int                    `toml:",omitempty"`
+		LightNoPrune               bool                   `toml:",omitempty"`
+		LightNoSyncServe           bool                   `toml:",omitempty"`
+		SyncFromCheckpoint         bool                   `toml:",omitempty"`
+		UltraLightServers          []string               `toml:",omitempty"`
+		UltraLightFraction         int                    `toml:",omitempty"`
+		UltraLightOnlyAnnounce     bool                   `toml:",omitempty"`
+		SkipBcVersionCheck         bool                   `toml:"-"`
+		DatabaseHandles            int                    `toml:"-"`
+		DatabaseCache              int
+		DatabaseFreezer            string
+		ZktrieDatabaseEngine       string `toml:",omitempty"`
+		ZktrieDatabaseCache        int
+		DatabaseCompactionIdle     time.Duration `toml:",omitempty"`
+		DatabaseCompactionInterval time.Duration `toml:",omitempty"`
+		TrieCleanCache             int
+		TrieCleanCacheJournal      string        `toml:",omitempty"`
+		TrieCleanCacheRejournal    time.Duration `toml:",omitempty"`
+		TrieDirtyCache             int
+		TrieTimeout                time.Duration
+		SnapshotCache              int
+		CodeCache                  int `toml:",omitempty"`
+		PrefetchWorkers            int `toml:",omitempty"`
+		Preimages                  bool
+		RecoveryParallel           bool `toml:",omitempty"`
+		Miner                      miner.Config
+		PreconfirmationKey         *ecdsa.PrivateKey `toml:"-"`
+		GracefulShutdown           time.Duration     `toml:",omitempty"`
+		Ethash                     ethash.Config
+		TxPool                     core.TxPoolConfig
+		GPO                        gasprice.Config
+		EnablePreimageRecording    bool
+		EnableInstructionFusion    bool
+		DocRoot                    string `toml:"-"`
+		RPCGasCap                  uint64
+		RPCEVMTimeout              time.Duration
+		RPCCallCacheSize           int
+		RPCCallCacheTTL            time.Duration
+		RPCMaxReexec               uint64
+		RPCLogQueryMaxBlocks       uint64
+		RPCLogQueryMaxResults      int
+		RPCTxFeeCap                float64
+		TxForward                  []string                       `toml:",omitempty"`
+		TxForwardRate              float64                        `toml:",omitempty"`
+		Checkpoint                 *params.TrustedCheckpoint      `toml:",omitempty"`
+		CheckpointOracle           *params.CheckpointOracleConfig `toml:",omitempty"`
+		OverrideArrowGlacier       *big.Int                       `toml:",omitempty"`
+		OverrideStateScheme        bool                           `toml:",omitempty"`
 	}
 	var enc Config
 	enc.Genesis = c.Genesis
@@ -70,7 +95,13 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.NoPruning = c.NoPruning
 	enc.NoPrefetch = c.NoPrefetch
 	enc.TxLookupLimit = c.TxLookupLimit
+	enc.BloomSectionSize = c.BloomSectionSize
+	enc.SenderNonceIndex = c.SenderNonceIndex
+	enc.AddressActivity = c.AddressActivity
+	enc.TokenTransfers = c.TokenTransfers
+	enc.EventLogIndex = c.EventLogIndex
 	enc.Whitelist = c.Whitelist
+	enc.CheckpointSigner = c.CheckpointSigner
 	enc.LightServ = c.LightServ
 	enc.LightIngress = c.LightIngress
 	enc.LightEgress = c.LightEgress
@@ -85,73 +116,115 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.DatabaseHandles = c.DatabaseHandles
 	enc.DatabaseCache = c.DatabaseCache
 	enc.DatabaseFreezer = c.DatabaseFreezer
+	enc.ZktrieDatabaseEngine = c.ZktrieDatabaseEngine
+	enc.ZktrieDatabaseCache = c.ZktrieDatabaseCache
+	enc.DatabaseCompactionIdle = c.DatabaseCompactionIdle
+	enc.DatabaseCompactionInterval = c.DatabaseCompactionInterval
 	enc.TrieCleanCache = c.TrieCleanCache
 	enc.TrieCleanCacheJournal = c.TrieCleanCacheJournal
 	enc.TrieCleanCacheRejournal = c.TrieCleanCacheRejournal
 	enc.TrieDirtyCache = c.TrieDirtyCache
 	enc.TrieTimeout = c.TrieTimeout
 	enc.SnapshotCache = c.SnapshotCache
+	enc.CodeCache = c.CodeCache
+	enc.PrefetchWorkers = c.PrefetchWorkers
 	enc.Preimages = c.Preimages
+	enc.RecoveryParallel = c.RecoveryParallel
 	enc.Miner = c.Miner
+	enc.PreconfirmationKey = c.PreconfirmationKey
+	enc.GracefulShutdown = c.GracefulShutdown
 	enc.Ethash = c.Ethash
 	enc.TxPool = c.TxPool
 	enc.GPO = c.GPO
 	enc.EnablePreimageRecording = c.EnablePreimageRecording
+	enc.EnableInstructionFusion = c.EnableInstructionFusion
 	enc.DocRoot = c.DocRoot
 	enc.RPCGasCap = c.RPCGasCap
 	enc.RPCEVMTimeout = c.RPCEVMTimeout
+	enc.RPCCallCacheSize = c.RPCCallCacheSize
+	enc.RPCCallCacheTTL = c.RPCCallCacheTTL
+	enc.RPCMaxReexec = c.RPCMaxReexec
+	enc.RPCLogQueryMaxBlocks = c.RPCLogQueryMaxBlocks
+	enc.RPCLogQueryMaxResults = c.RPCLogQueryMaxResults
 	enc.RPCTxFeeCap = c.RPCTxFeeCap
+	enc.TxForward = c.TxForward
+	enc.TxForwardRate = c.TxForwardRate
 	enc.Checkpoint = c.Checkpoint
 	enc.CheckpointOracle = c.CheckpointOracle
 	enc.OverrideArrowGlacier = c.OverrideArrowGlacier
+	enc.OverrideStateScheme = c.OverrideStateScheme
 	return &enc, nil
 }
 
 // UnmarshalTOML unmarshals from TOML.
 func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	type Config struct {
-		Genesis                 *core.Genesis `toml:",omitempty"`
-		NetworkId               *uint64
-		SyncMode                *downloader.SyncMode
-		EthDiscoveryURLs        []string
-		SnapDiscoveryURLs       []string
-		NoPruning               *bool
-		NoPrefetch              *bool
-		TxLookupLimit           *uint64                `toml:",omitempty"`
-		Whitelist               map[uint64]common.Hash `toml:"-"`
-		LightServ               *int                   `toml:",omitempty"`
-		LightIngress            *int                   `toml:",omitempty"`
-		LightEgress             *int                   `toml:",omitempty"`
-		LightPeers              *int                   `toml:",omitempty"`
-		LightNoPrune            *bool                  `toml:",omitempty"`
-		LightNoSyncServe        *bool                  `toml:",omitempty"`
-		SyncFromCheckpoint      *bool                  `toml:",omitempty"`
-		UltraLightServers       []string               `toml:",omitempty"`
-		UltraLightFraction      *int                   `toml:",omitempty"`
-		UltraLightOnlyAnnounce  *bool                  `toml:",omitempty"`
-		SkipBcVersionCheck      *bool                  `toml:"-"`
-		DatabaseHandles         *int                   `toml:"-"`
-		DatabaseCache           *int
-		DatabaseFreezer         *string
-		TrieCleanCache          *int
-		TrieCleanCacheJournal   *string        `toml:",omitempty"`
-		TrieCleanCacheRejournal *time.Duration `toml:",omitempty"`
-		TrieDirtyCache          *int
-		TrieTimeout             *time.Duration
-		SnapshotCache           *int
-		Preimages               *bool
-		Miner                   *miner.Config
-		Ethash                  *ethash.Config
-		TxPool                  *core.TxPoolConfig
-		GPO                     *gasprice.Config
-		EnablePreimageRecording *bool
-		DocRoot                 *string `toml:"-"`
-		RPCGasCap               *uint64
-		RPCEVMTimeout           *time.Duration
-		RPCTxFeeCap             *float64
-		Checkpoint              *params.TrustedCheckpoint      `toml:",omitempty"`
-		CheckpointOracle        *params.CheckpointOracleConfig `toml:",omitempty"`
-		OverrideArrowGlacier    *big.Int                       `toml:",omitempty"`
+		Genesis                    *core.Genesis `toml:",omitempty"`
+		NetworkId                  *uint64
+		SyncMode                   *downloader.SyncMode
+		EthDiscoveryURLs           []string
+		SnapDiscoveryURLs          []string
+		NoPruning                  *bool
+		NoPrefetch                 *bool
+		TxLookupLimit              *uint64                `toml:",omitempty"`
+		BloomSectionSize           *uint64                `toml:",omitempty"`
+		SenderNonceIndex           *bool                  `toml:",omitempty"`
+		AddressActivity            *bool                  `toml:",omitempty"`
+		TokenTransfers             *bool                  `toml:",omitempty"`
+		EventLogIndex              *bool                  `toml:",omitempty"`
+		Whitelist                  map[uint64]common.Hash `toml:"-"`
+		CheckpointSigner           *common.Address        `toml:",omitempty"`
+		LightServ                  *int                   `toml:",omitempty"`
+		LightIngress               *int                   `toml:",omitempty"`
+		LightEgress                *int                   `toml:",omitempty"`
+		LightPeers                 *int                   `toml:",omitempty"`
+		LightNoPrune               *bool                  `toml:",omitempty"`
+		LightNoSyncServe           *bool                  `toml:",omitempty"`
+		SyncFromCheckpoint         *bool                  `toml:",omitempty"`
+		UltraLightServers          []string               `toml:",omitempty"`
+		UltraLightFraction         *int                   `toml:",omitempty"`
+		UltraLightOnlyAnnounce     *bool                  `toml:",omitempty"`
+		SkipBcVersionCheck         *bool                  `toml:"-"`
+		DatabaseHandles            *int                   `toml:"-"`
+		DatabaseCache              *int
+		DatabaseFreezer            *string
+		ZktrieDatabaseEngine       *string `toml:",omitempty"`
+		ZktrieDatabaseCache        *int
+		DatabaseCompactionIdle     *time.Duration `toml:",omitempty"`
+		DatabaseCompactionInterval *time.Duration `toml:",omitempty"`
+		TrieCleanCache             *int
+		TrieCleanCacheJournal      *string        `toml:",omitempty"`
+		TrieCleanCacheRejournal    *time.Duration `toml:",omitempty"`
+		TrieDirtyCache             *int
+		TrieTimeout                *time.Duration
+		SnapshotCache              *int
+		CodeCache                  *int `toml:",omitempty"`
+		PrefetchWorkers            *int `toml:",omitempty"`
+		Preimages                  *bool
+		RecoveryParallel           *bool `toml:",omitempty"`
+		Miner                      *miner.Config
+		PreconfirmationKey         *ecdsa.PrivateKey `toml:"-"`
+		GracefulShutdown           *time.Duration    `toml:",omitempty"`
+		Ethash                     *ethash.Config
+		TxPool                     *core.TxPoolConfig
+		GPO                        *gasprice.Config
+		EnablePreimageRecording    *bool
+		EnableInstructionFusion    *bool
+		DocRoot                    *string `toml:"-"`
+		RPCGasCap                  *uint64
+		RPCEVMTimeout              *time.Duration
+		RPCCallCacheSize           *int
+		RPCCallCacheTTL            *time.Duration
+		RPCMaxReexec               *uint64
+		RPCLogQueryMaxBlocks       *uint64
+		RPCLogQueryMaxResults      *int
+		RPCTxFeeCap                *float64
+		TxForward                  []string                       `toml:",omitempty"`
+		TxForwardRate              *float64                       `toml:",omitempty"`
+		Checkpoint                 *params.TrustedCheckpoint      `toml:",omitempty"`
+		CheckpointOracle           *params.CheckpointOracleConfig `toml:",omitempty"`
+		OverrideArrowGlacier       *big.Int                       `toml:",omitempty"`
+		OverrideStateScheme        *bool                          `toml:",omitempty"`
 	}
 	var dec Config
 	if err := unmarshal(&dec); err != nil {
@@ -181,9 +254,27 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.TxLookupLimit != nil {
 		c.TxLookupLimit = *dec.TxLookupLimit
 	}
+	if dec.BloomSectionSize != nil {
+		c.BloomSectionSize = *dec.BloomSectionSize
+	}
+	if dec.SenderNonceIndex != nil {
+		c.SenderNonceIndex = *dec.SenderNonceIndex
+	}
+	if dec.AddressActivity != nil {
+		c.AddressActivity = *dec.AddressActivity
+	}
+	if dec.TokenTransfers != nil {
+		c.TokenTransfers = *dec.TokenTransfers
+	}
+	if dec.EventLogIndex != nil {
+		c.EventLogIndex = *dec.EventLogIndex
+	}
 	if dec.Whitelist != nil {
 		c.Whitelist = dec.Whitelist
 	}
+	if dec.CheckpointSigner != nil {
+		c.CheckpointSigner = dec.CheckpointSigner
+	}
 	if dec.LightServ != nil {
 		c.LightServ = *dec.LightServ
 	}
@@ -226,6 +317,18 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.DatabaseFreezer != nil {
 		c.DatabaseFreezer = *dec.DatabaseFreezer
 	}
+	if dec.ZktrieDatabaseEngine != nil {
+		c.ZktrieDatabaseEngine = *dec.ZktrieDatabaseEngine
+	}
+	if dec.ZktrieDatabaseCache != nil {
+		c.ZktrieDatabaseCache = *dec.ZktrieDatabaseCache
+	}
+	if dec.DatabaseCompactionIdle != nil {
+		c.DatabaseCompactionIdle = *dec.DatabaseCompactionIdle
+	}
+	if dec.DatabaseCompactionInterval != nil {
+		c.DatabaseCompactionInterval = *dec.DatabaseCompactionInterval
+	}
 	if dec.TrieCleanCache != nil {
 		c.TrieCleanCache = *dec.TrieCleanCache
 	}
@@ -244,12 +347,27 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.SnapshotCache != nil {
 		c.SnapshotCache = *dec.SnapshotCache
 	}
+	if dec.CodeCache != nil {
+		c.CodeCache = *dec.CodeCache
+	}
+	if dec.PrefetchWorkers != nil {
+		c.PrefetchWorkers = *dec.PrefetchWorkers
+	}
 	if dec.Preimages != nil {
 		c.Preimages = *dec.Preimages
 	}
+	if dec.RecoveryParallel != nil {
+		c.RecoveryParallel = *dec.RecoveryParallel
+	}
 	if dec.Miner != nil {
 		c.Miner = *dec.Miner
 	}
+	if dec.PreconfirmationKey != nil {
+		c.PreconfirmationKey = dec.PreconfirmationKey
+	}
+	if dec.GracefulShutdown != nil {
+		c.GracefulShutdown = *dec.GracefulShutdown
+	}
 	if dec.Ethash != nil {
 		c.Ethash = *dec.Ethash
 	}
@@ -262,6 +380,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.EnablePreimageRecording != nil {
 		c.EnablePreimageRecording = *dec.EnablePreimageRecording
 	}
+	if dec.EnableInstructionFusion != nil {
+		c.EnableInstructionFusion = *dec.EnableInstructionFusion
+	}
 	if dec.DocRoot != nil {
 		c.DocRoot = *dec.DocRoot
 	}
@@ -271,9 +392,30 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.RPCEVMTimeout != nil {
 		c.RPCEVMTimeout = *dec.RPCEVMTimeout
 	}
+	if dec.RPCCallCacheSize != nil {
+		c.RPCCallCacheSize = *dec.RPCCallCacheSize
+	}
+	if dec.RPCCallCacheTTL != nil {
+		c.RPCCallCacheTTL = *dec.RPCCallCacheTTL
+	}
+	if dec.RPCMaxReexec != nil {
+		c.RPCMaxReexec = *dec.RPCMaxReexec
+	}
+	if dec.RPCLogQueryMaxBlocks != nil {
+		c.RPCLogQueryMaxBlocks = *dec.RPCLogQueryMaxBlocks
+	}
+	if dec.RPCLogQueryMaxResults != nil {
+		c.RPCLogQueryMaxResults = *dec.RPCLogQueryMaxResults
+	}
 	if dec.RPCTxFeeCap != nil {
 		c.RPCTxFeeCap = *dec.RPCTxFeeCap
 	}
+	if dec.TxForward != nil {
+		c.TxForward = dec.TxForward
+	}
+	if dec.TxForwardRate != nil {
+		c.TxForwardRate = *dec.TxForwardRate
+	}
 	if dec.Checkpoint != nil {
 		c.Checkpoint = dec.Checkpoint
 	}
@@ -283,5 +425,8 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.OverrideArrowGlacier != nil {
 		c.OverrideArrowGlacier = dec.OverrideArrowGlacier
 	}
+	if dec.OverrideStateScheme != nil {
+		c.OverrideStateScheme = *dec.OverrideStateScheme
+	}
 	return nil
 }
@@ -28,12 +28,27 @@ import (
 	"github.com/scroll-tech/go-ethereum"
 	"github.com/scroll-tech/go-ethereum/common"
 	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/go-ethereum/core"
 	"github.com/scroll-tech/go-ethereum/core/types"
 	"github.com/scroll-tech/go-ethereum/ethdb"
 	"github.com/scroll-tech/go-ethereum/event"
+	"github.com/scroll-tech/go-ethereum/rlp"
 	"github.com/scroll-tech/go-ethereum/rpc"
 )
 
+var (
+	errExceedBlockRange = errors.New("query exceeds max block range")
+	errPagedBlockHash   = errors.New("paged log queries don't support blockHash, use eth_getLogs")
+	errInvalidCursor    = errors.New("cursor outside of the queried block range")
+)
+
+// LogQueryLimits caps the resources a single log query may consume, protecting
+// public RPC endpoints from unbounded queries. Zero values mean unlimited.
+type LogQueryLimits struct {
+	MaxBlockRange uint64 // Maximum number of blocks a query may span
+	MaxResults    int    // Maximum number of logs a query may return
+}
+
 // filter is a helper struct that holds meta information over the filter type
 // and associated subscription in the event system.
 type filter struct {
@@ -42,6 +57,7 @@ type filter struct {
 	hashes   []common.Hash
 	crit     FilterCriteria
 	logs     []*types.Log
+	reorgs   []*Reorg
 	s        *Subscription // associated subscription in event system
 }
 
@@ -56,16 +72,18 @@ type PublicFilterAPI struct {
 	filtersMu sync.Mutex
 	filters   map[rpc.ID]*filter
 	timeout   time.Duration
+	limits    LogQueryLimits
 }
 
 // NewPublicFilterAPI returns a new PublicFilterAPI instance.
-func NewPublicFilterAPI(backend Backend, lightMode bool, timeout time.Duration) *PublicFilterAPI {
+func NewPublicFilterAPI(backend Backend, lightMode bool, timeout time.Duration, limits LogQueryLimits) *PublicFilterAPI {
 	api := &PublicFilterAPI{
 		backend: backend,
 		chainDb: backend.ChainDb(),
 		events:  NewEventSystem(backend, lightMode),
 		filters: make(map[rpc.ID]*filter),
 		timeout: timeout,
+		limits:  limits,
 	}
 	go api.timeoutLoop(timeout)
 
@@ -240,6 +258,118 @@ func (api *PublicFilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, er
 	return rpcSub, nil
 }
 
+const (
+	// headerRangeBatch is the maximum number of headers delivered in a single
+	// newHeadersRange notification.
+	headerRangeBatch = 256
+
+	// headerRangeReorgDepth is the number of delivered headers a newHeadersRange
+	// subscription tracks to detect reorgs.
+	headerRangeReorgDepth = 128
+)
+
+// HeaderRangeOptions are the options of a newHeadersRange subscription.
+type HeaderRangeOptions struct {
+	RLP bool `json:"rlp"` // Deliver the headers RLP encoded instead of as objects
+}
+
+// NewHeadersRange sends the canonical headers starting at the given block number,
+// first backfilling the range up to the current head in batches and then
+// following the chain. On a reorg the headers are resent from the first
+// replaced block on. Each notification is a batch of consecutive headers.
+func (api *PublicFilterAPI) NewHeadersRange(ctx context.Context, from hexutil.Uint64, opts *HeaderRangeOptions) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	if opts == nil {
+		opts = new(HeaderRangeOptions)
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		var (
+			next = uint64(from)
+			sent = make(map[uint64]common.Hash) // Recently delivered headers
+		)
+
+		// send delivers the canonical headers from next up to head, reporting
+		// whether the subscription is still alive.
+		send := func(head uint64) bool {
+			for next <= head {
+				batch := make([]interface{}, 0, headerRangeBatch)
+				for ; next <= head && len(batch) < headerRangeBatch; next++ {
+					header, _ := api.backend.HeaderByNumber(context.Background(), rpc.BlockNumber(next))
+					if header == nil {
+						// Reorged away while backfilling, the new head resends it
+						break
+					}
+					sent[next] = header.Hash()
+					delete(sent, next-headerRangeReorgDepth)
+					if opts.RLP {
+						enc, err := rlp.EncodeToBytes(header)
+						if err != nil {
+							return false
+						}
+						batch = append(batch, hexutil.Bytes(enc))
+					} else {
+						batch = append(batch, header)
+					}
+				}
+				if len(batch) == 0 {
+					return true
+				}
+				notifier.Notify(rpcSub.ID, batch)
+
+				select {
+				case <-rpcSub.Err():
+					return false
+				case <-notifier.Closed():
+					return false
+				default:
+				}
+			}
+			return true
+		}
+		// Backfill before subscribing, so the event system isn't held up. Heads
+		// arriving in between are covered by the range sent on the next one.
+		head, _ := api.backend.HeaderByNumber(context.Background(), rpc.LatestBlockNumber)
+		if head != nil && !send(head.Number.Uint64()) {
+			return
+		}
+		headers := make(chan *types.Header)
+		headersSub := api.events.SubscribeNewHeads(headers)
+		defer headersSub.Unsubscribe()
+
+		for {
+			select {
+			case h := <-headers:
+				// Rewind to the last delivered header still canonical
+				for next > uint64(from) {
+					hash, ok := sent[next-1]
+					if !ok {
+						break
+					}
+					if header, _ := api.backend.HeaderByNumber(context.Background(), rpc.BlockNumber(next-1)); header != nil && header.Hash() == hash {
+						break
+					}
+					delete(sent, next-1)
+					next--
+				}
+				if !send(h.Number.Uint64()) {
+					return
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
 func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
@@ -307,6 +437,104 @@ func (api *PublicFilterAPI) NewBlockResult(ctx context.Context) (*rpc.Subscripti
 	return rpcSub, nil
 }
 
+// ReorgBlock identifies a block of a reorganised chain segment.
+type ReorgBlock struct {
+	Number hexutil.Uint64 `json:"number"`
+	Hash   common.Hash    `json:"hash"`
+}
+
+// Reorg describes a reorganisation of the canonical chain. The chain segments
+// are ordered newest first. Dropped transactions are no longer part of the
+// canonical chain, re-included ones were moved into the new segment.
+type Reorg struct {
+	CommonAncestor ReorgBlock    `json:"commonAncestor"`
+	OldChain       []ReorgBlock  `json:"oldChain"`
+	NewChain       []ReorgBlock  `json:"newChain"`
+	Dropped        []common.Hash `json:"droppedTransactions"`
+	Reincluded     []common.Hash `json:"reincludedTransactions"`
+}
+
+// newReorg converts a reorg event into its RPC representation.
+func newReorg(ev *core.ReorgEvent) *Reorg {
+	segment := func(headers []*types.Header) []ReorgBlock {
+		blocks := make([]ReorgBlock, len(headers))
+		for i, header := range headers {
+			blocks[i] = ReorgBlock{Number: hexutil.Uint64(header.Number.Uint64()), Hash: header.Hash()}
+		}
+		return blocks
+	}
+	return &Reorg{
+		CommonAncestor: ReorgBlock{Number: hexutil.Uint64(ev.CommonAncestor.Number.Uint64()), Hash: ev.CommonAncestor.Hash()},
+		OldChain:       segment(ev.OldChain),
+		NewChain:       segment(ev.NewChain),
+		Dropped:        returnHashes(ev.Dropped),
+		Reincluded:     returnHashes(ev.Reincluded),
+	}
+}
+
+// NewReorgFilter creates a filter that fetches the reorganisations of the
+// canonical chain. Changes are polled with eth_getFilterChanges.
+func (api *PublicFilterAPI) NewReorgFilter() rpc.ID {
+	var (
+		reorgs   = make(chan *core.ReorgEvent)
+		reorgSub = api.events.SubscribeReorgs(reorgs)
+	)
+
+	api.filtersMu.Lock()
+	api.filters[reorgSub.ID] = &filter{typ: ReorgsSubscription, deadline: time.NewTimer(api.timeout), reorgs: make([]*Reorg, 0), s: reorgSub}
+	api.filtersMu.Unlock()
+
+	go func() {
+		for {
+			select {
+			case ev := <-reorgs:
+				api.filtersMu.Lock()
+				if f, found := api.filters[reorgSub.ID]; found {
+					f.reorgs = append(f.reorgs, newReorg(ev))
+				}
+				api.filtersMu.Unlock()
+			case <-reorgSub.Err():
+				api.filtersMu.Lock()
+				delete(api.filters, reorgSub.ID)
+				api.filtersMu.Unlock()
+				return
+			}
+		}
+	}()
+
+	return reorgSub.ID
+}
+
+// Reorgs sends a notification each time the canonical chain is reorganised,
+// listing the affected blocks and transactions.
+func (api *PublicFilterAPI) Reorgs(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		reorgs := make(chan *core.ReorgEvent)
+		reorgSub := api.events.SubscribeReorgs(reorgs)
+
+		for {
+			select {
+			case ev := <-reorgs:
+				notifier.Notify(rpcSub.ID, newReorg(ev))
+			case <-rpcSub.Err():
+				reorgSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				reorgSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
 // FilterCriteria represents a request to create a new filter.
 // Same as ethereum.FilterQuery but with UnmarshalJSON() method.
 type FilterCriteria ethereum.FilterQuery
@@ -360,29 +588,134 @@ func (api *PublicFilterAPI) NewFilter(crit FilterCriteria) (rpc.ID, error) {
 //
 // https://eth.wiki/json-rpc/API#eth_getlogs
 func (api *PublicFilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) ([]*types.Log, error) {
+	filter, err := api.newLogFilter(ctx, crit)
+	if err != nil {
+		return nil, err
+	}
+	// Run the filter and return all the logs
+	logs, err := filter.Logs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := api.checkResults(logs); err != nil {
+		return nil, err
+	}
+	return returnLogs(logs), err
+}
+
+// LogCursor is the position a paged log query continues from.
+type LogCursor struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	LogIndex    hexutil.Uint   `json:"logIndex"`
+}
+
+// LogPage is a single page of a paged log query.
+type LogPage struct {
+	Logs   []*types.Log `json:"logs"`
+	Cursor *LogCursor   `json:"cursor"` // Position of the next page, nil if exhausted
+}
+
+// GetLogsPaged returns the logs matching the given argument a page at a time.
+// Each page spans at most the configured max block range and holds at most the
+// configured max results. If the range isn't exhausted, the returned cursor
+// can be passed back to retrieve the next page.
+func (api *PublicFilterAPI) GetLogsPaged(ctx context.Context, crit FilterCriteria, cursor *LogCursor) (*LogPage, error) {
+	if crit.BlockHash != nil {
+		return nil, errPagedBlockHash
+	}
+	begin, end := crit.blockRange()
+	from, to, err := api.resolveRange(ctx, begin, end)
+	if err != nil {
+		return nil, err
+	}
+	if cursor != nil {
+		if uint64(cursor.BlockNumber) < from || uint64(cursor.BlockNumber) > to {
+			return nil, errInvalidCursor
+		}
+		from = uint64(cursor.BlockNumber)
+	}
+	last := to
+	if max := api.limits.MaxBlockRange; max > 0 && last >= from && last-from+1 > max {
+		last = from + max - 1
+	}
+	filter := NewRangeFilter(api.backend, int64(from), int64(last), crit.Addresses, crit.Topics)
+	filter.SetLimit(api.limits.MaxResults)
+
+	logs, err := filter.Logs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Drop the logs of the cursor block which were returned in the previous page
+	if cursor != nil && cursor.LogIndex > 0 {
+		var skip int
+		for skip < len(logs) && logs[skip].BlockNumber == from && logs[skip].Index < uint(cursor.LogIndex) {
+			skip++
+		}
+		logs = logs[skip:]
+	}
+	page := &LogPage{Logs: returnLogs(logs)}
+	if max := api.limits.MaxResults; max > 0 && len(logs) > max {
+		page.Logs = logs[:max]
+		page.Cursor = &LogCursor{BlockNumber: hexutil.Uint64(logs[max].BlockNumber), LogIndex: hexutil.Uint(logs[max].Index)}
+	} else if next := uint64(filter.begin); next <= to {
+		page.Cursor = &LogCursor{BlockNumber: hexutil.Uint64(next)}
+	}
+	return page, nil
+}
+
+// newLogFilter constructs a one-shot filter for the given criteria, enforcing
+// the log query limits of the API.
+func (api *PublicFilterAPI) newLogFilter(ctx context.Context, crit FilterCriteria) (*Filter, error) {
 	var filter *Filter
 	if crit.BlockHash != nil {
 		// Block filter requested, construct a single-shot filter
 		filter = NewBlockFilter(api.backend, *crit.BlockHash, crit.Addresses, crit.Topics)
 	} else {
-		// Convert the RPC block numbers into internal representations
-		begin := rpc.LatestBlockNumber.Int64()
-		if crit.FromBlock != nil {
-			begin = crit.FromBlock.Int64()
-		}
-		end := rpc.LatestBlockNumber.Int64()
-		if crit.ToBlock != nil {
-			end = crit.ToBlock.Int64()
+		begin, end := crit.blockRange()
+		if max := api.limits.MaxBlockRange; max > 0 {
+			from, to, err := api.resolveRange(ctx, begin, end)
+			if err != nil {
+				return nil, err
+			}
+			if to >= from && to-from+1 > max {
+				return nil, fmt.Errorf("%w: %d > %d", errExceedBlockRange, to-from+1, max)
+			}
 		}
 		// Construct the range filter
 		filter = NewRangeFilter(api.backend, begin, end, crit.Addresses, crit.Topics)
 	}
-	// Run the filter and return all the logs
-	logs, err := filter.Logs(ctx)
+	filter.SetLimit(api.limits.MaxResults)
+	return filter, nil
+}
+
+// checkResults returns an error if the given logs exceed the max results limit.
+func (api *PublicFilterAPI) checkResults(logs []*types.Log) error {
+	if max := api.limits.MaxResults; max > 0 && len(logs) > max {
+		return fmt.Errorf("query returned more than %d results", max)
+	}
+	return nil
+}
+
+// resolveRange converts the given RPC block numbers into absolute ones, treating
+// the special latest and pending markers as the current head.
+func (api *PublicFilterAPI) resolveRange(ctx context.Context, begin, end int64) (uint64, uint64, error) {
+	header, err := api.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
 	if err != nil {
-		return nil, err
+		return 0, 0, err
 	}
-	return returnLogs(logs), err
+	if header == nil {
+		return 0, 0, errors.New("unknown head block")
+	}
+	head := header.Number.Uint64()
+
+	from, to := head, head
+	if begin >= 0 {
+		from = uint64(begin)
+	}
+	if end >= 0 {
+		to = uint64(end)
+	}
+	return from, to, nil
 }
 
 // UninstallFilter removes the filter with the given filter id.
@@ -415,28 +748,18 @@ func (api *PublicFilterAPI) GetFilterLogs(ctx context.Context, id rpc.ID) ([]*ty
 		return nil, fmt.Errorf("filter not found")
 	}
 
-	var filter *Filter
-	if f.crit.BlockHash != nil {
-		// Block filter requested, construct a single-shot filter
-		filter = NewBlockFilter(api.backend, *f.crit.BlockHash, f.crit.Addresses, f.crit.Topics)
-	} else {
-		// Convert the RPC block numbers into internal representations
-		begin := rpc.LatestBlockNumber.Int64()
-		if f.crit.FromBlock != nil {
-			begin = f.crit.FromBlock.Int64()
-		}
-		end := rpc.LatestBlockNumber.Int64()
-		if f.crit.ToBlock != nil {
-			end = f.crit.ToBlock.Int64()
-		}
-		// Construct the range filter
-		filter = NewRangeFilter(api.backend, begin, end, f.crit.Addresses, f.crit.Topics)
+	filter, err := api.newLogFilter(ctx, f.crit)
+	if err != nil {
+		return nil, err
 	}
 	// Run the filter and return all the logs
 	logs, err := filter.Logs(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if err := api.checkResults(logs); err != nil {
+		return nil, err
+	}
 	return returnLogs(logs), nil
 }
 
@@ -444,7 +767,7 @@ func (api *PublicFilterAPI) GetFilterLogs(ctx context.Context, id rpc.ID) ([]*ty
 // last time it was called. This can be used for polling.
 //
 // For pending transaction and block filters the result is []common.Hash.
-// (pending)Log filters return []Log.
+// (pending)Log filters return []Log, reorg filters return []Reorg.
 //
 // https://eth.wiki/json-rpc/API#eth_getfilterchanges
 func (api *PublicFilterAPI) GetFilterChanges(id rpc.ID) (interface{}, error) {
@@ -468,6 +791,10 @@ func (api *PublicFilterAPI) GetFilterChanges(id rpc.ID) (interface{}, error) {
 			logs := f.logs
 			f.logs = nil
 			return returnLogs(logs), nil
+		case ReorgsSubscription:
+			reorgs := f.reorgs
+			f.reorgs = make([]*Reorg, 0)
+			return reorgs, nil
 		}
 	}
 
@@ -492,6 +819,20 @@ func returnLogs(logs []*types.Log) []*types.Log {
 	return logs
 }
 
+// blockRange converts the RPC block numbers of the criteria into internal
+// representations, defaulting to the latest block.
+func (args *FilterCriteria) blockRange() (int64, int64) {
+	begin := rpc.LatestBlockNumber.Int64()
+	if args.FromBlock != nil {
+		begin = args.FromBlock.Int64()
+	}
+	end := rpc.LatestBlockNumber.Int64()
+	if args.ToBlock != nil {
+		end = args.ToBlock.Int64()
+	}
+	return begin, end
+}
+
 // UnmarshalJSON sets *args fields with given data.
 func (args *FilterCriteria) UnmarshalJSON(data []byte) error {
 	type input struct {
@@ -40,6 +40,7 @@ type Backend interface {
 	SubscribeNewTxsEvent(chan<- core.NewTxsEvent) event.Subscription
 	SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription
 	SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription
+	SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription
 	SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription
 	SubscribePendingLogsEvent(ch chan<- []*types.Log) event.Subscription
 
@@ -58,6 +59,9 @@ type Filter struct {
 	block      common.Hash // Block hash if filtering a single block
 	begin, end int64       // Range interval if filtering multiple blocks
 
+	limit int // Number of logs after which a range filter stops (0 = unlimited)
+	found int // Number of logs gathered by the current run
+
 	matcher *bloombits.Matcher
 }
 
@@ -114,9 +118,23 @@ func newFilter(backend Backend, addresses []common.Address, topics [][]common.Ha
 	}
 }
 
+// SetLimit caps the number of logs a range filter gathers. Once more than limit
+// logs were found, the filter finishes the current block and returns, leaving
+// the start of the filter at the first unprocessed block.
+func (f *Filter) SetLimit(limit int) {
+	f.limit = limit
+}
+
+// full reports whether the filter gathered more logs than its limit allows.
+func (f *Filter) full() bool {
+	return f.limit > 0 && f.found > f.limit
+}
+
 // Logs searches the blockchain for matching log entries, returning all from the
 // first block that contains matches, updating the start of the filter accordingly.
 func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
+	f.found = 0
+
 	// If we're doing singleton block filtering, execute and return
 	if f.block != (common.Hash{}) {
 		header, err := f.backend.HeaderByHash(ctx, f.block)
@@ -154,7 +172,7 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 		} else {
 			logs, err = f.indexedLogs(ctx, indexed-1)
 		}
-		if err != nil {
+		if err != nil || f.full() {
 			return logs, err
 		}
 	}
@@ -204,6 +222,10 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, err
 			}
 			logs = append(logs, found...)
 
+			if f.found += len(found); f.full() {
+				return logs, nil
+			}
+
 		case <-ctx.Done():
 			return logs, ctx.Err()
 		}
@@ -225,6 +247,11 @@ func (f *Filter) unindexedLogs(ctx context.Context, end uint64) ([]*types.Log, e
 			return logs, err
 		}
 		logs = append(logs, found...)
+
+		if f.found += len(found); f.full() {
+			f.begin++
+			return logs, nil
+		}
 	}
 	return logs, nil
 }
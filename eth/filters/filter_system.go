@@ -54,6 +54,8 @@ const (
 	BlocksSubscription
 	// BlockResultsSubscription queries for block execution traces
 	BlockResultsSubscription
+	// ReorgsSubscription queries for reorganisations of the canonical chain
+	ReorgsSubscription
 	// LastSubscription keeps track of the last index
 	LastIndexSubscription
 )
@@ -68,6 +70,8 @@ const (
 	logsChanSize = 10
 	// chainEvChanSize is the size of channel listening to ChainEvent.
 	chainEvChanSize = 10
+	// reorgChanSize is the size of channel listening to ReorgEvent.
+	reorgChanSize = 10
 )
 
 type subscription struct {
@@ -79,6 +83,7 @@ type subscription struct {
 	hashes       chan []common.Hash
 	headers      chan *types.Header
 	blockResults chan *types.BlockResult
+	reorgs       chan *core.ReorgEvent
 	installed    chan struct{} // closed when the filter is installed
 	err          chan error    // closed when the filter is uninstalled
 }
@@ -96,6 +101,7 @@ type EventSystem struct {
 	rmLogsSub      event.Subscription // Subscription for removed log event
 	pendingLogsSub event.Subscription // Subscription for pending log event
 	chainSub       event.Subscription // Subscription for new chain event
+	reorgSub       event.Subscription // Subscription for chain reorg event
 
 	// Channels
 	install       chan *subscription         // install filter for event notification
@@ -105,6 +111,7 @@ type EventSystem struct {
 	pendingLogsCh chan []*types.Log          // Channel to receive new log event
 	rmLogsCh      chan core.RemovedLogsEvent // Channel to receive removed log event
 	chainCh       chan core.ChainEvent       // Channel to receive new chain event
+	reorgCh       chan core.ReorgEvent       // Channel to receive chain reorg event
 }
 
 // NewEventSystem creates a new manager that listens for event on the given mux,
@@ -124,6 +131,7 @@ func NewEventSystem(backend Backend, lightMode bool) *EventSystem {
 		rmLogsCh:      make(chan core.RemovedLogsEvent, rmLogsChanSize),
 		pendingLogsCh: make(chan []*types.Log, logsChanSize),
 		chainCh:       make(chan core.ChainEvent, chainEvChanSize),
+		reorgCh:       make(chan core.ReorgEvent, reorgChanSize),
 	}
 
 	// Subscribe events
@@ -132,9 +140,10 @@ func NewEventSystem(backend Backend, lightMode bool) *EventSystem {
 	m.rmLogsSub = m.backend.SubscribeRemovedLogsEvent(m.rmLogsCh)
 	m.chainSub = m.backend.SubscribeChainEvent(m.chainCh)
 	m.pendingLogsSub = m.backend.SubscribePendingLogsEvent(m.pendingLogsCh)
+	m.reorgSub = m.backend.SubscribeReorgEvent(m.reorgCh)
 
 	// Make sure none of the subscriptions are empty
-	if m.txsSub == nil || m.logsSub == nil || m.rmLogsSub == nil || m.chainSub == nil || m.pendingLogsSub == nil {
+	if m.txsSub == nil || m.logsSub == nil || m.rmLogsSub == nil || m.chainSub == nil || m.pendingLogsSub == nil || m.reorgSub == nil {
 		log.Crit("Subscribe for event system failed")
 	}
 
@@ -170,6 +179,7 @@ func (sub *Subscription) Unsubscribe() {
 			case <-sub.f.logs:
 			case <-sub.f.hashes:
 			case <-sub.f.headers:
+			case <-sub.f.reorgs:
 			}
 		}
 
@@ -306,6 +316,20 @@ func (es *EventSystem) SubscribeBlockResult(blockResult chan *types.BlockResult)
 	return es.subscribe(sub)
 }
 
+// SubscribeReorgs creates a subscription that writes the old and new chain
+// segments of every reorganisation of the canonical chain.
+func (es *EventSystem) SubscribeReorgs(reorgs chan *core.ReorgEvent) *Subscription {
+	sub := &subscription{
+		id:        rpc.NewID(),
+		typ:       ReorgsSubscription,
+		created:   time.Now(),
+		reorgs:    reorgs,
+		installed: make(chan struct{}),
+		err:       make(chan error),
+	}
+	return es.subscribe(sub)
+}
+
 // SubscribePendingTxs creates a subscription that writes transaction hashes for
 // transactions that enter the transaction pool.
 func (es *EventSystem) SubscribePendingTxs(hashes chan []common.Hash) *Subscription {
@@ -387,6 +411,12 @@ func (es *EventSystem) handleChainEvent(filters filterIndex, ev core.ChainEvent)
 	}
 }
 
+func (es *EventSystem) handleReorgEvent(filters filterIndex, ev core.ReorgEvent) {
+	for _, f := range filters[ReorgsSubscription] {
+		f.reorgs <- &ev
+	}
+}
+
 func (es *EventSystem) lightFilterNewHead(newHeader *types.Header, callBack func(*types.Header, bool)) {
 	oldh := es.lastHead
 	es.lastHead = newHeader
@@ -469,6 +499,7 @@ func (es *EventSystem) eventLoop() {
 		es.rmLogsSub.Unsubscribe()
 		es.pendingLogsSub.Unsubscribe()
 		es.chainSub.Unsubscribe()
+		es.reorgSub.Unsubscribe()
 	}()
 
 	index := make(filterIndex)
@@ -488,6 +519,8 @@ func (es *EventSystem) eventLoop() {
 			es.handlePendingLogs(index, ev)
 		case ev := <-es.chainCh:
 			es.handleChainEvent(index, ev)
+		case ev := <-es.reorgCh:
+			es.handleReorgEvent(index, ev)
 
 		case f := <-es.install:
 			if f.typ == MinedAndPendingLogsSubscription {
@@ -518,6 +551,8 @@ func (es *EventSystem) eventLoop() {
 			return
 		case <-es.chainSub.Err():
 			return
+		case <-es.reorgSub.Err():
+			return
 		}
 	}
 }
@@ -52,6 +52,7 @@ type testBackend struct {
 	rmLogsFeed      event.Feed
 	pendingLogsFeed event.Feed
 	chainFeed       event.Feed
+	reorgFeed       event.Feed
 }
 
 func (b *testBackend) ChainDb() ethdb.Database {
@@ -126,6 +127,10 @@ func (b *testBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subsc
 	return b.chainFeed.Subscribe(ch)
 }
 
+func (b *testBackend) SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription {
+	return b.reorgFeed.Subscribe(ch)
+}
+
 func (b *testBackend) BloomStatus() (uint64, uint64) {
 	return params.BloomBitsBlocks, b.sections
 }
@@ -168,7 +173,7 @@ func TestBlockSubscription(t *testing.T) {
 	var (
 		db          = rawdb.NewMemoryDatabase()
 		backend     = &testBackend{db: db}
-		api         = NewPublicFilterAPI(backend, false, deadline)
+		api         = NewPublicFilterAPI(backend, false, deadline, LogQueryLimits{})
 		genesis     = (&core.Genesis{BaseFee: big.NewInt(params.InitialBaseFee)}).MustCommit(db)
 		chain, _    = core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 10, func(i int, gen *core.BlockGen) {})
 		chainEvents = []core.ChainEvent{}
@@ -220,7 +225,7 @@ func TestPendingTxFilter(t *testing.T) {
 	var (
 		db      = rawdb.NewMemoryDatabase()
 		backend = &testBackend{db: db}
-		api     = NewPublicFilterAPI(backend, false, deadline)
+		api     = NewPublicFilterAPI(backend, false, deadline, LogQueryLimits{})
 
 		transactions = []*types.Transaction{
 			types.NewTransaction(0, common.HexToAddress("0xb794f5ea0ba39494ce83a213fffba74279579268"), new(big.Int), 0, new(big.Int), nil),
@@ -269,13 +274,62 @@ func TestPendingTxFilter(t *testing.T) {
 	}
 }
 
+// TestReorgFilter tests whether reorg events are delivered through a polling filter.
+func TestReorgFilter(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		backend = &testBackend{db: db}
+		api     = NewPublicFilterAPI(backend, false, deadline, LogQueryLimits{})
+
+		ancestor = &types.Header{Number: big.NewInt(10)}
+		oldHead  = &types.Header{Number: big.NewInt(11), ParentHash: ancestor.Hash(), Extra: []byte("old")}
+		newHead  = &types.Header{Number: big.NewInt(11), ParentHash: ancestor.Hash(), Extra: []byte("new")}
+		event    = core.ReorgEvent{
+			CommonAncestor: ancestor,
+			OldChain:       []*types.Header{oldHead},
+			NewChain:       []*types.Header{newHead},
+			Dropped:        []common.Hash{{0x01}},
+		}
+	)
+
+	fid := api.NewReorgFilter()
+
+	time.Sleep(1 * time.Second)
+	backend.reorgFeed.Send(event)
+
+	var reorgs []*Reorg
+	for timeout := time.Now().Add(1 * time.Second); len(reorgs) == 0 && time.Now().Before(timeout); {
+		results, err := api.GetFilterChanges(fid)
+		if err != nil {
+			t.Fatalf("Unable to retrieve reorgs: %v", err)
+		}
+		reorgs = append(reorgs, results.([]*Reorg)...)
+		time.Sleep(100 * time.Millisecond)
+	}
+	if len(reorgs) != 1 {
+		t.Fatalf("invalid number of reorgs, want 1, got %d", len(reorgs))
+	}
+	want := &Reorg{
+		CommonAncestor: ReorgBlock{Number: 10, Hash: ancestor.Hash()},
+		OldChain:       []ReorgBlock{{Number: 11, Hash: oldHead.Hash()}},
+		NewChain:       []ReorgBlock{{Number: 11, Hash: newHead.Hash()}},
+		Dropped:        []common.Hash{{0x01}},
+		Reincluded:     []common.Hash{},
+	}
+	if !reflect.DeepEqual(reorgs[0], want) {
+		t.Errorf("reorg mismatch: have %+v, want %+v", reorgs[0], want)
+	}
+}
+
 // TestLogFilterCreation test whether a given filter criteria makes sense.
 // If not it must return an error.
 func TestLogFilterCreation(t *testing.T) {
 	var (
 		db      = rawdb.NewMemoryDatabase()
 		backend = &testBackend{db: db}
-		api     = NewPublicFilterAPI(backend, false, deadline)
+		api     = NewPublicFilterAPI(backend, false, deadline, LogQueryLimits{})
 
 		testCases = []struct {
 			crit    FilterCriteria
@@ -319,7 +373,7 @@ func TestInvalidLogFilterCreation(t *testing.T) {
 	var (
 		db      = rawdb.NewMemoryDatabase()
 		backend = &testBackend{db: db}
-		api     = NewPublicFilterAPI(backend, false, deadline)
+		api     = NewPublicFilterAPI(backend, false, deadline, LogQueryLimits{})
 	)
 
 	// different situations where log filter creation should fail.
@@ -341,7 +395,7 @@ func TestInvalidGetLogsRequest(t *testing.T) {
 	var (
 		db        = rawdb.NewMemoryDatabase()
 		backend   = &testBackend{db: db}
-		api       = NewPublicFilterAPI(backend, false, deadline)
+		api       = NewPublicFilterAPI(backend, false, deadline, LogQueryLimits{})
 		blockHash = common.HexToHash("0x1111111111111111111111111111111111111111111111111111111111111111")
 	)
 
@@ -366,7 +420,7 @@ func TestLogFilter(t *testing.T) {
 	var (
 		db      = rawdb.NewMemoryDatabase()
 		backend = &testBackend{db: db}
-		api     = NewPublicFilterAPI(backend, false, deadline)
+		api     = NewPublicFilterAPI(backend, false, deadline, LogQueryLimits{})
 
 		firstAddr      = common.HexToAddress("0x1111111111111111111111111111111111111111")
 		secondAddr     = common.HexToAddress("0x2222222222222222222222222222222222222222")
@@ -480,7 +534,7 @@ func TestPendingLogsSubscription(t *testing.T) {
 	var (
 		db      = rawdb.NewMemoryDatabase()
 		backend = &testBackend{db: db}
-		api     = NewPublicFilterAPI(backend, false, deadline)
+		api     = NewPublicFilterAPI(backend, false, deadline, LogQueryLimits{})
 
 		firstAddr      = common.HexToAddress("0x1111111111111111111111111111111111111111")
 		secondAddr     = common.HexToAddress("0x2222222222222222222222222222222222222222")
@@ -664,7 +718,7 @@ func TestPendingTxFilterDeadlock(t *testing.T) {
 	var (
 		db      = rawdb.NewMemoryDatabase()
 		backend = &testBackend{db: db}
-		api     = NewPublicFilterAPI(backend, false, timeout)
+		api     = NewPublicFilterAPI(backend, false, timeout, LogQueryLimits{})
 		done    = make(chan struct{})
 	)
 
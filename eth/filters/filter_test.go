@@ -18,6 +18,7 @@ package filters
 
 import (
 	"context"
+	"errors"
 	"io/ioutil"
 	"math/big"
 	"os"
@@ -227,3 +228,83 @@ func TestFilters(t *testing.T) {
 		t.Error("expected 0 log, got", len(logs))
 	}
 }
+
+func TestLogQueryLimits(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		backend = &testBackend{db: db}
+		api     = NewPublicFilterAPI(backend, false, deadline, LogQueryLimits{MaxBlockRange: 3, MaxResults: 3})
+		addr    = common.BytesToAddress([]byte("logger"))
+	)
+	defer db.Close()
+
+	genesis := core.GenesisBlockForTesting(db, addr, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 10, func(i int, gen *core.BlockGen) {
+		switch i {
+		case 1, 3, 4:
+			receipt := types.NewReceipt(nil, false, 0)
+			receipt.Logs = []*types.Log{{Address: addr}, {Address: addr}}
+			gen.AddUncheckedReceipt(receipt)
+			gen.AddUncheckedTx(types.NewTransaction(uint64(i), common.HexToAddress("0x1"), big.NewInt(1), 1, gen.BaseFee(), nil))
+		}
+	})
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	crit := func(from, to int64) FilterCriteria {
+		return FilterCriteria{FromBlock: big.NewInt(from), ToBlock: big.NewInt(to), Addresses: []common.Address{addr}}
+	}
+	// Queries spanning too many blocks or returning too many logs are rejected
+	if _, err := api.GetLogs(context.Background(), crit(0, 10)); !errors.Is(err, errExceedBlockRange) {
+		t.Errorf("block range limit not enforced: %v", err)
+	}
+	if _, err := api.GetLogs(context.Background(), crit(3, 5)); err == nil {
+		t.Errorf("result limit not enforced")
+	}
+	if logs, err := api.GetLogs(context.Background(), crit(1, 3)); err != nil || len(logs) != 2 {
+		t.Errorf("in-limit query failed: have %d logs, err %v", len(logs), err)
+	}
+	// Paged queries must return every log exactly once, in order
+	var (
+		logs   []*types.Log
+		cursor *LogCursor
+		pages  int
+	)
+	for {
+		page, err := api.GetLogsPaged(context.Background(), crit(0, 10), cursor)
+		if err != nil {
+			t.Fatalf("page %d: failed to retrieve logs: %v", pages, err)
+		}
+		if len(page.Logs) > 3 {
+			t.Fatalf("page %d: result limit exceeded: %d logs", pages, len(page.Logs))
+		}
+		logs = append(logs, page.Logs...)
+		pages++
+
+		if cursor = page.Cursor; cursor == nil {
+			break
+		}
+	}
+	if pages != 4 {
+		t.Errorf("page count mismatch: have %d, want %d", pages, 4)
+	}
+	want := []struct {
+		number uint64
+		index  uint
+	}{{2, 0}, {2, 1}, {4, 0}, {4, 1}, {5, 0}, {5, 1}}
+	if len(logs) != len(want) {
+		t.Fatalf("log count mismatch: have %d, want %d", len(logs), len(want))
+	}
+	for i, log := range logs {
+		if log.BlockNumber != want[i].number || log.Index != want[i].index {
+			t.Errorf("log %d: position mismatch: have %d/%d, want %d/%d", i, log.BlockNumber, log.Index, want[i].number, want[i].index)
+		}
+	}
+	// Cursors outside of the queried range are rejected
+	if _, err := api.GetLogsPaged(context.Background(), crit(0, 10), &LogCursor{BlockNumber: 11}); err != errInvalidCursor {
+		t.Errorf("invalid cursor accepted: %v", err)
+	}
+}
@@ -194,9 +194,10 @@ func (oracle *Oracle) FeeHistory(ctx context.Context, blocks int, unresolvedLast
 	if blocks < 1 {
 		return common.Big0, nil, nil, nil, nil // returning with no data and no error means there are no retrievable blocks
 	}
-	maxFeeHistory := oracle.maxHeaderHistory
+	settings := oracle.currentSettings()
+	maxFeeHistory := settings.maxHeaderHistory
 	if len(rewardPercentiles) != 0 {
-		maxFeeHistory = oracle.maxBlockHistory
+		maxFeeHistory = settings.maxBlockHistory
 	}
 	if blocks > maxFeeHistory {
 		log.Warn("Sanitizing fee history length", "requested", blocks, "truncated", maxFeeHistory)
@@ -63,22 +63,90 @@ type OracleBackend interface {
 // Oracle recommends gas prices based on the content of recent
 // blocks. Suitable for both light and full clients.
 type Oracle struct {
-	backend     OracleBackend
-	lastHead    common.Hash
-	lastPrice   *big.Int
-	maxPrice    *big.Int
-	ignorePrice *big.Int
-	cacheLock   sync.RWMutex
-	fetchLock   sync.Mutex
+	backend   OracleBackend
+	lastHead  common.Hash
+	lastPrice *big.Int
+	cacheLock sync.RWMutex
+	fetchLock sync.Mutex
 
+	settings     oracleSettings
+	settingsLock sync.RWMutex
+	historyCache *lru.Cache
+}
+
+// oracleSettings are the sanitized tunables of the oracle, which may be
+// replaced at runtime.
+type oracleSettings struct {
+	maxPrice                          *big.Int
+	ignorePrice                       *big.Int
 	checkBlocks, percentile           int
 	maxHeaderHistory, maxBlockHistory int
-	historyCache                      *lru.Cache
 }
 
 // NewOracle returns a new gasprice oracle which can recommend suitable
 // gasprice for newly created transaction.
 func NewOracle(backend OracleBackend, params Config) *Oracle {
+	cache, _ := lru.New(2048)
+	headEvent := make(chan core.ChainHeadEvent, 1)
+	backend.SubscribeChainHeadEvent(headEvent)
+	go func() {
+		var lastHead common.Hash
+		for ev := range headEvent {
+			if ev.Block.ParentHash() != lastHead {
+				cache.Purge()
+			}
+			lastHead = ev.Block.Hash()
+		}
+	}()
+
+	return &Oracle{
+		backend:      backend,
+		lastPrice:    params.Default,
+		settings:     sanitizeSettings(params),
+		historyCache: cache,
+	}
+}
+
+// SetConfig replaces the sampling parameters and price bounds of the oracle. The
+// default price is only used at startup and is ignored. The cached suggestion is
+// discarded so the next request is priced with the new parameters.
+func (oracle *Oracle) SetConfig(params Config) {
+	settings := sanitizeSettings(params)
+
+	oracle.settingsLock.Lock()
+	oracle.settings = settings
+	oracle.settingsLock.Unlock()
+
+	oracle.cacheLock.Lock()
+	oracle.lastHead = common.Hash{}
+	oracle.cacheLock.Unlock()
+}
+
+// Config returns the sampling parameters and price bounds the oracle is
+// currently running with. The default price is left unset.
+func (oracle *Oracle) Config() Config {
+	settings := oracle.currentSettings()
+	return Config{
+		Blocks:           settings.checkBlocks,
+		Percentile:       settings.percentile,
+		MaxHeaderHistory: settings.maxHeaderHistory,
+		MaxBlockHistory:  settings.maxBlockHistory,
+		MaxPrice:         settings.maxPrice,
+		IgnorePrice:      settings.ignorePrice,
+	}
+}
+
+// currentSettings returns the tunables the oracle is currently running with.
+func (oracle *Oracle) currentSettings() oracleSettings {
+	oracle.settingsLock.RLock()
+	defer oracle.settingsLock.RUnlock()
+
+	return oracle.settings
+}
+
+// sanitizeSettings validates the oracle configuration, replacing invalid values
+// with sane defaults.
+func sanitizeSettings(params Config) oracleSettings {
 	blocks := params.Blocks
 	if blocks < 1 {
 		blocks = 1
@@ -114,30 +182,13 @@ func NewOracle(backend OracleBackend, params Config) *Oracle {
 		maxBlockHistory = 1
 		log.Warn("Sanitizing invalid gasprice oracle max block history", "provided", params.MaxBlockHistory, "updated", maxBlockHistory)
 	}
-
-	cache, _ := lru.New(2048)
-	headEvent := make(chan core.ChainHeadEvent, 1)
-	backend.SubscribeChainHeadEvent(headEvent)
-	go func() {
-		var lastHead common.Hash
-		for ev := range headEvent {
-			if ev.Block.ParentHash() != lastHead {
-				cache.Purge()
-			}
-			lastHead = ev.Block.Hash()
-		}
-	}()
-
-	return &Oracle{
-		backend:          backend,
-		lastPrice:        params.Default,
+	return oracleSettings{
 		maxPrice:         maxPrice,
 		ignorePrice:      ignorePrice,
 		checkBlocks:      blocks,
 		percentile:       percent,
 		maxHeaderHistory: maxHeaderHistory,
 		maxBlockHistory:  maxBlockHistory,
-		historyCache:     cache,
 	}
 }
 
@@ -169,14 +220,15 @@ func (oracle *Oracle) SuggestTipCap(ctx context.Context) (*big.Int, error) {
 		return new(big.Int).Set(lastPrice), nil
 	}
 	var (
+		settings  = oracle.currentSettings()
 		sent, exp int
 		number    = head.Number.Uint64()
-		result    = make(chan results, oracle.checkBlocks)
+		result    = make(chan results, settings.checkBlocks)
 		quit      = make(chan struct{})
 		results   []*big.Int
 	)
-	for sent < oracle.checkBlocks && number > 0 {
-		go oracle.getBlockValues(ctx, types.MakeSigner(oracle.backend.ChainConfig(), big.NewInt(int64(number))), number, sampleNumber, oracle.ignorePrice, result, quit)
+	for sent < settings.checkBlocks && number > 0 {
+		go oracle.getBlockValues(ctx, types.MakeSigner(oracle.backend.ChainConfig(), big.NewInt(int64(number))), number, sampleNumber, settings.ignorePrice, result, quit)
 		sent++
 		exp++
 		number--
@@ -198,8 +250,8 @@ func (oracle *Oracle) SuggestTipCap(ctx context.Context) (*big.Int, error) {
 		// Besides, in order to collect enough data for sampling, if nothing
 		// meaningful returned, try to query more blocks. But the maximum
 		// is 2*checkBlocks.
-		if len(res.values) == 1 && len(results)+1+exp < oracle.checkBlocks*2 && number > 0 {
-			go oracle.getBlockValues(ctx, types.MakeSigner(oracle.backend.ChainConfig(), big.NewInt(int64(number))), number, sampleNumber, oracle.ignorePrice, result, quit)
+		if len(res.values) == 1 && len(results)+1+exp < settings.checkBlocks*2 && number > 0 {
+			go oracle.getBlockValues(ctx, types.MakeSigner(oracle.backend.ChainConfig(), big.NewInt(int64(number))), number, sampleNumber, settings.ignorePrice, result, quit)
 			sent++
 			exp++
 			number--
@@ -209,10 +261,10 @@ func (oracle *Oracle) SuggestTipCap(ctx context.Context) (*big.Int, error) {
 	price := lastPrice
 	if len(results) > 0 {
 		sort.Sort(bigIntArray(results))
-		price = results[(len(results)-1)*oracle.percentile/100]
+		price = results[(len(results)-1)*settings.percentile/100]
 	}
-	if price.Cmp(oracle.maxPrice) > 0 {
-		price = new(big.Int).Set(oracle.maxPrice)
+	if price.Cmp(settings.maxPrice) > 0 {
+		price = new(big.Int).Set(settings.maxPrice)
 	}
 	oracle.cacheLock.Lock()
 	oracle.lastHead = headHash
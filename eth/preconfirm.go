@@ -0,0 +1,137 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru"
+	"golang.org/x/time/rate"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/log"
+)
+
+const (
+	// preconfirmationExpiry is the number of blocks after the tentative one a
+	// preconfirmed transaction is promised to be included by.
+	preconfirmationExpiry = 8
+
+	// preconfirmationCache is the number of issued preconfirmations kept, so that
+	// repeated requests get the same promise.
+	preconfirmationCache = 16384
+
+	// preconfirmationRate and preconfirmationBurst limit the rate new
+	// preconfirmations are issued at. Issued ones are served without limit.
+	preconfirmationRate  = 100
+	preconfirmationBurst = 200
+)
+
+var (
+	errPreconfirmationsDisabled = errors.New("preconfirmations are disabled")
+	errPreconfirmationRate      = errors.New("preconfirmation rate limit exceeded")
+)
+
+// preconfirmer issues the signed preconfirmations of the transactions ordered
+// in the pending block. Once issued, the transaction and the ones ordered before
+// it are pinned in the miner, which includes them ahead of any other transaction
+// until the expiry.
+type preconfirmer struct {
+	eth     *Ethereum
+	key     *ecdsa.PrivateKey // Dedicated key signing the preconfirmations
+	limiter *rate.Limiter     // Limiter of the preconfirmations issued
+	issued  *lru.Cache        // Transaction hash -> *types.Preconfirmation
+	lock    sync.Mutex        // Lock serializing the pinning of transactions
+}
+
+func newPreconfirmer(eth *Ethereum, key *ecdsa.PrivateKey) *preconfirmer {
+	issued, _ := lru.New(preconfirmationCache)
+	return &preconfirmer{
+		eth:     eth,
+		key:     key,
+		limiter: rate.NewLimiter(preconfirmationRate, preconfirmationBurst),
+		issued:  issued,
+	}
+}
+
+// preconfirm returns the preconfirmation of a transaction, signing a new one if
+// the transaction is ordered in the pending block. It returns nil if the
+// transaction isn't ordered yet.
+func (p *preconfirmer) preconfirm(hash common.Hash) (*types.Preconfirmation, error) {
+	if issued, ok := p.issued.Get(hash); ok {
+		return issued.(*types.Preconfirmation), nil
+	}
+	preconf, err := p.pin(hash)
+	if preconf == nil || err != nil {
+		return nil, err
+	}
+	// Sign the promise outside the lock, the transaction is pinned already
+	if err := preconf.Sign(p.key); err != nil {
+		return nil, err
+	}
+	p.issued.Add(hash, preconf)
+
+	log.Debug("Preconfirmed transaction", "hash", hash, "number", preconf.BlockNumber, "index", preconf.Index)
+	return preconf, nil
+}
+
+// pin pins a transaction ordered in the pending block in the miner, along with
+// all the transactions ordered before it, returning the unsigned promise.
+func (p *preconfirmer) pin(hash common.Hash) (*types.Preconfirmation, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	block := p.eth.miner.PendingBlock()
+	if block == nil {
+		return nil, nil
+	}
+	// System transactions are recreated for every block, they aren't pinned
+	var (
+		index = -1
+		ahead types.Transactions
+	)
+	for i, tx := range block.Transactions() {
+		if tx.Type() == types.SystemTxType {
+			continue
+		}
+		ahead = append(ahead, tx)
+		if tx.Hash() == hash {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, nil
+	}
+	if !p.limiter.Allow() {
+		return nil, errPreconfirmationRate
+	}
+	expiry := block.NumberU64() + preconfirmationExpiry
+	if err := p.eth.miner.PinTransactions(ahead, expiry); err != nil {
+		return nil, err
+	}
+	return &types.Preconfirmation{
+		ChainID:     p.eth.blockchain.Config().ChainID.Uint64(),
+		TxHash:      hash,
+		BlockNumber: block.NumberU64(),
+		Index:       uint64(index),
+		Expiry:      expiry,
+	}, nil
+}
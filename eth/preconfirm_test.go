@@ -0,0 +1,90 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/scroll-tech/go-ethereum/eth/ethconfig"
+	"github.com/scroll-tech/go-ethereum/node"
+	"github.com/scroll-tech/go-ethereum/params"
+)
+
+func TestPreconfirmation(t *testing.T) {
+	stack, err := node.New(&node.Config{})
+	if err != nil {
+		t.Fatalf("can't create node: %v", err)
+	}
+	defer stack.Close()
+
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	preconfKey, _ := crypto.GenerateKey()
+	sequencer := crypto.PubkeyToAddress(preconfKey.PublicKey)
+
+	config := ethconfig.Defaults
+	config.Genesis = core.DeveloperGenesisBlock(5, 11500000, sender)
+	config.Miner.Etherbase = sender
+	config.PreconfirmationKey = preconfKey
+	backend, err := New(stack, &config)
+	if err != nil {
+		t.Fatalf("can't create ethereum service: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("can't start node: %v", err)
+	}
+	api := NewPublicPreconfirmationAPI(backend)
+
+	signer := types.LatestSigner(backend.blockchain.Config())
+	tx, _ := types.SignTx(types.NewTransaction(0, common.Address{1}, big.NewInt(1), 21000, big.NewInt(params.GWei*10), nil), signer, key)
+	if preconf, err := api.GetPreconfirmation(tx.Hash()); preconf != nil || err != nil {
+		t.Fatalf("unknown transaction preconfirmed: %v %v", preconf, err)
+	}
+	if err := backend.txPool.AddLocal(tx); err != nil {
+		t.Fatalf("can't add transaction: %v", err)
+	}
+	// The pending block is updated in the background
+	var preconf *types.Preconfirmation
+	for deadline := time.Now().Add(5 * time.Second); preconf == nil && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if preconf, err = api.GetPreconfirmation(tx.Hash()); err != nil {
+			t.Fatalf("can't preconfirm: %v", err)
+		}
+	}
+	if preconf == nil {
+		t.Fatal("transaction not preconfirmed")
+	}
+	if preconf.TxHash != tx.Hash() || preconf.BlockNumber != 1 || preconf.Index != 0 || preconf.Expiry != 1+preconfirmationExpiry {
+		t.Fatalf("preconfirmation mismatch: %+v", preconf)
+	}
+	if have, err := preconf.Signer(); err != nil || have != sequencer {
+		t.Fatalf("signer mismatch: have %x (%v), want %x", have, err, sequencer)
+	}
+	// Repeated requests get the same promise
+	if again, _ := api.GetPreconfirmation(tx.Hash()); again != preconf {
+		t.Fatal("preconfirmation not reused")
+	}
+	// Disabled nodes refuse to preconfirm
+	if _, err := NewPublicPreconfirmationAPI(&Ethereum{}).GetPreconfirmation(tx.Hash()); err != errPreconfirmationsDisabled {
+		t.Fatalf("error mismatch: have %v, want %v", err, errPreconfirmationsDisabled)
+	}
+}
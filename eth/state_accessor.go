@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
 	"github.com/scroll-tech/go-ethereum/core"
 	"github.com/scroll-tech/go-ethereum/core/state"
 	"github.com/scroll-tech/go-ethereum/core/types"
@@ -63,7 +64,7 @@ func (eth *Ethereum) stateAtBlock(block *types.Block, reexec uint64, base *state
 		if preferDisk {
 			// Create an ephemeral trie.Database for isolating the live one. Otherwise
 			// the internal junks created by tracing will be persisted into the disk.
-			database = state.NewDatabaseWithConfig(eth.chainDb, &trie.Config{Cache: 16})
+			database = state.NewDatabaseWithCodeCache(eth.chainDb, &trie.Config{Cache: 16}, eth.blockchain.CodeCache())
 			if statedb, err = state.New(block.Root(), database, nil); err == nil {
 				log.Info("Found disk backend for state trie", "root", block.Root(), "number", block.Number())
 				return statedb, nil
@@ -78,7 +79,7 @@ func (eth *Ethereum) stateAtBlock(block *types.Block, reexec uint64, base *state
 
 		// Create an ephemeral trie.Database for isolating the live one. Otherwise
 		// the internal junks created by tracing will be persisted into the disk.
-		database = state.NewDatabaseWithConfig(eth.chainDb, &trie.Config{Cache: 16})
+		database = state.NewDatabaseWithCodeCache(eth.chainDb, &trie.Config{Cache: 16}, eth.blockchain.CodeCache())
 
 		// If we didn't check the dirty database, do check the clean one, otherwise
 		// we would rewind past a persisted block (specific corner case is chain
@@ -108,7 +109,7 @@ func (eth *Ethereum) stateAtBlock(block *types.Block, reexec uint64, base *state
 		if err != nil {
 			switch err.(type) {
 			case *trie.MissingNodeError:
-				return nil, fmt.Errorf("required historical state unavailable (reexec=%d)", reexec)
+				return nil, eth.stateUnavailable(block, database, reexec)
 			default:
 				return nil, err
 			}
@@ -158,6 +159,74 @@ func (eth *Ethereum) stateAtBlock(block *types.Block, reexec uint64, base *state
 	return statedb, nil
 }
 
+// stateHintProbes is the number of older ancestors probed for available state
+// when the state of a block can't be regenerated, to hint the caller at the
+// re-execution depth required. The probes are spaced at doubling distances, so
+// the hint is cheap to compute but not necessarily the nearest state.
+const stateHintProbes = 8
+
+// StateUnavailableError is returned when the state of a historical block is not
+// retained and can't be regenerated within the re-execution limit.
+type StateUnavailableError struct {
+	Number    uint64 // Block whose state was requested
+	Available uint64 // Nearest ancestor with available state, if found
+	Reexec    uint64 // Blocks to re-execute from the available state, zero if none was found
+	Limit     uint64 // Re-execution limit the state couldn't be regenerated within
+}
+
+func (e *StateUnavailableError) Error() string {
+	if e.Reexec == 0 {
+		return fmt.Sprintf("required historical state unavailable (block %d, reexec=%d, no older state found)", e.Number, e.Limit)
+	}
+	return fmt.Sprintf("required historical state unavailable (block %d, reexec=%d, nearest state at block %d needs reexec=%d)", e.Number, e.Limit, e.Available, e.Reexec)
+}
+
+// ErrorData implements rpc.DataError, exposing the hints in the RPC response.
+func (e *StateUnavailableError) ErrorData() interface{} {
+	data := map[string]interface{}{
+		"block": hexutil.Uint64(e.Number),
+		"limit": hexutil.Uint64(e.Limit),
+	}
+	if e.Reexec != 0 {
+		data["availableBlock"] = hexutil.Uint64(e.Available)
+		data["reexec"] = hexutil.Uint64(e.Reexec)
+	}
+	return data
+}
+
+// stateUnavailable creates the error of a block whose state couldn't be found
+// within the given re-execution limit, probing a few older ancestors for an
+// available state.
+func (eth *Ethereum) stateUnavailable(block *types.Block, database state.Database, limit uint64) error {
+	var (
+		err      = &StateUnavailableError{Number: block.NumberU64(), Limit: limit}
+		distance = limit
+	)
+	if distance == 0 {
+		distance = 1
+	}
+	for i := 0; i < stateHintProbes; i++ {
+		if distance *= 2; distance > block.NumberU64() {
+			distance = block.NumberU64()
+		}
+		maxNonCanonical := uint64(100)
+		hash, number := eth.blockchain.GetAncestor(block.Hash(), block.NumberU64(), distance, &maxNonCanonical)
+		if hash == (common.Hash{}) {
+			break
+		}
+		if header := eth.blockchain.GetHeader(hash, number); header != nil {
+			if _, serr := state.New(header.Root, database, nil); serr == nil {
+				err.Available, err.Reexec = number, distance
+				break
+			}
+		}
+		if number == 0 {
+			break
+		}
+	}
+	return err
+}
+
 // stateAtTransaction returns the execution environment of a certain transaction.
 func (eth *Ethereum) stateAtTransaction(block *types.Block, txIndex int, reexec uint64) (core.Message, vm.BlockContext, *state.StateDB, error) {
 	// Short circuit if it's genesis block.
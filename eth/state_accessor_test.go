@@ -0,0 +1,79 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/consensus/ethash"
+	"github.com/scroll-tech/go-ethereum/core"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/core/vm"
+	"github.com/scroll-tech/go-ethereum/eth/ethconfig"
+	"github.com/scroll-tech/go-ethereum/params"
+	"github.com/scroll-tech/go-ethereum/rpc"
+)
+
+// Tests that a historical state beyond the re-execution limit is reported with
+// the nearest available state, and regenerated when within the limit.
+func TestStateAtBlockUnavailable(t *testing.T) {
+	// Generate the blocks on a separate database, so only the genesis and the
+	// states of the recent blocks are retained by the imported chain
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		gendb   = rawdb.NewMemoryDatabase()
+		genesis = &core.Genesis{Config: params.TestChainConfig}
+	)
+	genesis.MustCommit(db)
+	blocks, _ := core.GenerateChain(params.TestChainConfig, genesis.MustCommit(gendb), ethash.NewFaker(), gendb, 300, nil)
+
+	chain, _ := core.NewBlockChain(db, nil, params.TestChainConfig, ethash.NewFaker(), vm.Config{}, nil, nil)
+	defer chain.Stop()
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	eth := &Ethereum{blockchain: chain, chainDb: db, config: &ethconfig.Config{RPCMaxReexec: 10}}
+	block := chain.GetBlockByNumber(100)
+
+	_, err := eth.stateAtBlock(block, 10, nil, true, false)
+	serr, ok := err.(*StateUnavailableError)
+	if !ok {
+		t.Fatalf("error mismatch: have %v, want state unavailable", err)
+	}
+	if serr.Number != 100 || serr.Available != 0 || serr.Reexec != 100 || serr.Limit != 10 {
+		t.Errorf("error hints mismatch: have %+v", serr)
+	}
+	// Requests over RPC are bounded by the configured limit
+	backend := &EthAPIBackend{eth: eth}
+	if _, err := backend.StateAtBlock(context.Background(), block, 1000, nil, true, false); err == nil {
+		t.Fatalf("state regenerated beyond the configured limit")
+	}
+	eth.config.RPCMaxReexec = 100
+
+	// Plain state requests never regenerate
+	if _, _, err := backend.StateAndHeaderByNumber(context.Background(), rpc.BlockNumber(100)); err == nil {
+		t.Fatalf("state regenerated for a plain state request")
+	}
+	statedb, err := backend.StateAtBlock(context.Background(), block, 100, nil, true, false)
+	if err != nil {
+		t.Fatalf("failed to regenerate state: %v", err)
+	}
+	if root := statedb.IntermediateRoot(true); root != block.Root() {
+		t.Errorf("regenerated state root mismatch: have %x, want %x", root, block.Root())
+	}
+}
@@ -0,0 +1,449 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	zkt "github.com/scroll-tech/go-ethereum/core/types/zktrie"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/scroll-tech/go-ethereum/ethdb"
+	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/rlp"
+	"github.com/scroll-tech/go-ethereum/rpc"
+	"github.com/scroll-tech/go-ethereum/trie"
+)
+
+const (
+	// maxStateLeaves is the maximum number of trie leaves served at once.
+	maxStateLeaves = 4096
+
+	// maxStateCodes is the maximum number of contract codes served at once.
+	maxStateCodes = 256
+
+	// stateCopyLeaves is the number of trie leaves requested at once when
+	// copying the state from another node.
+	stateCopyLeaves = 1024
+
+	// stateCopyTimeout is the maximum time of a single state copy request.
+	stateCopyTimeout = time.Minute
+)
+
+var (
+	// emptyCodeHash is the code hash of the accounts without code.
+	emptyCodeHash = crypto.Keccak256Hash(nil)
+
+	errStateCopyRunning     = errors.New("state copy already running")
+	errStateCopyUnsupported = errors.New("state copy requires the zktrie state")
+	errStatePageFull        = errors.New("state page full")
+)
+
+// StateLeaves is a page of the leaves of a zk trie, in path order.
+type StateLeaves struct {
+	Leaves    []hexutil.Bytes `json:"leaves"`    // Encoded leaf nodes
+	Preimages []hexutil.Bytes `json:"preimages"` // Preimages of the leaf keys, empty if unknown
+	Next      hexutil.Bytes   `json:"next"`      // Hashed key to resume after, empty once the trie is exhausted
+}
+
+// StateCopyStatus is the progress of the last state copy started on the node.
+type StateCopyStatus struct {
+	Source   string         `json:"source"`
+	Root     common.Hash    `json:"root"`
+	Running  bool           `json:"running"`
+	Accounts hexutil.Uint64 `json:"accounts"` // Accounts copied by the current run
+	Slots    hexutil.Uint64 `json:"slots"`    // Storage slots copied by the current run
+	Codes    hexutil.Uint64 `json:"codes"`    // Contract codes copied by the current run
+	Next     hexutil.Bytes  `json:"next"`     // Hashed key of the last account copied
+	Error    string         `json:"error,omitempty"`
+}
+
+// stateServer serves the zk state of the local database to the nodes copying it.
+type stateServer struct {
+	db     ethdb.Database
+	triedb *trie.Database
+}
+
+// StateLeaves returns up to limit leaves of the zk trie with the given root, in
+// path order, starting after the given hashed key. The root may be the one of
+// an account trie or of a storage trie.
+func (s *stateServer) StateLeaves(root common.Hash, after *hexutil.Bytes, limit int) (*StateLeaves, error) {
+	tr, err := trie.NewZkTrie(root, trie.NewZktrieDatabaseFromTriedb(s.triedb))
+	if err != nil {
+		return nil, fmt.Errorf("state %x not available: %v", root, err)
+	}
+	if limit <= 0 || limit > maxStateLeaves {
+		limit = maxStateLeaves
+	}
+	var start []byte
+	if after != nil {
+		start = *after
+	}
+	var (
+		page = &StateLeaves{Leaves: []hexutil.Bytes{}, Preimages: []hexutil.Bytes{}}
+		last []byte
+	)
+	err = tr.WalkLeafNodesAfter(start, func(n *trie.Node) error {
+		if len(page.Leaves) == limit {
+			page.Next = last
+			return errStatePageFull
+		}
+		enc, err := n.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		last = n.NodeKey.Bytes()
+		page.Leaves = append(page.Leaves, enc)
+		page.Preimages = append(page.Preimages, tr.GetKey(last))
+		return nil
+	})
+	if err != nil && err != errStatePageFull {
+		return nil, err
+	}
+	return page, nil
+}
+
+// StateCode returns the contract codes with the given hashes.
+func (s *stateServer) StateCode(hashes []common.Hash) ([]hexutil.Bytes, error) {
+	if len(hashes) > maxStateCodes {
+		return nil, fmt.Errorf("too many codes requested: %d > %d", len(hashes), maxStateCodes)
+	}
+	codes := make([]hexutil.Bytes, len(hashes))
+	for i, hash := range hashes {
+		if codes[i] = rawdb.ReadCode(s.db, hash); len(codes[i]) == 0 {
+			return nil, fmt.Errorf("code %x not available", hash)
+		}
+	}
+	return codes, nil
+}
+
+// stateCopyProgress is the marker stored to resume an interrupted state copy.
+type stateCopyProgress struct {
+	Root    common.Hash // State root being copied
+	Partial common.Hash // Root of the account trie rebuilt so far
+	Next    []byte      // Hashed key of the last account copied
+}
+
+// stateCopier copies the zk state at a given root from another node over its
+// admin RPC endpoint, as an alternative to syncing it over p2p. The account and
+// storage tries are rebuilt from their leaves and verified against the roots
+// they are expected to have. The progress is stored after every page of
+// accounts, so that an interrupted copy of the same root resumes where it
+// stopped.
+type stateCopier struct {
+	db ethdb.Database
+
+	status StateCopyStatus
+	lock   sync.Mutex
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newStateCopier creates a state copier writing into the given database.
+func newStateCopier(db ethdb.Database) *stateCopier {
+	return &stateCopier{
+		db:   db,
+		quit: make(chan struct{}),
+	}
+}
+
+// start copies the state with the given root from the node reached with the
+// given client in the background. The client is closed once the copy ends.
+func (c *stateCopier) start(client *rpc.Client, source string, root common.Hash) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.status.Running {
+		return errStateCopyRunning
+	}
+	c.status = StateCopyStatus{Source: source, Root: root, Running: true}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer client.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			select {
+			case <-c.quit:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		start := time.Now()
+		err := c.run(ctx, client, root)
+
+		c.lock.Lock()
+		c.status.Running = false
+		if err != nil {
+			c.status.Error = err.Error()
+		}
+		c.lock.Unlock()
+
+		if err != nil {
+			log.Error("State copy failed", "source", source, "root", root, "err", err)
+		} else {
+			log.Info("State copy finished", "source", source, "root", root, "elapsed", common.PrettyDuration(time.Since(start)))
+		}
+	}()
+	return nil
+}
+
+// Status returns the progress of the last state copy.
+func (c *stateCopier) Status() StateCopyStatus {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.status
+}
+
+// close interrupts the running state copy, if any, keeping its progress.
+func (c *stateCopier) close() {
+	close(c.quit)
+	c.wg.Wait()
+}
+
+// run copies the state with the given root, resuming the stored progress if it
+// is the one of the same root.
+func (c *stateCopier) run(ctx context.Context, client *rpc.Client, root common.Hash) error {
+	var (
+		triedb   = trie.NewDatabase(c.db)
+		zktriedb = trie.NewZktrieDatabaseFromTriedb(triedb)
+	)
+	if _, err := trie.NewZkTrie(root, zktriedb); err == nil {
+		log.Info("State already present, nothing to copy", "root", root)
+		return nil
+	}
+	progress := stateCopyProgress{Root: root}
+	if blob := rawdb.ReadStateCopyProgress(c.db); len(blob) > 0 {
+		var stored stateCopyProgress
+		if err := rlp.DecodeBytes(blob, &stored); err != nil {
+			log.Warn("Failed to decode state copy progress", "err", err)
+		} else if stored.Root == root {
+			progress = stored
+			log.Info("Resuming state copy", "root", root, "next", hexutil.Bytes(progress.Next))
+		}
+	}
+	accounts, err := trie.NewZkTrie(progress.Partial, zktriedb)
+	if err != nil {
+		return err
+	}
+	for {
+		page, err := c.fetchLeaves(ctx, client, root, progress.Next)
+		if err != nil {
+			return err
+		}
+		var (
+			batch = c.db.NewBatch()
+			codes []common.Hash
+			known = make(map[common.Hash]bool)
+			slots int
+			last  []byte
+		)
+		for i, enc := range page.Leaves {
+			n, err := decodeStateLeaf(enc, page.Preimages[i], batch)
+			if err != nil {
+				return err
+			}
+			acc, err := types.UnmarshalStateAccount(n.Data())
+			if err != nil {
+				return err
+			}
+			count, err := c.copyStorage(ctx, client, zktriedb, batch, acc.Root)
+			if err != nil {
+				return fmt.Errorf("account %x: %v", n.NodeKey.Bytes(), err)
+			}
+			slots += count
+
+			hash := common.BytesToHash(acc.CodeHash)
+			if hash != emptyCodeHash && !known[hash] && len(rawdb.ReadCode(c.db, hash)) == 0 {
+				codes = append(codes, hash)
+				known[hash] = true
+			}
+			if err := accounts.TryUpdateLeaf(n); err != nil {
+				return err
+			}
+			last = n.NodeKey.Bytes()
+		}
+		if err := c.copyCodes(ctx, client, batch, codes); err != nil {
+			return err
+		}
+		// Flush the rebuilt nodes before recording the progress, so that it never
+		// points to a partial trie missing from the database
+		if err := triedb.Commit(common.Hash{}, false, nil); err != nil {
+			return err
+		}
+		if last != nil {
+			progress.Partial, progress.Next = accounts.Hash(), last
+		}
+		blob, err := rlp.EncodeToBytes(&progress)
+		if err != nil {
+			return err
+		}
+		rawdb.WriteStateCopyProgress(batch, blob)
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		c.lock.Lock()
+		c.status.Accounts += hexutil.Uint64(len(page.Leaves))
+		c.status.Slots += hexutil.Uint64(slots)
+		c.status.Codes += hexutil.Uint64(len(codes))
+		c.status.Next = common.CopyBytes(progress.Next)
+		c.lock.Unlock()
+
+		log.Info("Copied state accounts", "root", root, "accounts", len(page.Leaves), "slots", slots, "codes", len(codes), "next", hexutil.Bytes(progress.Next))
+		if len(page.Next) == 0 {
+			break
+		}
+	}
+	// All the accounts were copied, the rebuilt trie must match the requested root
+	rawdb.DeleteStateCopyProgress(c.db)
+	if have := accounts.Hash(); have != root {
+		return fmt.Errorf("state root mismatch: have %x, want %x", have, root)
+	}
+	return nil
+}
+
+// copyStorage copies the storage trie with the given root, unless it is empty or
+// already present, and returns the number of slots copied.
+func (c *stateCopier) copyStorage(ctx context.Context, client *rpc.Client, zktriedb *trie.ZktrieDatabase, batch ethdb.KeyValueWriter, root common.Hash) (int, error) {
+	if root == (common.Hash{}) {
+		return 0, nil
+	}
+	if _, err := trie.NewZkTrie(root, zktriedb); err == nil {
+		return 0, nil
+	}
+	storage, err := trie.NewZkTrie(common.Hash{}, zktriedb)
+	if err != nil {
+		return 0, err
+	}
+	var (
+		after []byte
+		slots int
+	)
+	for {
+		page, err := c.fetchLeaves(ctx, client, root, after)
+		if err != nil {
+			return 0, err
+		}
+		for i, enc := range page.Leaves {
+			n, err := decodeStateLeaf(enc, page.Preimages[i], batch)
+			if err != nil {
+				return 0, err
+			}
+			if err := storage.TryUpdateLeaf(n); err != nil {
+				return 0, err
+			}
+		}
+		slots += len(page.Leaves)
+		if len(page.Next) == 0 {
+			break
+		}
+		after = page.Next
+	}
+	if have := storage.Hash(); have != root {
+		return 0, fmt.Errorf("storage root mismatch: have %x, want %x", have, root)
+	}
+	return slots, nil
+}
+
+// copyCodes copies the contract codes with the given hashes.
+func (c *stateCopier) copyCodes(ctx context.Context, client *rpc.Client, batch ethdb.KeyValueWriter, hashes []common.Hash) error {
+	for len(hashes) > 0 {
+		request := hashes
+		if len(request) > maxStateCodes {
+			request = request[:maxStateCodes]
+		}
+		hashes = hashes[len(request):]
+
+		var codes []hexutil.Bytes
+		if err := c.call(ctx, client, &codes, "admin_stateCode", request); err != nil {
+			return err
+		}
+		if len(codes) != len(request) {
+			return fmt.Errorf("code count mismatch: have %d, want %d", len(codes), len(request))
+		}
+		for i, code := range codes {
+			if hash := crypto.Keccak256Hash(code); hash != request[i] {
+				return fmt.Errorf("code hash mismatch: have %x, want %x", hash, request[i])
+			}
+			rawdb.WriteCode(batch, request[i], code)
+		}
+	}
+	return nil
+}
+
+// fetchLeaves requests a page of the leaves of the trie with the given root,
+// starting after the given hashed key.
+func (c *stateCopier) fetchLeaves(ctx context.Context, client *rpc.Client, root common.Hash, after []byte) (*StateLeaves, error) {
+	var start *hexutil.Bytes
+	if after != nil {
+		start = (*hexutil.Bytes)(&after)
+	}
+	page := new(StateLeaves)
+	if err := c.call(ctx, client, page, "admin_stateLeaves", root, start, stateCopyLeaves); err != nil {
+		return nil, err
+	}
+	if len(page.Preimages) != len(page.Leaves) {
+		return nil, fmt.Errorf("preimage count mismatch: have %d, want %d", len(page.Preimages), len(page.Leaves))
+	}
+	return page, nil
+}
+
+// call invokes a method of the source node, bounding its duration.
+func (c *stateCopier) call(ctx context.Context, client *rpc.Client, result interface{}, method string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, stateCopyTimeout)
+	defer cancel()
+
+	return client.CallContext(ctx, result, method, args...)
+}
+
+// decodeStateLeaf decodes a leaf node served by the source node and stores the
+// preimage of its key, if known. The leaf contents are only checked when the
+// rebuilt trie is compared to its expected root, but a preimage is verified
+// right away as it isn't part of the trie.
+func decodeStateLeaf(enc, preimage []byte, batch ethdb.KeyValueWriter) (*trie.Node, error) {
+	n := new(trie.Node)
+	if err := n.UnmarshalBinary(enc); err != nil {
+		return nil, err
+	}
+	if n.Type != trie.NodeTypeLeaf {
+		return nil, fmt.Errorf("unexpected trie node type %d", n.Type)
+	}
+	if len(preimage) > 0 {
+		hash, err := zkt.NewByte32FromBytesPaddingZero(preimage).Hash()
+		if err != nil {
+			return nil, err
+		}
+		key := common.BytesToHash(n.NodeKey.Bytes())
+		if common.BigToHash(hash) != key {
+			return nil, fmt.Errorf("key preimage mismatch for %x", key)
+		}
+		rawdb.WritePreimages(batch, map[common.Hash][]byte{key: preimage})
+	}
+	return n, nil
+}
@@ -0,0 +1,161 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/core/state"
+	zkt "github.com/scroll-tech/go-ethereum/core/types/zktrie"
+	"github.com/scroll-tech/go-ethereum/ethdb"
+	"github.com/scroll-tech/go-ethereum/rpc"
+	"github.com/scroll-tech/go-ethereum/trie"
+)
+
+// flakyStateServer serves the state like stateServer, but fails to serve the
+// account trie past its first page.
+type flakyStateServer struct {
+	*stateServer
+	root common.Hash
+}
+
+func (s *flakyStateServer) StateLeaves(root common.Hash, after *hexutil.Bytes, limit int) (*StateLeaves, error) {
+	if root == s.root && after != nil {
+		return nil, errors.New("connection reset")
+	}
+	return s.stateServer.StateLeaves(root, after, limit)
+}
+
+// newTestStateCopySource creates a zk state with more accounts than fit in a
+// page, some of them contracts with storage.
+func newTestStateCopySource(t *testing.T) (ethdb.Database, *trie.Database, common.Hash) {
+	db := rawdb.NewMemoryDatabase()
+	sdb := state.NewDatabaseWithConfig(db, &trie.Config{Zktrie: true, Preimages: true})
+	statedb, _ := state.New(common.Hash{}, sdb, nil)
+	for i := 0; i < stateCopyLeaves+100; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i + 1)))
+		statedb.SetBalance(addr, big.NewInt(int64(i+1)))
+		statedb.SetNonce(addr, uint64(i))
+		if i%100 == 0 {
+			statedb.SetCode(addr, []byte{0x60, byte(i / 100), 0x00})
+			for j := 0; j <= i/100; j++ {
+				statedb.SetState(addr, common.BigToHash(big.NewInt(int64(j))), common.BigToHash(big.NewInt(int64(i+j+1))))
+			}
+		}
+	}
+	root, err := statedb.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := sdb.TrieDB().Commit(root, false, nil); err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	return db, sdb.TrieDB(), root
+}
+
+// Tests that the state is copied from another node, and that an interrupted copy
+// resumes where it stopped.
+func TestStateCopy(t *testing.T) {
+	source, triedb, root := newTestStateCopySource(t)
+	server := &stateServer{db: source, triedb: triedb}
+
+	flaky := rpc.NewServer()
+	defer flaky.Stop()
+	if err := flaky.RegisterName("admin", &flakyStateServer{stateServer: server, root: root}); err != nil {
+		t.Fatal(err)
+	}
+	healthy := rpc.NewServer()
+	defer healthy.Stop()
+	if err := healthy.RegisterName("admin", server); err != nil {
+		t.Fatal(err)
+	}
+	db := rawdb.NewMemoryDatabase()
+	copier := newStateCopier(db)
+	defer copier.close()
+
+	// Copy the first page of accounts only
+	if err := copier.start(rpc.DialInProc(flaky), "flaky", root); err != nil {
+		t.Fatalf("failed to start copy: %v", err)
+	}
+	copier.wg.Wait()
+	status := copier.Status()
+	if status.Running || status.Error == "" {
+		t.Fatalf("interrupted copy status mismatch: %+v", status)
+	}
+	if status.Accounts != stateCopyLeaves {
+		t.Fatalf("copied account count mismatch: have %d, want %d", status.Accounts, stateCopyLeaves)
+	}
+	if len(rawdb.ReadStateCopyProgress(db)) == 0 {
+		t.Fatal("state copy progress not stored")
+	}
+	// Resume the copy from a healthy source
+	if err := copier.start(rpc.DialInProc(healthy), "healthy", root); err != nil {
+		t.Fatalf("failed to start copy: %v", err)
+	}
+	copier.wg.Wait()
+	if status = copier.Status(); status.Error != "" {
+		t.Fatalf("failed to copy state: %v", status.Error)
+	}
+	if status.Accounts != 100 {
+		t.Fatalf("resumed account count mismatch: have %d, want %d", status.Accounts, 100)
+	}
+	if len(rawdb.ReadStateCopyProgress(db)) != 0 {
+		t.Fatal("state copy progress not deleted")
+	}
+	// Check the copied state against the original one
+	want, _ := state.New(root, state.NewDatabaseWithConfig(source, &trie.Config{Zktrie: true}), nil)
+	have, err := state.New(root, state.NewDatabaseWithConfig(db, &trie.Config{Zktrie: true}), nil)
+	if err != nil {
+		t.Fatalf("copied state not available: %v", err)
+	}
+	for i := 0; i < stateCopyLeaves+100; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i + 1)))
+		if have.GetBalance(addr).Cmp(want.GetBalance(addr)) != 0 || have.GetNonce(addr) != want.GetNonce(addr) {
+			t.Fatalf("account %d mismatch", i)
+		}
+		if string(have.GetCode(addr)) != string(want.GetCode(addr)) {
+			t.Fatalf("account %d code mismatch", i)
+		}
+		for j := 0; j <= i/100; j++ {
+			slot := common.BigToHash(big.NewInt(int64(j)))
+			if have.GetState(addr, slot) != want.GetState(addr, slot) {
+				t.Fatalf("account %d slot %d mismatch", i, j)
+			}
+		}
+	}
+	// The key preimages are copied along
+	addr := common.BigToAddress(big.NewInt(1))
+	hash, _ := zkt.NewByte32FromBytesPaddingZero(addr.Bytes()).Hash()
+	if preimage := rawdb.ReadPreimage(db, common.BigToHash(hash)); !bytes.Equal(preimage, addr.Bytes()) {
+		t.Fatalf("account preimage mismatch: have %x, want %x", preimage, addr)
+	}
+
+	// Copying a present state does nothing
+	if err := copier.start(rpc.DialInProc(healthy), "healthy", root); err != nil {
+		t.Fatalf("failed to start copy: %v", err)
+	}
+	copier.wg.Wait()
+	if status = copier.Status(); status.Error != "" || status.Accounts != 0 {
+		t.Fatalf("present state copy status mismatch: %+v", status)
+	}
+}
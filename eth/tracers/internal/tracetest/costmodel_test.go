@@ -0,0 +1,146 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracetest
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/core/vm"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/scroll-tech/go-ethereum/eth/tracers"
+	"github.com/scroll-tech/go-ethereum/params"
+	"github.com/scroll-tech/go-ethereum/tests"
+)
+
+// TestCostModelTracer tests that the costModel tracer reports the opcode counts
+// and memory and stack high-water marks of every call frame separately.
+func TestCostModelTracer(t *testing.T) {
+	var (
+		to     = common.HexToAddress("0x00000000000000000000000000000000deadbeef")
+		callee = common.HexToAddress("0x00000000000000000000000000000000cafebabe")
+	)
+	privkey, err := crypto.HexToECDSA("0000000000000000deadbeef00000000000000000000000000000000deadbeef")
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	signer := types.NewEIP155Signer(big.NewInt(1))
+	tx, err := types.SignNewTx(privkey, signer, &types.LegacyTx{
+		GasPrice: big.NewInt(0),
+		Gas:      100000,
+		To:       &to,
+	})
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	origin, _ := signer.Sender(tx)
+	txContext := vm.TxContext{
+		Origin:   origin,
+		GasPrice: big.NewInt(1),
+	}
+	context := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		Coinbase:    common.Address{},
+		BlockNumber: new(big.Int).SetUint64(8000000),
+		Time:        new(big.Int).SetUint64(5),
+		Difficulty:  big.NewInt(0x30000),
+		GasLimit:    uint64(6000000),
+	}
+	// call(gas, callee, 0, 0, 0, 0, 0)
+	code := []byte{
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		byte(vm.PUSH20),
+	}
+	code = append(code, callee.Bytes()...)
+	code = append(code, byte(vm.GAS), byte(vm.CALL), byte(vm.POP), byte(vm.STOP))
+
+	var alloc = core.GenesisAlloc{
+		to: core.GenesisAccount{
+			Nonce: 1,
+			Code:  code,
+		},
+		callee: core.GenesisAccount{
+			Nonce: 1,
+			Code:  []byte{byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0x0, byte(vm.MSTORE), byte(vm.STOP)}, // mstore(0, 32)
+		},
+		origin: core.GenesisAccount{
+			Nonce:   0,
+			Balance: big.NewInt(500000000000000),
+		},
+	}
+	_, statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), alloc, false)
+
+	tracer, err := tracers.New("costModel", nil)
+	if err != nil {
+		t.Fatalf("failed to create cost model tracer: %v", err)
+	}
+	evm := vm.NewEVM(context, txContext, statedb, params.MainnetChainConfig, vm.Config{Debug: true, Tracer: tracer})
+	msg, err := tx.AsMessage(signer, nil)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
+	if _, err = st.TransitionDb(); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	type frame struct {
+		Type      string            `json:"type"`
+		Address   common.Address    `json:"address"`
+		Depth     int               `json:"depth"`
+		Steps     uint64            `json:"steps"`
+		Opcodes   map[string]uint64 `json:"opcodes"`
+		MaxMemory uint64            `json:"maxMemory"`
+		MaxStack  int               `json:"maxStack"`
+		Calls     []*frame          `json:"calls"`
+	}
+	var root frame
+	if err := json.Unmarshal(res, &root); err != nil {
+		t.Fatalf("failed to unmarshal trace result: %v", err)
+	}
+	if root.Type != "CALL" || root.Address != to || root.Depth != 1 {
+		t.Errorf("root frame mismatch: have %s %x at depth %d", root.Type, root.Address, root.Depth)
+	}
+	if root.Steps != 10 || root.Opcodes["PUSH1"] != 5 || root.Opcodes["CALL"] != 1 {
+		t.Errorf("root opcode counts mismatch: have %d steps, %v", root.Steps, root.Opcodes)
+	}
+	if root.MaxStack != 7 || root.MaxMemory != 0 {
+		t.Errorf("root high-water marks mismatch: have stack %d, memory %d, want 7, 0", root.MaxStack, root.MaxMemory)
+	}
+	if len(root.Calls) != 1 {
+		t.Fatalf("sub-call count mismatch: have %d, want 1", len(root.Calls))
+	}
+	call := root.Calls[0]
+	if call.Type != "CALL" || call.Address != callee || call.Depth != 2 {
+		t.Errorf("sub-call frame mismatch: have %s %x at depth %d", call.Type, call.Address, call.Depth)
+	}
+	if call.Steps != 4 || call.Opcodes["PUSH1"] != 2 || call.Opcodes["MSTORE"] != 1 {
+		t.Errorf("sub-call opcode counts mismatch: have %d steps, %v", call.Steps, call.Opcodes)
+	}
+	if call.MaxStack != 2 || call.MaxMemory != 32 {
+		t.Errorf("sub-call high-water marks mismatch: have stack %d, memory %d, want 2, 32", call.MaxStack, call.MaxMemory)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracetest
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/core/types/zktrie"
+	"github.com/scroll-tech/go-ethereum/core/vm"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/scroll-tech/go-ethereum/eth/tracers"
+	"github.com/scroll-tech/go-ethereum/params"
+	"github.com/scroll-tech/go-ethereum/tests"
+)
+
+// TestZkStorageTracer tests that the zkStorageTracer reports the storage slots
+// accessed by a transaction along with their Poseidon hashed trie keys.
+func TestZkStorageTracer(t *testing.T) {
+	var to = common.HexToAddress("0x00000000000000000000000000000000deadbeef")
+	privkey, err := crypto.HexToECDSA("0000000000000000deadbeef00000000000000000000000000000000deadbeef")
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	signer := types.NewEIP155Signer(big.NewInt(1))
+	tx, err := types.SignNewTx(privkey, signer, &types.LegacyTx{
+		GasPrice: big.NewInt(0),
+		Gas:      100000,
+		To:       &to,
+	})
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	origin, _ := signer.Sender(tx)
+	txContext := vm.TxContext{
+		Origin:   origin,
+		GasPrice: big.NewInt(1),
+	}
+	context := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		Coinbase:    common.Address{},
+		BlockNumber: new(big.Int).SetUint64(8000000),
+		Time:        new(big.Int).SetUint64(5),
+		Difficulty:  big.NewInt(0x30000),
+		GasLimit:    uint64(6000000),
+	}
+	var code = []byte{
+		byte(vm.PUSH1), 0x1, byte(vm.SLOAD), byte(vm.POP), // sload(1)
+		byte(vm.PUSH1), 0x3, byte(vm.PUSH1), 0x2, byte(vm.SSTORE), // sstore(2, 3)
+	}
+	var alloc = core.GenesisAlloc{
+		to: core.GenesisAccount{
+			Nonce: 1,
+			Code:  code,
+		},
+		origin: core.GenesisAccount{
+			Nonce:   0,
+			Balance: big.NewInt(500000000000000),
+		},
+	}
+	_, statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), alloc, false)
+
+	tracer, err := tracers.New("zkStorageTracer", nil)
+	if err != nil {
+		t.Fatalf("failed to create zk storage tracer: %v", err)
+	}
+	evm := vm.NewEVM(context, txContext, statedb, params.MainnetChainConfig, vm.Config{Debug: true, Tracer: tracer})
+	msg, err := tx.AsMessage(signer, nil)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
+	if _, err = st.TransitionDb(); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	var accesses []struct {
+		Op      string         `json:"op"`
+		Address common.Address `json:"address"`
+		Slot    *common.Hash   `json:"slot"`
+		TrieKey common.Hash    `json:"trieKey"`
+	}
+	if err := json.Unmarshal(res, &accesses); err != nil {
+		t.Fatalf("failed to unmarshal trace result: %v", err)
+	}
+	trieKey := func(raw []byte) common.Hash {
+		hash, err := zktrie.NewByte32FromBytesPaddingZero(raw).Hash()
+		if err != nil {
+			t.Fatalf("failed to hash key: %v", err)
+		}
+		return common.BigToHash(hash)
+	}
+	want := []struct {
+		op   string
+		addr common.Address
+		slot *common.Hash
+	}{
+		{"TX_FROM", origin, nil},
+		{"TX_TO", to, nil},
+		{"COINBASE", common.Address{}, nil},
+		{"SLOAD", to, &common.Hash{31: 0x1}},
+		{"SSTORE", to, &common.Hash{31: 0x2}},
+	}
+	if len(accesses) != len(want) {
+		t.Fatalf("access count mismatch: have %d, want %d", len(accesses), len(want))
+	}
+	for i, w := range want {
+		have := accesses[i]
+		if have.Op != w.op || have.Address != w.addr {
+			t.Errorf("access %d: have %s %x, want %s %x", i, have.Op, have.Address, w.op, w.addr)
+		}
+		wantKey := trieKey(w.addr.Bytes())
+		if w.slot != nil {
+			if have.Slot == nil || *have.Slot != *w.slot {
+				t.Errorf("access %d: slot mismatch: have %v, want %x", i, have.Slot, *w.slot)
+			}
+			wantKey = trieKey(w.slot.Bytes())
+		}
+		if have.TrieKey != wantKey {
+			t.Errorf("access %d: trie key mismatch: have %x, want %x", i, have.TrieKey, wantKey)
+		}
+	}
+}
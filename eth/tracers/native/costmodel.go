@@ -0,0 +1,186 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/vm"
+	"github.com/scroll-tech/go-ethereum/eth/tracers"
+)
+
+func init() {
+	register("costModel", newCostModelTracer)
+}
+
+// costFrame is the execution profile of a single call frame. The counters only
+// cover the opcodes executed by the frame itself, not those of its sub-calls.
+type costFrame struct {
+	Type      string            `json:"type"`
+	Address   common.Address    `json:"address"`
+	Depth     int               `json:"depth"`
+	Gas       uint64            `json:"gas"`
+	GasUsed   uint64            `json:"gasUsed"`
+	Steps     uint64            `json:"steps"`
+	Opcodes   map[string]uint64 `json:"opcodes"`
+	MaxMemory uint64            `json:"maxMemory"` // Memory high-water mark in bytes
+	MaxStack  int               `json:"maxStack"`  // Stack high-water mark in items
+	Error     string            `json:"error,omitempty"`
+	Calls     []*costFrame      `json:"calls,omitempty"`
+}
+
+func newCostFrame(typ string, addr common.Address, depth int, gas uint64) *costFrame {
+	return &costFrame{
+		Type:    typ,
+		Address: addr,
+		Depth:   depth,
+		Gas:     gas,
+		Opcodes: make(map[string]uint64),
+	}
+}
+
+// costModelTracer collects the opcode counts and the memory and stack high-water
+// marks of every call frame of a transaction. The distributions are used to
+// model the circuit capacity a transaction consumes in the prover.
+//
+// Example:
+//   > debug.traceTransaction("0x...", {tracer: "costModel"})
+//   {
+//     type: "CALL", address: "0x...", depth: 1, steps: 42,
+//     opcodes: {PUSH1: 20, SLOAD: 2, ...}, maxMemory: 96, maxStack: 7,
+//     calls: [...]
+//   }
+type costModelTracer struct {
+	env       *vm.EVM
+	callstack []*costFrame
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+// newCostModelTracer returns a native go tracer which profiles the call frames
+// of a tx, and implements vm.EVMLogger.
+func newCostModelTracer() tracers.Tracer {
+	return &costModelTracer{}
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *costModelTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.env = env
+
+	typ := "CALL"
+	if create {
+		typ = "CREATE"
+	}
+	t.callstack = []*costFrame{newCostFrame(typ, to, 1, gas)}
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *costModelTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if err != nil || len(t.callstack) == 0 {
+		return
+	}
+	// Skip if tracing was interrupted
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		t.env.Cancel()
+		return
+	}
+	frame := t.callstack[len(t.callstack)-1]
+	frame.Steps++
+	frame.Opcodes[op.String()]++
+
+	// Memory is already expanded for the operation at this point
+	if size := uint64(scope.Memory.Len()); size > frame.MaxMemory {
+		frame.MaxMemory = size
+	}
+	if size := len(scope.Stack.Data()); size > frame.MaxStack {
+		frame.MaxStack = size
+	}
+}
+
+// CaptureStateAfter tracks the stack high-water mark once the operation pushed
+// its results.
+func (t *costModelTracer) CaptureStateAfter(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if len(t.callstack) == 0 {
+		return
+	}
+	frame := t.callstack[len(t.callstack)-1]
+	if size := len(scope.Stack.Data()); size > frame.MaxStack {
+		frame.MaxStack = size
+	}
+}
+
+// CaptureFault implements the EVMLogger interface to trace an execution fault.
+func (t *costModelTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, _ *vm.ScopeContext, depth int, err error) {
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *costModelTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.callstack = append(t.callstack, newCostFrame(typ.String(), to, len(t.callstack)+1, gas))
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *costModelTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	size := len(t.callstack)
+	if size <= 1 {
+		return
+	}
+	frame := t.callstack[size-1]
+	t.callstack = t.callstack[:size-1]
+
+	frame.GasUsed = gasUsed
+	if err != nil {
+		frame.Error = err.Error()
+	}
+	parent := t.callstack[size-2]
+	parent.Calls = append(parent.Calls, frame)
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *costModelTracer) CaptureEnd(output []byte, gasUsed uint64, _ time.Duration, err error) {
+	if len(t.callstack) == 0 {
+		return
+	}
+	t.callstack[0].GasUsed = gasUsed
+	if err != nil {
+		t.callstack[0].Error = err.Error()
+	}
+}
+
+// GetResult returns the json-encoded tree of call frame profiles, and any error
+// arising from the encoding or forceful termination (via `Stop`).
+func (t *costModelTracer) GetResult() (json.RawMessage, error) {
+	if len(t.callstack) != 1 {
+		return nil, errors.New("incorrect number of top-level calls")
+	}
+	res, err := json.Marshal(t.callstack[0])
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(res), t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *costModelTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
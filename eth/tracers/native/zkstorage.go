@@ -0,0 +1,207 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/types/zktrie"
+	"github.com/scroll-tech/go-ethereum/core/vm"
+	"github.com/scroll-tech/go-ethereum/eth/tracers"
+)
+
+func init() {
+	register("zkStorageTracer", newZkStorageTracer)
+}
+
+// zkAccess is a single account or storage slot access, along with the key it
+// maps to in the zk trie.
+type zkAccess struct {
+	Op      string         `json:"op"`
+	Depth   int            `json:"depth"`
+	Address common.Address `json:"address"`
+	Slot    *common.Hash   `json:"slot,omitempty"`
+	TrieKey common.Hash    `json:"trieKey"`         // Poseidon hash of the raw key
+	Value   *common.Hash   `json:"value,omitempty"` // Written value for SSTORE
+	Error   string         `json:"error,omitempty"`
+}
+
+// zkStorageTracer records every account and storage slot access of a transaction,
+// in execution order, along with the Poseidon hashed key of the accessed zk trie
+// leaf. It's meant for debugging the inputs of the storage circuit.
+//
+// Example:
+//   > debug.traceTransaction("0x...", {tracer: "zkStorageTracer"})
+//   [
+//     {op: "TX_FROM", depth: 0, address: "0x...", trieKey: "0x..."},
+//     {op: "SLOAD", depth: 1, address: "0x...", slot: "0x...", trieKey: "0x..."},
+//     ...
+//   ]
+type zkStorageTracer struct {
+	env       *vm.EVM
+	accesses  []zkAccess
+	keys      map[string]common.Hash // Cache of raw key -> hashed key
+	depth     int                    // Depth of the currently executing call frame
+	interrupt uint32                 // Atomic flag to signal execution interruption
+	reason    error                  // Textual reason for the interruption
+}
+
+// newZkStorageTracer returns a native go tracer which records the zk trie keys
+// accessed by a tx, and implements vm.EVMLogger.
+func newZkStorageTracer() tracers.Tracer {
+	return &zkStorageTracer{
+		keys: make(map[string]common.Hash),
+	}
+}
+
+// trieKey returns the Poseidon hash of the given raw account or slot key, which
+// is the key used to address the leaf in the zk trie.
+func (t *zkStorageTracer) trieKey(raw []byte) (common.Hash, error) {
+	if key, ok := t.keys[string(raw)]; ok {
+		return key, nil
+	}
+	hash, err := zktrie.NewByte32FromBytesPaddingZero(raw).Hash()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	key := common.BigToHash(hash)
+	t.keys[string(raw)] = key
+	return key, nil
+}
+
+// recordAccount records an access of the given account.
+func (t *zkStorageTracer) recordAccount(op string, depth int, addr common.Address) {
+	access := zkAccess{Op: op, Depth: depth, Address: addr}
+	if key, err := t.trieKey(addr.Bytes()); err != nil {
+		access.Error = err.Error()
+	} else {
+		access.TrieKey = key
+	}
+	t.accesses = append(t.accesses, access)
+}
+
+// recordSlot records an access of the given storage slot.
+func (t *zkStorageTracer) recordSlot(op string, depth int, addr common.Address, slot common.Hash, value *common.Hash) {
+	access := zkAccess{Op: op, Depth: depth, Address: addr, Slot: &slot, Value: value}
+	if key, err := t.trieKey(slot.Bytes()); err != nil {
+		access.Error = err.Error()
+	} else {
+		access.TrieKey = key
+	}
+	t.accesses = append(t.accesses, access)
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *zkStorageTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.env = env
+	t.depth = 1
+
+	t.recordAccount("TX_FROM", 0, from)
+	t.recordAccount("TX_TO", 0, to)
+	t.recordAccount("COINBASE", 0, env.Context.Coinbase)
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *zkStorageTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if err != nil {
+		return
+	}
+	// Skip if tracing was interrupted
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		t.env.Cancel()
+		return
+	}
+	stack := scope.Stack
+	switch op {
+	case vm.SLOAD:
+		if len(stack.Data()) < 1 {
+			return
+		}
+		slot := common.Hash(stack.Back(0).Bytes32())
+		t.recordSlot(op.String(), depth, scope.Contract.Address(), slot, nil)
+
+	case vm.SSTORE:
+		if len(stack.Data()) < 2 {
+			return
+		}
+		slot, value := common.Hash(stack.Back(0).Bytes32()), common.Hash(stack.Back(1).Bytes32())
+		t.recordSlot(op.String(), depth, scope.Contract.Address(), slot, &value)
+
+	case vm.BALANCE, vm.EXTCODESIZE, vm.EXTCODEHASH, vm.EXTCODECOPY, vm.SELFDESTRUCT:
+		if len(stack.Data()) < 1 {
+			return
+		}
+		addr := common.Address(stack.Back(0).Bytes20())
+		t.recordAccount(op.String(), depth, addr)
+
+	case vm.SELFBALANCE:
+		t.recordAccount(op.String(), depth, scope.Contract.Address())
+	}
+}
+
+// CaptureStateAfter for special needs, tracks SSTORE ops and records the storage change.
+func (t *zkStorageTracer) CaptureStateAfter(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *zkStorageTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.depth++
+
+	// Selfdestructs are already recorded when the opcode is executed
+	if typ == vm.SELFDESTRUCT {
+		return
+	}
+	t.recordAccount(typ.String(), t.depth, to)
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *zkStorageTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	t.depth--
+}
+
+// CaptureFault implements the EVMLogger interface to trace an execution fault.
+func (t *zkStorageTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, _ *vm.ScopeContext, depth int, err error) {
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *zkStorageTracer) CaptureEnd(output []byte, gasUsed uint64, _ time.Duration, err error) {
+}
+
+// GetResult returns the json-encoded list of accesses, and any error arising
+// from the encoding or forceful termination (via `Stop`).
+func (t *zkStorageTracer) GetResult() (json.RawMessage, error) {
+	accesses := t.accesses
+	if accesses == nil {
+		accesses = []zkAccess{}
+	}
+	res, err := json.Marshal(accesses)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(res), t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *zkStorageTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
@@ -0,0 +1,223 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"golang.org/x/time/rate"
+
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/go-ethereum/core"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/metrics"
+	"github.com/scroll-tech/go-ethereum/rpc"
+)
+
+const (
+	// txForwardQueue is the number of transactions waiting to be relayed to an
+	// endpoint, newer ones are dropped while the queue is full.
+	txForwardQueue = 4096
+
+	// txForwardKnown is the number of recently relayed transaction hashes kept to
+	// avoid relaying the same transaction twice.
+	txForwardKnown = 32768
+
+	// txForwardRetries is the number of times relaying a transaction is retried
+	// after a transport failure, doubling the delay between attempts.
+	txForwardRetries    = 3
+	txForwardRetryDelay = 250 * time.Millisecond
+
+	// txForwardTimeout is the maximum time of a single relay attempt.
+	txForwardTimeout = 5 * time.Second
+)
+
+var (
+	txForwardMeter          = metrics.NewRegisteredMeter("eth/txforward/relayed", nil)
+	txForwardKnownMeter     = metrics.NewRegisteredMeter("eth/txforward/known", nil)     // Already known by the sequencer
+	txForwardDuplicateMeter = metrics.NewRegisteredMeter("eth/txforward/duplicate", nil) // Skipped as recently relayed
+	txForwardDropMeter      = metrics.NewRegisteredMeter("eth/txforward/dropped", nil)   // Dropped due to a full queue
+	txForwardRetryMeter     = metrics.NewRegisteredMeter("eth/txforward/retry", nil)
+	txForwardFailMeter      = metrics.NewRegisteredMeter("eth/txforward/failed", nil)
+	txForwardTimer          = metrics.NewRegisteredTimer("eth/txforward/latency", nil)
+)
+
+// txForwardEndpoint is a sequencer RPC endpoint transactions are relayed to.
+type txForwardEndpoint struct {
+	url     string
+	queue   chan *types.Transaction
+	limiter *rate.Limiter // Relay rate limiter, nil if unlimited
+	client  *rpc.Client   // Connection to the endpoint, dialed on first use
+}
+
+// txForwarder relays the transactions submitted to the node over RPC directly
+// to the configured sequencer endpoints, so that they don't depend on the p2p
+// gossip to reach the block producer.
+type txForwarder struct {
+	endpoints []*txForwardEndpoint
+	known     *lru.Cache // Hashes of the recently relayed transactions
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newTxForwarder creates a forwarder relaying transactions to the given RPC
+// endpoints, each at most at the given rate per second, and starts it.
+func newTxForwarder(urls []string, limit float64) *txForwarder {
+	known, _ := lru.New(txForwardKnown)
+	f := &txForwarder{
+		known: known,
+		quit:  make(chan struct{}),
+	}
+	for _, url := range urls {
+		endpoint := &txForwardEndpoint{
+			url:   url,
+			queue: make(chan *types.Transaction, txForwardQueue),
+		}
+		if limit > 0 {
+			burst := int(limit)
+			if burst < 1 {
+				burst = 1
+			}
+			endpoint.limiter = rate.NewLimiter(rate.Limit(limit), burst)
+		}
+		f.endpoints = append(f.endpoints, endpoint)
+
+		f.wg.Add(1)
+		go f.loop(endpoint)
+	}
+	log.Info("Relaying transactions to sequencers", "endpoints", len(urls), "rate", limit)
+	return f
+}
+
+// forward schedules the transaction to be relayed to all endpoints, unless it
+// was relayed recently. It never blocks, the transaction is dropped for the
+// endpoints whose queues are full.
+func (f *txForwarder) forward(tx *types.Transaction) {
+	if known, _ := f.known.ContainsOrAdd(tx.Hash(), struct{}{}); known {
+		txForwardDuplicateMeter.Mark(1)
+		return
+	}
+	for _, endpoint := range f.endpoints {
+		select {
+		case endpoint.queue <- tx:
+		default:
+			txForwardDropMeter.Mark(1)
+			log.Debug("Transaction relay queue full, dropping", "endpoint", endpoint.url, "hash", tx.Hash())
+		}
+	}
+}
+
+// close stops relaying transactions, dropping the queued ones.
+func (f *txForwarder) close() {
+	close(f.quit)
+	f.wg.Wait()
+
+	for _, endpoint := range f.endpoints {
+		if endpoint.client != nil {
+			endpoint.client.Close()
+		}
+	}
+}
+
+// loop relays the queued transactions of an endpoint, one at a time.
+func (f *txForwarder) loop(endpoint *txForwardEndpoint) {
+	defer f.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-f.quit
+		cancel()
+	}()
+	for {
+		select {
+		case tx := <-endpoint.queue:
+			if endpoint.limiter != nil {
+				if err := endpoint.limiter.Wait(ctx); err != nil {
+					return
+				}
+			}
+			f.relay(ctx, endpoint, tx)
+		case <-f.quit:
+			return
+		}
+	}
+}
+
+// relay sends a transaction to an endpoint, retrying on transport failures. The
+// transactions rejected by the endpoint are not retried.
+func (f *txForwarder) relay(ctx context.Context, endpoint *txForwardEndpoint, tx *types.Transaction) {
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		log.Error("Failed to encode relayed transaction", "hash", tx.Hash(), "err", err)
+		return
+	}
+	var (
+		start = time.Now()
+		delay = txForwardRetryDelay
+	)
+	for attempt := 0; ; attempt++ {
+		err = f.send(ctx, endpoint, raw)
+		if err == nil {
+			txForwardMeter.Mark(1)
+			txForwardTimer.UpdateSince(start)
+			return
+		}
+		if _, ok := err.(rpc.Error); ok {
+			// The endpoint processed the transaction and rejected it, there's no
+			// point in retrying
+			if strings.Contains(err.Error(), core.ErrAlreadyKnown.Error()) {
+				txForwardKnownMeter.Mark(1)
+				return
+			}
+			break
+		}
+		if attempt == txForwardRetries {
+			break
+		}
+		txForwardRetryMeter.Mark(1)
+		select {
+		case <-time.After(delay):
+			delay *= 2
+		case <-ctx.Done():
+			return
+		}
+	}
+	txForwardFailMeter.Mark(1)
+	log.Warn("Failed to relay transaction", "endpoint", endpoint.url, "hash", tx.Hash(), "err", err)
+}
+
+// send submits an encoded transaction to an endpoint once.
+func (f *txForwarder) send(ctx context.Context, endpoint *txForwardEndpoint, raw []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, txForwardTimeout)
+	defer cancel()
+
+	if endpoint.client == nil {
+		client, err := rpc.DialContext(ctx, endpoint.url)
+		if err != nil {
+			return err
+		}
+		endpoint.client = client
+	}
+	return endpoint.client.CallContext(ctx, nil, "eth_sendRawTransaction", hexutil.Bytes(raw))
+}
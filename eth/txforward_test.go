@@ -0,0 +1,113 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/rpc"
+)
+
+// testSequencer is a fake sequencer RPC service recording the relayed
+// transactions.
+type testSequencer struct {
+	txs    chan *types.Transaction
+	reject map[common.Hash]bool
+	calls  int32
+}
+
+func (s *testSequencer) SendRawTransaction(raw hexutil.Bytes) (common.Hash, error) {
+	atomic.AddInt32(&s.calls, 1)
+
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return common.Hash{}, err
+	}
+	if s.reject[tx.Hash()] {
+		return common.Hash{}, errors.New("nonce too low")
+	}
+	s.txs <- tx
+	return tx.Hash(), nil
+}
+
+// Tests that transactions are relayed to the sequencer, retried on transport
+// failures but not on rejections, and deduplicated.
+func TestTxForwarder(t *testing.T) {
+	var (
+		accepted = types.NewTransaction(0, common.Address{0x01}, big.NewInt(1), 21000, big.NewInt(1), nil)
+		rejected = types.NewTransaction(1, common.Address{0x01}, big.NewInt(1), 21000, big.NewInt(1), nil)
+		service  = &testSequencer{txs: make(chan *types.Transaction, 4), reject: map[common.Hash]bool{rejected.Hash(): true}}
+		failures = int32(2)
+	)
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", service); err != nil {
+		t.Fatal(err)
+	}
+	// Fail the first requests at the transport level
+	httpsrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		server.ServeHTTP(w, r)
+	}))
+	defer httpsrv.Close()
+
+	forwarder := newTxForwarder([]string{httpsrv.URL}, 0)
+	defer forwarder.close()
+
+	forwarder.forward(accepted)
+	forwarder.forward(accepted)
+	forwarder.forward(rejected)
+
+	select {
+	case tx := <-service.txs:
+		if tx.Hash() != accepted.Hash() {
+			t.Fatalf("relayed transaction mismatch: have %x, want %x", tx.Hash(), accepted.Hash())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("transaction not relayed")
+	}
+	// Wait for the rejected transaction to be processed
+	for start := time.Now(); atomic.LoadInt32(&service.calls) < 2; {
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("rejected transaction not relayed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Give a wrongful retry of the rejected one time to show up
+	time.Sleep(2 * txForwardRetryDelay)
+
+	select {
+	case tx := <-service.txs:
+		t.Fatalf("duplicate transaction relayed: %x", tx.Hash())
+	default:
+	}
+	if calls := atomic.LoadInt32(&service.calls); calls != 2 {
+		t.Errorf("relay call count mismatch: have %d, want 2", calls)
+	}
+}
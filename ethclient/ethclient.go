@@ -28,12 +28,14 @@ import (
 	"github.com/scroll-tech/go-ethereum/common"
 	"github.com/scroll-tech/go-ethereum/common/hexutil"
 	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/rlp"
 	"github.com/scroll-tech/go-ethereum/rpc"
 )
 
 // Client defines typed wrappers for the Ethereum RPC API.
 type Client struct {
-	c *rpc.Client
+	c     *rpc.Client
+	retry RetryConfig
 }
 
 // Dial connects a client to the given URL.
@@ -51,7 +53,7 @@ func DialContext(ctx context.Context, rawurl string) (*Client, error) {
 
 // NewClient creates a client that uses the given RPC client.
 func NewClient(c *rpc.Client) *Client {
-	return &Client{c}
+	return &Client{c: c}
 }
 
 func (ec *Client) Close() {
@@ -63,7 +65,7 @@ func (ec *Client) Close() {
 // ChainID retrieves the current chain ID for transaction replay protection.
 func (ec *Client) ChainID(ctx context.Context) (*big.Int, error) {
 	var result hexutil.Big
-	err := ec.c.CallContext(ctx, &result, "eth_chainId")
+	err := ec.callContext(ctx, &result, "eth_chainId")
 	if err != nil {
 		return nil, err
 	}
@@ -90,7 +92,7 @@ func (ec *Client) BlockByNumber(ctx context.Context, number *big.Int) (*types.Bl
 // BlockNumber returns the most recent block number
 func (ec *Client) BlockNumber(ctx context.Context) (uint64, error) {
 	var result hexutil.Uint64
-	err := ec.c.CallContext(ctx, &result, "eth_blockNumber")
+	err := ec.callContext(ctx, &result, "eth_blockNumber")
 	return uint64(result), err
 }
 
@@ -102,7 +104,7 @@ type rpcBlock struct {
 
 func (ec *Client) getBlock(ctx context.Context, method string, args ...interface{}) (*types.Block, error) {
 	var raw json.RawMessage
-	err := ec.c.CallContext(ctx, &raw, method, args...)
+	err := ec.callContext(ctx, &raw, method, args...)
 	if err != nil {
 		return nil, err
 	} else if len(raw) == 0 {
@@ -168,7 +170,7 @@ func (ec *Client) getBlock(ctx context.Context, method string, args ...interface
 // HeaderByHash returns the block header with the given hash.
 func (ec *Client) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
 	var head *types.Header
-	err := ec.c.CallContext(ctx, &head, "eth_getBlockByHash", hash, false)
+	err := ec.callContext(ctx, &head, "eth_getBlockByHash", hash, false)
 	if err == nil && head == nil {
 		err = ethereum.NotFound
 	}
@@ -179,13 +181,38 @@ func (ec *Client) HeaderByHash(ctx context.Context, hash common.Hash) (*types.He
 // nil, the latest known header is returned.
 func (ec *Client) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
 	var head *types.Header
-	err := ec.c.CallContext(ctx, &head, "eth_getBlockByNumber", toBlockNumArg(number), false)
+	err := ec.callContext(ctx, &head, "eth_getBlockByNumber", toBlockNumArg(number), false)
 	if err == nil && head == nil {
 		err = ethereum.NotFound
 	}
 	return head, err
 }
 
+// HeadersByRange returns up to count canonical headers starting at the given
+// block number, fewer if the range extends past the chain head.
+func (ec *Client) HeadersByRange(ctx context.Context, from, count uint64) ([]*types.Header, error) {
+	var encs []hexutil.Bytes
+	if err := ec.callContext(ctx, &encs, "eth_getHeadersByRange", hexutil.Uint64(from), hexutil.Uint64(count), true); err != nil {
+		return nil, err
+	}
+	headers := make([]*types.Header, len(encs))
+	for i, enc := range encs {
+		headers[i] = new(types.Header)
+		if err := rlp.DecodeBytes(enc, headers[i]); err != nil {
+			return nil, err
+		}
+	}
+	return headers, nil
+}
+
+// SubscribeHeadersRange subscribes to the canonical headers starting at the
+// given block number, backfilled up to the chain head in batches and then
+// following the chain. On a reorg the headers are resent from the first
+// replaced block on.
+func (ec *Client) SubscribeHeadersRange(ctx context.Context, from uint64, ch chan<- []*types.Header) (ethereum.Subscription, error) {
+	return ec.c.EthSubscribe(ctx, ch, "newHeadersRange", hexutil.Uint64(from))
+}
+
 type rpcTransaction struct {
 	tx *types.Transaction
 	txExtraInfo
@@ -207,7 +234,7 @@ func (tx *rpcTransaction) UnmarshalJSON(msg []byte) error {
 // TransactionByHash returns the transaction with the given hash.
 func (ec *Client) TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error) {
 	var json *rpcTransaction
-	err = ec.c.CallContext(ctx, &json, "eth_getTransactionByHash", hash)
+	err = ec.callContext(ctx, &json, "eth_getTransactionByHash", hash)
 	if err != nil {
 		return nil, false, err
 	} else if json == nil {
@@ -239,7 +266,7 @@ func (ec *Client) TransactionSender(ctx context.Context, tx *types.Transaction,
 		Hash common.Hash
 		From common.Address
 	}
-	if err = ec.c.CallContext(ctx, &meta, "eth_getTransactionByBlockHashAndIndex", block, hexutil.Uint64(index)); err != nil {
+	if err = ec.callContext(ctx, &meta, "eth_getTransactionByBlockHashAndIndex", block, hexutil.Uint64(index)); err != nil {
 		return common.Address{}, err
 	}
 	if meta.Hash == (common.Hash{}) || meta.Hash != tx.Hash() {
@@ -251,14 +278,14 @@ func (ec *Client) TransactionSender(ctx context.Context, tx *types.Transaction,
 // TransactionCount returns the total number of transactions in the given block.
 func (ec *Client) TransactionCount(ctx context.Context, blockHash common.Hash) (uint, error) {
 	var num hexutil.Uint
-	err := ec.c.CallContext(ctx, &num, "eth_getBlockTransactionCountByHash", blockHash)
+	err := ec.callContext(ctx, &num, "eth_getBlockTransactionCountByHash", blockHash)
 	return uint(num), err
 }
 
 // TransactionInBlock returns a single transaction at index in the given block.
 func (ec *Client) TransactionInBlock(ctx context.Context, blockHash common.Hash, index uint) (*types.Transaction, error) {
 	var json *rpcTransaction
-	err := ec.c.CallContext(ctx, &json, "eth_getTransactionByBlockHashAndIndex", blockHash, hexutil.Uint64(index))
+	err := ec.callContext(ctx, &json, "eth_getTransactionByBlockHashAndIndex", blockHash, hexutil.Uint64(index))
 	if err != nil {
 		return nil, err
 	}
@@ -277,7 +304,7 @@ func (ec *Client) TransactionInBlock(ctx context.Context, blockHash common.Hash,
 // Note that the receipt is not available for pending transactions.
 func (ec *Client) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
 	var r *types.Receipt
-	err := ec.c.CallContext(ctx, &r, "eth_getTransactionReceipt", txHash)
+	err := ec.callContext(ctx, &r, "eth_getTransactionReceipt", txHash)
 	if err == nil {
 		if r == nil {
 			return nil, ethereum.NotFound
@@ -286,6 +313,16 @@ func (ec *Client) TransactionReceipt(ctx context.Context, txHash common.Hash) (*
 	return r, err
 }
 
+// BlockReceipts returns the receipts of all transactions in the given block.
+func (ec *Client) BlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error) {
+	var r []*types.Receipt
+	err := ec.callContext(ctx, &r, "eth_getBlockReceipts", blockNrOrHash)
+	if err == nil && r == nil {
+		return nil, ethereum.NotFound
+	}
+	return r, err
+}
+
 type rpcProgress struct {
 	StartingBlock hexutil.Uint64
 	CurrentBlock  hexutil.Uint64
@@ -298,7 +335,7 @@ type rpcProgress struct {
 // no sync currently running, it returns nil.
 func (ec *Client) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, error) {
 	var raw json.RawMessage
-	if err := ec.c.CallContext(ctx, &raw, "eth_syncing"); err != nil {
+	if err := ec.callContext(ctx, &raw, "eth_syncing"); err != nil {
 		return nil, err
 	}
 	// Handle the possible response types
@@ -328,12 +365,106 @@ func (ec *Client) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header)
 // GetBlockResultByHash returns the blockResult.
 func (ec *Client) GetBlockResultByHash(ctx context.Context, blockHash common.Hash) (*types.BlockResult, error) {
 	var blockResult types.BlockResult
-	if err := ec.c.CallContext(ctx, &blockResult, "eth_getBlockResultByHash", blockHash); err != nil {
+	if err := ec.callContext(ctx, &blockResult, "eth_getBlockResultByHash", blockHash); err != nil {
 		return nil, err
 	}
 	return &blockResult, nil
 }
 
+// GetBatchWitnessByHashes returns the deduplicated witness of a batch of
+// consecutive blocks, given by their hashes in order.
+func (ec *Client) GetBatchWitnessByHashes(ctx context.Context, blockHashes []common.Hash) (*types.BatchWitness, error) {
+	var witness types.BatchWitness
+	if err := ec.callContext(ctx, &witness, "eth_getBatchWitnessByHashes", blockHashes); err != nil {
+		return nil, err
+	}
+	return &witness, nil
+}
+
+// TokenTransferQuery selects the transfers returned by GetTokenTransfers.
+type TokenTransferQuery struct {
+	Token     *common.Address `json:"token,omitempty"`
+	Holder    *common.Address `json:"holder,omitempty"`
+	FromBlock *hexutil.Uint64 `json:"fromBlock,omitempty"`
+	ToBlock   *hexutil.Uint64 `json:"toBlock,omitempty"`
+	Cursor    hexutil.Bytes   `json:"cursor,omitempty"` // Cursor of the previous page, overrides FromBlock
+	Limit     *hexutil.Uint   `json:"limit,omitempty"`
+}
+
+// TokenTransfer is an ERC-20 or ERC-721 transfer of the token transfer index.
+type TokenTransfer struct {
+	Token            common.Address `json:"token"`
+	Standard         string         `json:"standard"`
+	From             common.Address `json:"from"`
+	To               common.Address `json:"to"`
+	Value            *hexutil.Big   `json:"value"`
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	BlockHash        common.Hash    `json:"blockHash"`
+	TransactionHash  common.Hash    `json:"transactionHash"`
+	TransactionIndex hexutil.Uint   `json:"transactionIndex"`
+	LogIndex         hexutil.Uint   `json:"logIndex"`
+}
+
+// TokenTransfersPage is a page of token transfers. Cursor is nil on the last page.
+type TokenTransfersPage struct {
+	Transfers []*TokenTransfer `json:"transfers"`
+	Cursor    hexutil.Bytes    `json:"cursor"`
+}
+
+// GetTokenTransfers returns a page of the canonical token transfers of a token
+// or a holder. The node needs the token transfer index enabled.
+func (ec *Client) GetTokenTransfers(ctx context.Context, query TokenTransferQuery) (*TokenTransfersPage, error) {
+	var page TokenTransfersPage
+	if err := ec.callContext(ctx, &page, "scroll_getTokenTransfers", query); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// EventLogQuery selects the logs returned by GetLogsByEvent.
+type EventLogQuery struct {
+	Address   common.Address  `json:"address"`
+	Topic     common.Hash     `json:"topic"`
+	FromBlock *hexutil.Uint64 `json:"fromBlock,omitempty"`
+	ToBlock   *hexutil.Uint64 `json:"toBlock,omitempty"`
+	Cursor    hexutil.Bytes   `json:"cursor,omitempty"` // Cursor of the previous page, overrides FromBlock
+	Limit     *hexutil.Uint   `json:"limit,omitempty"`
+}
+
+// EventLogsPage is a page of logs. Cursor is nil on the last page.
+type EventLogsPage struct {
+	Logs   []*types.Log  `json:"logs"`
+	Cursor hexutil.Bytes `json:"cursor"`
+}
+
+// GetLogsByEvent returns a page of the canonical logs emitted by a contract with
+// an event signature. The node needs the event log index enabled.
+func (ec *Client) GetLogsByEvent(ctx context.Context, query EventLogQuery) (*EventLogsPage, error) {
+	var page EventLogsPage
+	if err := ec.callContext(ctx, &page, "scroll_getLogsByEvent", query); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// WitnessSize is the estimated size of the witness proving a transaction.
+type WitnessSize struct {
+	Accounts  hexutil.Uint64 `json:"accounts"`
+	Slots     hexutil.Uint64 `json:"slots"`
+	TrieNodes hexutil.Uint64 `json:"trieNodes"`
+	CodeBytes hexutil.Uint64 `json:"codeBytes"`
+}
+
+// EstimateWitnessSize estimates the number of trie nodes and code bytes needed
+// to prove the execution of the message against the pending block.
+func (ec *Client) EstimateWitnessSize(ctx context.Context, msg ethereum.CallMsg) (*WitnessSize, error) {
+	var size WitnessSize
+	if err := ec.callContext(ctx, &size, "scroll_estimateWitnessSize", toCallArg(msg)); err != nil {
+		return nil, err
+	}
+	return &size, nil
+}
+
 // SubscribeNewBlockResult subscribes to block execution trace when a new block is created.
 func (ec *Client) SubscribeNewBlockResult(ctx context.Context, ch chan<- *types.BlockResult) (ethereum.Subscription, error) {
 	return ec.c.EthSubscribe(ctx, ch, "newBlockResult")
@@ -345,7 +476,7 @@ func (ec *Client) SubscribeNewBlockResult(ctx context.Context, ch chan<- *types.
 func (ec *Client) NetworkID(ctx context.Context) (*big.Int, error) {
 	version := new(big.Int)
 	var ver string
-	if err := ec.c.CallContext(ctx, &ver, "net_version"); err != nil {
+	if err := ec.callContext(ctx, &ver, "net_version"); err != nil {
 		return nil, err
 	}
 	if _, ok := version.SetString(ver, 10); !ok {
@@ -358,7 +489,7 @@ func (ec *Client) NetworkID(ctx context.Context) (*big.Int, error) {
 // The block number can be nil, in which case the balance is taken from the latest known block.
 func (ec *Client) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
 	var result hexutil.Big
-	err := ec.c.CallContext(ctx, &result, "eth_getBalance", account, toBlockNumArg(blockNumber))
+	err := ec.callContext(ctx, &result, "eth_getBalance", account, toBlockNumArg(blockNumber))
 	return (*big.Int)(&result), err
 }
 
@@ -366,7 +497,7 @@ func (ec *Client) BalanceAt(ctx context.Context, account common.Address, blockNu
 // The block number can be nil, in which case the value is taken from the latest known block.
 func (ec *Client) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
 	var result hexutil.Bytes
-	err := ec.c.CallContext(ctx, &result, "eth_getStorageAt", account, key, toBlockNumArg(blockNumber))
+	err := ec.callContext(ctx, &result, "eth_getStorageAt", account, key, toBlockNumArg(blockNumber))
 	return result, err
 }
 
@@ -374,7 +505,7 @@ func (ec *Client) StorageAt(ctx context.Context, account common.Address, key com
 // The block number can be nil, in which case the code is taken from the latest known block.
 func (ec *Client) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
 	var result hexutil.Bytes
-	err := ec.c.CallContext(ctx, &result, "eth_getCode", account, toBlockNumArg(blockNumber))
+	err := ec.callContext(ctx, &result, "eth_getCode", account, toBlockNumArg(blockNumber))
 	return result, err
 }
 
@@ -382,7 +513,7 @@ func (ec *Client) CodeAt(ctx context.Context, account common.Address, blockNumbe
 // The block number can be nil, in which case the nonce is taken from the latest known block.
 func (ec *Client) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
 	var result hexutil.Uint64
-	err := ec.c.CallContext(ctx, &result, "eth_getTransactionCount", account, toBlockNumArg(blockNumber))
+	err := ec.callContext(ctx, &result, "eth_getTransactionCount", account, toBlockNumArg(blockNumber))
 	return uint64(result), err
 }
 
@@ -395,7 +526,7 @@ func (ec *Client) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]typ
 	if err != nil {
 		return nil, err
 	}
-	err = ec.c.CallContext(ctx, &result, "eth_getLogs", arg)
+	err = ec.callContext(ctx, &result, "eth_getLogs", arg)
 	return result, err
 }
 
@@ -434,21 +565,21 @@ func toFilterArg(q ethereum.FilterQuery) (interface{}, error) {
 // PendingBalanceAt returns the wei balance of the given account in the pending state.
 func (ec *Client) PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error) {
 	var result hexutil.Big
-	err := ec.c.CallContext(ctx, &result, "eth_getBalance", account, "pending")
+	err := ec.callContext(ctx, &result, "eth_getBalance", account, "pending")
 	return (*big.Int)(&result), err
 }
 
 // PendingStorageAt returns the value of key in the contract storage of the given account in the pending state.
 func (ec *Client) PendingStorageAt(ctx context.Context, account common.Address, key common.Hash) ([]byte, error) {
 	var result hexutil.Bytes
-	err := ec.c.CallContext(ctx, &result, "eth_getStorageAt", account, key, "pending")
+	err := ec.callContext(ctx, &result, "eth_getStorageAt", account, key, "pending")
 	return result, err
 }
 
 // PendingCodeAt returns the contract code of the given account in the pending state.
 func (ec *Client) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
 	var result hexutil.Bytes
-	err := ec.c.CallContext(ctx, &result, "eth_getCode", account, "pending")
+	err := ec.callContext(ctx, &result, "eth_getCode", account, "pending")
 	return result, err
 }
 
@@ -456,14 +587,14 @@ func (ec *Client) PendingCodeAt(ctx context.Context, account common.Address) ([]
 // This is the nonce that should be used for the next transaction.
 func (ec *Client) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
 	var result hexutil.Uint64
-	err := ec.c.CallContext(ctx, &result, "eth_getTransactionCount", account, "pending")
+	err := ec.callContext(ctx, &result, "eth_getTransactionCount", account, "pending")
 	return uint64(result), err
 }
 
 // PendingTransactionCount returns the total number of transactions in the pending state.
 func (ec *Client) PendingTransactionCount(ctx context.Context) (uint, error) {
 	var num hexutil.Uint
-	err := ec.c.CallContext(ctx, &num, "eth_getBlockTransactionCountByNumber", "pending")
+	err := ec.callContext(ctx, &num, "eth_getBlockTransactionCountByNumber", "pending")
 	return uint(num), err
 }
 
@@ -477,7 +608,7 @@ func (ec *Client) PendingTransactionCount(ctx context.Context) (uint, error) {
 // blocks might not be available.
 func (ec *Client) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
 	var hex hexutil.Bytes
-	err := ec.c.CallContext(ctx, &hex, "eth_call", toCallArg(msg), toBlockNumArg(blockNumber))
+	err := ec.callContext(ctx, &hex, "eth_call", toCallArg(msg), toBlockNumArg(blockNumber))
 	if err != nil {
 		return nil, err
 	}
@@ -488,7 +619,7 @@ func (ec *Client) CallContract(ctx context.Context, msg ethereum.CallMsg, blockN
 // The state seen by the contract call is the pending state.
 func (ec *Client) PendingCallContract(ctx context.Context, msg ethereum.CallMsg) ([]byte, error) {
 	var hex hexutil.Bytes
-	err := ec.c.CallContext(ctx, &hex, "eth_call", toCallArg(msg), "pending")
+	err := ec.callContext(ctx, &hex, "eth_call", toCallArg(msg), "pending")
 	if err != nil {
 		return nil, err
 	}
@@ -499,7 +630,7 @@ func (ec *Client) PendingCallContract(ctx context.Context, msg ethereum.CallMsg)
 // execution of a transaction.
 func (ec *Client) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
 	var hex hexutil.Big
-	if err := ec.c.CallContext(ctx, &hex, "eth_gasPrice"); err != nil {
+	if err := ec.callContext(ctx, &hex, "eth_gasPrice"); err != nil {
 		return nil, err
 	}
 	return (*big.Int)(&hex), nil
@@ -509,7 +640,7 @@ func (ec *Client) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
 // allow a timely execution of a transaction.
 func (ec *Client) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
 	var hex hexutil.Big
-	if err := ec.c.CallContext(ctx, &hex, "eth_maxPriorityFeePerGas"); err != nil {
+	if err := ec.callContext(ctx, &hex, "eth_maxPriorityFeePerGas"); err != nil {
 		return nil, err
 	}
 	return (*big.Int)(&hex), nil
@@ -521,7 +652,7 @@ func (ec *Client) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
 // but it should provide a basis for setting a reasonable default.
 func (ec *Client) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
 	var hex hexutil.Uint64
-	err := ec.c.CallContext(ctx, &hex, "eth_estimateGas", toCallArg(msg))
+	err := ec.callContext(ctx, &hex, "eth_estimateGas", toCallArg(msg))
 	if err != nil {
 		return 0, err
 	}
@@ -537,6 +668,7 @@ func (ec *Client) SendTransaction(ctx context.Context, tx *types.Transaction) er
 	if err != nil {
 		return err
 	}
+	// Not retried, the node may have accepted the transaction before failing
 	return ec.c.CallContext(ctx, nil, "eth_sendRawTransaction", hexutil.Encode(data))
 }
 
@@ -28,6 +28,7 @@ import (
 
 	"github.com/scroll-tech/go-ethereum"
 	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
 	"github.com/scroll-tech/go-ethereum/consensus/ethash"
 	"github.com/scroll-tech/go-ethereum/core"
 	"github.com/scroll-tech/go-ethereum/core/rawdb"
@@ -291,6 +292,18 @@ func TestEthClient(t *testing.T) {
 		"TransactionSender": {
 			func(t *testing.T) { testTransactionSender(t, client) },
 		},
+		"BlockReceipts": {
+			func(t *testing.T) { testBlockReceipts(t, chain, client) },
+		},
+		"HeadersRange": {
+			func(t *testing.T) { testHeadersRange(t, chain, client) },
+		},
+		"GetAccount": {
+			func(t *testing.T) { testGetAccount(t, client) },
+		},
+		"ScrollMethods": {
+			func(t *testing.T) { testScrollMethods(t, client) },
+		},
 	}
 
 	t.Parallel()
@@ -639,6 +652,148 @@ func testTransactionSender(t *testing.T, client *rpc.Client) {
 	}
 }
 
+func testBlockReceipts(t *testing.T, chain []*types.Block, client *rpc.Client) {
+	ec := NewClient(client)
+
+	// Fetch the receipts of the block with the test transactions
+	receipts, err := ec.BlockReceipts(context.Background(), rpc.BlockNumberOrHashWithHash(chain[2].Hash(), false))
+	if err != nil {
+		t.Fatalf("can't get block receipts: %v", err)
+	}
+	if len(receipts) != 2 {
+		t.Fatalf("receipt count mismatch: have %d, want 2", len(receipts))
+	}
+	for i, tx := range []*types.Transaction{testTx1, testTx2} {
+		if receipts[i].TxHash != tx.Hash() {
+			t.Errorf("receipt %d: tx hash mismatch: have %x, want %x", i, receipts[i].TxHash, tx.Hash())
+		}
+		if receipts[i].BlockHash != chain[2].Hash() {
+			t.Errorf("receipt %d: block hash mismatch: have %x, want %x", i, receipts[i].BlockHash, chain[2].Hash())
+		}
+		if receipts[i].Status != types.ReceiptStatusSuccessful {
+			t.Errorf("receipt %d: unexpected status %d", i, receipts[i].Status)
+		}
+	}
+	// Empty blocks should return an empty list, unknown ones not found
+	if receipts, err = ec.BlockReceipts(context.Background(), rpc.BlockNumberOrHashWithNumber(1)); err != nil || len(receipts) != 0 {
+		t.Fatalf("unexpected receipts for empty block: %v, %v", receipts, err)
+	}
+	if _, err = ec.BlockReceipts(context.Background(), rpc.BlockNumberOrHashWithNumber(10)); err != ethereum.NotFound {
+		t.Fatalf("error mismatch for unknown block: have %v, want %v", err, ethereum.NotFound)
+	}
+}
+
+func testHeadersRange(t *testing.T, chain []*types.Block, client *rpc.Client) {
+	ec := NewClient(client)
+
+	// Ranges are cut off at the chain head
+	headers, err := ec.HeadersByRange(context.Background(), 1, 10)
+	if err != nil {
+		t.Fatalf("can't get headers: %v", err)
+	}
+	if len(headers) != 2 {
+		t.Fatalf("header count mismatch: have %d, want 2", len(headers))
+	}
+	for i, header := range headers {
+		if header.Hash() != chain[i+1].Hash() {
+			t.Errorf("header %d: hash mismatch: have %x, want %x", i+1, header.Hash(), chain[i+1].Hash())
+		}
+	}
+	if _, err := ec.HeadersByRange(context.Background(), 0, 100000); err == nil {
+		t.Fatal("oversized header range accepted")
+	}
+	// Subscriptions backfill up to the head
+	ch := make(chan []*types.Header)
+	sub, err := ec.SubscribeHeadersRange(context.Background(), 0, ch)
+	if err != nil {
+		t.Fatalf("can't subscribe to headers: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	select {
+	case batch := <-ch:
+		if len(batch) != len(chain) {
+			t.Fatalf("backfilled header count mismatch: have %d, want %d", len(batch), len(chain))
+		}
+		for i, header := range batch {
+			if header.Hash() != chain[i].Hash() {
+				t.Errorf("backfilled header %d: hash mismatch: have %x, want %x", i, header.Hash(), chain[i].Hash())
+			}
+		}
+	case err := <-sub.Err():
+		t.Fatalf("subscription failed: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no headers backfilled")
+	}
+}
+
+func testGetAccount(t *testing.T, client *rpc.Client) {
+	type account struct {
+		Balance      *hexutil.Big   `json:"balance"`
+		Nonce        hexutil.Uint64 `json:"nonce"`
+		CodeHash     common.Hash    `json:"codeHash"`
+		StorageRoot  common.Hash    `json:"storageRoot"`
+		AccountProof []string       `json:"accountProof"`
+	}
+	ec := NewClient(client)
+
+	var have account
+	if err := client.CallContext(context.Background(), &have, "eth_getAccount", testAddr, "latest", true); err != nil {
+		t.Fatalf("can't get account: %v", err)
+	}
+	balance, err := ec.BalanceAt(context.Background(), testAddr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce, err := ec.NonceAt(context.Background(), testAddr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have.Balance.ToInt().Cmp(balance) != 0 || uint64(have.Nonce) != nonce {
+		t.Fatalf("account mismatch: have balance %v nonce %d, want balance %v nonce %d", have.Balance, have.Nonce, balance, nonce)
+	}
+	if have.CodeHash != crypto.Keccak256Hash(nil) || have.StorageRoot != types.EmptyRootHash {
+		t.Fatalf("hash mismatch: have code %x storage %x", have.CodeHash, have.StorageRoot)
+	}
+	if len(have.AccountProof) == 0 {
+		t.Fatal("account proof missing")
+	}
+	// Proofs are only included on request, missing accounts are empty
+	var missing account
+	if err := client.CallContext(context.Background(), &missing, "eth_getAccount", common.Address{0xff}, "latest"); err != nil {
+		t.Fatalf("can't get account: %v", err)
+	}
+	if missing.Balance.ToInt().Sign() != 0 || missing.Nonce != 0 || missing.CodeHash != crypto.Keccak256Hash(nil) || missing.StorageRoot != types.EmptyRootHash {
+		t.Fatalf("missing account mismatch: %+v", missing)
+	}
+	if missing.AccountProof != nil {
+		t.Fatal("unrequested account proof included")
+	}
+}
+
+func testScrollMethods(t *testing.T, client *rpc.Client) {
+	ec := NewClient(client)
+
+	size, err := ec.EstimateWitnessSize(context.Background(), ethereum.CallMsg{
+		From:  testAddr,
+		To:    &common.Address{2},
+		Value: big.NewInt(1),
+	})
+	if err != nil {
+		t.Fatalf("can't estimate witness size: %v", err)
+	}
+	if size.Accounts < 2 || size.TrieNodes == 0 {
+		t.Fatalf("witness size too small: %+v", size)
+	}
+	// The test node runs without the indexes
+	if _, err := ec.GetTokenTransfers(context.Background(), TokenTransferQuery{Token: &common.Address{2}}); err == nil || err.Error() != "token transfer index is disabled" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := ec.GetLogsByEvent(context.Background(), EventLogQuery{Address: common.Address{2}}); err == nil || err.Error() != "event log index is disabled" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 func sendTransaction(ec *Client) error {
 	chainID, err := ec.ChainID(context.Background())
 	if err != nil {
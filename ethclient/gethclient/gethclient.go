@@ -22,11 +22,13 @@ import (
 	"math/big"
 	"runtime"
 	"runtime/debug"
+	"time"
 
 	"github.com/scroll-tech/go-ethereum"
 	"github.com/scroll-tech/go-ethereum/common"
 	"github.com/scroll-tech/go-ethereum/common/hexutil"
 	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/event"
 	"github.com/scroll-tech/go-ethereum/p2p"
 	"github.com/scroll-tech/go-ethereum/rpc"
 )
@@ -179,6 +181,37 @@ func (ec *Client) SubscribePendingTransactions(ctx context.Context, ch chan<- co
 	return ec.c.EthSubscribe(ctx, ch, "newPendingTransactions")
 }
 
+// ReorgBlock identifies a block of a reorganised chain segment.
+type ReorgBlock struct {
+	Number hexutil.Uint64 `json:"number"`
+	Hash   common.Hash    `json:"hash"`
+}
+
+// Reorg describes a reorganisation of the canonical chain. The chain segments
+// are ordered newest first.
+type Reorg struct {
+	CommonAncestor ReorgBlock    `json:"commonAncestor"`
+	OldChain       []ReorgBlock  `json:"oldChain"`
+	NewChain       []ReorgBlock  `json:"newChain"`
+	Dropped        []common.Hash `json:"droppedTransactions"`    // No longer part of the canonical chain
+	Reincluded     []common.Hash `json:"reincludedTransactions"` // Moved into the new chain segment
+}
+
+// SubscribeReorgs subscribes to the reorganisations of the canonical chain.
+func (ec *Client) SubscribeReorgs(ctx context.Context, ch chan<- *Reorg) (*rpc.ClientSubscription, error) {
+	return ec.c.EthSubscribe(ctx, ch, "reorgs")
+}
+
+// ResubscribeReorgs keeps a reorg subscription established, subscribing again
+// (which redials a dropped websocket or IPC connection) with a backoff of up to
+// backoffMax whenever it fails. Reorgs happening while disconnected are missed,
+// callers needing them should compare their view of the chain after a gap.
+func (ec *Client) ResubscribeReorgs(ch chan<- *Reorg, backoffMax time.Duration) event.Subscription {
+	return event.Resubscribe(backoffMax, func(ctx context.Context) (event.Subscription, error) {
+		return ec.SubscribeReorgs(ctx, ch)
+	})
+}
+
 func toBlockNumArg(number *big.Int) string {
 	if number == nil {
 		return "latest"
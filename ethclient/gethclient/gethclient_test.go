@@ -21,6 +21,7 @@ import (
 	"context"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/scroll-tech/go-ethereum"
 	"github.com/scroll-tech/go-ethereum/common"
@@ -306,3 +307,61 @@ func testCallContract(t *testing.T, client *rpc.Client) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestSubscribeReorgs(t *testing.T) {
+	genesis, blocks := generateTestChain()
+	n, err := node.New(&node.Config{})
+	if err != nil {
+		t.Fatalf("can't create new node: %v", err)
+	}
+	defer n.Close()
+	config := &ethconfig.Config{Genesis: genesis}
+	config.Ethash.PowMode = ethash.ModeFake
+	ethservice, err := eth.New(n, config)
+	if err != nil {
+		t.Fatalf("can't create new ethereum service: %v", err)
+	}
+	if err := n.Start(); err != nil {
+		t.Fatalf("can't start test node: %v", err)
+	}
+	if _, err := ethservice.BlockChain().InsertChain(blocks[1:]); err != nil {
+		t.Fatalf("can't import test blocks: %v", err)
+	}
+	client, err := n.Attach()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ch := make(chan *Reorg)
+	sub := New(client).ResubscribeReorgs(ch, time.Second)
+	defer sub.Unsubscribe()
+
+	// Replace the canonical block with a longer fork once the subscription,
+	// established in the background, is live
+	db := rawdb.NewMemoryDatabase()
+	fork, _ := core.GenerateChain(genesis.Config, genesis.MustCommit(db), ethash.NewFaker(), db, 2, func(i int, g *core.BlockGen) {
+		g.OffsetTime(5)
+		g.SetExtra([]byte("fork"))
+	})
+	time.Sleep(100 * time.Millisecond)
+	if _, err := ethservice.BlockChain().InsertChain(fork); err != nil {
+		t.Fatalf("can't import fork: %v", err)
+	}
+	select {
+	case reorg := <-ch:
+		if reorg.CommonAncestor.Hash != blocks[0].Hash() {
+			t.Errorf("common ancestor mismatch: have %x, want %x", reorg.CommonAncestor.Hash, blocks[0].Hash())
+		}
+		if len(reorg.OldChain) != 1 || reorg.OldChain[0].Hash != blocks[1].Hash() {
+			t.Errorf("old chain mismatch: %v", reorg.OldChain)
+		}
+		if len(reorg.NewChain) != 2 || reorg.NewChain[0].Hash != fork[1].Hash() || uint64(reorg.NewChain[0].Number) != 2 {
+			t.Errorf("new chain mismatch: %v", reorg.NewChain)
+		}
+	case err := <-sub.Err():
+		t.Fatalf("subscription failed: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("reorg not notified")
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/rpc"
+)
+
+// RetryConfig configures the retries of the calls failing in transit. Errors
+// returned by the node itself are final and never retried.
+type RetryConfig struct {
+	Attempts   int           // Retries after the first failure, 0 to disable
+	Backoff    time.Duration // Delay before the first retry, doubled on every retry
+	MaxBackoff time.Duration // Upper bound of the delay, 0 for none
+}
+
+// WithRetry returns a client sharing the RPC connection which retries the
+// failed calls according to config. Subscriptions and SendTransaction are
+// never retried.
+func (ec *Client) WithRetry(config RetryConfig) *Client {
+	return &Client{c: ec.c, retry: config}
+}
+
+// callContext performs the call, retrying it on transport failures.
+func (ec *Client) callContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	backoff := ec.retry.Backoff
+	for attempt := 0; ; attempt++ {
+		err := ec.c.CallContext(ctx, result, method, args...)
+		if err == nil || attempt >= ec.retry.Attempts || !retryable(err) {
+			return err
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		if backoff *= 2; ec.retry.MaxBackoff > 0 && backoff > ec.retry.MaxBackoff {
+			backoff = ec.retry.MaxBackoff
+		}
+	}
+}
+
+// retryable reports whether a failed call may succeed when repeated.
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return false
+	}
+	// The node answered with a result of the wrong shape
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+		return false
+	}
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= http.StatusInternalServerError
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/go-ethereum/rpc"
+)
+
+type retryTestService struct{}
+
+func (s *retryTestService) ChainId() *hexutil.Big { return (*hexutil.Big)(big.NewInt(1337)) }
+
+func (s *retryTestService) BlockNumber() (hexutil.Uint64, error) {
+	return 0, errors.New("node failure")
+}
+
+// newRetryTestServer starts an HTTP RPC server failing the first requests with
+// status 503.
+func newRetryTestServer(t *testing.T, failures int32) (*httptest.Server, *int32) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", new(retryTestService)); err != nil {
+		t.Fatal(err)
+	}
+	var requests int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= failures {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		server.ServeHTTP(w, r)
+	})), &requests
+}
+
+func TestClientRetry(t *testing.T) {
+	srv, requests := newRetryTestServer(t, 2)
+	defer srv.Close()
+
+	c, err := rpc.DialHTTP(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	client := NewClient(c)
+
+	// Without retries the first failure is returned
+	if _, err := client.ChainID(context.Background()); err == nil {
+		t.Fatal("expected the call to fail")
+	}
+	// Transport failures are retried until the call goes through
+	retrying := client.WithRetry(RetryConfig{Attempts: 3, Backoff: time.Millisecond})
+	id, err := retrying.ChainID(context.Background())
+	if err != nil {
+		t.Fatalf("retried call failed: %v", err)
+	}
+	if id.Uint64() != 1337 {
+		t.Fatalf("chain id mismatch: have %d, want 1337", id)
+	}
+	if n := atomic.LoadInt32(requests); n != 3 {
+		t.Fatalf("request count mismatch: have %d, want 3", n)
+	}
+	// Errors returned by the node are final
+	if _, err := retrying.BlockNumber(context.Background()); err == nil || err.Error() != "node failure" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := atomic.LoadInt32(requests); n != 4 {
+		t.Fatalf("node error retried: have %d requests, want 4", n)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package firehose
+
+import (
+	"encoding/json"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/go-ethereum/core/types"
+)
+
+// reorg is the notice published when blocks are dropped from the canonical chain.
+type reorg struct {
+	OldHead        common.Hash   `json:"oldHead"`
+	NewHead        common.Hash   `json:"newHead"`
+	AncestorNumber uint64        `json:"ancestorNumber"`
+	AncestorHash   common.Hash   `json:"ancestorHash"`
+	Dropped        []common.Hash `json:"dropped"` // Dropped blocks, newest first
+}
+
+// encoder serializes the published messages.
+type encoder interface {
+	head(header *types.Header) ([]byte, error)
+	receipts(header *types.Header, receipts types.Receipts) ([]byte, error)
+	reorg(reorg *reorg) ([]byte, error)
+}
+
+// jsonEncoder encodes the messages as JSON, the heads and receipts the same way
+// as the RPC API does.
+type jsonEncoder struct{}
+
+func (jsonEncoder) head(header *types.Header) ([]byte, error) {
+	return json.Marshal(header)
+}
+
+func (jsonEncoder) receipts(header *types.Header, receipts types.Receipts) ([]byte, error) {
+	return json.Marshal(&struct {
+		BlockNumber hexutil.Uint64 `json:"blockNumber"`
+		BlockHash   common.Hash    `json:"blockHash"`
+		Receipts    types.Receipts `json:"receipts"`
+	}{hexutil.Uint64(header.Number.Uint64()), header.Hash(), receipts})
+}
+
+func (jsonEncoder) reorg(reorg *reorg) ([]byte, error) {
+	return json.Marshal(reorg)
+}
+
+// protoEncoder encodes the messages as protocol buffers, following the schema
+// of firehose.proto.
+type protoEncoder struct{}
+
+func (protoEncoder) head(header *types.Header) ([]byte, error) {
+	var b []byte
+	b = appendUint(b, 1, header.Number.Uint64())
+	b = appendBytes(b, 2, header.Hash().Bytes())
+	b = appendBytes(b, 3, header.ParentHash.Bytes())
+	b = appendUint(b, 4, header.Time)
+	b = appendBytes(b, 5, header.Coinbase.Bytes())
+	b = appendUint(b, 6, header.GasLimit)
+	b = appendUint(b, 7, header.GasUsed)
+	if header.BaseFee != nil {
+		b = appendBytes(b, 8, header.BaseFee.Bytes())
+	}
+	b = appendBytes(b, 9, header.Root.Bytes())
+	b = appendBytes(b, 10, header.TxHash.Bytes())
+	b = appendBytes(b, 11, header.ReceiptHash.Bytes())
+	return b, nil
+}
+
+func (protoEncoder) receipts(header *types.Header, receipts types.Receipts) ([]byte, error) {
+	var b []byte
+	b = appendUint(b, 1, header.Number.Uint64())
+	b = appendBytes(b, 2, header.Hash().Bytes())
+	for _, receipt := range receipts {
+		var r []byte
+		r = appendBytes(r, 1, receipt.TxHash.Bytes())
+		r = appendUint(r, 2, uint64(receipt.TransactionIndex))
+		r = appendUint(r, 3, uint64(receipt.Type))
+		r = appendUint(r, 4, receipt.Status)
+		r = appendUint(r, 5, receipt.CumulativeGasUsed)
+		r = appendUint(r, 6, receipt.GasUsed)
+		if receipt.ContractAddress != (common.Address{}) {
+			r = appendBytes(r, 7, receipt.ContractAddress.Bytes())
+		}
+		for _, log := range receipt.Logs {
+			var l []byte
+			l = appendBytes(l, 1, log.Address.Bytes())
+			for _, topic := range log.Topics {
+				l = appendBytes(l, 2, topic.Bytes())
+			}
+			l = appendBytes(l, 3, log.Data)
+			l = appendUint(l, 4, uint64(log.Index))
+			r = appendBytes(r, 8, l)
+		}
+		b = appendBytes(b, 3, r)
+	}
+	return b, nil
+}
+
+func (protoEncoder) reorg(reorg *reorg) ([]byte, error) {
+	var b []byte
+	b = appendBytes(b, 1, reorg.OldHead.Bytes())
+	b = appendBytes(b, 2, reorg.NewHead.Bytes())
+	b = appendUint(b, 3, reorg.AncestorNumber)
+	b = appendBytes(b, 4, reorg.AncestorHash.Bytes())
+	for _, hash := range reorg.Dropped {
+		b = appendBytes(b, 5, hash.Bytes())
+	}
+	return b, nil
+}
+
+// appendUint appends a varint field, omitting the default zero value.
+func appendUint(b []byte, field protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, field, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+// appendBytes appends a length delimited field.
+func appendBytes(b []byte, field protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, field, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
@@ -0,0 +1,324 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package firehose implements a service pushing the new chain heads, their
+// receipts and the reorg notices to a message broker, for downstream indexers
+// to consume instead of polling the RPC API.
+package firehose
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/event"
+	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/metrics"
+	"github.com/scroll-tech/go-ethereum/node"
+)
+
+const (
+	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
+	chainHeadChanSize = 10
+
+	// messageQueueSize is the number of messages waiting to be published, above
+	// which new messages are dropped.
+	messageQueueSize = 1024
+
+	// maxReorgDepth is the number of published blocks remembered to detect the
+	// reorgs, and the maximum number of ancestors published for a single head.
+	maxReorgDepth = 128
+)
+
+var (
+	publishMeter = metrics.NewRegisteredMeter("firehose/published", nil)
+	failureMeter = metrics.NewRegisteredMeter("firehose/failures", nil)
+	droppedMeter = metrics.NewRegisteredMeter("firehose/dropped", nil)
+	reorgMeter   = metrics.NewRegisteredMeter("firehose/reorgs", nil)
+)
+
+// Config contains the settings of the firehose.
+type Config struct {
+	URL    string `toml:",omitempty"` // Broker endpoint, nats://host:port or kafka+http(s)://restproxy:port
+	Topic  string // Prefix of the topics (NATS subjects) published to
+	Format string // Message encoding, json or protobuf
+}
+
+// DefaultConfig is the default firehose configuration.
+var DefaultConfig = Config{
+	Topic:  "geth",
+	Format: "json",
+}
+
+// backend encompasses the bare-minimum functionality needed for the firehose.
+type backend interface {
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+	HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error)
+	GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error)
+}
+
+// sink is a message broker the firehose publishes to.
+type sink interface {
+	publish(topic string, key []byte, value []byte) error
+	close() error
+}
+
+// Service implements a daemon publishing every new canonical block to a message
+// broker. The blocks are published in order, with a reorg notice listing the
+// blocks dropped from the canonical chain before the blocks replacing them.
+type Service struct {
+	backend backend
+	sink    sink
+	encoder encoder
+	topics  [3]string // Topics of the heads, receipts and reorgs
+
+	published map[uint64]common.Hash // Recently published canonical blocks
+	head      *types.Header          // Last published head
+	queue     chan *message          // Messages waiting to be delivered to the broker
+
+	headSub event.Subscription
+	quit    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// New creates a firehose publishing to the configured broker and registers it
+// on the node.
+func New(node *node.Node, backend backend, config *Config) error {
+	s, err := newService(backend, config)
+	if err != nil {
+		return err
+	}
+	node.RegisterLifecycle(s)
+	return nil
+}
+
+// newService creates the firehose, without starting it.
+func newService(backend backend, config *Config) (*Service, error) {
+	var enc encoder
+	switch config.Format {
+	case "", "json":
+		enc = jsonEncoder{}
+	case "protobuf":
+		enc = protoEncoder{}
+	default:
+		return nil, fmt.Errorf("unknown firehose format %q", config.Format)
+	}
+	sink, err := newSink(config.URL)
+	if err != nil {
+		return nil, err
+	}
+	prefix := config.Topic
+	if prefix == "" {
+		prefix = DefaultConfig.Topic
+	}
+	return &Service{
+		backend:   backend,
+		sink:      sink,
+		encoder:   enc,
+		topics:    [3]string{prefix + ".heads", prefix + ".receipts", prefix + ".reorgs"},
+		published: make(map[uint64]common.Hash),
+		queue:     make(chan *message, messageQueueSize),
+		quit:      make(chan struct{}),
+	}, nil
+}
+
+// newSink creates the broker client of the given endpoint.
+func newSink(endpoint string) (sink, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid firehose URL: %v", err)
+	}
+	switch u.Scheme {
+	case "nats":
+		return newNATSSink(u), nil
+	case "kafka+http", "kafka+https":
+		return newKafkaSink(u), nil
+	default:
+		return nil, fmt.Errorf("unsupported firehose URL scheme %q", u.Scheme)
+	}
+}
+
+// Start implements node.Lifecycle, starting up the publishing daemon.
+func (s *Service) Start() error {
+	chainHeadCh := make(chan core.ChainHeadEvent, chainHeadChanSize)
+	s.headSub = s.backend.SubscribeChainHeadEvent(chainHeadCh)
+
+	s.wg.Add(2)
+	go s.loop(chainHeadCh)
+	go s.publishLoop()
+
+	log.Info("Firehose started", "topics", strings.Join(s.topics[:], ","))
+	return nil
+}
+
+// Stop implements node.Lifecycle, terminating the publishing daemon.
+func (s *Service) Stop() error {
+	s.headSub.Unsubscribe()
+	close(s.quit)
+	s.wg.Wait()
+
+	s.sink.close()
+	log.Info("Firehose stopped")
+	return nil
+}
+
+// loop publishes the new chain heads until termination.
+func (s *Service) loop(chainHeadCh chan core.ChainHeadEvent) {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case head := <-chainHeadCh:
+			s.update(head.Block.Header())
+		case <-s.headSub.Err():
+			return
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// update publishes a new chain head, preceded by the ancestors not published yet
+// (the head events of batch imports are only fired for the last block) and a
+// reorg notice if blocks were dropped from the canonical chain.
+func (s *Service) update(head *types.Header) {
+	if s.published[head.Number.Uint64()] == head.Hash() {
+		return
+	}
+	// Gather the new canonical blocks down to a published ancestor, or one too
+	// old to be remembered
+	headers := []*types.Header{head}
+	for s.head != nil && len(headers) < maxReorgDepth {
+		oldest := headers[len(headers)-1]
+		number := oldest.Number.Uint64()
+		if number == 0 {
+			break
+		}
+		hash, ok := s.published[number-1]
+		if ok && hash == oldest.ParentHash {
+			break
+		}
+		if !ok && number-1 <= s.head.Number.Uint64() {
+			break
+		}
+		parent, err := s.backend.HeaderByHash(context.Background(), oldest.ParentHash)
+		if err != nil || parent == nil {
+			log.Warn("Firehose failed to retrieve ancestor", "number", number-1, "hash", oldest.ParentHash, "err", err)
+			break
+		}
+		headers = append(headers, parent)
+	}
+	// Notify the blocks dropped from the canonical chain, if any
+	oldest := headers[len(headers)-1].Number.Uint64()
+	var dropped []common.Hash
+	if s.head != nil {
+		for number := s.head.Number.Uint64(); number >= oldest; number-- {
+			if hash, ok := s.published[number]; ok {
+				dropped = append(dropped, hash)
+				delete(s.published, number)
+			}
+			if number == 0 {
+				break
+			}
+		}
+	}
+	if len(dropped) > 0 {
+		reorg := &reorg{
+			OldHead:        s.head.Hash(),
+			NewHead:        head.Hash(),
+			AncestorNumber: oldest - 1,
+			AncestorHash:   headers[len(headers)-1].ParentHash,
+			Dropped:        dropped,
+		}
+		reorgMeter.Mark(1)
+		log.Info("Firehose publishing reorg", "ancestor", reorg.AncestorNumber, "dropped", len(dropped))
+		value, err := s.encoder.reorg(reorg)
+		s.send(s.topics[2], reorg.NewHead, value, err)
+	}
+	// Publish the new canonical blocks in order
+	for i := len(headers) - 1; i >= 0; i-- {
+		header := headers[i]
+		hash := header.Hash()
+
+		value, err := s.encoder.head(header)
+		s.send(s.topics[0], hash, value, err)
+
+		receipts, err := s.backend.GetReceipts(context.Background(), hash)
+		if err != nil {
+			log.Warn("Firehose failed to retrieve receipts", "number", header.Number, "hash", hash, "err", err)
+		} else {
+			value, err = s.encoder.receipts(header, receipts)
+			s.send(s.topics[1], hash, value, err)
+		}
+		s.published[header.Number.Uint64()] = hash
+		delete(s.published, header.Number.Uint64()-maxReorgDepth)
+	}
+	s.head = head
+}
+
+// message is a message waiting to be published, keyed by block hash.
+type message struct {
+	topic string
+	key   common.Hash
+	value []byte
+}
+
+// send queues a message for publishing. Messages which can't be encoded or
+// don't fit in the queue are dropped, the broker being no reason to stall the
+// node.
+func (s *Service) send(topic string, key common.Hash, value []byte, err error) {
+	if err != nil {
+		failureMeter.Mark(1)
+		log.Warn("Firehose failed to encode message", "topic", topic, "key", key, "err", err)
+		return
+	}
+	select {
+	case s.queue <- &message{topic: topic, key: key, value: value}:
+	default:
+		droppedMeter.Mark(1)
+		log.Warn("Firehose queue full, dropping message", "topic", topic, "key", key)
+	}
+}
+
+// publishLoop delivers the queued messages to the broker until termination,
+// away from the chain head events so that a slow broker doesn't hold them up.
+func (s *Service) publishLoop() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case msg := <-s.queue:
+			s.publish(msg)
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// publish delivers a message to the broker.
+func (s *Service) publish(msg *message) {
+	if err := s.sink.publish(msg.topic, msg.key.Bytes(), msg.value); err != nil {
+		failureMeter.Mark(1)
+		log.Warn("Firehose failed to publish message", "topic", msg.topic, "key", msg.key, "err", err)
+		return
+	}
+	publishMeter.Mark(1)
+}
@@ -0,0 +1,300 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package firehose
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/event"
+)
+
+// testBackend serves the headers of an in-memory block tree.
+type testBackend struct {
+	headers map[common.Hash]*types.Header
+}
+
+func (b *testBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return nil
+}
+
+func (b *testBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return b.headers[hash], nil
+}
+
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return types.Receipts{}, nil
+}
+
+// extend creates a branch of n headers on top of parent.
+func (b *testBackend) extend(parent *types.Header, n int, seed uint64) []*types.Header {
+	var branch []*types.Header
+	for i := 0; i < n; i++ {
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).Add(parent.Number, common.Big1),
+			Extra:      new(big.Int).SetUint64(seed).Bytes(),
+		}
+		b.headers[header.Hash()] = header
+		branch = append(branch, header)
+		parent = header
+	}
+	return branch
+}
+
+// testSink records the published messages.
+type testSink struct {
+	messages []string // topic:key
+}
+
+func (s *testSink) publish(topic string, key []byte, value []byte) error {
+	s.messages = append(s.messages, fmt.Sprintf("%s:%x", topic, key[:2]))
+	return nil
+}
+
+func (s *testSink) close() error { return nil }
+
+// Tests that the blocks are published in order, with the ancestors skipped by
+// the head events, and with reorg notices before the replacing blocks.
+func TestFirehoseOrdering(t *testing.T) {
+	backend := &testBackend{headers: make(map[common.Hash]*types.Header)}
+	genesis := &types.Header{Number: common.Big0}
+	backend.headers[genesis.Hash()] = genesis
+
+	sink := new(testSink)
+	s := &Service{
+		backend:   backend,
+		sink:      sink,
+		encoder:   jsonEncoder{},
+		topics:    [3]string{"heads", "receipts", "reorgs"},
+		published: make(map[uint64]common.Hash),
+		queue:     make(chan *message, messageQueueSize),
+	}
+	key := func(header *types.Header) string { return fmt.Sprintf("%x", header.Hash().Bytes()[:2]) }
+	expect := func(want ...string) {
+		t.Helper()
+		flush(s)
+		if !reflect.DeepEqual(sink.messages, want) {
+			t.Fatalf("published messages mismatch:\nhave %v\nwant %v", sink.messages, want)
+		}
+		sink.messages = nil
+	}
+	main := backend.extend(genesis, 5, 0)
+	s.update(main[0])
+	expect("heads:"+key(main[0]), "receipts:"+key(main[0]))
+
+	// Batch import, only the last block announced
+	s.update(main[2])
+	expect("heads:"+key(main[1]), "receipts:"+key(main[1]), "heads:"+key(main[2]), "receipts:"+key(main[2]))
+
+	// Duplicate announcement
+	s.update(main[2])
+	expect()
+
+	// Reorg to a longer side branch forking off main[0]
+	side := backend.extend(main[0], 3, 1)
+	s.update(side[2])
+	expect("reorgs:"+key(side[2]),
+		"heads:"+key(side[0]), "receipts:"+key(side[0]),
+		"heads:"+key(side[1]), "receipts:"+key(side[1]),
+		"heads:"+key(side[2]), "receipts:"+key(side[2]))
+
+	// Reorg back to a shorter branch
+	s.update(main[1])
+	expect("reorgs:"+key(main[1]), "heads:"+key(main[1]), "receipts:"+key(main[1]))
+	if hash, ok := s.published[3]; ok {
+		t.Errorf("dropped block still tracked: %x", hash)
+	}
+}
+
+// Tests that the reorg notice identifies the dropped blocks and the ancestor.
+func TestFirehoseReorgNotice(t *testing.T) {
+	backend := &testBackend{headers: make(map[common.Hash]*types.Header)}
+	genesis := &types.Header{Number: common.Big0}
+	main := backend.extend(genesis, 3, 0)
+	side := backend.extend(main[0], 3, 1)
+
+	var notice []byte
+	s := &Service{
+		backend: backend,
+		sink: sinkFunc(func(topic string, value []byte) {
+			if topic == "reorgs" {
+				notice = value
+			}
+		}),
+		encoder:   jsonEncoder{},
+		topics:    [3]string{"heads", "receipts", "reorgs"},
+		published: make(map[uint64]common.Hash),
+		queue:     make(chan *message, messageQueueSize),
+	}
+	s.update(main[0])
+	s.update(main[2])
+	s.update(side[2])
+	flush(s)
+
+	var have reorg
+	if err := json.Unmarshal(notice, &have); err != nil {
+		t.Fatalf("failed to decode reorg notice: %v", err)
+	}
+	want := reorg{
+		OldHead:        main[2].Hash(),
+		NewHead:        side[2].Hash(),
+		AncestorNumber: 1,
+		AncestorHash:   main[0].Hash(),
+		Dropped:        []common.Hash{main[2].Hash(), main[1].Hash()},
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("reorg notice mismatch:\nhave %+v\nwant %+v", have, want)
+	}
+}
+
+// Tests that publishing doesn't block on a full queue, dropping the messages
+// which don't fit instead.
+func TestFirehoseQueueFull(t *testing.T) {
+	backend := &testBackend{headers: make(map[common.Hash]*types.Header)}
+	genesis := &types.Header{Number: common.Big0}
+	main := backend.extend(genesis, 3, 0)
+
+	s := &Service{
+		backend:   backend,
+		sink:      new(testSink),
+		encoder:   jsonEncoder{},
+		topics:    [3]string{"heads", "receipts", "reorgs"},
+		published: make(map[uint64]common.Hash),
+		queue:     make(chan *message, 1),
+	}
+	s.update(main[2]) // Six messages, nothing draining the queue
+
+	if len(s.queue) != 1 {
+		t.Fatalf("queued message count mismatch: have %d, want 1", len(s.queue))
+	}
+	if s.head.Hash() != main[2].Hash() {
+		t.Fatalf("head not advanced past dropped messages")
+	}
+}
+
+// flush delivers the queued messages synchronously.
+func flush(s *Service) {
+	for {
+		select {
+		case msg := <-s.queue:
+			s.publish(msg)
+		default:
+			return
+		}
+	}
+}
+
+// sinkFunc is a sink calling a function for each message.
+type sinkFunc func(topic string, value []byte)
+
+func (f sinkFunc) publish(topic string, key []byte, value []byte) error {
+	f(topic, value)
+	return nil
+}
+
+func (f sinkFunc) close() error { return nil }
+
+// Tests that messages are published to NATS with the core protocol.
+func TestNATSSink(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+
+		reader := bufio.NewReader(conn)
+		connect, _ := reader.ReadString('\n')
+		if ping, _ := reader.ReadString('\n'); !strings.HasPrefix(connect, "CONNECT {") || ping != "PING\r\n" {
+			received <- "bad handshake: " + connect + ping
+			return
+		}
+		conn.Write([]byte("PONG\r\n"))
+		pub, _ := reader.ReadString('\n')
+		payload, _ := reader.ReadString('\n')
+		received <- pub + payload
+	}()
+	u, _ := url.Parse("nats://token@" + listener.Addr().String())
+	sink := newNATSSink(u)
+	defer sink.close()
+
+	if err := sink.publish("geth.heads", []byte{0x01}, []byte("hello")); err != nil {
+		t.Fatalf("failed to publish: %v", err)
+	}
+	if have, want := <-received, "PUB geth.heads 5\r\nhello\r\n"; have != want {
+		t.Errorf("published message mismatch: have %q, want %q", have, want)
+	}
+}
+
+// Tests that messages are produced to Kafka through the REST proxy.
+func TestKafkaSink(t *testing.T) {
+	var (
+		path    string
+		records kafkaRecords
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &records)
+		if strings.Contains(path, "failing") {
+			w.Write([]byte(`{"offsets":[{"partition":null,"offset":null,"error_code":50002,"error":"boom"}]}`))
+			return
+		}
+		w.Write([]byte(`{"offsets":[{"partition":0,"offset":1,"error_code":null,"error":null}]}`))
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse("kafka+" + server.URL)
+	sink := newKafkaSink(u)
+	defer sink.close()
+
+	if err := sink.publish("geth.heads", []byte{0x01}, []byte("hello")); err != nil {
+		t.Fatalf("failed to publish: %v", err)
+	}
+	if path != "/topics/geth.heads" {
+		t.Errorf("topic path mismatch: have %s", path)
+	}
+	if len(records.Records) != 1 || string(records.Records[0].Key) != "\x01" || string(records.Records[0].Value) != "hello" {
+		t.Errorf("record mismatch: %+v", records)
+	}
+	if err := sink.publish("failing", []byte{0x01}, []byte("hello")); err == nil {
+		t.Errorf("failed produce accepted")
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package firehose
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// kafkaTimeout is the maximum time to wait for the REST proxy to accept a message.
+const kafkaTimeout = 10 * time.Second
+
+// kafkaSink publishes messages to Kafka through a REST proxy implementing the
+// Confluent v2 API, kafka+http://proxy:8082 posting to http://proxy:8082. The
+// records are keyed by block hash, so a topic's partitioning keeps the messages
+// of a block together.
+type kafkaSink struct {
+	base   string
+	user   *url.Userinfo
+	client *http.Client
+}
+
+func newKafkaSink(u *url.URL) *kafkaSink {
+	base := *u
+	base.Scheme = strings.TrimPrefix(u.Scheme, "kafka+")
+	base.User = nil
+	return &kafkaSink{
+		base:   strings.TrimSuffix(base.String(), "/"),
+		user:   u.User,
+		client: &http.Client{Timeout: kafkaTimeout},
+	}
+}
+
+// kafkaRecords is the body of a produce request.
+type kafkaRecords struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaRecord struct {
+	Key   []byte `json:"key"`   // Base64 encoded by encoding/json
+	Value []byte `json:"value"` // Base64 encoded by encoding/json
+}
+
+// kafkaOffsets is the response of a produce request.
+type kafkaOffsets struct {
+	Offsets []struct {
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+func (s *kafkaSink) publish(topic string, key []byte, value []byte) error {
+	body, err := json.Marshal(&kafkaRecords{Records: []kafkaRecord{{Key: key, Value: value}}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", s.base+"/topics/"+url.PathEscape(topic), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.binary.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+	if s.user != nil {
+		pass, _ := s.user.Password()
+		req.SetBasicAuth(s.user.Username(), pass)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("kafka proxy returned %s: %s", res.Status, bytes.TrimSpace(msg))
+	}
+	var offsets kafkaOffsets
+	if err := json.NewDecoder(res.Body).Decode(&offsets); err != nil {
+		return fmt.Errorf("invalid kafka proxy response: %v", err)
+	}
+	for _, offset := range offsets.Offsets {
+		if offset.ErrorCode != nil {
+			return fmt.Errorf("kafka produce failed (code %d): %s", *offset.ErrorCode, offset.Error)
+		}
+	}
+	return nil
+}
+
+func (s *kafkaSink) close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
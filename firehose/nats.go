@@ -0,0 +1,186 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package firehose
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// natsTimeout is the maximum time to connect to the NATS server or write to it.
+const natsTimeout = 5 * time.Second
+
+// natsSink publishes messages to a NATS server, using the plain text core
+// protocol (https://docs.nats.io/reference/reference-protocols/nats-protocol).
+// Core NATS delivers at most once, lost connections are redialed on the next
+// message. The message keys are not part of the protocol and are dropped.
+type natsSink struct {
+	addr    string
+	connect []byte // CONNECT command, carrying the credentials
+
+	lock sync.Mutex
+	conn net.Conn // Lazily dialed, reset on failure
+}
+
+func newNATSSink(u *url.URL) *natsSink {
+	options := map[string]interface{}{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     "geth",
+		"lang":     "go",
+	}
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			options["user"], options["pass"] = u.User.Username(), pass
+		} else {
+			options["auth_token"] = u.User.Username()
+		}
+	}
+	blob, _ := json.Marshal(options)
+
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	return &natsSink{
+		addr:    addr,
+		connect: []byte("CONNECT " + string(blob) + "\r\nPING\r\n"),
+	}
+}
+
+// dial connects to the server, waiting for it to acknowledge the handshake.
+func (s *natsSink) dial() (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", s.addr, natsTimeout)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(natsTimeout))
+	reader := bufio.NewReader(conn)
+
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected NATS greeting %q", strings.TrimSpace(line))
+	}
+	var info struct {
+		TLSRequired bool `json:"tls_required"`
+	}
+	if err := json.Unmarshal([]byte(line[5:]), &info); err == nil && info.TLSRequired {
+		conn.Close()
+		return nil, errors.New("NATS server requires TLS")
+	}
+	if _, err := conn.Write(s.connect); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if line, err = reader.ReadString('\n'); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if line = strings.TrimSpace(line); line != "PONG" {
+		conn.Close()
+		return nil, fmt.Errorf("NATS handshake failed: %s", line)
+	}
+	conn.SetDeadline(time.Time{})
+
+	go s.read(conn, reader)
+	return conn, nil
+}
+
+// read answers the keepalive pings of the server until the connection fails.
+func (s *natsSink) read(conn net.Conn, reader *bufio.Reader) {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			s.reset(conn)
+			return
+		}
+		switch line = strings.TrimSpace(line); {
+		case line == "PING":
+			s.lock.Lock()
+			conn.SetWriteDeadline(time.Now().Add(natsTimeout))
+			_, err = conn.Write([]byte("PONG\r\n"))
+			s.lock.Unlock()
+			if err != nil {
+				s.reset(conn)
+				return
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			// The server closes the connection after most errors
+			failureMeter.Mark(1)
+		}
+	}
+}
+
+// reset drops a failed connection, unless it was replaced already.
+func (s *natsSink) reset(conn net.Conn) {
+	conn.Close()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.conn == conn {
+		s.conn = nil
+	}
+}
+
+func (s *natsSink) publish(subject string, key []byte, value []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.conn == nil {
+		conn, err := s.dial()
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	msg := make([]byte, 0, len(subject)+len(value)+32)
+	msg = append(msg, fmt.Sprintf("PUB %s %d\r\n", subject, len(value))...)
+	msg = append(msg, value...)
+	msg = append(msg, "\r\n"...)
+
+	s.conn.SetWriteDeadline(time.Now().Add(natsTimeout))
+	if _, err := s.conn.Write(msg); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (s *natsSink) close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
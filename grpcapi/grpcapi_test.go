@@ -0,0 +1,174 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core"
+	"github.com/scroll-tech/go-ethereum/core/state"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/event"
+	"github.com/scroll-tech/go-ethereum/params"
+	"github.com/scroll-tech/go-ethereum/rpc"
+)
+
+// testBackend serves a single header and a head event feed.
+type testBackend struct {
+	header   *types.Header
+	headFeed event.Feed
+}
+
+func (b *testBackend) HeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Header, error) {
+	if number, ok := blockNrOrHash.Number(); ok && number == rpc.BlockNumber(b.header.Number.Int64()) {
+		return b.header, nil
+	}
+	return nil, nil
+}
+
+func (b *testBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error) {
+	return nil, nil
+}
+
+func (b *testBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	return nil, nil, nil
+}
+
+func (b *testBackend) GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error) {
+	return nil, common.Hash{}, 0, 0, nil
+}
+
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return nil, nil
+}
+
+func (b *testBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return b.headFeed.Subscribe(ch)
+}
+
+func (b *testBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error { <-quit; return nil })
+}
+
+func (b *testBackend) ChainConfig() *params.ChainConfig {
+	return params.TestChainConfig
+}
+
+// newTestClient starts a gateway server for the backend and connects a client
+// to it through the standard gRPC transport.
+func newTestClient(t *testing.T, backend backend) (ChainClient, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := newServer(backend)
+	go server.Serve(listener)
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		server.Stop()
+		t.Fatalf("failed to dial: %v", err)
+	}
+	return NewChainClient(conn), func() {
+		conn.Close()
+		server.Stop()
+	}
+}
+
+// Tests the unary methods and their status codes.
+func TestUnary(t *testing.T) {
+	backend := &testBackend{header: &types.Header{Number: big.NewInt(7), GasLimit: 30000000}}
+	client, stop := newTestClient(t, backend)
+	defer stop()
+
+	tests := []struct {
+		block  *BlockId
+		code   codes.Code
+		number uint64
+	}{
+		{&BlockId{Id: &BlockId_Number{Number: 7}}, codes.OK, 7},
+		{&BlockId{Id: &BlockId_Number{Number: 8}}, codes.NotFound, 0},
+		{&BlockId{Id: &BlockId_Tag{Tag: "finalized"}}, codes.InvalidArgument, 0},
+	}
+	for i, tt := range tests {
+		header, err := client.GetHeader(context.Background(), &GetHeaderRequest{Block: tt.block})
+		if code := status.Code(err); code != tt.code {
+			t.Errorf("test %d: status mismatch: have %v, want %v (%v)", i, code, tt.code, err)
+			continue
+		}
+		if tt.code != codes.OK {
+			continue
+		}
+		if header.Number != tt.number {
+			t.Errorf("test %d: number mismatch: have %d, want %d", i, header.Number, tt.number)
+		}
+		if !bytes.Equal(header.Hash, backend.header.Hash().Bytes()) {
+			t.Errorf("test %d: hash mismatch: have %x", i, header.Hash)
+		}
+	}
+	if _, err := client.GetTransaction(context.Background(), &TransactionRequest{Hash: []byte{1}}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("short transaction hash accepted: %v", err)
+	}
+}
+
+// Tests that the new heads are streamed to subscribers.
+func TestSubscribeHeads(t *testing.T) {
+	backend := &testBackend{header: &types.Header{Number: big.NewInt(7)}}
+	client, stop := newTestClient(t, backend)
+	defer stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.SubscribeHeads(ctx, &SubscribeHeadsRequest{})
+	if err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	// Wait for the subscription, then feed heads to the stream
+	genesis := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(0)})
+	for start := time.Now(); backend.headFeed.Send(core.ChainHeadEvent{Block: genesis}) == 0; {
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("subscription not established")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for i := 1; i <= 3; i++ {
+		header := &types.Header{Number: big.NewInt(int64(i))}
+		backend.headFeed.Send(core.ChainHeadEvent{Block: types.NewBlockWithHeader(header)})
+	}
+	for i := 1; i <= 3; {
+		head, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("failed to read head %d: %v", i, err)
+		}
+		if head.Number == 0 {
+			continue // probing head
+		} else if head.Number != uint64(i) {
+			t.Fatalf("head number mismatch: have %d, want %d", head.Number, i)
+		}
+		i++
+	}
+}
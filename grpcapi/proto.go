@@ -0,0 +1,170 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package grpcapi
+
+import (
+	"math/big"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/rpc"
+)
+
+// The messages of geth.proto are generated into geth.pb.go and the services into
+// geth_grpc.pb.go, this file converts between them and the core types.
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative geth.proto
+
+// bigBytes returns the big endian bytes of a big integer, nil if absent.
+func bigBytes(v *big.Int) []byte {
+	if v == nil {
+		return nil
+	}
+	return v.Bytes()
+}
+
+// toHash converts a hash field, which must be 32 bytes long.
+func toHash(v []byte, name string) (common.Hash, error) {
+	if len(v) != common.HashLength {
+		return common.Hash{}, status.Errorf(codes.InvalidArgument, "invalid %s length %d", name, len(v))
+	}
+	return common.BytesToHash(v), nil
+}
+
+// toAddress converts an address field, which must be 20 bytes long.
+func toAddress(v []byte, name string) (common.Address, error) {
+	if len(v) != common.AddressLength {
+		return common.Address{}, status.Errorf(codes.InvalidArgument, "invalid %s length %d", name, len(v))
+	}
+	return common.BytesToAddress(v), nil
+}
+
+// toBlockNumberOrHash converts a block selector, the latest block if absent.
+func toBlockNumberOrHash(id *BlockId) (rpc.BlockNumberOrHash, error) {
+	switch id := id.GetId().(type) {
+	case *BlockId_Number:
+		return rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(id.Number)), nil
+	case *BlockId_Hash:
+		hash, err := toHash(id.Hash, "block hash")
+		if err != nil {
+			return rpc.BlockNumberOrHash{}, err
+		}
+		return rpc.BlockNumberOrHashWithHash(hash, false), nil
+	case *BlockId_Tag:
+		switch id.Tag {
+		case "", "latest":
+		case "pending":
+			return rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber), nil
+		case "earliest":
+			return rpc.BlockNumberOrHashWithNumber(rpc.EarliestBlockNumber), nil
+		default:
+			return rpc.BlockNumberOrHash{}, status.Errorf(codes.InvalidArgument, "unknown block tag %q", id.Tag)
+		}
+	}
+	return rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil
+}
+
+// newHeader converts a block header into a Header message.
+func newHeader(header *types.Header) *Header {
+	return &Header{
+		Number:           header.Number.Uint64(),
+		Hash:             header.Hash().Bytes(),
+		ParentHash:       header.ParentHash.Bytes(),
+		Timestamp:        header.Time,
+		Coinbase:         header.Coinbase.Bytes(),
+		GasLimit:         header.GasLimit,
+		GasUsed:          header.GasUsed,
+		BaseFee:          bigBytes(header.BaseFee),
+		StateRoot:        header.Root.Bytes(),
+		TransactionsRoot: header.TxHash.Bytes(),
+		ReceiptsRoot:     header.ReceiptHash.Bytes(),
+		Extra:            header.Extra,
+		Difficulty:       bigBytes(header.Difficulty),
+	}
+}
+
+// newTransaction converts a transaction into a Transaction message, with its
+// position in the chain if included.
+func newTransaction(tx *types.Transaction, from common.Address, blockHash common.Hash, blockNumber uint64, index uint64) *Transaction {
+	msg := &Transaction{
+		Hash:     tx.Hash().Bytes(),
+		Type:     uint64(tx.Type()),
+		Nonce:    tx.Nonce(),
+		From:     from.Bytes(),
+		Value:    bigBytes(tx.Value()),
+		Gas:      tx.Gas(),
+		GasPrice: bigBytes(tx.GasPrice()),
+		Input:    tx.Data(),
+	}
+	if tx.To() != nil {
+		msg.To = tx.To().Bytes()
+	}
+	if tx.Type() == types.DynamicFeeTxType {
+		msg.MaxPriorityFee = bigBytes(tx.GasTipCap())
+		msg.MaxFee = bigBytes(tx.GasFeeCap())
+	}
+	if blockHash != (common.Hash{}) {
+		msg.BlockHash = blockHash.Bytes()
+		msg.BlockNumber = blockNumber
+		msg.Index = index
+	}
+	return msg
+}
+
+// newLog converts a log into a Log message.
+func newLog(log *types.Log) *Log {
+	msg := &Log{
+		Address:     log.Address.Bytes(),
+		Data:        log.Data,
+		Index:       uint64(log.Index),
+		BlockNumber: log.BlockNumber,
+		BlockHash:   log.BlockHash.Bytes(),
+		TxHash:      log.TxHash.Bytes(),
+		TxIndex:     uint64(log.TxIndex),
+		Removed:     log.Removed,
+	}
+	for _, topic := range log.Topics {
+		msg.Topics = append(msg.Topics, topic.Bytes())
+	}
+	return msg
+}
+
+// newReceipt converts a receipt into a Receipt message.
+func newReceipt(receipt *types.Receipt) *Receipt {
+	msg := &Receipt{
+		TxHash:            receipt.TxHash.Bytes(),
+		TxIndex:           uint64(receipt.TransactionIndex),
+		Type:              uint64(receipt.Type),
+		Status:            receipt.Status,
+		CumulativeGasUsed: receipt.CumulativeGasUsed,
+		GasUsed:           receipt.GasUsed,
+		BlockHash:         receipt.BlockHash.Bytes(),
+	}
+	if receipt.ContractAddress != (common.Address{}) {
+		msg.ContractAddress = receipt.ContractAddress.Bytes()
+	}
+	for _, log := range receipt.Logs {
+		msg.Logs = append(msg.Logs, newLog(log))
+	}
+	if receipt.BlockNumber != nil {
+		msg.BlockNumber = receipt.BlockNumber.Uint64()
+	}
+	return msg
+}
@@ -0,0 +1,364 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package grpcapi implements a gRPC gateway to the core read APIs, for services
+// wanting typed clients and multiplexed streams instead of JSON-RPC. The
+// protobuf schema of the gateway is defined in geth.proto, clients can be
+// generated from it with the standard gRPC tooling.
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core"
+	"github.com/scroll-tech/go-ethereum/core/state"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/event"
+	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/node"
+	"github.com/scroll-tech/go-ethereum/params"
+	"github.com/scroll-tech/go-ethereum/rpc"
+)
+
+const (
+	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
+	chainHeadChanSize = 10
+
+	// logsChanSize is the size of channel listening to LogsEvent.
+	logsChanSize = 10
+
+	// streamQueueSize is the number of messages queued for a streaming client.
+	// Clients falling further behind are disconnected, not to stall the node.
+	streamQueueSize = 1024
+)
+
+// backend encompasses the bare-minimum functionality needed by the gateway.
+type backend interface {
+	HeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Header, error)
+	BlockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error)
+	StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error)
+	GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error)
+	GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error)
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+	SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription
+	ChainConfig() *params.ChainConfig
+}
+
+// Service is the gRPC gateway, serving on its own endpoint.
+type Service struct {
+	endpoint string
+	server   *grpc.Server
+	listener net.Listener
+}
+
+// New creates a gRPC gateway listening on the given endpoint and registers it
+// on the node.
+func New(node *node.Node, backend backend, endpoint string) error {
+	if endpoint == "" {
+		return errors.New("no gRPC endpoint configured")
+	}
+	node.RegisterLifecycle(&Service{
+		endpoint: endpoint,
+		server:   newServer(backend),
+	})
+	return nil
+}
+
+// newServer creates the gRPC server serving the gateway services.
+func newServer(backend backend) *grpc.Server {
+	server := grpc.NewServer()
+	RegisterChainServer(server, &chainAPI{backend: backend})
+	RegisterStateServer(server, &stateAPI{backend: backend})
+	return server
+}
+
+// Start implements node.Lifecycle, opening the gRPC endpoint.
+func (s *Service) Start() error {
+	listener, err := net.Listen("tcp", s.endpoint)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	go s.server.Serve(listener)
+
+	log.Info("gRPC endpoint opened", "addr", listener.Addr())
+	return nil
+}
+
+// Stop implements node.Lifecycle, closing the gRPC endpoint and terminating
+// the open streams.
+func (s *Service) Stop() error {
+	s.server.Stop()
+	log.Info("gRPC endpoint closed", "addr", s.listener.Addr())
+	return nil
+}
+
+// chainAPI implements the Chain service of the gateway.
+type chainAPI struct {
+	UnimplementedChainServer
+	backend backend
+}
+
+// GetHeader implements Chain.GetHeader.
+func (api *chainAPI) GetHeader(ctx context.Context, req *GetHeaderRequest) (*Header, error) {
+	block, err := toBlockNumberOrHash(req.Block)
+	if err != nil {
+		return nil, err
+	}
+	header, err := api.backend.HeaderByNumberOrHash(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, status.Error(codes.NotFound, "header not found")
+	}
+	return newHeader(header), nil
+}
+
+// GetBlock implements Chain.GetBlock.
+func (api *chainAPI) GetBlock(ctx context.Context, req *GetBlockRequest) (*Block, error) {
+	id, err := toBlockNumberOrHash(req.Block)
+	if err != nil {
+		return nil, err
+	}
+	block, err := api.backend.BlockByNumberOrHash(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, status.Error(codes.NotFound, "block not found")
+	}
+	res := &Block{Header: newHeader(block.Header())}
+	if req.FullTransactions {
+		signer := types.MakeSigner(api.backend.ChainConfig(), block.Number())
+		for i, tx := range block.Transactions() {
+			from, _ := types.Sender(signer, tx)
+			res.Transactions = append(res.Transactions, newTransaction(tx, from, block.Hash(), block.NumberU64(), uint64(i)))
+		}
+	} else {
+		for _, tx := range block.Transactions() {
+			res.TransactionHashes = append(res.TransactionHashes, tx.Hash().Bytes())
+		}
+	}
+	return res, nil
+}
+
+// GetTransaction implements Chain.GetTransaction.
+func (api *chainAPI) GetTransaction(ctx context.Context, req *TransactionRequest) (*Transaction, error) {
+	hash, err := toHash(req.Hash, "transaction hash")
+	if err != nil {
+		return nil, err
+	}
+	tx, blockHash, blockNumber, index, err := api.backend.GetTransaction(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, status.Error(codes.NotFound, "transaction not found")
+	}
+	signer := types.MakeSigner(api.backend.ChainConfig(), new(big.Int).SetUint64(blockNumber))
+	from, _ := types.Sender(signer, tx)
+	return newTransaction(tx, from, blockHash, blockNumber, index), nil
+}
+
+// GetReceipt implements Chain.GetReceipt.
+func (api *chainAPI) GetReceipt(ctx context.Context, req *TransactionRequest) (*Receipt, error) {
+	hash, err := toHash(req.Hash, "transaction hash")
+	if err != nil {
+		return nil, err
+	}
+	tx, blockHash, _, index, err := api.backend.GetTransaction(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, status.Error(codes.NotFound, "transaction not found")
+	}
+	receipts, err := api.backend.GetReceipts(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(receipts)) <= index {
+		return nil, status.Error(codes.NotFound, "receipt not found")
+	}
+	return newReceipt(receipts[index]), nil
+}
+
+// SubscribeHeads implements Chain.SubscribeHeads.
+func (api *chainAPI) SubscribeHeads(req *SubscribeHeadsRequest, stream Chain_SubscribeHeadsServer) error {
+	ch := make(chan core.ChainHeadEvent, chainHeadChanSize)
+	sub := api.backend.SubscribeChainHeadEvent(ch)
+
+	send := func(msg interface{}) error { return stream.Send(msg.(*Header)) }
+	return relay(stream.Context(), sub, send, func(queue func(interface{}) bool, done <-chan struct{}) {
+		for {
+			select {
+			case ev := <-ch:
+				if !queue(newHeader(ev.Block.Header())) {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	})
+}
+
+// SubscribeLogs implements Chain.SubscribeLogs, streaming the logs matching one
+// of the addresses and one of the first topics, if any are given.
+func (api *chainAPI) SubscribeLogs(req *SubscribeLogsRequest, stream Chain_SubscribeLogsServer) error {
+	addresses := make(map[common.Address]bool)
+	for _, address := range req.Addresses {
+		addresses[common.BytesToAddress(address)] = true
+	}
+	topics := make(map[common.Hash]bool)
+	for _, topic := range req.Topics {
+		topics[common.BytesToHash(topic)] = true
+	}
+	match := func(entry *types.Log) bool {
+		if len(addresses) > 0 && !addresses[entry.Address] {
+			return false
+		}
+		return len(topics) == 0 || (len(entry.Topics) > 0 && topics[entry.Topics[0]])
+	}
+	ch := make(chan []*types.Log, logsChanSize)
+	sub := api.backend.SubscribeLogsEvent(ch)
+
+	send := func(msg interface{}) error { return stream.Send(msg.(*Log)) }
+	return relay(stream.Context(), sub, send, func(queue func(interface{}) bool, done <-chan struct{}) {
+		for {
+			select {
+			case logs := <-ch:
+				for _, entry := range logs {
+					if match(entry) && !queue(newLog(entry)) {
+						return
+					}
+				}
+			case <-done:
+				return
+			}
+		}
+	})
+}
+
+// relay streams the messages produced from the events of a subscription until
+// the stream fails or is cancelled. The events are converted and queued on a
+// separate goroutine, so a slow client can't stall the event feed. It gets
+// disconnected instead, once its queue is full.
+func relay(ctx context.Context, sub event.Subscription, send func(interface{}) error, produce func(queue func(interface{}) bool, done <-chan struct{})) error {
+	var (
+		queue    = make(chan interface{}, streamQueueSize)
+		overflow = make(chan struct{})
+		done     = make(chan struct{})
+	)
+	defer sub.Unsubscribe()
+	defer close(done)
+
+	go produce(func(msg interface{}) bool {
+		select {
+		case queue <- msg:
+			return true
+		default:
+			close(overflow)
+			return false
+		}
+	}, done)
+
+	for {
+		select {
+		case msg := <-queue:
+			if err := send(msg); err != nil {
+				return err
+			}
+		case <-overflow:
+			return status.Error(codes.ResourceExhausted, "stream consumer too slow")
+		case err := <-sub.Err():
+			return status.Errorf(codes.Unavailable, "subscription terminated: %v", err)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// stateAPI implements the State service of the gateway.
+type stateAPI struct {
+	UnimplementedStateServer
+	backend backend
+}
+
+// GetAccount implements State.GetAccount.
+func (api *stateAPI) GetAccount(ctx context.Context, req *AccountRequest) (*Account, error) {
+	statedb, address, err := api.state(ctx, req.Address, req.Block)
+	if err != nil {
+		return nil, err
+	}
+	res := &Account{
+		Balance:  statedb.GetBalance(address).Bytes(),
+		Nonce:    statedb.GetNonce(address),
+		CodeHash: statedb.GetCodeHash(address).Bytes(),
+	}
+	return res, statedb.Error()
+}
+
+// GetCode implements State.GetCode.
+func (api *stateAPI) GetCode(ctx context.Context, req *AccountRequest) (*Code, error) {
+	statedb, address, err := api.state(ctx, req.Address, req.Block)
+	if err != nil {
+		return nil, err
+	}
+	return &Code{Code: statedb.GetCode(address)}, statedb.Error()
+}
+
+// GetStorage implements State.GetStorage.
+func (api *stateAPI) GetStorage(ctx context.Context, req *StorageRequest) (*StorageValue, error) {
+	slot, err := toHash(req.Slot, "storage slot")
+	if err != nil {
+		return nil, err
+	}
+	statedb, address, err := api.state(ctx, req.Address, req.Block)
+	if err != nil {
+		return nil, err
+	}
+	return &StorageValue{Value: statedb.GetState(address, slot).Bytes()}, statedb.Error()
+}
+
+// state resolves the account and the block state of an account request.
+func (api *stateAPI) state(ctx context.Context, addr []byte, id *BlockId) (*state.StateDB, common.Address, error) {
+	address, err := toAddress(addr, "address")
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	block, err := toBlockNumberOrHash(id)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	statedb, _, err := api.backend.StateAndHeaderByNumberOrHash(ctx, block)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	if statedb == nil {
+		return nil, common.Address{}, status.Error(codes.NotFound, "state not found")
+	}
+	return statedb, address, nil
+}
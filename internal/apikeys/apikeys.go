@@ -0,0 +1,294 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package apikeys implements API key authentication and per-key quotas for the
+// HTTP and WebSocket RPC endpoints.
+//
+// Keys are loaded from a JSON file assigning every key to a tier:
+//
+//	{
+//	  "tiers": {
+//	    "free": {"rate": 10, "burst": 20, "gasCap": 10000000, "timeout": "2s"},
+//	    "pro":  {"rate": 100, "burst": 200, "gasCap": 50000000, "timeout": "5s",
+//	             "methods": {"debug_traceCall": {"gasCap": 10000000, "timeout": "1s"}}}
+//	  },
+//	  "keys": [
+//	    {"key": "c0ffee...", "name": "acme", "tier": "pro"}
+//	  ],
+//	  "anonymous": "free"
+//	}
+//
+// The key is taken from the X-API-Key header, or from the apikey query parameter
+// for clients unable to set headers, e.g. browser websockets. Requests without a
+// key are served with the anonymous tier, or refused if there is none.
+package apikeys
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/scroll-tech/go-ethereum/metrics"
+	"github.com/scroll-tech/go-ethereum/rpc"
+)
+
+const (
+	// HeaderName is the HTTP header carrying the API key.
+	HeaderName = "X-API-Key"
+
+	// QueryName is the URL query parameter carrying the API key.
+	QueryName = "apikey"
+
+	// anonymousName is the name of the anonymous client in the metrics.
+	anonymousName = "anonymous"
+)
+
+// errRateLimited is returned to calls exceeding the rate limit of their key.
+var errRateLimited = &limitError{"rate limit exceeded"}
+
+// limitError is the JSON-RPC error of a call refused by its quota.
+type limitError struct{ message string }
+
+func (e *limitError) ErrorCode() int { return -32005 }
+
+func (e *limitError) Error() string { return e.message }
+
+// Duration is a time.Duration encoded as a string in JSON, e.g. "1.5s".
+type Duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(input []byte) error {
+	var s string
+	if err := json.Unmarshal(input, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Limits are the execution caps of a call. Zero values mean unlimited.
+type Limits struct {
+	GasCap  uint64   `json:"gasCap,omitempty"`  // Gas cap of eth_call and eth_estimateGas
+	Timeout Duration `json:"timeout,omitempty"` // Maximum execution time of a call
+}
+
+// Tier is a class of service shared by multiple API keys.
+type Tier struct {
+	Limits
+	Rate    float64           `json:"rate,omitempty"`    // Calls per second of a key, zero means unlimited
+	Burst   int               `json:"burst,omitempty"`   // Maximum burst of calls, defaults to the rate
+	Methods map[string]Limits `json:"methods,omitempty"` // Per method overrides of the tier limits
+}
+
+// limits returns the execution caps of a method, overridden fields taking
+// precedence over the ones of the tier.
+func (t *Tier) limits(method string) Limits {
+	limits := t.Limits
+	if override, ok := t.Methods[method]; ok {
+		if override.GasCap != 0 {
+			limits.GasCap = override.GasCap
+		}
+		if override.Timeout != 0 {
+			limits.Timeout = override.Timeout
+		}
+	}
+	return limits
+}
+
+// Key is an API key assigned to a tier.
+type Key struct {
+	Key  string `json:"key"`
+	Name string `json:"name"` // Name of the key holder, used in logs and metrics
+	Tier string `json:"tier"`
+}
+
+// Config is the content of the API keys file.
+type Config struct {
+	Tiers     map[string]*Tier `json:"tiers"`
+	Keys      []Key            `json:"keys"`
+	Anonymous string           `json:"anonymous,omitempty"` // Tier of calls without a key, refused if empty
+}
+
+// client is the quota state of a single API key.
+type client struct {
+	tier    *Tier
+	limiter *rate.Limiter
+
+	requests metrics.Meter // Calls made with the key
+	limited  metrics.Meter // Calls refused due to the rate limit
+}
+
+// newClient creates the quota state of a key holder.
+func newClient(name string, tier *Tier) *client {
+	c := &client{
+		tier:     tier,
+		requests: metrics.GetOrRegisterMeter(fmt.Sprintf("rpc/apikeys/%s/requests", name), nil),
+		limited:  metrics.GetOrRegisterMeter(fmt.Sprintf("rpc/apikeys/%s/limited", name), nil),
+	}
+	if tier.Rate > 0 {
+		burst := tier.Burst
+		if burst <= 0 {
+			burst = int(tier.Rate)
+			if burst < 1 {
+				burst = 1
+			}
+		}
+		c.limiter = rate.NewLimiter(rate.Limit(tier.Rate), burst)
+	}
+	return c
+}
+
+// guard implements rpc.CallGuard, enforcing the quotas of the key.
+func (c *client) guard(ctx context.Context, method string) (context.Context, context.CancelFunc, error) {
+	c.requests.Mark(1)
+	if c.limiter != nil && !c.limiter.Allow() {
+		c.limited.Mark(1)
+		return nil, nil, errRateLimited
+	}
+	limits := c.tier.limits(method)
+	if limits.GasCap != 0 {
+		ctx = context.WithValue(ctx, gasCapContextKey{}, limits.GasCap)
+	}
+	var cancel context.CancelFunc
+	if limits.Timeout != 0 {
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(limits.Timeout))
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	return ctx, cancel, nil
+}
+
+// Store authenticates RPC requests against a set of API keys.
+type Store struct {
+	clients   map[string]*client
+	anonymous *client
+}
+
+// New creates a key store from the given configuration.
+func New(config *Config) (*Store, error) {
+	for name, tier := range config.Tiers {
+		if tier == nil {
+			return nil, fmt.Errorf("tier %q is empty", name)
+		}
+		if tier.Rate < 0 || tier.Burst < 0 {
+			return nil, fmt.Errorf("tier %q has a negative rate limit", name)
+		}
+	}
+	store := &Store{clients: make(map[string]*client)}
+	for i, key := range config.Keys {
+		if key.Key == "" {
+			return nil, fmt.Errorf("key #%d is empty", i)
+		}
+		if key.Name == "" {
+			return nil, fmt.Errorf("key #%d has no name", i)
+		}
+		if _, ok := store.clients[key.Key]; ok {
+			return nil, fmt.Errorf("key %q is duplicated", key.Name)
+		}
+		tier, ok := config.Tiers[key.Tier]
+		if !ok {
+			return nil, fmt.Errorf("key %q has unknown tier %q", key.Name, key.Tier)
+		}
+		store.clients[key.Key] = newClient(key.Name, tier)
+	}
+	if config.Anonymous != "" {
+		tier, ok := config.Tiers[config.Anonymous]
+		if !ok {
+			return nil, fmt.Errorf("unknown anonymous tier %q", config.Anonymous)
+		}
+		store.anonymous = newClient(anonymousName, tier)
+	}
+	return store, nil
+}
+
+// Load creates a key store from the given JSON file.
+func Load(path string) (*Store, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := new(Config)
+	if err := json.Unmarshal(blob, config); err != nil {
+		return nil, fmt.Errorf("invalid API keys file %s: %v", path, err)
+	}
+	return New(config)
+}
+
+// errUnauthorized is the response of a request without a valid key.
+var errUnauthorized = errors.New("missing or invalid API key")
+
+// authenticate looks up the quota state of the key used by a request.
+func (s *Store) authenticate(r *http.Request) (*client, error) {
+	key := r.Header.Get(HeaderName)
+	if key == "" {
+		key = r.URL.Query().Get(QueryName)
+	}
+	if key == "" {
+		if s.anonymous == nil {
+			return nil, errUnauthorized
+		}
+		return s.anonymous, nil
+	}
+	c, ok := s.clients[key]
+	if !ok {
+		return nil, errUnauthorized
+	}
+	return c, nil
+}
+
+// Handler wraps an RPC handler, refusing requests without a valid key and
+// enforcing the quotas of the key on every call. A nil store returns the
+// handler unchanged.
+func (s *Store) Handler(next http.Handler) http.Handler {
+	if s == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := s.authenticate(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(rpc.WithCallGuard(r.Context(), c.guard)))
+	})
+}
+
+type gasCapContextKey struct{}
+
+// GasCap returns the gas cap of a call, the lower of the global cap and the
+// cap of the API key used, if any. A zero global cap means unlimited.
+func GasCap(ctx context.Context, global uint64) uint64 {
+	limit, ok := ctx.Value(gasCapContextKey{}).(uint64)
+	if !ok || (global != 0 && global <= limit) {
+		return global
+	}
+	return limit
+}
@@ -0,0 +1,182 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package apikeys
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/rpc"
+)
+
+const testGlobalGasCap = 50000000
+
+type testService struct{}
+
+func (testService) GasCap(ctx context.Context) uint64 {
+	return GasCap(ctx, testGlobalGasCap)
+}
+
+func (testService) Deadline(ctx context.Context) bool {
+	_, ok := ctx.Deadline()
+	return ok
+}
+
+func newTestStore(t *testing.T, anonymous string) *Store {
+	config := new(Config)
+	blob := `{
+		"tiers": {
+			"free": {"rate": 0.001, "burst": 2, "gasCap": 1000000},
+			"pro":  {"gasCap": 100000000, "timeout": "1s", "methods": {"test_deadline": {"gasCap": 2000000}}}
+		},
+		"keys": [
+			{"key": "freekey", "name": "alice", "tier": "free"},
+			{"key": "prokey", "name": "bob", "tier": "pro"}
+		],
+		"anonymous": "` + anonymous + `"
+	}`
+	if err := json.Unmarshal([]byte(blob), config); err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	store, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	return store
+}
+
+func newTestServer(t *testing.T, store *Store) (*rpc.Server, *httptest.Server) {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("test", testService{}); err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/", store.Handler(srv))
+	mux.Handle("/ws", store.Handler(srv.WebsocketHandler([]string{"*"})))
+	return srv, httptest.NewServer(mux)
+}
+
+// Tests that requests without a valid key are refused unless there is an
+// anonymous tier.
+func TestAuthentication(t *testing.T) {
+	srv, httpsrv := newTestServer(t, newTestStore(t, ""))
+	defer srv.Stop()
+	defer httpsrv.Close()
+
+	for _, key := range []string{"", "badkey"} {
+		req, _ := http.NewRequest("POST", httpsrv.URL, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"test_gasCap"}`))
+		req.Header.Set("content-type", "application/json")
+		if key != "" {
+			req.Header.Set(HeaderName, key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("key %q: status mismatch: have %d, want %d", key, resp.StatusCode, http.StatusUnauthorized)
+		}
+	}
+	// Calls without a key are served by the anonymous tier if configured
+	srv, httpsrv = newTestServer(t, newTestStore(t, "pro"))
+	defer srv.Stop()
+	defer httpsrv.Close()
+
+	client, err := rpc.Dial(httpsrv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var cap uint64
+	if err := client.Call(&cap, "test_gasCap"); err != nil {
+		t.Fatalf("anonymous call failed: %v", err)
+	}
+	if cap != testGlobalGasCap {
+		t.Errorf("anonymous gas cap mismatch: have %d, want %d", cap, testGlobalGasCap)
+	}
+}
+
+// Tests that the execution caps of the tier are applied to the calls.
+func TestCallLimits(t *testing.T) {
+	srv, httpsrv := newTestServer(t, newTestStore(t, ""))
+	defer srv.Stop()
+	defer httpsrv.Close()
+
+	client, err := rpc.Dial(httpsrv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.SetHeader(HeaderName, "prokey")
+
+	// The global cap is lower than the one of the tier
+	var cap uint64
+	if err := client.Call(&cap, "test_gasCap"); err != nil {
+		t.Fatal(err)
+	}
+	if cap != testGlobalGasCap {
+		t.Errorf("gas cap mismatch: have %d, want %d", cap, testGlobalGasCap)
+	}
+	// Calls of the tier have a deadline
+	var deadline bool
+	if err := client.Call(&deadline, "test_deadline"); err != nil {
+		t.Fatal(err)
+	}
+	if !deadline {
+		t.Errorf("call has no deadline")
+	}
+	// Method overrides take precedence over the tier
+	limits := newTestStore(t, "").clients["prokey"].tier.limits("test_deadline")
+	if limits.GasCap != 2000000 || time.Duration(limits.Timeout) != time.Second {
+		t.Errorf("method limits mismatch: have %+v", limits)
+	}
+}
+
+// Tests that calls exceeding the rate limit of the key are refused, also over
+// websocket connections authenticated through the query string.
+func TestRateLimit(t *testing.T) {
+	srv, httpsrv := newTestServer(t, newTestStore(t, ""))
+	defer srv.Stop()
+	defer httpsrv.Close()
+
+	client, err := rpc.Dial("ws" + strings.TrimPrefix(httpsrv.URL, "http") + "/ws?" + QueryName + "=freekey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var cap uint64
+	for i := 0; i < 2; i++ {
+		if err := client.Call(&cap, "test_gasCap"); err != nil {
+			t.Fatalf("call %d failed: %v", i, err)
+		}
+		if cap != 1000000 {
+			t.Errorf("call %d: gas cap mismatch: have %d, want %d", i, cap, 1000000)
+		}
+	}
+	err = client.Call(&cap, "test_gasCap")
+	if rerr, ok := err.(rpc.Error); !ok || rerr.ErrorCode() != -32005 {
+		t.Fatalf("rate limited call error mismatch: have %v", err)
+	}
+}
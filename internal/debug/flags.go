@@ -103,6 +103,11 @@ var (
 		Usage: "Output witness for mpt circuit with Specified order (default = no output, 1 = by executing order",
 		Value: 0,
 	}
+	// CallTraceIndexFlag is exported for the node role profiles enabling it
+	CallTraceIndexFlag = cli.BoolFlag{
+		Name:  "trace.callindex",
+		Usage: "Index the call traces of imported transactions (served by debug_getCallTrace)",
+	}
 )
 
 // Flags holds all command-line flags required for debugging.
@@ -121,6 +126,7 @@ var Flags = []cli.Flag{
 	traceFlag,
 	traceCacheLimitFlag,
 	mptWitnessFlag,
+	CallTraceIndexFlag,
 }
 
 var glogger *log.GlogHandler
@@ -137,6 +143,7 @@ type TraceConfig struct {
 	// Trace option
 	TraceCacheLimit int
 	MPTWitness      int
+	CallTraceIndex  bool
 }
 
 func ConfigTrace(ctx *cli.Context) *TraceConfig {
@@ -144,6 +151,7 @@ func ConfigTrace(ctx *cli.Context) *TraceConfig {
 	cfg.TracePath = ctx.GlobalString(traceFlag.Name)
 	cfg.TraceCacheLimit = ctx.GlobalInt(traceCacheLimitFlag.Name)
 	cfg.MPTWitness = ctx.GlobalInt(mptWitnessFlag.Name)
+	cfg.CallTraceIndex = ctx.GlobalBool(CallTraceIndexFlag.Name)
 
 	return cfg
 }
@@ -42,6 +42,7 @@ import (
 	"github.com/scroll-tech/go-ethereum/core/types"
 	"github.com/scroll-tech/go-ethereum/core/vm"
 	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/scroll-tech/go-ethereum/internal/apikeys"
 	"github.com/scroll-tech/go-ethereum/log"
 	"github.com/scroll-tech/go-ethereum/p2p"
 	"github.com/scroll-tech/go-ethereum/params"
@@ -122,6 +123,11 @@ func (s *PublicEthereumAPI) FeeHistory(ctx context.Context, blockCount rpc.Decim
 // - highestBlock:  block number of the highest block header this node has received from peers
 // - pulledStates:  number of state entries processed until now
 // - knownStates:   number of known state entries that still need to be pulled
+// While regenerating the state lost in an unclean shutdown, the blocks replayed are
+// reported instead, along with:
+// - recovering:       always true
+// - recoveryParallel: whether the parallel import pipeline is used
+// - recoveryEta:      estimated number of seconds until the state is regenerated
 func (s *PublicEthereumAPI) Syncing() (interface{}, error) {
 	progress := s.b.SyncProgress()
 
@@ -130,13 +136,20 @@ func (s *PublicEthereumAPI) Syncing() (interface{}, error) {
 		return false, nil
 	}
 	// Otherwise gather the block sync stats
-	return map[string]interface{}{
+	result := map[string]interface{}{
 		"startingBlock": hexutil.Uint64(progress.StartingBlock),
 		"currentBlock":  hexutil.Uint64(progress.CurrentBlock),
 		"highestBlock":  hexutil.Uint64(progress.HighestBlock),
 		"pulledStates":  hexutil.Uint64(progress.PulledStates),
 		"knownStates":   hexutil.Uint64(progress.KnownStates),
-	}, nil
+	}
+	// Extend the stats if state lost in an unclean shutdown is being regenerated
+	if recovery := s.b.RecoveryProgress(); recovery != nil {
+		result["recovering"] = true
+		result["recoveryParallel"] = recovery.Parallel
+		result["recoveryEta"] = hexutil.Uint64(recovery.ETA() / time.Second)
+	}
+	return result, nil
 }
 
 // PublicTxPoolAPI offers and API for the transaction pool. It only operates on data that is non confidential.
@@ -211,12 +224,59 @@ func (s *PublicTxPoolAPI) Status() map[string]hexutil.Uint {
 // Inspect retrieves the content of the transaction pool and flattens it into an
 // easily inspectable list.
 func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
+	return s.inspect(nil)
+}
+
+// TxPoolFilter selects the transactions returned by the filtered pool inspection.
+// Omitted fields match every transaction.
+type TxPoolFilter struct {
+	From        *common.Address `json:"from"`        // Only transactions of this sender
+	MinGasPrice *hexutil.Big    `json:"minGasPrice"` // Only transactions paying at least this much
+	MaxGasPrice *hexutil.Big    `json:"maxGasPrice"` // Only transactions paying at most this much
+	MinAge      *hexutil.Uint64 `json:"minAge"`      // Only transactions seen at least this many seconds ago
+	MaxAge      *hexutil.Uint64 `json:"maxAge"`      // Only transactions seen at most this many seconds ago
+}
+
+// matches checks whether the given transaction satisfies the filter.
+func (f *TxPoolFilter) matches(tx *types.Transaction, now time.Time) bool {
+	if f.MinGasPrice != nil && tx.GasPrice().Cmp(f.MinGasPrice.ToInt()) < 0 {
+		return false
+	}
+	if f.MaxGasPrice != nil && tx.GasPrice().Cmp(f.MaxGasPrice.ToInt()) > 0 {
+		return false
+	}
+	age := now.Sub(tx.Time())
+	if f.MinAge != nil && age < time.Duration(*f.MinAge)*time.Second {
+		return false
+	}
+	if f.MaxAge != nil && age > time.Duration(*f.MaxAge)*time.Second {
+		return false
+	}
+	return true
+}
+
+// InspectFiltered retrieves the transactions of the pool matching the given
+// filter and flattens them into an easily inspectable list. Accounts without
+// matching transactions are omitted.
+func (s *PublicTxPoolAPI) InspectFiltered(filter TxPoolFilter) map[string]map[string]map[string]string {
+	return s.inspect(&filter)
+}
+
+// inspect flattens the transactions of the pool matching the optional filter
+// into an easily inspectable list.
+func (s *PublicTxPoolAPI) inspect(filter *TxPoolFilter) map[string]map[string]map[string]string {
 	content := map[string]map[string]map[string]string{
 		"pending": make(map[string]map[string]string),
 		"queued":  make(map[string]map[string]string),
 	}
-	pending, queue := s.b.TxPoolContent()
-
+	var pending, queue map[common.Address]types.Transactions
+	if filter != nil && filter.From != nil {
+		txs, queued := s.b.TxPoolContentFrom(*filter.From)
+		pending = map[common.Address]types.Transactions{*filter.From: txs}
+		queue = map[common.Address]types.Transactions{*filter.From: queued}
+	} else {
+		pending, queue = s.b.TxPoolContent()
+	}
 	// Define a formatter to flatten a transaction into a string
 	var format = func(tx *types.Transaction) string {
 		if to := tx.To(); to != nil {
@@ -224,25 +284,85 @@ func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
 		}
 		return fmt.Sprintf("contract creation: %v wei + %v gas × %v wei", tx.Value(), tx.Gas(), tx.GasPrice())
 	}
-	// Flatten the pending transactions
-	for account, txs := range pending {
+	// Define a flattener to dump the matching transactions of an account
+	now := time.Now()
+	var flatten = func(txs types.Transactions) map[string]string {
 		dump := make(map[string]string)
 		for _, tx := range txs {
-			dump[fmt.Sprintf("%d", tx.Nonce())] = format(tx)
+			if filter == nil || filter.matches(tx, now) {
+				dump[fmt.Sprintf("%d", tx.Nonce())] = format(tx)
+			}
+		}
+		return dump
+	}
+	// Flatten the pending and queued transactions
+	for account, txs := range pending {
+		if dump := flatten(txs); filter == nil || len(dump) > 0 {
+			content["pending"][account.Hex()] = dump
 		}
-		content["pending"][account.Hex()] = dump
 	}
-	// Flatten the queued transactions
 	for account, txs := range queue {
-		dump := make(map[string]string)
-		for _, tx := range txs {
-			dump[fmt.Sprintf("%d", tx.Nonce())] = format(tx)
+		if dump := flatten(txs); filter == nil || len(dump) > 0 {
+			content["queued"][account.Hex()] = dump
 		}
-		content["queued"][account.Hex()] = dump
 	}
 	return content
 }
 
+// RPCDroppedTransaction reports a transaction evicted from the pool.
+type RPCDroppedTransaction struct {
+	Hash   common.Hash       `json:"hash"`
+	From   common.Address    `json:"from"`
+	Nonce  hexutil.Uint64    `json:"nonce"`
+	Reason core.TxDropReason `json:"reason"`
+	Age    hexutil.Uint64    `json:"age"` // Seconds the transaction spent in the pool
+}
+
+// Dropped creates a subscription that is triggered each time a transaction is
+// evicted from the pool, reporting the reason of the eviction. If from is set,
+// only transactions of that sender are reported.
+func (s *PublicTxPoolAPI) Dropped(ctx context.Context, from *common.Address) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		var (
+			signer  = types.LatestSigner(s.b.ChainConfig())
+			dropped = make(chan core.DroppedTxsEvent, 128)
+			sub     = s.b.SubscribeDroppedTxsEvent(dropped)
+		)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-dropped:
+				now := time.Now()
+				for _, tx := range ev.Txs {
+					sender, _ := types.Sender(signer, tx)
+					if from != nil && sender != *from {
+						continue
+					}
+					notifier.Notify(rpcSub.ID, &RPCDroppedTransaction{
+						Hash:   tx.Hash(),
+						From:   sender,
+						Nonce:  hexutil.Uint64(tx.Nonce()),
+						Reason: ev.Reason,
+						Age:    hexutil.Uint64(now.Sub(tx.Time()) / time.Second),
+					})
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
 // PublicAccountAPI provides an API to access accounts managed by this node.
 // It offers only methods that can retrieve accounts.
 type PublicAccountAPI struct {
@@ -700,6 +820,45 @@ func (s *PublicBlockChainAPI) GetProof(ctx context.Context, address common.Addre
 	}, state.Error()
 }
 
+// AccountInfo is the result of eth_getAccount, holding all the fields of an
+// account and optionally its proof.
+type AccountInfo struct {
+	Balance      *hexutil.Big   `json:"balance"`
+	Nonce        hexutil.Uint64 `json:"nonce"`
+	CodeHash     common.Hash    `json:"codeHash"`
+	StorageRoot  common.Hash    `json:"storageRoot"`
+	AccountProof []string       `json:"accountProof,omitempty"`
+}
+
+// GetAccount returns the balance, nonce, code hash and storage root of an
+// account in the state of the given block, sparing separate queries for each.
+// The accounts that don't exist have the hashes of empty code and storage. If
+// withProof is set, the proof of the account is included, being a Poseidon one
+// on zktrie chains.
+func (s *PublicBlockChainAPI) GetAccount(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash, withProof *bool) (*AccountInfo, error) {
+	state, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	info := &AccountInfo{
+		Balance:     (*hexutil.Big)(state.GetBalance(address)),
+		Nonce:       hexutil.Uint64(state.GetNonce(address)),
+		CodeHash:    state.GetCodeHash(address),
+		StorageRoot: state.GetStorageRoot(address),
+	}
+	if !state.Exist(address) {
+		info.CodeHash = crypto.Keccak256Hash(nil)
+	}
+	if withProof != nil && *withProof {
+		proof, err := state.GetProof(address)
+		if err != nil {
+			return nil, err
+		}
+		info.AccountProof = toHexSlice(proof)
+	}
+	return info, state.Error()
+}
+
 // GetHeaderByNumber returns the requested canonical block header.
 // * When blockNr is -1 the chain head is returned.
 // * When blockNr is -2 the pending chain head is returned.
@@ -727,6 +886,40 @@ func (s *PublicBlockChainAPI) GetHeaderByHash(ctx context.Context, hash common.H
 	return nil
 }
 
+// maxHeaderRange is the maximum number of headers returned by a single
+// eth_getHeadersByRange call.
+const maxHeaderRange = 1024
+
+// GetHeadersByRange returns up to count canonical headers starting at the given
+// block number, fewer if the range extends past the chain head. If rlpEncoded is
+// set, the headers are returned RLP encoded instead of as objects.
+func (s *PublicBlockChainAPI) GetHeadersByRange(ctx context.Context, from hexutil.Uint64, count hexutil.Uint64, rlpEncoded *bool) ([]interface{}, error) {
+	if count > maxHeaderRange {
+		return nil, fmt.Errorf("header range too large: %d > %d", count, maxHeaderRange)
+	}
+	head := s.b.CurrentHeader().Number.Uint64()
+	result := make([]interface{}, 0, count)
+	for number := uint64(from); number < uint64(from)+uint64(count) && number <= head; number++ {
+		header, err := s.b.HeaderByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			break
+		}
+		if rlpEncoded != nil && *rlpEncoded {
+			enc, err := rlp.EncodeToBytes(header)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, hexutil.Bytes(enc))
+		} else {
+			result = append(result, s.rpcMarshalHeader(ctx, header))
+		}
+	}
+	return result, nil
+}
+
 // GetBlockByNumber returns the requested canonical block.
 // * When blockNr is -1 the chain head is returned.
 // * When blockNr is -2 the pending chain head is returned.
@@ -903,8 +1096,15 @@ func DoCall(ctx context.Context, b Backend, args TransactionArgs, blockNrOrHash
 	// this makes sure resources are cleaned up.
 	defer cancel()
 
+	return doCall(ctx, b, args, state, header, timeout, globalGasCap)
+}
+
+// doCall executes a single message call on top of the given state. The caller
+// is responsible for setting up (and cancelling) the context.
+func doCall(ctx context.Context, b Backend, args TransactionArgs, state *state.StateDB, header *types.Header, timeout time.Duration, globalGasCap uint64) (*core.ExecutionResult, error) {
 	// Get a new instance of the EVM.
-	msg, err := args.ToMessage(globalGasCap, header.BaseFee)
+	// Public endpoints may cap the gas of calls made with a given API key
+	msg, err := args.ToMessage(apikeys.GasCap(ctx, globalGasCap), header.BaseFee)
 	if err != nil {
 		return nil, err
 	}
@@ -972,19 +1172,156 @@ func (e *revertError) ErrorData() interface{} {
 //
 // Note, this function doesn't make and changes in the state/blockchain and is
 // useful to execute and retrieve values.
+//
+// Results are memoized if the call cache is enabled, unless the request carries
+// a "Cache-Control: no-cache" header.
 func (s *PublicBlockChainAPI) Call(ctx context.Context, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride) (hexutil.Bytes, error) {
+	cache := s.b.RPCCallCache()
+	if cache == nil || bypassCallCache(ctx) {
+		return s.call(ctx, args, blockNrOrHash, overrides, nil, common.Hash{})
+	}
+	// The pending state changes without a new block hash, it can't be cached
+	if number, ok := blockNrOrHash.Number(); ok && number == rpc.PendingBlockNumber {
+		return s.call(ctx, args, blockNrOrHash, overrides, nil, common.Hash{})
+	}
+	header, err := s.b.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if header == nil || err != nil {
+		return s.call(ctx, args, blockNrOrHash, overrides, nil, common.Hash{})
+	}
+	key, err := callCacheKey(header.Hash(), &args, overrides, apikeys.GasCap(ctx, s.b.RPCGasCap()))
+	if err != nil {
+		return s.call(ctx, args, blockNrOrHash, overrides, nil, common.Hash{})
+	}
+	if entry, ok := cache.get(key); ok {
+		return entry.result, entry.err
+	}
+	// Execute on the resolved block, the head may have moved in the meantime
+	return s.call(ctx, args, rpc.BlockNumberOrHashWithHash(header.Hash(), false), overrides, cache, key)
+}
+
+// call executes an eth_call, memoizing its outcome in the given cache if the
+// execution completed, successfully or not.
+func (s *PublicBlockChainAPI) call(ctx context.Context, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride, cache *CallCache, key common.Hash) (hexutil.Bytes, error) {
 	result, err := DoCall(ctx, s.b, args, blockNrOrHash, overrides, s.b.RPCEVMTimeout(), s.b.RPCGasCap())
 	if err != nil {
 		return nil, err
 	}
 	// If the result contains a revert reason, try to unpack and return it.
 	if len(result.Revert()) > 0 {
-		return nil, newRevertError(result)
+		err := newRevertError(result)
+		cache.put(key, nil, err)
+		return nil, err
 	}
+	cache.put(key, result.Return(), result.Err)
 	return result.Return(), result.Err
 }
 
+// maxCallManyCalls is the maximum number of calls that can be executed in a
+// single eth_callMany request.
+const maxCallManyCalls = 256
+
+// CallManyArgs represents a single call of an eth_callMany batch, together
+// with the state overrides to apply right before executing it.
+type CallManyArgs struct {
+	TransactionArgs
+	StateOverrides *StateOverride `json:"stateOverrides"`
+}
+
+// CallManyResult is the outcome of a single call of an eth_callMany batch.
+type CallManyResult struct {
+	ReturnData hexutil.Bytes  `json:"returnData"`
+	GasUsed    hexutil.Uint64 `json:"gasUsed"`
+	Error      string         `json:"error,omitempty"`
+	Revert     string         `json:"revert,omitempty"` // hex encoded revert reason, if any
+}
+
+// CallMany executes a sequence of calls on top of the state of the given block.
+// All calls share a single state copy, so each call observes the state changes
+// made by the previous ones. Optional per-call state overrides are applied right
+// before the respective call is executed.
+//
+// The RPC gas cap is a budget for the whole batch rather than for every single
+// call: each call may only spend what the previous ones left over, and the batch
+// is aborted once the budget is exhausted.
+//
+// A failing call doesn't abort the batch, its error is reported in the result.
+func (s *PublicBlockChainAPI) CallMany(ctx context.Context, calls []CallManyArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride) ([]CallManyResult, error) {
+	if len(calls) == 0 {
+		return nil, errors.New("empty call list")
+	} else if len(calls) > maxCallManyCalls {
+		return nil, fmt.Errorf("too many calls: %d > %d", len(calls), maxCallManyCalls)
+	}
+	state, header, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	if err := overrides.Apply(state); err != nil {
+		return nil, err
+	}
+	// The timeout applies to the whole batch rather than to every single call.
+	var (
+		timeout = s.b.RPCEVMTimeout()
+		cancel  context.CancelFunc
+	)
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	var (
+		budget  = apikeys.GasCap(ctx, s.b.RPCGasCap())
+		left    = budget
+		results = make([]CallManyResult, 0, len(calls))
+	)
+	for i, call := range calls {
+		// A zero cap would mean unlimited gas, so stop before handing it out
+		if budget != 0 && left == 0 {
+			return nil, fmt.Errorf("call %d: batch gas budget of %d exhausted", i, budget)
+		}
+		if err := call.StateOverrides.Apply(state); err != nil {
+			return nil, fmt.Errorf("call %d: %w", i, err)
+		}
+		// Every call starts with a fresh access list and transient storage, like
+		// a transaction of its own
+		state.Prepare(common.Hash{}, i)
+
+		result, err := doCall(ctx, s.b, call.TransactionArgs, state, header, timeout, left)
+		if err != nil {
+			// Consensus errors (nonce, balance, etc) leave the state untouched,
+			// report them and carry on with the rest of the batch.
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			results = append(results, CallManyResult{Error: err.Error()})
+			continue
+		}
+		if budget != 0 {
+			left -= result.UsedGas
+		}
+		res := CallManyResult{
+			ReturnData: result.Return(),
+			GasUsed:    hexutil.Uint64(result.UsedGas),
+		}
+		if len(result.Revert()) > 0 {
+			revert := newRevertError(result)
+			res.Error, res.Revert = revert.Error(), revert.reason
+		} else if result.Err != nil {
+			res.Error = result.Err.Error()
+		}
+		results = append(results, res)
+
+		// Finalise the call so that refunds and self-destructs are settled
+		// before the next call of the batch executes.
+		state.Finalise(true)
+	}
+	return results, nil
+}
+
 func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, gasCap uint64) (hexutil.Uint64, error) {
+	gasCap = apikeys.GasCap(ctx, gasCap)
+
 	// Binary search the gas requirement, as it may be higher than the amount used
 	var (
 		lo  uint64 = params.TxGas - 1
@@ -1114,6 +1451,56 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args TransactionA
 	return DoEstimateGas(ctx, s.b, args, bNrOrHash, s.b.RPCGasCap())
 }
 
+// PublicScrollAPI provides an API to access rollup specific information.
+type PublicScrollAPI struct {
+	b Backend
+}
+
+// NewPublicScrollAPI creates a new rollup API.
+func NewPublicScrollAPI(b Backend) *PublicScrollAPI {
+	return &PublicScrollAPI{b}
+}
+
+// WitnessSizeResult is the estimated witness size of a transaction.
+type WitnessSizeResult struct {
+	Accounts  hexutil.Uint64 `json:"accounts"`
+	Slots     hexutil.Uint64 `json:"slots"`
+	TrieNodes hexutil.Uint64 `json:"trieNodes"`
+	CodeBytes hexutil.Uint64 `json:"codeBytes"`
+}
+
+// EstimateWitnessSize returns an estimate of the number of trie nodes and code
+// bytes needed to prove the execution of the given transaction against the
+// current pending block.
+func (s *PublicScrollAPI) EstimateWitnessSize(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash) (*WitnessSizeResult, error) {
+	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
+	if blockNrOrHash != nil {
+		bNrOrHash = *blockNrOrHash
+	}
+	state, header, err := s.b.StateAndHeaderByNumberOrHash(ctx, bNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	msg, err := args.ToMessage(s.b.RPCGasCap(), header.BaseFee)
+	if err != nil {
+		return nil, err
+	}
+	evm, _, err := s.b.GetEVM(ctx, msg, state, header, &vm.Config{NoBaseFee: true})
+	if err != nil {
+		return nil, err
+	}
+	size, err := core.EstimateWitnessSize(s.b.ChainConfig(), evm.Context, state, msg, vm.Config{NoBaseFee: true})
+	if err != nil {
+		return nil, err
+	}
+	return &WitnessSizeResult{
+		Accounts:  hexutil.Uint64(size.Accounts),
+		Slots:     hexutil.Uint64(size.Slots),
+		TrieNodes: hexutil.Uint64(size.TrieNodes),
+		CodeBytes: hexutil.Uint64(size.CodeBytes),
+	}, nil
+}
+
 // RPCMarshalHeader converts the given header to the RPC output .
 func RPCMarshalHeader(head *types.Header) map[string]interface{} {
 	result := map[string]interface{}{
@@ -1221,6 +1608,10 @@ type RPCTransaction struct {
 	V                *hexutil.Big      `json:"v"`
 	R                *hexutil.Big      `json:"r"`
 	S                *hexutil.Big      `json:"s"`
+	FeePayer         *common.Address   `json:"feePayer,omitempty"`
+	FeePayerV        *hexutil.Big      `json:"feePayerV,omitempty"`
+	FeePayerR        *hexutil.Big      `json:"feePayerR,omitempty"`
+	FeePayerS        *hexutil.Big      `json:"feePayerS,omitempty"`
 }
 
 // newRPCTransaction returns a transaction that will serialize to the RPC
@@ -1253,7 +1644,7 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 		al := tx.AccessList()
 		result.Accesses = &al
 		result.ChainID = (*hexutil.Big)(tx.ChainId())
-	case types.DynamicFeeTxType:
+	case types.DynamicFeeTxType, types.FeePayerTxType:
 		al := tx.AccessList()
 		result.Accesses = &al
 		result.ChainID = (*hexutil.Big)(tx.ChainId())
@@ -1267,6 +1658,13 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 		} else {
 			result.GasPrice = (*hexutil.Big)(tx.GasFeeCap())
 		}
+		if tx.Type() == types.FeePayerTxType {
+			fv, fr, fs := tx.RawFeePayerSignatureValues()
+			result.FeePayer = tx.FeePayer()
+			result.FeePayerV = (*hexutil.Big)(fv)
+			result.FeePayerR = (*hexutil.Big)(fr)
+			result.FeePayerS = (*hexutil.Big)(fs)
+		}
 	}
 	return result
 }
@@ -1517,6 +1915,31 @@ func (s *PublicTransactionPoolAPI) GetTransactionByHash(ctx context.Context, has
 	return nil, nil
 }
 
+// GetTransactionBySenderAndNonce returns the transaction sent by the given account
+// with the given nonce. Mined transactions are looked up in the sender and nonce
+// index, while transactions still waiting in the pool, e.g. stuck ones pending a
+// replacement, are returned as pending.
+func (s *PublicTransactionPoolAPI) GetTransactionBySenderAndNonce(ctx context.Context, sender common.Address, nonce hexutil.Uint64) (*RPCTransaction, error) {
+	// Try to return an already finalized transaction
+	tx, blockHash, blockNumber, index, indexErr := s.b.GetTransactionBySenderAndNonce(ctx, sender, uint64(nonce))
+	if tx != nil {
+		header, err := s.b.HeaderByHash(ctx, blockHash)
+		if err != nil {
+			return nil, err
+		}
+		return newRPCTransaction(tx, blockHash, blockNumber, index, header.BaseFee, s.b.ChainConfig()), nil
+	}
+	// No finalized transaction, try to retrieve it from the pool
+	pending, queued := s.b.TxPoolContentFrom(sender)
+	for _, tx := range append(pending, queued...) {
+		if tx.Nonce() == uint64(nonce) {
+			return newRPCPendingTransaction(tx, s.b.CurrentHeader(), s.b.ChainConfig()), nil
+		}
+	}
+	// Transaction unknown, report if the index could not be consulted
+	return nil, indexErr
+}
+
 // GetRawTransactionByHash returns the bytes of the transaction for the given hash.
 func (s *PublicTransactionPoolAPI) GetRawTransactionByHash(ctx context.Context, hash common.Hash) (hexutil.Bytes, error) {
 	// Retrieve a finalized transaction, or a pooled otherwise
@@ -1549,15 +1972,57 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 	}
 	receipt := receipts[index]
 
-	// Derive the sender.
-	bigblock := new(big.Int).SetUint64(blockNumber)
-	signer := types.MakeSigner(s.b.ChainConfig(), bigblock)
+	// Derive the base fee needed for the effective gas price
+	var baseFee *big.Int
+	if s.b.ChainConfig().IsLondon(new(big.Int).SetUint64(blockNumber)) {
+		header, err := s.b.HeaderByHash(ctx, blockHash)
+		if err != nil {
+			return nil, err
+		}
+		baseFee = header.BaseFee
+	}
+	signer := types.MakeSigner(s.b.ChainConfig(), new(big.Int).SetUint64(blockNumber))
+	return marshalReceipt(receipt, blockHash, blockNumber, signer, tx, index, baseFee), nil
+}
+
+// GetBlockReceipts returns the receipts of all the transactions in a block,
+// saving the need to query them one by one.
+func (s *PublicTransactionPoolAPI) GetBlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]map[string]interface{}, error) {
+	block, err := s.b.BlockByNumberOrHash(ctx, blockNrOrHash)
+	if block == nil || err != nil {
+		return nil, err
+	}
+	receipts, err := s.b.GetReceipts(ctx, block.Hash())
+	if err != nil {
+		return nil, err
+	}
+	txs := block.Transactions()
+	if len(txs) != len(receipts) {
+		return nil, fmt.Errorf("receipts length mismatch: %d vs %d", len(txs), len(receipts))
+	}
+	var baseFee *big.Int
+	if s.b.ChainConfig().IsLondon(block.Number()) {
+		baseFee = block.BaseFee()
+	}
+	signer := types.MakeSigner(s.b.ChainConfig(), block.Number())
+
+	result := make([]map[string]interface{}, len(receipts))
+	for i, receipt := range receipts {
+		result[i] = marshalReceipt(receipt, block.Hash(), block.NumberU64(), signer, txs[i], uint64(i), baseFee)
+	}
+	return result, nil
+}
+
+// marshalReceipt converts a receipt into the RPC representation, filling the
+// fields not stored in the receipt itself from its transaction and block. The
+// base fee must be nil for blocks before London.
+func marshalReceipt(receipt *types.Receipt, blockHash common.Hash, blockNumber uint64, signer types.Signer, tx *types.Transaction, index uint64, baseFee *big.Int) map[string]interface{} {
 	from, _ := types.Sender(signer, tx)
 
 	fields := map[string]interface{}{
 		"blockHash":         blockHash,
 		"blockNumber":       hexutil.Uint64(blockNumber),
-		"transactionHash":   hash,
+		"transactionHash":   tx.Hash(),
 		"transactionIndex":  hexutil.Uint64(index),
 		"from":              from,
 		"to":                tx.To(),
@@ -1569,14 +2034,10 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 		"type":              hexutil.Uint(tx.Type()),
 	}
 	// Assign the effective gas price paid
-	if !s.b.ChainConfig().IsLondon(bigblock) {
+	if baseFee == nil {
 		fields["effectiveGasPrice"] = hexutil.Uint64(tx.GasPrice().Uint64())
 	} else {
-		header, err := s.b.HeaderByHash(ctx, blockHash)
-		if err != nil {
-			return nil, err
-		}
-		gasPrice := new(big.Int).Add(header.BaseFee, tx.EffectiveGasTipValue(header.BaseFee))
+		gasPrice := new(big.Int).Add(baseFee, tx.EffectiveGasTipValue(baseFee))
 		fields["effectiveGasPrice"] = hexutil.Uint64(gasPrice.Uint64())
 	}
 	// Assign receipt status or post state.
@@ -1592,7 +2053,12 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 	if receipt.ContractAddress != (common.Address{}) {
 		fields["contractAddress"] = receipt.ContractAddress
 	}
-	return fields, nil
+	// Fee payer transactions report the account that paid for the gas
+	if tx.Type() == types.FeePayerTxType {
+		payer, _ := types.FeePayer(signer, tx)
+		fields["feePayer"] = payer
+	}
+	return fields
 }
 
 // sign is a helper function that signs a transaction with the private key of the given address.
@@ -1608,6 +2074,24 @@ func (s *PublicTransactionPoolAPI) sign(addr common.Address, tx *types.Transacti
 	return wallet.SignTx(account, tx, s.b.ChainConfig().ChainID)
 }
 
+// txTypeError is an API error returned when a transaction is rejected because
+// its type isn't activated on the chain yet.
+type txTypeError struct {
+	error
+	txType uint8
+}
+
+// ErrorCode returns the JSON error code for a rejected transaction.
+// See: https://eips.ethereum.org/EIPS/eip-1474
+func (e *txTypeError) ErrorCode() int {
+	return -32003
+}
+
+// ErrorData returns the type of the rejected transaction.
+func (e *txTypeError) ErrorData() interface{} {
+	return hexutil.Uint64(e.txType)
+}
+
 // SubmitTransaction is a helper function that submits tx to txPool and logs a message.
 func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (common.Hash, error) {
 	// If the transaction fee cap is already specified, ensure the
@@ -1620,6 +2104,9 @@ func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (c
 		return common.Hash{}, errors.New("only replay-protected (EIP-155) transactions allowed over RPC")
 	}
 	if err := b.SendTx(ctx, tx); err != nil {
+		if errors.Is(err, core.ErrTxTypeNotSupported) {
+			return common.Hash{}, &txTypeError{error: err, txType: tx.Type()}
+		}
 		return common.Hash{}, err
 	}
 	// Print a log with full tx details for manual investigations and interventions
@@ -1672,7 +2159,9 @@ func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args Tra
 
 // FillTransaction fills the defaults (nonce, gas, gasPrice or 1559 fields)
 // on a given unsigned transaction, and returns it to the caller for further
-// processing (signing + broadcast).
+// processing (signing + broadcast). Fee fields are priced against the base fee
+// of the next block, honoring the base fee oracle, and the tip suggested by
+// the gas price oracle.
 func (s *PublicTransactionPoolAPI) FillTransaction(ctx context.Context, args TransactionArgs) (*SignTransactionResult, error) {
 	// Set some sanity defaults and terminate on failure
 	if err := args.setDefaults(ctx, s.b); err != nil {
@@ -1958,6 +2447,18 @@ func (api *PrivateDebugAPI) ChaindbCompact() error {
 	return nil
 }
 
+// DbAncient retrieves an ancient binary blob from the append-only immutable files.
+// It is a mapping to the `AncientReaderOp.Ancient` method
+func (api *PrivateDebugAPI) DbAncient(kind string, number uint64) (hexutil.Bytes, error) {
+	return api.b.ChainDb().Ancient(kind, number)
+}
+
+// DbAncients returns the ancient item numbers in the ancient store.
+// It is a mapping to the `AncientReaderOp.Ancients` method
+func (api *PrivateDebugAPI) DbAncients() (uint64, error) {
+	return api.b.ChainDb().Ancients()
+}
+
 // SetHead rewinds the head of the blockchain to a previous block.
 func (api *PrivateDebugAPI) SetHead(number hexutil.Uint64) {
 	api.b.SetHead(uint64(number))
@@ -0,0 +1,315 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/go-ethereum/consensus/ethash"
+	"github.com/scroll-tech/go-ethereum/core"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/core/vm"
+	"github.com/scroll-tech/go-ethereum/params"
+	"github.com/scroll-tech/go-ethereum/rpc"
+)
+
+var (
+	callManySender  = common.HexToAddress("0x1001")
+	callManyCounter = common.HexToAddress("0x1002")
+	callManyBurner  = common.HexToAddress("0x1003")
+	callManyReader  = common.HexToAddress("0x1004")
+)
+
+// newCallManyAPI creates a blockchain API on top of a chain with a counter
+// contract, a contract burning all the gas it's given and one reading a slot.
+func newCallManyAPI(t *testing.T, gasCap uint64) *PublicBlockChainAPI {
+	var (
+		// Increments slot 0 and returns the new value:
+		// PUSH1 0 SLOAD PUSH1 1 ADD DUP1 PUSH1 0 SSTORE PUSH1 0 MSTORE
+		// PUSH1 32 PUSH1 0 RETURN
+		counter = common.FromHex("0x6000546001018060005560005260206000f3")
+
+		// Loops forever: JUMPDEST PUSH1 0 JUMP
+		burner = common.FromHex("0x5b600056")
+
+		// Reads slot 0: PUSH1 0 SLOAD POP STOP
+		reader = common.FromHex("0x6000545000")
+	)
+	db := rawdb.NewMemoryDatabase()
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			callManyCounter: {Balance: common.Big0, Code: counter},
+			callManyBurner:  {Balance: common.Big0, Code: burner},
+			callManyReader:  {Balance: common.Big0, Code: reader},
+		},
+	}
+	gspec.MustCommit(db)
+	chain, _ := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	t.Cleanup(chain.Stop)
+
+	return NewPublicBlockChainAPI(&simBackend{chain: chain, gasCap: gasCap})
+}
+
+func TestCallMany(t *testing.T) {
+	api := newCallManyAPI(t, 0)
+
+	// Every call observes the state changes of the previous ones, and per call
+	// overrides are applied right before the call itself.
+	var (
+		call  = CallManyArgs{TransactionArgs: TransactionArgs{From: &callManySender, To: &callManyCounter}}
+		reset = CallManyArgs{
+			TransactionArgs: TransactionArgs{From: &callManySender, To: &callManyCounter},
+			StateOverrides: &StateOverride{callManyCounter: OverrideAccount{
+				StateDiff: &map[common.Hash]common.Hash{{}: common.BigToHash(big.NewInt(10))},
+			}},
+		}
+	)
+	results, err := api.CallMany(context.Background(), []CallManyArgs{call, call, reset, call}, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil)
+	if err != nil {
+		t.Fatalf("failed to execute calls: %v", err)
+	}
+	for i, want := range []int64{1, 2, 11, 12} {
+		if results[i].Error != "" {
+			t.Fatalf("call %d: failed: %v", i, results[i].Error)
+		}
+		if have := new(big.Int).SetBytes(results[i].ReturnData).Int64(); have != want {
+			t.Errorf("call %d: counter mismatch: have %d, want %d", i, have, want)
+		}
+	}
+}
+
+// Tests that every call of a batch starts with a cold access list, so that slots
+// read by a call are charged as cold again in the next one.
+func TestCallManyAccessList(t *testing.T) {
+	api := newCallManyAPI(t, 0)
+
+	read := CallManyArgs{TransactionArgs: TransactionArgs{From: &callManySender, To: &callManyReader}}
+	results, err := api.CallMany(context.Background(), []CallManyArgs{read, read}, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil)
+	if err != nil {
+		t.Fatalf("failed to execute calls: %v", err)
+	}
+	want := params.TxGas + 3 + params.ColdSloadCostEIP2929 + 2 // PUSH1, cold SLOAD, POP
+	for i, result := range results {
+		if result.Error != "" {
+			t.Fatalf("call %d: failed: %v", i, result.Error)
+		}
+		if uint64(result.GasUsed) != want {
+			t.Errorf("call %d: gas used mismatch: have %d, want %d", i, result.GasUsed, want)
+		}
+	}
+}
+
+func TestCallManyLimits(t *testing.T) {
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+
+	// Batches above the call limit are rejected up front
+	api := newCallManyAPI(t, 0)
+	calls := make([]CallManyArgs, maxCallManyCalls+1)
+	for i := range calls {
+		calls[i] = CallManyArgs{TransactionArgs: TransactionArgs{From: &callManySender, To: &callManyCounter}}
+	}
+	if _, err := api.CallMany(context.Background(), calls, latest, nil); err == nil || !strings.Contains(err.Error(), "too many calls") {
+		t.Errorf("oversized batch error mismatch: have %v, want too many calls", err)
+	}
+	if _, err := api.CallMany(context.Background(), calls[:maxCallManyCalls], latest, nil); err != nil {
+		t.Errorf("failed to execute full batch: %v", err)
+	}
+
+	// The gas cap is shared by the whole batch: every call is capped to the gas
+	// left over by the previous ones, and the batch fails once nothing is left.
+	api = newCallManyAPI(t, 1000000)
+
+	gas := hexutil.Uint64(600000)
+	burn := CallManyArgs{TransactionArgs: TransactionArgs{From: &callManySender, To: &callManyBurner, Gas: &gas}}
+
+	results, err := api.CallMany(context.Background(), []CallManyArgs{burn, burn}, latest, nil)
+	if err != nil {
+		t.Fatalf("failed to execute calls: %v", err)
+	}
+	for i, want := range []uint64{600000, 400000} {
+		if results[i].Error == "" {
+			t.Errorf("call %d: expected out of gas failure", i)
+		}
+		if uint64(results[i].GasUsed) != want {
+			t.Errorf("call %d: gas used mismatch: have %d, want %d", i, results[i].GasUsed, want)
+		}
+	}
+	if _, err := api.CallMany(context.Background(), []CallManyArgs{burn, burn, burn}, latest, nil); err == nil || !strings.Contains(err.Error(), "budget") {
+		t.Errorf("exhausted budget error mismatch: have %v, want budget exhausted", err)
+	}
+}
+
+// txPoolBackend is a Backend serving just a fixed transaction pool content.
+type txPoolBackend struct {
+	Backend
+	pending map[common.Address]types.Transactions
+	queued  map[common.Address]types.Transactions
+}
+
+func (b *txPoolBackend) TxPoolContent() (map[common.Address]types.Transactions, map[common.Address]types.Transactions) {
+	return b.pending, b.queued
+}
+
+func (b *txPoolBackend) TxPoolContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
+	return b.pending[addr], b.queued[addr]
+}
+
+func (b *txPoolBackend) TxPoolPending() map[common.Address]types.Transactions {
+	return b.pending
+}
+
+func TestTxPoolInspectFiltered(t *testing.T) {
+	var (
+		alice = common.HexToAddress("0xa1")
+		bob   = common.HexToAddress("0xb0")
+		now   = time.Now()
+	)
+	// poolTx creates a transaction with the given nonce and gas price, first seen
+	// the given number of minutes ago.
+	poolTx := func(nonce uint64, price int64, minutes int) *types.Transaction {
+		tx := types.NewTransaction(nonce, common.Address{0xff}, big.NewInt(1), 21000, big.NewInt(price), nil)
+		tx.SetTime(now.Add(-time.Duration(minutes) * time.Minute))
+		return tx
+	}
+	api := NewPublicTxPoolAPI(&txPoolBackend{
+		pending: map[common.Address]types.Transactions{
+			alice: {poolTx(0, 10, 60), poolTx(1, 20, 30)},
+			bob:   {poolTx(0, 30, 5)},
+		},
+		queued: map[common.Address]types.Transactions{
+			alice: {poolTx(3, 40, 1)},
+		},
+	})
+	var (
+		addr  = func(a common.Address) *common.Address { return &a }
+		price = func(p int64) *hexutil.Big { return (*hexutil.Big)(big.NewInt(p)) }
+		age   = func(minutes uint64) *hexutil.Uint64 { v := hexutil.Uint64(minutes * 60); return &v }
+	)
+	// nonces lists the expected nonces per account, pending and queued
+	type nonces struct {
+		pending map[common.Address][]string
+		queued  map[common.Address][]string
+	}
+	tests := []struct {
+		name   string
+		filter TxPoolFilter
+		want   nonces
+	}{
+		{
+			name:   "empty",
+			filter: TxPoolFilter{},
+			want: nonces{
+				pending: map[common.Address][]string{alice: {"0", "1"}, bob: {"0"}},
+				queued:  map[common.Address][]string{alice: {"3"}},
+			},
+		},
+		{
+			name:   "from",
+			filter: TxPoolFilter{From: addr(bob)},
+			want:   nonces{pending: map[common.Address][]string{bob: {"0"}}},
+		},
+		{
+			name:   "from unknown",
+			filter: TxPoolFilter{From: addr(common.HexToAddress("0xcc"))},
+		},
+		{
+			name:   "min gas price",
+			filter: TxPoolFilter{MinGasPrice: price(20)},
+			want: nonces{
+				pending: map[common.Address][]string{alice: {"1"}, bob: {"0"}},
+				queued:  map[common.Address][]string{alice: {"3"}},
+			},
+		},
+		{
+			name:   "max gas price",
+			filter: TxPoolFilter{MaxGasPrice: price(20)},
+			want:   nonces{pending: map[common.Address][]string{alice: {"0", "1"}}},
+		},
+		{
+			name:   "min age",
+			filter: TxPoolFilter{MinAge: age(10)},
+			want:   nonces{pending: map[common.Address][]string{alice: {"0", "1"}}},
+		},
+		{
+			name:   "max age",
+			filter: TxPoolFilter{MaxAge: age(10)},
+			want: nonces{
+				pending: map[common.Address][]string{bob: {"0"}},
+				queued:  map[common.Address][]string{alice: {"3"}},
+			},
+		},
+		{
+			name:   "gas price range",
+			filter: TxPoolFilter{MinGasPrice: price(15), MaxGasPrice: price(35)},
+			want:   nonces{pending: map[common.Address][]string{alice: {"1"}, bob: {"0"}}},
+		},
+		{
+			name:   "age range",
+			filter: TxPoolFilter{MinAge: age(2), MaxAge: age(45)},
+			want:   nonces{pending: map[common.Address][]string{alice: {"1"}, bob: {"0"}}},
+		},
+		{
+			name:   "sender and gas price",
+			filter: TxPoolFilter{From: addr(alice), MinGasPrice: price(15)},
+			want: nonces{
+				pending: map[common.Address][]string{alice: {"1"}},
+				queued:  map[common.Address][]string{alice: {"3"}},
+			},
+		},
+		{
+			name:   "all fields",
+			filter: TxPoolFilter{From: addr(alice), MinGasPrice: price(5), MaxGasPrice: price(35), MinAge: age(10), MaxAge: age(50)},
+			want:   nonces{pending: map[common.Address][]string{alice: {"1"}}},
+		},
+		{
+			name:   "disjoint",
+			filter: TxPoolFilter{MinGasPrice: price(30), MinAge: age(10)},
+		},
+	}
+	for _, tt := range tests {
+		content := api.InspectFiltered(tt.filter)
+		for _, kind := range []string{"pending", "queued"} {
+			want := tt.want.pending
+			if kind == "queued" {
+				want = tt.want.queued
+			}
+			have := make(map[common.Address][]string)
+			for account, txs := range content[kind] {
+				for nonce := range txs {
+					have[common.HexToAddress(account)] = append(have[common.HexToAddress(account)], nonce)
+				}
+				sort.Strings(have[common.HexToAddress(account)])
+			}
+			if len(have) == 0 && len(want) == 0 {
+				continue
+			}
+			if !reflect.DeepEqual(have, want) {
+				t.Errorf("%s: %s transactions mismatch: have %v, want %v", tt.name, kind, have, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/scroll-tech/go-ethereum/metrics"
+)
+
+var (
+	callCacheHitMeter    = metrics.NewRegisteredMeter("rpc/callcache/hit", nil)
+	callCacheMissMeter   = metrics.NewRegisteredMeter("rpc/callcache/miss", nil)
+	callCacheBypassMeter = metrics.NewRegisteredMeter("rpc/callcache/bypass", nil)
+	callCacheSizeGauge   = metrics.NewRegisteredGauge("rpc/callcache/size", nil)
+)
+
+// callCacheEntry is the memoized outcome of an eth_call.
+type callCacheEntry struct {
+	result  hexutil.Bytes
+	err     error // Execution error of the call, e.g. a revert
+	expires time.Time
+}
+
+// CallCache memoizes the results of eth_call by block hash and call parameters.
+// The state of a block never changes, so an identical call at the same block
+// always yields the same result. Entries are evicted after a TTL, or when the
+// cache is full, in least recently used order.
+type CallCache struct {
+	entries *lru.Cache
+	ttl     time.Duration
+}
+
+// NewCallCache creates a call cache of the given number of entries, returning
+// nil if the size is not positive.
+func NewCallCache(size int, ttl time.Duration) *CallCache {
+	if size <= 0 {
+		return nil
+	}
+	entries, _ := lru.New(size)
+	return &CallCache{entries: entries, ttl: ttl}
+}
+
+// callCacheKey derives the cache key of a call at a block. The gas cap is part
+// of the key, as it depends on the API key of the caller.
+func callCacheKey(block common.Hash, args *TransactionArgs, overrides *StateOverride, gasCap uint64) (common.Hash, error) {
+	blob, err := json.Marshal([]interface{}{args, overrides})
+	if err != nil {
+		return common.Hash{}, err
+	}
+	var cap [8]byte
+	binary.BigEndian.PutUint64(cap[:], gasCap)
+	return crypto.Keccak256Hash(block.Bytes(), cap[:], blob), nil
+}
+
+// get retrieves the outcome of a call, if cached and not expired.
+func (c *CallCache) get(key common.Hash) (*callCacheEntry, bool) {
+	if c == nil {
+		return nil, false
+	}
+	cached, ok := c.entries.Get(key)
+	if !ok {
+		callCacheMissMeter.Mark(1)
+		return nil, false
+	}
+	entry := cached.(*callCacheEntry)
+	if c.ttl > 0 && time.Now().After(entry.expires) {
+		c.entries.Remove(key)
+		callCacheMissMeter.Mark(1)
+		return nil, false
+	}
+	callCacheHitMeter.Mark(1)
+	return entry, true
+}
+
+// put stores the outcome of a call.
+func (c *CallCache) put(key common.Hash, result hexutil.Bytes, err error) {
+	if c == nil {
+		return
+	}
+	c.entries.Add(key, &callCacheEntry{result: result, err: err, expires: time.Now().Add(c.ttl)})
+	callCacheSizeGauge.Update(int64(c.entries.Len()))
+}
+
+// bypassCallCache reports whether the caller asked for a fresh execution with a
+// "Cache-Control: no-cache" header.
+func bypassCallCache(ctx context.Context) bool {
+	control, _ := ctx.Value("Cache-Control").(string)
+	for _, directive := range strings.Split(control, ",") {
+		if directive = strings.TrimSpace(directive); directive == "no-cache" || directive == "no-store" {
+			callCacheBypassMeter.Mark(1)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+)
+
+// Tests that call results are memoized per block, parameters and gas cap, and
+// expire after the TTL.
+func TestCallCache(t *testing.T) {
+	var (
+		cache  = NewCallCache(16, 50*time.Millisecond)
+		to     = common.HexToAddress("0x01")
+		input  = hexutil.Bytes{0x01, 0x02}
+		args   = TransactionArgs{To: &to, Input: &input}
+		block1 = common.HexToHash("0x01")
+		block2 = common.HexToHash("0x02")
+	)
+	key, err := callCacheKey(block1, &args, nil, 1000)
+	if err != nil {
+		t.Fatalf("failed to derive key: %v", err)
+	}
+	for _, other := range []struct {
+		block  common.Hash
+		input  hexutil.Bytes
+		gasCap uint64
+	}{
+		{block2, input, 1000},
+		{block1, hexutil.Bytes{0x01}, 1000},
+		{block1, input, 2000},
+	} {
+		args := TransactionArgs{To: &to, Input: &other.input}
+		if have, _ := callCacheKey(other.block, &args, nil, other.gasCap); have == key {
+			t.Errorf("key collision: %x", have)
+		}
+	}
+	if _, ok := cache.get(key); ok {
+		t.Fatalf("empty cache returned an entry")
+	}
+	reverted := errors.New("execution reverted")
+	cache.put(key, hexutil.Bytes{0xff}, reverted)
+
+	entry, ok := cache.get(key)
+	if !ok {
+		t.Fatalf("memoized entry missing")
+	}
+	if !bytes.Equal(entry.result, []byte{0xff}) || entry.err != reverted {
+		t.Errorf("memoized entry mismatch: have %x, %v", entry.result, entry.err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, ok := cache.get(key); ok {
+		t.Errorf("expired entry returned")
+	}
+	// A disabled cache can be used unconditionally
+	var disabled *CallCache
+	disabled.put(key, nil, nil)
+	if _, ok := disabled.get(key); ok {
+		t.Errorf("disabled cache returned an entry")
+	}
+}
+
+// Tests that the cache is bypassed on request.
+func TestCallCacheBypass(t *testing.T) {
+	tests := []struct {
+		control string
+		bypass  bool
+	}{
+		{"", false},
+		{"max-age=0", false},
+		{"no-cache", true},
+		{"max-age=0, no-store", true},
+	}
+	for _, tt := range tests {
+		ctx := context.Background()
+		if tt.control != "" {
+			ctx = context.WithValue(ctx, "Cache-Control", tt.control)
+		}
+		if have := bypassCallCache(ctx); have != tt.bypass {
+			t.Errorf("%q: bypass mismatch: have %v, want %v", tt.control, have, tt.bypass)
+		}
+	}
+}
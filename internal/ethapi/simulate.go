@@ -0,0 +1,439 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/go-ethereum/consensus"
+	"github.com/scroll-tech/go-ethereum/core"
+	"github.com/scroll-tech/go-ethereum/core/state"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/core/vm"
+	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/miner"
+	"github.com/scroll-tech/go-ethereum/rpc"
+	"github.com/scroll-tech/go-ethereum/trie"
+)
+
+const (
+	// maxSimulateBlocks is the maximum number of blocks that can be simulated
+	// in a single eth_simulateV1 request.
+	maxSimulateBlocks = 256
+
+	// simulateTimestampIncrement is the default timestamp increment between
+	// consecutive simulated blocks if no override is given.
+	simulateTimestampIncrement = 12
+)
+
+// BlockOverrides is a set of header fields to override when simulating a block.
+type BlockOverrides struct {
+	Number        *hexutil.Big    `json:"number"`
+	Time          *hexutil.Uint64 `json:"time"`
+	GasLimit      *hexutil.Uint64 `json:"gasLimit"`
+	FeeRecipient  *common.Address `json:"feeRecipient"`
+	PrevRandao    *common.Hash    `json:"prevRandao"`
+	BaseFeePerGas *hexutil.Big    `json:"baseFeePerGas"`
+}
+
+// apply overrides the fields of the given header.
+func (o *BlockOverrides) apply(header *types.Header) {
+	if o == nil {
+		return
+	}
+	if o.Number != nil {
+		header.Number = o.Number.ToInt()
+	}
+	if o.Time != nil {
+		header.Time = uint64(*o.Time)
+	}
+	if o.GasLimit != nil {
+		header.GasLimit = uint64(*o.GasLimit)
+	}
+	if o.FeeRecipient != nil {
+		header.Coinbase = *o.FeeRecipient
+	}
+	if o.PrevRandao != nil {
+		header.MixDigest = *o.PrevRandao
+	}
+	if o.BaseFeePerGas != nil {
+		header.BaseFee = o.BaseFeePerGas.ToInt()
+	}
+}
+
+// SimBlock is a batch of calls to be simulated in a single block, along with
+// the block header and state overrides to apply beforehand.
+type SimBlock struct {
+	BlockOverrides *BlockOverrides   `json:"blockOverrides"`
+	StateOverrides *StateOverride    `json:"stateOverrides"`
+	Calls          []TransactionArgs `json:"calls"`
+}
+
+// SimOpts are the inputs to eth_simulateV1.
+type SimOpts struct {
+	BlockStateCalls        []SimBlock `json:"blockStateCalls"`
+	Validation             bool       `json:"validation"`
+	ReturnFullTransactions bool       `json:"returnFullTransactions"`
+}
+
+// simCallResult is the result of a single simulated call.
+type simCallResult struct {
+	ReturnValue hexutil.Bytes  `json:"returnData"`
+	Logs        []*types.Log   `json:"logs"`
+	GasUsed     hexutil.Uint64 `json:"gasUsed"`
+	Status      hexutil.Uint64 `json:"status"`
+	Error       *callError     `json:"error,omitempty"`
+}
+
+// callError is the error of a failed simulated call.
+type callError struct {
+	Message string `json:"message"`
+	Code    int    `json:"code"`
+	Data    string `json:"data,omitempty"`
+}
+
+// simulator is a stateful object that simulates a series of blocks on top of
+// a base block.
+type simulator struct {
+	b           Backend
+	state       *state.StateDB
+	base        *types.Header
+	validate    bool
+	fullTx      bool
+	timeout     time.Duration
+	gasCap      uint64
+	hashes      map[uint64]common.Hash         // hashes of the already simulated blocks
+	canonical   vm.GetHashFunc                 // hashes of the ancestors of the base block
+	senders     map[common.Hash]common.Address // senders of the unsigned simulated txs
+	nonceCursor map[common.Address]uint64      // next nonces of the senders of included calls
+}
+
+// simChainContext resolves the ancestors of the simulation base block.
+type simChainContext struct {
+	ctx context.Context
+	b   Backend
+}
+
+func (c *simChainContext) Engine() consensus.Engine { return c.b.Engine() }
+
+func (c *simChainContext) GetHeader(hash common.Hash, number uint64) *types.Header {
+	header, _ := c.b.HeaderByHash(c.ctx, hash)
+	return header
+}
+
+// SimulateV1 executes a series of blocks of calls on top of the given base
+// block. Each block can override header fields and account state, and every
+// block observes the state changes made by the previous ones.
+//
+// Note, the simulated blocks are never persisted nor announced.
+func (s *PublicBlockChainAPI) SimulateV1(ctx context.Context, opts SimOpts, blockNrOrHash *rpc.BlockNumberOrHash) ([]map[string]interface{}, error) {
+	if len(opts.BlockStateCalls) == 0 {
+		return nil, errors.New("empty input")
+	} else if len(opts.BlockStateCalls) > maxSimulateBlocks {
+		return nil, fmt.Errorf("too many blocks: %d > %d", len(opts.BlockStateCalls), maxSimulateBlocks)
+	}
+	if blockNrOrHash == nil {
+		n := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &n
+	}
+	state, base, err := s.b.StateAndHeaderByNumberOrHash(ctx, *blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	// Resolve the ancestors of the base block as seen from its child
+	child := &types.Header{ParentHash: base.Hash(), Number: new(big.Int).Add(base.Number, common.Big1)}
+	sim := &simulator{
+		b:           s.b,
+		state:       state,
+		base:        base,
+		validate:    opts.Validation,
+		fullTx:      opts.ReturnFullTransactions,
+		timeout:     s.b.RPCEVMTimeout(),
+		gasCap:      s.b.RPCGasCap(),
+		hashes:      make(map[uint64]common.Hash),
+		canonical:   core.GetHashFn(child, &simChainContext{ctx: ctx, b: s.b}),
+		senders:     make(map[common.Hash]common.Address),
+		nonceCursor: make(map[common.Address]uint64),
+	}
+	return sim.execute(ctx, opts.BlockStateCalls)
+}
+
+// execute runs the given blocks of calls one after the other.
+func (sim *simulator) execute(ctx context.Context, blocks []SimBlock) ([]map[string]interface{}, error) {
+	// The timeout applies to the whole simulation.
+	var cancel context.CancelFunc
+	if sim.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, sim.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	var (
+		parent  = sim.base
+		results = make([]map[string]interface{}, 0, len(blocks))
+	)
+	for i, block := range blocks {
+		// Fill the block numbers skipped by an override with empty blocks, so
+		// that they are hashed and resolvable like on a real chain.
+		if block.BlockOverrides != nil && block.BlockOverrides.Number != nil {
+			number := block.BlockOverrides.Number.ToInt()
+			for new(big.Int).Add(parent.Number, common.Big1).Cmp(number) < 0 {
+				if len(results)+len(blocks)-i >= maxSimulateBlocks {
+					return nil, fmt.Errorf("too many blocks: more than %d", maxSimulateBlocks)
+				}
+				header, err := sim.makeHeader(parent, nil)
+				if err != nil {
+					return nil, fmt.Errorf("block %d: %w", i, err)
+				}
+				result, sealed, err := sim.processBlock(ctx, header, nil)
+				if err != nil {
+					return nil, fmt.Errorf("block %d: %w", i, err)
+				}
+				results = append(results, result)
+				parent = sealed
+			}
+		}
+		header, err := sim.makeHeader(parent, block.BlockOverrides)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %w", i, err)
+		}
+		if err := block.StateOverrides.Apply(sim.state); err != nil {
+			return nil, fmt.Errorf("block %d: %w", i, err)
+		}
+		result, sealed, err := sim.processBlock(ctx, header, block.Calls)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %w", i, err)
+		}
+		results = append(results, result)
+		parent = sealed
+	}
+	return results, nil
+}
+
+// makeHeader assembles the header of the next simulated block on top of the
+// given parent with the miner's header builder, and applies the overrides. The
+// gas limit is kept at the parent's one, as the miner's target isn't known.
+func (sim *simulator) makeHeader(parent *types.Header, overrides *BlockOverrides) (*types.Header, error) {
+	header := miner.MakeHeader(sim.b.ChainConfig(), parent, sim.state, parent.Time+simulateTimestampIncrement, parent.GasLimit)
+	header.UncleHash = types.EmptyUncleHash
+	header.Coinbase = parent.Coinbase
+	header.Difficulty = new(big.Int)
+	overrides.apply(header)
+
+	if header.Number.Cmp(parent.Number) <= 0 {
+		return nil, fmt.Errorf("block numbers must be in order: %v <= %v", header.Number, parent.Number)
+	}
+	if header.Time <= parent.Time {
+		return nil, fmt.Errorf("block timestamps must be in order: %d <= %d", header.Time, parent.Time)
+	}
+	return header, nil
+}
+
+// processBlock executes the calls of a single simulated block and returns the
+// RPC representation of the resulting block along with its final header.
+func (sim *simulator) processBlock(ctx context.Context, header *types.Header, calls []TransactionArgs) (map[string]interface{}, *types.Header, error) {
+	var (
+		config   = sim.b.ChainConfig()
+		gp       = new(core.GasPool).AddGas(header.GasLimit)
+		txs      = make([]*types.Transaction, 0, len(calls))
+		receipts = make([]*types.Receipt, 0, len(calls))
+		results  = make([]simCallResult, 0, len(calls))
+		gasUsed  uint64
+		vmConfig = &vm.Config{NoBaseFee: !sim.validate}
+	)
+	for i, call := range calls {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, fmt.Errorf("execution aborted (timeout = %v)", sim.timeout)
+		}
+		tx, msg, err := sim.makeCall(call, header, gp.Gas())
+		if err != nil {
+			return nil, nil, fmt.Errorf("call %d: %w", i, err)
+		}
+		sim.state.Prepare(tx.Hash(), len(txs))
+
+		evm, vmError, err := sim.b.GetEVM(ctx, msg, sim.state, header, vmConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		// The chain doesn't know the simulated blocks, resolve their hashes
+		// here and look up the ancestors of the base block starting from it.
+		evm.Context.GetHash = sim.getHash
+		go func() {
+			<-ctx.Done()
+			evm.Cancel()
+		}()
+		result, err := core.ApplyMessage(evm, msg, gp)
+		if err := vmError(); err != nil {
+			return nil, nil, err
+		}
+		if evm.Cancelled() {
+			return nil, nil, fmt.Errorf("execution aborted (timeout = %v)", sim.timeout)
+		}
+		if err != nil {
+			// In validation mode, consensus errors invalidate the whole block.
+			if sim.validate {
+				return nil, nil, fmt.Errorf("call %d: %w", i, err)
+			}
+			results = append(results, simCallResult{
+				ReturnValue: hexutil.Bytes{},
+				Logs:        []*types.Log{},
+				Error:       &callError{Message: err.Error(), Code: -32015},
+			})
+			continue
+		}
+		sim.state.Finalise(config.IsEIP158(header.Number))
+		sim.nonceCursor[msg.From()] = tx.Nonce() + 1
+		gasUsed += result.UsedGas
+
+		receipt := &types.Receipt{
+			Type:              tx.Type(),
+			CumulativeGasUsed: gasUsed,
+			TxHash:            tx.Hash(),
+			GasUsed:           result.UsedGas,
+			Logs:              sim.state.GetLogs(tx.Hash(), common.Hash{}),
+			BlockNumber:       new(big.Int).Set(header.Number),
+			TransactionIndex:  uint(len(txs)),
+		}
+		res := simCallResult{
+			ReturnValue: result.Return(),
+			Logs:        receipt.Logs,
+			GasUsed:     hexutil.Uint64(result.UsedGas),
+		}
+		if result.Failed() {
+			receipt.Status = types.ReceiptStatusFailed
+			if len(result.Revert()) > 0 {
+				revert := newRevertError(result)
+				res.Error = &callError{Message: revert.Error(), Code: revert.ErrorCode(), Data: revert.reason}
+			} else {
+				res.Error = &callError{Message: result.Err.Error(), Code: -32015}
+			}
+		} else {
+			receipt.Status = types.ReceiptStatusSuccessful
+			res.Status = hexutil.Uint64(types.ReceiptStatusSuccessful)
+		}
+		if res.Logs == nil {
+			res.Logs = []*types.Log{}
+		}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+		txs = append(txs, tx)
+		receipts = append(receipts, receipt)
+		results = append(results, res)
+	}
+	header.GasUsed = gasUsed
+	header.Root = sim.state.IntermediateRoot(config.IsEIP158(header.Number))
+
+	block := types.NewBlock(header, txs, nil, receipts, trie.NewStackTrie(nil))
+	hash := block.Hash()
+	sim.hashes[header.Number.Uint64()] = hash
+
+	// Now that the block hash is known, fill it into the emitted logs. The state
+	// numbers the logs across all simulated blocks, renumber them per block.
+	var index uint
+	for _, receipt := range receipts {
+		for _, l := range receipt.Logs {
+			l.BlockHash, l.BlockNumber, l.Index = hash, header.Number.Uint64(), index
+			index++
+		}
+	}
+	fields, err := RPCMarshalBlock(block, true, sim.fullTx, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The simulated transactions are unsigned, patch in the senders.
+	if txs, ok := fields["transactions"].([]interface{}); ok && sim.fullTx {
+		for _, tx := range txs {
+			if rpcTx, ok := tx.(*RPCTransaction); ok {
+				rpcTx.From = sim.senders[rpcTx.Hash]
+			}
+		}
+	}
+	fields["calls"] = results
+	return fields, block.Header(), nil
+}
+
+// getHash returns the hash of the given block number, be it simulated or an
+// ancestor of the simulation base.
+func (sim *simulator) getHash(n uint64) common.Hash {
+	if hash, ok := sim.hashes[n]; ok {
+		return hash
+	}
+	if n > sim.base.Number.Uint64() {
+		return common.Hash{}
+	}
+	return sim.canonical(n)
+}
+
+// makeCall converts the call arguments into a transaction (used for hashing
+// and block assembly) and the message to execute.
+func (sim *simulator) makeCall(args TransactionArgs, header *types.Header, gasLeft uint64) (*types.Transaction, types.Message, error) {
+	// Default the sender, nonce and gas so that every call forms a unique and
+	// well defined transaction.
+	from := args.from()
+	if args.Nonce == nil {
+		nonce, ok := sim.nonceCursor[from]
+		if !ok {
+			nonce = sim.state.GetNonce(from)
+		}
+		args.Nonce = (*hexutil.Uint64)(&nonce)
+	}
+
+	if args.Gas == nil {
+		gas := hexutil.Uint64(gasLeft)
+		if sim.gasCap != 0 && sim.gasCap < gasLeft {
+			gas = hexutil.Uint64(sim.gasCap)
+		}
+		args.Gas = &gas
+	}
+	if args.Value == nil {
+		args.Value = new(hexutil.Big)
+	}
+	if args.GasPrice == nil && args.MaxFeePerGas == nil {
+		if header.BaseFee != nil {
+			args.MaxFeePerGas, args.MaxPriorityFeePerGas = new(hexutil.Big), new(hexutil.Big)
+			if sim.validate {
+				args.MaxFeePerGas = (*hexutil.Big)(header.BaseFee)
+			}
+		} else {
+			args.GasPrice = new(hexutil.Big)
+		}
+	}
+	if args.MaxFeePerGas != nil && args.MaxPriorityFeePerGas == nil {
+		args.MaxPriorityFeePerGas = new(hexutil.Big)
+	}
+	if args.ChainID == nil {
+		args.ChainID = (*hexutil.Big)(sim.b.ChainConfig().ChainID)
+	}
+	msg, err := args.ToMessage(sim.gasCap, header.BaseFee)
+	if err != nil {
+		return nil, types.Message{}, err
+	}
+	if sim.validate {
+		// Re-create the message with nonce checks enabled.
+		msg = types.NewMessage(msg.From(), msg.To(), uint64(*args.Nonce), msg.Value(), msg.Gas(), msg.GasPrice(), msg.GasFeeCap(), msg.GasTipCap(), msg.Data(), msg.AccessList(), false)
+	}
+	tx := args.toTransaction()
+	sim.senders[tx.Hash()] = from
+	log.Trace("Simulating call", "from", from, "nonce", tx.Nonce(), "hash", tx.Hash())
+	return tx, msg, nil
+}
@@ -0,0 +1,254 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/go-ethereum/consensus/ethash"
+	"github.com/scroll-tech/go-ethereum/core"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/core/state"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/core/vm"
+	"github.com/scroll-tech/go-ethereum/params"
+	"github.com/scroll-tech/go-ethereum/rpc"
+)
+
+// simBackend is a Backend serving just what the simulator needs from a local
+// test chain.
+type simBackend struct {
+	Backend
+	chain  *core.BlockChain
+	gasCap uint64 // RPC gas cap, defaults to 50M if unset
+}
+
+func (b *simBackend) ChainConfig() *params.ChainConfig { return b.chain.Config() }
+func (b *simBackend) RPCEVMTimeout() time.Duration     { return 5 * time.Second }
+
+func (b *simBackend) RPCGasCap() uint64 {
+	if b.gasCap == 0 {
+		return 50000000
+	}
+	return b.gasCap
+}
+
+func (b *simBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	statedb, err := b.chain.State()
+	return statedb, b.chain.CurrentHeader(), err
+}
+
+func (b *simBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return b.chain.GetHeaderByHash(hash), nil
+}
+
+func (b *simBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmConfig *vm.Config) (*vm.EVM, func() error, error) {
+	context := core.NewEVMBlockContext(header, b.chain, nil)
+	return vm.NewEVM(context, core.NewEVMTxContext(msg), state, b.chain.Config(), *vmConfig), func() error { return nil }, nil
+}
+
+func TestSimulateV1(t *testing.T) {
+	var (
+		sender  = common.HexToAddress("0x1001")
+		counter = common.HexToAddress("0x1002")
+		topic   = common.HexToHash("0xaa")
+
+		// Increments slot 0 and logs the new value:
+		// PUSH1 0 SLOAD PUSH1 1 ADD DUP1 PUSH1 0 SSTORE PUSH1 0 MSTORE
+		// PUSH1 0xaa PUSH1 32 PUSH1 0 LOG1 STOP
+		code = common.FromHex("0x6000546001018060005560005260aa60206000a100")
+	)
+	db := rawdb.NewMemoryDatabase()
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  core.GenesisAlloc{counter: {Balance: common.Big0, Code: code}},
+	}
+	gspec.MustCommit(db)
+	chain, _ := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	defer chain.Stop()
+
+	api := NewPublicBlockChainAPI(&simBackend{chain: chain})
+	call := TransactionArgs{From: &sender, To: &counter}
+	blocks, err := api.SimulateV1(context.Background(), SimOpts{
+		BlockStateCalls: []SimBlock{
+			{Calls: []TransactionArgs{call, call}},
+			{Calls: []TransactionArgs{call}},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to simulate: %v", err)
+	}
+	if len(blocks) != 2 {
+		t.Fatalf("block count mismatch: have %d, want 2", len(blocks))
+	}
+	// Every call observes the state changes of the previous ones, across blocks
+	var value int64
+	for i, block := range blocks {
+		var (
+			number = (*big.Int)(block["number"].(*hexutil.Big)).Uint64()
+			hash   = block["hash"].(common.Hash)
+			txs    = block["transactions"].([]interface{})
+			calls  = block["calls"].([]simCallResult)
+		)
+		if number != uint64(i+1) {
+			t.Errorf("block %d: number mismatch: have %d, want %d", i, number, i+1)
+		}
+		if len(calls) != len(txs) {
+			t.Fatalf("block %d: call count mismatch: have %d, want %d", i, len(calls), len(txs))
+		}
+		for j, res := range calls {
+			value++
+			if res.Status != hexutil.Uint64(types.ReceiptStatusSuccessful) || res.Error != nil {
+				t.Fatalf("block %d, call %d: failed: %+v", i, j, res.Error)
+			}
+			if len(res.Logs) != 1 {
+				t.Fatalf("block %d, call %d: log count mismatch: have %d, want 1", i, j, len(res.Logs))
+			}
+			l := res.Logs[0]
+			if have := new(big.Int).SetBytes(l.Data).Int64(); have != value {
+				t.Errorf("block %d, call %d: counter mismatch: have %d, want %d", i, j, have, value)
+			}
+			if l.Address != counter || len(l.Topics) != 1 || l.Topics[0] != topic {
+				t.Errorf("block %d, call %d: log content mismatch: %+v", i, j, l)
+			}
+			if l.BlockNumber != number || l.BlockHash != hash {
+				t.Errorf("block %d, call %d: log block mismatch: have %d/%x, want %d/%x", i, j, l.BlockNumber, l.BlockHash, number, hash)
+			}
+			if l.TxHash != txs[j].(common.Hash) || l.TxIndex != uint(j) || l.Index != uint(j) {
+				t.Errorf("block %d, call %d: log position mismatch: have %x/%d/%d", i, j, l.TxHash, l.TxIndex, l.Index)
+			}
+		}
+	}
+	if value != 3 {
+		t.Errorf("call count mismatch: have %d, want 3", value)
+	}
+}
+
+func TestSimulateV1BlockHash(t *testing.T) {
+	var (
+		sender = common.HexToAddress("0x1001")
+		hasher = common.HexToAddress("0x1002")
+
+		// Returns the hash of the block number in the calldata:
+		// PUSH1 0 CALLDATALOAD BLOCKHASH PUSH1 0 MSTORE PUSH1 32 PUSH1 0 RETURN
+		code = common.FromHex("0x6000354060005260206000f3")
+	)
+	db := rawdb.NewMemoryDatabase()
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  core.GenesisAlloc{hasher: {Balance: common.Big0, Code: code}},
+	}
+	genesis := gspec.MustCommit(db)
+	chain, _ := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	defer chain.Stop()
+
+	canonical, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 2, nil)
+	if _, err := chain.InsertChain(canonical); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	api := NewPublicBlockChainAPI(&simBackend{chain: chain})
+
+	// Simulate block 3, then skip straight to block 6 and query every ancestor
+	var calls []TransactionArgs
+	for n := 0; n <= 6; n++ {
+		input := hexutil.Bytes(common.BigToHash(big.NewInt(int64(n))).Bytes())
+		calls = append(calls, TransactionArgs{From: &sender, To: &hasher, Input: &input})
+	}
+	blocks, err := api.SimulateV1(context.Background(), SimOpts{
+		BlockStateCalls: []SimBlock{
+			{},
+			{BlockOverrides: &BlockOverrides{Number: (*hexutil.Big)(big.NewInt(6))}, Calls: calls},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to simulate: %v", err)
+	}
+	// The skipped blocks 4 and 5 are simulated as empty blocks
+	if len(blocks) != 4 {
+		t.Fatalf("block count mismatch: have %d, want 4", len(blocks))
+	}
+	want := []common.Hash{genesis.Hash(), canonical[0].Hash(), canonical[1].Hash()}
+	for i, block := range blocks {
+		number := (*big.Int)(block["number"].(*hexutil.Big)).Int64()
+		if number != int64(i+3) {
+			t.Fatalf("block %d: number mismatch: have %d, want %d", i, number, i+3)
+		}
+		if i > 0 && block["parentHash"].(common.Hash) != want[len(want)-1] {
+			t.Errorf("block %d: parent hash mismatch", i)
+		}
+		want = append(want, block["hash"].(common.Hash))
+	}
+	// The current block's hash is not available to itself
+	want[6] = common.Hash{}
+
+	results := blocks[3]["calls"].([]simCallResult)
+	for n, res := range results {
+		if res.Error != nil {
+			t.Fatalf("block hash %d: call failed: %v", n, res.Error.Message)
+		}
+		if have := common.BytesToHash(res.ReturnValue); have != want[n] {
+			t.Errorf("block hash %d: mismatch: have %x, want %x", n, have, want[n])
+		}
+	}
+}
+
+func TestSimulateV1NonceCursor(t *testing.T) {
+	var (
+		sender = common.HexToAddress("0x1001")
+		target = common.HexToAddress("0x1002")
+	)
+	db := rawdb.NewMemoryDatabase()
+	gspec := &core.Genesis{Config: params.TestChainConfig}
+	gspec.MustCommit(db)
+	chain, _ := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	defer chain.Stop()
+
+	api := NewPublicBlockChainAPI(&simBackend{chain: chain})
+
+	// A call exceeding the block gas limit is not included, and must not take
+	// up a nonce of its sender.
+	var (
+		huge   = hexutil.Uint64(gspec.GasLimit + 1)
+		failed = TransactionArgs{From: &sender, To: &target, Gas: &huge}
+		call   = TransactionArgs{From: &sender, To: &target}
+	)
+	blocks, err := api.SimulateV1(context.Background(), SimOpts{
+		BlockStateCalls:        []SimBlock{{Calls: []TransactionArgs{failed, call, call}}},
+		ReturnFullTransactions: true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to simulate: %v", err)
+	}
+	calls := blocks[0]["calls"].([]simCallResult)
+	if calls[0].Error == nil {
+		t.Fatalf("oversized call did not fail")
+	}
+	txs := blocks[0]["transactions"].([]interface{})
+	if len(txs) != 2 {
+		t.Fatalf("transaction count mismatch: have %d, want 2", len(txs))
+	}
+	for i, tx := range txs {
+		if nonce := uint64(tx.(*RPCTransaction).Nonce); nonce != uint64(i) {
+			t.Errorf("transaction %d: nonce mismatch: have %d, want %d", i, nonce, i)
+		}
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/scroll-tech/go-ethereum/common"
 	"github.com/scroll-tech/go-ethereum/common/hexutil"
 	"github.com/scroll-tech/go-ethereum/common/math"
+	"github.com/scroll-tech/go-ethereum/consensus/misc"
 	"github.com/scroll-tech/go-ethereum/core/types"
 	"github.com/scroll-tech/go-ethereum/log"
 	"github.com/scroll-tech/go-ethereum/rpc"
@@ -83,8 +84,13 @@ func (args *TransactionArgs) setDefaults(ctx context.Context, b Backend) error {
 	// If user specifies both maxPriorityfee and maxFee, then we do not
 	// need to consult the chain for defaults. It's definitely a London tx.
 	if args.MaxPriorityFeePerGas == nil || args.MaxFeePerGas == nil {
-		// In this clause, user left some fields unspecified.
-		if b.ChainConfig().IsLondon(head.Number) && args.GasPrice == nil {
+		// In this clause, user left some fields unspecified. Price against the
+		// base fee of the next block, which may be set by the base fee oracle.
+		var baseFee *big.Int
+		if b.ChainConfig().IsLondon(head.Number) {
+			baseFee = pendingBaseFee(ctx, b, head)
+		}
+		if baseFee != nil && args.GasPrice == nil {
 			if args.MaxPriorityFeePerGas == nil {
 				tip, err := b.SuggestGasTipCap(ctx)
 				if err != nil {
@@ -95,7 +101,7 @@ func (args *TransactionArgs) setDefaults(ctx context.Context, b Backend) error {
 			if args.MaxFeePerGas == nil {
 				gasFeeCap := new(big.Int).Add(
 					(*big.Int)(args.MaxPriorityFeePerGas),
-					new(big.Int).Mul(head.BaseFee, big.NewInt(2)),
+					new(big.Int).Mul(baseFee, big.NewInt(2)),
 				)
 				args.MaxFeePerGas = (*hexutil.Big)(gasFeeCap)
 			}
@@ -111,11 +117,11 @@ func (args *TransactionArgs) setDefaults(ctx context.Context, b Backend) error {
 				if err != nil {
 					return err
 				}
-				if b.ChainConfig().IsLondon(head.Number) {
+				if baseFee != nil {
 					// The legacy tx gas price suggestion should not add 2x base fee
 					// because all fees are consumed, so it would result in a spiral
 					// upwards.
-					price.Add(price, head.BaseFee)
+					price.Add(price, baseFee)
 				}
 				args.GasPrice = (*hexutil.Big)(price)
 			}
@@ -172,6 +178,20 @@ func (args *TransactionArgs) setDefaults(ctx context.Context, b Backend) error {
 	return nil
 }
 
+// pendingBaseFee returns the base fee of the block following the head, reading
+// the target of the base fee oracle from the head state if one is in force.
+func pendingBaseFee(ctx context.Context, b Backend, head *types.Header) *big.Int {
+	config := b.ChainConfig()
+	if config.BaseFeeOracle(new(big.Int).Add(head.Number, common.Big1)) != nil {
+		state, _, err := b.StateAndHeaderByNumberOrHash(ctx, rpc.BlockNumberOrHashWithHash(head.Hash(), false))
+		if err == nil && state != nil {
+			return misc.CalcBaseFeeFromState(config, head, state)
+		}
+		log.Debug("Approximating oracle base fee without state", "number", head.Number, "err", err)
+	}
+	return misc.CalcBaseFee(config, head)
+}
+
 // ToMessage converts the transaction arguments to the Message type used by the
 // core evm. This method is used in calls and traces that do not require a real
 // live transaction.
@@ -0,0 +1,97 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/consensus/misc"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/core/state"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/params"
+	"github.com/scroll-tech/go-ethereum/rpc"
+)
+
+// baseFeeBackend is a Backend serving the state of the head block, if any, for
+// the base fee oracle.
+type baseFeeBackend struct {
+	Backend
+	config *params.ChainConfig
+	head   *types.Header
+	state  *state.StateDB // State of the head block, nil if unavailable
+}
+
+func (b *baseFeeBackend) ChainConfig() *params.ChainConfig {
+	return b.config
+}
+
+func (b *baseFeeBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	if hash, ok := blockNrOrHash.Hash(); !ok || hash != b.head.Hash() {
+		return nil, nil, errors.New("unknown block")
+	}
+	if b.state == nil {
+		return nil, nil, errors.New("missing state")
+	}
+	return b.state, b.head, nil
+}
+
+// Tests that the base fee of the pending block is read from the oracle target in
+// the head state, and approximated if the state is unavailable.
+func TestPendingBaseFee(t *testing.T) {
+	oracle := &params.BaseFeeOracleConfig{Address: common.Address{0x01}}
+	config := *params.TestChainConfig
+	config.EIP1559 = []*params.EIP1559Config{{Block: big.NewInt(10), Oracle: oracle}}
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetState(oracle.Address, oracle.Slot, common.BigToHash(big.NewInt(500)))
+
+	newHead := func(number int64) *types.Header {
+		return &types.Header{
+			Number:   big.NewInt(number),
+			GasLimit: 20000000,
+			GasUsed:  15000000,
+			BaseFee:  big.NewInt(params.InitialBaseFee),
+		}
+	}
+	tests := []struct {
+		head  *types.Header
+		state *state.StateDB
+		want  *big.Int
+	}{
+		// The oracle is in force for the pending block, its target is read from the head state
+		{newHead(9), statedb, big.NewInt(500)},
+		// The head state is unavailable, the base fee of the head is kept
+		{newHead(9), nil, big.NewInt(params.InitialBaseFee)},
+		// The oracle is not in force yet, the base fee follows the EIP-1559 dynamics
+		{newHead(8), statedb, misc.CalcBaseFee(&config, newHead(8))},
+	}
+	for i, tt := range tests {
+		backend := &baseFeeBackend{config: &config, head: tt.head, state: tt.state}
+		if have := pendingBaseFee(context.Background(), backend, tt.head); have.Cmp(tt.want) != 0 {
+			t.Errorf("test %d: base fee mismatch: have %d, want %d", i, have, tt.want)
+		}
+	}
+	// The dynamics must actually move the base fee for the last case to be telling
+	if misc.CalcBaseFee(&config, newHead(8)).Cmp(big.NewInt(params.InitialBaseFee)) == 0 {
+		t.Error("base fee of a busy block unchanged")
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/go-ethereum/consensus/misc"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/miner"
+	"github.com/scroll-tech/go-ethereum/rpc"
+)
+
+// RPCTransactionStatus is the inclusion status of a transaction.
+type RPCTransactionStatus struct {
+	Status          string          `json:"status"` // included, pending, queued, dropped or unknown
+	BlockHash       *common.Hash    `json:"blockHash,omitempty"`
+	BlockNumber     *hexutil.Big    `json:"blockNumber,omitempty"`
+	Position        *hexutil.Uint64 `json:"position,omitempty"`        // Number of pending transactions ordered before it
+	GasAhead        *hexutil.Uint64 `json:"gasAhead,omitempty"`        // Gas limit of the pending transactions ordered before it
+	EstimatedBlocks *hexutil.Uint64 `json:"estimatedBlocks,omitempty"` // Blocks until inclusion, the next block being 1
+	Reason          string          `json:"reason,omitempty"`          // Why the transaction is waiting or was dropped
+}
+
+// GetTransactionStatus reports where a transaction stands: the block including
+// it, its position in the block ordering of the pending pool along with the
+// estimated number of blocks until its inclusion, or why it's waiting or was
+// evicted. The estimate replays the ordering of the miner, local transactions
+// first and by effective tip otherwise, filling blocks with the gas limits of
+// the transactions.
+//
+// Blocks are filled up to the current gas limit, unless the ordering audit of
+// the latest block shows that the witness limit of the miner cut it short, in
+// which case they're filled up to the gas that block used. The witness a
+// transaction adds is only known by executing it, so without ordering audits
+// the estimate doesn't account for the witness limit.
+func (s *PublicTransactionPoolAPI) GetTransactionStatus(ctx context.Context, hash common.Hash) (*RPCTransactionStatus, error) {
+	tx, blockHash, blockNumber, _, err := s.b.GetTransaction(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if tx != nil {
+		return &RPCTransactionStatus{
+			Status:      "included",
+			BlockHash:   &blockHash,
+			BlockNumber: (*hexutil.Big)(new(big.Int).SetUint64(blockNumber)),
+		}, nil
+	}
+	if tx = s.b.GetPoolTransaction(hash); tx == nil {
+		if reason, ok := s.b.TxPoolDropReason(hash); ok {
+			return &RPCTransactionStatus{Status: "dropped", Reason: reason.String()}, nil
+		}
+		return &RPCTransactionStatus{Status: "unknown"}, nil
+	}
+	state, head, err := s.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	var (
+		config = s.b.ChainConfig()
+		signer = types.LatestSigner(config)
+	)
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return nil, err
+	}
+	// Explain why a queued transaction isn't executable yet
+	pending, _ := s.b.TxPoolContentFrom(from)
+	if !containsTx(pending, hash) {
+		status := &RPCTransactionStatus{Status: "queued"}
+		if next := state.GetNonce(from) + uint64(len(pending)); tx.Nonce() > next {
+			status.Reason = fmt.Sprintf("nonce gap, waiting for nonce %d", next)
+		} else if state.GetBalance(from).Cmp(tx.Cost()) < 0 {
+			status.Reason = "insufficient funds"
+		} else {
+			status.Reason = "awaiting promotion"
+		}
+		return status, nil
+	}
+	// Locate the transaction in the block ordering of the pending pool
+	status := &RPCTransactionStatus{Status: "pending"}
+	var baseFee *big.Int
+	if config.IsLondon(new(big.Int).Add(head.Number, common.Big1)) {
+		baseFee = misc.CalcBaseFeeFromState(config, head, state)
+	}
+	switch {
+	case tx.Gas() > head.GasLimit:
+		status.Reason = "exceeds block gas limit"
+	case baseFee != nil && tx.GasFeeCap().Cmp(baseFee) < 0:
+		status.Reason = "fee cap below base fee"
+	default:
+		capacity := head.GasLimit
+		if audit := rawdb.ReadOrderingAudit(s.b.ChainDb(), head.Hash()); miner.WitnessBound(audit) && head.GasUsed > 0 {
+			capacity = head.GasUsed
+		}
+		position, gasAhead, blocks, found := estimateInclusion(signer, s.b.TxPoolPending(), s.b.TxPoolLocals(), baseFee, head.GasLimit, capacity, hash)
+		if !found {
+			status.Reason = "blocked by an earlier transaction of the sender"
+			break
+		}
+		status.Position = (*hexutil.Uint64)(&position)
+		status.GasAhead = (*hexutil.Uint64)(&gasAhead)
+		status.EstimatedBlocks = (*hexutil.Uint64)(&blocks)
+	}
+	return status, nil
+}
+
+// containsTx checks whether the transaction with the given hash is in the list.
+func containsTx(txs types.Transactions, hash common.Hash) bool {
+	for _, tx := range txs {
+		if tx.Hash() == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// estimateInclusion orders the pending transactions the way the miner does and
+// returns the number of transactions and gas ahead of the one with the given
+// hash, along with the number of blocks needed to include it. Blocks take up to
+// capacity gas, but always take a first transaction within the gas limit, like
+// the miner does under a witness limit. Transactions above the gas limit are
+// skipped. The pending lists are not modified.
+func estimateInclusion(signer types.Signer, pending map[common.Address]types.Transactions, locals []common.Address, baseFee *big.Int, gasLimit, capacity uint64, hash common.Hash) (position, gasAhead, blocks uint64, found bool) {
+	// Split the pending transactions into locals and remotes
+	localTxs, remoteTxs := make(map[common.Address]types.Transactions), make(map[common.Address]types.Transactions, len(pending))
+	for addr, txs := range pending {
+		remoteTxs[addr] = txs
+	}
+	for _, addr := range locals {
+		if txs := remoteTxs[addr]; len(txs) > 0 {
+			delete(remoteTxs, addr)
+			localTxs[addr] = txs
+		}
+	}
+	gasLeft := capacity
+	blocks = 1
+	for _, txs := range []map[common.Address]types.Transactions{localTxs, remoteTxs} {
+		set := types.NewTransactionsByPriceAndNonce(signer, txs, baseFee)
+		for tx := set.Peek(); tx != nil; tx = set.Peek() {
+			if tx.Gas() > gasLimit {
+				set.Pop()
+				continue
+			}
+			if tx.Gas() > gasLeft && gasLeft < capacity {
+				blocks, gasLeft = blocks+1, capacity
+			}
+			if tx.Hash() == hash {
+				return position, gasAhead, blocks, true
+			}
+			position, gasAhead = position+1, gasAhead+tx.Gas()
+			if tx.Gas() < gasLeft {
+				gasLeft -= tx.Gas()
+			} else {
+				gasLeft = 0
+			}
+			set.Shift()
+		}
+	}
+	return 0, 0, 0, false
+}
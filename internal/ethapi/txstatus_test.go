@@ -0,0 +1,90 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/scroll-tech/go-ethereum/params"
+)
+
+func TestEstimateInclusion(t *testing.T) {
+	signer := types.LatestSigner(params.TestChainConfig)
+	keys := make([]*ecdsa.PrivateKey, 3)
+	addrs := make([]common.Address, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+	}
+	newTx := func(key *ecdsa.PrivateKey, nonce uint64, gas uint64, feeCap int64) *types.Transaction {
+		return types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   params.TestChainConfig.ChainID,
+			Nonce:     nonce,
+			Gas:       gas,
+			GasFeeCap: big.NewInt(feeCap),
+			GasTipCap: big.NewInt(feeCap),
+		})
+	}
+	var (
+		cheap  = newTx(keys[0], 0, 60000, 3)  // lowest tip, but local
+		rich1  = newTx(keys[1], 0, 60000, 10) // highest tip
+		rich2  = newTx(keys[1], 1, 60000, 10)
+		middle = newTx(keys[2], 0, 30000, 5)
+		under  = newTx(keys[2], 1, 30000, 1) // below the base fee
+	)
+	pending := map[common.Address]types.Transactions{
+		addrs[0]: {cheap},
+		addrs[1]: {rich1, rich2},
+		addrs[2]: {middle, under},
+	}
+	locals := []common.Address{addrs[0]}
+
+	tests := []struct {
+		tx                 *types.Transaction
+		capacity           uint64
+		position, gas, blk uint64
+		found              bool
+	}{
+		{cheap, 150000, 0, 0, 1, true},
+		{rich1, 150000, 1, 60000, 1, true},
+		{rich2, 150000, 2, 120000, 2, true},
+		{middle, 150000, 3, 180000, 2, true},
+		{under, 150000, 0, 0, 0, false},
+
+		// Blocks cut short by the witness limit still take one transaction each
+		{cheap, 50000, 0, 0, 1, true},
+		{rich1, 50000, 1, 60000, 2, true},
+		{rich2, 50000, 2, 120000, 3, true},
+		{middle, 50000, 3, 180000, 4, true},
+	}
+	for i, tt := range tests {
+		position, gas, blocks, found := estimateInclusion(signer, pending, locals, big.NewInt(2), 150000, tt.capacity, tt.tx.Hash())
+		if position != tt.position || gas != tt.gas || blocks != tt.blk || found != tt.found {
+			t.Errorf("test %d: have position %d, gas %d, blocks %d, found %v; want %d, %d, %d, %v",
+				i, position, gas, blocks, found, tt.position, tt.gas, tt.blk, tt.found)
+		}
+	}
+	// The estimation must not disturb the pending set
+	if len(pending[addrs[0]]) != 1 || len(pending[addrs[1]]) != 2 {
+		t.Errorf("pending set modified")
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tabular
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// csvWriter writes a table as CSV, with a header line of the column names.
+type csvWriter struct {
+	schema Schema
+	out    *csv.Writer
+	record []string
+	header bool
+}
+
+// NewCSVWriter creates a CSV table writer.
+func NewCSVWriter(w io.Writer, schema Schema) Writer {
+	return &csvWriter{
+		schema: schema,
+		out:    csv.NewWriter(w),
+		record: make([]string, len(schema)),
+	}
+}
+
+// writeHeader writes the column names, once.
+func (w *csvWriter) writeHeader() error {
+	if w.header {
+		return nil
+	}
+	w.header = true
+	for i, column := range w.schema {
+		w.record[i] = column.Name
+	}
+	return w.out.Write(w.record)
+}
+
+// Write implements Writer.
+func (w *csvWriter) Write(row ...interface{}) error {
+	if err := w.schema.check(row); err != nil {
+		return err
+	}
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	for i, value := range row {
+		switch value := value.(type) {
+		case int64:
+			w.record[i] = strconv.FormatInt(value, 10)
+		case string:
+			w.record[i] = value
+		}
+	}
+	return w.out.Write(w.record)
+}
+
+// Close implements Writer.
+func (w *csvWriter) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.out.Flush()
+	return w.out.Error()
+}
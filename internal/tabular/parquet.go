@@ -0,0 +1,327 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tabular
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// The Parquet writer produces flat files of required columns, each row group
+// holding a single uncompressed, PLAIN encoded data page per column. That is the
+// simplest layout all readers support, compression is left to the storage.
+//
+// See https://github.com/apache/parquet-format for the format specification.
+
+const (
+	parquetMagic = "PAR1"
+
+	// Limits of the rows buffered in memory before a row group is flushed
+	parquetRowGroupRows  = 128 * 1024
+	parquetRowGroupBytes = 64 * 1024 * 1024
+)
+
+// Parquet enum values, from parquet.thrift.
+const (
+	parquetTypeInt64     = 2
+	parquetTypeByteArray = 6
+
+	parquetRequired      = 0 // FieldRepetitionType.REQUIRED
+	parquetConvertedUTF8 = 0 // ConvertedType.UTF8
+
+	parquetEncodingPlain = 0
+	parquetEncodingRLE   = 3
+
+	parquetCodecUncompressed = 0
+	parquetPageData          = 0 // PageType.DATA_PAGE
+)
+
+// parquetChunk is the metadata of a column chunk written to the file.
+type parquetChunk struct {
+	offset int64 // Offset of the data page header
+	size   int64 // Size of the page, header included
+	values int64
+}
+
+// parquetRowGroup is the metadata of a row group written to the file.
+type parquetRowGroup struct {
+	chunks []parquetChunk
+	rows   int64
+	size   int64
+}
+
+// parquetWriter writes a table as a Parquet file.
+type parquetWriter struct {
+	schema Schema
+	out    io.Writer
+	offset int64
+	err    error
+
+	columns  [][]byte // PLAIN encoded values of the buffered rows, per column
+	rows     int64    // Number of buffered rows
+	buffered int      // Size of the buffered values
+
+	groups []parquetRowGroup
+	total  int64
+}
+
+// NewParquetWriter creates a Parquet table writer.
+func NewParquetWriter(w io.Writer, schema Schema) Writer {
+	return &parquetWriter{
+		schema:  schema,
+		out:     w,
+		columns: make([][]byte, len(schema)),
+	}
+}
+
+// write appends data to the file, tracking the offset and the first error.
+func (w *parquetWriter) write(data []byte) {
+	if w.err != nil {
+		return
+	}
+	n, err := w.out.Write(data)
+	w.offset += int64(n)
+	w.err = err
+}
+
+// Write implements Writer.
+func (w *parquetWriter) Write(row ...interface{}) error {
+	if err := w.schema.check(row); err != nil {
+		return err
+	}
+	for i, value := range row {
+		before := len(w.columns[i])
+		switch value := value.(type) {
+		case int64:
+			w.columns[i] = appendUint64(w.columns[i], uint64(value))
+		case string:
+			w.columns[i] = appendUint32(w.columns[i], uint32(len(value)))
+			w.columns[i] = append(w.columns[i], value...)
+		}
+		w.buffered += len(w.columns[i]) - before
+	}
+	w.rows++
+	if w.rows >= parquetRowGroupRows || w.buffered >= parquetRowGroupBytes {
+		w.flush()
+	}
+	return w.err
+}
+
+// flush writes the buffered rows as a row group.
+func (w *parquetWriter) flush() {
+	if w.rows == 0 {
+		return
+	}
+	if w.offset == 0 {
+		w.write([]byte(parquetMagic))
+	}
+	group := parquetRowGroup{rows: w.rows}
+	for i, values := range w.columns {
+		header := new(thriftWriter)
+		header.i32(1, parquetPageData)
+		header.i32(2, int32(len(values)))
+		header.i32(3, int32(len(values)))
+		header.beginStruct(5)
+		header.i32(1, int32(w.rows))
+		header.i32(2, parquetEncodingPlain)
+		header.i32(3, parquetEncodingRLE)
+		header.i32(4, parquetEncodingRLE)
+		header.endStruct()
+		header.stop()
+
+		chunk := parquetChunk{offset: w.offset, values: w.rows}
+		w.write(header.buf)
+		w.write(values)
+		chunk.size = w.offset - chunk.offset
+
+		group.chunks = append(group.chunks, chunk)
+		group.size += chunk.size
+		w.columns[i] = values[:0]
+	}
+	w.groups = append(w.groups, group)
+	w.total += w.rows
+	w.rows, w.buffered = 0, 0
+}
+
+// Close implements Writer, writing the file metadata.
+func (w *parquetWriter) Close() error {
+	w.flush()
+	if w.offset == 0 {
+		w.write([]byte(parquetMagic))
+	}
+	meta := new(thriftWriter)
+	meta.i32(1, 1)
+
+	meta.list(2, thriftStruct, len(w.schema)+1)
+	meta.beginElem()
+	meta.binary(4, []byte("schema"))
+	meta.i32(5, int32(len(w.schema)))
+	meta.endStruct()
+	for _, column := range w.schema {
+		meta.beginElem()
+		meta.i32(1, column.physicalType())
+		meta.i32(3, parquetRequired)
+		meta.binary(4, []byte(column.Name))
+		if column.Kind == String {
+			meta.i32(6, parquetConvertedUTF8)
+		}
+		meta.endStruct()
+	}
+	meta.i64(3, w.total)
+
+	meta.list(4, thriftStruct, len(w.groups))
+	for _, group := range w.groups {
+		meta.beginElem()
+		meta.list(1, thriftStruct, len(group.chunks))
+		for i, chunk := range group.chunks {
+			meta.beginElem()
+			meta.i64(2, chunk.offset)
+			meta.beginStruct(3)
+			meta.i32(1, w.schema[i].physicalType())
+			meta.list(2, thriftI32, 1)
+			meta.varint(zigzag(parquetEncodingPlain))
+			meta.list(3, thriftBinary, 1)
+			meta.varint(uint64(len(w.schema[i].Name)))
+			meta.buf = append(meta.buf, w.schema[i].Name...)
+			meta.i32(4, parquetCodecUncompressed)
+			meta.i64(5, chunk.values)
+			meta.i64(6, chunk.size)
+			meta.i64(7, chunk.size)
+			meta.i64(9, chunk.offset)
+			meta.endStruct()
+			meta.endStruct()
+		}
+		meta.i64(2, group.size)
+		meta.i64(3, group.rows)
+		meta.endStruct()
+	}
+	meta.binary(6, []byte("go-ethereum"))
+	meta.stop()
+
+	w.write(meta.buf)
+	w.write(appendUint32(nil, uint32(len(meta.buf))))
+	w.write([]byte(parquetMagic))
+	return w.err
+}
+
+// physicalType returns the Parquet type storing the values of a column.
+func (c Column) physicalType() int32 {
+	if c.Kind == Int64 {
+		return parquetTypeInt64
+	}
+	return parquetTypeByteArray
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var enc [4]byte
+	binary.LittleEndian.PutUint32(enc[:], v)
+	return append(b, enc[:]...)
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var enc [8]byte
+	binary.LittleEndian.PutUint64(enc[:], v)
+	return append(b, enc[:]...)
+}
+
+// Thrift compact protocol type identifiers.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes the Parquet metadata structures with the Thrift compact
+// protocol. Only the subset of the protocol used by the metadata is supported.
+type thriftWriter struct {
+	buf    []byte
+	last   int16   // Identifier of the last field written in the current struct
+	parent []int16 // Last field identifiers of the enclosing structs
+}
+
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
+
+func (w *thriftWriter) varint(v uint64) {
+	var enc [binary.MaxVarintLen64]byte
+	w.buf = append(w.buf, enc[:binary.PutUvarint(enc[:], v)]...)
+}
+
+// field writes a field header, using the short form for close identifiers.
+func (w *thriftWriter) field(id int16, typ byte) {
+	if delta := id - w.last; delta > 0 && delta <= 15 {
+		w.buf = append(w.buf, byte(delta)<<4|typ)
+	} else {
+		w.buf = append(w.buf, typ)
+		w.varint(zigzag(int64(id)))
+	}
+	w.last = id
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(id, thriftI32)
+	w.varint(zigzag(int64(v)))
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(id, thriftI64)
+	w.varint(zigzag(v))
+}
+
+func (w *thriftWriter) binary(id int16, v []byte) {
+	w.field(id, thriftBinary)
+	w.varint(uint64(len(v)))
+	w.buf = append(w.buf, v...)
+}
+
+// list writes the header of a list field, the elements are to follow.
+func (w *thriftWriter) list(id int16, elem byte, size int) {
+	w.field(id, thriftList)
+	if size < 15 {
+		w.buf = append(w.buf, byte(size)<<4|elem)
+	} else {
+		w.buf = append(w.buf, 0xf0|elem)
+		w.varint(uint64(size))
+	}
+}
+
+// beginStruct starts a struct field.
+func (w *thriftWriter) beginStruct(id int16) {
+	w.field(id, thriftStruct)
+	w.beginElem()
+}
+
+// beginElem starts a struct element of a list.
+func (w *thriftWriter) beginElem() {
+	w.parent = append(w.parent, w.last)
+	w.last = 0
+}
+
+// endStruct terminates the current struct.
+func (w *thriftWriter) endStruct() {
+	w.stop()
+	w.last = w.parent[len(w.parent)-1]
+	w.parent = w.parent[:len(w.parent)-1]
+}
+
+// stop terminates the top level struct.
+func (w *thriftWriter) stop() {
+	w.buf = append(w.buf, 0)
+}
@@ -0,0 +1,84 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package tabular writes records of a fixed schema into the flat file formats
+// consumed by analytics tooling, CSV and Parquet.
+package tabular
+
+import (
+	"fmt"
+	"io"
+)
+
+// Kind is the type of the values of a column.
+type Kind int
+
+const (
+	Int64  Kind = iota // Values are int64
+	String             // Values are UTF-8 strings
+)
+
+// Column is a named, typed column of a schema.
+type Column struct {
+	Name string
+	Kind Kind
+}
+
+// Schema is the ordered list of the columns of a table.
+type Schema []Column
+
+// Writer writes rows into a table file.
+type Writer interface {
+	// Write appends a row, holding a value of the matching kind for each
+	// column of the schema.
+	Write(row ...interface{}) error
+
+	// Close flushes the buffered rows and finalizes the file. It doesn't close
+	// the underlying writer.
+	Close() error
+}
+
+// NewWriter creates a table writer of the given format, "csv" or "parquet".
+func NewWriter(format string, w io.Writer, schema Schema) (Writer, error) {
+	switch format {
+	case "csv":
+		return NewCSVWriter(w, schema), nil
+	case "parquet":
+		return NewParquetWriter(w, schema), nil
+	default:
+		return nil, fmt.Errorf("unknown table format %q", format)
+	}
+}
+
+// check verifies that a row matches the schema.
+func (s Schema) check(row []interface{}) error {
+	if len(row) != len(s) {
+		return fmt.Errorf("row has %d values, schema has %d columns", len(row), len(s))
+	}
+	for i, column := range s {
+		var ok bool
+		switch column.Kind {
+		case Int64:
+			_, ok = row[i].(int64)
+		case String:
+			_, ok = row[i].(string)
+		}
+		if !ok {
+			return fmt.Errorf("invalid value %v (%T) for column %s", row[i], row[i], column.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tabular
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/source"
+)
+
+var testSchema = Schema{
+	{Name: "number", Kind: Int64},
+	{Name: "hash", Kind: String},
+}
+
+// Tests that rows are written as CSV with a header line.
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewCSVWriter(&buf, testSchema)
+	if err := w.Write(int64(1), "0x01"); err != nil {
+		t.Fatalf("failed to write row: %v", err)
+	}
+	if err := w.Write(int64(-2), "a,b"); err != nil {
+		t.Fatalf("failed to write row: %v", err)
+	}
+	if err := w.Write("3", "0x03"); err == nil {
+		t.Fatalf("mistyped row accepted")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+	if have, want := buf.String(), "number,hash\n1,0x01\n-2,\"a,b\"\n"; have != want {
+		t.Errorf("output mismatch:\nhave %q\nwant %q", have, want)
+	}
+}
+
+// thriftReader decodes Thrift compact structs into maps keyed by field id, to
+// check the metadata written by the Parquet writer.
+type thriftReader struct {
+	buf []byte
+	err error
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.err = fmt.Errorf("invalid varint")
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *thriftReader) varint() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.varint()
+	case thriftBinary:
+		n := r.uvarint()
+		if r.err != nil || uint64(len(r.buf)) < n {
+			r.err = fmt.Errorf("invalid binary")
+			return nil
+		}
+		v := string(r.buf[:n])
+		r.buf = r.buf[n:]
+		return v
+	case thriftList:
+		header := r.buf[0]
+		r.buf = r.buf[1:]
+		size, elem := uint64(header>>4), header&0x0f
+		if size == 15 {
+			size = r.uvarint()
+		}
+		list := make([]interface{}, 0, size)
+		for i := uint64(0); i < size && r.err == nil; i++ {
+			list = append(list, r.value(elem))
+		}
+		return list
+	case thriftStruct:
+		fields := make(map[int64]interface{})
+		var last int64
+		for r.err == nil && len(r.buf) > 0 {
+			header := r.buf[0]
+			r.buf = r.buf[1:]
+			if header == 0 {
+				return fields
+			}
+			if delta := int64(header >> 4); delta != 0 {
+				last += delta
+			} else {
+				last = r.varint()
+			}
+			fields[last] = r.value(header & 0x0f)
+		}
+		r.err = fmt.Errorf("unterminated struct")
+		return nil
+	}
+	r.err = fmt.Errorf("unsupported type %d", typ)
+	return nil
+}
+
+// Tests that the Parquet files carry the rows with well formed metadata.
+func TestParquetWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewParquetWriter(&buf, testSchema)
+	for i := 0; i < 3; i++ {
+		if err := w.Write(int64(i), fmt.Sprintf("0x%02x", i)); err != nil {
+			t.Fatalf("failed to write row %d: %v", i, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+	file := buf.Bytes()
+	if !bytes.HasPrefix(file, []byte(parquetMagic)) || !bytes.HasSuffix(file, []byte(parquetMagic)) {
+		t.Fatalf("missing magic")
+	}
+	size := binary.LittleEndian.Uint32(file[len(file)-8:])
+	r := &thriftReader{buf: file[len(file)-8-int(size) : len(file)-8]}
+	meta := r.value(thriftStruct)
+	if r.err != nil {
+		t.Fatalf("failed to decode metadata: %v", r.err)
+	}
+	fields := meta.(map[int64]interface{})
+	if rows := fields[3]; rows != int64(3) {
+		t.Errorf("row count mismatch: have %v, want 3", rows)
+	}
+	schema := fields[2].([]interface{})
+	if len(schema) != 3 {
+		t.Fatalf("schema length mismatch: have %d, want 3", len(schema))
+	}
+	for i, column := range testSchema {
+		element := schema[i+1].(map[int64]interface{})
+		if element[4] != column.Name || element[1] != int64(column.physicalType()) {
+			t.Errorf("schema element %d mismatch: %v", i, element)
+		}
+	}
+	// Decode the values of the columns from their data pages
+	groups := fields[4].([]interface{})
+	if len(groups) != 1 {
+		t.Fatalf("row group count mismatch: have %d, want 1", len(groups))
+	}
+	chunks := groups[0].(map[int64]interface{})[1].([]interface{})
+	want := [][]byte{
+		{0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0},
+		{4, 0, 0, 0, '0', 'x', '0', '0', 4, 0, 0, 0, '0', 'x', '0', '1', 4, 0, 0, 0, '0', 'x', '0', '2'},
+	}
+	for i, chunk := range chunks {
+		meta := chunk.(map[int64]interface{})[3].(map[int64]interface{})
+		offset := meta[9].(int64)
+
+		r := &thriftReader{buf: file[offset:]}
+		header := r.value(thriftStruct).(map[int64]interface{})
+		if r.err != nil {
+			t.Fatalf("column %d: failed to decode page header: %v", i, r.err)
+		}
+		length := header[3].(int64)
+		if values := r.buf[:length]; !bytes.Equal(values, want[i]) {
+			t.Errorf("column %d: values mismatch: have %x, want %x", i, values, want[i])
+		}
+		if path := meta[3]; !reflect.DeepEqual(path, []interface{}{testSchema[i].Name}) {
+			t.Errorf("column %d: path mismatch: have %v", i, path)
+		}
+		if total := meta[6].(int64); total != int64(len(file)-len(r.buf))-offset+length {
+			t.Errorf("column %d: chunk size mismatch: have %d", i, total)
+		}
+	}
+}
+
+// parquetFile is a read only in-memory source.ParquetFile.
+type parquetFile struct {
+	*bytes.Reader
+	data []byte
+}
+
+func (f *parquetFile) Open(name string) (source.ParquetFile, error) {
+	return &parquetFile{Reader: bytes.NewReader(f.data), data: f.data}, nil
+}
+func (f *parquetFile) Create(name string) (source.ParquetFile, error) {
+	return nil, errors.New("read only")
+}
+func (f *parquetFile) Write(p []byte) (int, error) { return 0, errors.New("read only") }
+func (f *parquetFile) Close() error                { return nil }
+
+// Tests that the Parquet files can be read back by an independent reader,
+// spanning multiple row groups.
+func TestParquetRoundtrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewParquetWriter(&buf, testSchema)
+
+	rows := parquetRowGroupRows + 10
+	for i := 0; i < rows; i++ {
+		if err := w.Write(int64(i)-5, fmt.Sprintf("0x%x", i)); err != nil {
+			t.Fatalf("failed to write row %d: %v", i, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+	type row struct {
+		Number int64  `parquet:"name=number, type=INT64"`
+		Hash   string `parquet:"name=hash, type=UTF8"`
+	}
+	pr, err := reader.NewParquetReader(&parquetFile{Reader: bytes.NewReader(buf.Bytes()), data: buf.Bytes()}, new(row), 1)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer pr.ReadStop()
+
+	if n := pr.GetNumRows(); n != int64(rows) {
+		t.Fatalf("row count mismatch: have %d, want %d", n, rows)
+	}
+	if groups := len(pr.Footer.RowGroups); groups != 2 {
+		t.Errorf("row group count mismatch: have %d, want 2", groups)
+	}
+	have := make([]row, rows)
+	if err := pr.Read(&have); err != nil {
+		t.Fatalf("failed to read rows: %v", err)
+	}
+	for i, r := range have {
+		if want := (row{Number: int64(i) - 5, Hash: fmt.Sprintf("0x%x", i)}); r != want {
+			t.Fatalf("row %d mismatch: have %+v, want %+v", i, r, want)
+		}
+	}
+}
@@ -27,9 +27,11 @@ var Modules = map[string]string{
 	"net":      NetJs,
 	"personal": PersonalJs,
 	"rpc":      RpcJs,
+	"scroll":   ScrollJs,
 	"txpool":   TxpoolJs,
 	"les":      LESJs,
 	"vflux":    VfluxJs,
+	"watchdog": WatchdogJs,
 }
 
 const CliqueJs = `
@@ -152,6 +154,22 @@ web3._extend({
 			call: 'admin_importChain',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'scheduleGasLimit',
+			call: 'admin_scheduleGasLimit',
+			params: 3,
+			inputFormatter: [web3._extend.utils.fromDecimal, web3._extend.utils.fromDecimal, null]
+		}),
+		new web3._extend.Method({
+			name: 'gasLimitSchedule',
+			call: 'admin_gasLimitSchedule'
+		}),
+		new web3._extend.Method({
+			name: 'cancelGasLimitChange',
+			call: 'admin_cancelGasLimitChange',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
 		new web3._extend.Method({
 			name: 'sleepBlocks',
 			call: 'admin_sleepBlocks',
@@ -189,6 +207,25 @@ web3._extend({
 			name: 'stopWS',
 			call: 'admin_stopWS'
 		}),
+		new web3._extend.Method({
+			name: 'reloadConfig',
+			call: 'admin_reloadConfig'
+		}),
+		new web3._extend.Method({
+			name: 'copyState',
+			call: 'admin_copyState',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'stateLeaves',
+			call: 'admin_stateLeaves',
+			params: 3
+		}),
+		new web3._extend.Method({
+			name: 'stateCode',
+			call: 'admin_stateCode',
+			params: 1
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -203,6 +240,10 @@ web3._extend({
 			name: 'datadir',
 			getter: 'admin_datadir'
 		}),
+		new web3._extend.Property({
+			name: 'copyStateStatus',
+			getter: 'admin_copyStateStatus'
+		}),
 	]
 });
 `
@@ -217,6 +258,16 @@ web3._extend({
 			params: 6,
 			inputFormatter: [web3._extend.formatters.inputDefaultBlockNumberFormatter, null, null, null, null, null],
 		}),
+		new web3._extend.Method({
+			name: 'getCallTrace',
+			call: 'debug_getCallTrace',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getStateRootBlockNumbers',
+			call: 'debug_getStateRootBlockNumbers',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'printBlock',
 			call: 'debug_printBlock',
@@ -265,6 +316,16 @@ web3._extend({
 			name: 'chaindbCompact',
 			call: 'debug_chaindbCompact',
 		}),
+		new web3._extend.Method({
+			name: 'dbAncient',
+			call: 'debug_dbAncient',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'dbAncients',
+			call: 'debug_dbAncients',
+			params: 0
+		}),
 		new web3._extend.Method({
 			name: 'verbosity',
 			call: 'debug_verbosity',
@@ -443,6 +504,12 @@ web3._extend({
 			call: 'debug_getBadBlocks',
 			params: 0,
 		}),
+		new web3._extend.Method({
+			name: 'getOrderingAudit',
+			call: 'debug_getOrderingAudit',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter],
+		}),
 		new web3._extend.Method({
 			name: 'storageRangeAt',
 			call: 'debug_storageRangeAt',
@@ -533,6 +600,12 @@ web3._extend({
 			call: 'eth_getHeaderByHash',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'getHeadersByRange',
+			call: 'eth_getHeadersByRange',
+			params: 3,
+			inputFormatter: [web3._extend.utils.fromDecimal, web3._extend.utils.fromDecimal, null]
+		}),
 		new web3._extend.Method({
 			name: 'getBlockByNumber',
 			call: 'eth_getBlockByNumber',
@@ -550,6 +623,24 @@ web3._extend({
 			call: 'eth_getRawTransactionByHash',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'getTransactionStatus',
+			call: 'eth_getTransactionStatus',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getTransactionBySenderAndNonce',
+			call: 'eth_getTransactionBySenderAndNonce',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.utils.fromDecimal],
+			outputFormatter: web3._extend.formatters.outputTransactionFormatter
+		}),
+		new web3._extend.Method({
+			name: 'getTransactionsByAddress',
+			call: 'eth_getTransactionsByAddress',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.utils.fromDecimal, null]
+		}),
 		new web3._extend.Method({
 			name: 'getRawTransactionFromBlock',
 			call: function(args) {
@@ -564,6 +655,12 @@ web3._extend({
 			params: 3,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputBlockNumberFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'getAccount',
+			call: 'eth_getAccount',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
 		new web3._extend.Method({
 			name: 'createAccessList',
 			call: 'eth_createAccessList',
@@ -576,6 +673,30 @@ web3._extend({
 			params: 3,
 			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter, null]
 		}),
+		new web3._extend.Method({
+			name: 'callMany',
+			call: 'eth_callMany',
+			params: 3,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'simulateV1',
+			call: 'eth_simulateV1',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getLogsPaged',
+			call: 'eth_getLogsPaged',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'getBlockReceipts',
+			call: 'eth_getBlockReceipts',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -760,6 +881,29 @@ web3._extend({
 			call: 'txpool_contentFrom',
 			params: 1,
 		}),
+		new web3._extend.Method({
+			name: 'inspectFiltered',
+			call: 'txpool_inspectFiltered',
+			params: 1,
+		}),
+		new web3._extend.Method({
+			name: 'export',
+			call: 'txpool_export',
+		}),
+		new web3._extend.Method({
+			name: 'import',
+			call: 'txpool_import',
+			params: 1,
+		}),
+		new web3._extend.Method({
+			name: 'setPolicy',
+			call: 'txpool_setPolicy',
+			params: 1,
+		}),
+		new web3._extend.Property({
+			name: 'policy',
+			getter: 'txpool_policy'
+		}),
 	]
 });
 `
@@ -848,3 +992,53 @@ web3._extend({
 	]
 });
 `
+
+const ScrollJs = `
+web3._extend({
+	property: 'scroll',
+	methods:
+	[
+		new web3._extend.Method({
+			name: 'estimateWitnessSize',
+			call: 'scroll_estimateWitnessSize',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputCallFormatter, web3._extend.formatters.inputBlockNumberFormatter],
+		}),
+		new web3._extend.Method({
+			name: 'getTokenTransfers',
+			call: 'scroll_getTokenTransfers',
+			params: 1,
+		}),
+		new web3._extend.Method({
+			name: 'getLogsByEvent',
+			call: 'scroll_getLogsByEvent',
+			params: 1,
+		}),
+		new web3._extend.Method({
+			name: 'getPreconfirmation',
+			call: 'scroll_getPreconfirmation',
+			params: 1,
+		}),
+	]
+});
+`
+
+const WatchdogJs = `
+web3._extend({
+	property: 'watchdog',
+	methods:
+	[
+		new web3._extend.Method({
+			name: 'resume',
+			call: 'watchdog_resume',
+		}),
+	],
+	properties:
+	[
+		new web3._extend.Property({
+			name: 'status',
+			getter: 'watchdog_status',
+		}),
+	]
+});
+`
@@ -35,6 +35,7 @@ import (
 	"github.com/scroll-tech/go-ethereum/eth/gasprice"
 	"github.com/scroll-tech/go-ethereum/ethdb"
 	"github.com/scroll-tech/go-ethereum/event"
+	"github.com/scroll-tech/go-ethereum/internal/ethapi"
 	"github.com/scroll-tech/go-ethereum/light"
 	"github.com/scroll-tech/go-ethereum/params"
 	"github.com/scroll-tech/go-ethereum/rpc"
@@ -45,6 +46,7 @@ type LesApiBackend struct {
 	allowUnprotectedTxs bool
 	eth                 *LightEthereum
 	gpo                 *gasprice.Oracle
+	callCache           *ethapi.CallCache
 }
 
 func (b *LesApiBackend) ChainConfig() *params.ChainConfig {
@@ -211,6 +213,10 @@ func (b *LesApiBackend) GetTransaction(ctx context.Context, txHash common.Hash)
 	return light.GetTransaction(ctx, b.eth.odr, txHash)
 }
 
+func (b *LesApiBackend) GetTransactionBySenderAndNonce(ctx context.Context, sender common.Address, nonce uint64) (*types.Transaction, common.Hash, uint64, uint64, error) {
+	return nil, common.Hash{}, 0, 0, errors.New("sender and nonce index not available in light mode")
+}
+
 func (b *LesApiBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
 	return b.eth.txPool.GetNonce(ctx, addr)
 }
@@ -227,10 +233,36 @@ func (b *LesApiBackend) TxPoolContentFrom(addr common.Address) (types.Transactio
 	return b.eth.txPool.ContentFrom(addr)
 }
 
+func (b *LesApiBackend) TxPoolPending() map[common.Address]types.Transactions {
+	pending, _ := b.eth.txPool.Content()
+	return pending
+}
+
 func (b *LesApiBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
 	return b.eth.txPool.SubscribeNewTxsEvent(ch)
 }
 
+// TxPoolLocals returns no accounts, the light pool doesn't prioritize local
+// transactions.
+func (b *LesApiBackend) TxPoolLocals() []common.Address {
+	return nil
+}
+
+// TxPoolDropReason never finds a reason, the light pool doesn't evict
+// transactions.
+func (b *LesApiBackend) TxPoolDropReason(hash common.Hash) (core.TxDropReason, bool) {
+	return 0, false
+}
+
+// SubscribeDroppedTxsEvent returns a subscription that never fires, the light
+// pool doesn't evict transactions.
+func (b *LesApiBackend) SubscribeDroppedTxsEvent(ch chan<- core.DroppedTxsEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
 func (b *LesApiBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
 	return b.eth.blockchain.SubscribeChainEvent(ch)
 }
@@ -258,14 +290,27 @@ func (b *LesApiBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEven
 	return b.eth.blockchain.SubscribeRemovedLogsEvent(ch)
 }
 
+func (b *LesApiBackend) SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription {
+	return b.eth.blockchain.SubscribeReorgEvent(ch)
+}
+
 func (b *LesApiBackend) SyncProgress() ethereum.SyncProgress {
 	return b.eth.Downloader().Progress()
 }
 
+func (b *LesApiBackend) RecoveryProgress() *core.RecoveryProgress {
+	return nil
+}
+
 func (b *LesApiBackend) ProtocolVersion() int {
 	return b.eth.LesVersion() + 10000
 }
 
+// GasPriceOracle returns the oracle suggesting transaction fees.
+func (b *LesApiBackend) GasPriceOracle() *gasprice.Oracle {
+	return b.gpo
+}
+
 func (b *LesApiBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
 	return b.gpo.SuggestTipCap(ctx)
 }
@@ -298,6 +343,10 @@ func (b *LesApiBackend) RPCEVMTimeout() time.Duration {
 	return b.eth.config.RPCEVMTimeout
 }
 
+func (b *LesApiBackend) RPCCallCache() *ethapi.CallCache {
+	return b.callCache
+}
+
 func (b *LesApiBackend) RPCTxFeeCap() float64 {
 	return b.eth.config.RPCTxFeeCap
 }
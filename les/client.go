@@ -162,7 +162,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*LightEthereum, error) {
 		rawdb.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
 
-	leth.ApiBackend = &LesApiBackend{stack.Config().ExtRPCEnabled(), stack.Config().AllowUnprotectedTxs, leth, nil}
+	leth.ApiBackend = &LesApiBackend{stack.Config().ExtRPCEnabled(), stack.Config().AllowUnprotectedTxs, leth, nil, ethapi.NewCallCache(config.RPCCallCacheSize, config.RPCCallCacheTTL)}
 	gpoParams := config.GPO
 	if gpoParams.Default == nil {
 		gpoParams.Default = config.Miner.GasPrice
@@ -282,6 +282,14 @@ func (s *LightDummyAPI) Mining() bool {
 	return false
 }
 
+// logQueryLimits returns the log query limits configured for the filter API.
+func (s *LightEthereum) logQueryLimits() filters.LogQueryLimits {
+	return filters.LogQueryLimits{
+		MaxBlockRange: s.config.RPCLogQueryMaxBlocks,
+		MaxResults:    s.config.RPCLogQueryMaxResults,
+	}
+}
+
 // APIs returns the collection of RPC services the ethereum package offers.
 // NOTE, some of these services probably need to be moved to somewhere else.
 func (s *LightEthereum) APIs() []rpc.API {
@@ -301,7 +309,7 @@ func (s *LightEthereum) APIs() []rpc.API {
 		}, {
 			Namespace: "eth",
 			Version:   "1.0",
-			Service:   filters.NewPublicFilterAPI(s.ApiBackend, true, 5*time.Minute),
+			Service:   filters.NewPublicFilterAPI(s.ApiBackend, true, 5*time.Minute, s.logQueryLimits()),
 			Public:    true,
 		}, {
 			Namespace: "net",
@@ -0,0 +1,147 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/types"
+)
+
+// Reasons for leaving a candidate transaction out of a block.
+const (
+	reasonReplayProtected = "replay protected before EIP-155"
+	reasonWitnessLimit    = "witness limit reached"
+	reasonGasLimit        = "block gas limit reached"
+	reasonNonceTooLow     = "nonce too low"
+	reasonNonceTooHigh    = "nonce too high"
+	reasonTypeUnsupported = "transaction type not supported"
+	reasonAccountSkipped  = "account skipped" // An earlier transaction of the sender was left out
+	reasonNotReached      = "not reached"     // The block filled up or was sealed first
+	reasonFailedPrefix    = "failed: "        // Followed by the execution error
+)
+
+// orderingAudit collects the ordering decisions of the block being assembled.
+// All methods are no-ops on a nil audit, so that the worker doesn't need to check
+// whether auditing is enabled.
+type orderingAudit struct {
+	baseFee    *big.Int
+	candidates types.Transactions // Pending transactions seen, by sender and nonce
+	decisions  []*types.OrderingDecision
+	decided    map[common.Hash]struct{}
+	skipped    map[common.Address]string // Senders whose remaining transactions are skipped, with the reason
+}
+
+func newOrderingAudit(pending map[common.Address]types.Transactions, baseFee *big.Int) *orderingAudit {
+	senders := make([]common.Address, 0, len(pending))
+	for from := range pending {
+		senders = append(senders, from)
+	}
+	sort.Slice(senders, func(i, j int) bool { return bytes.Compare(senders[i][:], senders[j][:]) < 0 })
+
+	audit := &orderingAudit{
+		baseFee: baseFee,
+		decided: make(map[common.Hash]struct{}),
+		skipped: make(map[common.Address]string),
+	}
+	for _, from := range senders {
+		audit.candidates = append(audit.candidates, pending[from]...)
+	}
+	return audit
+}
+
+// decide records the decision for a transaction. An empty reason means that it
+// was included at the given position.
+func (a *orderingAudit) decide(tx *types.Transaction, from common.Address, index int, reason string) {
+	if a == nil {
+		return
+	}
+	decision := a.decision(tx, from, reason)
+	if reason == "" {
+		decision.Index = uint64(index)
+	}
+	a.decisions = append(a.decisions, decision)
+	a.decided[tx.Hash()] = struct{}{}
+}
+
+// skip records the decision for a transaction whose sender is skipped from
+// there on.
+func (a *orderingAudit) skip(tx *types.Transaction, from common.Address, reason string) {
+	if a == nil {
+		return
+	}
+	a.decide(tx, from, 0, reason)
+	a.skipped[from] = reason
+}
+
+func (a *orderingAudit) decision(tx *types.Transaction, from common.Address, reason string) *types.OrderingDecision {
+	tip, err := tx.EffectiveGasTip(a.baseFee)
+	if err != nil {
+		tip = new(big.Int) // Fee cap below the base fee
+	}
+	return &types.OrderingDecision{
+		Hash:     tx.Hash(),
+		Sender:   from,
+		Nonce:    tx.Nonce(),
+		Tip:      tip,
+		Included: reason == "",
+		Reason:   reason,
+	}
+}
+
+// finalize returns the audit of the block as assembled so far, with the
+// candidates not gone through yet at the end.
+func (a *orderingAudit) finalize(signer types.Signer) *types.OrderingAudit {
+	if a == nil {
+		return nil
+	}
+	audit := &types.OrderingAudit{
+		BaseFee:    a.baseFee,
+		Candidates: make([]*types.OrderingDecision, len(a.decisions), len(a.decisions)+len(a.candidates)),
+	}
+	copy(audit.Candidates, a.decisions)
+	for _, tx := range a.candidates {
+		if _, ok := a.decided[tx.Hash()]; ok {
+			continue
+		}
+		from, _ := types.Sender(signer, tx)
+		reason := reasonNotReached
+		if skipped, ok := a.skipped[from]; ok {
+			reason = reasonAccountSkipped + " (" + skipped + ")"
+		}
+		audit.Candidates = append(audit.Candidates, a.decision(tx, from, reason))
+	}
+	return audit
+}
+
+// WitnessBound reports whether the audited block left out transactions because
+// of the witness limit, meaning that its gas used is all the witness budget let
+// in rather than what the gas limit would have.
+func WitnessBound(audit *types.OrderingAudit) bool {
+	if audit == nil {
+		return false
+	}
+	for _, decision := range audit.Candidates {
+		if decision.Reason == reasonWitnessLimit {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/scroll-tech/go-ethereum/params"
+)
+
+var (
+	errGasLimitTooLow     = fmt.Errorf("gas limit below minimum of %d", params.MinGasLimit)
+	errPastGasLimitChange = errors.New("gas limit change not in the future")
+)
+
+// GasLimitChange schedules a change of the gas limit target from the given block
+// on. With a ramp, the target moves linearly from the previous one over the
+// given number of blocks. The protocol bound on the change between consecutive
+// blocks applies on top of the schedule.
+type GasLimitChange struct {
+	Number   uint64 // Block number the change takes effect at
+	GasLimit uint64 // Gas limit target once the change is complete
+	Ramp     uint64 `toml:",omitempty"` // Number of blocks to reach the target over
+}
+
+// gasLimitSchedule is a list of gas limit changes sorted by block number.
+type gasLimitSchedule []GasLimitChange
+
+// newGasLimitSchedule validates and sorts a list of gas limit changes.
+func newGasLimitSchedule(changes []GasLimitChange) (gasLimitSchedule, error) {
+	schedule := make(gasLimitSchedule, len(changes))
+	copy(schedule, changes)
+	sort.Slice(schedule, func(i, j int) bool { return schedule[i].Number < schedule[j].Number })
+
+	for i, change := range schedule {
+		if change.GasLimit < params.MinGasLimit {
+			return nil, fmt.Errorf("block %d: %w", change.Number, errGasLimitTooLow)
+		}
+		if i > 0 && schedule[i-1].Number == change.Number {
+			return nil, fmt.Errorf("block %d: duplicate gas limit change", change.Number)
+		}
+	}
+	return schedule, nil
+}
+
+// insert adds a change to the schedule, replacing any other one at the same block.
+func (s gasLimitSchedule) insert(change GasLimitChange) (gasLimitSchedule, error) {
+	if change.GasLimit < params.MinGasLimit {
+		return nil, errGasLimitTooLow
+	}
+	changes := make([]GasLimitChange, 0, len(s)+1)
+	for _, c := range s {
+		if c.Number != change.Number {
+			changes = append(changes, c)
+		}
+	}
+	return newGasLimitSchedule(append(changes, change))
+}
+
+// remove drops the change at the given block from the schedule.
+func (s gasLimitSchedule) remove(number uint64) (gasLimitSchedule, bool) {
+	changes := make(gasLimitSchedule, 0, len(s))
+	for _, c := range s {
+		if c.Number != number {
+			changes = append(changes, c)
+		}
+	}
+	return changes, len(changes) != len(s)
+}
+
+// target returns the gas limit target of the given block, falling back to the
+// base target before the first scheduled change.
+func (s gasLimitSchedule) target(number uint64, base uint64) uint64 {
+	for i := len(s) - 1; i >= 0; i-- {
+		change := s[i]
+		if change.Number > number {
+			continue
+		}
+		if change.Ramp == 0 || number-change.Number >= change.Ramp {
+			return change.GasLimit
+		}
+		// Interpolate from the target in force when the ramp started
+		var (
+			from     = new(big.Int).SetUint64(s[:i].target(change.Number, base))
+			to       = new(big.Int).SetUint64(change.GasLimit)
+			progress = new(big.Int).SetUint64(number - change.Number)
+		)
+		delta := new(big.Int).Sub(to, from)
+		delta.Mul(delta, progress)
+		delta.Quo(delta, new(big.Int).SetUint64(change.Ramp))
+		return from.Add(from, delta).Uint64()
+	}
+	return base
+}
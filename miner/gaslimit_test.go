@@ -0,0 +1,80 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGasLimitScheduleTarget(t *testing.T) {
+	schedule, err := newGasLimitSchedule([]GasLimitChange{
+		{Number: 200, GasLimit: 10_000_000, Ramp: 100},
+		{Number: 100, GasLimit: 20_000_000},
+		{Number: 250, GasLimit: 30_000_000, Ramp: 10},
+	})
+	if err != nil {
+		t.Fatalf("failed to create schedule: %v", err)
+	}
+	tests := []struct {
+		number uint64
+		target uint64
+	}{
+		{0, 8_000_000},    // base target before the first change
+		{99, 8_000_000},   // base target before the first change
+		{100, 20_000_000}, // immediate change
+		{199, 20_000_000},
+		{200, 20_000_000}, // ramp down starts
+		{225, 17_500_000},
+		{249, 15_100_000},
+		{250, 15_000_000}, // ramp up starts from the interrupted ramp down
+		{255, 22_500_000},
+		{260, 30_000_000}, // ramp complete
+		{1000, 30_000_000},
+	}
+	for _, tt := range tests {
+		if target := schedule.target(tt.number, 8_000_000); target != tt.target {
+			t.Errorf("block %d: target mismatch: have %d, want %d", tt.number, target, tt.target)
+		}
+	}
+}
+
+func TestGasLimitScheduleUpdate(t *testing.T) {
+	if _, err := newGasLimitSchedule([]GasLimitChange{{Number: 1, GasLimit: 1000}}); !errors.Is(err, errGasLimitTooLow) {
+		t.Fatalf("low gas limit accepted: %v", err)
+	}
+	if _, err := newGasLimitSchedule([]GasLimitChange{{Number: 1, GasLimit: 10_000_000}, {Number: 1, GasLimit: 20_000_000}}); err == nil {
+		t.Fatalf("duplicate change accepted")
+	}
+	var schedule gasLimitSchedule
+	for _, change := range []GasLimitChange{{Number: 20, GasLimit: 10_000_000}, {Number: 10, GasLimit: 20_000_000}, {Number: 20, GasLimit: 30_000_000}} {
+		var err error
+		if schedule, err = schedule.insert(change); err != nil {
+			t.Fatalf("failed to insert change: %v", err)
+		}
+	}
+	if len(schedule) != 2 || schedule[0].Number != 10 || schedule[1].GasLimit != 30_000_000 {
+		t.Fatalf("schedule mismatch: %v", schedule)
+	}
+	schedule, ok := schedule.remove(10)
+	if !ok || len(schedule) != 1 || schedule[0].Number != 20 {
+		t.Fatalf("failed to remove change: %v", schedule)
+	}
+	if _, ok := schedule.remove(10); ok {
+		t.Fatalf("removed missing change")
+	}
+}
@@ -52,6 +52,11 @@ type Config struct {
 	GasPrice   *big.Int       // Minimum gas price for mining a transaction
 	Recommit   time.Duration  // The time interval for miner to re-create mining work.
 	Noverify   bool           // Disable remote mining solution verification(only useful in ethash).
+
+	MaxWitnessNodes int  // Maximum estimated witness trie nodes of mined blocks (0 = unlimited)
+	OrderingAudit   bool `toml:",omitempty"` // Persist the ordering decisions of the sealed blocks
+
+	GasLimitSchedule []GasLimitChange `toml:",omitempty"` // Scheduled changes of the gas limit target, overriding GasCeil
 }
 
 // Miner creates blocks and searches for proof-of-work values.
@@ -157,6 +162,14 @@ func (miner *Miner) Stop() {
 	miner.stopCh <- struct{}{}
 }
 
+// Drain stops the miner from starting new blocks and waits up to the given timeout
+// for the one being sealed to be finished, after which it is abandoned. It reports
+// whether the in-flight block, if any, was finished.
+func (miner *Miner) Drain(timeout time.Duration) bool {
+	miner.Stop()
+	return miner.worker.drain(timeout)
+}
+
 func (miner *Miner) Close() {
 	close(miner.exitCh)
 	miner.wg.Wait()
@@ -216,6 +229,33 @@ func (miner *Miner) SetGasCeil(ceil uint64) {
 	miner.worker.setGasCeil(ceil)
 }
 
+// GasLimitSchedule returns the scheduled changes of the gas limit target.
+func (miner *Miner) GasLimitSchedule() []GasLimitChange {
+	return miner.worker.gasLimitSchedule()
+}
+
+// ScheduleGasLimit schedules a change of the gas limit target at a future block,
+// replacing any change already scheduled at the same block.
+func (miner *Miner) ScheduleGasLimit(change GasLimitChange) error {
+	if change.Number <= miner.eth.BlockChain().CurrentHeader().Number.Uint64() {
+		return errPastGasLimitChange
+	}
+	return miner.worker.scheduleGasLimit(change)
+}
+
+// CancelGasLimitChange removes the change of the gas limit target scheduled at
+// the given block, reporting whether there was one.
+func (miner *Miner) CancelGasLimitChange(number uint64) bool {
+	return miner.worker.cancelGasLimitChange(number)
+}
+
+// PinTransactions promises to include the given transactions by the expiry
+// block. Pinned transactions are included ahead of any other transaction, in
+// the order they were pinned in.
+func (miner *Miner) PinTransactions(txs types.Transactions, expiry uint64) error {
+	return miner.worker.pinTransactions(txs, expiry)
+}
+
 // EnablePreseal turns on the preseal mining feature. It's enabled by default.
 // Note this function shouldn't be exposed to API, it's unnecessary for users
 // (miners) to actually know the underlying detail. It's only for outside project
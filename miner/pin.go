@@ -0,0 +1,103 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/log"
+)
+
+// pinnedTx is a transaction promised to be included by a deadline. Pinned
+// transactions are committed ahead of any other one, in the order they were
+// pinned in.
+type pinnedTx struct {
+	tx     *types.Transaction
+	from   common.Address
+	expiry uint64 // Last block the transaction is promised to be included by
+}
+
+// pinTransactions pins the given transactions behind the ones already pinned,
+// skipping any pinned before.
+func (w *worker) pinTransactions(txs types.Transactions, expiry uint64) error {
+	signer := types.LatestSigner(w.chainConfig)
+
+	w.pinMu.Lock()
+	defer w.pinMu.Unlock()
+
+	for _, tx := range txs {
+		if w.pinnedSet[tx.Hash()] {
+			continue
+		}
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return err
+		}
+		w.pinned = append(w.pinned, &pinnedTx{tx: tx, from: from, expiry: expiry})
+		w.pinnedSet[tx.Hash()] = true
+	}
+	return nil
+}
+
+// commitPinnedTransactions commits the pinned transactions in order, ahead of
+// any other transaction. Transactions already included are unpinned, as are the
+// ones past their expiry. The senders of the committed transactions are returned.
+func (w *worker) commitPinnedTransactions() map[common.Address]struct{} {
+	w.pinMu.Lock()
+	defer w.pinMu.Unlock()
+
+	if w.current.gasPool == nil {
+		w.current.gasPool = new(core.GasPool).AddGas(w.current.header.GasLimit)
+	}
+	var (
+		number    = w.current.header.Number.Uint64()
+		committed = make(map[common.Address]struct{})
+		kept      = w.pinned[:0]
+	)
+	for _, p := range w.pinned {
+		switch {
+		case w.current.state.GetNonce(p.from) > p.tx.Nonce():
+			if w.chain.GetTransactionLookup(p.tx.Hash()) == nil {
+				log.Warn("Preconfirmed transaction replaced", "hash", p.tx.Hash(), "sender", p.from, "nonce", p.tx.Nonce())
+			}
+			delete(w.pinnedSet, p.tx.Hash())
+			continue
+
+		case p.expiry < number:
+			log.Warn("Preconfirmed transaction expired", "hash", p.tx.Hash(), "expiry", p.expiry)
+			delete(w.pinnedSet, p.tx.Hash())
+			continue
+		}
+		kept = append(kept, p)
+
+		w.current.state.Prepare(p.tx.Hash(), w.current.tcount)
+		if _, err := w.commitTransaction(p.tx, w.coinbase); err != nil {
+			// Retried in the next block until the expiry
+			log.Debug("Preconfirmed transaction postponed", "hash", p.tx.Hash(), "err", err)
+			continue
+		}
+		w.current.tcount++
+		w.current.audit.decide(p.tx, p.from, len(w.current.txs)-1, "")
+		committed[p.from] = struct{}{}
+	}
+	for i := len(kept); i < len(w.pinned); i++ {
+		w.pinned[i] = nil
+	}
+	w.pinned = kept
+	return committed
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/scroll-tech/go-ethereum/core/state"
 	"github.com/scroll-tech/go-ethereum/core/types"
 	"github.com/scroll-tech/go-ethereum/core/vm"
+	"github.com/scroll-tech/go-ethereum/crypto"
 	"github.com/scroll-tech/go-ethereum/event"
 	"github.com/scroll-tech/go-ethereum/log"
 	"github.com/scroll-tech/go-ethereum/params"
@@ -79,8 +80,16 @@ const (
 
 	// staleThreshold is the maximum depth of the acceptable stale block.
 	staleThreshold = 7
+
+	// drainPollInterval is the time interval to check whether the in-flight block
+	// was written while draining the worker.
+	drainPollInterval = 50 * time.Millisecond
 )
 
+// errWitnessLimitReached is returned by commitTransaction if a transaction grew
+// the witness of the block beyond the configured budget and was reverted.
+var errWitnessLimitReached = errors.New("witness limit reached")
+
 // environment is the worker's current environment and holds all of the current state information.
 type environment struct {
 	signer types.Signer
@@ -92,10 +101,15 @@ type environment struct {
 	tcount    int            // tx count in cycle
 	gasPool   *core.GasPool  // available gas used to pack transactions
 
+	witness *core.WitnessCollector // witness of the packed transactions, nil unless limited
+	audit   *orderingAudit         // ordering decisions, nil unless auditing
+
 	header           *types.Header
 	txs              []*types.Transaction
 	receipts         []*types.Receipt
 	executionResults []*types.ExecutionResult
+	traces           []*types.CallTrace // call traces, nil unless indexing call traces
+	internal         [][]common.Address // internal transfer parties, nil unless indexing address activity
 	proofs           map[string][]hexutil.Bytes
 	storageProofs    map[string]map[string][]hexutil.Bytes
 }
@@ -105,8 +119,11 @@ type task struct {
 	receipts         []*types.Receipt
 	executionResults []*types.ExecutionResult
 	storageResults   *types.StorageTrace
+	traces           []*types.CallTrace
+	internal         [][]common.Address
 	state            *state.StateDB
 	block            *types.Block
+	audit            *types.OrderingAudit
 	createdAt        time.Time
 }
 
@@ -173,6 +190,10 @@ type worker struct {
 	pendingMu    sync.RWMutex
 	pendingTasks map[common.Hash]*task
 
+	pinMu     sync.Mutex           // The lock used to protect the pinned transactions
+	pinned    []*pinnedTx          // Transactions promised to be included, in promise order
+	pinnedSet map[common.Hash]bool // Hashes of the pinned transactions
+
 	snapshotMu       sync.RWMutex // The lock used to protect the snapshots below
 	snapshotBlock    *types.Block
 	snapshotReceipts types.Receipts
@@ -212,6 +233,7 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 		remoteUncles:       make(map[common.Hash]*types.Block),
 		unconfirmed:        newUnconfirmedBlocks(eth.BlockChain(), miningLogAtDepth),
 		pendingTasks:       make(map[common.Hash]*task),
+		pinnedSet:          make(map[common.Hash]bool),
 		txsCh:              make(chan core.NewTxsEvent, txChanSize),
 		chainHeadCh:        make(chan core.ChainHeadEvent, chainHeadChanSize),
 		chainSideCh:        make(chan core.ChainSideEvent, chainSideChanSize),
@@ -235,6 +257,14 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 		log.Warn("Sanitizing miner recommit interval", "provided", recommit, "updated", minRecommitInterval)
 		recommit = minRecommitInterval
 	}
+	// Sort the configured gas limit schedule, dropping it if invalid.
+	if schedule, err := newGasLimitSchedule(worker.config.GasLimitSchedule); err != nil {
+		log.Error("Ignoring invalid gas limit schedule", "err", err)
+		worker.config.GasLimitSchedule = nil
+	} else if len(schedule) > 0 {
+		worker.config.GasLimitSchedule = schedule
+		log.Info("Loaded gas limit schedule", "changes", len(schedule), "first", schedule[0].Number)
+	}
 
 	worker.wg.Add(4)
 	go worker.mainLoop()
@@ -262,6 +292,42 @@ func (w *worker) setGasCeil(ceil uint64) {
 	w.config.GasCeil = ceil
 }
 
+// gasLimitSchedule returns a copy of the scheduled gas limit changes.
+func (w *worker) gasLimitSchedule() []GasLimitChange {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return append([]GasLimitChange(nil), w.config.GasLimitSchedule...)
+}
+
+// scheduleGasLimit adds a change to the gas limit schedule.
+func (w *worker) scheduleGasLimit(change GasLimitChange) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	schedule, err := gasLimitSchedule(w.config.GasLimitSchedule).insert(change)
+	if err != nil {
+		return err
+	}
+	w.config.GasLimitSchedule = schedule
+	return nil
+}
+
+// cancelGasLimitChange removes a change from the gas limit schedule.
+func (w *worker) cancelGasLimitChange(number uint64) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	schedule, ok := gasLimitSchedule(w.config.GasLimitSchedule).remove(number)
+	w.config.GasLimitSchedule = schedule
+	return ok
+}
+
+// gasLimitTarget returns the gas limit to strive for at the given block. The
+// caller must hold w.mu.
+func (w *worker) gasLimitTarget(number uint64) uint64 {
+	return gasLimitSchedule(w.config.GasLimitSchedule).target(number, w.config.GasCeil)
+}
+
 // setExtra sets the content used to initialize the block extra field.
 func (w *worker) setExtra(extra []byte) {
 	w.mu.Lock()
@@ -335,6 +401,44 @@ func (w *worker) close() {
 	w.wg.Wait()
 }
 
+// drain stops the worker from starting new sealing work and waits up to the given
+// timeout for the block being sealed to be written to the chain. It reports whether
+// no sealing work was left unfinished.
+func (w *worker) drain(timeout time.Duration) bool {
+	atomic.StoreInt32(&w.running, 0)
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	poll := time.NewTicker(drainPollInterval)
+	defer poll.Stop()
+
+	for w.sealing() {
+		select {
+		case <-poll.C:
+		case <-deadline.C:
+			return false
+		case <-w.exitCh:
+			return false
+		}
+	}
+	return true
+}
+
+// sealing reports whether a block above the current chain head is being sealed.
+func (w *worker) sealing() bool {
+	head := w.chain.CurrentBlock().NumberU64()
+
+	w.pendingMu.RLock()
+	defer w.pendingMu.RUnlock()
+
+	for _, task := range w.pendingTasks {
+		if task.block.NumberU64() > head {
+			return true
+		}
+	}
+	return false
+}
+
 // recalcRecommit recalculates the resubmitting interval upon feedback.
 func recalcRecommit(minRecommit, prev time.Duration, target float64, inc bool) time.Duration {
 	var (
@@ -673,12 +777,17 @@ func (w *worker) resultLoop() {
 				}
 				logs = append(logs, receipt.Logs...)
 			}
-			// Commit block and state to database.
-			_, err := w.chain.WriteBlockWithState(block, receipts, logs, evmTraces, storageTrace, task.state, true)
+			// Commit block and state to database, holding off database compactions.
+			done := w.chain.Compactor().Busy()
+			_, err := w.chain.WriteBlockWithState(block, receipts, logs, evmTraces, storageTrace, task.traces, task.internal, task.state, true)
+			done()
 			if err != nil {
 				log.Error("Failed writing block to chain", "err", err)
 				continue
 			}
+			if task.audit != nil {
+				w.chain.WriteOrderingAudit(hash, task.audit)
+			}
 			log.Info("Successfully sealed new block", "number", block.Number(), "sealhash", sealhash, "hash", hash,
 				"elapsed", common.PrettyDuration(time.Since(task.createdAt)))
 
@@ -736,6 +845,9 @@ func (w *worker) makeCurrent(parent *types.Block, header *types.Header) error {
 		proofs:        proofs,
 		storageProofs: make(map[string]map[string][]hexutil.Bytes),
 	}
+	if w.config.MaxWitnessNodes > 0 {
+		env.witness = core.NewWitnessCollector(state.Copy())
+	}
 	// when 08 is processed ancestors contain 07 (quick block)
 	for _, ancestor := range w.chain.GetBlocksFromHash(parent.Hash(), 7) {
 		for _, uncle := range ancestor.Uncles() {
@@ -835,11 +947,69 @@ func (w *worker) commitTransaction(tx *types.Transaction, coinbase common.Addres
 		}
 	}
 
-	receipt, err := core.ApplyTransaction(w.chainConfig, w.chain, &coinbase, w.current.gasPool, w.current.state, w.current.header, tx, &w.current.header.GasUsed, *w.chain.GetVMConfig())
+	// Collect the call frames and the internal value transfers next to the
+	// struct logs if the transactions are indexed by them, and the accessed
+	// state if the witness of the block is limited.
+	var (
+		vmConfig  = *w.chain.GetVMConfig()
+		tracers   = core.TracerMux{vmConfig.Tracer}
+		collector *core.CallTraceCollector
+		activity  *core.AddressActivityCollector
+		access    *vm.AccessListTracer
+		target    common.Address
+	)
+	if w.chain.CallTraceIndexed() {
+		collector = core.NewCallTraceCollector()
+		tracers = append(tracers, collector)
+	}
+	if w.chain.AddressActivityIndexed() {
+		activity = core.NewAddressActivityCollector()
+		tracers = append(tracers, activity)
+	}
+	if w.current.witness != nil {
+		if tx.To() != nil {
+			target = *tx.To()
+		} else {
+			target = crypto.CreateAddress(from, tx.Nonce())
+		}
+		precompiles := vm.ActivePrecompiles(w.chainConfig.Rules(w.current.header.Number))
+		access = vm.NewAccessListTracer(nil, from, target, precompiles)
+		tracers = append(tracers, access)
+	}
+	if len(tracers) > 1 {
+		vmConfig.Tracer = tracers
+	}
+	// Applying the transaction finalises the state, keep a copy to roll back
+	// to if it turns out to grow the witness too much.
+	var backup *state.StateDB
+	if access != nil && w.current.tcount > 0 {
+		backup = w.current.state.Copy()
+	}
+	receipt, err := core.ApplyTransaction(w.chainConfig, w.chain, &coinbase, w.current.gasPool, w.current.state, w.current.header, tx, &w.current.header.GasUsed, vmConfig)
 	if err != nil {
 		w.current.state.RevertToSnapshot(snap)
 		return nil, err
 	}
+	// Undo the transaction if it grew the witness beyond the budget. The first
+	// transaction is always allowed, so that a single large one doesn't stall
+	// the chain.
+	if access != nil {
+		accessed := append(types.AccessList{{Address: from}, {Address: target}, {Address: coinbase}}, access.AccessList()...)
+		if payer := tx.FeePayer(); payer != nil {
+			accessed = append(accessed, types.AccessTuple{Address: *payer})
+		}
+		size, err := w.current.witness.Add(accessed)
+		if err != nil {
+			log.Warn("Failed to collect transaction witness", "hash", tx.Hash(), "err", err)
+		} else if backup != nil && tx.Type() != types.SystemTxType && size.TrieNodes > w.config.MaxWitnessNodes {
+			w.current.witness.Revert()
+			w.current.state.StopPrefetcher()
+			w.current.state = backup
+			w.current.gasPool.AddGas(receipt.GasUsed)
+			w.current.header.GasUsed -= receipt.GasUsed
+			return nil, errWitnessLimitReached
+		}
+	}
 
 	createdAcc := tracer.CreatedAccount()
 	var after []*types.AccountWrapper
@@ -919,10 +1089,43 @@ func (w *worker) commitTransaction(tx *types.Transaction, coinbase common.Addres
 		ReturnValue:    fmt.Sprintf("%x", receipt.ReturnValue),
 		StructLogs:     vm.FormatLogs(tracer.StructLogs()),
 	})
-
+	if collector != nil {
+		var trace *types.CallTrace
+		if traces := collector.Traces(); len(traces) > 0 {
+			trace = traces[0]
+		}
+		w.current.traces = append(w.current.traces, trace)
+	}
+	if activity != nil {
+		var transfers []common.Address
+		if internal := activity.Transfers(); len(internal) > 0 {
+			transfers = internal[0]
+		}
+		w.current.internal = append(w.current.internal, transfers)
+	}
 	return receipt.Logs, nil
 }
 
+// commitSystemTransactions applies the system transactions the protocol requires
+// the current block to open with.
+func (w *worker) commitSystemTransactions() error {
+	txs, err := core.SystemTransactions(w.chainConfig, w.current.header, w.current.state)
+	if err != nil {
+		return err
+	}
+	if w.current.gasPool == nil {
+		w.current.gasPool = new(core.GasPool).AddGas(w.current.header.GasLimit)
+	}
+	for _, tx := range txs {
+		w.current.state.Prepare(tx.Hash(), w.current.tcount)
+		if _, err := w.commitTransaction(tx, w.coinbase); err != nil {
+			return err
+		}
+		w.current.tcount++
+	}
+	return nil
+}
+
 func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coinbase common.Address, interrupt *int32) bool {
 	// Short circuit if current is nil
 	if w.current == nil {
@@ -977,6 +1180,7 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 		if tx.Protected() && !w.chainConfig.IsEIP155(w.current.header.Number) {
 			log.Trace("Ignoring reply protected transaction", "hash", tx.Hash(), "eip155", w.chainConfig.EIP155Block)
 
+			w.current.audit.skip(tx, from, reasonReplayProtected)
 			txs.Pop()
 			continue
 		}
@@ -988,33 +1192,45 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 		case errors.Is(err, core.ErrGasLimitReached):
 			// Pop the current out-of-gas transaction without shifting in the next from the account
 			log.Trace("Gas limit exceeded for current block", "sender", from)
+			w.current.audit.skip(tx, from, reasonGasLimit)
+			txs.Pop()
+
+		case errors.Is(err, errWitnessLimitReached):
+			// Pop the transaction growing the witness too much without shifting in the next from the account
+			log.Trace("Witness limit exceeded for current block", "sender", from)
+			w.current.audit.skip(tx, from, reasonWitnessLimit)
 			txs.Pop()
 
 		case errors.Is(err, core.ErrNonceTooLow):
 			// New head notification data race between the transaction pool and miner, shift
 			log.Trace("Skipping transaction with low nonce", "sender", from, "nonce", tx.Nonce())
+			w.current.audit.decide(tx, from, 0, reasonNonceTooLow)
 			txs.Shift()
 
 		case errors.Is(err, core.ErrNonceTooHigh):
 			// Reorg notification data race between the transaction pool and miner, skip account =
 			log.Trace("Skipping account with hight nonce", "sender", from, "nonce", tx.Nonce())
+			w.current.audit.skip(tx, from, reasonNonceTooHigh)
 			txs.Pop()
 
 		case errors.Is(err, nil):
 			// Everything ok, collect the logs and shift in the next transaction from the same account
 			coalescedLogs = append(coalescedLogs, logs...)
 			w.current.tcount++
+			w.current.audit.decide(tx, from, len(w.current.txs)-1, "")
 			txs.Shift()
 
 		case errors.Is(err, core.ErrTxTypeNotSupported):
 			// Pop the unsupported transaction without shifting in the next from the account
 			log.Trace("Skipping unsupported transaction type", "sender", from, "type", tx.Type())
+			w.current.audit.skip(tx, from, reasonTypeUnsupported)
 			txs.Pop()
 
 		default:
 			// Strange error, discard the transaction and get the next in line (note, the
 			// nonce-too-high clause will prevent us from executing in vain).
 			log.Debug("Transaction failed, account skipped", "hash", tx.Hash(), "err", err)
+			w.current.audit.decide(tx, from, 0, reasonFailedPrefix+err.Error())
 			txs.Shift()
 		}
 	}
@@ -1043,32 +1259,45 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 }
 
 // commitNewWork generates several new sealing tasks based on the parent block.
+// MakeHeader assembles the header of the block following parent, moving the gas
+// limit towards gasTarget. If the base fee oracle is in force, the base fee is
+// read from the given parent state, or kept at the parent's one if it's nil.
+//
+// The coinbase, extra-data and consensus fields are left to the caller.
+func MakeHeader(config *params.ChainConfig, parent *types.Header, state misc.StateReader, timestamp uint64, gasTarget uint64) *types.Header {
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		GasLimit:   core.CalcGasLimit(parent.GasLimit, gasTarget),
+		Time:       timestamp,
+	}
+	// Set baseFee and GasLimit if we are on an EIP-1559 chain
+	if config.IsLondon(header.Number) {
+		header.BaseFee = misc.CalcBaseFeeFromState(config, parent, state)
+		if !config.IsLondon(parent.Number) {
+			parentGasLimit := parent.GasLimit * config.ElasticityMultiplier(header.Number)
+			header.GasLimit = core.CalcGasLimit(parentGasLimit, gasTarget)
+		}
+	}
+	return header
+}
+
 func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64) {
 	w.mu.RLock()
 	defer w.mu.RUnlock()
 
+	// Assembling a block is latency critical, hold off database compactions.
+	defer w.chain.Compactor().Busy()()
+
 	tstart := time.Now()
 	parent := w.chain.CurrentBlock()
 
 	if parent.Time() >= uint64(timestamp) {
 		timestamp = int64(parent.Time() + 1)
 	}
-	num := parent.Number()
-	header := &types.Header{
-		ParentHash: parent.Hash(),
-		Number:     num.Add(num, common.Big1),
-		GasLimit:   core.CalcGasLimit(parent.GasLimit(), w.config.GasCeil),
-		Extra:      w.extra,
-		Time:       uint64(timestamp),
-	}
-	// Set baseFee and GasLimit if we are on an EIP-1559 chain
-	if w.chainConfig.IsLondon(header.Number) {
-		header.BaseFee = misc.CalcBaseFee(w.chainConfig, parent.Header())
-		if !w.chainConfig.IsLondon(parent.Number()) {
-			parentGasLimit := parent.GasLimit() * params.ElasticityMultiplier
-			header.GasLimit = core.CalcGasLimit(parentGasLimit, w.config.GasCeil)
-		}
-	}
+	header := MakeHeader(w.chainConfig, parent.Header(), nil, uint64(timestamp), w.gasLimitTarget(parent.NumberU64()+1))
+	header.Extra = w.extra
+
 	// Only set the coinbase if our consensus engine is running (avoid spurious block rewards)
 	if w.isRunning() {
 		if w.coinbase == (common.Address{}) {
@@ -1102,6 +1331,9 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 	}
 	// Create the current work task and check any fork transitions needed
 	env := w.current
+	if header.BaseFee != nil && w.chainConfig.BaseFeeOracle(header.Number) != nil {
+		header.BaseFee = misc.CalcBaseFeeFromState(w.chainConfig, parent.Header(), env.state)
+	}
 	if w.chainConfig.DAOForkSupport && w.chainConfig.DAOForkBlock != nil && w.chainConfig.DAOForkBlock.Cmp(header.Number) == 0 {
 		misc.ApplyDAOHardFork(env.state)
 	}
@@ -1130,6 +1362,11 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 	commitUncles(w.localUncles)
 	commitUncles(w.remoteUncles)
 
+	// Open the block with the system transactions required by the protocol
+	if err := w.commitSystemTransactions(); err != nil {
+		log.Error("Failed to commit system transactions", "err", err)
+		return
+	}
 	// Create an empty block based on temporary copied state for
 	// sealing in advance without waiting block execution finished.
 	if !noempty && atomic.LoadUint32(&w.noempty) == 0 {
@@ -1138,13 +1375,43 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 
 	// Fill the block with all available pending transactions.
 	pending := w.eth.TxPool().Pending(true)
+	if w.config.OrderingAudit {
+		env.audit = newOrderingAudit(pending, header.BaseFee)
+	}
+	// Include the transactions promised to be included first, dropping them and
+	// any transaction they replace from the pending ones.
+	pinned := w.commitPinnedTransactions()
+	for from := range pinned {
+		nonce := env.state.GetNonce(from)
+		txs := pending[from]
+		for len(txs) > 0 && txs[0].Nonce() < nonce {
+			txs = txs[1:]
+		}
+		if len(txs) == 0 {
+			delete(pending, from)
+		} else {
+			pending[from] = txs
+		}
+	}
 	// Short circuit if there is no available pending transactions.
 	// But if we disable empty precommit already, ignore it. Since
 	// empty block is necessary to keep the liveness of the network.
-	if len(pending) == 0 && atomic.LoadUint32(&w.noempty) == 0 {
+	if len(pending) == 0 && len(pinned) == 0 && atomic.LoadUint32(&w.noempty) == 0 {
 		w.updateSnapshot()
 		return
 	}
+	// Warm the zk trie caches by running the pending transactions in parallel
+	// while they are executed one by one below.
+	var (
+		prefetchTxs       types.Transactions
+		prefetchInterrupt uint32
+	)
+	for _, txs := range pending {
+		prefetchTxs = append(prefetchTxs, txs...)
+	}
+	w.chain.PrefetchTransactions(header, prefetchTxs, w.current.state, &prefetchInterrupt)
+	defer atomic.StoreUint32(&prefetchInterrupt, 1)
+
 	// Split the pending transactions into locals and remotes
 	localTxs, remoteTxs := make(map[common.Address]types.Transactions), pending
 	for _, account := range w.eth.TxPool().Locals() {
@@ -1193,7 +1460,7 @@ func (w *worker) commit(uncles []*types.Header, interval func(), update bool, st
 			interval()
 		}
 		select {
-		case w.taskCh <- &task{receipts: receipts, executionResults: w.current.executionResults, storageResults: storage, state: s, block: block, createdAt: time.Now()}:
+		case w.taskCh <- &task{receipts: receipts, executionResults: w.current.executionResults, storageResults: storage, traces: w.current.traces, internal: w.current.internal, state: s, block: block, audit: w.current.audit.finalize(w.current.signer), createdAt: time.Now()}:
 			w.unconfirmed.Shift(block.NumberU64() - 1)
 			log.Info("Commit new mining work", "number", block.Number(), "sealhash", w.engine.SealHash(block.Header()),
 				"uncles", len(uncles), "txs", w.current.tcount,
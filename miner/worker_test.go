@@ -525,3 +525,295 @@ func testAdjustInterval(t *testing.T, chainConfig *params.ChainConfig, engine co
 		t.Error("interval reset timeout")
 	}
 }
+
+func TestWorkerDrain(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, b := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	// A block which is never sealed must be abandoned after the timeout
+	stuck := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1)})
+	w.pendingMu.Lock()
+	w.pendingTasks[w.engine.SealHash(stuck.Header())] = &task{block: stuck}
+	w.pendingMu.Unlock()
+
+	if w.drain(100 * time.Millisecond) {
+		t.Fatal("unsealed block not abandoned")
+	}
+	w.pendingMu.Lock()
+	delete(w.pendingTasks, w.engine.SealHash(stuck.Header()))
+	w.pendingMu.Unlock()
+
+	// The block in flight must be finished while draining a running worker
+	sub := w.mux.Subscribe(core.NewMinedBlockEvent{})
+	w.start()
+	select {
+	case <-sub.Chan():
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout")
+	}
+	sub.Unsubscribe() // Posting mined blocks would block on an unread subscription
+
+	if !w.drain(3 * time.Second) {
+		t.Fatal("in-flight block not finished")
+	}
+	if w.isRunning() {
+		t.Fatal("drained worker still running")
+	}
+	if w.sealing() {
+		t.Fatalf("block above head %d still being sealed", b.chain.CurrentBlock().NumberU64())
+	}
+}
+
+func TestOrderingAudit(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	db := rawdb.NewMemoryDatabase()
+	b := newTestWorkerBackend(t, ethashChainConfig, engine, db, 0)
+	config := *testConfig
+	config.OrderingAudit = true
+	w := newWorker(&config, ethashChainConfig, engine, b, new(event.TypeMux), nil, false)
+	w.setEtherbase(testBankAddress)
+	defer w.close()
+
+	// Ignore empty commit here for less noise.
+	w.skipSealHook = func(task *task) bool {
+		return len(task.receipts) == 0
+	}
+	sub := w.mux.Subscribe(core.NewMinedBlockEvent{})
+	defer sub.Unsubscribe()
+
+	b.txPool.AddLocal(b.newRandomTx(false))
+	w.start()
+
+	var block *types.Block
+	select {
+	case ev := <-sub.Chan():
+		block = ev.Data.(core.NewMinedBlockEvent).Block
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout")
+	}
+	audit := rawdb.ReadOrderingAudit(db, block.Hash())
+	if audit == nil {
+		t.Fatal("no ordering audit stored for the mined block")
+	}
+	if audit.BaseFee.Cmp(block.BaseFee()) != 0 {
+		t.Errorf("base fee mismatch: have %v, want %v", audit.BaseFee, block.BaseFee())
+	}
+	included := 0
+	for _, decision := range audit.Candidates {
+		if !decision.Included {
+			continue
+		}
+		if decision.Index >= uint64(len(block.Transactions())) {
+			t.Fatalf("transaction %x included at index %d out of range", decision.Hash, decision.Index)
+		}
+		if have := block.Transactions()[decision.Index].Hash(); have != decision.Hash {
+			t.Errorf("transaction %d mismatch: have %x, want %x", decision.Index, have, decision.Hash)
+		}
+		if decision.Sender != testBankAddress {
+			t.Errorf("transaction %x sender mismatch: have %x, want %x", decision.Hash, decision.Sender, testBankAddress)
+		}
+		included++
+	}
+	if included != len(block.Transactions()) {
+		t.Errorf("included transaction count mismatch: have %d, want %d", included, len(block.Transactions()))
+	}
+}
+
+func TestOrderingAuditFinalize(t *testing.T) {
+	signer := types.LatestSigner(params.TestChainConfig)
+	txs := make(types.Transactions, 3)
+	for i := range txs {
+		txs[i] = types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{
+			Nonce:    uint64(i),
+			To:       &testUserAddress,
+			Gas:      params.TxGas,
+			GasPrice: big.NewInt(params.InitialBaseFee),
+		})
+	}
+	userTx := types.MustSignNewTx(testUserKey, signer, &types.LegacyTx{
+		To:       &testBankAddress,
+		Gas:      params.TxGas,
+		GasPrice: big.NewInt(params.InitialBaseFee),
+	})
+	pending := map[common.Address]types.Transactions{
+		testBankAddress: txs,
+		testUserAddress: {userTx},
+	}
+	a := newOrderingAudit(pending, big.NewInt(params.InitialBaseFee/2))
+	a.decide(txs[0], testBankAddress, 0, "")
+	a.skip(txs[1], testBankAddress, reasonGasLimit)
+
+	audit := a.finalize(signer)
+	want := map[common.Hash]string{
+		txs[0].Hash(): "",
+		txs[1].Hash(): reasonGasLimit,
+		txs[2].Hash(): reasonAccountSkipped + " (" + reasonGasLimit + ")",
+		userTx.Hash(): reasonNotReached,
+	}
+	if len(audit.Candidates) != len(want) {
+		t.Fatalf("candidate count mismatch: have %d, want %d", len(audit.Candidates), len(want))
+	}
+	for _, decision := range audit.Candidates {
+		if reason, ok := want[decision.Hash]; !ok || decision.Reason != reason {
+			t.Errorf("transaction %x reason mismatch: have %q, want %q", decision.Hash, decision.Reason, reason)
+		}
+		if decision.Tip.Cmp(big.NewInt(params.InitialBaseFee/2)) != 0 {
+			t.Errorf("transaction %x tip mismatch: have %v", decision.Hash, decision.Tip)
+		}
+	}
+	if (*orderingAudit)(nil).finalize(signer) != nil {
+		t.Error("disabled audit produced a result")
+	}
+}
+
+func TestPinTransactions(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, _ := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	// Pin a transaction missing from the pool behind a pooled one, along with
+	// one that can't be included before its expiry
+	signer := types.LatestSigner(ethashChainConfig)
+	stuck := types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{
+		Nonce:    5,
+		To:       &testUserAddress,
+		Gas:      params.TxGas,
+		GasPrice: big.NewInt(params.InitialBaseFee),
+	})
+	if err := w.pinTransactions(types.Transactions{pendingTxs[0], newTxs[0]}, 2); err != nil {
+		t.Fatalf("failed to pin transactions: %v", err)
+	}
+	if err := w.pinTransactions(types.Transactions{pendingTxs[0], stuck}, 0); err != nil {
+		t.Fatalf("failed to pin transactions: %v", err)
+	}
+	w.commitNewWork(nil, true, time.Now().Unix())
+
+	txs := w.pendingBlock().Transactions()
+	if len(txs) != 2 || txs[0].Hash() != pendingTxs[0].Hash() || txs[1].Hash() != newTxs[0].Hash() {
+		t.Fatalf("pinned transactions not included in order: have %d transactions", len(txs))
+	}
+	w.pinMu.Lock()
+	defer w.pinMu.Unlock()
+
+	if len(w.pinned) != 2 || w.pinnedSet[stuck.Hash()] {
+		t.Fatalf("pinned transaction count mismatch: have %d, want 2", len(w.pinned))
+	}
+}
+
+// Tests that the witness budget of sealed blocks counts the trie nodes shared by
+// their transactions once.
+func TestWitnessLimit(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	// Fill the state up, so that the proofs consist of more than the root node
+	alloc := core.GenesisAlloc{testBankAddress: {Balance: testBankFunds}}
+	for i := int64(1); i <= 16; i++ {
+		alloc[common.BigToAddress(big.NewInt(i))] = core.GenesisAccount{Balance: common.Big1}
+	}
+	for _, slack := range []int{0, -1} {
+		db := rawdb.NewMemoryDatabase()
+		gspec := core.Genesis{Config: ethashChainConfig, Alloc: alloc}
+		gspec.MustCommit(db)
+
+		chain, _ := core.NewBlockChain(db, &core.CacheConfig{TrieDirtyDisabled: true}, gspec.Config, engine, vm.Config{
+			Debug:  true,
+			Tracer: vm.NewStructLogger(&vm.LogConfig{EnableMemory: true})}, nil, nil)
+		backend := &testWorkerBackend{db: db, chain: chain, txPool: core.NewTxPool(testTxPoolConfig, ethashChainConfig, chain), genesis: &gspec}
+		backend.txPool.AddLocals(append(pendingTxs, newTxs...))
+
+		// Both transactions access the same accounts, so their witness is the
+		// one of a single transaction
+		statedb, _ := backend.chain.State()
+		size, err := core.NewWitnessCollector(statedb).Add(types.AccessList{{Address: testBankAddress}, {Address: testUserAddress}})
+		if err != nil {
+			t.Fatalf("failed to measure witness: %v", err)
+		}
+		config := *testConfig
+		config.MaxWitnessNodes = size.TrieNodes + slack
+
+		w := newWorker(&config, ethashChainConfig, engine, backend, new(event.TypeMux), nil, false)
+		w.setEtherbase(testBankAddress)
+		w.commitNewWork(nil, true, time.Now().Unix())
+
+		want := 2
+		if slack < 0 {
+			want = 1 // the first transaction is always included
+		}
+		if have := len(w.pendingBlock().Transactions()); have != want {
+			t.Errorf("slack %d: transaction count mismatch: have %d, want %d", slack, have, want)
+		}
+		w.close()
+		backend.txPool.Stop()
+		chain.Stop()
+	}
+}
+
+// Tests that locally sealed blocks index the call traces and the internal value
+// transfers of their transactions, just like imported ones.
+func TestSealedBlockIndexes(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	var (
+		forwarder   = common.HexToAddress("0xf0")
+		beneficiary = common.HexToAddress("0xbb")
+
+		// forwarder calls the beneficiary with the received value
+		code = append(append([]byte{0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x34, 0x73}, beneficiary.Bytes()...), 0x5a, 0xf1, 0x00)
+	)
+	db := rawdb.NewMemoryDatabase()
+	gspec := core.Genesis{
+		Config: ethashChainConfig,
+		Alloc: core.GenesisAlloc{
+			testBankAddress: {Balance: testBankFunds},
+			forwarder:       {Balance: common.Big0, Code: code},
+		},
+	}
+	gspec.MustCommit(db)
+
+	chain, _ := core.NewBlockChain(db, &core.CacheConfig{TrieDirtyDisabled: true, CallTraceIndex: true, AddressActivity: true}, gspec.Config, engine, vm.Config{
+		Debug:  true,
+		Tracer: vm.NewStructLogger(&vm.LogConfig{EnableMemory: true})}, nil, nil)
+	defer chain.Stop()
+
+	b := &testWorkerBackend{db: db, chain: chain, txPool: core.NewTxPool(testTxPoolConfig, ethashChainConfig, chain), genesis: &gspec}
+	w := newWorker(testConfig, ethashChainConfig, engine, b, new(event.TypeMux), nil, false)
+	w.setEtherbase(testBankAddress)
+	defer w.close()
+
+	w.skipSealHook = func(task *task) bool {
+		return len(task.receipts) == 0
+	}
+	sub := w.mux.Subscribe(core.NewMinedBlockEvent{})
+	defer sub.Unsubscribe()
+
+	tx, _ := types.SignTx(types.NewTransaction(0, forwarder, big.NewInt(1000), 100000, big.NewInt(10*params.InitialBaseFee), nil), types.HomesteadSigner{}, testBankKey)
+	b.txPool.AddLocal(tx)
+	w.start()
+
+	var block *types.Block
+	select {
+	case ev := <-sub.Chan():
+		block = ev.Data.(core.NewMinedBlockEvent).Block
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout")
+	}
+	if trace := chain.GetCallTrace(tx.Hash()); trace == nil || len(trace.Calls) != 1 || trace.Calls[0].To != beneficiary {
+		t.Fatalf("call trace mismatch: %+v", trace)
+	}
+	entries, _ := chain.GetAddressActivity(beneficiary, 0, 100)
+	if len(entries) != 1 {
+		t.Fatalf("beneficiary activity mismatch: have %d entries, want 1", len(entries))
+	}
+	if entries[0].BlockHash != block.Hash() || entries[0].TxHash != tx.Hash() || entries[0].Flags != rawdb.ActivityInternal {
+		t.Errorf("beneficiary entry mismatch: %+v", entries[0])
+	}
+}
@@ -185,6 +185,8 @@ func (api *privateAdminAPI) StartHTTP(host *string, port *int, cors *string, api
 		CorsAllowedOrigins: api.node.config.HTTPCors,
 		Vhosts:             api.node.config.HTTPVirtualHosts,
 		Modules:            api.node.config.HTTPModules,
+		BatchLimits:        api.node.config.rpcBatchLimits(),
+		apiKeys:            api.node.apiKeys,
 	}
 	if cors != nil {
 		config.CorsAllowedOrigins = nil
@@ -258,6 +260,9 @@ func (api *privateAdminAPI) StartWS(host *string, port *int, allowedOrigins *str
 	config := wsConfig{
 		Modules: api.node.config.WSModules,
 		Origins: api.node.config.WSOrigins,
+		Limits:  api.node.config.wsSubscriptionLimits(),
+		Batch:   api.node.config.rpcBatchLimits(),
+		apiKeys: api.node.apiKeys,
 		// ExposeAll: api.node.config.WSExposeAll,
 	}
 	if apis != nil {
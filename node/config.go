@@ -65,6 +65,14 @@ type Config struct {
 	// in memory.
 	DataDir string
 
+	// ReadOnly opens all databases of the data directory in read-only mode. Any
+	// writes done by the node are kept in memory and discarded on shutdown.
+	ReadOnly bool `toml:",omitempty"`
+
+	// AncientRemote maps tables of the ancient chain store to the URL of the
+	// object storage their complete data files are offloaded to.
+	AncientRemote map[string]string `toml:",omitempty"`
+
 	// Configuration of peer-to-peer networking.
 	P2P p2p.Config
 
@@ -160,6 +168,35 @@ type Config struct {
 	// exposed.
 	WSModules []string
 
+	// WSMaxSubscriptions is the maximum number of active subscriptions a single
+	// websocket connection may hold. Zero means unlimited.
+	WSMaxSubscriptions int `toml:",omitempty"`
+
+	// WSNotificationBuffer is the maximum number of notifications queued for each
+	// subscription of a websocket connection. Zero disables the queue, notifying
+	// then blocks until the consumer has read the message.
+	WSNotificationBuffer int `toml:",omitempty"`
+
+	// WSDisconnectSlow closes the connection of a websocket consumer overflowing
+	// its notification buffer, instead of dropping its oldest notifications.
+	WSDisconnectSlow bool `toml:",omitempty"`
+
+	// RPCBatchItemLimit is the maximum number of calls in a JSON-RPC batch served
+	// over HTTP or WebSocket. Zero means unlimited.
+	RPCBatchItemLimit int `toml:",omitempty"`
+
+	// RPCBatchCostLimit is the maximum sum of the method weights of the calls in
+	// a JSON-RPC batch served over HTTP or WebSocket. Zero means unlimited.
+	RPCBatchCostLimit uint64 `toml:",omitempty"`
+
+	// RPCMethodWeights are the batch cost weights of the expensive RPC methods,
+	// methods not listed weigh 1.
+	RPCMethodWeights map[string]uint64 `toml:",omitempty"`
+
+	// RPCAPIKeys is the path of the API keys file. When set, calls served over HTTP
+	// and WebSocket require a known key and are subject to the quotas of its tier.
+	RPCAPIKeys string `toml:",omitempty"`
+
 	// WSExposeAll exposes all API modules via the WebSocket RPC interface rather
 	// than just the public ones.
 	//
@@ -261,6 +298,24 @@ func (c *Config) WSEndpoint() string {
 	return fmt.Sprintf("%s:%d", c.WSHost, c.WSPort)
 }
 
+// wsSubscriptionLimits returns the subscription limits of the websocket connections.
+func (c *Config) wsSubscriptionLimits() rpc.SubscriptionLimits {
+	return rpc.SubscriptionLimits{
+		MaxSubscriptions:   c.WSMaxSubscriptions,
+		NotificationBuffer: c.WSNotificationBuffer,
+		DisconnectSlow:     c.WSDisconnectSlow,
+	}
+}
+
+// rpcBatchLimits returns the batch limits of the HTTP and websocket endpoints.
+func (c *Config) rpcBatchLimits() rpc.BatchLimits {
+	return rpc.BatchLimits{
+		MaxItems: c.RPCBatchItemLimit,
+		MaxCost:  c.RPCBatchCostLimit,
+		Weights:  c.RPCMethodWeights,
+	}
+}
+
 // DefaultWSEndpoint returns the websocket endpoint used by default.
 func DefaultWSEndpoint() string {
 	config := &Config{WSHost: DefaultWSHost, WSPort: DefaultWSPort}
@@ -36,6 +36,19 @@ const (
 	DefaultGraphQLPort = 8547        // Default TCP port for the GraphQL server
 )
 
+// DefaultMethodWeights are the batch cost weights of the expensive RPC methods,
+// other methods weigh 1.
+var DefaultMethodWeights = map[string]uint64{
+	"eth_call":                 5,
+	"eth_estimateGas":          10,
+	"eth_createAccessList":     10,
+	"eth_getLogs":              20,
+	"debug_traceCall":          50,
+	"debug_traceTransaction":   50,
+	"debug_traceBlockByNumber": 100,
+	"debug_traceBlockByHash":   100,
+}
+
 // DefaultConfig contains reasonable default settings.
 var DefaultConfig = Config{
 	DataDir:             DefaultDataDir(),
@@ -45,6 +58,9 @@ var DefaultConfig = Config{
 	HTTPTimeouts:        rpc.DefaultHTTPTimeouts,
 	WSPort:              DefaultWSPort,
 	WSModules:           []string{"net", "web3"},
+	RPCBatchItemLimit:   1000,
+	RPCBatchCostLimit:   5000,
+	RPCMethodWeights:    DefaultMethodWeights,
 	GraphQLVirtualHosts: []string{"localhost"},
 	P2P: p2p.Config{
 		ListenAddr: ":30303",
@@ -32,6 +32,7 @@ import (
 	"github.com/scroll-tech/go-ethereum/core/rawdb"
 	"github.com/scroll-tech/go-ethereum/ethdb"
 	"github.com/scroll-tech/go-ethereum/event"
+	"github.com/scroll-tech/go-ethereum/internal/apikeys"
 	"github.com/scroll-tech/go-ethereum/log"
 	"github.com/scroll-tech/go-ethereum/p2p"
 	"github.com/scroll-tech/go-ethereum/rpc"
@@ -52,12 +53,13 @@ type Node struct {
 	state         int               // Tracks state of node lifecycle
 
 	lock          sync.Mutex
-	lifecycles    []Lifecycle // All registered backends, services, and auxiliary services that have a lifecycle
-	rpcAPIs       []rpc.API   // List of APIs currently provided by the node
-	http          *httpServer //
-	ws            *httpServer //
-	ipc           *ipcServer  // Stores information about the ipc http server
-	inprocHandler *rpc.Server // In-process RPC request handler to process the API requests
+	lifecycles    []Lifecycle    // All registered backends, services, and auxiliary services that have a lifecycle
+	rpcAPIs       []rpc.API      // List of APIs currently provided by the node
+	http          *httpServer    //
+	ws            *httpServer    //
+	ipc           *ipcServer     // Stores information about the ipc http server
+	inprocHandler *rpc.Server    // In-process RPC request handler to process the API requests
+	apiKeys       *apikeys.Store // API keys of the HTTP and WebSocket endpoints, if configured
 
 	databases map[*closeTrackingDB]struct{} // All open databases
 }
@@ -146,6 +148,13 @@ func New(conf *Config) (*Node, error) {
 		return nil, err
 	}
 
+	// Load the API keys of the public RPC endpoints.
+	if conf.RPCAPIKeys != "" {
+		if node.apiKeys, err = apikeys.Load(conf.RPCAPIKeys); err != nil {
+			return nil, err
+		}
+	}
+
 	// Configure RPC servers.
 	node.http = newHTTPServer(node.log, conf.HTTPTimeouts)
 	node.ws = newHTTPServer(node.log, rpc.DefaultHTTPTimeouts)
@@ -357,6 +366,8 @@ func (n *Node) startRPC() error {
 			CorsAllowedOrigins: n.config.HTTPCors,
 			Vhosts:             n.config.HTTPVirtualHosts,
 			Modules:            n.config.HTTPModules,
+			BatchLimits:        n.config.rpcBatchLimits(),
+			apiKeys:            n.apiKeys,
 			prefix:             n.config.HTTPPathPrefix,
 		}
 		if err := n.http.setListenAddr(n.config.HTTPHost, n.config.HTTPPort); err != nil {
@@ -373,6 +384,9 @@ func (n *Node) startRPC() error {
 		config := wsConfig{
 			Modules: n.config.WSModules,
 			Origins: n.config.WSOrigins,
+			Limits:  n.config.wsSubscriptionLimits(),
+			Batch:   n.config.rpcBatchLimits(),
+			apiKeys: n.apiKeys,
 			prefix:  n.config.WSPathPrefix,
 		}
 		if err := server.setListenAddr(n.config.WSHost, n.config.WSPort); err != nil {
@@ -496,6 +510,26 @@ func (n *Node) Config() *Config {
 	return n.config
 }
 
+// SetRPCLimits replaces the JSON-RPC batch limits and the websocket subscription
+// limits of the node with the ones in conf, applying them to the running HTTP and
+// WebSocket endpoints. Websocket connections opened earlier keep the subscription
+// limits they were established with.
+func (n *Node) SetRPCLimits(conf *Config) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.config.RPCBatchItemLimit = conf.RPCBatchItemLimit
+	n.config.RPCBatchCostLimit = conf.RPCBatchCostLimit
+	n.config.RPCMethodWeights = conf.RPCMethodWeights
+	n.config.WSMaxSubscriptions = conf.WSMaxSubscriptions
+	n.config.WSNotificationBuffer = conf.WSNotificationBuffer
+	n.config.WSDisconnectSlow = conf.WSDisconnectSlow
+
+	batch, subs := n.config.rpcBatchLimits(), n.config.wsSubscriptionLimits()
+	n.http.setLimits(batch, subs)
+	n.ws.setLimits(batch, subs)
+}
+
 // Server retrieves the currently running P2P network layer. This method is meant
 // only to inspect fields of the currently running server. Callers should not
 // start or stop the returned server.
@@ -567,7 +601,10 @@ func (n *Node) OpenDatabase(name string, cache, handles int, namespace string, r
 	if n.config.DataDir == "" {
 		db = rawdb.NewMemoryDatabase()
 	} else {
-		db, err = rawdb.NewLevelDBDatabase(n.ResolvePath(name), cache, handles, namespace, readonly)
+		db, err = rawdb.NewLevelDBDatabase(n.ResolvePath(name), cache, handles, namespace, readonly || n.config.ReadOnly)
+		if err == nil && n.config.ReadOnly {
+			db = rawdb.NewReadOnlyDatabase(db)
+		}
 	}
 
 	if err == nil {
@@ -600,7 +637,16 @@ func (n *Node) OpenDatabaseWithFreezer(name string, cache, handles int, freezer,
 		case !filepath.IsAbs(freezer):
 			freezer = n.ResolvePath(freezer)
 		}
-		db, err = rawdb.NewLevelDBDatabaseWithFreezer(root, cache, handles, freezer, namespace, readonly)
+		stores := make(map[string]rawdb.FreezerStore)
+		for table, url := range n.config.AncientRemote {
+			if stores[table], err = rawdb.NewFreezerStore(url); err != nil {
+				return nil, fmt.Errorf("ancient store of %s: %v", table, err)
+			}
+		}
+		db, err = rawdb.NewLevelDBDatabaseWithFreezerStores(root, cache, handles, freezer, namespace, readonly || n.config.ReadOnly, stores)
+		if err == nil && n.config.ReadOnly {
+			db = rawdb.NewReadOnlyDatabase(db)
+		}
 	}
 
 	if err == nil {
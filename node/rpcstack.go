@@ -31,6 +31,7 @@ import (
 
 	"github.com/rs/cors"
 
+	"github.com/scroll-tech/go-ethereum/internal/apikeys"
 	"github.com/scroll-tech/go-ethereum/log"
 	"github.com/scroll-tech/go-ethereum/rpc"
 )
@@ -40,14 +41,19 @@ type httpConfig struct {
 	Modules            []string
 	CorsAllowedOrigins []string
 	Vhosts             []string
-	prefix             string // path prefix on which to mount http handler
+	BatchLimits        rpc.BatchLimits
+	apiKeys            *apikeys.Store // API keys the calls are authenticated against
+	prefix             string         // path prefix on which to mount http handler
 }
 
 // wsConfig is the JSON-RPC/Websocket configuration
 type wsConfig struct {
 	Origins []string
 	Modules []string
-	prefix  string // path prefix on which to mount ws handler
+	Limits  rpc.SubscriptionLimits
+	Batch   rpc.BatchLimits
+	apiKeys *apikeys.Store // API keys the calls are authenticated against
+	prefix  string         // path prefix on which to mount ws handler
 }
 
 type rpcHandler struct {
@@ -281,17 +287,35 @@ func (h *httpServer) enableRPC(apis []rpc.API, config httpConfig) error {
 
 	// Create RPC server and handler.
 	srv := rpc.NewServer()
+	srv.SetBatchLimits(config.BatchLimits)
 	if err := RegisterApis(apis, config.Modules, srv, false); err != nil {
 		return err
 	}
 	h.httpConfig = config
 	h.httpHandler.Store(&rpcHandler{
-		Handler: NewHTTPHandlerStack(srv, config.CorsAllowedOrigins, config.Vhosts),
+		// Authenticate below the CORS handler, preflight requests carry no key
+		Handler: NewHTTPHandlerStack(config.apiKeys.Handler(srv), config.CorsAllowedOrigins, config.Vhosts),
 		server:  srv,
 	})
 	return nil
 }
 
+// setLimits replaces the batch and subscription limits of the running handlers.
+func (h *httpServer) setLimits(batch rpc.BatchLimits, subs rpc.SubscriptionLimits) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.httpConfig.BatchLimits = batch
+	if handler, _ := h.httpHandler.Load().(*rpcHandler); handler != nil {
+		handler.server.SetBatchLimits(batch)
+	}
+	h.wsConfig.Limits, h.wsConfig.Batch = subs, batch
+	if handler, _ := h.wsHandler.Load().(*rpcHandler); handler != nil {
+		handler.server.SetSubscriptionLimits(subs)
+		handler.server.SetBatchLimits(batch)
+	}
+}
+
 // disableRPC stops the HTTP RPC handler. This is internal, the caller must hold h.mu.
 func (h *httpServer) disableRPC() bool {
 	handler := h.httpHandler.Load().(*rpcHandler)
@@ -313,12 +337,14 @@ func (h *httpServer) enableWS(apis []rpc.API, config wsConfig) error {
 
 	// Create RPC server and handler.
 	srv := rpc.NewServer()
+	srv.SetSubscriptionLimits(config.Limits)
+	srv.SetBatchLimits(config.Batch)
 	if err := RegisterApis(apis, config.Modules, srv, false); err != nil {
 		return err
 	}
 	h.wsConfig = config
 	h.wsHandler.Store(&rpcHandler{
-		Handler: srv.WebsocketHandler(config.Origins),
+		Handler: config.apiKeys.Handler(srv.WebsocketHandler(config.Origins)),
 		server:  srv,
 	})
 	return nil
@@ -517,7 +543,8 @@ func (is *ipcServer) stop() error {
 }
 
 // RegisterApis checks the given modules' availability, generates an allowlist based on the allowed modules,
-// and then registers all of the APIs exposed by the services.
+// and then registers all of the APIs exposed by the services. APIs administering the node are only registered
+// if the admin namespace is allowed too.
 func RegisterApis(apis []rpc.API, modules []string, srv *rpc.Server, exposeAll bool) error {
 	if bad, available := checkModuleAvailability(modules, apis); len(bad) > 0 {
 		log.Error("Unavailable modules in HTTP API list", "unavailable", bad, "available", available)
@@ -530,6 +557,10 @@ func RegisterApis(apis []rpc.API, modules []string, srv *rpc.Server, exposeAll b
 	// Register all the APIs exposed by the services
 	for _, api := range apis {
 		if exposeAll || allowList[api.Namespace] || (len(allowList) == 0 && api.Public) {
+			if api.Admin && !exposeAll && !allowList["admin"] {
+				log.Warn("Not exposing administrative methods without the admin API", "namespace", api.Namespace)
+				continue
+			}
 			if err := srv.RegisterName(api.Namespace, api.Service); err != nil {
 				return err
 			}
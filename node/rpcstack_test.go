@@ -18,6 +18,7 @@ package node
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -292,3 +293,89 @@ func rpcRequest(t *testing.T, url string, extraHeaders ...string) *http.Response
 	}
 	return resp
 }
+
+// TestSetLimits makes sure batch limits can be replaced on a running server.
+func TestSetLimits(t *testing.T) {
+	srv := createAndStartServer(t, &httpConfig{}, false, &wsConfig{})
+	defer srv.stop()
+	url := "http://" + srv.listenAddr()
+
+	refused := func() int {
+		body := `[{"jsonrpc":"2.0","id":1,"method":"rpc_modules"},{"jsonrpc":"2.0","id":2,"method":"rpc_modules"},{"jsonrpc":"2.0","id":3,"method":"rpc_modules"}]`
+		resp, err := http.Post(url, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		var results []struct {
+			Error *struct{ Code int } `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+			t.Fatal("could not decode batch response:", err)
+		}
+		var n int
+		for _, result := range results {
+			if result.Error != nil {
+				n++
+			}
+		}
+		return n
+	}
+	assert.Equal(t, 0, refused())
+
+	srv.setLimits(rpc.BatchLimits{MaxItems: 1}, rpc.SubscriptionLimits{})
+	assert.Equal(t, 2, refused())
+}
+
+// testTxPoolAPI is a stand-in for the public transaction pool API.
+type testTxPoolAPI struct{}
+
+func (testTxPoolAPI) Status() string { return "ok" }
+
+// testTxPoolAdminAPI is a stand-in for the transaction pool API administering
+// the node.
+type testTxPoolAdminAPI struct{}
+
+func (testTxPoolAdminAPI) SetPolicy() string { return "ok" }
+
+func (testTxPoolAdminAPI) Import() string { return "ok" }
+
+// TestAdminApis makes sure methods administering the node are only served over
+// HTTP and WebSocket if the admin API is enabled too.
+func TestAdminApis(t *testing.T) {
+	apis := []rpc.API{
+		{Namespace: "txpool", Service: testTxPoolAPI{}, Public: true},
+		{Namespace: "txpool", Service: testTxPoolAdminAPI{}, Admin: true},
+		{Namespace: "admin", Service: testTxPoolAdminAPI{}},
+	}
+	exposed := func(modules []string, method string, ws bool) bool {
+		srv := newHTTPServer(testlog.Logger(t, log.LvlDebug), rpc.DefaultHTTPTimeouts)
+		url := "http://"
+		if ws {
+			assert.NoError(t, srv.enableWS(apis, wsConfig{Modules: modules}))
+			url = "ws://"
+		} else {
+			assert.NoError(t, srv.enableRPC(apis, httpConfig{Modules: modules}))
+		}
+		assert.NoError(t, srv.setListenAddr("localhost", 0))
+		assert.NoError(t, srv.start())
+		defer srv.stop()
+
+		client, err := rpc.Dial(url + srv.listenAddr())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+
+		var result string
+		return client.Call(&result, method) == nil
+	}
+	for _, ws := range []bool{false, true} {
+		assert.True(t, exposed([]string{"txpool"}, "txpool_status", ws))
+		for _, method := range []string{"txpool_setPolicy", "txpool_import"} {
+			assert.False(t, exposed([]string{"txpool"}, method, ws), method)
+			assert.True(t, exposed([]string{"txpool", "admin"}, method, ws), method)
+		}
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math/big"
+	"sort"
 
 	"golang.org/x/crypto/sha3"
 
@@ -54,6 +55,10 @@ var CheckpointOracles = map[common.Hash]*CheckpointOracleConfig{
 	GoerliGenesisHash:  GoerliCheckpointOracle,
 }
 
+// CheckpointSigners associates the signer of the header checkpoint bundles of
+// each known network with the genesis hash of the chain it belongs to.
+var CheckpointSigners = map[common.Hash]common.Address{}
+
 var (
 	// MainnetChainConfig is the chain parameters to run a node on the main network.
 	MainnetChainConfig = &ChainConfig{
@@ -258,16 +263,16 @@ var (
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil, false}
+	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, new(EthashConfig), nil, false, nil, nil}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
 	// and accepted by the Ethereum core developers into the Clique consensus.
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}, false}
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}, false, nil, nil}
 
-	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil, false}
+	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, new(EthashConfig), nil, false, nil, nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
 )
 
@@ -347,6 +352,10 @@ type ChainConfig struct {
 	BerlinBlock         *big.Int `json:"berlinBlock,omitempty"`         // Berlin switch block (nil = no fork, 0 = already on berlin)
 	LondonBlock         *big.Int `json:"londonBlock,omitempty"`         // London switch block (nil = no fork, 0 = already on london)
 	ArrowGlacierBlock   *big.Int `json:"arrowGlacierBlock,omitempty"`   // Eip-4345 (bomb delay) switch block (nil = no fork, 0 = already activated)
+	FeePayerBlock       *big.Int `json:"feePayerBlock,omitempty"`       // Fee payer transactions switch block (nil = no fork, 0 = already activated)
+	P256VerifyBlock     *big.Int `json:"p256VerifyBlock,omitempty"`     // RIP-7212 (P-256 verification precompile) switch block (nil = no fork, 0 = already activated)
+	BLS12381Block       *big.Int `json:"bls12381Block,omitempty"`       // Eip-2537 (BLS12-381 precompiles) switch block (nil = no fork, 0 = already activated)
+	CurieBlock          *big.Int `json:"curieBlock,omitempty"`          // Curie switch block, Eip-1153 and Eip-5656 (nil = no fork, 0 = already on curie)
 
 	// TerminalTotalDifficulty is the amount of total difficulty reached by
 	// the network that triggers the consensus upgrade.
@@ -358,6 +367,51 @@ type ChainConfig struct {
 
 	// Use zktrie
 	Zktrie bool `json:"zktrie,omitempty"`
+
+	// EIP1559 overrides the base fee parameters of EIP-1559, ordered by
+	// activation block
+	EIP1559 []*EIP1559Config `json:"eip1559,omitempty"`
+
+	// FeatureForks adopt individual features ahead of, or instead of, the
+	// forks bundling them, ordered by activation block
+	FeatureForks []*FeatureFork `json:"featureForks,omitempty"`
+}
+
+// EIP1559Config is a set of EIP-1559 base fee parameters taking effect from the
+// given block on. Zero values select the protocol defaults.
+type EIP1559Config struct {
+	Block                    *big.Int `json:"block"`                              // Activation block
+	ElasticityMultiplier     uint64   `json:"elasticityMultiplier,omitempty"`     // Ratio of the gas limit to the gas target
+	BaseFeeChangeDenominator uint64   `json:"baseFeeChangeDenominator,omitempty"` // Bounds the base fee change between blocks
+	MinBaseFee               *big.Int `json:"minBaseFee,omitempty"`               // Lower bound of the base fee
+
+	Oracle *BaseFeeOracleConfig `json:"oracle,omitempty"` // Operator-set base fee replacing the EIP-1559 dynamics
+}
+
+// equal reports whether two parameter sets select the same parameters.
+func (c *EIP1559Config) equal(other *EIP1559Config) bool {
+	return c.ElasticityMultiplier == other.ElasticityMultiplier &&
+		c.BaseFeeChangeDenominator == other.BaseFeeChangeDenominator &&
+		configNumEqual(c.MinBaseFee, other.MinBaseFee) &&
+		c.Oracle.equal(other.Oracle)
+}
+
+// BaseFeeOracleConfig is the location of an operator-set base fee in the state.
+// Each block pins its base fee to the value stored in the parent state, or with
+// smoothing moves the base fee of the parent by a fraction of the distance to it.
+// While the slot is empty, the EIP-1559 dynamics apply.
+type BaseFeeOracleConfig struct {
+	Address   common.Address `json:"address"`             // Oracle contract
+	Slot      common.Hash    `json:"slot"`                // Storage slot holding the base fee
+	Smoothing uint64         `json:"smoothing,omitempty"` // Inverse of the distance moved per block, 0 to pin
+}
+
+// equal reports whether two oracle configs are the same, nil meaning no oracle.
+func (c *BaseFeeOracleConfig) equal(other *BaseFeeOracleConfig) bool {
+	if c == nil || other == nil {
+		return c == other
+	}
+	return *c == *other
 }
 
 // EthashConfig is the consensus engine configs for proof-of-work based sealing.
@@ -370,8 +424,9 @@ func (c *EthashConfig) String() string {
 
 // CliqueConfig is the consensus engine configs for proof-of-authority based sealing.
 type CliqueConfig struct {
-	Period uint64 `json:"period"` // Number of seconds between blocks to enforce
-	Epoch  uint64 `json:"epoch"`  // Epoch length to reset votes and checkpoint
+	Period    uint64 `json:"period"`              // Number of seconds between blocks to enforce
+	Epoch     uint64 `json:"epoch"`               // Epoch length to reset votes and checkpoint
+	TypedSeal bool   `json:"typedSeal,omitempty"` // Seal EIP-712 typed data, which hardware wallets can sign
 }
 
 // String implements the stringer interface, returning the consensus engine details.
@@ -390,7 +445,7 @@ func (c *ChainConfig) String() string {
 	default:
 		engine = "unknown"
 	}
-	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v Petersburg: %v Istanbul: %v, Muir Glacier: %v, Berlin: %v, London: %v, Arrow Glacier: %v, Engine: %v}",
+	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v Petersburg: %v Istanbul: %v, Muir Glacier: %v, Berlin: %v, London: %v, Arrow Glacier: %v, Fee Payer: %v, P256 Verify: %v, BLS12-381: %v, Curie: %v, Engine: %v}",
 		c.ChainID,
 		c.HomesteadBlock,
 		c.DAOForkBlock,
@@ -406,6 +461,10 @@ func (c *ChainConfig) String() string {
 		c.BerlinBlock,
 		c.LondonBlock,
 		c.ArrowGlacierBlock,
+		c.FeePayerBlock,
+		c.P256VerifyBlock,
+		c.BLS12381Block,
+		c.CurieBlock,
 		engine,
 	)
 }
@@ -477,6 +536,72 @@ func (c *ChainConfig) IsArrowGlacier(num *big.Int) bool {
 	return isForked(c.ArrowGlacierBlock, num)
 }
 
+// IsFeePayer returns whether num is either equal to the fee payer fork block or greater.
+func (c *ChainConfig) IsFeePayer(num *big.Int) bool {
+	return isForked(c.FeePayerBlock, num)
+}
+
+// IsP256Verify returns whether num is either equal to the RIP-7212 fork block or greater.
+func (c *ChainConfig) IsP256Verify(num *big.Int) bool {
+	return isForked(c.P256VerifyBlock, num)
+}
+
+// IsBLS12381 returns whether num is either equal to the EIP-2537 fork block or greater.
+func (c *ChainConfig) IsBLS12381(num *big.Int) bool {
+	return isForked(c.BLS12381Block, num)
+}
+
+// IsCurie returns whether num is either equal to the Curie fork block or greater.
+func (c *ChainConfig) IsCurie(num *big.Int) bool {
+	return isForked(c.CurieBlock, num)
+}
+
+// eip1559Config returns the EIP-1559 parameter overrides in force at num, nil
+// if none is.
+func (c *ChainConfig) eip1559Config(num *big.Int) *EIP1559Config {
+	for i := len(c.EIP1559) - 1; i >= 0; i-- {
+		if isForked(c.EIP1559[i].Block, num) {
+			return c.EIP1559[i]
+		}
+	}
+	return nil
+}
+
+// ElasticityMultiplier returns the ratio of the gas limit to the gas target of
+// the block num.
+func (c *ChainConfig) ElasticityMultiplier(num *big.Int) uint64 {
+	if cfg := c.eip1559Config(num); cfg != nil && cfg.ElasticityMultiplier != 0 {
+		return cfg.ElasticityMultiplier
+	}
+	return ElasticityMultiplier
+}
+
+// BaseFeeChangeDenominator returns the bound on the base fee change of the block num.
+func (c *ChainConfig) BaseFeeChangeDenominator(num *big.Int) uint64 {
+	if cfg := c.eip1559Config(num); cfg != nil && cfg.BaseFeeChangeDenominator != 0 {
+		return cfg.BaseFeeChangeDenominator
+	}
+	return BaseFeeChangeDenominator
+}
+
+// MinBaseFee returns the lower bound of the base fee of the block num, nil if
+// there is none.
+func (c *ChainConfig) MinBaseFee(num *big.Int) *big.Int {
+	if cfg := c.eip1559Config(num); cfg != nil {
+		return cfg.MinBaseFee
+	}
+	return nil
+}
+
+// BaseFeeOracle returns the base fee oracle in force at the block num, nil if the
+// base fee follows the EIP-1559 dynamics.
+func (c *ChainConfig) BaseFeeOracle(num *big.Int) *BaseFeeOracleConfig {
+	if cfg := c.eip1559Config(num); cfg != nil {
+		return cfg.Oracle
+	}
+	return nil
+}
+
 // IsTerminalPoWBlock returns whether the given block is the last block of PoW stage.
 func (c *ChainConfig) IsTerminalPoWBlock(parentTotalDiff *big.Int, totalDiff *big.Int) bool {
 	if c.TerminalTotalDifficulty == nil {
@@ -526,6 +651,7 @@ func (c *ChainConfig) CheckConfigForkOrder() error {
 		{name: "berlinBlock", block: c.BerlinBlock},
 		{name: "londonBlock", block: c.LondonBlock},
 		{name: "arrowGlacierBlock", block: c.ArrowGlacierBlock, optional: true},
+		{name: "curieBlock", block: c.CurieBlock, optional: true},
 	} {
 		if lastFork.name != "" {
 			// Next one must be higher number
@@ -545,6 +671,25 @@ func (c *ChainConfig) CheckConfigForkOrder() error {
 			lastFork = cur
 		}
 	}
+	// The feature forks activate independently of each other, fee payer
+	// transactions build on the dynamic fee ones though
+	if c.FeePayerBlock != nil && (c.LondonBlock == nil || c.LondonBlock.Cmp(c.FeePayerBlock) > 0) {
+		return fmt.Errorf("unsupported fork ordering: londonBlock enabled at %v, but feePayerBlock enabled at %v",
+			c.LondonBlock, c.FeePayerBlock)
+	}
+	if err := c.checkFeatureForks(); err != nil {
+		return err
+	}
+	// The EIP-1559 parameter overrides must be ordered by activation
+	for i, cfg := range c.EIP1559 {
+		if cfg.Block == nil {
+			return fmt.Errorf("eip1559 override %d has no activation block", i)
+		}
+		if i > 0 && c.EIP1559[i-1].Block.Cmp(cfg.Block) >= 0 {
+			return fmt.Errorf("unsupported eip1559 override ordering: override %d enabled at %v, but override %d enabled at %v",
+				i-1, c.EIP1559[i-1].Block, i, cfg.Block)
+		}
+	}
 	return nil
 }
 
@@ -598,6 +743,57 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.ArrowGlacierBlock, newcfg.ArrowGlacierBlock, head) {
 		return newCompatError("Arrow Glacier fork block", c.ArrowGlacierBlock, newcfg.ArrowGlacierBlock)
 	}
+	if isForkIncompatible(c.FeePayerBlock, newcfg.FeePayerBlock, head) {
+		return newCompatError("Fee payer fork block", c.FeePayerBlock, newcfg.FeePayerBlock)
+	}
+	if isForkIncompatible(c.P256VerifyBlock, newcfg.P256VerifyBlock, head) {
+		return newCompatError("P256 verify fork block", c.P256VerifyBlock, newcfg.P256VerifyBlock)
+	}
+	if isForkIncompatible(c.BLS12381Block, newcfg.BLS12381Block, head) {
+		return newCompatError("BLS12-381 fork block", c.BLS12381Block, newcfg.BLS12381Block)
+	}
+	if isForkIncompatible(c.CurieBlock, newcfg.CurieBlock, head) {
+		return newCompatError("Curie fork block", c.CurieBlock, newcfg.CurieBlock)
+	}
+	if block := c.eip1559Divergence(newcfg); isForked(block, head) {
+		return newCompatError("EIP-1559 parameters", block, block)
+	}
+	if block := c.featureDivergence(newcfg); isForked(block, head) {
+		return newCompatError("Feature fork", block, block)
+	}
+	// Typed seals change how every clique header past the genesis is signed
+	if c.Clique != nil && newcfg.Clique != nil && c.Clique.TypedSeal != newcfg.Clique.TypedSeal {
+		if block := big.NewInt(1); isForked(block, head) {
+			return newCompatError("Clique typed seal flag", block, block)
+		}
+	}
+	return nil
+}
+
+// eip1559Divergence returns the first block at which the EIP-1559 parameter
+// overrides of two configs differ, nil if they never do.
+func (c *ChainConfig) eip1559Divergence(newcfg *ChainConfig) *big.Int {
+	var blocks []*big.Int
+	for _, cfg := range append(append([]*EIP1559Config{}, c.EIP1559...), newcfg.EIP1559...) {
+		if cfg.Block != nil {
+			blocks = append(blocks, cfg.Block)
+		}
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].Cmp(blocks[j]) < 0 })
+
+	var empty EIP1559Config
+	for _, block := range blocks {
+		have, want := c.eip1559Config(block), newcfg.eip1559Config(block)
+		if have == nil {
+			have = &empty
+		}
+		if want == nil {
+			want = &empty
+		}
+		if !have.equal(want) {
+			return block
+		}
+	}
 	return nil
 }
 
@@ -665,7 +861,9 @@ type Rules struct {
 	ChainID                                                 *big.Int
 	IsHomestead, IsEIP150, IsEIP155, IsEIP158               bool
 	IsByzantium, IsConstantinople, IsPetersburg, IsIstanbul bool
-	IsBerlin, IsLondon                                      bool
+	IsBerlin, IsLondon, IsFeePayer                          bool
+	IsP256Verify, IsBLS12381, IsCurie                       bool
+	Features                                                FeatureSet
 }
 
 // Rules ensures c's ChainID is not nil.
@@ -686,5 +884,10 @@ func (c *ChainConfig) Rules(num *big.Int) Rules {
 		IsIstanbul:       c.IsIstanbul(num),
 		IsBerlin:         c.IsBerlin(num),
 		IsLondon:         c.IsLondon(num),
+		IsFeePayer:       c.IsFeePayer(num),
+		IsP256Verify:     c.IsP256Verify(num),
+		IsBLS12381:       c.IsBLS12381(num),
+		IsCurie:          c.IsCurie(num),
+		Features:         c.Features(num),
 	}
 }
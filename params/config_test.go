@@ -17,6 +17,7 @@
 package params
 
 import (
+	"encoding/json"
 	"math/big"
 	"reflect"
 	"testing"
@@ -87,6 +88,40 @@ func TestCheckCompatible(t *testing.T) {
 				RewindTo:     30,
 			},
 		},
+		{
+			stored:  &ChainConfig{EIP1559: []*EIP1559Config{{Block: big.NewInt(10), ElasticityMultiplier: 4}}},
+			new:     &ChainConfig{EIP1559: []*EIP1559Config{{Block: big.NewInt(10), ElasticityMultiplier: 4}, {Block: big.NewInt(50), MinBaseFee: big.NewInt(1)}}},
+			head:    40,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{EIP1559: []*EIP1559Config{{Block: big.NewInt(10), ElasticityMultiplier: 4}}},
+			new:    &ChainConfig{EIP1559: []*EIP1559Config{{Block: big.NewInt(10), ElasticityMultiplier: 4}, {Block: big.NewInt(20), MinBaseFee: big.NewInt(1)}}},
+			head:   40,
+			wantErr: &ConfigCompatError{
+				What:         "EIP-1559 parameters",
+				StoredConfig: big.NewInt(20),
+				NewConfig:    big.NewInt(20),
+				RewindTo:     19,
+			},
+		},
+		{
+			stored:  &ChainConfig{Clique: &CliqueConfig{Period: 1}},
+			new:     &ChainConfig{Clique: &CliqueConfig{Period: 1, TypedSeal: true}},
+			head:    0,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{Clique: &CliqueConfig{Period: 1}},
+			new:    &ChainConfig{Clique: &CliqueConfig{Period: 1, TypedSeal: true}},
+			head:   5,
+			wantErr: &ConfigCompatError{
+				What:         "Clique typed seal flag",
+				StoredConfig: big.NewInt(1),
+				NewConfig:    big.NewInt(1),
+				RewindTo:     0,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -96,3 +131,103 @@ func TestCheckCompatible(t *testing.T) {
 		}
 	}
 }
+
+func TestEIP1559Overrides(t *testing.T) {
+	config := &ChainConfig{EIP1559: []*EIP1559Config{
+		{Block: big.NewInt(10), ElasticityMultiplier: 4, MinBaseFee: big.NewInt(7)},
+		{Block: big.NewInt(20), BaseFeeChangeDenominator: 16},
+	}}
+	if err := config.CheckConfigForkOrder(); err != nil {
+		t.Fatalf("valid overrides rejected: %v", err)
+	}
+	tests := []struct {
+		number      int64
+		elasticity  uint64
+		denominator uint64
+		minBaseFee  *big.Int
+	}{
+		{9, ElasticityMultiplier, BaseFeeChangeDenominator, nil},
+		{10, 4, BaseFeeChangeDenominator, big.NewInt(7)},
+		{20, ElasticityMultiplier, 16, nil},
+	}
+	for _, tt := range tests {
+		num := big.NewInt(tt.number)
+		if have := config.ElasticityMultiplier(num); have != tt.elasticity {
+			t.Errorf("block %d: elasticity mismatch: have %d, want %d", tt.number, have, tt.elasticity)
+		}
+		if have := config.BaseFeeChangeDenominator(num); have != tt.denominator {
+			t.Errorf("block %d: denominator mismatch: have %d, want %d", tt.number, have, tt.denominator)
+		}
+		if have := config.MinBaseFee(num); !configNumEqual(have, tt.minBaseFee) {
+			t.Errorf("block %d: min base fee mismatch: have %v, want %v", tt.number, have, tt.minBaseFee)
+		}
+	}
+	config.EIP1559[1].Block = big.NewInt(10)
+	if err := config.CheckConfigForkOrder(); err == nil {
+		t.Fatalf("misordered overrides accepted")
+	}
+}
+
+func TestFeatureForks(t *testing.T) {
+	config := *AllEthashProtocolChanges
+	config.LondonBlock, config.ArrowGlacierBlock, config.CurieBlock = big.NewInt(5), nil, big.NewInt(30)
+
+	input := `{"featureForks": [{"block": 10, "features": ["push0"]}, {"block": 20, "features": ["mcopy"]}]}`
+	if err := json.Unmarshal([]byte(input), &config); err != nil {
+		t.Fatalf("failed to decode config: %v", err)
+	}
+	if err := config.CheckConfigForkOrder(); err != nil {
+		t.Fatalf("valid feature forks rejected: %v", err)
+	}
+	tests := []struct {
+		number int64
+		want   FeatureSet
+	}{
+		{9, 0},
+		{10, NewFeatureSet(FeaturePush0)},
+		{20, NewFeatureSet(FeaturePush0, FeatureMcopy)},
+		{30, NewFeatureSet(FeaturePush0, FeatureMcopy, FeatureTransientStorage)},
+	}
+	for _, tt := range tests {
+		if have := config.Rules(big.NewInt(tt.number)).Features; have != tt.want {
+			t.Errorf("block %d: features mismatch: have %v, want %v", tt.number, have, tt.want)
+		}
+	}
+	// Round trip the feature names
+	enc, err := json.Marshal(config.FeatureForks[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"block":20,"features":["mcopy"]}`; string(enc) != want {
+		t.Errorf("encoding mismatch: have %s, want %s", enc, want)
+	}
+	if err := json.Unmarshal([]byte(`["push1"]`), new(FeatureSet)); err == nil {
+		t.Error("unknown feature accepted")
+	}
+	// Invalid feature forks
+	for i, forks := range [][]*FeatureFork{
+		{{Block: nil, Features: NewFeatureSet(FeaturePush0)}},
+		{{Block: big.NewInt(10), Features: NewFeatureSet(FeaturePush0)}, {Block: big.NewInt(10), Features: NewFeatureSet(FeatureMcopy)}},
+		{{Block: big.NewInt(4), Features: NewFeatureSet(FeaturePush0)}},
+		{{Block: big.NewInt(10), Features: NewFeatureSet(FeatureBlobs)}},
+		{{Block: big.NewInt(10), Features: FeatureSet(1 << 40)}},
+	} {
+		invalid := config
+		invalid.FeatureForks = forks
+		if err := invalid.CheckConfigForkOrder(); err == nil {
+			t.Errorf("test %d: invalid feature forks accepted", i)
+		}
+	}
+	// Rescheduling a passed feature fork is incompatible
+	rescheduled := config
+	rescheduled.FeatureForks = []*FeatureFork{
+		{Block: big.NewInt(10), Features: NewFeatureSet(FeaturePush0, FeatureMcopy)},
+	}
+	if err := config.CheckCompatible(&rescheduled, 9); err != nil {
+		t.Errorf("future feature fork change rejected: %v", err)
+	}
+	want := &ConfigCompatError{What: "Feature fork", StoredConfig: big.NewInt(10), NewConfig: big.NewInt(10), RewindTo: 9}
+	if err := config.CheckCompatible(&rescheduled, 15); !reflect.DeepEqual(err, want) {
+		t.Errorf("compatibility error mismatch: have %v, want %v", err, want)
+	}
+}
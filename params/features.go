@@ -0,0 +1,234 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package params
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// Feature is a single protocol change that can be adopted on its own, outside
+// of the fork bundling it upstream.
+type Feature uint8
+
+const (
+	FeaturePush0            Feature = iota // EIP-3855: PUSH0 instruction
+	FeatureTransientStorage                // EIP-1153: TLOAD and TSTORE instructions
+	FeatureMcopy                           // EIP-5656: MCOPY instruction
+	FeatureBlobs                           // EIP-4844: blob transactions, not supported
+
+	numFeatures
+)
+
+// featureInfo describes a feature.
+type featureInfo struct {
+	name      string // Name used in the chain config
+	eip       int    // Specifying EIP
+	supported bool   // Whether the feature can be enabled
+}
+
+var features = [numFeatures]featureInfo{
+	FeaturePush0:            {"push0", 3855, true},
+	FeatureTransientStorage: {"transientStorage", 1153, true},
+	FeatureMcopy:            {"mcopy", 5656, true},
+	FeatureBlobs:            {"blobs", 4844, false},
+}
+
+// String implements the stringer interface, returning the config name of f.
+func (f Feature) String() string {
+	if f < numFeatures {
+		return features[f].name
+	}
+	return fmt.Sprintf("feature(%d)", uint8(f))
+}
+
+// EIP returns the number of the EIP specifying f.
+func (f Feature) EIP() int {
+	if f < numFeatures {
+		return features[f].eip
+	}
+	return 0
+}
+
+// FeatureSet is a bitmap of features, bit i standing for Feature(i).
+type FeatureSet uint64
+
+// CurieFeatures is the set of features adopted as a whole by the Curie fork.
+const CurieFeatures = FeatureSet(1<<FeatureTransientStorage | 1<<FeatureMcopy)
+
+// NewFeatureSet creates a feature set of the given features.
+func NewFeatureSet(fs ...Feature) FeatureSet {
+	var set FeatureSet
+	for _, f := range fs {
+		set |= 1 << f
+	}
+	return set
+}
+
+// Has returns whether f is in the set.
+func (s FeatureSet) Has(f Feature) bool {
+	return s&(1<<f) != 0
+}
+
+// Features returns the features in the set in ascending order.
+func (s FeatureSet) Features() []Feature {
+	var fs []Feature
+	for f := Feature(0); f < 64; f++ {
+		if s.Has(f) {
+			fs = append(fs, f)
+		}
+	}
+	return fs
+}
+
+// EIPs returns the numbers of the EIPs specifying the features in the set.
+func (s FeatureSet) EIPs() []int {
+	var eips []int
+	for _, f := range s.Features() {
+		eips = append(eips, f.EIP())
+	}
+	return eips
+}
+
+// String implements the stringer interface, listing the features in the set.
+func (s FeatureSet) String() string {
+	var names []string
+	for _, f := range s.Features() {
+		names = append(names, f.String())
+	}
+	return "[" + strings.Join(names, " ") + "]"
+}
+
+// validate checks that every feature in the set is known and supported.
+func (s FeatureSet) validate() error {
+	for _, f := range s.Features() {
+		if f >= numFeatures {
+			return fmt.Errorf("unknown %v", f)
+		}
+		if !features[f].supported {
+			return fmt.Errorf("feature %v (EIP-%d) is not supported", f, f.EIP())
+		}
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, encoding the set as a list of names.
+func (s FeatureSet) MarshalJSON() ([]byte, error) {
+	names := []string{}
+	for _, f := range s.Features() {
+		names = append(names, f.String())
+	}
+	return json.Marshal(names)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding a list of names.
+func (s *FeatureSet) UnmarshalJSON(input []byte) error {
+	var names []string
+	if err := json.Unmarshal(input, &names); err != nil {
+		return err
+	}
+	*s = 0
+next:
+	for _, name := range names {
+		for f := Feature(0); f < numFeatures; f++ {
+			if features[f].name == name {
+				*s |= 1 << f
+				continue next
+			}
+		}
+		return fmt.Errorf("unknown feature %q", name)
+	}
+	return nil
+}
+
+// FeatureFork enables a set of features from the given block on, on top of the
+// ones enabled by earlier feature forks.
+type FeatureFork struct {
+	Block    *big.Int   `json:"block"`    // Activation block
+	Features FeatureSet `json:"features"` // Features enabled at the block
+}
+
+// adoptedFeatures returns the features enabled by the feature forks at num.
+func (c *ChainConfig) adoptedFeatures(num *big.Int) FeatureSet {
+	var set FeatureSet
+	for _, fork := range c.FeatureForks {
+		if isForked(fork.Block, num) {
+			set |= fork.Features
+		}
+	}
+	return set
+}
+
+// Features returns the set of individually adoptable features active at num,
+// whether enabled by a feature fork or by a fork bundling them.
+func (c *ChainConfig) Features(num *big.Int) FeatureSet {
+	set := c.adoptedFeatures(num)
+	if c.IsCurie(num) {
+		set |= CurieFeatures
+	}
+	return set
+}
+
+// IsFeatureEnabled returns whether the feature f is active at num.
+func (c *ChainConfig) IsFeatureEnabled(f Feature, num *big.Int) bool {
+	return c.Features(num).Has(f)
+}
+
+// checkFeatureForks checks that the feature forks are ordered by activation,
+// only enable supported features and come after London, which all of them
+// build on.
+func (c *ChainConfig) checkFeatureForks() error {
+	for i, fork := range c.FeatureForks {
+		if fork.Block == nil {
+			return fmt.Errorf("feature fork %d has no activation block", i)
+		}
+		if i > 0 && c.FeatureForks[i-1].Block.Cmp(fork.Block) >= 0 {
+			return fmt.Errorf("unsupported feature fork ordering: fork %d enabled at %v, but fork %d enabled at %v",
+				i-1, c.FeatureForks[i-1].Block, i, fork.Block)
+		}
+		if err := fork.Features.validate(); err != nil {
+			return fmt.Errorf("feature fork %d: %v", i, err)
+		}
+		if c.LondonBlock == nil || c.LondonBlock.Cmp(fork.Block) > 0 {
+			return fmt.Errorf("unsupported fork ordering: londonBlock enabled at %v, but feature fork %d enabled at %v",
+				c.LondonBlock, i, fork.Block)
+		}
+	}
+	return nil
+}
+
+// featureDivergence returns the first block at which the feature forks of two
+// configs enable different features, nil if they never do.
+func (c *ChainConfig) featureDivergence(newcfg *ChainConfig) *big.Int {
+	var blocks []*big.Int
+	for _, fork := range append(append([]*FeatureFork{}, c.FeatureForks...), newcfg.FeatureForks...) {
+		if fork.Block != nil {
+			blocks = append(blocks, fork.Block)
+		}
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].Cmp(blocks[j]) < 0 })
+
+	for _, block := range blocks {
+		if c.adoptedFeatures(block) != newcfg.adoptedFeatures(block) {
+			return block
+		}
+	}
+	return nil
+}
@@ -24,6 +24,12 @@ const (
 	// contains on the server side.
 	BloomBitsBlocks uint64 = 4096
 
+	// BloomBitsBlocksFine is the number of blocks a single bloom bit section vector
+	// contains on the server side if fine-grained sections are enabled. It is tuned
+	// for chains with short block times, where the default sections would leave a
+	// long unindexed tail to be filtered block by block.
+	BloomBitsBlocksFine uint64 = 512
+
 	// BloomBitsBlocksClient is the number of blocks a single bloom bit section vector
 	// contains on the light client side
 	BloomBitsBlocksClient uint64 = 32768
@@ -0,0 +1,235 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package rootcheck implements a service cross-checking the state roots of the
+// local chain against a set of reference nodes.
+package rootcheck
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/go-ethereum/core"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/event"
+	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/metrics"
+	"github.com/scroll-tech/go-ethereum/node"
+	"github.com/scroll-tech/go-ethereum/rpc"
+)
+
+const (
+	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
+	chainHeadChanSize = 10
+
+	// requestTimeout is the maximum time to wait for a reference node to answer.
+	requestTimeout = 5 * time.Second
+)
+
+var (
+	errHashMismatch = errors.New("block hash mismatch")
+	errRootMismatch = errors.New("state root mismatch")
+)
+
+var (
+	checkMeter        = metrics.NewRegisteredMeter("rootcheck/checks", nil)
+	mismatchMeter     = metrics.NewRegisteredMeter("rootcheck/mismatches", nil)
+	hashMismatchMeter = metrics.NewRegisteredMeter("rootcheck/hashmismatches", nil)
+	failureMeter      = metrics.NewRegisteredMeter("rootcheck/failures", nil)
+	mismatchGauge     = metrics.NewRegisteredGauge("rootcheck/mismatch/block", nil)
+)
+
+// backend encompasses the bare-minimum functionality needed for root checking.
+type backend interface {
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+}
+
+// reference is a remote node the local state roots are checked against.
+type reference struct {
+	url    string
+	client *rpc.Client // Lazily dialed, reset on failure
+}
+
+// Service implements a daemon which, after each imported block, fetches the
+// header of the same height from the reference nodes and raises an alarm if
+// their state root differs from the local one.
+type Service struct {
+	backend backend
+	refs    []*reference
+
+	headSub event.Subscription
+	quit    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// New creates a state root checker against the given reference endpoints and
+// registers it on the node.
+func New(node *node.Node, backend backend, urls []string) error {
+	if len(urls) == 0 {
+		return errors.New("no reference endpoints configured")
+	}
+	refs := make([]*reference, len(urls))
+	for i, url := range urls {
+		refs[i] = &reference{url: url}
+	}
+	node.RegisterLifecycle(&Service{
+		backend: backend,
+		refs:    refs,
+		quit:    make(chan struct{}),
+	})
+	return nil
+}
+
+// Start implements node.Lifecycle, starting up the checking daemon.
+func (s *Service) Start() error {
+	chainHeadCh := make(chan core.ChainHeadEvent, chainHeadChanSize)
+	s.headSub = s.backend.SubscribeChainHeadEvent(chainHeadCh)
+
+	s.wg.Add(1)
+	go s.loop(chainHeadCh)
+
+	log.Info("State root checker started", "references", len(s.refs))
+	return nil
+}
+
+// Stop implements node.Lifecycle, terminating the checking daemon.
+func (s *Service) Stop() error {
+	s.headSub.Unsubscribe()
+	close(s.quit)
+	s.wg.Wait()
+
+	for _, ref := range s.refs {
+		if ref.client != nil {
+			ref.client.Close()
+		}
+	}
+	log.Info("State root checker stopped")
+	return nil
+}
+
+// loop checks every new chain head against the reference nodes until termination.
+// Heads arriving while a check is in flight are coalesced, only the latest one
+// being checked next.
+func (s *Service) loop(chainHeadCh chan core.ChainHeadEvent) {
+	defer s.wg.Done()
+
+	headCh := make(chan *types.Header, 1)
+	go func() {
+		for {
+			select {
+			case head := <-chainHeadCh:
+				select {
+				case headCh <- head.Block.Header():
+				default:
+					// Replace the pending head with the newer one
+					select {
+					case <-headCh:
+					default:
+					}
+					headCh <- head.Block.Header()
+				}
+			case <-s.headSub.Err():
+				return
+			case <-s.quit:
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case head := <-headCh:
+			s.check(head)
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// check compares the given local header against all reference nodes.
+func (s *Service) check(header *types.Header) {
+	for _, ref := range s.refs {
+		remote, err := s.fetch(ref, header.Number.Uint64())
+		if err != nil {
+			failureMeter.Mark(1)
+			log.Debug("Failed to fetch reference state root", "url", ref.url, "number", header.Number, "err", err)
+			continue
+		}
+		if remote == nil {
+			// The reference node is behind, nothing to compare against
+			log.Trace("Reference node missing block", "url", ref.url, "number", header.Number)
+			continue
+		}
+		checkMeter.Mark(1)
+
+		switch err := verify(header, remote); err {
+		case errRootMismatch:
+			mismatchMeter.Mark(1)
+			mismatchGauge.Update(header.Number.Int64())
+			log.Error("State root mismatch with reference node", "url", ref.url, "number", header.Number, "hash", header.Hash(), "remotehash", remote.Hash, "local", header.Root, "remote", remote.Root)
+		case errHashMismatch:
+			hashMismatchMeter.Mark(1)
+			log.Warn("Reference node has a different block", "url", ref.url, "number", header.Number, "local", header.Hash(), "remote", remote.Hash)
+		}
+	}
+}
+
+// verify checks a local header against the one served by a reference node. The
+// state roots are compared on their own and reported first, a differing block
+// hash with matching roots is only reported after.
+func verify(local *types.Header, remote *remoteHeader) error {
+	if remote.Root != local.Root {
+		return errRootMismatch
+	}
+	if remote.Hash != local.Hash() {
+		return errHashMismatch
+	}
+	return nil
+}
+
+// remoteHeader is the subset of the header fields needed from reference nodes,
+// kept minimal so that endpoints of other clients can be decoded too.
+type remoteHeader struct {
+	Hash common.Hash `json:"hash"`
+	Root common.Hash `json:"stateRoot"`
+}
+
+// fetch retrieves the header at the given height from a reference node, dialing
+// it if not yet connected. A nil header is returned if the node doesn't have
+// the block yet.
+func (s *Service) fetch(ref *reference, number uint64) (*remoteHeader, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	if ref.client == nil {
+		client, err := rpc.DialContext(ctx, ref.url)
+		if err != nil {
+			return nil, err
+		}
+		ref.client = client
+	}
+	var head *remoteHeader
+	if err := ref.client.CallContext(ctx, &head, "eth_getBlockByNumber", hexutil.EncodeUint64(number), false); err != nil {
+		// Drop the connection so it's redialed on the next check
+		ref.client.Close()
+		ref.client = nil
+		return nil, err
+	}
+	return head, nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rootcheck
+
+import (
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/rpc"
+)
+
+// referenceAPI serves a fixed set of headers over eth_getBlockByNumber.
+type referenceAPI struct {
+	headers map[uint64]*types.Header
+}
+
+func (api *referenceAPI) GetBlockByNumber(number hexutil.Uint64, full bool) map[string]interface{} {
+	header, ok := api.headers[uint64(number)]
+	if !ok {
+		return nil
+	}
+	return map[string]interface{}{
+		"number":    (*hexutil.Big)(header.Number),
+		"hash":      header.Hash(),
+		"stateRoot": header.Root,
+	}
+}
+
+func TestRootCheck(t *testing.T) {
+	header := &types.Header{Number: big.NewInt(1), Root: common.HexToHash("0x01")}
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", &referenceAPI{headers: map[uint64]*types.Header{1: header}}); err != nil {
+		t.Fatalf("failed to register reference API: %v", err)
+	}
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+
+	ref := &reference{url: httpsrv.URL}
+	s := &Service{refs: []*reference{ref}}
+	defer func() { ref.client.Close() }()
+
+	// Block available on the reference node
+	remote, err := s.fetch(ref, 1)
+	if err != nil {
+		t.Fatalf("failed to fetch header: %v", err)
+	}
+	if err := verify(header, remote); err != nil {
+		t.Fatalf("matching header failed verification: %v", err)
+	}
+	// Block missing, reference node lagging behind
+	if remote, err = s.fetch(ref, 2); err != nil {
+		t.Fatalf("failed to fetch header: %v", err)
+	}
+	if remote != nil {
+		t.Fatalf("unexpected header for missing block: %v", remote)
+	}
+}
+
+func TestRootCheckVerify(t *testing.T) {
+	header := &types.Header{Number: big.NewInt(1), Root: common.HexToHash("0x01")}
+
+	tests := []struct {
+		remote *remoteHeader
+		err    error
+	}{
+		{&remoteHeader{Hash: header.Hash(), Root: header.Root}, nil},
+		{&remoteHeader{Hash: header.Hash(), Root: common.HexToHash("0x02")}, errRootMismatch},
+		{&remoteHeader{Hash: common.HexToHash("0x03"), Root: common.HexToHash("0x02")}, errRootMismatch},
+		{&remoteHeader{Hash: common.HexToHash("0x03"), Root: header.Root}, errHashMismatch},
+	}
+	for i, tt := range tests {
+		if err := verify(header, tt.remote); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
@@ -79,9 +79,11 @@ type BatchElem struct {
 
 // Client represents a connection to an RPC server.
 type Client struct {
-	idgen    func() ID // for subscriptions
-	scheme   string    // connection type: http, ws or ipc
-	services *serviceRegistry
+	idgen       func() ID // for subscriptions
+	scheme      string    // connection type: http, ws or ipc
+	services    *serviceRegistry
+	subLimits   SubscriptionLimits // limits of the subscriptions served to the remote side
+	batchLimits BatchLimits        // limits of the batches served to the remote side
 
 	idCounter uint32
 
@@ -120,7 +122,12 @@ func (c *Client) newClientConn(conn ServerCodec) *clientConn {
 	if !c.isHTTP() && c.scheme != "" {
 		ctx = context.WithValue(ctx, "scheme", c.scheme)
 	}
+	if wc, ok := conn.(*websocketCodec); ok && wc.guard != nil {
+		ctx = WithCallGuard(ctx, wc.guard)
+	}
 	handler := newHandler(ctx, conn, c.idgen, c.services)
+	handler.subLimits = c.subLimits
+	handler.batchLimits = c.batchLimits
 	return &clientConn{conn, handler}
 }
 
@@ -206,12 +213,12 @@ func newClient(initctx context.Context, connect reconnectFunc) (*Client, error)
 	if err != nil {
 		return nil, err
 	}
-	c := initClient(conn, randomIDGenerator(), new(serviceRegistry))
+	c := initClient(conn, randomIDGenerator(), new(serviceRegistry), SubscriptionLimits{}, BatchLimits{})
 	c.reconnectFunc = connect
 	return c, nil
 }
 
-func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry) *Client {
+func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry, subLimits SubscriptionLimits, batchLimits BatchLimits) *Client {
 	scheme := ""
 	switch conn.(type) {
 	case *httpConn:
@@ -225,6 +232,8 @@ func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry) *C
 		idgen:       idgen,
 		scheme:      scheme,
 		services:    services,
+		subLimits:   subLimits,
+		batchLimits: batchLimits,
 		writeConn:   conn,
 		close:       make(chan struct{}),
 		closing:     make(chan struct{}),
@@ -54,6 +54,8 @@ var (
 	_ Error = new(invalidRequestError)
 	_ Error = new(invalidMessageError)
 	_ Error = new(invalidParamsError)
+	_ Error = new(subscriptionLimitError)
+	_ Error = new(batchLimitError)
 )
 
 const defaultErrorCode = -32000
@@ -101,3 +103,18 @@ type invalidParamsError struct{ message string }
 func (e *invalidParamsError) ErrorCode() int { return -32602 }
 
 func (e *invalidParamsError) Error() string { return e.message }
+
+// too many active subscriptions on the connection
+type subscriptionLimitError struct{ limit int }
+
+func (e *subscriptionLimitError) ErrorCode() int { return -32005 }
+
+func (e *subscriptionLimitError) Error() string {
+	return fmt.Sprintf("too many subscriptions on connection (limit %d)", e.limit)
+}
+
+type batchLimitError struct{ message string }
+
+func (e *batchLimitError) ErrorCode() int { return -32005 }
+
+func (e *batchLimitError) Error() string { return e.message }
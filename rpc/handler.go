@@ -61,6 +61,8 @@ type handler struct {
 	conn           jsonWriter                     // where responses will be sent
 	log            log.Logger
 	allowSubscribe bool
+	subLimits      SubscriptionLimits // resource limits of the server-side subscriptions
+	batchLimits    BatchLimits        // limits of the served batches
 
 	subLock    sync.Mutex
 	serverSubs map[ID]*Subscription
@@ -112,6 +114,11 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 	if len(calls) == 0 {
 		return
 	}
+	// Refuse the calls exceeding the batch limits, answering each of them:
+	admitted, limitErr := h.batchLimits.admit(calls)
+	rejected := calls[admitted:]
+	calls = calls[:admitted]
+
 	// Process calls on a goroutine because they may block indefinitely:
 	h.startCallProc(func(cp *callProc) {
 		answers := make([]*jsonrpcMessage, 0, len(msgs))
@@ -120,6 +127,15 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 				answers = append(answers, answer)
 			}
 		}
+		if len(rejected) > 0 {
+			rpcBatchRejectMeter.Mark(int64(len(rejected)))
+			h.log.Debug("Refused calls over batch limits", "calls", len(rejected), "err", limitErr)
+		}
+		for _, msg := range rejected {
+			if msg.isCall() {
+				answers = append(answers, msg.errorResponse(limitErr))
+			}
+		}
 		h.addSubscriptions(cp.notifiers)
 		if len(answers) > 0 {
 			h.conn.writeJSON(cp.ctx, answers)
@@ -200,6 +216,7 @@ func (h *handler) addSubscriptions(nn []*Notifier) {
 	for _, n := range nn {
 		if sub := n.takeSubscription(); sub != nil {
 			h.serverSubs[sub.ID] = sub
+			rpcSubscriptionGauge.Inc(1)
 		}
 	}
 }
@@ -212,7 +229,9 @@ func (h *handler) cancelServerSubscriptions(err error) {
 	for id, s := range h.serverSubs {
 		s.err <- err
 		close(s.err)
+		close(s.done)
 		delete(h.serverSubs, id)
+		rpcSubscriptionGauge.Dec(1)
 	}
 }
 
@@ -317,6 +336,17 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMess
 
 // handleCall processes method calls.
 func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage {
+	ctx := cp.ctx
+	if guard := callGuardFromContext(ctx); guard != nil && !msg.isUnsubscribe() {
+		var (
+			cancel context.CancelFunc
+			err    error
+		)
+		if ctx, cancel, err = guard(ctx, msg.Method); err != nil {
+			return msg.errorResponse(err)
+		}
+		defer cancel()
+	}
 	if msg.isSubscribe() {
 		return h.handleSubscribe(cp, msg)
 	}
@@ -334,7 +364,7 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 		return msg.errorResponse(&invalidParamsError{err.Error()})
 	}
 	start := time.Now()
-	answer := h.runMethod(cp.ctx, msg, callb, args)
+	answer := h.runMethod(ctx, msg, callb, args)
 
 	// Collect the statistics for RPC calls if metrics is enabled.
 	// We only care about pure rpc call. Filter out subscription.
@@ -376,6 +406,17 @@ func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage) *jsonrpcMes
 	}
 	args = args[1:]
 
+	// Refuse the subscription if the connection holds too many already.
+	if limit := h.subLimits.MaxSubscriptions; limit > 0 {
+		h.subLock.Lock()
+		active := len(h.serverSubs)
+		h.subLock.Unlock()
+
+		if active+len(cp.notifiers) >= limit {
+			rpcSubscriptionRejectMeter.Mark(1)
+			return msg.errorResponse(&subscriptionLimitError{limit})
+		}
+	}
 	// Install notifier in context so the subscription handler can find it.
 	n := &Notifier{h: h, namespace: namespace}
 	cp.notifiers = append(cp.notifiers, n)
@@ -403,7 +444,9 @@ func (h *handler) unsubscribe(ctx context.Context, id ID) (bool, error) {
 		return false, ErrSubscriptionNotFound
 	}
 	close(s.err)
+	close(s.done)
 	delete(h.serverSubs, id)
+	rpcSubscriptionGauge.Dec(1)
 	return true, nil
 }
 
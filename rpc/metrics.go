@@ -27,6 +27,14 @@ var (
 	successfulRequestGauge = metrics.NewRegisteredGauge("rpc/success", nil)
 	failedReqeustGauge     = metrics.NewRegisteredGauge("rpc/failure", nil)
 	rpcServingTimer        = metrics.NewRegisteredTimer("rpc/duration/all", nil)
+
+	rpcSubscriptionGauge           = metrics.NewRegisteredGauge("rpc/subscriptions/active", nil)
+	rpcSubscriptionRejectMeter     = metrics.NewRegisteredMeter("rpc/subscriptions/rejected", nil)
+	rpcSubscriptionDisconnectMeter = metrics.NewRegisteredMeter("rpc/subscriptions/disconnected", nil)
+	rpcNotificationQueueGauge      = metrics.NewRegisteredGauge("rpc/subscriptions/queued", nil)
+	rpcNotificationDropMeter       = metrics.NewRegisteredMeter("rpc/subscriptions/dropped", nil)
+
+	rpcBatchRejectMeter = metrics.NewRegisteredMeter("rpc/batch/rejected", nil)
 )
 
 func newRPCServingTimer(method string, valid bool) metrics.Timer {
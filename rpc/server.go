@@ -18,7 +18,9 @@ package rpc
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"sync"
 	"sync/atomic"
 
 	mapset "github.com/deckarep/golang-set"
@@ -41,12 +43,61 @@ const (
 	OptionSubscriptions = 1 << iota // support pub sub
 )
 
+// SubscriptionLimits bounds the resources a single connection may hold through
+// its subscriptions, so that a slow consumer can't grow the memory of the node.
+type SubscriptionLimits struct {
+	MaxSubscriptions   int  // Maximum number of active subscriptions per connection (0 = unlimited)
+	NotificationBuffer int  // Maximum number of queued notifications per subscription (0 = unbuffered, notifying blocks)
+	DisconnectSlow     bool // Whether to close the connection of an overflowing consumer instead of dropping its oldest notifications
+}
+
+// BatchLimits bounds the work a single JSON-RPC batch may request, so that a
+// huge batch can't monopolize the node. Calls of a batch exceeding the limits
+// are not executed, they are answered with an error each.
+type BatchLimits struct {
+	MaxItems      int               // Maximum number of calls per batch (0 = unlimited)
+	MaxCost       uint64            // Maximum sum of the call weights per batch (0 = unlimited)
+	Weights       map[string]uint64 // Weights of the expensive methods
+	DefaultWeight uint64            // Weight of the methods not listed in Weights (0 = 1)
+}
+
+// weight returns the cost of a call to the given method.
+func (l *BatchLimits) weight(method string) uint64 {
+	if weight, ok := l.Weights[method]; ok {
+		return weight
+	}
+	if l.DefaultWeight == 0 {
+		return 1
+	}
+	return l.DefaultWeight
+}
+
+// admit returns the number of leading calls of a batch within the limits, along
+// with the error to answer the remaining ones with.
+func (l *BatchLimits) admit(calls []*jsonrpcMessage) (int, error) {
+	var cost uint64
+	for i, msg := range calls {
+		if l.MaxItems > 0 && i >= l.MaxItems {
+			return i, &batchLimitError{fmt.Sprintf("batch too large (limit %d calls)", l.MaxItems)}
+		}
+		if l.MaxCost > 0 {
+			if cost += l.weight(msg.Method); cost > l.MaxCost {
+				return i, &batchLimitError{fmt.Sprintf("batch too expensive (limit %d)", l.MaxCost)}
+			}
+		}
+	}
+	return len(calls), nil
+}
+
 // Server is an RPC server.
 type Server struct {
-	services serviceRegistry
-	idgen    func() ID
-	run      int32
-	codecs   mapset.Set
+	services    serviceRegistry
+	idgen       func() ID
+	run         int32
+	codecs      mapset.Set
+	subLimits   SubscriptionLimits
+	batchLimits BatchLimits
+	limitsLock  sync.RWMutex // Protects the limits, which may be changed while serving
 }
 
 // NewServer creates a new server instance with no registered handlers.
@@ -67,6 +118,31 @@ func (s *Server) RegisterName(name string, receiver interface{}) error {
 	return s.services.registerName(name, receiver)
 }
 
+// SetSubscriptionLimits configures the subscription resource limits of the
+// connections served afterwards.
+func (s *Server) SetSubscriptionLimits(limits SubscriptionLimits) {
+	s.limitsLock.Lock()
+	defer s.limitsLock.Unlock()
+
+	s.subLimits = limits
+}
+
+// SetBatchLimits configures the batch limits of the requests served afterwards.
+func (s *Server) SetBatchLimits(limits BatchLimits) {
+	s.limitsLock.Lock()
+	defer s.limitsLock.Unlock()
+
+	s.batchLimits = limits
+}
+
+// limits returns the subscription and batch limits to serve a new connection with.
+func (s *Server) limits() (SubscriptionLimits, BatchLimits) {
+	s.limitsLock.RLock()
+	defer s.limitsLock.RUnlock()
+
+	return s.subLimits, s.batchLimits
+}
+
 // ServeCodec reads incoming requests from codec, calls the appropriate callback and writes
 // the response back using the given codec. It will block until the codec is closed or the
 // server is stopped. In either case the codec is closed.
@@ -84,7 +160,8 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 	s.codecs.Add(codec)
 	defer s.codecs.Remove(codec)
 
-	c := initClient(codec, s.idgen, &s.services)
+	subLimits, batchLimits := s.limits()
+	c := initClient(codec, s.idgen, &s.services, subLimits, batchLimits)
 	<-codec.closed()
 	c.Close()
 }
@@ -100,6 +177,7 @@ func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec) {
 
 	h := newHandler(ctx, codec, s.idgen, &s.services)
 	h.allowSubscribe = false
+	_, h.batchLimits = s.limits()
 	defer h.close(io.EOF, nil)
 
 	reqs, batch, err := codec.readBatch()
@@ -146,3 +224,24 @@ func (s *RPCService) Modules() map[string]string {
 	}
 	return modules
 }
+
+// CallGuard is consulted before every method call served on a connection. It
+// can refuse the call by returning an error, which is sent back to the caller,
+// or return a derived context for executing it, e.g. carrying a deadline. The
+// returned cancel function is invoked once the call has been answered.
+type CallGuard func(ctx context.Context, method string) (context.Context, context.CancelFunc, error)
+
+type callGuardContextKey struct{}
+
+// WithCallGuard returns a copy of the context carrying the given call guard. It
+// is meant to be used by HTTP middleware wrapping the RPC handlers, the guard is
+// applied to all calls of the HTTP request or websocket connection.
+func WithCallGuard(ctx context.Context, guard CallGuard) context.Context {
+	return context.WithValue(ctx, callGuardContextKey{}, guard)
+}
+
+// callGuardFromContext retrieves the call guard of a connection, if any.
+func callGuardFromContext(ctx context.Context) CallGuard {
+	guard, _ := ctx.Value(callGuardContextKey{}).(CallGuard)
+	return guard
+}
@@ -19,6 +19,8 @@ package rpc
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net"
@@ -150,3 +152,49 @@ func TestServerShortLivedConn(t *testing.T) {
 		}
 	}
 }
+
+// This test checks that the calls of a batch exceeding the batch limits are
+// answered with an error each, while the leading ones are executed.
+func TestServerBatchLimits(t *testing.T) {
+	tests := []struct {
+		limits  BatchLimits
+		calls   int
+		refused int
+	}{
+		{BatchLimits{}, 10, 0},
+		{BatchLimits{MaxItems: 4}, 10, 6},
+		{BatchLimits{MaxCost: 10, Weights: map[string]uint64{"test_rets": 3}}, 10, 7},
+		{BatchLimits{MaxItems: 8, MaxCost: 100, DefaultWeight: 20}, 10, 5},
+	}
+	for i, tt := range tests {
+		p1, p2 := net.Pipe()
+
+		server := newTestServer()
+		server.SetBatchLimits(tt.limits)
+		go server.ServeCodec(NewCodec(p1), 0)
+
+		batch := make([]string, tt.calls)
+		for j := range batch {
+			batch[j] = fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"test_rets"}`, j)
+		}
+		p2.SetDeadline(time.Now().Add(10 * time.Second))
+		fmt.Fprintf(p2, "[%s]", strings.Join(batch, ","))
+
+		var resps []jsonrpcMessage
+		if err := json.NewDecoder(p2).Decode(&resps); err != nil {
+			t.Fatalf("test %d: failed to read response: %v", i, err)
+		}
+		if len(resps) != tt.calls {
+			t.Fatalf("test %d: response count mismatch: have %d, want %d", i, len(resps), tt.calls)
+		}
+		for j, resp := range resps {
+			if refused := j >= tt.calls-tt.refused; refused != (resp.Error != nil) {
+				t.Errorf("test %d: call %d: refusal mismatch: have %v, want %v", i, j, resp.Error, refused)
+			} else if refused && resp.Error.Code != -32005 {
+				t.Errorf("test %d: call %d: error code mismatch: have %d, want %d", i, j, resp.Error.Code, -32005)
+			}
+		}
+		p2.Close()
+		server.Stop()
+	}
+}
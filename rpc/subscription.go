@@ -36,6 +36,9 @@ var (
 	ErrNotificationsUnsupported = errors.New("notifications not supported")
 	// ErrNotificationNotFound is returned when the notification for the given id is not found
 	ErrSubscriptionNotFound = errors.New("subscription not found")
+	// ErrNotificationQueueOverflow is returned when a consumer can't keep up with its
+	// notifications and the connection is dropped
+	ErrNotificationQueueOverflow = errors.New("notification queue overflow")
 )
 
 var globalGen = randomIDGenerator()
@@ -99,6 +102,9 @@ type Notifier struct {
 	buffer       []json.RawMessage
 	callReturned bool
 	activated    bool
+
+	wake    chan struct{} // Signals the sender loop that notifications got queued
+	sendErr error         // Error which terminated the sender loop
 }
 
 // CreateSubscription returns a new subscription that is coupled to the
@@ -114,7 +120,7 @@ func (n *Notifier) CreateSubscription() *Subscription {
 	} else if n.callReturned {
 		panic("can't create subscription after subscribe call has returned")
 	}
-	n.sub = &Subscription{ID: n.h.idgen(), namespace: n.namespace, err: make(chan error, 1)}
+	n.sub = &Subscription{ID: n.h.idgen(), namespace: n.namespace, err: make(chan error, 1), done: make(chan struct{})}
 	return n.sub
 }
 
@@ -134,6 +140,9 @@ func (n *Notifier) Notify(id ID, data interface{}) error {
 	} else if n.sub.ID != id {
 		panic("Notify with wrong ID")
 	}
+	if n.h.subLimits.NotificationBuffer > 0 {
+		return n.enqueue(enc)
+	}
 	if n.activated {
 		return n.send(n.sub, enc)
 	}
@@ -141,6 +150,77 @@ func (n *Notifier) Notify(id ID, data interface{}) error {
 	return nil
 }
 
+// enqueue queues a notification for the sender loop if notification buffering is
+// enabled. If the consumer has fallen behind by a full buffer, either its oldest
+// notification is dropped or the connection is closed. It expects n.mu to be held.
+func (n *Notifier) enqueue(enc json.RawMessage) error {
+	if n.sendErr != nil {
+		return n.sendErr
+	}
+	if len(n.buffer) >= n.h.subLimits.NotificationBuffer {
+		if n.h.subLimits.DisconnectSlow {
+			rpcSubscriptionDisconnectMeter.Mark(1)
+			n.h.log.Warn("Disconnecting slow subscription consumer", "id", n.sub.ID, "queued", len(n.buffer))
+
+			n.sendErr = ErrNotificationQueueOverflow
+			if codec, ok := n.h.conn.(ServerCodec); ok {
+				codec.close()
+			}
+			return n.sendErr
+		}
+		n.buffer[0] = nil
+		n.buffer = n.buffer[1:]
+		rpcNotificationDropMeter.Mark(1)
+		rpcNotificationQueueGauge.Dec(1)
+	}
+	n.buffer = append(n.buffer, enc)
+	rpcNotificationQueueGauge.Inc(1)
+
+	if n.activated {
+		select {
+		case n.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// sendLoop writes the queued notifications to the connection until it or the
+// subscription is closed. Queueing and writing are decoupled, so a slow consumer
+// holds back its own notifications only, not the producers of the events.
+func (n *Notifier) sendLoop(sub *Subscription) {
+	defer func() {
+		n.mu.Lock()
+		rpcNotificationQueueGauge.Dec(int64(len(n.buffer)))
+		n.buffer = nil
+		n.mu.Unlock()
+	}()
+	for {
+		n.mu.Lock()
+		queued := n.buffer
+		n.buffer = nil
+		n.mu.Unlock()
+
+		for i, data := range queued {
+			rpcNotificationQueueGauge.Dec(1)
+			if err := n.send(sub, data); err != nil {
+				rpcNotificationQueueGauge.Dec(int64(len(queued) - i - 1))
+				n.mu.Lock()
+				n.sendErr = err
+				n.mu.Unlock()
+				return
+			}
+		}
+		select {
+		case <-n.wake:
+		case <-sub.done:
+			return
+		case <-n.h.rootCtx.Done():
+			return
+		}
+	}
+}
+
 // Closed returns a channel that is closed when the RPC connection is closed.
 // Deprecated: use subscription error channel
 func (n *Notifier) Closed() <-chan interface{} {
@@ -163,6 +243,14 @@ func (n *Notifier) activate() error {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
+	if n.h.subLimits.NotificationBuffer > 0 {
+		if n.sub != nil {
+			n.wake = make(chan struct{}, 1)
+			go n.sendLoop(n.sub)
+		}
+		n.activated = true
+		return nil
+	}
 	for _, data := range n.buffer {
 		if err := n.send(n.sub, data); err != nil {
 			return err
@@ -187,7 +275,8 @@ func (n *Notifier) send(sub *Subscription, data json.RawMessage) error {
 type Subscription struct {
 	ID        ID
 	namespace string
-	err       chan error // closed on unsubscribe
+	err       chan error    // closed on unsubscribe
+	done      chan struct{} // closed on unsubscribe, for the server side
 }
 
 // Err returns a channel that is closed when the client send an unsubscribe request.
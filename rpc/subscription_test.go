@@ -171,6 +171,104 @@ func TestServerUnsubscribe(t *testing.T) {
 	}
 }
 
+// This test checks that subscriptions beyond the per-connection limit are refused.
+func TestSubscriptionLimit(t *testing.T) {
+	p1, p2 := net.Pipe()
+	defer p2.Close()
+
+	server := newTestServer()
+	server.SetSubscriptionLimits(SubscriptionLimits{MaxSubscriptions: 1})
+	server.RegisterName("nftest", &notificationTestService{})
+	go server.ServeCodec(NewCodec(p1), 0)
+
+	p2.SetDeadline(time.Now().Add(10 * time.Second))
+	in := json.NewDecoder(p2)
+	for i := 0; i < 2; i++ {
+		fmt.Fprintf(p2, `{"jsonrpc":"2.0","id":%d,"method":"nftest_subscribe","params":["someSubscription",0,0]}`, i)
+
+		var resp jsonrpcMessage
+		if err := in.Decode(&resp); err != nil {
+			t.Fatalf("subscription %d: failed to read response: %v", i, err)
+		}
+		switch {
+		case i == 0 && resp.Error != nil:
+			t.Fatalf("first subscription refused: %v", resp.Error)
+		case i == 1 && (resp.Error == nil || resp.Error.Code != -32005):
+			t.Fatalf("second subscription error mismatch: have %+v", resp.Error)
+		}
+	}
+}
+
+// This test checks that a consumer falling behind loses its oldest notifications
+// instead of blocking the producer.
+func TestNotificationBufferDropOldest(t *testing.T) {
+	p1, p2 := net.Pipe()
+	defer p2.Close()
+
+	server := newTestServer()
+	server.SetSubscriptionLimits(SubscriptionLimits{NotificationBuffer: 4})
+	server.RegisterName("nftest", &notificationTestService{})
+	go server.ServeCodec(NewCodec(p1), 0)
+
+	p2.SetDeadline(time.Now().Add(10 * time.Second))
+	p2.Write([]byte(`{"jsonrpc":"2.0","id":1,"method":"nftest_subscribe","params":["someSubscription",100,0]}`))
+
+	in := json.NewDecoder(p2)
+	if _, _, err := readAndValidateMessage(in); err != nil {
+		t.Fatal(err)
+	}
+	// Let the producer run ahead of the consumer, it must not block
+	time.Sleep(200 * time.Millisecond)
+
+	var received int
+	for {
+		_, notification, err := readAndValidateMessage(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		received++
+
+		var val int
+		if err := json.Unmarshal(notification.Result, &val); err != nil {
+			t.Fatalf("invalid notification: %v", err)
+		}
+		if val == 99 {
+			break
+		}
+	}
+	if received >= 100 {
+		t.Fatalf("no notifications dropped: received %d", received)
+	}
+}
+
+// This test checks that a consumer overflowing its notification buffer gets
+// disconnected if configured so.
+func TestNotificationBufferDisconnect(t *testing.T) {
+	p1, p2 := net.Pipe()
+	defer p2.Close()
+
+	server := newTestServer()
+	server.SetSubscriptionLimits(SubscriptionLimits{NotificationBuffer: 2, DisconnectSlow: true})
+	server.RegisterName("nftest", &notificationTestService{})
+	go server.ServeCodec(NewCodec(p1), 0)
+
+	p2.SetDeadline(time.Now().Add(10 * time.Second))
+	p2.Write([]byte(`{"jsonrpc":"2.0","id":1,"method":"nftest_subscribe","params":["someSubscription",100,0]}`))
+
+	in := json.NewDecoder(p2)
+	if _, _, err := readAndValidateMessage(in); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	for i := 0; i < 100; i++ {
+		if _, _, err := readAndValidateMessage(in); err != nil {
+			return // connection dropped
+		}
+	}
+	t.Fatal("slow consumer not disconnected")
+}
+
 type subConfirmation struct {
 	reqid int
 	subid ID
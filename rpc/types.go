@@ -34,6 +34,7 @@ type API struct {
 	Version   string      // api version for DApp's
 	Service   interface{} // receiver instance which holds the methods
 	Public    bool        // indication if the methods must be considered safe for public use
+	Admin     bool        // indication if the methods administer the node, exposed only along with the admin namespace
 }
 
 // ServerCodec implements reading, parsing and writing RPC messages for the server side of
@@ -62,6 +62,7 @@ func (s *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
 			return
 		}
 		codec := newWebsocketCodec(conn)
+		codec.(*websocketCodec).guard = callGuardFromContext(r.Context())
 		s.ServeCodec(codec, 0)
 	})
 }
@@ -239,6 +240,7 @@ type websocketCodec struct {
 
 	wg        sync.WaitGroup
 	pingReset chan struct{}
+	guard     CallGuard // Call guard of the upgrade request, applied to the connection
 }
 
 func newWebsocketCodec(conn *websocket.Conn) ServerCodec {
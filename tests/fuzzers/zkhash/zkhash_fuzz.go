@@ -0,0 +1,66 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package zkhash
+
+import (
+	"fmt"
+
+	zkt "github.com/scroll-tech/go-ethereum/core/types/zktrie"
+)
+
+// Fuzz implements a go-fuzz fuzzer method to test the textual formats of the zk
+// trie hashes. Any string accepted by the text decoder must survive a round trip
+// via each of the supported formats.
+func Fuzz(data []byte) int {
+	var h zkt.Hash
+	if err := h.UnmarshalText(data); err != nil {
+		return 0
+	}
+	text, err := h.MarshalText()
+	if err != nil {
+		panic(err)
+	}
+	formats := []struct {
+		text  string
+		parse func(string) (*zkt.Hash, error)
+	}{
+		{string(text), unmarshalText},
+		{string(text), zkt.NewHashFromString},
+		{"0x" + h.Hex(), unmarshalText},
+		{"0x" + h.Hex(), zkt.NewHashFromHex},
+		{h.Hex(), zkt.NewHashFromHex},
+	}
+	for i, format := range formats {
+		dec, err := format.parse(format.text)
+		if err != nil {
+			panic(fmt.Sprintf("format %d: failed to parse %q: %v", i, format.text, err))
+		}
+		if *dec != h {
+			panic(fmt.Sprintf("format %d: round trip mismatch for %q", i, format.text))
+		}
+	}
+	return 1
+}
+
+// unmarshalText decodes a hash with its text unmarshaler.
+func unmarshalText(s string) (*zkt.Hash, error) {
+	h := new(zkt.Hash)
+	if err := h.UnmarshalText([]byte(s)); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
@@ -105,7 +105,10 @@ func (t *SecureTrie) Prove(key []byte, fromLevel uint, proofDb ethdb.KeyValueWri
 // proof contains invalid trie nodes or the wrong value.
 func VerifyProof(rootHash common.Hash, key []byte, proofDb ethdb.KeyValueReader) (value []byte, err error) {
 
-	// test the type of proof (for trie or SMT)
+	// test the type of proof (for trie, SMT or compact SMT)
+	if blob, _ := proofDb.Get(compactProofKey); blob != nil {
+		return VerifyCompactProof(rootHash, key, blob)
+	}
 	if buf, _ := proofDb.Get(magicHash); buf != nil {
 		return VerifyProofSMT(rootHash, key, proofDb)
 	}
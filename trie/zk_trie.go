@@ -207,11 +207,107 @@ func (t *ZkTrie) Copy() *ZkTrie {
 	if err != nil {
 		panic("clone trie failed")
 	}
+	cpy.owner = t.tree.owner
 	return &ZkTrie{
 		tree: cpy,
 	}
 }
 
+// CheckNodes verifies that all nodes of the trie down to the given depth below
+// the root are present in the database and decodable. A depth of zero checks
+// the root node only.
+func (t *ZkTrie) CheckNodes(depth int) error {
+	return t.tree.checkNodes(t.tree.rootKey, depth)
+}
+
+// WalkLeaves calls f for every leaf of the trie with its hashed key, its value
+// and its depth, being the number of middle nodes above it. The walk stops at
+// the first error returned by f.
+func (t *ZkTrie) WalkLeaves(f func(key, value []byte, depth int) error) error {
+	return t.tree.walkLeaves(t.tree.rootKey, 0, f)
+}
+
+// SetOwner sets the key of the account leaf owning a storage trie, see
+// ZkTrieKey. The state epochs of the leaf accesses are recorded per owner, as
+// tries of different accounts may share leaf nodes, see LeafEpoch.
+func (t *ZkTrie) SetOwner(owner common.Hash) {
+	t.tree.owner = owner
+}
+
+// ZkTrieKey returns the hashed key a zk trie stores the given key under, which
+// is the key of its leaf node.
+func ZkTrieKey(key []byte) (common.Hash, error) {
+	k, err := zkt.NewByte32FromBytesPaddingZero(key).Hash()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(zkt.NewHashFromBigInt(k).Bytes()), nil
+}
+
+// LeafEpoch returns the state epoch of the last canonical access to the given
+// leaf node of the trie, zero if it was not accessed since epochs are recorded.
+func (t *ZkTrie) LeafEpoch(n *Node) (uint64, error) {
+	return t.tree.db.leafEpoch(t.tree.owner, n.NodeKey)
+}
+
+// WalkLeafNodes calls f for every leaf node of the trie with its depth, being
+// the number of middle nodes above it. The walk stops at the first error
+// returned by f.
+func (t *ZkTrie) WalkLeafNodes(f func(n *Node, depth int) error) error {
+	return t.tree.walkLeafNodes(t.tree.rootKey, 0, f)
+}
+
+// WalkLeafNodesAfter calls f for the leaf nodes of the trie in path order,
+// starting after the path of the given hashed key, which needn't be in the trie.
+// A nil key starts at the first leaf. The walk stops at the first error returned
+// by f, so a trie can be read in pages by resuming after the last leaf seen.
+func (t *ZkTrie) WalkLeafNodesAfter(after []byte, f func(n *Node) error) error {
+	return t.walkLeafNodesFrom(after, false, f)
+}
+
+// WalkLeafNodesFrom calls f for the leaf nodes of the trie in path order,
+// starting at the path of the given hashed key, which needn't be in the trie.
+// A nil key starts at the first leaf. The walk stops at the first error returned
+// by f.
+func (t *ZkTrie) WalkLeafNodesFrom(start []byte, f func(n *Node) error) error {
+	return t.walkLeafNodesFrom(start, true, f)
+}
+
+func (t *ZkTrie) walkLeafNodesFrom(key []byte, inclusive bool, f func(n *Node) error) error {
+	var start *zkt.Hash
+	if key != nil {
+		var err error
+		if start, err = zkt.NewHashFromBytes(key); err != nil {
+			return err
+		}
+	}
+	return t.tree.walkLeafNodesFrom(t.tree.rootKey, 0, start, inclusive, f)
+}
+
+// TryUpdateLeaf inserts a leaf node taken from another trie as it is, under its
+// hashed key. It allows rebuilding a trie from its leaves without knowing the
+// preimages of their keys.
+func (t *ZkTrie) TryUpdateLeaf(n *Node) error {
+	if n.Type != NodeTypeLeaf {
+		return ErrInvalidNodeFound
+	}
+	return t.tree.tryUpdate(n.NodeKey, n.CompressedFlags, n.ValuePreimage)
+}
+
+// DiffLeaves calls f for every leaf differing between the trie and the other one,
+// with its hashed key and both values, nil if the key is absent in a trie. Only
+// the subtries whose hashes differ are walked.
+func (t *ZkTrie) DiffLeaves(other *ZkTrie, f func(key, value, otherValue []byte) error) error {
+	return t.tree.diffLeaves(t.tree.rootKey, other.tree, other.tree.rootKey, f)
+}
+
+// WalkNodes calls f for every non-empty node of the trie with the database key
+// it is stored under, middle nodes before their children. The walk stops at the
+// first error returned by f.
+func (t *ZkTrie) WalkNodes(f func(key []byte, n *Node) error) error {
+	return t.tree.walkNodes(t.tree.rootKey, f)
+}
+
 // NodeIterator returns an iterator that returns nodes of the underlying trie. Iteration
 // starts at the key after the given start key.
 func (t *ZkTrie) NodeIterator(start []byte) NodeIterator {
@@ -273,3 +369,19 @@ func (t *ZkTrie) Prove(key []byte, fromLevel uint, proofDb ethdb.KeyValueWriter)
 	// make suitable Proof
 	return proofDb.Put(magicHash, magicSMTBytes)
 }
+
+// ProveCompact constructs a merkle proof for key in the compact encoding, which
+// is less than half the size of the node based one. The proof is stored in the
+// proof database as a single entry, and is accepted by VerifyProof.
+func (t *ZkTrie) ProveCompact(key []byte, proofDb ethdb.KeyValueWriter) error {
+	word := zkt.NewByte32FromBytesPaddingZero(key)
+	k, err := word.Hash()
+	if err != nil {
+		return err
+	}
+	blob, err := t.tree.proveCompact(zkt.NewHashFromBigInt(k))
+	if err != nil {
+		return err
+	}
+	return proofDb.Put(compactProofKey, blob)
+}
@@ -1,8 +1,14 @@
 package trie
 
 import (
+	"encoding/binary"
+	"fmt"
+
 	"github.com/syndtr/goleveldb/leveldb"
 
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	zkt "github.com/scroll-tech/go-ethereum/core/types/zktrie"
 	"github.com/scroll-tech/go-ethereum/ethdb"
 )
 
@@ -32,7 +38,28 @@ func (l *ZktrieDatabase) Put(k, v []byte) error {
 	return nil
 }
 
-// Get retrieves a value from a key in the Storage
+// leafEpoch returns the state epoch of the last canonical access to a leaf, zero
+// if it was not accessed since epochs are recorded. Epochs are kept apart from
+// the content addressed leaf nodes, keyed by the trie owner and the leaf key.
+func (l *ZktrieDatabase) leafEpoch(owner common.Hash, leaf *zkt.Hash) (uint64, error) {
+	key := rawdb.ZkLeafEpochKey(owner, common.BytesToHash(leaf.Bytes()))
+	if has, err := l.db.diskdb.Has(key); err != nil || !has {
+		return 0, err
+	}
+	enc, err := l.db.diskdb.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	if len(enc) != 8 {
+		return 0, fmt.Errorf("malformed epoch of leaf %x: %d bytes", leaf.Bytes(), len(enc))
+	}
+	return binary.BigEndian.Uint64(enc), nil
+}
+
+// Get retrieves a value from a key in the Storage. Nodes are keyed by their
+// hash, so the ones read from disk are kept in the clean cache of the backing
+// trie database, if it has one. Leaf epochs change without the node bytes
+// changing, which is why they are kept apart and never go through the cache.
 func (l *ZktrieDatabase) Get(key []byte) ([]byte, error) {
 	concatKey := Concat(l.prefix, key[:])
 	l.db.lock.RLock()
@@ -41,10 +68,22 @@ func (l *ZktrieDatabase) Get(key []byte) ([]byte, error) {
 	if ok {
 		return value, nil
 	}
+	if l.db.cleans != nil {
+		if enc := l.db.cleans.Get(nil, concatKey); enc != nil {
+			memcacheCleanHitMeter.Mark(1)
+			memcacheCleanReadMeter.Mark(int64(len(enc)))
+			return enc, nil
+		}
+	}
 	v, err := l.db.diskdb.Get(concatKey)
 	if err == leveldb.ErrNotFound {
 		return nil, ErrNotFound
 	}
+	if err == nil && l.db.cleans != nil {
+		l.db.cleans.Set(concatKey, v)
+		memcacheCleanMissMeter.Mark(1)
+		memcacheCleanWriteMeter.Mark(int64(len(v)))
+	}
 	return v, err
 }
 
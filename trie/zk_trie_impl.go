@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sort"
 
 	cryptoUtils "github.com/iden3/go-iden3-crypto/utils"
 
@@ -49,6 +50,7 @@ var (
 // ZkTrieImpl is the struct with the main elements of the ZkTrieImpl
 type ZkTrieImpl struct {
 	db        *ZktrieDatabase
+	owner     common.Hash // Key of the account leaf owning a storage trie, zero for the account trie
 	rootKey   *zkt.Hash
 	writable  bool
 	maxLevels int
@@ -805,6 +807,104 @@ type Proof struct {
 	NodeAux *NodeAux
 }
 
+const (
+	proofFlagNonExistence = 0x01 // Set if the proof is a proof of non-existence
+	proofFlagNodeAux      = 0x02 // Set if the auxiliary node is included
+	proofFlagKey          = 0x04 // Set if the leaf key is included
+)
+
+// MarshalBinary implements encoding.BinaryMarshaler. The encoding consists of a
+// 32 byte header holding the flags, the depth and the bitmap of the non-empty
+// siblings, followed by the siblings, the leaf key and the auxiliary node:
+//
+//	flags (1 byte) || depth (1 byte) || notempties (30 bytes) || siblings || [key] || [aux key || aux value]
+func (proof *Proof) MarshalBinary() ([]byte, error) {
+	if proof.depth > 8*uint(len(proof.notempties)) {
+		return nil, fmt.Errorf("proof depth %d out of range", proof.depth)
+	}
+	bs := make([]byte, proofFlagsLen, proofFlagsLen+len(proof.notempties)+zkt.ElemBytesLen*(len(proof.Siblings)+3))
+	if !proof.Existence {
+		bs[0] |= proofFlagNonExistence
+	}
+	bs[1] = byte(proof.depth)
+	bs = append(bs, proof.notempties[:]...)
+	for _, sibling := range proof.Siblings {
+		bs = append(bs, sibling[:]...)
+	}
+	if proof.Key != nil {
+		bs[0] |= proofFlagKey
+		bs = append(bs, proof.Key[:]...)
+	}
+	if proof.NodeAux != nil {
+		if proof.NodeAux.Key == nil || proof.NodeAux.Value == nil {
+			return nil, errors.New("incomplete auxiliary node")
+		}
+		bs[0] |= proofFlagNodeAux
+		bs = append(bs, proof.NodeAux.Key[:]...)
+		bs = append(bs, proof.NodeAux.Value[:]...)
+	}
+	return bs, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. Non-canonical encodings,
+// with unknown flags, bitmap bits beyond the depth or trailing bytes, are rejected.
+func (proof *Proof) UnmarshalBinary(bs []byte) error {
+	var dec Proof
+
+	header := proofFlagsLen + len(dec.notempties)
+	if len(bs) < header {
+		return ErrNodeBytesBadSize
+	}
+	flags := bs[0]
+	if flags&^(proofFlagNonExistence|proofFlagNodeAux|proofFlagKey) != 0 {
+		return fmt.Errorf("invalid proof flags %#x", flags)
+	}
+	dec.Existence = flags&proofFlagNonExistence == 0
+	dec.depth = uint(bs[1])
+	if dec.depth > 8*uint(len(dec.notempties)) {
+		return fmt.Errorf("proof depth %d out of range", dec.depth)
+	}
+	copy(dec.notempties[:], bs[proofFlagsLen:header])
+	bs = bs[header:]
+
+	siblings := 0
+	for i := uint(0); i < 8*uint(len(dec.notempties)); i++ {
+		if zkt.TestBitBigEndian(dec.notempties[:], i) {
+			if i >= dec.depth {
+				return fmt.Errorf("sibling at level %d beyond proof depth %d", i, dec.depth)
+			}
+			siblings++
+		}
+	}
+	readHash := func() *zkt.Hash {
+		h := new(zkt.Hash)
+		copy(h[:], bs[:zkt.ElemBytesLen])
+		bs = bs[zkt.ElemBytesLen:]
+		return h
+	}
+	size := siblings
+	if flags&proofFlagKey != 0 {
+		size++
+	}
+	if flags&proofFlagNodeAux != 0 {
+		size += 2
+	}
+	if len(bs) != size*zkt.ElemBytesLen {
+		return ErrNodeBytesBadSize
+	}
+	for i := 0; i < siblings; i++ {
+		dec.Siblings = append(dec.Siblings, readHash())
+	}
+	if flags&proofFlagKey != 0 {
+		dec.Key = readHash()
+	}
+	if flags&proofFlagNodeAux != 0 {
+		dec.NodeAux = &NodeAux{Key: readHash(), Value: readHash()}
+	}
+	*proof = dec
+	return nil
+}
+
 // VerifyProof verifies the Merkle Proof for the entry and root.
 func VerifyProofZkTrie(rootKey *zkt.Hash, proof *Proof, node *Node) bool {
 	key, err := node.Key()
@@ -900,6 +1000,187 @@ func (mt *ZkTrieImpl) walk(key *zkt.Hash, f func(*Node)) error {
 	return nil
 }
 
+// walkLeaves is a helper recursive function to call f for all leaves below the
+// given key, which is at the given depth.
+func (mt *ZkTrieImpl) walkLeaves(key *zkt.Hash, depth int, f func(key, value []byte, depth int) error) error {
+	return mt.walkLeafNodes(key, depth, func(n *Node, depth int) error {
+		return f(n.NodeKey.Bytes(), n.Data(), depth)
+	})
+}
+
+// walkLeafNodes is a helper recursive function to call f for all leaf nodes
+// below the given key, which is at the given depth.
+func (mt *ZkTrieImpl) walkLeafNodes(key *zkt.Hash, depth int, f func(n *Node, depth int) error) error {
+	n, err := mt.GetNode(key)
+	if err != nil {
+		return err
+	}
+	switch n.Type {
+	case NodeTypeEmpty:
+		return nil
+	case NodeTypeLeaf:
+		return f(n, depth)
+	case NodeTypeMiddle:
+		if err := mt.walkLeafNodes(n.ChildL, depth+1, f); err != nil {
+			return err
+		}
+		return mt.walkLeafNodes(n.ChildR, depth+1, f)
+	default:
+		return ErrInvalidNodeFound
+	}
+}
+
+// walkLeafNodesFrom is a helper recursive function to call f for the leaf
+// nodes below the given key, which is at the given depth, whose path comes after
+// the path of the given hashed key, or is the same if inclusive is set. A nil
+// hashed key selects all the leaves.
+func (mt *ZkTrieImpl) walkLeafNodesFrom(key *zkt.Hash, depth int, start *zkt.Hash, inclusive bool, f func(n *Node) error) error {
+	if start == nil {
+		return mt.walkLeafNodes(key, depth, func(n *Node, depth int) error {
+			return f(n)
+		})
+	}
+	n, err := mt.GetNode(key)
+	if err != nil {
+		return err
+	}
+	switch n.Type {
+	case NodeTypeEmpty:
+		return nil
+	case NodeTypeLeaf:
+		// The leaf shares the first depth bits of its path with the hashed key,
+		// compare the remaining ones.
+		for i := depth; i < mt.maxLevels; i++ {
+			if bit := zkt.TestBit(n.NodeKey[:], uint(i)); bit != zkt.TestBit(start[:], uint(i)) {
+				if bit {
+					return f(n)
+				}
+				return nil
+			}
+		}
+		if inclusive {
+			return f(n)
+		}
+		return nil
+	case NodeTypeMiddle:
+		if zkt.TestBit(start[:], uint(depth)) {
+			return mt.walkLeafNodesFrom(n.ChildR, depth+1, start, inclusive, f)
+		}
+		if err := mt.walkLeafNodesFrom(n.ChildL, depth+1, start, inclusive, f); err != nil {
+			return err
+		}
+		return mt.walkLeafNodesFrom(n.ChildR, depth+1, nil, inclusive, f)
+	default:
+		return ErrInvalidNodeFound
+	}
+}
+
+// walkNodes is a helper recursive function to call f for all non-empty nodes
+// below the given key, together with the database key they are stored under.
+func (mt *ZkTrieImpl) walkNodes(key *zkt.Hash, f func(key []byte, n *Node) error) error {
+	n, err := mt.GetNode(key)
+	if err != nil {
+		return err
+	}
+	switch n.Type {
+	case NodeTypeEmpty:
+		return nil
+	case NodeTypeLeaf:
+		return f(key[:], n)
+	case NodeTypeMiddle:
+		if err := f(key[:], n); err != nil {
+			return err
+		}
+		if err := mt.walkNodes(n.ChildL, f); err != nil {
+			return err
+		}
+		return mt.walkNodes(n.ChildR, f)
+	default:
+		return ErrInvalidNodeFound
+	}
+}
+
+// diffLeaves is a helper recursive function to call f for all leaves differing
+// between the subtrie below the given key and the one below the other key in the
+// other trie, both being at the same path. Equal subtries are skipped.
+func (mt *ZkTrieImpl) diffLeaves(key *zkt.Hash, other *ZkTrieImpl, otherKey *zkt.Hash, f func(key, value, otherValue []byte) error) error {
+	if bytes.Equal(key[:], otherKey[:]) {
+		return nil
+	}
+	n, err := mt.GetNode(key)
+	if err != nil {
+		return err
+	}
+	on, err := other.GetNode(otherKey)
+	if err != nil {
+		return err
+	}
+	if n.Type == NodeTypeMiddle && on.Type == NodeTypeMiddle {
+		if err := mt.diffLeaves(n.ChildL, other, on.ChildL, f); err != nil {
+			return err
+		}
+		return mt.diffLeaves(n.ChildR, other, on.ChildR, f)
+	}
+	// The shapes differ, compare all the leaves below the path. At least one of
+	// the subtries is a single leaf or empty, so there are few of them.
+	values := make(map[string][]byte)
+	err = mt.walkLeaves(key, 0, func(key, value []byte, depth int) error {
+		values[string(key)] = common.CopyBytes(value)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = other.walkLeaves(otherKey, 0, func(key, value []byte, depth int) error {
+		k := string(key)
+		if v, ok := values[k]; ok {
+			delete(values, k)
+			if bytes.Equal(v, value) {
+				return nil
+			}
+			return f(key, v, value)
+		}
+		return f(key, nil, value)
+	})
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := f([]byte(k), values[k], nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkNodes is a helper recursive function to verify that all nodes below the
+// given key exist, down to the given depth.
+func (mt *ZkTrieImpl) checkNodes(key *zkt.Hash, depth int) error {
+	n, err := mt.GetNode(key)
+	if err != nil {
+		return fmt.Errorf("missing trie node %x: %w", key.Bytes(), err)
+	}
+	switch n.Type {
+	case NodeTypeEmpty, NodeTypeLeaf:
+		return nil
+	case NodeTypeMiddle:
+		if depth == 0 {
+			return nil
+		}
+		if err := mt.checkNodes(n.ChildL, depth-1); err != nil {
+			return err
+		}
+		return mt.checkNodes(n.ChildR, depth-1)
+	default:
+		return ErrInvalidNodeFound
+	}
+}
+
 // Walk iterates over all the branches of a ZkTrieImpl with the given rootKey
 // if rootKey is nil, it will get the current RootKey of the current state of
 // the ZkTrieImpl.  For each node, it calls the f function given in the
@@ -1,6 +1,7 @@
 package trie
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"math/big"
@@ -91,6 +92,9 @@ func NewNodeFromBytes(b []byte) (*Node, error) {
 		mark := binary.LittleEndian.Uint32(b[32:36])
 		preimageLen := int(mark & 255)
 		n.CompressedFlags = mark >> 8
+		if len(b) < 36+preimageLen*32+1 {
+			return nil, ErrNodeBytesBadSize
+		}
 		n.ValuePreimage = make([]zkt.Byte32, preimageLen)
 		curPos := 36
 		for i := 0; i < preimageLen; i++ {
@@ -99,10 +103,16 @@ func NewNodeFromBytes(b []byte) (*Node, error) {
 		curPos = 36 + preimageLen*32
 		preImageSize := int(b[curPos])
 		curPos += 1
+		if preImageSize > len(zkt.Byte32{}) || len(b) < curPos+preImageSize {
+			return nil, ErrNodeBytesBadSize
+		}
 		if preImageSize != 0 {
 			n.KeyPreimage = new(zkt.Byte32)
 			copy(n.KeyPreimage[:], b[curPos:curPos+preImageSize])
 		}
+		if len(b) != curPos+preImageSize {
+			return nil, ErrNodeBytesBadSize
+		}
 	case NodeTypeEmpty:
 		break
 	default:
@@ -209,6 +219,31 @@ func (n *Node) Value() []byte {
 	}
 }
 
+// MarshalBinary implements encoding.BinaryMarshaler, encoding the node in the
+// same format it is stored in the database.
+func (n *Node) MarshalBinary() ([]byte, error) {
+	switch n.Type {
+	case NodeTypeMiddle, NodeTypeLeaf, NodeTypeEmpty:
+		return n.Value(), nil
+	default:
+		return nil, ErrInvalidNodeFound
+	}
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. Only the canonical
+// encoding of a node is accepted, so that equal nodes have equal encodings.
+func (n *Node) UnmarshalBinary(b []byte) error {
+	dec, err := NewNodeFromBytes(b)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(dec.Value(), b) {
+		return ErrNodeBytesBadSize
+	}
+	*n = *dec
+	return nil
+}
+
 // String outputs a string representation of a node (different for each type).
 func (n *Node) String() string {
 	switch n.Type {
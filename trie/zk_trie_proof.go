@@ -14,11 +14,22 @@ import (
 var magicHash []byte
 var magicSMTBytes []byte
 
+// compactProofKey is the key a compact zk trie proof is stored under in a proof
+// database, letting VerifyProof tell it apart from the node based forms.
+var compactProofKey []byte
+
 func init() {
 	magicSMTBytes = []byte("THIS IS SOME MAGIC BYTES FOR SMT m1rRXgP2xpDI")
 	hasher := newHasher(false)
 	defer returnHasherToPool(hasher)
 	magicHash = hasher.hashData(magicSMTBytes)
+	compactProofKey = hasher.hashData([]byte("COMPACT SMT PROOF"))
+}
+
+// IsProofMarker reports whether a proof entry is the marker appended to every zk
+// trie proof to tell it apart from merkle patricia ones, rather than a node.
+func IsProofMarker(blob []byte) bool {
+	return bytes.Equal(blob, magicSMTBytes)
 }
 
 // Prove constructs a merkle proof for SMT, it respect the protocol used by the ethereum-trie
@@ -122,7 +133,11 @@ func buildZkTrieProof(rootKey *zkt.Hash, k *big.Int, lvl int, getNode func(key *
 				return p, n, nil
 			}
 			// We found a leaf whose entry didn't match hIndex
-			p.NodeAux = &NodeAux{Key: n.NodeKey, Value: n.valueHash}
+			valueHash, err := n.ValueKey()
+			if err != nil {
+				return nil, nil, err
+			}
+			p.NodeAux = &NodeAux{Key: n.NodeKey, Value: valueHash}
 			return p, n, nil
 		case NodeTypeMiddle:
 			if path[p.depth] {
@@ -193,3 +208,233 @@ func VerifyProofSMT(rootHash common.Hash, key []byte, proofDb ethdb.KeyValueRead
 		return nil, fmt.Errorf("bad proof node %v", proof)
 	}
 }
+
+// ProveCompact constructs a merkle proof for the key in the compact encoding and
+// stores it in the proof database. See EncodeCompactProof for the format.
+func (mt *ZkTrieImpl) ProveCompact(key []byte, proofDb ethdb.KeyValueWriter) error {
+	kHash, err := zkt.NewHashFromBytes(common.BytesToHash(key).Bytes())
+	if err != nil {
+		return err
+	}
+	blob, err := mt.proveCompact(kHash)
+	if err != nil {
+		return err
+	}
+	return proofDb.Put(compactProofKey, blob)
+}
+
+// proveCompact collects the nodes on the path of the key and packs them into a
+// compact proof.
+func (mt *ZkTrieImpl) proveCompact(kHash *zkt.Hash) ([]byte, error) {
+	var nodes []*Node
+	err := mt.prove(kHash, 0, func(n *Node) error {
+		nodes = append(nodes, n)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return encodeCompactProof(kHash, nodes)
+}
+
+// EncodeCompactProof converts a node based zk trie proof of the key into the
+// compact encoding. The compact form omits the nodes on the path itself, which
+// can be recomputed from below, and the empty siblings, which hash to zero:
+//
+//	depth (1 byte) || sibling bitmap (depth bits) || siblings (32 bytes each) || terminal node
+//
+// where bit i of the bitmap is set if the sibling at level i is not empty, and the
+// terminal node is the leaf or empty node the path of the key ends at. The node
+// key of a leaf holding the proven key is omitted as well.
+func EncodeCompactProof(rootHash common.Hash, key []byte, proofDb ethdb.KeyValueReader) ([]byte, error) {
+	root, err := zkt.NewHashFromBytes(rootHash.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	word := zkt.NewByte32FromBytesPaddingZero(key)
+	k, err := word.Hash()
+	if err != nil {
+		return nil, err
+	}
+	kHash := zkt.NewHashFromBigInt(k)
+	path := getPath(maxCompactProofDepth, kHash[:])
+
+	var nodes []*Node
+	for next := root; ; {
+		buf, _ := proofDb.Get(next[:])
+		if buf == nil {
+			return nil, fmt.Errorf("proof node %d (hash %x) missing", len(nodes), next[:])
+		}
+		n, err := NewNodeFromBytes(buf)
+		if err != nil {
+			return nil, fmt.Errorf("bad proof node %d: %v", len(nodes), err)
+		}
+		nodes = append(nodes, n)
+		if n.Type != NodeTypeMiddle {
+			break
+		}
+		if len(nodes) > maxCompactProofDepth {
+			return nil, fmt.Errorf("proof deeper than %d levels", maxCompactProofDepth)
+		}
+		if path[len(nodes)-1] {
+			next = n.ChildR
+		} else {
+			next = n.ChildL
+		}
+	}
+	return encodeCompactProof(kHash, nodes)
+}
+
+const (
+	// maxCompactProofDepth is the deepest path a compact proof can represent.
+	maxCompactProofDepth = 8 * (zkt.ElemBytesLen - proofFlagsLen)
+
+	// compactLeafAtKey replaces the type of the leaf node in a compact proof of
+	// existence, whose node key is omitted as it equals the hash of the key.
+	compactLeafAtKey = 0xff
+)
+
+// encodeCompactProof packs the nodes on the path of a key, ordered from the root
+// down to the terminal node, into a compact proof.
+func encodeCompactProof(kHash *zkt.Hash, nodes []*Node) ([]byte, error) {
+	if len(nodes) == 0 {
+		return nil, ErrKeyNotFound
+	}
+	depth := len(nodes) - 1
+	if depth > maxCompactProofDepth {
+		return nil, fmt.Errorf("proof deeper than %d levels", maxCompactProofDepth)
+	}
+	var (
+		path     = getPath(depth, kHash[:])
+		bitmap   = make([]byte, (depth+7)/8)
+		siblings []byte
+	)
+	for i, n := range nodes[:depth] {
+		if n.Type != NodeTypeMiddle {
+			return nil, ErrInvalidNodeFound
+		}
+		sibling := n.ChildR
+		if path[i] {
+			sibling = n.ChildL
+		}
+		if bytes.Equal(sibling[:], zkt.HashZero[:]) {
+			continue
+		}
+		bitmap[i/8] |= 1 << (i % 8)
+		siblings = append(siblings, sibling[:]...)
+	}
+	terminal := nodes[depth]
+	if terminal.Type == NodeTypeMiddle {
+		return nil, ErrInvalidNodeFound
+	}
+	blob := make([]byte, 0, 1+len(bitmap)+len(siblings)+1)
+	blob = append(blob, byte(depth))
+	blob = append(blob, bitmap...)
+	blob = append(blob, siblings...)
+
+	// The key of the leaf proving existence is the hash of the proven key itself
+	value := terminal.Value()
+	if terminal.Type == NodeTypeLeaf && bytes.Equal(terminal.NodeKey[:], kHash[:]) {
+		blob = append(blob, compactLeafAtKey)
+		return append(blob, value[1+zkt.ElemBytesLen:]...), nil
+	}
+	return append(blob, value...), nil
+}
+
+// DecodeCompactProof unpacks a compact proof of the key with the given hash into
+// the siblings on the path and the terminal node.
+func DecodeCompactProof(kHash *zkt.Hash, blob []byte) (*Proof, *Node, error) {
+	if len(blob) < 1 {
+		return nil, nil, ErrNodeBytesBadSize
+	}
+	depth := int(blob[0])
+	if depth > maxCompactProofDepth {
+		return nil, nil, fmt.Errorf("proof deeper than %d levels", maxCompactProofDepth)
+	}
+	blob = blob[1:]
+
+	size := (depth + 7) / 8
+	if len(blob) < size {
+		return nil, nil, ErrNodeBytesBadSize
+	}
+	bitmap := blob[:size]
+	blob = blob[size:]
+
+	proof := &Proof{depth: uint(depth)}
+	for i := 0; i < depth; i++ {
+		if !zkt.TestBit(bitmap, uint(i)) {
+			continue
+		}
+		if len(blob) < zkt.ElemBytesLen {
+			return nil, nil, ErrNodeBytesBadSize
+		}
+		sibling := new(zkt.Hash)
+		copy(sibling[:], blob[:zkt.ElemBytesLen])
+		blob = blob[zkt.ElemBytesLen:]
+
+		zkt.SetBitBigEndian(proof.notempties[:], uint(i))
+		proof.Siblings = append(proof.Siblings, sibling)
+	}
+	if len(blob) > 0 && blob[0] == compactLeafAtKey {
+		leaf := append([]byte{byte(NodeTypeLeaf)}, kHash[:]...)
+		blob = append(leaf, blob[1:]...)
+	}
+	n, err := NewNodeFromBytes(blob)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch n.Type {
+	case NodeTypeMiddle:
+		return nil, nil, ErrInvalidNodeFound
+	case NodeTypeLeaf:
+		proof.Existence = bytes.Equal(n.NodeKey[:], kHash[:])
+	}
+	return proof, n, nil
+}
+
+// VerifyCompactProof checks a compact proof of the key against the root hash. It
+// returns the value of the key, or nil if the proof shows that it is absent.
+func VerifyCompactProof(rootHash common.Hash, key []byte, blob []byte) ([]byte, error) {
+	root, err := zkt.NewHashFromBytes(rootHash.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	word := zkt.NewByte32FromBytesPaddingZero(key)
+	k, err := word.Hash()
+	if err != nil {
+		return nil, err
+	}
+	kHash := zkt.NewHashFromBigInt(k)
+
+	proof, n, err := DecodeCompactProof(kHash, blob)
+	if err != nil {
+		return nil, fmt.Errorf("bad compact proof: %v", err)
+	}
+	// A leaf of another key proves absence only if it sits on the path of the key
+	if n.Type == NodeTypeLeaf {
+		if !proof.Existence {
+			depth := int(proof.depth)
+			leafPath, keyPath := getPath(depth, n.NodeKey[:]), getPath(depth, kHash[:])
+			for i := 0; i < depth; i++ {
+				if leafPath[i] != keyPath[i] {
+					return nil, fmt.Errorf("bad compact proof: leaf %v off the key path", n.NodeKey)
+				}
+			}
+		}
+	}
+	nodeKey, err := n.Key()
+	if err != nil {
+		return nil, err
+	}
+	computed, err := proof.rootFromProof(nodeKey, kHash)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(computed[:], root[:]) {
+		return nil, fmt.Errorf("bad compact proof: root mismatch (have %x, want %x)", computed.Bytes(), root.Bytes())
+	}
+	if !proof.Existence {
+		return nil, nil
+	}
+	return n.Data(), nil
+}
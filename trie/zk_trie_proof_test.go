@@ -19,6 +19,7 @@ package trie
 import (
 	"bytes"
 	mrand "math/rand"
+	"reflect"
 	"testing"
 	"time"
 
@@ -187,3 +188,162 @@ func randomZktrie(t *testing.T, n int) (*ZkTrieImpl, map[string]*kv) {
 
 	return mt, vals
 }
+
+func TestSMTCompactProof(t *testing.T) {
+	mt, vals := randomZktrie(t, 500)
+	root := common.BytesToHash(mt.Root().Bytes())
+	for _, kv := range vals {
+		word := zkt.NewByte32FromBytesPaddingZero(kv.k)
+		k, err := word.Hash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		proof := memorydb.New()
+		if err := mt.Prove(k.Bytes(), 0, proof); err != nil {
+			t.Fatalf("failed to prove key %x: %v", kv.k, err)
+		}
+		compact := memorydb.New()
+		if err := mt.ProveCompact(k.Bytes(), compact); err != nil {
+			t.Fatalf("failed to compactly prove key %x: %v", kv.k, err)
+		}
+		blob, _ := compact.Get(compactProofKey)
+
+		// The compact proof must match the converted one and be at most half the size
+		converted, err := EncodeCompactProof(root, kv.k, proof)
+		if err != nil {
+			t.Fatalf("failed to convert proof of key %x: %v", kv.k, err)
+		}
+		if !bytes.Equal(blob, converted) {
+			t.Fatalf("compact proof mismatch for key %x: have %x, want %x", kv.k, converted, blob)
+		}
+		size := 0
+		it := proof.NewIterator(nil, nil)
+		for it.Next() {
+			size += len(it.Value())
+		}
+		it.Release()
+		if len(blob)*2 > size {
+			t.Errorf("compact proof of key %x too large: %d bytes, full proof %d bytes", kv.k, len(blob), size)
+		}
+		val, err := VerifyProof(root, kv.k, compact)
+		if err != nil {
+			t.Fatalf("failed to verify compact proof for key %x: %v", kv.k, err)
+		}
+		if !verifyValue(val, zkt.NewByte32FromBytesPaddingZero(kv.v)[:]) {
+			t.Fatalf("verified value mismatch for key %x, want %x, get %x", kv.k, kv.v, val)
+		}
+	}
+	// Ensure missing keys are proven absent
+	for _, key := range []string{"a", "j", "l", "z"} {
+		keyBytes := bytes.Repeat([]byte(key), 32)
+		word := zkt.NewByte32FromBytesPaddingZero(keyBytes)
+		k, err := word.Hash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		compact := memorydb.New()
+		if err := mt.ProveCompact(k.Bytes(), compact); err != nil {
+			t.Fatalf("failed to compactly prove key %x: %v", keyBytes, err)
+		}
+		val, err := VerifyProof(root, keyBytes, compact)
+		if err != nil {
+			t.Fatalf("failed to verify compact proof for missing key %x: %v", keyBytes, err)
+		}
+		if val != nil {
+			t.Fatalf("verified value mismatch for missing key %x: have %x, want nil", keyBytes, val)
+		}
+	}
+}
+
+func TestSMTCompactBadProof(t *testing.T) {
+	mt, vals := randomZktrie(t, 500)
+	root := common.BytesToHash(mt.Root().Bytes())
+	for _, kv := range vals {
+		word := zkt.NewByte32FromBytesPaddingZero(kv.k)
+		k, err := word.Hash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		compact := memorydb.New()
+		if err := mt.ProveCompact(k.Bytes(), compact); err != nil {
+			t.Fatalf("failed to compactly prove key %x: %v", kv.k, err)
+		}
+		blob, _ := compact.Get(compactProofKey)
+		blob = common.CopyBytes(blob)
+
+		// Corrupt one of the siblings, leaving the structure intact
+		start := 1 + (int(blob[0])+7)/8
+		siblings := 0
+		for i := 0; i < int(blob[0]); i++ {
+			if zkt.TestBit(blob[1:start], uint(i)) {
+				siblings++
+			}
+		}
+		if siblings == 0 {
+			continue
+		}
+		blob[start+mrand.Intn(siblings*zkt.ElemBytesLen)] ^= 0x01
+		compact.Put(compactProofKey, blob)
+
+		if val, err := VerifyProof(root, kv.k, compact); err == nil && verifyValue(val, zkt.NewByte32FromBytesPaddingZero(kv.v)[:]) {
+			t.Fatalf("expected compact proof to fail for key %x", kv.k)
+		}
+	}
+}
+
+func TestSMTProofBinary(t *testing.T) {
+	mt, vals := randomZktrie(t, 100)
+	var keys [][]byte
+	for _, kv := range vals {
+		keys = append(keys, kv.k)
+	}
+	keys = append(keys, bytes.Repeat([]byte("a"), 32), bytes.Repeat([]byte("z"), 32))
+
+	for _, key := range keys {
+		word := zkt.NewByte32FromBytesPaddingZero(key)
+		k, err := word.Hash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		proof, node, err := buildZkTrieProof(mt.rootKey, k, mt.maxLevels, mt.GetNode)
+		if err != nil {
+			t.Fatalf("failed to build proof for key %x: %v", key, err)
+		}
+		enc, err := proof.MarshalBinary()
+		if err != nil {
+			t.Fatalf("failed to encode proof for key %x: %v", key, err)
+		}
+		dec := new(Proof)
+		if err := dec.UnmarshalBinary(enc); err != nil {
+			t.Fatalf("failed to decode proof for key %x: %v", key, err)
+		}
+		if !reflect.DeepEqual(proof, dec) {
+			t.Fatalf("proof mismatch for key %x: have %+v, want %+v", key, dec, proof)
+		}
+		if reenc, _ := dec.MarshalBinary(); !bytes.Equal(enc, reenc) {
+			t.Fatalf("proof encoding not canonical for key %x", key)
+		}
+		if err := new(Proof).UnmarshalBinary(append(enc, 0)); err == nil {
+			t.Fatalf("proof with trailing bytes accepted for key %x", key)
+		}
+		// Existence proofs of the decoded proof must still verify
+		if dec.Existence && !VerifyProofZkTrie(mt.rootKey, dec, node) {
+			t.Fatalf("decoded proof for key %x fails to verify", key)
+		}
+		// The terminal node must round trip too
+		blob, err := node.MarshalBinary()
+		if err != nil {
+			t.Fatalf("failed to encode node for key %x: %v", key, err)
+		}
+		decNode := new(Node)
+		if err := decNode.UnmarshalBinary(blob); err != nil {
+			t.Fatalf("failed to decode node for key %x: %v", key, err)
+		}
+		if !bytes.Equal(decNode.Value(), node.Value()) {
+			t.Fatalf("node mismatch for key %x", key)
+		}
+		if err := new(Node).UnmarshalBinary(append(blob, 0)); err == nil {
+			t.Fatalf("node with trailing bytes accepted for key %x", key)
+		}
+	}
+}
@@ -18,6 +18,9 @@ package trie
 
 import (
 	"bytes"
+	"errors"
+	"math/big"
+	"reflect"
 	"runtime"
 	"sync"
 	"testing"
@@ -25,6 +28,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/rawdb"
 	zkt "github.com/scroll-tech/go-ethereum/core/types/zktrie"
 	"github.com/scroll-tech/go-ethereum/ethdb/memorydb"
 )
@@ -147,3 +151,300 @@ func TestZkTrieConcurrency(t *testing.T) {
 	// Wait for all threads to finish
 	pend.Wait()
 }
+
+func TestZkTrieCheckNodes(t *testing.T) {
+	diskdb := memorydb.New()
+	triedb := NewZktrieDatabase(diskdb)
+	trie, _ := NewZkTrie(common.Hash{}, triedb)
+	for i := byte(0); i < 64; i++ {
+		trie.Update(common.LeftPadBytes([]byte{1, i}, 32), bytes.Repeat([]byte{i}, 32))
+	}
+	if err := triedb.db.Commit(common.Hash{}, false, nil); err != nil {
+		t.Fatalf("failed to flush trie nodes: %v", err)
+	}
+	if err := trie.CheckNodes(8); err != nil {
+		t.Fatalf("complete trie reported missing nodes: %v", err)
+	}
+	// Drop a child of the root and ensure it's only detected when checked
+	root, err := trie.tree.GetNode(trie.tree.rootKey)
+	if err != nil {
+		t.Fatalf("failed to resolve root node: %v", err)
+	}
+	if root.Type != NodeTypeMiddle {
+		t.Fatalf("root node type mismatch: have %v, want %v", root.Type, NodeTypeMiddle)
+	}
+	if err := diskdb.Delete(root.ChildL[:]); err != nil {
+		t.Fatalf("failed to delete child node: %v", err)
+	}
+	if err := trie.CheckNodes(0); err != nil {
+		t.Fatalf("root only check failed: %v", err)
+	}
+	if err := trie.CheckNodes(1); err == nil {
+		t.Fatalf("missing child node not detected")
+	}
+}
+
+func TestZkTrieAccessEpoch(t *testing.T) {
+	diskdb := memorydb.New()
+	triedb := NewZktrieDatabase(diskdb)
+	trie, _ := NewZkTrie(common.Hash{}, triedb)
+
+	var (
+		key1 = common.LeftPadBytes([]byte{1}, 32)
+		key2 = common.LeftPadBytes([]byte{2}, 32)
+		key3 = common.LeftPadBytes([]byte{3}, 32)
+	)
+	trie.Update(key1, bytes.Repeat([]byte{1}, 32))
+	trie.Update(key2, bytes.Repeat([]byte{2}, 32))
+	trie.Update(key3, bytes.Repeat([]byte{3}, 32))
+
+	// The same leaf in a trie of another owner
+	other, _ := NewZkTrie(common.Hash{}, triedb)
+	other.SetOwner(common.Hash{0x01})
+	other.Update(key3, bytes.Repeat([]byte{3}, 32))
+
+	if err := triedb.db.Commit(common.Hash{}, false, nil); err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	// Writing leaves doesn't record epochs, the canonical chain does
+	epochs := func(tr *ZkTrie) map[common.Hash]uint64 {
+		epochs := make(map[common.Hash]uint64)
+		err := tr.WalkLeafNodes(func(n *Node, depth int) error {
+			epoch, err := tr.LeafEpoch(n)
+			if err != nil {
+				return err
+			}
+			epochs[common.BytesToHash(n.NodeKey.Bytes())] = epoch
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("failed to walk trie: %v", err)
+		}
+		return epochs
+	}
+	hash1, _ := ZkTrieKey(key1)
+	hash2, _ := ZkTrieKey(key2)
+	hash3, _ := ZkTrieKey(key3)
+	for hash, epoch := range epochs(trie) {
+		if epoch != 0 {
+			t.Errorf("leaf %x: epoch recorded on write: %d", hash, epoch)
+		}
+	}
+	rawdb.WriteZkLeafEpoch(diskdb, common.Hash{}, hash1, 5)
+	rawdb.WriteZkLeafEpoch(diskdb, common.Hash{}, hash3, 3)
+	rawdb.WriteZkLeafEpoch(diskdb, common.Hash{0x01}, hash3, 7)
+
+	want := map[common.Hash]uint64{hash1: 5, hash2: 0, hash3: 3}
+	if have := epochs(trie); !reflect.DeepEqual(have, want) {
+		t.Errorf("epoch mismatch: have %v, want %v", have, want)
+	}
+	want = map[common.Hash]uint64{hash3: 7}
+	if have := epochs(other); !reflect.DeepEqual(have, want) {
+		t.Errorf("epoch mismatch of other owner: have %v, want %v", have, want)
+	}
+	// Malformed epochs are rejected
+	diskdb.Put(rawdb.ZkLeafEpochKey(common.Hash{}, hash1), []byte{0x01})
+	err := trie.WalkLeafNodes(func(n *Node, depth int) error {
+		_, err := trie.LeafEpoch(n)
+		return err
+	})
+	if err == nil {
+		t.Errorf("malformed epoch accepted")
+	}
+}
+
+func TestZkTrieCleanCacheEpochs(t *testing.T) {
+	diskdb := memorydb.New()
+	triedb := NewZktrieDatabaseFromTriedb(NewDatabaseWithConfig(diskdb, &Config{Cache: 16}))
+
+	key, val := common.LeftPadBytes([]byte{1}, 32), bytes.Repeat([]byte{1}, 32)
+	commit := func(epoch uint64) common.Hash {
+		trie, _ := NewZkTrie(common.Hash{}, triedb)
+		trie.Update(key, val)
+		if err := triedb.db.Commit(common.Hash{}, false, nil); err != nil {
+			t.Fatalf("failed to commit trie: %v", err)
+		}
+		hash, _ := ZkTrieKey(key)
+		rawdb.WriteZkLeafEpoch(diskdb, common.Hash{}, hash, epoch)
+		return trie.Hash()
+	}
+	check := func(root common.Hash, want uint64) {
+		trie, _ := NewZkTrie(root, triedb)
+		err := trie.WalkLeafNodes(func(n *Node, depth int) error {
+			epoch, err := trie.LeafEpoch(n)
+			if err != nil {
+				return err
+			}
+			if epoch != want {
+				t.Errorf("epoch mismatch: have %d, want %d", epoch, want)
+			}
+			// The cached node must match the one on disk
+			hash, _ := n.Key()
+			blob, _ := diskdb.Get(hash[:])
+			if enc := triedb.db.cleans.Get(nil, hash[:]); !bytes.Equal(enc, blob) {
+				t.Errorf("cached node mismatch: have %x, want %x", enc, blob)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("failed to walk trie: %v", err)
+		}
+	}
+	// Load the leaf into the clean cache, then rewrite it in a later epoch. The
+	// node bytes don't change, so the cached node stays valid.
+	root := commit(3)
+	check(root, 3)
+	if commit(5) != root {
+		t.Fatalf("rewriting a leaf with its old value changed the root")
+	}
+	check(root, 5)
+}
+
+func TestZkTrieNodeTrailer(t *testing.T) {
+	n := NewNodeLeaf(zkt.NewHashFromBigInt(big.NewInt(1)), 1, []zkt.Byte32{{0x02}})
+	if _, err := n.Key(); err != nil {
+		t.Fatal(err)
+	}
+	if dec, err := NewNodeFromBytes(n.Value()); err != nil {
+		t.Fatalf("failed to decode leaf: %v", err)
+	} else if !bytes.Equal(dec.Value(), n.Value()) {
+		t.Fatalf("leaf mismatch: have %x, want %x", dec.Value(), n.Value())
+	}
+	// A leaf must end with its key preimage, so that it has a single encoding
+	for _, trailer := range []int{1, 7, 8, 9} {
+		blob := append(n.Value(), make([]byte, trailer)...)
+		if _, err := NewNodeFromBytes(blob); err == nil {
+			t.Errorf("trailer of %d bytes accepted", trailer)
+		}
+	}
+}
+
+func TestZkTrieWalkLeafNodesAfter(t *testing.T) {
+	_, trie, content := makeTestZkTrie()
+
+	var all [][]byte
+	err := trie.WalkLeafNodes(func(n *Node, depth int) error {
+		all = append(all, n.NodeKey.Bytes())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to walk trie: %v", err)
+	}
+	if len(all) != len(content) {
+		t.Fatalf("leaf count mismatch: have %d, want %d", len(all), len(content))
+	}
+	// Read the trie in pages, resuming after the last leaf of each page, and
+	// rebuild it from the leaves into a fresh database
+	var (
+		errPageFull = errors.New("page full")
+		rebuilt, _  = NewZkTrie(common.Hash{}, NewZktrieDatabase(memorydb.New()))
+		have        [][]byte
+		after       []byte
+	)
+	for {
+		var page int
+		err := trie.WalkLeafNodesAfter(after, func(n *Node) error {
+			if page == 7 {
+				return errPageFull
+			}
+			page++
+			have = append(have, n.NodeKey.Bytes())
+			after = n.NodeKey.Bytes()
+			return rebuilt.TryUpdateLeaf(n)
+		})
+		if err == nil {
+			break
+		}
+		if err != errPageFull {
+			t.Fatalf("failed to walk trie: %v", err)
+		}
+	}
+	assert.Equal(t, all, have)
+	if rebuilt.Hash() != trie.Hash() {
+		t.Fatalf("rebuilt root mismatch: have %x, want %x", rebuilt.Hash(), trie.Hash())
+	}
+	// Keys absent from the trie resume at the next leaf in path order
+	for i := 0; i < len(all)-1; i++ {
+		absent, _ := zkt.NewHashFromBytes(all[i])
+		absent[31] ^= 0x20 // flip a bit deeper than any leaf
+
+		want := all[i]
+		if absent[31]&0x20 != 0 {
+			want = all[i+1]
+		}
+		var next []byte
+		err := trie.WalkLeafNodesAfter(absent.Bytes(), func(n *Node) error {
+			next = n.NodeKey.Bytes()
+			return errPageFull
+		})
+		if err != errPageFull {
+			t.Fatalf("failed to walk trie: %v", err)
+		}
+		if !bytes.Equal(next, want) {
+			t.Fatalf("walk after %x resumed at %x, want %x", absent.Bytes(), next, want)
+		}
+	}
+	// Walks from a key present in the trie include it
+	for _, key := range all {
+		var first []byte
+		err := trie.WalkLeafNodesFrom(key, func(n *Node) error {
+			first = n.NodeKey.Bytes()
+			return errPageFull
+		})
+		if err != errPageFull {
+			t.Fatalf("failed to walk trie: %v", err)
+		}
+		if !bytes.Equal(first, key) {
+			t.Fatalf("walk from %x started at %x", key, first)
+		}
+	}
+}
+
+func TestZkTrieDiffLeaves(t *testing.T) {
+	triedb, trie, content := makeTestZkTrie()
+
+	other, err := NewZkTrie(trie.Hash(), triedb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		updated = common.LeftPadBytes([]byte{1, 1}, 32)
+		zeroed  = common.LeftPadBytes([]byte{2, 2}, 32)
+		created = common.LeftPadBytes([]byte{13, 3}, 32)
+		value   = bytes.Repeat([]byte{0xff}, 32)
+	)
+	other.Update(updated, value)
+	other.Delete(zeroed)
+	other.Update(created, value)
+
+	hashKey := func(key []byte) string {
+		kHash, err := zkt.NewByte32FromBytesPaddingZero(key).Hash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(zkt.NewHashFromBigInt(kHash).Bytes())
+	}
+	want := map[string][2][]byte{
+		hashKey(updated): {content[string(updated)], value},
+		hashKey(zeroed):  {content[string(zeroed)], make([]byte, 32)}, // deletions leave zero leaves
+		hashKey(created): {nil, value},
+	}
+	have := make(map[string][2][]byte)
+	err = trie.DiffLeaves(other, func(key, value, otherValue []byte) error {
+		have[string(key)] = [2][]byte{common.CopyBytes(value), common.CopyBytes(otherValue)}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to diff tries: %v", err)
+	}
+	assert.Equal(t, want, have)
+
+	// Identical tries have no differences
+	err = trie.DiffLeaves(trie, func(key, value, otherValue []byte) error {
+		t.Errorf("unexpected difference at %x", key)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to diff tries: %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package watchdog
+
+// Status is the state of the watchdog reported over RPC.
+type Status struct {
+	Halted    bool   `json:"halted"`
+	Reorgs    uint64 `json:"reorgs"`
+	LastReorg *Alert `json:"lastReorg"`
+}
+
+// PrivateWatchdogAPI provides the operator controls of the chain head watchdog.
+type PrivateWatchdogAPI struct {
+	s *Service
+}
+
+// Status returns whether sequencing is halted and the latest deep reorg.
+func (api *PrivateWatchdogAPI) Status() Status {
+	api.s.mu.Lock()
+	defer api.s.mu.Unlock()
+
+	return Status{Halted: api.s.halted, Reorgs: api.s.alerts, LastReorg: api.s.last}
+}
+
+// Resume confirms a deep reorg, restarting sequencing halted by the watchdog.
+func (api *PrivateWatchdogAPI) Resume() error {
+	return api.s.resume()
+}
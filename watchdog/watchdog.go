@@ -0,0 +1,263 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package watchdog implements a service guarding the chain head against deep
+// reorgs, re-queueing the dropped transactions and optionally halting sequencing.
+package watchdog
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/event"
+	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/metrics"
+	"github.com/scroll-tech/go-ethereum/node"
+	"github.com/scroll-tech/go-ethereum/rpc"
+)
+
+// reorgChanSize is the size of channel listening to ReorgEvent.
+const reorgChanSize = 10
+
+var errNotHalted = errors.New("sequencing not halted by the watchdog")
+
+var (
+	reorgMeter    = metrics.NewRegisteredMeter("watchdog/reorgs", nil)
+	requeueMeter  = metrics.NewRegisteredMeter("watchdog/requeued", nil)
+	rejectMeter   = metrics.NewRegisteredMeter("watchdog/rejected", nil)
+	haltMeter     = metrics.NewRegisteredMeter("watchdog/halts", nil)
+	depthGauge    = metrics.NewRegisteredGauge("watchdog/depth", nil)
+	ancestorGauge = metrics.NewRegisteredGauge("watchdog/ancestor", nil)
+)
+
+// Config are the configuration parameters of the chain head watchdog.
+type Config struct {
+	Depth uint64 `toml:",omitempty"` // Number of dropped blocks triggering the watchdog, 0 to disable
+	Halt  bool   `toml:",omitempty"` // Whether to stop sequencing after a deep reorg until resumed
+}
+
+// chain is the part of the blockchain watched for reorgs.
+type chain interface {
+	SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription
+	GetBlock(hash common.Hash, number uint64) *types.Block
+}
+
+// txPool re-validates and accepts the dropped transactions.
+type txPool interface {
+	AddRemotes(txs []*types.Transaction) []error
+}
+
+// sequencer is the block producer halted after a deep reorg.
+type sequencer interface {
+	IsMining() bool
+	StartMining(threads int) error
+	StopMining()
+}
+
+// Alert describes a deep reorg handled by the watchdog.
+type Alert struct {
+	Time           time.Time   `json:"time"`
+	Depth          uint64      `json:"depth"`
+	CommonAncestor uint64      `json:"commonAncestor"`
+	OldHead        common.Hash `json:"oldHead"`
+	NewHead        common.Hash `json:"newHead"`
+	Dropped        int         `json:"dropped"`
+	Requeued       int         `json:"requeued"`
+	Rejected       int         `json:"rejected"`
+	Halted         bool        `json:"halted"`
+}
+
+// Service implements a daemon which, after a reorg dropping at least the
+// configured number of blocks, raises an alarm and feeds the transactions
+// missing from the new chain back into the pool. The pool only reinjects the
+// transactions of shallow reorgs by itself.
+type Service struct {
+	cfg       Config
+	chain     chain
+	pool      txPool
+	sequencer sequencer
+
+	mu     sync.Mutex
+	halted bool   // Whether sequencing was stopped by the watchdog
+	alerts uint64 // Number of deep reorgs handled
+	last   *Alert // Latest deep reorg handled
+
+	reorgSub event.Subscription
+	quit     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// New creates a chain head watchdog and registers it and its API on the node.
+func New(node *node.Node, chain chain, pool txPool, sequencer sequencer, cfg *Config) error {
+	if cfg.Depth == 0 {
+		return errors.New("no reorg depth configured")
+	}
+	s := &Service{
+		cfg:       *cfg,
+		chain:     chain,
+		pool:      pool,
+		sequencer: sequencer,
+		quit:      make(chan struct{}),
+	}
+	node.RegisterAPIs([]rpc.API{{
+		Namespace: "watchdog",
+		Version:   "1.0",
+		Service:   &PrivateWatchdogAPI{s},
+	}})
+	node.RegisterLifecycle(s)
+	return nil
+}
+
+// Start implements node.Lifecycle, starting up the watchdog.
+func (s *Service) Start() error {
+	reorgCh := make(chan core.ReorgEvent, reorgChanSize)
+	s.reorgSub = s.chain.SubscribeReorgEvent(reorgCh)
+
+	s.wg.Add(1)
+	go s.loop(reorgCh)
+
+	log.Info("Chain head watchdog started", "depth", s.cfg.Depth, "halt", s.cfg.Halt)
+	return nil
+}
+
+// Stop implements node.Lifecycle, terminating the watchdog.
+func (s *Service) Stop() error {
+	s.reorgSub.Unsubscribe()
+	close(s.quit)
+	s.wg.Wait()
+
+	log.Info("Chain head watchdog stopped")
+	return nil
+}
+
+// loop handles the reorgs of the chain until termination.
+func (s *Service) loop(reorgCh chan core.ReorgEvent) {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case ev := <-reorgCh:
+			s.handle(&ev)
+		case <-s.reorgSub.Err():
+			return
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// handle checks the depth of a reorg, and if deep enough halts sequencing if
+// configured so and re-queues the dropped transactions.
+func (s *Service) handle(ev *core.ReorgEvent) {
+	depth := uint64(len(ev.OldChain))
+	if depth < s.cfg.Depth {
+		return
+	}
+	reorgMeter.Mark(1)
+	depthGauge.Update(int64(depth))
+	ancestorGauge.Update(ev.CommonAncestor.Number.Int64())
+
+	alert := &Alert{
+		Time:           time.Now(),
+		Depth:          depth,
+		CommonAncestor: ev.CommonAncestor.Number.Uint64(),
+		OldHead:        ev.OldChain[0].Hash(),
+		Dropped:        len(ev.Dropped),
+	}
+	if len(ev.NewChain) > 0 {
+		alert.NewHead = ev.NewChain[0].Hash()
+	}
+	// Stop building on the new chain before touching the pool
+	if s.cfg.Halt && s.sequencer.IsMining() {
+		s.sequencer.StopMining()
+		haltMeter.Mark(1)
+		alert.Halted = true
+	}
+	alert.Requeued, alert.Rejected = s.requeue(ev)
+
+	s.mu.Lock()
+	if alert.Halted {
+		s.halted = true
+	}
+	s.alerts++
+	s.last = alert
+	s.mu.Unlock()
+
+	log.Error("Deep chain reorg detected", "depth", depth, "ancestor", alert.CommonAncestor,
+		"oldhead", alert.OldHead, "newhead", alert.NewHead, "dropped", alert.Dropped,
+		"requeued", alert.Requeued, "rejected", alert.Rejected)
+	if alert.Halted {
+		log.Warn("Sequencing halted after deep reorg, resume with watchdog_resume")
+	}
+}
+
+// requeue feeds the dropped transactions of a reorg back into the pool in their
+// original order, returning the number of accepted and rejected ones.
+func (s *Service) requeue(ev *core.ReorgEvent) (requeued int, rejected int) {
+	if len(ev.Dropped) == 0 {
+		return 0, 0
+	}
+	dropped := make(map[common.Hash]struct{}, len(ev.Dropped))
+	for _, hash := range ev.Dropped {
+		dropped[hash] = struct{}{}
+	}
+	var txs []*types.Transaction
+	for i := len(ev.OldChain) - 1; i >= 0; i-- {
+		header := ev.OldChain[i]
+		block := s.chain.GetBlock(header.Hash(), header.Number.Uint64())
+		if block == nil {
+			log.Warn("Dropped block unavailable", "number", header.Number, "hash", header.Hash())
+			continue
+		}
+		for _, tx := range block.Transactions() {
+			if _, ok := dropped[tx.Hash()]; ok {
+				txs = append(txs, tx)
+			}
+		}
+	}
+	for i, err := range s.pool.AddRemotes(txs) {
+		if err != nil && !errors.Is(err, core.ErrAlreadyKnown) {
+			rejected++
+			log.Debug("Dropped transaction rejected", "hash", txs[i].Hash(), "err", err)
+			continue
+		}
+		requeued++
+	}
+	requeueMeter.Mark(int64(requeued))
+	rejectMeter.Mark(int64(rejected))
+	return requeued, rejected
+}
+
+// resume restarts sequencing after it was halted by the watchdog.
+func (s *Service) resume() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.halted {
+		return errNotHalted
+	}
+	if err := s.sequencer.StartMining(runtime.NumCPU()); err != nil {
+		return err
+	}
+	s.halted = false
+	log.Info("Sequencing resumed by the operator")
+	return nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package watchdog
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/event"
+)
+
+// testChain serves the blocks of the dropped chain segment.
+type testChain struct {
+	blocks map[common.Hash]*types.Block
+}
+
+func (c *testChain) SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription {
+	return new(event.Feed).Subscribe(ch)
+}
+
+func (c *testChain) GetBlock(hash common.Hash, number uint64) *types.Block {
+	return c.blocks[hash]
+}
+
+// testPool records the re-queued transactions, rejecting the configured ones.
+type testPool struct {
+	added  []*types.Transaction
+	reject map[common.Hash]bool
+}
+
+func (p *testPool) AddRemotes(txs []*types.Transaction) []error {
+	errs := make([]error, len(txs))
+	for i, tx := range txs {
+		if p.reject[tx.Hash()] {
+			errs[i] = core.ErrNonceTooLow
+			continue
+		}
+		p.added = append(p.added, tx)
+	}
+	return errs
+}
+
+// testSequencer tracks whether mining is running.
+type testSequencer struct {
+	mining bool
+}
+
+func (s *testSequencer) IsMining() bool                { return s.mining }
+func (s *testSequencer) StartMining(threads int) error { s.mining = true; return nil }
+func (s *testSequencer) StopMining()                   { s.mining = false }
+
+func TestWatchdog(t *testing.T) {
+	var (
+		txs = []*types.Transaction{
+			types.NewTransaction(0, common.Address{0x01}, new(big.Int), 21000, new(big.Int), nil),
+			types.NewTransaction(1, common.Address{0x01}, new(big.Int), 21000, new(big.Int), nil),
+			types.NewTransaction(2, common.Address{0x01}, new(big.Int), 21000, new(big.Int), nil),
+		}
+		ancestor = &types.Header{Number: big.NewInt(10)}
+		block1   = types.NewBlockWithHeader(&types.Header{Number: big.NewInt(11), ParentHash: ancestor.Hash()}).WithBody(txs[:2], nil)
+		block2   = types.NewBlockWithHeader(&types.Header{Number: big.NewInt(12), ParentHash: block1.Hash()}).WithBody(txs[2:], nil)
+		newHead  = &types.Header{Number: big.NewInt(11), ParentHash: ancestor.Hash(), Extra: []byte("new")}
+
+		chain     = &testChain{blocks: map[common.Hash]*types.Block{block1.Hash(): block1, block2.Hash(): block2}}
+		pool      = &testPool{reject: map[common.Hash]bool{txs[2].Hash(): true}}
+		sequencer = &testSequencer{mining: true}
+		s         = &Service{cfg: Config{Depth: 2, Halt: true}, chain: chain, pool: pool, sequencer: sequencer}
+		api       = &PrivateWatchdogAPI{s}
+	)
+	// A reorg below the threshold is left to the pool
+	s.handle(&core.ReorgEvent{
+		CommonAncestor: block1.Header(),
+		OldChain:       []*types.Header{block2.Header()},
+		NewChain:       []*types.Header{newHead},
+		Dropped:        []common.Hash{txs[2].Hash()},
+	})
+	if status := api.Status(); status.Reorgs != 0 || status.Halted || len(pool.added) != 0 {
+		t.Fatalf("shallow reorg handled: %+v", status)
+	}
+	if err := api.Resume(); err != errNotHalted {
+		t.Fatalf("resume without halt: have %v, want %v", err, errNotHalted)
+	}
+	// A deep reorg halts sequencing and re-queues the dropped transactions,
+	// skipping the re-included one
+	s.handle(&core.ReorgEvent{
+		CommonAncestor: ancestor,
+		OldChain:       []*types.Header{block2.Header(), block1.Header()},
+		NewChain:       []*types.Header{newHead},
+		Dropped:        []common.Hash{txs[2].Hash(), txs[0].Hash()},
+		Reincluded:     []common.Hash{txs[1].Hash()},
+	})
+	status := api.Status()
+	if !status.Halted || sequencer.mining {
+		t.Fatalf("sequencing not halted")
+	}
+	if status.Reorgs != 1 || status.LastReorg == nil {
+		t.Fatalf("deep reorg not reported: %+v", status)
+	}
+	if alert := status.LastReorg; alert.Depth != 2 || alert.CommonAncestor != 10 || alert.Dropped != 2 || alert.Requeued != 1 || alert.Rejected != 1 {
+		t.Errorf("alert mismatch: %+v", alert)
+	}
+	if len(pool.added) != 1 || pool.added[0].Hash() != txs[0].Hash() {
+		t.Errorf("re-queued transactions mismatch: %v", pool.added)
+	}
+	// Sequencing is restarted once the operator confirms
+	if err := api.Resume(); err != nil {
+		t.Fatalf("failed to resume: %v", err)
+	}
+	if !sequencer.mining || api.Status().Halted {
+		t.Fatalf("sequencing not resumed")
+	}
+}